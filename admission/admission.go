@@ -0,0 +1,159 @@
+// Package admission converts the v1 AdmissionReviews sent to admission webhooks into IWorkloads, and builds their allowed, denied and patched responses.
+// The webhooks evaluate the workloads with the same workload abstraction as the cluster scans
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	AdmissionReviewKind = "AdmissionReview"
+)
+
+// statusReasons are the reasons of the Status of the denied reviews per HTTP status code
+var statusReasons = map[int32]metav1.StatusReason{
+	http.StatusBadRequest:            metav1.StatusReasonBadRequest,
+	http.StatusUnauthorized:          metav1.StatusReasonUnauthorized,
+	http.StatusForbidden:             metav1.StatusReasonForbidden,
+	http.StatusNotFound:              metav1.StatusReasonNotFound,
+	http.StatusMethodNotAllowed:      metav1.StatusReasonMethodNotAllowed,
+	http.StatusNotAcceptable:         metav1.StatusReasonNotAcceptable,
+	http.StatusConflict:              metav1.StatusReasonConflict,
+	http.StatusGone:                  metav1.StatusReasonGone,
+	http.StatusRequestEntityTooLarge: metav1.StatusReasonRequestEntityTooLarge,
+	http.StatusUnsupportedMediaType:  metav1.StatusReasonUnsupportedMediaType,
+	http.StatusUnprocessableEntity:   metav1.StatusReasonInvalid,
+	http.StatusTooManyRequests:       metav1.StatusReasonTooManyRequests,
+	http.StatusInternalServerError:   metav1.StatusReasonInternalError,
+	http.StatusServiceUnavailable:    metav1.StatusReasonServiceUnavailable,
+	http.StatusGatewayTimeout:        metav1.StatusReasonTimeout,
+}
+
+// Request is the request of an AdmissionReview with its objects converted into workloads. The raw objects of the AdmissionRequest are kept as sent,
+// Patched diffs the mutated object against the raw object so the workloads can be mutated in place
+type Request struct {
+	*admissionv1.AdmissionRequest
+	// Object is the object of the request, nil for DELETE requests
+	Object workloadinterface.IWorkload
+	// OldObject is the existing object of UPDATE and DELETE requests, nil for the other operations
+	OldObject workloadinterface.IWorkload
+}
+
+// Decode decodes the body of an admission webhook request, an AdmissionReview of admission.k8s.io/v1, and converts its objects. See ParseRequest
+func Decode(body []byte) (*Request, error) {
+	review := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil {
+		return nil, fmt.Errorf("failed to decode the AdmissionReview: %w", err)
+	}
+	return ParseRequest(review)
+}
+
+// ParseRequest converts the objects of the request of the AdmissionReview into workloads. Only the admission.k8s.io/v1 reviews are supported
+func ParseRequest(review *admissionv1.AdmissionReview) (*Request, error) {
+	if gvk := review.GroupVersionKind(); gvk != admissionv1.SchemeGroupVersion.WithKind(AdmissionReviewKind) {
+		return nil, fmt.Errorf("unsupported AdmissionReview apiVersion '%s' and kind '%s', expected '%s' and '%s'", review.APIVersion, review.Kind, admissionv1.SchemeGroupVersion.String(), AdmissionReviewKind)
+	}
+	if review.Request == nil {
+		return nil, fmt.Errorf("the AdmissionReview has no request")
+	}
+	request := &Request{AdmissionRequest: review.Request}
+	var err error
+	if request.Object, err = rawToWorkload(review.Request, review.Request.Object.Raw); err != nil {
+		return nil, fmt.Errorf("failed to decode the object of the request '%s': %w", review.Request.UID, err)
+	}
+	if request.OldObject, err = rawToWorkload(review.Request, review.Request.OldObject.Raw); err != nil {
+		return nil, fmt.Errorf("failed to decode the old object of the request '%s': %w", review.Request.UID, err)
+	}
+	return request, nil
+}
+
+// Allowed returns the review allowing the request
+func Allowed(request *Request) *admissionv1.AdmissionReview {
+	return newReview(request, &admissionv1.AdmissionResponse{Allowed: true})
+}
+
+// Denied returns the review denying the request with the message, the HTTP status code is 403 (Forbidden) if not set.
+// The reason of the status is the metav1.StatusReason of the code, empty if there is none
+func Denied(request *Request, code int32, message string) *admissionv1.AdmissionReview {
+	if code == 0 {
+		code = http.StatusForbidden
+	}
+	return newReview(request, &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result:  &metav1.Status{Status: metav1.StatusFailure, Code: code, Message: message, Reason: statusReasons[code]},
+	})
+}
+
+// Patched returns the review allowing the request with the JSONPatch from the object sent in the request (the raw object of the AdmissionRequest)
+// to the mutated object, which may be the Object of the request mutated in place. The review has no patch if the object was not mutated
+func Patched(request *Request, mutated workloadinterface.IWorkload) (*admissionv1.AdmissionReview, error) {
+	if request.Object == nil {
+		return nil, fmt.Errorf("the %s request '%s' has no object to patch", request.Operation, request.UID)
+	}
+	original := request.Object
+	if len(request.AdmissionRequest.Object.Raw) > 0 {
+		var err error
+		if original, err = rawToWorkload(request.AdmissionRequest, request.AdmissionRequest.Object.Raw); err != nil {
+			return nil, fmt.Errorf("failed to decode the object of the request '%s': %w", request.UID, err)
+		}
+	}
+	patch, err := CreateJSONPatch(original.GetObject(), mutated.GetObject())
+	if err != nil {
+		return nil, err
+	}
+	review := Allowed(request)
+	if len(patch) == 0 {
+		return review, nil
+	}
+	if review.Response.Patch, err = json.Marshal(patch); err != nil {
+		return nil, fmt.Errorf("failed to encode the JSONPatch: %w", err)
+	}
+	patchType := admissionv1.PatchTypeJSONPatch
+	review.Response.PatchType = &patchType
+	return review, nil
+}
+
+// Encode encodes the review, the body of the admission webhook response
+func Encode(review *admissionv1.AdmissionReview) ([]byte, error) {
+	body, err := json.Marshal(review)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the AdmissionReview: %w", err)
+	}
+	return body, nil
+}
+
+func newReview(request *Request, response *admissionv1.AdmissionResponse) *admissionv1.AdmissionReview {
+	response.UID = request.UID
+	return &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: AdmissionReviewKind},
+		Response: response,
+	}
+}
+
+// rawToWorkload converts the raw object into a workload, nil if empty. The apiVersion and kind of the request are set if missing
+func rawToWorkload(request *admissionv1.AdmissionRequest, raw []byte) (workloadinterface.IWorkload, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	workload, err := workloadinterface.NewWorkload(raw)
+	if err != nil {
+		return nil, err
+	}
+	if workload.GetApiVersion() == "" {
+		gvk := metav1.GroupVersion{Group: request.Kind.Group, Version: request.Kind.Version}
+		workload.SetApiVersion(gvk.String())
+	}
+	if workload.GetKind() == "" {
+		workload.SetKind(request.Kind.Kind)
+	}
+	if workload.GetNamespace() == "" && request.Namespace != "" {
+		workload.SetNamespace(request.Namespace)
+	}
+	return workload, nil
+}
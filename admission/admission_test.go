@@ -0,0 +1,113 @@
+package admission
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const updateReview = `{
+	"apiVersion": "admission.k8s.io/v1",
+	"kind": "AdmissionReview",
+	"request": {
+		"uid": "705ab4f5-6393-11e8-b7cc-42010a800002",
+		"kind": {"group": "apps", "version": "v1", "kind": "Deployment"},
+		"resource": {"group": "apps", "version": "v1", "resource": "deployments"},
+		"namespace": "default",
+		"operation": "UPDATE",
+		"userInfo": {"username": "admin"},
+		"object": {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "nginx", "namespace": "default"}, "spec": {"replicas": 2}},
+		"oldObject": {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "nginx", "namespace": "default"}, "spec": {"replicas": 1}}
+	}
+}`
+
+func TestDecode(t *testing.T) {
+	request, err := Decode([]byte(updateReview))
+	require.NoError(t, err)
+	assert.Equal(t, admissionv1.Update, request.Operation)
+	assert.Equal(t, "admin", request.UserInfo.Username)
+	require.NotNil(t, request.Object)
+	assert.Equal(t, "apps/v1/default/Deployment/nginx", request.Object.GetID())
+	replicas, _ := workloadinterface.InspectMap(request.Object.GetObject(), "spec", "replicas")
+	assert.Equal(t, float64(2), replicas)
+	require.NotNil(t, request.OldObject)
+	replicas, _ = workloadinterface.InspectMap(request.OldObject.GetObject(), "spec", "replicas")
+	assert.Equal(t, float64(1), replicas)
+
+	// the objects of CREATE requests may have no namespace, DELETE requests have no object
+	request, err = Decode([]byte(`{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview", "request": {"uid": "1", "kind": {"version": "v1", "kind": "Pod"}, "namespace": "default", "operation": "DELETE",
+		"oldObject": {"metadata": {"name": "nginx"}}}}`))
+	require.NoError(t, err)
+	assert.Nil(t, request.Object)
+	require.NotNil(t, request.OldObject)
+	assert.Equal(t, "/v1/default/Pod/nginx", request.OldObject.GetID())
+
+	_, err = Decode([]byte(`{"apiVersion": "admission.k8s.io/v1beta1", "kind": "AdmissionReview", "request": {"uid": "1"}}`))
+	assert.Error(t, err)
+	_, err = Decode([]byte(`{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview"}`))
+	assert.Error(t, err)
+	_, err = Decode([]byte(`{`))
+	assert.Error(t, err)
+}
+
+func TestResponses(t *testing.T) {
+	request, err := Decode([]byte(updateReview))
+	require.NoError(t, err)
+
+	review := Allowed(request)
+	assert.Equal(t, "admission.k8s.io/v1", review.APIVersion)
+	assert.Equal(t, AdmissionReviewKind, review.Kind)
+	assert.Equal(t, request.UID, review.Response.UID)
+	assert.True(t, review.Response.Allowed)
+
+	review = Denied(request, 0, "privileged containers are not allowed")
+	assert.False(t, review.Response.Allowed)
+	assert.Equal(t, int32(http.StatusForbidden), review.Response.Result.Code)
+	assert.Equal(t, "privileged containers are not allowed", review.Response.Result.Message)
+	assert.Equal(t, metav1.StatusReasonForbidden, review.Response.Result.Reason)
+
+	review = Denied(request, http.StatusUnprocessableEntity, "invalid")
+	assert.Equal(t, metav1.StatusReasonInvalid, review.Response.Result.Reason)
+	review = Denied(request, http.StatusTeapot, "teapot")
+	assert.Empty(t, review.Response.Result.Reason)
+
+	// not mutated
+	review, err = Patched(request, request.Object)
+	require.NoError(t, err)
+	assert.True(t, review.Response.Allowed)
+	assert.Nil(t, review.Response.PatchType)
+	assert.Empty(t, review.Response.Patch)
+
+	objectJSON, err := json.Marshal(request.Object.GetObject())
+	require.NoError(t, err)
+	mutated, err := workloadinterface.NewWorkload(objectJSON)
+	require.NoError(t, err)
+	mutated.SetLabel("scanned", "true")
+	review, err = Patched(request, mutated)
+	require.NoError(t, err)
+	require.NotNil(t, review.Response.PatchType)
+	assert.Equal(t, admissionv1.PatchTypeJSONPatch, *review.Response.PatchType)
+	assert.JSONEq(t, `[{"op":"add","path":"/metadata/labels","value":{"scanned":"true"}}]`, string(review.Response.Patch))
+
+	body, err := Encode(review)
+	require.NoError(t, err)
+	decoded := &admissionv1.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(body, decoded))
+	assert.Equal(t, review.Response.Patch, decoded.Response.Patch)
+
+	// mutated in place
+	request.Object.SetLabel("scanned", "true")
+	review, err = Patched(request, request.Object)
+	require.NoError(t, err)
+	require.NotNil(t, review.Response.PatchType)
+	assert.JSONEq(t, `[{"op":"add","path":"/metadata/labels","value":{"scanned":"true"}}]`, string(review.Response.Patch))
+
+	_, err = Patched(&Request{AdmissionRequest: &admissionv1.AdmissionRequest{Operation: admissionv1.Delete}}, mutated)
+	assert.Error(t, err)
+}
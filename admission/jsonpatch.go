@@ -0,0 +1,130 @@
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	PatchOperationAdd     = "add"
+	PatchOperationRemove  = "remove"
+	PatchOperationReplace = "replace"
+)
+
+// PatchOperation is an operation of a JSONPatch (RFC 6902)
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// MarshalJSON encodes the value of the add and replace operations even if it is null
+func (operation PatchOperation) MarshalJSON() ([]byte, error) {
+	if operation.Op == PatchOperationRemove {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{Op: operation.Op, Path: operation.Path})
+	}
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}{Op: operation.Op, Path: operation.Path, Value: operation.Value})
+}
+
+// CreateJSONPatch returns the JSONPatch transforming the original object into the mutated object, empty if they are equal.
+// The objects are compared as JSON: the fields are added, removed or replaced, the lists are patched by index
+func CreateJSONPatch(original, mutated map[string]interface{}) ([]PatchOperation, error) {
+	// the mutated objects may hold values of any type, e.g. int or []string, compare them as decoded from JSON
+	normalizedOriginal, err := normalizeJSON(original)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the original object: %w", err)
+	}
+	normalizedMutated, err := normalizeJSON(mutated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the mutated object: %w", err)
+	}
+	return diffJSON("", normalizedOriginal, normalizedMutated, []PatchOperation{}), nil
+}
+
+func diffJSON(path string, original, mutated interface{}, operations []PatchOperation) []PatchOperation {
+	switch originalValue := original.(type) {
+	case map[string]interface{}:
+		if mutatedValue, ok := mutated.(map[string]interface{}); ok {
+			return diffJSONObjects(path, originalValue, mutatedValue, operations)
+		}
+	case []interface{}:
+		if mutatedValue, ok := mutated.([]interface{}); ok {
+			return diffJSONArrays(path, originalValue, mutatedValue, operations)
+		}
+	}
+	if reflect.DeepEqual(original, mutated) {
+		return operations
+	}
+	return append(operations, PatchOperation{Op: PatchOperationReplace, Path: path, Value: mutated})
+}
+
+func diffJSONObjects(path string, original, mutated map[string]interface{}, operations []PatchOperation) []PatchOperation {
+	for _, key := range sortedKeys(original) {
+		if mutatedValue, ok := mutated[key]; ok {
+			operations = diffJSON(path+"/"+escapeJSONPointer(key), original[key], mutatedValue, operations)
+		} else {
+			operations = append(operations, PatchOperation{Op: PatchOperationRemove, Path: path + "/" + escapeJSONPointer(key)})
+		}
+	}
+	for _, key := range sortedKeys(mutated) {
+		if _, ok := original[key]; !ok {
+			operations = append(operations, PatchOperation{Op: PatchOperationAdd, Path: path + "/" + escapeJSONPointer(key), Value: mutated[key]})
+		}
+	}
+	return operations
+}
+
+func diffJSONArrays(path string, original, mutated []interface{}, operations []PatchOperation) []PatchOperation {
+	common := len(original)
+	if len(mutated) < common {
+		common = len(mutated)
+	}
+	for i := 0; i < common; i++ {
+		operations = diffJSON(path+"/"+strconv.Itoa(i), original[i], mutated[i], operations)
+	}
+	for i := common; i < len(mutated); i++ {
+		operations = append(operations, PatchOperation{Op: PatchOperationAdd, Path: path + "/" + strconv.Itoa(i), Value: mutated[i]})
+	}
+	// the elements are removed from the last one, so the indexes of the remaining elements do not change
+	for i := len(original) - 1; i >= common; i-- {
+		operations = append(operations, PatchOperation{Op: PatchOperationRemove, Path: path + "/" + strconv.Itoa(i)})
+	}
+	return operations
+}
+
+// escapeJSONPointer escapes the reference token of a JSON pointer (RFC 6901)
+func escapeJSONPointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func normalizeJSON(object map[string]interface{}) (interface{}, error) {
+	b, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(b, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
@@ -0,0 +1,76 @@
+package admission
+
+import (
+	"encoding/json"
+	"testing"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateJSONPatch(t *testing.T) {
+	original := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":        "nginx",
+			"labels":      map[string]interface{}{"app": "nginx", "team/owner": "web"},
+			"annotations": map[string]interface{}{"removed": "true"},
+		},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "nginx", "image": "nginx:1.25"},
+				map[string]interface{}{"name": "sidecar", "image": "busybox"},
+			},
+			"replicas": float64(1),
+		},
+	}
+	mutated := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":   "nginx",
+			"labels": map[string]interface{}{"app": "nginx", "team/owner": "platform", "scanned~by": "kubescape"},
+		},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{"name": "nginx", "image": "nginx:1.26"},
+			},
+			// not decoded from JSON
+			"replicas":    3,
+			"tolerations": []string{"infra"},
+		},
+	}
+
+	patch, err := CreateJSONPatch(original, mutated)
+	require.NoError(t, err)
+	assert.Equal(t, []PatchOperation{
+		{Op: PatchOperationRemove, Path: "/metadata/annotations"},
+		{Op: PatchOperationReplace, Path: "/metadata/labels/team~1owner", Value: "platform"},
+		{Op: PatchOperationAdd, Path: "/metadata/labels/scanned~0by", Value: "kubescape"},
+		{Op: PatchOperationReplace, Path: "/spec/containers/0/image", Value: "nginx:1.26"},
+		{Op: PatchOperationRemove, Path: "/spec/containers/1"},
+		{Op: PatchOperationReplace, Path: "/spec/replicas", Value: float64(3)},
+		{Op: PatchOperationAdd, Path: "/spec/tolerations", Value: []interface{}{"infra"}},
+	}, patch)
+
+	// the patch transforms the original object into the mutated object
+	patchJSON, err := json.Marshal(patch)
+	require.NoError(t, err)
+	decodedPatch, err := jsonpatch.DecodePatch(patchJSON)
+	require.NoError(t, err)
+	originalJSON, err := json.Marshal(original)
+	require.NoError(t, err)
+	patchedJSON, err := decodedPatch.Apply(originalJSON)
+	require.NoError(t, err)
+	mutatedJSON, err := json.Marshal(mutated)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(mutatedJSON), string(patchedJSON))
+
+	patch, err = CreateJSONPatch(original, original)
+	require.NoError(t, err)
+	assert.Empty(t, patch)
+}
+
+func TestPatchOperationMarshalJSON(t *testing.T) {
+	b, err := json.Marshal([]PatchOperation{{Op: PatchOperationAdd, Path: "/spec/nodeName"}, {Op: PatchOperationRemove, Path: "/spec/hostname", Value: "ignored"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"op":"add","path":"/spec/nodeName","value":null},{"op":"remove","path":"/spec/hostname"}]`, string(b))
+}
@@ -111,7 +111,9 @@ func (ApiServerInfo *ApiServerInfo) SetProvider(provider string) {
 func (apiServerInfo *ApiServerInfo) SetApiServerVersion(version *version.Info) {
 	apiServerInfo.Data = version
 	apiServerInfo.SetName(apiServerInfoVersionName)
-	// TODO: Parse the gitVersion and set the provider
+	if version != nil {
+		apiServerInfo.SetProvider(string(k8sinterface.DetectDistribution(version.GitVersion, nil)))
+	}
 }
 
 func NewApiServerInfo() *ApiServerInfo {
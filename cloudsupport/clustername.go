@@ -0,0 +1,121 @@
+package cloudsupport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterNameStrategy is the strategy used to resolve the cluster name
+type ClusterNameStrategy string
+
+const (
+	ClusterNameStrategyOverride      ClusterNameStrategy = "override"
+	ClusterNameStrategyCloudProvider ClusterNameStrategy = "cloudProvider"
+	ClusterNameStrategyKubeSystemUID ClusterNameStrategy = "kubeSystemUID"
+	ClusterNameStrategyKubeContext   ClusterNameStrategy = "kubeContext"
+)
+
+const kubeSystemNamespace = "kube-system"
+
+// describeCloudCluster returns the cloud provider description of the cluster, replaced in tests
+var describeCloudCluster = GetDescriptiveInfoFromCloudProvider
+
+// ClusterName is the resolved cluster name and the strategy that resolved it
+type ClusterName struct {
+	Name     string              `json:"name"`
+	Strategy ClusterNameStrategy `json:"strategy"`
+}
+
+// ClusterNameOptions configures GetClusterName
+type ClusterNameOptions struct {
+	// Override is the explicit cluster name. When empty, the KS_KUBE_CLUSTER environment variable is used
+	Override string
+	// DisableCloudProvider skips describing the cluster in the cloud provider (AKS/EKS/GKE)
+	DisableCloudProvider bool
+}
+
+type clusterNameStrategyFunc func(ctx context.Context, kapi *k8sinterface.KubernetesApi, opts *ClusterNameOptions) (string, error)
+
+type clusterNameStrategy struct {
+	strategy ClusterNameStrategy
+	resolve  clusterNameStrategyFunc
+}
+
+// clusterNameStrategies is the ordered strategy chain, the first strategy that returns a name wins
+var clusterNameStrategies = []clusterNameStrategy{
+	{strategy: ClusterNameStrategyOverride, resolve: clusterNameFromOverride},
+	{strategy: ClusterNameStrategyCloudProvider, resolve: clusterNameFromCloudProvider},
+	{strategy: ClusterNameStrategyKubeSystemUID, resolve: clusterNameFromKubeSystemUID},
+	{strategy: ClusterNameStrategyKubeContext, resolve: clusterNameFromKubeContext},
+}
+
+// GetClusterName resolves the cluster name using the following strategies, in order:
+// the explicit override, the cloud provider cluster description, the kube-system namespace UID and the kubeconfig context name.
+// All consumers should use this function so the same cluster is reported under the same name
+func GetClusterName(ctx context.Context, kapi *k8sinterface.KubernetesApi, opts *ClusterNameOptions) (*ClusterName, error) {
+	if opts == nil {
+		opts = &ClusterNameOptions{}
+	}
+	var errs []string
+	for _, s := range clusterNameStrategies {
+		name, err := s.resolve(ctx, kapi, opts)
+		if err != nil {
+			kapi.GetLogger().Debug("failed to resolve cluster name", "strategy", string(s.strategy), "error", err.Error())
+			errs = append(errs, fmt.Sprintf("%s: %s", s.strategy, err.Error()))
+			continue
+		}
+		if name != "" {
+			return &ClusterName{Name: name, Strategy: s.strategy}, nil
+		}
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("failed to resolve cluster name")
+	}
+	return nil, fmt.Errorf("failed to resolve cluster name: %s", strings.Join(errs, "; "))
+}
+
+func clusterNameFromOverride(_ context.Context, _ *k8sinterface.KubernetesApi, opts *ClusterNameOptions) (string, error) {
+	if opts.Override != "" {
+		return opts.Override, nil
+	}
+	return os.Getenv(KS_KUBE_CLUSTER_ENV_VAR), nil
+}
+
+func clusterNameFromCloudProvider(_ context.Context, _ *k8sinterface.KubernetesApi, opts *ClusterNameOptions) (string, error) {
+	if opts.DisableCloudProvider {
+		return "", nil
+	}
+	contextName := k8sinterface.GetContextName()
+	cloudProvider := GetCloudProvider(contextName)
+	if cloudProvider == "" {
+		return "", nil
+	}
+	description, err := describeCloudCluster(contextName, cloudProvider)
+	if err != nil {
+		return "", err
+	}
+	if description == nil {
+		return "", nil
+	}
+	return description.GetName(), nil
+}
+
+func clusterNameFromKubeSystemUID(ctx context.Context, kapi *k8sinterface.KubernetesApi, _ *ClusterNameOptions) (string, error) {
+	if kapi == nil || kapi.KubernetesClient == nil {
+		return "", nil
+	}
+	namespace, err := kapi.KubernetesClient.CoreV1().Namespaces().Get(ctx, kubeSystemNamespace, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	return string(namespace.GetUID()), nil
+}
+
+func clusterNameFromKubeContext(_ context.Context, _ *k8sinterface.KubernetesApi, _ *ClusterNameOptions) (string, error) {
+	return k8sinterface.GetContextName(), nil
+}
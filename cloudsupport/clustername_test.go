@@ -0,0 +1,103 @@
+package cloudsupport
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	cloudsupportv1 "github.com/kubescape/k8s-interface/cloudsupport/v1"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetClusterName(t *testing.T) {
+	kubeSystem := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: types.UID("4e8c7b8e-0d3a-4f3b-9a3e-1c1e2f3a4b5c")}}
+	kapi := &k8sinterface.KubernetesApi{KubernetesClient: kubernetesfake.NewSimpleClientset(kubeSystem), Context: context.Background()}
+
+	defer func(f func(string, string) (workloadinterface.IMetadata, error)) { describeCloudCluster = f }(describeCloudCluster)
+	describeCloudCluster = func(cluster, cloudProvider string) (workloadinterface.IMetadata, error) {
+		if cluster != "gke_project_region_prod" {
+			return nil, fmt.Errorf("not found")
+		}
+		description := &cloudsupportv1.CloudProviderDescribe{}
+		description.SetName("prod")
+		description.SetProvider(cloudProvider)
+		return description, nil
+	}
+	defer k8sinterface.SetClusterContextName("")
+
+	tests := []struct {
+		name         string
+		contextName  string
+		env          string
+		opts         *ClusterNameOptions
+		kapi         *k8sinterface.KubernetesApi
+		want         string
+		wantStrategy ClusterNameStrategy
+		wantErr      bool
+	}{
+		{
+			name:         "explicit override",
+			contextName:  "gke_project_region_prod",
+			opts:         &ClusterNameOptions{Override: "my-cluster"},
+			kapi:         kapi,
+			want:         "my-cluster",
+			wantStrategy: ClusterNameStrategyOverride,
+		},
+		{
+			name:         "override from env",
+			contextName:  "gke_project_region_prod",
+			env:          "env-cluster",
+			kapi:         kapi,
+			want:         "env-cluster",
+			wantStrategy: ClusterNameStrategyOverride,
+		},
+		{
+			name:         "cloud provider",
+			contextName:  "gke_project_region_prod",
+			kapi:         kapi,
+			want:         "prod",
+			wantStrategy: ClusterNameStrategyCloudProvider,
+		},
+		{
+			name:         "cloud provider disabled",
+			contextName:  "gke_project_region_prod",
+			opts:         &ClusterNameOptions{DisableCloudProvider: true},
+			kapi:         kapi,
+			want:         "4e8c7b8e-0d3a-4f3b-9a3e-1c1e2f3a4b5c",
+			wantStrategy: ClusterNameStrategyKubeSystemUID,
+		},
+		{
+			name:         "cloud provider failed",
+			contextName:  "gke_project_region_dev",
+			kapi:         kapi,
+			want:         "4e8c7b8e-0d3a-4f3b-9a3e-1c1e2f3a4b5c",
+			wantStrategy: ClusterNameStrategyKubeSystemUID,
+		},
+		{
+			name:         "kube context",
+			contextName:  "minikube",
+			kapi:         &k8sinterface.KubernetesApi{KubernetesClient: kubernetesfake.NewSimpleClientset()},
+			want:         "minikube",
+			wantStrategy: ClusterNameStrategyKubeContext,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(KS_KUBE_CLUSTER_ENV_VAR, tt.env)
+			k8sinterface.SetClusterContextName(tt.contextName)
+			got, err := GetClusterName(context.Background(), tt.kapi, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetClusterName() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got.Name)
+			assert.Equal(t, tt.wantStrategy, got.Strategy)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package cloudsupport
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/kubescape/k8s-interface/imageref"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const defaultServiceAccountName = "default"
+
+// RegistryCredentialsOptions configures the credential sources used by ResolveRegistryCredentials
+type RegistryCredentialsOptions struct {
+	// CloudVendorCredentials exchanges the cloud identity of the running component for registry credentials (ECR/ACR/GCR)
+	CloudVendorCredentials bool
+	// NodeDockerConfigPaths are docker config files on the local node to read credentials from, e.g. /var/lib/kubelet/config.json
+	NodeDockerConfigPaths []string
+}
+
+// RegistryKeychain holds registry credentials keyed by registry host (optionally followed by a repository path prefix)
+type RegistryKeychain struct {
+	auths map[string]types.AuthConfig
+}
+
+// NewRegistryKeychain returns an empty keychain
+func NewRegistryKeychain() *RegistryKeychain {
+	return &RegistryKeychain{auths: map[string]types.AuthConfig{}}
+}
+
+// Add adds credentials for a registry. The registry may be a URL (e.g. https://index.docker.io/v1/), a host, a host with a repository path prefix or a wildcard host (e.g. *.azurecr.io).
+// Credentials that were already added for the same registry are not overridden
+func (keychain *RegistryKeychain) Add(registry string, auth types.AuthConfig) {
+	key := normalizeRegistryKey(registry)
+	if key == "" {
+		return
+	}
+	if _, ok := keychain.auths[key]; ok {
+		return
+	}
+	completeAuthConfig(&auth, key)
+	keychain.auths[key] = auth
+}
+
+// Resolve returns the credentials to use for pulling the image. The most specific matching registry entry wins
+func (keychain *RegistryKeychain) Resolve(image string) (types.AuthConfig, bool) {
+	ref, err := imageref.ParseNormalized(image)
+	if err != nil {
+		return types.AuthConfig{}, false
+	}
+	name := ref.Name()
+
+	bestKey := ""
+	for key := range keychain.auths {
+		if registryKeyMatches(key, name) && len(key) > len(bestKey) {
+			bestKey = key
+		}
+	}
+	if bestKey == "" {
+		return types.AuthConfig{}, false
+	}
+	return keychain.auths[bestKey], true
+}
+
+// Registries returns the sorted list of registries the keychain has credentials for
+func (keychain *RegistryKeychain) Registries() []string {
+	registries := make([]string, 0, len(keychain.auths))
+	for key := range keychain.auths {
+		registries = append(registries, key)
+	}
+	sort.Strings(registries)
+	return registries
+}
+
+// Len returns the number of registries in the keychain
+func (keychain *RegistryKeychain) Len() int {
+	return len(keychain.auths)
+}
+
+// ResolveRegistryCredentials resolves the registry credentials usable for pulling the workload images, in the same order the kubelet does:
+// the pod imagePullSecrets, the service account imagePullSecrets, and then, if configured, the node docker config files and the cloud vendor credentials.
+// Secrets that cannot be read are skipped
+func ResolveRegistryCredentials(k8sAPI *k8sinterface.KubernetesApi, workload k8sinterface.IWorkload, namespace string, opts *RegistryCredentialsOptions) (*RegistryKeychain, error) {
+	if opts == nil {
+		opts = &RegistryCredentialsOptions{}
+	}
+	if namespace == "" {
+		namespace = workload.GetNamespace()
+	}
+	podSpec, err := workload.GetPodSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	keychain := NewRegistryKeychain()
+
+	secrets, _ := listPodImagePullSecrets(podSpec)
+	serviceAccountName := podSpec.ServiceAccountName
+	if serviceAccountName == "" {
+		serviceAccountName = defaultServiceAccountName
+	}
+	if serviceAccountSecrets, err := listServiceAccountImagePullSecrets(k8sAPI, namespace, serviceAccountName); err != nil {
+		k8sAPI.GetLogger().Debug("failed to list service account imagePullSecrets", "serviceAccount", serviceAccountName, "error", err.Error())
+	} else {
+		secrets = append(secrets, serviceAccountSecrets...)
+	}
+
+	for i := range secrets {
+		secret, err := k8sAPI.KubernetesClient.CoreV1().Secrets(namespace).Get(k8sAPI.Context, secrets[i], metav1.GetOptions{})
+		if err != nil {
+			k8sAPI.GetLogger().Warning("unable to get imagePullSecret", "secret name", secrets[i], "error", err.Error())
+			continue
+		}
+		auths, err := ParseDockerConfigSecret(secret)
+		if err != nil {
+			k8sAPI.GetLogger().Warning("failed to parse imagePullSecret", "secret name", secrets[i], "error", err.Error())
+			continue
+		}
+		for registry, auth := range auths {
+			keychain.Add(registry, auth)
+		}
+	}
+
+	for _, path := range opts.NodeDockerConfigPaths {
+		auths, err := readDockerConfigFile(path)
+		if err != nil {
+			k8sAPI.GetLogger().Debug("failed to read node docker config", "path", path, "error", err.Error())
+			continue
+		}
+		for registry, auth := range auths {
+			keychain.Add(registry, auth)
+		}
+	}
+
+	if opts.CloudVendorCredentials {
+		for image := range GetWorkloadsImages(workload) {
+			ref, err := imageref.ParseNormalized(image)
+			if err != nil {
+				continue
+			}
+			if _, ok := keychain.Resolve(image); ok {
+				continue
+			}
+			cloudVendorSecrets, err := GetCloudVendorRegistryCredentials(image)
+			if err != nil {
+				k8sAPI.GetLogger().Debug("failed to GetCloudVendorRegistryCredentials", "image", image, "error", err.Error())
+				continue
+			}
+			if auth, ok := cloudVendorSecrets[image]; ok {
+				keychain.Add(ref.Registry, auth)
+			}
+		}
+	}
+
+	return keychain, nil
+}
+
+// ParseDockerConfigSecret returns the credentials of all registries found in a kubernetes.io/dockerconfigjson or kubernetes.io/dockercfg secret, keyed by registry
+func ParseDockerConfigSecret(secret *corev1.Secret) (map[string]types.AuthConfig, error) {
+	switch secret.Type {
+	case corev1.SecretTypeDockerConfigJson:
+		return parseDockerConfigJSON(secret.Data[corev1.DockerConfigJsonKey])
+	case corev1.SecretTypeDockercfg:
+		auths := map[string]types.AuthConfig{}
+		if err := json.Unmarshal(secret.Data[corev1.DockerConfigKey], &auths); err != nil {
+			return nil, fmt.Errorf("failed to parse secret '%s': %w", secret.GetName(), err)
+		}
+		return auths, nil
+	}
+	return nil, fmt.Errorf("secret '%s' of type '%s' is not a docker config secret", secret.GetName(), secret.Type)
+}
+
+func parseDockerConfigJSON(b []byte) (map[string]types.AuthConfig, error) {
+	dockerConfig := struct {
+		Auths map[string]types.AuthConfig `json:"auths"`
+	}{}
+	if err := json.Unmarshal(b, &dockerConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse docker config: %w", err)
+	}
+	return dockerConfig.Auths, nil
+}
+
+func readDockerConfigFile(path string) (map[string]types.AuthConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseDockerConfigJSON(b)
+}
+
+// completeAuthConfig fills the username/password from the encoded auth field (and vice versa)
+func completeAuthConfig(auth *types.AuthConfig, registry string) {
+	if auth.ServerAddress == "" {
+		auth.ServerAddress = registry
+	}
+	if (auth.Username == "" || auth.Password == "") && auth.Auth != "" {
+		if decoded, err := base64.StdEncoding.DecodeString(auth.Auth); err == nil {
+			if user, password, ok := strings.Cut(string(decoded), ":"); ok {
+				auth.Username, auth.Password = user, password
+			}
+		}
+	}
+	if auth.Auth == "" && auth.Username != "" {
+		auth.Auth = base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+	}
+}
+
+// normalizeRegistryKey converts a docker config registry key to host[/path], e.g. https://index.docker.io/v1/ -> docker.io
+func normalizeRegistryKey(registry string) string {
+	key := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(registry), "https://"), "http://")
+	key = strings.TrimSuffix(key, "/")
+	host, path, _ := strings.Cut(key, "/")
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		host = imageref.DefaultRegistry
+	}
+	// docker hub API versions are not repository paths
+	if path == "v1" || path == "v2" {
+		path = ""
+	}
+	if path == "" {
+		return host
+	}
+	return host + "/" + path
+}
+
+// registryKeyMatches returns true if the key (host[/path], host may start with a '*.' wildcard) matches the image name (host/repository)
+func registryKeyMatches(key, imageName string) bool {
+	keyHost, keyPath, _ := strings.Cut(key, "/")
+	imageHost, imagePath, _ := strings.Cut(imageName, "/")
+
+	if strings.HasPrefix(keyHost, "*.") {
+		if !strings.HasSuffix(imageHost, keyHost[1:]) {
+			return false
+		}
+	} else if keyHost != imageHost {
+		return false
+	}
+	return keyPath == "" || imagePath == keyPath || strings.HasPrefix(imagePath, keyPath+"/")
+}
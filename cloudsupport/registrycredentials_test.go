@@ -0,0 +1,115 @@
+package cloudsupport
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+const registryCredentialsPod = `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"app","namespace":"default"},"spec":{"imagePullSecrets":[{"name":"pull-secret"}],"containers":[{"name":"app","image":"quay.io/team/app:v1"},{"name":"nginx","image":"nginx"}]}}`
+
+func encodeAuth(user, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+}
+
+func TestResolveRegistryCredentials(t *testing.T) {
+	pullSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull-secret", Namespace: "default"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: []byte(`{"auths":{"quay.io":{"auth":"` + encodeAuth("quay-user", "quay-pass") + `"},"https://index.docker.io/v1/":{"username":"hub-user","password":"hub-pass"}}}`),
+		},
+	}
+	saSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sa-secret", Namespace: "default"},
+		Type:       corev1.SecretTypeDockercfg,
+		Data: map[string][]byte{
+			corev1.DockerConfigKey: []byte(`{"quay.io":{"auth":"` + encodeAuth("sa-user", "sa-pass") + `"},"*.azurecr.io":{"auth":"` + encodeAuth("acr-user", "acr-pass") + `"}}`),
+		},
+	}
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta:       metav1.ObjectMeta{Name: "default", Namespace: "default"},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "sa-secret"}, {Name: "missing"}},
+	}
+	k8sAPI := &k8sinterface.KubernetesApi{
+		KubernetesClient: kubernetesfake.NewSimpleClientset(pullSecret, saSecret, serviceAccount),
+		Context:          context.Background(),
+	}
+
+	nodeConfig := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, os.WriteFile(nodeConfig, []byte(`{"auths":{"ghcr.io/kubescape":{"auth":"`+encodeAuth("node-user", "node-pass")+`"}}}`), 0600))
+
+	workload, err := workloadinterface.NewWorkload([]byte(registryCredentialsPod))
+	assert.NoError(t, err)
+
+	keychain, err := ResolveRegistryCredentials(k8sAPI, workload, "", &RegistryCredentialsOptions{NodeDockerConfigPaths: []string{nodeConfig, "/does/not/exist"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"*.azurecr.io", "docker.io", "ghcr.io/kubescape", "quay.io"}, keychain.Registries())
+
+	// pod imagePullSecrets take precedence over the service account ones
+	auth, ok := keychain.Resolve("quay.io/team/app:v1")
+	assert.True(t, ok)
+	assert.Equal(t, "quay-user", auth.Username)
+	assert.Equal(t, "quay-pass", auth.Password)
+
+	auth, ok = keychain.Resolve("nginx")
+	assert.True(t, ok)
+	assert.Equal(t, "hub-user", auth.Username)
+	assert.Equal(t, encodeAuth("hub-user", "hub-pass"), auth.Auth)
+
+	auth, ok = keychain.Resolve("myregistry.azurecr.io/app")
+	assert.True(t, ok)
+	assert.Equal(t, "acr-user", auth.Username)
+
+	auth, ok = keychain.Resolve("ghcr.io/kubescape/kubevuln:v1")
+	assert.True(t, ok)
+	assert.Equal(t, "node-user", auth.Username)
+
+	_, ok = keychain.Resolve("ghcr.io/other/app")
+	assert.False(t, ok)
+}
+
+func TestRegistryKeychainResolve(t *testing.T) {
+	keychain := NewRegistryKeychain()
+	keychain.Add("registry.io", types.AuthConfig{Username: "registry"})
+	keychain.Add("registry.io/team", types.AuthConfig{Username: "team"})
+	keychain.Add("registry.io", types.AuthConfig{Username: "override"})
+	assert.Equal(t, 2, keychain.Len())
+
+	tests := []struct {
+		image string
+		want  string
+		found bool
+	}{
+		{image: "registry.io/app", want: "registry", found: true},
+		{image: "registry.io/team/app:v1", want: "team", found: true},
+		{image: "registry.io/teams/app", want: "registry", found: true},
+		{image: "other.io/team/app", found: false},
+		{image: "Invalid Image", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			auth, ok := keychain.Resolve(tt.image)
+			assert.Equal(t, tt.found, ok)
+			assert.Equal(t, tt.want, auth.Username)
+		})
+	}
+}
+
+func TestParseDockerConfigSecret(t *testing.T) {
+	_, err := ParseDockerConfigSecret(&corev1.Secret{Type: corev1.SecretTypeOpaque})
+	assert.Error(t, err)
+
+	_, err = ParseDockerConfigSecret(&corev1.Secret{Type: corev1.SecretTypeDockerConfigJson, Data: map[string][]byte{corev1.DockerConfigJsonKey: []byte("invalid")}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+// the types of the members of the Azure AD groups
+const (
+	GroupMemberTypeUser             = "user"
+	GroupMemberTypeServicePrincipal = "servicePrincipal"
+)
+
+const graphODataTypePrefix = "#microsoft.graph."
+
+var graphHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// IGroupMembersResolver resolves the members of the Azure AD groups, e.g. the groups of the AKS role bindings (see GetGroupIdsRoleBindings)
+type IGroupMembersResolver interface {
+	ExpandGroupMembers(ctx context.Context, groupIDs []string) ([]GroupMember, error)
+}
+
+// GroupMember is a user or a service principal of Azure AD groups
+type GroupMember struct {
+	// ID is the object ID of the member
+	ID string `json:"id"`
+	// Type is the type of the member, see the GroupMemberType constants
+	Type        string `json:"type"`
+	DisplayName string `json:"displayName,omitempty"`
+	// UserPrincipalName is set for the users
+	UserPrincipalName string `json:"userPrincipalName,omitempty"`
+	// AppID is set for the service principals
+	AppID string `json:"appId,omitempty"`
+	// GroupIDs are the IDs of the expanded groups the member belongs to, directly or through nested groups
+	GroupIDs []string `json:"groupIDs"`
+}
+
+type graphDirectoryObject struct {
+	ODataType         string `json:"@odata.type"`
+	ID                string `json:"id"`
+	DisplayName       string `json:"displayName"`
+	UserPrincipalName string `json:"userPrincipalName"`
+	AppID             string `json:"appId"`
+}
+
+type graphDirectoryObjectsPage struct {
+	Value    []graphDirectoryObject `json:"value"`
+	NextLink string                 `json:"@odata.nextLink"`
+}
+
+// ExpandGroupMembers returns the users and the service principals of the Azure AD groups, including the members of the nested groups, using the Microsoft Graph API.
+// A member of several groups is returned once. The Azure credential requires the GroupMember.Read.All permission of Microsoft Graph
+func (AKSSupport *AKSSupport) ExpandGroupMembers(ctx context.Context, groupIDs []string) (_ []GroupMember, err error) {
+	ctx, span := tracing.StartSpan(ctx, "aks.ExpandGroupMembers", tracing.AttributeCloudProvider.String(tracing.CloudProviderAzure))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	cred, err := AKSSupport.options.newAzureCredential()
+	if err != nil {
+		return nil, err
+	}
+	graphEndpoint := AKSSupport.options.environment.azureGraphEndpoint()
+	token, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{graphEndpoint + "/.default"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Microsoft Graph token: %w", err)
+	}
+	httpClient, err := AKSSupport.options.httpClient(graphHTTPClient)
+	if err != nil {
+		return nil, err
+	}
+	return expandGroupMembers(ctx, httpClient, graphEndpoint, token.Token, groupIDs)
+}
+
+func expandGroupMembers(ctx context.Context, httpClient *http.Client, graphEndpoint, accessToken string, groupIDs []string) ([]GroupMember, error) {
+	var members []GroupMember
+	memberIndex := map[string]int{}
+	for _, groupID := range groupIDs {
+		var objects []graphDirectoryObject
+		err := metrics.ObserveCall(metrics.SourceAzure, "graph.Groups.ListTransitiveMembers", isThrottlingError, func() error {
+			var err error
+			objects, err = listGroupTransitiveMembers(ctx, httpClient, graphEndpoint, accessToken, groupID)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the members of group '%s': %w", groupID, err)
+		}
+		for i := range objects {
+			memberType := strings.TrimPrefix(objects[i].ODataType, graphODataTypePrefix)
+			// the nested groups are expanded by the transitive listing, the other objects (e.g. devices) are not subjects
+			if memberType != GroupMemberTypeUser && memberType != GroupMemberTypeServicePrincipal {
+				continue
+			}
+			if index, ok := memberIndex[objects[i].ID]; ok {
+				members[index].GroupIDs = appendUnique(members[index].GroupIDs, groupID)
+				continue
+			}
+			memberIndex[objects[i].ID] = len(members)
+			members = append(members, GroupMember{
+				ID:                objects[i].ID,
+				Type:              memberType,
+				DisplayName:       objects[i].DisplayName,
+				UserPrincipalName: objects[i].UserPrincipalName,
+				AppID:             objects[i].AppID,
+				GroupIDs:          []string{groupID},
+			})
+		}
+	}
+	return members, nil
+}
+
+// listGroupTransitiveMembers lists the members of the group and of its nested groups, following the pages of the Graph API
+func listGroupTransitiveMembers(ctx context.Context, httpClient *http.Client, graphEndpoint, accessToken, groupID string) ([]graphDirectoryObject, error) {
+	var objects []graphDirectoryObject
+	nextLink := fmt.Sprintf("%s/v1.0/groups/%s/transitiveMembers?$select=id,displayName,userPrincipalName,appId", graphEndpoint, url.PathEscape(groupID))
+	for nextLink != "" {
+		page, err := getGraphDirectoryObjectsPage(ctx, httpClient, nextLink, accessToken)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, page.Value...)
+		nextLink = page.NextLink
+	}
+	return objects, nil
+}
+
+func getGraphDirectoryObjectsPage(ctx context.Context, httpClient *http.Client, pageURL, accessToken string) (*graphDirectoryObjectsPage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating HTTP request: %w", err)
+	}
+	req.Header.Add("Authorization", "Bearer "+accessToken)
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("calling Microsoft Graph: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, newHTTPResponseError("Microsoft Graph", resp, body)
+	}
+	page := &graphDirectoryObjectsPage{}
+	if err := json.Unmarshal(body, page); err != nil {
+		return nil, fmt.Errorf("unmarshalling the response: %w", err)
+	}
+	return page, nil
+}
+
+func appendUnique(values []string, value string) []string {
+	for i := range values {
+		if values[i] == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
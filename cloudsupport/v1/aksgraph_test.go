@@ -0,0 +1,48 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandGroupMembers(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch {
+		case r.URL.Path == "/v1.0/groups/admins/transitiveMembers" && r.URL.Query().Get("page") == "":
+			// the first page of the admins, a nested group and its member
+			fmt.Fprintf(w, `{"value": [
+				{"@odata.type": "#microsoft.graph.user", "id": "alice", "displayName": "Alice", "userPrincipalName": "alice@example.com"},
+				{"@odata.type": "#microsoft.graph.group", "id": "operators", "displayName": "Operators"},
+				{"@odata.type": "#microsoft.graph.user", "id": "bob", "userPrincipalName": "bob@example.com"}
+			], "@odata.nextLink": "%s/v1.0/groups/admins/transitiveMembers?page=2"}`, server.URL)
+		case r.URL.Path == "/v1.0/groups/admins/transitiveMembers":
+			fmt.Fprint(w, `{"value": [{"@odata.type": "#microsoft.graph.servicePrincipal", "id": "ci", "displayName": "CI", "appId": "app-id"},
+				{"@odata.type": "#microsoft.graph.device", "id": "laptop"}]}`)
+		case r.URL.Path == "/v1.0/groups/viewers/transitiveMembers":
+			fmt.Fprint(w, `{"value": [{"@odata.type": "#microsoft.graph.user", "id": "bob", "userPrincipalName": "bob@example.com"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": {"code": "Request_ResourceNotFound"}}`)
+		}
+	}))
+	defer server.Close()
+
+	members, err := expandGroupMembers(context.Background(), server.Client(), server.URL, "token", []string{"admins", "viewers"})
+	require.NoError(t, err)
+	assert.Equal(t, []GroupMember{
+		{ID: "alice", Type: GroupMemberTypeUser, DisplayName: "Alice", UserPrincipalName: "alice@example.com", GroupIDs: []string{"admins"}},
+		{ID: "bob", Type: GroupMemberTypeUser, UserPrincipalName: "bob@example.com", GroupIDs: []string{"admins", "viewers"}},
+		{ID: "ci", Type: GroupMemberTypeServicePrincipal, DisplayName: "CI", AppID: "app-id", GroupIDs: []string{"admins"}},
+	}, members)
+
+	_, err = expandGroupMembers(context.Background(), server.Client(), server.URL, "token", []string{"unknown"})
+	assert.ErrorContains(t, err, "404")
+}
@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	armauthorizationv2 "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+const managementGroupScopePrefix = "/providers/Microsoft.Management/managementGroups/"
+
+// SubscriptionScope returns the scope of the subscription, '/subscriptions/{subscriptionId}'
+func SubscriptionScope(subscriptionId string) string {
+	return "/subscriptions/" + subscriptionId
+}
+
+// ManagementGroupScope returns the scope of the management group, '/providers/Microsoft.Management/managementGroups/{managementGroupId}'
+func ManagementGroupScope(managementGroupId string) string {
+	return managementGroupScopePrefix + managementGroupId
+}
+
+// ListAllRolesForScopes lists the role assignments that apply to the scopes (subscriptions, management groups, resource groups or resources, see ListAllRolesForScope).
+// The assignments inherited from a common parent scope (e.g. a management group) are listed in each of its child scopes, they are returned once
+func (AKSSupport *AKSSupport) ListAllRolesForScopes(scopes []string) (_ *ListRoleAssignment, err error) {
+	ctx, span := tracing.StartSpan(AKSSupport.GetContext(), "aks.ListRoleAssignmentsForScopes", tracing.AttributeCloudProvider.String(tracing.CloudProviderAzure))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	cred, err := AKSSupport.options.newAzureCredential()
+	if err != nil {
+		return nil, err
+	}
+
+	// the subscription of the client is not used to list the role assignments of a scope, the scopes may belong to other subscriptions or to no subscription
+	client, err := armauthorizationv2.NewRoleAssignmentsClient("", cred, AKSSupport.options.armClientOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	var roleList []*armauthorizationv2.RoleAssignment
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		scopeRoleList, err := listRoleAssignmentsForScope(ctx, client, scope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the role assignments of scope '%s': %v", scope, err)
+		}
+		roleList = appendUniqueRoleAssignments(roleList, seen, scopeRoleList)
+	}
+
+	return &ListRoleAssignment{RoleAssignments: roleList}, nil
+}
+
+// ListAllRolesForSubscriptions lists the role assignments that apply to the subscriptions, including the assignments inherited from the management groups, see ListAllRolesForScopes
+func (AKSSupport *AKSSupport) ListAllRolesForSubscriptions(subscriptionIds []string) (*ListRoleAssignment, error) {
+	scopes := make([]string, 0, len(subscriptionIds))
+	for _, subscriptionId := range subscriptionIds {
+		scopes = append(scopes, SubscriptionScope(subscriptionId))
+	}
+	return AKSSupport.ListAllRolesForScopes(scopes)
+}
+
+func listRoleAssignmentsForScope(ctx context.Context, client *armauthorizationv2.RoleAssignmentsClient, scope string) ([]*armauthorizationv2.RoleAssignment, error) {
+	pager := client.NewListForScopePager(scope, &armauthorizationv2.RoleAssignmentsClientListForScopeOptions{Filter: nil,
+		TenantID:  nil,
+		SkipToken: nil,
+	})
+
+	var roleList []*armauthorizationv2.RoleAssignment
+
+	for pager.More() {
+		nextResult, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to advance page: %v", err)
+		}
+
+		roleList = append(roleList, nextResult.Value...)
+	}
+	return roleList, nil
+}
+
+// appendUniqueRoleAssignments appends the assignments whose ID is not in seen, keeping their order. The assignments without an ID are always appended
+func appendUniqueRoleAssignments(roleList []*armauthorizationv2.RoleAssignment, seen map[string]bool, assignments []*armauthorizationv2.RoleAssignment) []*armauthorizationv2.RoleAssignment {
+	for _, assignment := range assignments {
+		if assignment == nil {
+			continue
+		}
+		if assignment.ID != nil {
+			if seen[*assignment.ID] {
+				continue
+			}
+			seen[*assignment.ID] = true
+		}
+		roleList = append(roleList, assignment)
+	}
+	return roleList
+}
@@ -0,0 +1,35 @@
+package v1
+
+import (
+	"testing"
+
+	armauthorizationv2 "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleAssignmentScopes(t *testing.T) {
+	assert.Equal(t, "/subscriptions/1234", SubscriptionScope("1234"))
+	assert.Equal(t, "/providers/Microsoft.Management/managementGroups/root", ManagementGroupScope("root"))
+}
+
+func TestAppendUniqueRoleAssignments(t *testing.T) {
+	newAssignment := func(id string) *armauthorizationv2.RoleAssignment {
+		return &armauthorizationv2.RoleAssignment{ID: &id}
+	}
+	seen := map[string]bool{}
+
+	// the assignments of the first subscription, and an assignment inherited from the management group
+	roleList := appendUniqueRoleAssignments(nil, seen, []*armauthorizationv2.RoleAssignment{newAssignment("sub-a"), newAssignment("mg")})
+	// the inherited assignment is listed again in the second subscription
+	roleList = appendUniqueRoleAssignments(roleList, seen, []*armauthorizationv2.RoleAssignment{newAssignment("mg"), nil, newAssignment("sub-b"), {}})
+
+	ids := []string{}
+	for _, assignment := range roleList {
+		if assignment.ID == nil {
+			ids = append(ids, "")
+			continue
+		}
+		ids = append(ids, *assignment.ID)
+	}
+	assert.Equal(t, []string{"sub-a", "mg", "sub-b", ""}, ids)
+}
@@ -6,11 +6,14 @@ import (
 	"os"
 
 	// "github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2019-04-30/containerservice"
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization"
 	armauthorizationv2 "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
 	armcontainerservice "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
 	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/logging"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -28,7 +31,55 @@ type IAKSSupport interface {
 	GetGroupIdsRoleBindings(kapi *k8sinterface.KubernetesApi, namespace string) ([]string, error)
 	ListAllRoleDefinitions(subscriptionId string, scope string) (*ListRoleDefinition, error)
 }
+
+// The optional capabilities of the AKS support, implemented by AKSSupport (the registry tokens by IRegistryTokenProvider, the group members
+// by IGroupMembersResolver). They are not part of IAKSSupport so its implementations do not break, check for them with a type assertion
+type (
+	IAKSRoleAssignmentsSupport interface {
+		ListAllRolesForScopes(scopes []string) (*ListRoleAssignment, error)
+		ListAllRolesForSubscriptions(subscriptionIds []string) (*ListRoleAssignment, error)
+	}
+	IAKSCloudPermissionsSupport interface {
+		PreflightCloudPermissions(ctx context.Context, subscriptionId string, resourceGroup string) (*CloudPermissionsReport, error)
+	}
+	IAKSLoadBalancerSupport interface {
+		GetLoadBalancer(ctx context.Context, subscriptionId string, resourceGroup string, address string) (*LoadBalancer, error)
+	}
+	IAKSSecretsEncryptionSupport interface {
+		GetSecretsEncryption(ctx context.Context, subscriptionId string, clusterName string, resourceGroup string) (*SecretsEncryption, error)
+	}
+	IAKSDiskEncryptionSupport interface {
+		GetDiskEncryption(ctx context.Context, diskID string) (*DiskEncryption, error)
+	}
+	IAKSNetworkExposureSupport interface {
+		GetControlPlaneNetworkExposure(ctx context.Context, subscriptionId string, clusterName string, resourceGroup string) (*ControlPlaneNetworkExposure, error)
+	}
+	IAKSVersionSupport interface {
+		GetVersionSupport(ctx context.Context, subscriptionId string, clusterName string, resourceGroup string) (*VersionSupport, error)
+	}
+	IAKSNodePoolsSupport interface {
+		GetNodePools(ctx context.Context, subscriptionId string, clusterName string, resourceGroup string) ([]NodePool, error)
+	}
+)
+
+var (
+	_ IAKSSupport                  = &AKSSupport{}
+	_ IRegistryTokenProvider       = &AKSSupport{}
+	_ IGroupMembersResolver        = &AKSSupport{}
+	_ IAKSRoleAssignmentsSupport   = &AKSSupport{}
+	_ IAKSCloudPermissionsSupport  = &AKSSupport{}
+	_ IAKSLoadBalancerSupport      = &AKSSupport{}
+	_ IAKSSecretsEncryptionSupport = &AKSSupport{}
+	_ IAKSDiskEncryptionSupport    = &AKSSupport{}
+	_ IAKSNetworkExposureSupport   = &AKSSupport{}
+	_ IAKSVersionSupport           = &AKSSupport{}
+	_ IAKSNodePoolsSupport         = &AKSSupport{}
+)
+
 type AKSSupport struct {
+	logger  logging.Logger
+	ctx     context.Context
+	options cloudSupportOptions
 }
 
 type ListRoleAssignment struct {
@@ -39,25 +90,53 @@ type ListRoleDefinition struct {
 	RoleDefinitions []*armauthorization.RoleDefinition `json:"roleDefinitions"`
 }
 
-func NewAKSSupport() *AKSSupport {
-	return &AKSSupport{}
+func NewAKSSupport(opts ...CloudSupportOption) *AKSSupport {
+	return &AKSSupport{options: newCloudSupportOptions(opts)}
+}
+
+// SetLogger sets the logger of the AKS support, the logging package default logger is used if not set
+func (AKSSupport *AKSSupport) SetLogger(logger logging.Logger) {
+	AKSSupport.logger = logger
+}
+
+// GetLogger returns the logger of the AKS support
+func (AKSSupport *AKSSupport) GetLogger() logging.Logger {
+	return logging.OrDefault(AKSSupport.logger)
+}
+
+// SetContext sets the context of the AKS calls that do not take one, so they are traced as children of the span of the context and are canceled with it
+func (AKSSupport *AKSSupport) SetContext(ctx context.Context) {
+	AKSSupport.ctx = ctx
+}
+
+// GetContext returns the context of the AKS calls, context.Background() if not set
+func (AKSSupport *AKSSupport) GetContext() context.Context {
+	if AKSSupport.ctx == nil {
+		return context.Background()
+	}
+	return AKSSupport.ctx
 }
 
 // Get descriptive info about cluster running in AKS.
-func (AKSSupport *AKSSupport) GetClusterDescribe(subscriptionId string, clusterName string, resourceGroup string) (*armcontainerservice.ManagedCluster, error) {
+func (AKSSupport *AKSSupport) GetClusterDescribe(subscriptionId string, clusterName string, resourceGroup string) (_ *armcontainerservice.ManagedCluster, err error) {
+	ctx, span := tracing.StartSpan(AKSSupport.GetContext(), "aks.DescribeCluster", tracing.AttributeCloudProvider.String(tracing.CloudProviderAzure), tracing.AttributeCloudCluster.String(clusterName))
+	defer func() { tracing.EndSpan(span, err) }()
 
-	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	cred, err := AKSSupport.options.newAzureCredential()
 	if err != nil {
 		return nil, err
 	}
-	aksclient, err := armcontainerservice.NewManagedClustersClient(subscriptionId, cred, nil)
+	aksclient, err := armcontainerservice.NewManagedClustersClient(subscriptionId, cred, AKSSupport.options.armClientOptions())
 	if err != nil {
 		return nil, err
 	}
 
-	ctx := context.Background()
-
-	resp, err := aksclient.Get(ctx, resourceGroup, clusterName, nil)
+	var resp armcontainerservice.ManagedClustersClientGetResponse
+	err = metrics.ObserveCall(metrics.SourceAzure, "containerservice.ManagedClusters.Get", isThrottlingError, func() error {
+		var err error
+		resp, err = aksclient.Get(ctx, resourceGroup, clusterName, nil)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -93,33 +172,24 @@ func (AKSSupport *AKSSupport) GetResourceGroup() (string, error) {
 // subscriptionID (format: '/subscriptions/{subscriptionId}'),
 // resource group ID (format:'/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}', or
 // resource ID (format:'/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/{resourceProviderNamespace}/[{parentResourcePath}/]{resourceType}/{resourceName}'
-func (AKSSupport *AKSSupport) ListAllRolesForScope(subscriptionId string, scope string) (*ListRoleAssignment, error) {
+// management group ID (format: '/providers/Microsoft.Management/managementGroups/{managementGroupId}'), see ListAllRolesForScopes to list several scopes
+func (AKSSupport *AKSSupport) ListAllRolesForScope(subscriptionId string, scope string) (_ *ListRoleAssignment, err error) {
+	ctx, span := tracing.StartSpan(AKSSupport.GetContext(), "aks.ListRoleAssignments", tracing.AttributeCloudProvider.String(tracing.CloudProviderAzure))
+	defer func() { tracing.EndSpan(span, err) }()
 
-	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	cred, err := AKSSupport.options.newAzureCredential()
 	if err != nil {
 		return nil, err
 	}
-	ctx := context.Background()
 
-	client, err := armauthorizationv2.NewRoleAssignmentsClient(subscriptionId, cred, nil)
+	client, err := armauthorizationv2.NewRoleAssignmentsClient(subscriptionId, cred, AKSSupport.options.armClientOptions())
 	if err != nil {
 		return nil, err
 	}
 
-	pager := client.NewListForScopePager(scope, &armauthorizationv2.RoleAssignmentsClientListForScopeOptions{Filter: nil,
-		TenantID:  nil,
-		SkipToken: nil,
-	})
-
-	var roleList []*armauthorizationv2.RoleAssignment
-
-	for pager.More() {
-		nextResult, err := pager.NextPage(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("failed to advance page: %v", err)
-		}
-
-		roleList = append(roleList, nextResult.Value...)
+	roleList, err := listRoleAssignmentsForScope(ctx, client, scope)
+	if err != nil {
+		return nil, err
 	}
 
 	return &ListRoleAssignment{RoleAssignments: roleList}, nil
@@ -127,18 +197,20 @@ func (AKSSupport *AKSSupport) ListAllRolesForScope(subscriptionId string, scope
 }
 
 // ListAllRoleDefinitions - List all role definitions that are assigned in this scope
-func (AKSSupport *AKSSupport) ListAllRoleDefinitions(subscriptionId string, scope string) (*ListRoleDefinition, error) {
-	cred, err := azidentity.NewDefaultAzureCredential(nil)
+func (AKSSupport *AKSSupport) ListAllRoleDefinitions(subscriptionId string, scope string) (_ *ListRoleDefinition, err error) {
+	ctx, span := tracing.StartSpan(AKSSupport.GetContext(), "aks.ListRoleDefinitions", tracing.AttributeCloudProvider.String(tracing.CloudProviderAzure))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	cred, err := AKSSupport.options.newAzureCredential()
 	if err != nil {
 		return nil, fmt.Errorf("failed to obtain a credential: %v", err)
 	}
-	ctx := context.Background()
 	listRoleAssignment, err := AKSSupport.ListAllRolesForScope(subscriptionId, scope)
 	var roleDefinitionList []*armauthorization.RoleDefinition
 	if err != nil {
 		return nil, fmt.Errorf("failed to ListAllRolesForScope: %v", err)
 	}
-	client, err := armauthorization.NewRoleDefinitionsClient(cred, nil)
+	client, err := armauthorization.NewRoleDefinitionsClient(cred, AKSSupport.options.armClientOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client: %v", err)
 	}
@@ -193,3 +265,47 @@ func (AKSSupport *AKSSupport) GetGroupIdsRoleBindings(kapi *k8sinterface.Kuberne
 	return listgroupids, nil
 
 }
+
+// GetRegistryToken returns a token for the ACR registry (<name>.azurecr.io) by exchanging the AAD token of the default Azure credentials for an ACR refresh token
+func (AKSSupport *AKSSupport) GetRegistryToken(ctx context.Context, registryHost string) (_ *RegistryToken, err error) {
+	ctx, span := tracing.StartSpan(ctx, "acr.GetRegistryToken", tracing.AttributeCloudProvider.String(tracing.CloudProviderAzure), tracing.AttributeRegistry.String(registryHost))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !IsACRRegistry(registryHost) {
+		return nil, fmt.Errorf("registry '%s' is not an ACR registry", registryHost)
+	}
+	cred, err := AKSSupport.options.newAzureCredential()
+	if err != nil {
+		return nil, err
+	}
+	aadToken, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{AKSSupport.options.environment.azureManagementScope()}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AAD token: %w", err)
+	}
+	claims, err := jwtClaims(aadToken.Token)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, _ := claims["tid"].(string)
+	httpClient, err := AKSSupport.options.httpClient(registryTokenHTTPClient)
+	if err != nil {
+		return nil, err
+	}
+	var refreshToken string
+	err = metrics.ObserveCall(metrics.SourceAzure, "acr.ExchangeToken", isThrottlingError, func() error {
+		var err error
+		refreshToken, err = exchangeAADTokenForACRRefreshToken(ctx, httpClient, registryHost, tenantID, aadToken.Token)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange AAD token for ACR refresh token, registry: %s: %w", registryHost, err)
+	}
+	token := &RegistryToken{
+		Registry:  registryHost,
+		Username:  acrTokenUsername,
+		Password:  refreshToken,
+		ExpiresAt: jwtExpiry(refreshToken),
+	}
+	AKSSupport.GetLogger().Debug("minted registry token", "registry", registryHost, "expiresAt", token.ExpiresAt)
+	return token, nil
+}
@@ -1,11 +1,14 @@
 package v1
 
 import (
+	"context"
 	"encoding/json"
+	"time"
 
 	armcontainerservice "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
 	"github.com/kubescape/k8s-interface/cloudsupport/mockobjects"
 	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/tracing"
 )
 
 func NewAKSSupportMock() *AKSSupportMock {
@@ -28,6 +31,24 @@ func (AKSSupportM *AKSSupportMock) ListAllRolesForScope(subscriptionId string, s
 	return c, err
 }
 
+func (AKSSupportM *AKSSupportMock) ListAllRolesForScopes(scopes []string) (*ListRoleAssignment, error) {
+	c := &ListRoleAssignment{}
+	err := json.Unmarshal([]byte(mockobjects.AKSListRoleAssignments), c)
+	return c, err
+}
+
+func (AKSSupportM *AKSSupportMock) ListAllRolesForSubscriptions(subscriptionIds []string) (*ListRoleAssignment, error) {
+	return AKSSupportM.ListAllRolesForScopes(nil)
+}
+
+func (AKSSupportM *AKSSupportMock) ExpandGroupMembers(ctx context.Context, groupIDs []string) ([]GroupMember, error) {
+	members := make([]GroupMember, 0, len(groupIDs))
+	for _, groupID := range groupIDs {
+		members = append(members, GroupMember{ID: groupID + "-member", Type: GroupMemberTypeUser, GroupIDs: []string{groupID}})
+	}
+	return members, nil
+}
+
 func (AKSSupportM *AKSSupportMock) ListAllRoleDefinitions(subscriptionId string, scope string) (*ListRoleDefinition, error) {
 	c := &ListRoleDefinition{}
 	err := json.Unmarshal([]byte(mockobjects.AKSListRoleDefinitions), c)
@@ -48,3 +69,66 @@ func (AKSSupportM *AKSSupportMock) GetResourceGroup() (string, error) {
 func (AKSSupportM *AKSSupportMock) GetGroupIdsRoleBindings(kapi *k8sinterface.KubernetesApi, namespace string) ([]string, error) {
 	return []string{"e808215d-d159-49ba-8bb6-9661ba478842", "unexpected comma, expecting type"}, nil
 }
+
+func (AKSSupportM *AKSSupportMock) GetRegistryToken(ctx context.Context, registryHost string) (*RegistryToken, error) {
+	return &RegistryToken{
+		Registry:  registryHost,
+		Username:  acrTokenUsername,
+		Password:  "mock-acr-refresh-token",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil
+}
+
+func (AKSSupportM *AKSSupportMock) GetLoadBalancer(ctx context.Context, subscriptionId string, resourceGroup string, address string) (*LoadBalancer, error) {
+	return &LoadBalancer{
+		Provider:       tracing.CloudProviderAzure,
+		Type:           LoadBalancerTypeAzureLoadBalancer,
+		ID:             "/subscriptions/" + subscriptionId + "/resourceGroups/" + resourceGroup + "/providers/Microsoft.Network/loadBalancers/kubernetes",
+		Name:           "kubernetes",
+		Address:        address,
+		InternetFacing: true,
+	}, nil
+}
+
+func (AKSSupportM *AKSSupportMock) GetSecretsEncryption(ctx context.Context, subscriptionId string, clusterName string, resourceGroup string) (*SecretsEncryption, error) {
+	managedCluster, err := AKSSupportM.GetClusterDescribe(subscriptionId, clusterName, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	return newSecretsEncryptionAKS(managedCluster)
+}
+
+func (AKSSupportM *AKSSupportMock) GetDiskEncryption(ctx context.Context, diskID string) (*DiskEncryption, error) {
+	return newDiskEncryptionAKS(&azureDisk{ID: diskID}), nil
+}
+
+func (AKSSupportM *AKSSupportMock) GetControlPlaneNetworkExposure(ctx context.Context, subscriptionId string, clusterName string, resourceGroup string) (*ControlPlaneNetworkExposure, error) {
+	managedCluster, err := AKSSupportM.GetClusterDescribe(subscriptionId, clusterName, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	return newControlPlaneNetworkExposureAKS(managedCluster)
+}
+
+func (AKSSupportM *AKSSupportMock) GetVersionSupport(ctx context.Context, subscriptionId string, clusterName string, resourceGroup string) (*VersionSupport, error) {
+	managedCluster, err := AKSSupportM.GetClusterDescribe(subscriptionId, clusterName, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	return newVersionSupportAKS(managedCluster, nil, []aksKubernetesVersion{
+		{Version: "1.21", PatchVersions: map[string]aksKubernetesPatch{"1.21.9": {Upgrades: []string{"1.22.6"}}}},
+		{Version: "1.22", PatchVersions: map[string]aksKubernetesPatch{"1.22.6": {}}},
+	})
+}
+
+func (AKSSupportM *AKSSupportMock) GetNodePools(ctx context.Context, subscriptionId string, clusterName string, resourceGroup string) ([]NodePool, error) {
+	managedCluster, err := AKSSupportM.GetClusterDescribe(subscriptionId, clusterName, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	return newNodePoolsAKS(managedCluster)
+}
+
+func (AKSSupportM *AKSSupportMock) PreflightCloudPermissions(ctx context.Context, subscriptionId string, resourceGroup string) (*CloudPermissionsReport, error) {
+	return newCloudPermissionsReport(tracing.CloudProviderAzure, "", SubscriptionScope(subscriptionId)+"/resourceGroups/"+resourceGroup, AKSRequiredPermissions, func(string) bool { return true }), nil
+}
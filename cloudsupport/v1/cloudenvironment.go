@@ -0,0 +1,177 @@
+package v1
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+)
+
+// CloudEnvironment is the environment of the cloud provider APIs: the public clouds or a sovereign cloud
+type CloudEnvironment string
+
+const (
+	// CloudEnvironmentPublic is the public clouds, the default
+	CloudEnvironmentPublic CloudEnvironment = ""
+	// CloudEnvironmentUSGovernment is Azure Government and the AWS GovCloud (US) partition
+	CloudEnvironmentUSGovernment CloudEnvironment = "usgovernment"
+	// CloudEnvironmentChina is Azure China (21Vianet) and the AWS China partition
+	CloudEnvironmentChina CloudEnvironment = "china"
+)
+
+const azureManagementScope = "https://management.azure.com/.default"
+
+// the GCP services of the clients, keys of WithGCPEndpoints
+const (
+	GCPServiceContainer = "container"
+	GCPServiceCompute   = "compute"
+	GCPServiceKMS       = "kms"
+	// GCPServiceResourceManager is the Cloud Resource Manager, the permissions of the credential are checked with it
+	GCPServiceResourceManager = "cloudresourcemanager"
+)
+
+var (
+	// KS_CLOUD_ENVIRONMENT_ENV_VAR selects the cloud environment when WithCloudEnvironment is not used, see ParseCloudEnvironment
+	KS_CLOUD_ENVIRONMENT_ENV_VAR = "KS_CLOUD_ENVIRONMENT"
+	// KS_GCP_ENDPOINTS_ENV_VAR sets the endpoints of the GCP services when WithGCPEndpoints is not used: <service>=<endpoint> pairs separated by commas,
+	// e.g. container=container.me-central2.rep.googleapis.com:443,compute=https://compute.me-central2.rep.googleapis.com/compute/v1/
+	KS_GCP_ENDPOINTS_ENV_VAR = "KS_GCP_ENDPOINTS"
+)
+
+// the names of the environments in the Azure and AWS tooling are accepted too
+var cloudEnvironmentNames = map[string]CloudEnvironment{
+	"":                       CloudEnvironmentPublic,
+	"public":                 CloudEnvironmentPublic,
+	"azurecloud":             CloudEnvironmentPublic,
+	"azurepubliccloud":       CloudEnvironmentPublic,
+	"aws":                    CloudEnvironmentPublic,
+	"usgovernment":           CloudEnvironmentUSGovernment,
+	"azureusgovernment":      CloudEnvironmentUSGovernment,
+	"azureusgovernmentcloud": CloudEnvironmentUSGovernment,
+	"aws-us-gov":             CloudEnvironmentUSGovernment,
+	"china":                  CloudEnvironmentChina,
+	"azurechinacloud":        CloudEnvironmentChina,
+	"aws-cn":                 CloudEnvironmentChina,
+}
+
+// ParseCloudEnvironment parses the name of a cloud environment: public, usgovernment or china, or the names of the Azure environments
+// (AzureCloud, AzureUSGovernment, AzureChinaCloud) and the AWS partitions (aws, aws-us-gov, aws-cn). The names are case-insensitive
+func ParseCloudEnvironment(name string) (CloudEnvironment, error) {
+	environment, ok := cloudEnvironmentNames[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return CloudEnvironmentPublic, fmt.Errorf("unknown cloud environment '%s', supported environments: public, usgovernment, china", name)
+	}
+	return environment, nil
+}
+
+// WithCloudEnvironment sets the environment of the cloud provider APIs, the value of KS_CLOUD_ENVIRONMENT or the public clouds if not set.
+// The Azure clients use the endpoints and the authority of the environment. The AWS clients resolve the endpoints of the partition from the region,
+// the region defaults to the main region of the partition
+func WithCloudEnvironment(environment CloudEnvironment) CloudSupportOption {
+	return func(options *cloudSupportOptions) {
+		options.environment = environment
+		options.environmentSet = true
+	}
+}
+
+// WithGCPEndpoints sets the endpoints of the GCP services, e.g. regional endpoints, by service (see the GCPService constants).
+// The value of KS_GCP_ENDPOINTS is used if not set
+func WithGCPEndpoints(endpoints map[string]string) CloudSupportOption {
+	return func(options *cloudSupportOptions) {
+		options.gcpEndpoints = endpoints
+	}
+}
+
+// loadEnvironment sets the environment and the GCP endpoints from the environment variables if the options did not set them
+func (options *cloudSupportOptions) loadEnvironment() error {
+	if !options.environmentSet {
+		environment, err := ParseCloudEnvironment(os.Getenv(KS_CLOUD_ENVIRONMENT_ENV_VAR))
+		if err != nil {
+			return err
+		}
+		options.environment = environment
+	}
+	if _, ok := cloudEnvironmentNames[string(options.environment)]; !ok {
+		return fmt.Errorf("unknown cloud environment '%s', supported environments: public, usgovernment, china", options.environment)
+	}
+	if options.gcpEndpoints == nil {
+		endpoints, err := parseGCPEndpoints(os.Getenv(KS_GCP_ENDPOINTS_ENV_VAR))
+		if err != nil {
+			return err
+		}
+		options.gcpEndpoints = endpoints
+	}
+	return nil
+}
+
+func parseGCPEndpoints(value string) (map[string]string, error) {
+	endpoints := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		service, endpoint, found := strings.Cut(pair, "=")
+		if !found || strings.TrimSpace(service) == "" || strings.TrimSpace(endpoint) == "" {
+			return nil, fmt.Errorf("invalid GCP endpoint '%s', expected <service>=<endpoint>", pair)
+		}
+		endpoints[strings.TrimSpace(service)] = strings.TrimSpace(endpoint)
+	}
+	return endpoints, nil
+}
+
+// AWSPartition returns the AWS partition of the environment, used in the ARNs
+func (environment CloudEnvironment) AWSPartition() string {
+	switch environment {
+	case CloudEnvironmentUSGovernment:
+		return "aws-us-gov"
+	case CloudEnvironmentChina:
+		return "aws-cn"
+	}
+	return "aws"
+}
+
+func (environment CloudEnvironment) awsDefaultRegion() string {
+	switch environment {
+	case CloudEnvironmentUSGovernment:
+		return "us-gov-west-1"
+	case CloudEnvironmentChina:
+		return "cn-north-1"
+	}
+	return "us-east-1"
+}
+
+// azureCloud returns the Azure cloud of the environment
+func (environment CloudEnvironment) azureCloud() cloud.Configuration {
+	switch environment {
+	case CloudEnvironmentUSGovernment:
+		return cloud.AzureGovernment
+	case CloudEnvironmentChina:
+		return cloud.AzureChina
+	}
+	return cloud.AzurePublic
+}
+
+// azureManagementScope returns the scope of the Azure Resource Manager tokens of the environment
+func (environment CloudEnvironment) azureManagementScope() string {
+	if environment == CloudEnvironmentPublic {
+		return azureManagementScope
+	}
+	return strings.TrimSuffix(environment.azureResourceManager().Audience, "/") + "/.default"
+}
+
+// azureGraphEndpoint returns the endpoint of the Microsoft Graph API of the environment
+func (environment CloudEnvironment) azureGraphEndpoint() string {
+	switch environment {
+	case CloudEnvironmentUSGovernment:
+		return "https://graph.microsoft.us"
+	case CloudEnvironmentChina:
+		return "https://microsoftgraph.chinacloudapi.cn"
+	}
+	return "https://graph.microsoft.com"
+}
+
+// azureResourceManager returns the Azure Resource Manager of the environment: its endpoint and the audience of its tokens
+func (environment CloudEnvironment) azureResourceManager() cloud.ServiceConfiguration {
+	return environment.azureCloud().Services[cloud.ResourceManager]
+}
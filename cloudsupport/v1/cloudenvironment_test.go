@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCloudEnvironment(t *testing.T) {
+	for name, expected := range map[string]CloudEnvironment{
+		"":                  CloudEnvironmentPublic,
+		"Public":            CloudEnvironmentPublic,
+		"AzureCloud":        CloudEnvironmentPublic,
+		"usgovernment":      CloudEnvironmentUSGovernment,
+		"AzureUSGovernment": CloudEnvironmentUSGovernment,
+		"aws-us-gov":        CloudEnvironmentUSGovernment,
+		"AzureChinaCloud":   CloudEnvironmentChina,
+		" aws-cn ":          CloudEnvironmentChina,
+	} {
+		environment, err := ParseCloudEnvironment(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, environment, name)
+	}
+	_, err := ParseCloudEnvironment("germany")
+	assert.Error(t, err)
+}
+
+func TestCloudEnvironmentEndpoints(t *testing.T) {
+	assert.Equal(t, "https://management.azure.com", CloudEnvironmentPublic.azureResourceManager().Endpoint)
+	assert.Equal(t, "https://management.usgovcloudapi.net", CloudEnvironmentUSGovernment.azureResourceManager().Endpoint)
+	assert.Equal(t, "https://management.chinacloudapi.cn", CloudEnvironmentChina.azureResourceManager().Endpoint)
+	assert.Equal(t, "https://login.microsoftonline.us/", CloudEnvironmentUSGovernment.azureCloud().ActiveDirectoryAuthorityHost)
+
+	assert.Equal(t, "https://management.azure.com/.default", CloudEnvironmentPublic.azureManagementScope())
+	assert.Equal(t, "https://management.core.usgovcloudapi.net/.default", CloudEnvironmentUSGovernment.azureManagementScope())
+	assert.Equal(t, "https://graph.microsoft.com", CloudEnvironmentPublic.azureGraphEndpoint())
+	assert.Equal(t, "https://microsoftgraph.chinacloudapi.cn", CloudEnvironmentChina.azureGraphEndpoint())
+
+	assert.Equal(t, "aws", CloudEnvironmentPublic.AWSPartition())
+	assert.Equal(t, "aws-us-gov", CloudEnvironmentUSGovernment.AWSPartition())
+	assert.Equal(t, "aws-cn", CloudEnvironmentChina.AWSPartition())
+	assert.Equal(t, "us-gov-west-1", CloudEnvironmentUSGovernment.awsDefaultRegion())
+	assert.Equal(t, "cn-north-1", CloudEnvironmentChina.awsDefaultRegion())
+}
+
+func TestCloudEnvironmentOptions(t *testing.T) {
+	t.Setenv(KS_CLOUD_ENVIRONMENT_ENV_VAR, "AzureUSGovernment")
+	t.Setenv(KS_GCP_ENDPOINTS_ENV_VAR, "container=container.me-central2.rep.googleapis.com:443, kms=kms.me-central2.rep.googleapis.com:443")
+
+	options := NewAKSSupport().options
+	require.NoError(t, options.err)
+	assert.Equal(t, CloudEnvironmentUSGovernment, options.environment)
+	assert.Equal(t, "https://login.microsoftonline.us/", options.armClientOptions().Cloud.ActiveDirectoryAuthorityHost)
+	assert.Equal(t, map[string]string{GCPServiceContainer: "container.me-central2.rep.googleapis.com:443", GCPServiceKMS: "kms.me-central2.rep.googleapis.com:443"}, options.gcpEndpoints)
+	gcpClientOptions, err := options.gcpClientOptions(GCPServiceContainer)
+	require.NoError(t, err)
+	assert.Len(t, gcpClientOptions, 1)
+
+	// the options take precedence over the environment variables
+	options = NewAKSSupport(WithCloudEnvironment(CloudEnvironmentPublic), WithGCPEndpoints(map[string]string{})).options
+	require.NoError(t, options.err)
+	assert.Equal(t, CloudEnvironmentPublic, options.environment)
+	assert.Empty(t, options.gcpEndpoints)
+
+	options = NewEKSSupport(WithCloudEnvironment("germany")).options
+	assert.Error(t, options.err)
+
+	t.Setenv(KS_GCP_ENDPOINTS_ENV_VAR, "container")
+	options = NewGKESupport().options
+	assert.Error(t, options.err)
+	_, err = options.gcpClientOptions(GCPServiceContainer)
+	assert.Error(t, err)
+}
@@ -0,0 +1,138 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/aws/smithy-go"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the permissions the cloud support methods require, checked by PreflightCloudPermissions. The lists can be changed to check the permissions of a subset of the methods
+var (
+	EKSRequiredPermissions = []string{
+		"eks:DescribeCluster",
+		"eks:ListNodegroups",
+		"eks:DescribeNodegroup",
+		"eks:ListPodIdentityAssociations",
+		"eks:DescribePodIdentityAssociation",
+		"ecr:DescribeRepositories",
+		"ecr:GetAuthorizationToken",
+		"iam:ListPolicies",
+		"iam:ListEntitiesForPolicy",
+		"iam:GetPolicyVersion",
+		"iam:ListOpenIDConnectProviders",
+		"kms:DescribeKey",
+		"kms:GetKeyRotationStatus",
+		"ec2:DescribeVolumes",
+		"ec2:DescribeSecurityGroups",
+		"elasticloadbalancing:DescribeLoadBalancers",
+	}
+	AKSRequiredPermissions = []string{
+		"Microsoft.ContainerService/managedClusters/read",
+		"Microsoft.ContainerService/locations/kubernetesVersions/read",
+		"Microsoft.Authorization/roleAssignments/read",
+		"Microsoft.Authorization/roleDefinitions/read",
+		"Microsoft.Compute/disks/read",
+		"Microsoft.Network/loadBalancers/read",
+		"Microsoft.Network/publicIPAddresses/read",
+		"Microsoft.Network/applicationGateways/read",
+		"Microsoft.Network/networkSecurityGroups/read",
+	}
+	GKERequiredPermissions = []string{
+		"container.clusters.get",
+		"compute.disks.get",
+		"compute.forwardingRules.list",
+		"compute.firewalls.list",
+		"cloudkms.cryptoKeys.get",
+	}
+)
+
+// CloudPermissionsReport is the result of the check of the permissions of the cloud credential, see PreflightCloudPermissions
+type CloudPermissionsReport struct {
+	// Provider is one of aws/azure/gcp
+	Provider string `json:"provider"`
+	// Principal is the identity of the credential, if known
+	Principal string `json:"principal,omitempty"`
+	// Scope is the scope the permissions were checked in: the region, the resource group or the project
+	Scope   string   `json:"scope"`
+	Granted []string `json:"granted"`
+	Missing []string `json:"missing"`
+	// Unchecked are the permissions that could not be checked, e.g. the credential is not allowed to check its own permissions, see Reason
+	Unchecked []string `json:"unchecked,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+}
+
+// OK returns true if all the permissions are granted
+func (report *CloudPermissionsReport) OK() bool {
+	return len(report.Missing) == 0 && len(report.Unchecked) == 0
+}
+
+// newCloudPermissionsReport returns the report of the permissions, granted returns true if a permission is granted
+func newCloudPermissionsReport(provider, principal, scope string, permissions []string, granted func(permission string) bool) *CloudPermissionsReport {
+	report := &CloudPermissionsReport{Provider: provider, Principal: principal, Scope: scope, Granted: []string{}, Missing: []string{}}
+	for _, permission := range permissions {
+		if granted(permission) {
+			report.Granted = append(report.Granted, permission)
+		} else {
+			report.Missing = append(report.Missing, permission)
+		}
+	}
+	sort.Strings(report.Granted)
+	sort.Strings(report.Missing)
+	return report
+}
+
+// newUncheckedCloudPermissionsReport returns the report of permissions that could not be checked
+func newUncheckedCloudPermissionsReport(provider, principal, scope string, permissions []string, reason string) *CloudPermissionsReport {
+	unchecked := append([]string{}, permissions...)
+	sort.Strings(unchecked)
+	return &CloudPermissionsReport{Provider: provider, Principal: principal, Scope: scope, Granted: []string{}, Missing: []string{}, Unchecked: unchecked, Reason: reason}
+}
+
+// isAccessDeniedError returns true if the cloud provider denied the request, from the error code or the HTTP status of the errors of the SDKs
+func isAccessDeniedError(err error) bool {
+	if err == nil {
+		return false
+	}
+	code, httpStatus := cloudErrorStatus(err)
+	switch code {
+	case "AccessDenied", "AccessDeniedException", "UnauthorizedOperation", "AuthorizationFailed", codes.PermissionDenied.String():
+		return true
+	}
+	return httpStatus == http.StatusForbidden
+}
+
+// cloudErrorStatus returns the error code and the HTTP status of the error of the AWS, Azure and GCP SDKs, empty and 0 if unknown.
+// The code of the gRPC errors is their status code name, e.g. PermissionDenied
+func cloudErrorStatus(err error) (string, int) {
+	code, httpStatus := "", 0
+	var apiError smithy.APIError
+	if errors.As(err, &apiError) {
+		code = apiError.ErrorCode()
+	}
+	var httpError interface{ HTTPStatusCode() int }
+	if errors.As(err, &httpError) {
+		httpStatus = httpError.HTTPStatusCode()
+	}
+	var azureError *azcore.ResponseError
+	if errors.As(err, &azureError) {
+		code, httpStatus = azureError.ErrorCode, azureError.StatusCode
+	}
+	var googleError *googleapi.Error
+	if errors.As(err, &googleError) {
+		httpStatus = googleError.Code
+		if len(googleError.Errors) > 0 {
+			code = googleError.Errors[0].Reason
+		}
+	}
+	var grpcError interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcError) {
+		code = grpcError.GRPCStatus().Code().String()
+	}
+	return code, httpStatus
+}
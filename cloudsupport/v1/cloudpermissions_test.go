@@ -0,0 +1,108 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	armauthorizationv2 "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCloudPermissionsReport(t *testing.T) {
+	report := newCloudPermissionsReport("aws", "arn:aws:iam::123456789012:user/scanner", "eu-north-1", []string{"eks:DescribeCluster", "ecr:DescribeRepositories", "iam:ListPolicies"}, func(permission string) bool {
+		return permission != "iam:ListPolicies"
+	})
+	assert.Equal(t, []string{"ecr:DescribeRepositories", "eks:DescribeCluster"}, report.Granted)
+	assert.Equal(t, []string{"iam:ListPolicies"}, report.Missing)
+	assert.False(t, report.OK())
+
+	report = newUncheckedCloudPermissionsReport("gcp", "", "my-project", []string{"container.clusters.get"}, "permission denied")
+	assert.Equal(t, []string{"container.clusters.get"}, report.Unchecked)
+	assert.Empty(t, report.Missing)
+	assert.False(t, report.OK())
+
+	assert.True(t, newCloudPermissionsReport("gcp", "", "my-project", []string{"container.clusters.get"}, func(string) bool { return true }).OK())
+}
+
+type fakeIAMRoles map[string]string
+
+func (roles fakeIAMRoles) GetRole(_ context.Context, input *iam.GetRoleInput, _ ...func(*iam.Options)) (*iam.GetRoleOutput, error) {
+	roleARN, ok := roles[aws.ToString(input.RoleName)]
+	if !ok {
+		return nil, &smithy.OperationError{ServiceID: "IAM", OperationName: "GetRole", Err: &iamtypes.NoSuchEntityException{Message: aws.String("role not found")}}
+	}
+	return &iam.GetRoleOutput{Role: &iamtypes.Role{Arn: aws.String(roleARN)}}, nil
+}
+
+func TestIAMPrincipalARN(t *testing.T) {
+	roles := fakeIAMRoles{"kubescape-scanner": "arn:aws:iam::123456789012:role/security/kubescape-scanner"}
+
+	// the path of the role is resolved
+	principalARN, ok, err := iamPrincipalARN(context.Background(), roles, "arn:aws:sts::123456789012:assumed-role/kubescape-scanner/i-0123456789")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/security/kubescape-scanner", principalARN)
+
+	principalARN, ok, err = iamPrincipalARN(context.Background(), roles, "arn:aws-cn:iam::123456789012:user/scanner")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "arn:aws-cn:iam::123456789012:user/scanner", principalARN)
+
+	_, ok, err = iamPrincipalARN(context.Background(), roles, "arn:aws:iam::123456789012:root")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// the permissions of an unknown role are unchecked
+	_, _, err = iamPrincipalARN(context.Background(), roles, "arn:aws:sts::123456789012:assumed-role/deleted/i-0123456789")
+	assert.True(t, isUncheckedIAMError(err))
+	assert.False(t, isUncheckedIAMError(errors.New("connection refused")))
+}
+
+func TestAzureActionAllowed(t *testing.T) {
+	strs := func(values ...string) []*string {
+		pointers := make([]*string, 0, len(values))
+		for i := range values {
+			pointers = append(pointers, &values[i])
+		}
+		return pointers
+	}
+	permissions := []*armauthorizationv2.Permission{
+		// Reader, without the role assignments
+		{Actions: strs("*/read"), NotActions: strs("Microsoft.Authorization/roleAssignments/*")},
+		{Actions: strs("Microsoft.Network/loadBalancers/*")},
+	}
+	assert.True(t, azureActionAllowed(permissions, "Microsoft.ContainerService/managedClusters/read"))
+	assert.True(t, azureActionAllowed(permissions, "microsoft.compute/disks/READ"))
+	assert.True(t, azureActionAllowed(permissions, "Microsoft.Network/loadBalancers/delete"))
+	assert.False(t, azureActionAllowed(permissions, "Microsoft.Authorization/roleAssignments/read"))
+	assert.False(t, azureActionAllowed(permissions, "Microsoft.Compute/disks/write"))
+	assert.False(t, azureActionAllowed(nil, "Microsoft.Compute/disks/read"))
+
+	assert.True(t, wildcardMatch("*", "anything"))
+	assert.True(t, wildcardMatch("a*c*e", "abcde"))
+	assert.False(t, wildcardMatch("a*c*e", "abcd"))
+	assert.False(t, wildcardMatch("abc", "abcd"))
+}
+
+func TestIsAccessDeniedError(t *testing.T) {
+	assert.True(t, isAccessDeniedError(&smithy.OperationError{ServiceID: "IAM", OperationName: "SimulatePrincipalPolicy", Err: &smithy.GenericAPIError{Code: "AccessDenied"}}))
+	assert.True(t, isAccessDeniedError(&azcore.ResponseError{StatusCode: http.StatusForbidden, ErrorCode: "AuthorizationFailed"}))
+	assert.True(t, isAccessDeniedError(fmt.Errorf("failed: %w", &googleapi.Error{Code: http.StatusForbidden})))
+	assert.True(t, isAccessDeniedError(status.Error(codes.PermissionDenied, "permission denied")))
+	// the messages are not matched
+	assert.False(t, isAccessDeniedError(errors.New("failed to get the role 'role-403': access denied")))
+	assert.False(t, isAccessDeniedError(&azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: "ResourceGroupNotFound"}))
+	assert.False(t, isAccessDeniedError(nil))
+}
@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+// PreflightCloudPermissions checks that the AWS credential is allowed the actions of EKSRequiredPermissions, by simulating the policies of its IAM principal.
+// The simulation requires the iam:SimulatePrincipalPolicy permission, and iam:GetRole for the assumed roles: the permissions are reported as unchecked
+// if they are denied, or if the principal cannot be simulated.
+// The resource-based policies and the service control policies are not part of the simulation
+func (eksSupport *EKSSupport) PreflightCloudPermissions(ctx context.Context, region string) (_ *CloudPermissionsReport, err error) {
+	ctx, span := tracing.StartSpan(ctx, "eks.PreflightCloudPermissions", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	awsConfig, err := eksSupport.options.loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error: fail to load AWS SDK default %v", err)
+	}
+	if region != "" {
+		awsConfig.Region = region
+	}
+
+	var callerIdentity *sts.GetCallerIdentityOutput
+	err = metrics.ObserveCall(metrics.SourceAWS, "sts.GetCallerIdentity", isThrottlingError, func() error {
+		var err error
+		callerIdentity, err = sts.NewFromConfig(awsConfig).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the AWS caller identity: %w", err)
+	}
+	principal := aws.ToString(callerIdentity.Arn)
+	iamClient := iam.NewFromConfig(awsConfig)
+	principalARN, ok, err := iamPrincipalARN(ctx, iamClient, principal)
+	if isUncheckedIAMError(err) {
+		return newUncheckedCloudPermissionsReport(tracing.CloudProviderAWS, principal, region, EKSRequiredPermissions, err.Error()), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// the root user is allowed all the actions
+		return newCloudPermissionsReport(tracing.CloudProviderAWS, principal, region, EKSRequiredPermissions, func(string) bool { return true }), nil
+	}
+
+	allowed := map[string]bool{}
+	paginator := iam.NewSimulatePrincipalPolicyPaginator(iamClient, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principalARN),
+		ActionNames:     EKSRequiredPermissions,
+	})
+	for paginator.HasMorePages() {
+		var page *iam.SimulatePrincipalPolicyOutput
+		err = metrics.ObserveCall(metrics.SourceAWS, "iam.SimulatePrincipalPolicy", isThrottlingError, func() error {
+			var err error
+			page, err = paginator.NextPage(ctx)
+			return err
+		})
+		if isUncheckedIAMError(err) {
+			return newUncheckedCloudPermissionsReport(tracing.CloudProviderAWS, principal, region, EKSRequiredPermissions, fmt.Sprintf("failed to simulate the policies of '%s': %s", principalARN, err.Error())), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i := range page.EvaluationResults {
+			if page.EvaluationResults[i].EvalDecision == iamtypes.PolicyEvaluationDecisionTypeAllowed {
+				allowed[aws.ToString(page.EvaluationResults[i].EvalActionName)] = true
+			}
+		}
+	}
+	return newCloudPermissionsReport(tracing.CloudProviderAWS, principal, region, EKSRequiredPermissions, func(permission string) bool { return allowed[permission] }), nil
+}
+
+// iamPrincipalARN returns the ARN of the IAM principal of the caller identity, false for the root user. The path of the roles is not part of the
+// assumed role ARNs, the ARN of the role of an assumed role is returned by iam:GetRole
+func iamPrincipalARN(ctx context.Context, client iam.GetRoleAPIClient, callerARN string) (string, bool, error) {
+	// arn:<partition>:sts::<account>:assumed-role/<role>/<session>
+	parts := strings.SplitN(callerARN, ":", 6)
+	if len(parts) != 6 {
+		return callerARN, true, nil
+	}
+	if parts[5] == "root" {
+		return "", false, nil
+	}
+	if parts[2] != "sts" || !strings.HasPrefix(parts[5], "assumed-role/") {
+		return callerARN, true, nil
+	}
+	roleName := strings.SplitN(strings.TrimPrefix(parts[5], "assumed-role/"), "/", 2)[0]
+	var role *iam.GetRoleOutput
+	err := metrics.ObserveCall(metrics.SourceAWS, "iam.GetRole", isThrottlingError, func() error {
+		var err error
+		role, err = client.GetRole(ctx, &iam.GetRoleInput{RoleName: aws.String(roleName)})
+		return err
+	})
+	if err != nil {
+		return "", true, fmt.Errorf("failed to get the role '%s' of '%s': %w", roleName, callerARN, err)
+	}
+	if role.Role == nil || role.Role.Arn == nil {
+		return "", true, fmt.Errorf("failed to get the role '%s' of '%s': no role ARN", roleName, callerARN)
+	}
+	return aws.ToString(role.Role.Arn), true, nil
+}
+
+// isUncheckedIAMError returns true if the IAM error prevents checking the permissions: the request was denied, or the principal cannot be simulated
+func isUncheckedIAMError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var noSuchEntity *iamtypes.NoSuchEntityException
+	var invalidInput *iamtypes.InvalidInputException
+	return isAccessDeniedError(err) || errors.As(err, &noSuchEntity) || errors.As(err, &invalidInput)
+}
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	armauthorizationv2 "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v2"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+// PreflightCloudPermissions checks that the Azure credential is allowed the actions of AKSRequiredPermissions in the resource group of the cluster,
+// using the permissions the Azure Resource Manager reports for the caller. The deny assignments are not taken into account
+func (AKSSupport *AKSSupport) PreflightCloudPermissions(ctx context.Context, subscriptionId string, resourceGroup string) (_ *CloudPermissionsReport, err error) {
+	ctx, span := tracing.StartSpan(ctx, "aks.PreflightCloudPermissions", tracing.AttributeCloudProvider.String(tracing.CloudProviderAzure))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	scope := fmt.Sprintf("%s/resourceGroups/%s", SubscriptionScope(subscriptionId), resourceGroup)
+	cred, err := AKSSupport.options.newAzureCredential()
+	if err != nil {
+		return nil, err
+	}
+	client, err := armauthorizationv2.NewPermissionsClient(subscriptionId, cred, AKSSupport.options.armClientOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	var permissions []*armauthorizationv2.Permission
+	pager := client.NewListForResourceGroupPager(resourceGroup, nil)
+	for pager.More() {
+		var page armauthorizationv2.PermissionsClientListForResourceGroupResponse
+		err = metrics.ObserveCall(metrics.SourceAzure, "authorization.Permissions.ListForResourceGroup", isThrottlingError, func() error {
+			var err error
+			page, err = pager.NextPage(ctx)
+			return err
+		})
+		if isAccessDeniedError(err) {
+			return newUncheckedCloudPermissionsReport(tracing.CloudProviderAzure, "", scope, AKSRequiredPermissions, fmt.Sprintf("failed to list the permissions: %s", err.Error())), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the permissions of scope '%s': %v", scope, err)
+		}
+		permissions = append(permissions, page.Value...)
+	}
+	return newCloudPermissionsReport(tracing.CloudProviderAzure, "", scope, AKSRequiredPermissions, func(action string) bool {
+		return azureActionAllowed(permissions, action)
+	}), nil
+}
+
+// azureActionAllowed returns true if one of the permissions (the role definitions of the caller) allows the action and does not exclude it
+func azureActionAllowed(permissions []*armauthorizationv2.Permission, action string) bool {
+	for _, permission := range permissions {
+		if permission != nil && azureActionsMatch(permission.Actions, action) && !azureActionsMatch(permission.NotActions, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// azureActionsMatch returns true if one of the action patterns matches the action. The actions are case-insensitive, '*' matches any characters
+func azureActionsMatch(patterns []*string, action string) bool {
+	action = strings.ToLower(action)
+	for _, pattern := range patterns {
+		if pattern == nil {
+			continue
+		}
+		if wildcardMatch(strings.ToLower(*pattern), action) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardMatch returns true if the value matches the pattern, '*' matches any characters including '/'
+func wildcardMatch(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		index := strings.Index(value, part)
+		if index == -1 {
+			return false
+		}
+		value = value[index+len(part):]
+	}
+	return strings.HasSuffix(value, parts[len(parts)-1])
+}
@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+	"google.golang.org/api/cloudresourcemanager/v1"
+)
+
+// PreflightCloudPermissions checks that the GCP credential is granted the permissions of GKERequiredPermissions in the project. The permissions granted on
+// the resources only (e.g. on a KMS key ring of another project) are not taken into account
+func (gkeSupport *GKESupport) PreflightCloudPermissions(ctx context.Context, project string) (_ *CloudPermissionsReport, err error) {
+	ctx, span := tracing.StartSpan(ctx, "gke.PreflightCloudPermissions", tracing.AttributeCloudProvider.String(tracing.CloudProviderGCP))
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx = gkeSupport.options.gcpContext(ctx)
+	clientOptions, err := gkeSupport.options.gcpRESTClientOptions(ctx, GCPServiceResourceManager)
+	if err != nil {
+		return nil, err
+	}
+	service, err := cloudresourcemanager.NewService(ctx, clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	var response *cloudresourcemanager.TestIamPermissionsResponse
+	err = metrics.ObserveCall(metrics.SourceGCP, "cloudresourcemanager.Projects.TestIamPermissions", isThrottlingError, func() error {
+		var err error
+		response, err = service.Projects.TestIamPermissions(project, &cloudresourcemanager.TestIamPermissionsRequest{Permissions: GKERequiredPermissions}).Context(ctx).Do()
+		return err
+	})
+	if isAccessDeniedError(err) {
+		return newUncheckedCloudPermissionsReport(tracing.CloudProviderGCP, "", project, GKERequiredPermissions, fmt.Sprintf("failed to test the permissions: %s", err.Error())), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to test the permissions of project '%s': %v", project, err)
+	}
+	granted := map[string]bool{}
+	for _, permission := range response.Permissions {
+		granted[permission] = true
+	}
+	return newCloudPermissionsReport(tracing.CloudProviderGCP, "", project, GKERequiredPermissions, func(permission string) bool { return granted[permission] }), nil
+}
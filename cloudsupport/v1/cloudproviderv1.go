@@ -102,7 +102,7 @@ func GetListEntitiesForPoliciesAKS(aksSupport IAKSSupport, cluster string, subsc
 	if err != nil {
 		return nil, err
 	}
-	scope := fmt.Sprintf("/subscriptions/%s", subscriptionId)
+	scope := SubscriptionScope(subscriptionId)
 	listEntitiesForPolicies, err := aksSupport.ListAllRolesForScope(subscriptionId, scope)
 	if err != nil {
 		return nil, err
@@ -295,7 +295,7 @@ func GetPolicyVersionAKS(aksSupport IAKSSupport, cluster string, subscriptionId
 		return nil, err
 	}
 
-	scope := fmt.Sprintf("/subscriptions/%s", subscriptionId)
+	scope := SubscriptionScope(subscriptionId)
 	listPolicyVersion, err := aksSupport.ListAllRoleDefinitions(subscriptionId, scope)
 	if err != nil {
 		return nil, err
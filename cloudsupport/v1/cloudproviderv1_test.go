@@ -8,6 +8,23 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func TestCloudSupportCapabilities(t *testing.T) {
+	// the capabilities are checked with a type assertion on the provider interfaces
+	var eksSupport IEKSSupport = NewEKSSupportMock()
+	_, ok := eksSupport.(IEKSLoadBalancerSupport)
+	assert.True(t, ok)
+	_, ok = eksSupport.(IRegistryTokenProvider)
+	assert.True(t, ok)
+
+	var aksSupport IAKSSupport = NewAKSSupportMock()
+	_, ok = aksSupport.(IAKSVersionSupport)
+	assert.True(t, ok)
+
+	var gkeSupport IGKESupport = NewGKESupportMock()
+	_, ok = gkeSupport.(IGKENodePoolsSupport)
+	assert.True(t, ok)
+}
+
 func TestGetClusterDescribeGKE(t *testing.T) {
 	g := NewGKESupportMock()
 	des, err := GetClusterDescribeGKE(g, "kubescape-demo-01", "", "")
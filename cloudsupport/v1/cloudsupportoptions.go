@@ -0,0 +1,279 @@
+package v1
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/logging"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// CloudSupportOption is an option of NewEKSSupport, NewAKSSupport and NewGKESupport, applied to the cloud SDK clients they create
+type CloudSupportOption func(*cloudSupportOptions)
+
+type cloudSupportOptions struct {
+	identity       *k8sinterface.ClientIdentity
+	proxy          func(*http.Request) (*url.URL, error)
+	caData         []byte
+	insecure       bool
+	environment    CloudEnvironment
+	environmentSet bool
+	gcpEndpoints   map[string]string
+	// eksReleaseCalendar is the end of the standard support of the EKS versions, EKSStandardSupportEnd if nil
+	eksReleaseCalendar map[string]time.Time
+
+	// transport is the transport of the cloud SDK clients, nil to keep the defaults of the SDKs
+	transport *http.Transport
+	// err is the error of the options (e.g. an invalid CA bundle), returned when creating the clients
+	err error
+}
+
+// WithClientIdentity sets the User-Agent and the extra headers of the requests to the cloud provider APIs.
+// The User-Agent of the identity is added to the User-Agent of the SDKs, so the cloud audit logs (CloudTrail, Activity Log, Cloud Audit Logs) show both
+func WithClientIdentity(identity k8sinterface.ClientIdentity) CloudSupportOption {
+	return func(options *cloudSupportOptions) {
+		options.identity = &identity
+	}
+}
+
+// WithHTTPProxy sends the requests to the cloud provider APIs through the proxy, like the Proxy of rest.Config for the Kubernetes clients. The proxy of
+// the environment (HTTPS_PROXY, NO_PROXY) is used if not set. The link-local addresses (the instance metadata services) are never proxied.
+// The gRPC clients of GCP (container, KMS) only support the proxy of the environment
+func WithHTTPProxy(proxyURL *url.URL) CloudSupportOption {
+	return func(options *cloudSupportOptions) {
+		options.proxy = http.ProxyURL(proxyURL)
+	}
+}
+
+// WithCABundle trusts the PEM encoded CA certificates in addition to the system roots, like the CAData of rest.Config for the Kubernetes clients.
+// Used behind TLS inspecting proxies
+func WithCABundle(caData []byte) CloudSupportOption {
+	return func(options *cloudSupportOptions) {
+		options.caData = caData
+	}
+}
+
+// WithInsecureSkipTLSVerify does not verify the certificates of the cloud provider APIs, like the Insecure of rest.Config for the Kubernetes clients.
+// The connections are vulnerable to man-in-the-middle attacks, for testing only
+func WithInsecureSkipTLSVerify() CloudSupportOption {
+	return func(options *cloudSupportOptions) {
+		options.insecure = true
+	}
+}
+
+func newCloudSupportOptions(opts []CloudSupportOption) cloudSupportOptions {
+	options := cloudSupportOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.err = options.loadEnvironment(); options.err != nil {
+		return options
+	}
+	if options.proxy != nil || len(options.caData) > 0 || options.insecure {
+		options.transport, options.err = options.newTransport()
+	}
+	return options
+}
+
+// newTransport returns a copy of http.DefaultTransport with the proxy and the TLS options
+func (options *cloudSupportOptions) newTransport() (*http.Transport, error) {
+	tlsConfig, err := options.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	proxy := options.proxy
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		if ip := net.ParseIP(req.URL.Hostname()); ip != nil && ip.IsLinkLocalUnicast() {
+			return nil, nil
+		}
+		return proxy(req)
+	}
+	return transport, nil
+}
+
+func (options *cloudSupportOptions) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(options.caData) > 0 {
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(options.caData) {
+			return nil, fmt.Errorf("failed to load the CA bundle of the cloud provider clients, reason: no PEM certificate found")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	if options.insecure {
+		logging.L().Warning("the TLS certificates of the cloud provider APIs are not verified, the connections are insecure")
+		tlsConfig.InsecureSkipVerify = true
+	}
+	return tlsConfig, nil
+}
+
+// httpClient returns the client with the transport of the options, the client itself if the transport is not set
+func (options *cloudSupportOptions) httpClient(client *http.Client) (*http.Client, error) {
+	if options.err != nil {
+		return nil, options.err
+	}
+	if options.transport == nil {
+		return client, nil
+	}
+	withTransport := *client
+	withTransport.Transport = options.transport
+	return &withTransport, nil
+}
+
+// loadAWSConfig loads the default AWS config with the options
+func (options *cloudSupportOptions) loadAWSConfig(ctx context.Context) (aws.Config, error) {
+	if options.err != nil {
+		return aws.Config{}, options.err
+	}
+	loadOptions := []func(*config.LoadOptions) error{config.WithAPIOptions(options.awsAPIOptions())}
+	if options.transport != nil {
+		loadOptions = append(loadOptions, config.WithHTTPClient(&http.Client{Transport: options.transport}))
+	}
+	awsConfig, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err == nil && awsConfig.Region == "" && options.environment != CloudEnvironmentPublic {
+		// the global services (e.g. IAM) of the partition are called in its default region
+		awsConfig.Region = options.environment.awsDefaultRegion()
+	}
+	return awsConfig, err
+}
+
+func (options *cloudSupportOptions) awsAPIOptions() []func(*middleware.Stack) error {
+	var apiOptions []func(*middleware.Stack) error
+	if options.identity == nil {
+		return apiOptions
+	}
+	if options.identity.Component != "" {
+		apiOptions = append(apiOptions, awsmiddleware.AddUserAgentKeyValue(options.identity.Component, options.identity.Version))
+	}
+	for key, value := range options.identity.Headers {
+		apiOptions = append(apiOptions, smithyhttp.SetHeaderValue(key, value))
+	}
+	return apiOptions
+}
+
+// azureClientOptions returns the options of the Azure clients and credentials
+func (options *cloudSupportOptions) azureClientOptions() azcore.ClientOptions {
+	clientOptions := azcore.ClientOptions{Cloud: options.environment.azureCloud()}
+	if options.transport != nil {
+		clientOptions.Transport = &http.Client{Transport: options.transport}
+	}
+	if options.identity != nil {
+		clientOptions.PerCallPolicies = append(clientOptions.PerCallPolicies, &azureIdentityPolicy{userAgent: options.identity.UserAgent(), headers: options.identity.Headers})
+	}
+	return clientOptions
+}
+
+// armClientOptions returns the options of the Azure Resource Manager clients
+func (options *cloudSupportOptions) armClientOptions() *arm.ClientOptions {
+	return &arm.ClientOptions{ClientOptions: options.azureClientOptions()}
+}
+
+// newAzureCredential returns the default Azure credential, requesting the tokens with the options. Called before creating the Azure clients,
+// it returns the error of the transport
+func (options *cloudSupportOptions) newAzureCredential() (*azidentity.DefaultAzureCredential, error) {
+	if options.err != nil {
+		return nil, options.err
+	}
+	return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{ClientOptions: options.azureClientOptions()})
+}
+
+// gcpClientOptions returns the options of the GCP gRPC clients of the service, see the GCPService constants
+func (options *cloudSupportOptions) gcpClientOptions(service string) ([]option.ClientOption, error) {
+	if options.err != nil {
+		return nil, options.err
+	}
+	var clientOptions []option.ClientOption
+	if endpoint := options.gcpEndpoints[service]; endpoint != "" {
+		clientOptions = append(clientOptions, option.WithEndpoint(endpoint))
+	}
+	if userAgent := options.identity.UserAgent(); userAgent != "" {
+		clientOptions = append(clientOptions, option.WithUserAgent(userAgent))
+	}
+	if options.transport != nil {
+		clientOptions = append(clientOptions, option.WithGRPCDialOption(grpc.WithTransportCredentials(credentials.NewTLS(options.transport.TLSClientConfig))))
+	}
+	return clientOptions, nil
+}
+
+// gcpRESTClientOptions returns the options of the GCP REST clients. The transport is wrapped with the authentication of the default credentials,
+// the REST clients do not authenticate the requests of a custom HTTP client
+func (options *cloudSupportOptions) gcpRESTClientOptions(ctx context.Context, service string) ([]option.ClientOption, error) {
+	if options.err != nil || options.transport == nil {
+		return options.gcpClientOptions(service)
+	}
+	var clientOptions []option.ClientOption
+	if userAgent := options.identity.UserAgent(); userAgent != "" {
+		clientOptions = append(clientOptions, option.WithUserAgent(userAgent))
+	}
+	transport, err := htransport.NewTransport(ctx, options.transport, append(clientOptions, option.WithScopes(gcpCloudPlatformScope))...)
+	if err != nil {
+		return nil, err
+	}
+	clientOptions = []option.ClientOption{option.WithHTTPClient(&http.Client{Transport: transport})}
+	if endpoint := options.gcpEndpoints[service]; endpoint != "" {
+		clientOptions = append(clientOptions, option.WithEndpoint(endpoint))
+	}
+	return clientOptions, nil
+}
+
+// gcpContext returns the context of the GCP calls, with the headers of the identity in the outgoing metadata, sent by both the gRPC and the REST clients,
+// and the HTTP client of the OAuth2 token requests
+func (options *cloudSupportOptions) gcpContext(ctx context.Context) context.Context {
+	if options.transport != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: options.transport})
+	}
+	if options.identity == nil {
+		return ctx
+	}
+	for key, value := range options.identity.Headers {
+		ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(key), value)
+	}
+	return ctx
+}
+
+// azureIdentityPolicy sets the User-Agent and the headers of the identity on the requests of the Azure clients
+type azureIdentityPolicy struct {
+	userAgent string
+	headers   map[string]string
+}
+
+func (p *azureIdentityPolicy) Do(req *policy.Request) (*http.Response, error) {
+	raw := req.Raw()
+	for key, value := range p.headers {
+		raw.Header.Set(key, value)
+	}
+	if p.userAgent != "" {
+		raw.Header.Set("User-Agent", strings.TrimSpace(p.userAgent+" "+raw.Header.Get("User-Agent")))
+	}
+	return req.Next()
+}
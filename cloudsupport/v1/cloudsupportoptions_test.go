@@ -0,0 +1,123 @@
+package v1
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+var testClientIdentity = k8sinterface.ClientIdentity{Component: "kubescape", Version: "v3.0.0", Headers: map[string]string{"X-Scan-Id": "1234"}}
+
+type capturingTransport struct {
+	request *http.Request
+}
+
+func (transport *capturingTransport) Do(req *http.Request) (*http.Response, error) {
+	transport.request = req
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+}
+
+func TestCloudSupportOptions(t *testing.T) {
+	assert.Nil(t, NewEKSSupport().options.identity)
+	assert.Equal(t, "kubescape/v3.0.0", NewAKSSupport(WithClientIdentity(testClientIdentity)).options.identity.UserAgent())
+
+	options := NewEKSSupport(WithClientIdentity(testClientIdentity)).options
+	assert.Len(t, options.awsAPIOptions(), 2)
+	gcpClientOptions, err := options.gcpClientOptions(GCPServiceContainer)
+	require.NoError(t, err)
+	assert.Len(t, gcpClientOptions, 1)
+	none := NewGKESupport().options
+	assert.Empty(t, none.awsAPIOptions())
+	gcpClientOptions, err = none.gcpClientOptions(GCPServiceContainer)
+	require.NoError(t, err)
+	assert.Empty(t, gcpClientOptions)
+	assert.Nil(t, none.transport)
+	assert.Equal(t, context.Background(), none.gcpContext(context.Background()))
+
+	md, ok := metadata.FromOutgoingContext(options.gcpContext(context.Background()))
+	require.True(t, ok)
+	assert.Equal(t, []string{"1234"}, md.Get("x-scan-id"))
+}
+
+func TestAzureIdentityPolicy(t *testing.T) {
+	options := NewAKSSupport(WithClientIdentity(testClientIdentity)).options
+	clientOptions := options.azureClientOptions()
+	transport := &capturingTransport{}
+	clientOptions.Transport = transport
+
+	pipeline := runtime.NewPipeline("module", "v1.0.0", runtime.PipelineOptions{}, &clientOptions)
+	req, err := runtime.NewRequest(context.Background(), http.MethodGet, "https://management.azure.com/subscriptions")
+	require.NoError(t, err)
+	_, err = pipeline.Do(req)
+	require.NoError(t, err)
+	require.NotNil(t, transport.request)
+	assert.Regexp(t, `^kubescape/v3.0.0 azsdk-go-module/v1.0.0`, transport.request.Header.Get("User-Agent"))
+	assert.Equal(t, "1234", transport.request.Header.Get("X-Scan-Id"))
+}
+
+func TestCloudSupportTransportOptions(t *testing.T) {
+	proxyURL, err := url.Parse("http://proxy.corp:3128")
+	require.NoError(t, err)
+	options := NewAKSSupport(WithHTTPProxy(proxyURL)).options
+	require.NoError(t, options.err)
+	require.NotNil(t, options.transport)
+
+	req, err := http.NewRequest(http.MethodGet, "https://management.azure.com/subscriptions", nil)
+	require.NoError(t, err)
+	proxy, err := options.transport.Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, proxyURL, proxy)
+	// the instance metadata services are not proxied
+	req, err = http.NewRequest(http.MethodGet, "http://169.254.169.254/metadata/identity/oauth2/token", nil)
+	require.NoError(t, err)
+	proxy, err = options.transport.Proxy(req)
+	require.NoError(t, err)
+	assert.Nil(t, proxy)
+
+	clientOptions := options.azureClientOptions()
+	assert.NotNil(t, clientOptions.Transport)
+
+	options = NewEKSSupport(WithInsecureSkipTLSVerify()).options
+	require.NotNil(t, options.transport)
+	assert.True(t, options.transport.TLSClientConfig.InsecureSkipVerify)
+}
+
+func TestCloudSupportCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	// the certificate of the server is not trusted by default
+	_, err := http.Get(server.URL)
+	require.Error(t, err)
+
+	options := NewGKESupport(WithCABundle(caData)).options
+	client, err := options.httpClient(&http.Client{})
+	require.NoError(t, err)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// the errors of the transport are returned by the clients
+	options = NewGKESupport(WithCABundle([]byte("invalid"))).options
+	assert.Error(t, options.err)
+	_, err = options.httpClient(&http.Client{})
+	assert.Error(t, err)
+	_, err = options.gcpClientOptions(GCPServiceContainer)
+	assert.Error(t, err)
+	_, err = options.gcpRESTClientOptions(context.Background(), GCPServiceCompute)
+	assert.Error(t, err)
+	_, err = options.newAzureCredential()
+	assert.Error(t, err)
+	_, err = options.loadAWSConfig(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,183 @@
+package v1
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	containerpb "google.golang.org/genproto/googleapis/container/v1"
+)
+
+const (
+	anyNetwork           = "0.0.0.0/0"
+	secretsResource      = "secrets"
+	authorizationRBAC    = "RBAC"
+	authorizationNode    = "Node"
+	authorizationABAC    = "ABAC"
+	authorizationWebhook = "Webhook"
+	authorizationAllow   = "AlwaysAllow"
+)
+
+// ============================== ControlPlaneConfig ==============================
+
+// GetControlPlaneConfigEKS returns the control plane configuration of a cluster running in EKS
+func GetControlPlaneConfigEKS(eksSupport IEKSSupport, cluster string, region string) (*k8sinterface.ControlPlaneConfig, error) {
+	clusterDescribe, err := eksSupport.GetClusterDescribe(eksSupport.GetContextName(cluster), region)
+	if err != nil {
+		return nil, err
+	}
+	return NewControlPlaneConfigEKS(clusterDescribe)
+}
+
+// GetControlPlaneConfigGKE returns the control plane configuration of a cluster running in GKE
+func GetControlPlaneConfigGKE(gkeSupport IGKESupport, clusterName string, region string, project string) (*k8sinterface.ControlPlaneConfig, error) {
+	clusterDescribe, err := gkeSupport.GetClusterDescribe(gkeSupport.GetContextName(clusterName), region, project)
+	if err != nil {
+		return nil, err
+	}
+	return NewControlPlaneConfigGKE(clusterDescribe)
+}
+
+// GetControlPlaneConfigAKS returns the control plane configuration of a cluster running in AKS
+func GetControlPlaneConfigAKS(aksSupport IAKSSupport, cluster string, subscriptionId string, resourceGroup string) (*k8sinterface.ControlPlaneConfig, error) {
+	clusterDescribe, err := aksSupport.GetClusterDescribe(subscriptionId, cluster, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	return NewControlPlaneConfigAKS(clusterDescribe)
+}
+
+// NewControlPlaneConfigEKS returns the control plane configuration of the EKS cluster describe: the endpoint access and public access CIDRs,
+// the KMS encryption of the secrets and the audit logs
+func NewControlPlaneConfigEKS(clusterDescribe *eks.DescribeClusterOutput) (*k8sinterface.ControlPlaneConfig, error) {
+	if clusterDescribe == nil || clusterDescribe.Cluster == nil {
+		return nil, fmt.Errorf("error getting cluster descriptive information")
+	}
+	exposure, err := newControlPlaneNetworkExposureEKS(clusterDescribe)
+	if err != nil {
+		return nil, err
+	}
+	cluster := clusterDescribe.Cluster
+	config := &k8sinterface.ControlPlaneConfig{
+		Distribution:       k8sinterface.DistributionEKS,
+		Managed:            true,
+		AuthorizationModes: []string{authorizationNode, authorizationRBAC},
+		AuditLogging:       boolPtr(false),
+		SecretsEncryption:  boolPtr(false),
+	}
+	setNetworkExposure(config, exposure)
+	for _, encryptionConfig := range cluster.EncryptionConfig {
+		if !contains(encryptionConfig.Resources, secretsResource) {
+			continue
+		}
+		config.SecretsEncryption = boolPtr(true)
+		if encryptionConfig.Provider != nil && encryptionConfig.Provider.KeyArn != nil {
+			config.EncryptionKey = *encryptionConfig.Provider.KeyArn
+		}
+	}
+	if cluster.Logging != nil {
+		for _, logSetup := range cluster.Logging.ClusterLogging {
+			if logSetup.Enabled == nil || !*logSetup.Enabled {
+				continue
+			}
+			for _, logType := range logSetup.Types {
+				if logType == ekstypes.LogTypeAudit {
+					config.AuditLogging = boolPtr(true)
+				}
+			}
+		}
+	}
+	return config, nil
+}
+
+// NewControlPlaneConfigAKS returns the control plane configuration of the AKS managed cluster: the API server access profile,
+// the local accounts, the RBAC and the Azure Key Vault KMS encryption of the secrets.
+// The audit logs are diagnostic settings of the cluster resource and are not part of the describe
+func NewControlPlaneConfigAKS(managedCluster *armcontainerservice.ManagedCluster) (*k8sinterface.ControlPlaneConfig, error) {
+	exposure, err := newControlPlaneNetworkExposureAKS(managedCluster)
+	if err != nil {
+		return nil, err
+	}
+	properties := managedCluster.Properties
+	config := &k8sinterface.ControlPlaneConfig{
+		Distribution:      k8sinterface.DistributionAKS,
+		Managed:           true,
+		LocalAccounts:     boolPtr(properties.DisableLocalAccounts == nil || !*properties.DisableLocalAccounts),
+		SecretsEncryption: boolPtr(false),
+	}
+	setNetworkExposure(config, exposure)
+	if properties.EnableRBAC != nil {
+		if *properties.EnableRBAC {
+			config.AuthorizationModes = []string{authorizationNode, authorizationRBAC}
+			if properties.AADProfile != nil && properties.AADProfile.EnableAzureRBAC != nil && *properties.AADProfile.EnableAzureRBAC {
+				config.AuthorizationModes = append(config.AuthorizationModes, authorizationWebhook)
+			}
+		} else {
+			config.AuthorizationModes = []string{authorizationAllow}
+		}
+	}
+	if securityProfile := properties.SecurityProfile; securityProfile != nil && securityProfile.AzureKeyVaultKms != nil {
+		kms := securityProfile.AzureKeyVaultKms
+		config.SecretsEncryption = boolPtr(kms.Enabled != nil && *kms.Enabled)
+		if *config.SecretsEncryption && kms.KeyID != nil {
+			config.EncryptionKey = *kms.KeyID
+		}
+	}
+	return config, nil
+}
+
+// NewControlPlaneConfigGKE returns the control plane configuration of the GKE cluster: the private endpoint, the master authorized networks,
+// the basic auth and client certificates, the legacy ABAC and the application-layer secrets encryption.
+// The admin activity audit logs of GKE are always enabled
+func NewControlPlaneConfigGKE(cluster *containerpb.Cluster) (*k8sinterface.ControlPlaneConfig, error) {
+	exposure, err := newControlPlaneNetworkExposureGKE(cluster)
+	if err != nil {
+		return nil, err
+	}
+	masterAuth := cluster.GetMasterAuth()
+	config := &k8sinterface.ControlPlaneConfig{
+		Distribution:       k8sinterface.DistributionGKE,
+		Managed:            true,
+		LocalAccounts:      boolPtr(masterAuth.GetUsername() != "" || masterAuth.GetClientCertificateConfig().GetIssueClientCertificate()),
+		AuthorizationModes: []string{authorizationNode, authorizationRBAC},
+		AuditLogging:       boolPtr(true),
+		SecretsEncryption:  boolPtr(cluster.GetDatabaseEncryption().GetState() == containerpb.DatabaseEncryption_ENCRYPTED),
+	}
+	if cluster.GetLegacyAbac().GetEnabled() {
+		config.AuthorizationModes = append(config.AuthorizationModes, authorizationABAC)
+	}
+	setNetworkExposure(config, exposure)
+	if *config.SecretsEncryption {
+		config.EncryptionKey = cluster.GetDatabaseEncryption().GetKeyName()
+	}
+	return config, nil
+}
+
+func setNetworkExposure(config *k8sinterface.ControlPlaneConfig, exposure *ControlPlaneNetworkExposure) {
+	config.PublicEndpoint = boolPtr(exposure.PublicEndpoint)
+	config.PrivateEndpoint = boolPtr(exposure.PrivateEndpoint)
+	config.AuthorizedNetworks = authorizedNetworks(exposure.AllowedCIDRs)
+}
+
+// authorizedNetworks returns the CIDRs, nil if any network is allowed
+func authorizedNetworks(cidrs []string) []string {
+	if len(cidrs) == 0 || contains(cidrs, anyNetwork) {
+		return nil
+	}
+	return cidrs
+}
+
+func contains(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	containerpb "google.golang.org/genproto/googleapis/container/v1"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestGetControlPlaneConfigEKS(t *testing.T) {
+	config, err := GetControlPlaneConfigEKS(NewEKSSupportMock(), "", "")
+	require.NoError(t, err)
+	assert.Equal(t, k8sinterface.DistributionEKS, config.Distribution)
+	assert.True(t, config.Managed)
+	assert.True(t, *config.PublicEndpoint)
+	assert.True(t, *config.PrivateEndpoint)
+	assert.Nil(t, config.AuthorizedNetworks, "0.0.0.0/0 allows any network")
+	assert.False(t, *config.AuditLogging)
+	assert.False(t, *config.SecretsEncryption)
+
+	config, err = NewControlPlaneConfigEKS(&eks.DescribeClusterOutput{Cluster: &ekstypes.Cluster{
+		ResourcesVpcConfig: &ekstypes.VpcConfigResponse{EndpointPublicAccess: true, PublicAccessCidrs: []string{"203.0.113.0/24"}},
+		EncryptionConfig:   []ekstypes.EncryptionConfig{{Resources: []string{"secrets"}, Provider: &ekstypes.Provider{KeyArn: strPtr("arn:aws:kms:eu-west-1:123456789012:key/abc")}}},
+		Logging:            &ekstypes.Logging{ClusterLogging: []ekstypes.LogSetup{{Enabled: boolPtr(true), Types: []ekstypes.LogType{ekstypes.LogTypeApi, ekstypes.LogTypeAudit}}}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"203.0.113.0/24"}, config.AuthorizedNetworks)
+	assert.True(t, *config.SecretsEncryption)
+	assert.Equal(t, "arn:aws:kms:eu-west-1:123456789012:key/abc", config.EncryptionKey)
+	assert.True(t, *config.AuditLogging)
+
+	_, err = NewControlPlaneConfigEKS(&eks.DescribeClusterOutput{})
+	assert.Error(t, err)
+}
+
+func TestGetControlPlaneConfigAKS(t *testing.T) {
+	config, err := GetControlPlaneConfigAKS(NewAKSSupportMock(), "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, k8sinterface.DistributionAKS, config.Distribution)
+	assert.True(t, *config.PublicEndpoint)
+	assert.True(t, *config.LocalAccounts)
+	assert.Equal(t, []string{"Node", "RBAC"}, config.AuthorizationModes)
+	assert.Nil(t, config.AuditLogging)
+
+	config, err = NewControlPlaneConfigAKS(&armcontainerservice.ManagedCluster{Properties: &armcontainerservice.ManagedClusterProperties{
+		APIServerAccessProfile: &armcontainerservice.ManagedClusterAPIServerAccessProfile{EnablePrivateCluster: boolPtr(true), AuthorizedIPRanges: []*string{strPtr("10.0.0.0/8")}},
+		DisableLocalAccounts:   boolPtr(true),
+		EnableRBAC:             boolPtr(false),
+		SecurityProfile:        &armcontainerservice.ManagedClusterSecurityProfile{AzureKeyVaultKms: &armcontainerservice.AzureKeyVaultKms{Enabled: boolPtr(true), KeyID: strPtr("https://vault.vault.azure.net/keys/k8s/1")}},
+	}})
+	require.NoError(t, err)
+	assert.False(t, *config.PublicEndpoint)
+	assert.True(t, *config.PrivateEndpoint)
+	assert.Equal(t, []string{"10.0.0.0/8"}, config.AuthorizedNetworks)
+	assert.False(t, *config.LocalAccounts)
+	assert.Equal(t, []string{"AlwaysAllow"}, config.AuthorizationModes)
+	assert.True(t, *config.SecretsEncryption)
+	assert.Equal(t, "https://vault.vault.azure.net/keys/k8s/1", config.EncryptionKey)
+}
+
+func TestGetControlPlaneConfigGKE(t *testing.T) {
+	config, err := GetControlPlaneConfigGKE(NewGKESupportMock(), "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, k8sinterface.DistributionGKE, config.Distribution)
+	assert.True(t, *config.PublicEndpoint)
+	assert.Nil(t, config.AuthorizedNetworks)
+	assert.False(t, *config.LocalAccounts)
+	assert.False(t, *config.SecretsEncryption, "state 2 is DECRYPTED")
+
+	config, err = NewControlPlaneConfigGKE(&containerpb.Cluster{
+		PrivateClusterConfig: &containerpb.PrivateClusterConfig{EnablePrivateEndpoint: true},
+		MasterAuthorizedNetworksConfig: &containerpb.MasterAuthorizedNetworksConfig{
+			Enabled:    true,
+			CidrBlocks: []*containerpb.MasterAuthorizedNetworksConfig_CidrBlock{{CidrBlock: "192.168.0.0/16"}},
+		},
+		MasterAuth:         &containerpb.MasterAuth{ClientCertificateConfig: &containerpb.ClientCertificateConfig{IssueClientCertificate: true}},
+		LegacyAbac:         &containerpb.LegacyAbac{Enabled: true},
+		DatabaseEncryption: &containerpb.DatabaseEncryption{State: containerpb.DatabaseEncryption_ENCRYPTED, KeyName: "projects/p/locations/l/keyRings/r/cryptoKeys/k"},
+	})
+	require.NoError(t, err)
+	assert.False(t, *config.PublicEndpoint)
+	assert.Equal(t, []string{"192.168.0.0/16"}, config.AuthorizedNetworks)
+	assert.True(t, *config.LocalAccounts)
+	assert.Equal(t, []string{"Node", "RBAC", "ABAC"}, config.AuthorizationModes)
+	assert.True(t, *config.SecretsEncryption)
+	assert.Equal(t, "projects/p/locations/l/keyRings/r/cryptoKeys/k", config.EncryptionKey)
+}
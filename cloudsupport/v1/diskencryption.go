@@ -0,0 +1,101 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/kubescape/k8s-interface/tracing"
+	computepb "google.golang.org/genproto/googleapis/cloud/compute/v1"
+)
+
+// DiskEncryption is the encryption at rest of the cloud disk backing a persistent volume, see k8sinterface.CloudDisk for the disk of a volume
+type DiskEncryption struct {
+	Provider string `json:"provider"`
+	DiskID   string `json:"diskID"`
+	// Encrypted is always true for Azure and GCP, which encrypt the disks with platform keys by default
+	Encrypted bool `json:"encrypted"`
+	// CustomerManagedKey is true if the disk is encrypted with a key of the customer: a customer managed KMS key (AWS), a disk encryption set (Azure),
+	// a Cloud KMS key or a customer-supplied key (GCP)
+	CustomerManagedKey bool `json:"customerManagedKey"`
+	// KeyID is the KMS key ARN (AWS), the disk encryption set ID (Azure) or the Cloud KMS key version (GCP)
+	KeyID string `json:"keyID,omitempty"`
+	// Type is the Azure encryption type, e.g. EncryptionAtRestWithPlatformKey
+	Type string `json:"type,omitempty"`
+	// Error is set if the key could not be described, e.g. missing permissions on the KMS key
+	Error string `json:"error,omitempty"`
+}
+
+// azureDisk is the part of an Azure managed disk (Microsoft.Compute/disks) with its encryption
+type azureDisk struct {
+	ID         string `json:"id"`
+	Properties struct {
+		Encryption *struct {
+			Type                string `json:"type"`
+			DiskEncryptionSetID string `json:"diskEncryptionSetId"`
+		} `json:"encryption"`
+	} `json:"properties"`
+}
+
+const azureEncryptionAtRestWithPlatformKey = "EncryptionAtRestWithPlatformKey"
+
+func newDiskEncryptionEKS(volume *ec2types.Volume) *DiskEncryption {
+	encryption := &DiskEncryption{
+		Provider: tracing.CloudProviderAWS,
+		DiskID:   stringValue(volume.VolumeId),
+	}
+	if volume.Encrypted != nil {
+		encryption.Encrypted = *volume.Encrypted
+	}
+	encryption.KeyID = stringValue(volume.KmsKeyId)
+	return encryption
+}
+
+func newDiskEncryptionAKS(disk *azureDisk) *DiskEncryption {
+	encryption := &DiskEncryption{
+		Provider:  tracing.CloudProviderAzure,
+		DiskID:    disk.ID,
+		Encrypted: true,
+		Type:      azureEncryptionAtRestWithPlatformKey,
+	}
+	if disk.Properties.Encryption != nil {
+		if disk.Properties.Encryption.Type != "" {
+			encryption.Type = disk.Properties.Encryption.Type
+		}
+		encryption.KeyID = disk.Properties.Encryption.DiskEncryptionSetID
+	}
+	encryption.CustomerManagedKey = encryption.Type != azureEncryptionAtRestWithPlatformKey
+	return encryption
+}
+
+func newDiskEncryptionGKE(disk *computepb.Disk) *DiskEncryption {
+	key := disk.GetDiskEncryptionKey()
+	return &DiskEncryption{
+		Provider:           tracing.CloudProviderGCP,
+		DiskID:             disk.GetSelfLink(),
+		Encrypted:          true,
+		CustomerManagedKey: key.GetKmsKeyName() != "" || key.GetSha256() != "",
+		KeyID:              key.GetKmsKeyName(),
+	}
+}
+
+// parseGCPDiskID returns the project, the zone or the region (one is empty) and the name of the disk of a projects/<project>/(zones|regions)/<location>/disks/<name> ID.
+// The project is optional, the default project is used for zones/<zone>/disks/<name> IDs
+func parseGCPDiskID(diskID, defaultProject string) (string, string, string, string, error) {
+	parts := strings.Split(strings.Trim(diskID, "/"), "/")
+	project := defaultProject
+	if len(parts) == 6 && parts[0] == "projects" {
+		project = parts[1]
+		parts = parts[2:]
+	}
+	if len(parts) != 4 || parts[2] != "disks" || project == "" {
+		return "", "", "", "", fmt.Errorf("invalid GCP disk ID '%s'", diskID)
+	}
+	switch parts[0] {
+	case "zones":
+		return project, parts[1], "", parts[3], nil
+	case "regions":
+		return project, "", parts[1], parts[3], nil
+	}
+	return "", "", "", "", fmt.Errorf("invalid GCP disk ID '%s'", diskID)
+}
@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/kubescape/k8s-interface/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	computepb "google.golang.org/genproto/googleapis/cloud/compute/v1"
+)
+
+func TestNewDiskEncryption(t *testing.T) {
+	assert.Equal(t, &DiskEncryption{Provider: tracing.CloudProviderAWS, DiskID: "vol-1"}, newDiskEncryptionEKS(&ec2types.Volume{VolumeId: strPtr("vol-1"), Encrypted: boolPtr(false)}))
+	assert.Equal(t, &DiskEncryption{Provider: tracing.CloudProviderAWS, DiskID: "vol-2", Encrypted: true, KeyID: "arn:aws:kms:eu-west-1:123456789012:key/abc"},
+		newDiskEncryptionEKS(&ec2types.Volume{VolumeId: strPtr("vol-2"), Encrypted: boolPtr(true), KmsKeyId: strPtr("arn:aws:kms:eu-west-1:123456789012:key/abc")}))
+
+	disk := &azureDisk{}
+	require.NoError(t, json.Unmarshal([]byte(`{"id": "/subscriptions/sub/resourceGroups/mc_rg/providers/Microsoft.Compute/disks/pvc-1", "properties": {
+		"encryption": {"type": "EncryptionAtRestWithCustomerKey", "diskEncryptionSetId": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/diskEncryptionSets/des"}}}`), disk))
+	assert.Equal(t, &DiskEncryption{
+		Provider:           tracing.CloudProviderAzure,
+		DiskID:             "/subscriptions/sub/resourceGroups/mc_rg/providers/Microsoft.Compute/disks/pvc-1",
+		Encrypted:          true,
+		CustomerManagedKey: true,
+		KeyID:              "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/diskEncryptionSets/des",
+		Type:               "EncryptionAtRestWithCustomerKey",
+	}, newDiskEncryptionAKS(disk))
+	encryption := newDiskEncryptionAKS(&azureDisk{ID: "disk"})
+	assert.True(t, encryption.Encrypted)
+	assert.False(t, encryption.CustomerManagedKey)
+	assert.Equal(t, "EncryptionAtRestWithPlatformKey", encryption.Type)
+
+	encryption = newDiskEncryptionGKE(&computepb.Disk{DiskEncryptionKey: &computepb.CustomerEncryptionKey{KmsKeyName: strPtr("projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1")}})
+	assert.True(t, encryption.Encrypted)
+	assert.True(t, encryption.CustomerManagedKey)
+	assert.Equal(t, "projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1", encryption.KeyID)
+	assert.False(t, newDiskEncryptionGKE(&computepb.Disk{}).CustomerManagedKey)
+}
+
+func TestParseGCPDiskID(t *testing.T) {
+	project, zone, region, name, err := parseGCPDiskID("projects/my-project/zones/europe-west1-b/disks/pvc-1", "default")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"my-project", "europe-west1-b", "", "pvc-1"}, []string{project, zone, region, name})
+
+	project, zone, region, name, err = parseGCPDiskID("regions/europe-west1/disks/pvc-2", "default")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "", "europe-west1", "pvc-2"}, []string{project, zone, region, name})
+
+	_, _, _, _, err = parseGCPDiskID("zones/europe-west1-b/disks/pvc-1", "")
+	assert.Error(t, err, "no project")
+	_, _, _, _, err = parseGCPDiskID("projects/my-project/global/images/image", "")
+	assert.Error(t, err)
+}
+
+func TestGetDiskEncryptionMocks(t *testing.T) {
+	encryption, err := NewEKSSupportMock().GetDiskEncryption(context.Background(), "eu-west-1", "vol-1")
+	require.NoError(t, err)
+	assert.True(t, encryption.Encrypted)
+	assert.True(t, encryption.CustomerManagedKey)
+
+	encryption, err = NewAKSSupportMock().GetDiskEncryption(context.Background(), "/subscriptions/sub/resourceGroups/mc_rg/providers/Microsoft.Compute/disks/pvc-1")
+	require.NoError(t, err)
+	assert.Equal(t, tracing.CloudProviderAzure, encryption.Provider)
+	assert.False(t, encryption.CustomerManagedKey)
+
+	encryption, err = NewGKESupportMock().GetDiskEncryption(context.Background(), "my-project", "zones/europe-west1-b/disks/pvc-1")
+	require.NoError(t, err)
+	assert.Equal(t, "https://www.googleapis.com/compute/v1/projects/my-project/zones/europe-west1-b/disks/pvc-1", encryption.DiskID)
+}
@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+// GetDiskEncryption returns the encryption of the EBS volume. The KMS key of an encrypted volume is described to tell the AWS managed aws/ebs key from a customer managed key
+func (eksSupport *EKSSupport) GetDiskEncryption(ctx context.Context, region string, volumeID string) (_ *DiskEncryption, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ec2.GetDiskEncryption", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	awsConfig, err := eksSupport.options.loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error: fail to load AWS SDK default %v", err)
+	}
+	awsConfig.Region = region
+
+	var volumes *ec2.DescribeVolumesOutput
+	err = metrics.ObserveCall(metrics.SourceAWS, "ec2.DescribeVolumes", isThrottlingError, func() error {
+		var err error
+		volumes, err = ec2.NewFromConfig(awsConfig).DescribeVolumes(ctx, &ec2.DescribeVolumesInput{VolumeIds: []string{volumeID}})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(volumes.Volumes) == 0 {
+		return nil, fmt.Errorf("EBS volume '%s' not found", volumeID)
+	}
+	encryption := newDiskEncryptionEKS(&volumes.Volumes[0])
+	if encryption.KeyID == "" {
+		return encryption, nil
+	}
+
+	var describeKey *kms.DescribeKeyOutput
+	err = metrics.ObserveCall(metrics.SourceAWS, "kms.DescribeKey", isThrottlingError, func() error {
+		var err error
+		describeKey, err = kms.NewFromConfig(awsConfig).DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(encryption.KeyID)})
+		return err
+	})
+	if err != nil {
+		encryption.Error = err.Error()
+		return encryption, nil
+	}
+	encryption.CustomerManagedKey = describeKey.KeyMetadata != nil && describeKey.KeyMetadata.KeyManager == kmstypes.KeyManagerTypeCustomer
+	return encryption, nil
+}
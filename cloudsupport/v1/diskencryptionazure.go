@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+
+	armruntime "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+// azureDiskAPIVersion is the version of the Microsoft.Compute API the disks are fetched with
+const azureDiskAPIVersion = "2022-07-02"
+
+// GetDiskEncryption returns the encryption of the managed disk with the resource ID (/subscriptions/<subscription>/resourceGroups/<resource group>/providers/Microsoft.Compute/disks/<name>).
+// The disk is fetched through the Azure Resource Manager API, there is no compute client in the dependencies of the module
+func (AKSSupport *AKSSupport) GetDiskEncryption(ctx context.Context, diskID string) (_ *DiskEncryption, err error) {
+	ctx, span := tracing.StartSpan(ctx, "compute.GetDiskEncryption", tracing.AttributeCloudProvider.String(tracing.CloudProviderAzure))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	cred, err := AKSSupport.options.newAzureCredential()
+	if err != nil {
+		return nil, err
+	}
+	pipeline, err := armruntime.NewPipeline("cloudsupport", "v1", cred, runtime.PipelineOptions{}, AKSSupport.options.armClientOptions())
+	if err != nil {
+		return nil, err
+	}
+	request, err := runtime.NewRequest(ctx, http.MethodGet, runtime.JoinPaths(AKSSupport.options.environment.azureResourceManager().Endpoint, diskID))
+	if err != nil {
+		return nil, err
+	}
+	query := request.Raw().URL.Query()
+	query.Set("api-version", azureDiskAPIVersion)
+	request.Raw().URL.RawQuery = query.Encode()
+
+	disk := &azureDisk{}
+	err = metrics.ObserveCall(metrics.SourceAzure, "compute.Disks.Get", isThrottlingError, func() error {
+		response, err := pipeline.Do(request)
+		if err != nil {
+			return err
+		}
+		if !runtime.HasStatusCode(response, http.StatusOK) {
+			return runtime.NewResponseError(response)
+		}
+		return runtime.UnmarshalAsJSON(response, disk)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newDiskEncryptionAKS(disk), nil
+}
@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"context"
+
+	compute "cloud.google.com/go/compute/apiv1"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+	computepb "google.golang.org/genproto/googleapis/cloud/compute/v1"
+)
+
+// GetDiskEncryption returns the encryption of the zonal or regional persistent disk with the ID projects/<project>/(zones|regions)/<location>/disks/<name>.
+// The project is the project of disk IDs without project, e.g. the in-tree volumes
+func (gkeSupport *GKESupport) GetDiskEncryption(ctx context.Context, project string, diskID string) (_ *DiskEncryption, err error) {
+	ctx, span := tracing.StartSpan(ctx, "compute.GetDiskEncryption", tracing.AttributeCloudProvider.String(tracing.CloudProviderGCP))
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx = gkeSupport.options.gcpContext(ctx)
+	clientOptions, err := gkeSupport.options.gcpRESTClientOptions(ctx, GCPServiceCompute)
+	if err != nil {
+		return nil, err
+	}
+
+	project, zone, region, name, err := parseGCPDiskID(diskID, project)
+	if err != nil {
+		return nil, err
+	}
+
+	var disk *computepb.Disk
+	if zone != "" {
+		client, err := compute.NewDisksRESTClient(ctx, clientOptions...)
+		if err != nil {
+			return nil, err
+		}
+		defer client.Close()
+		err = metrics.ObserveCall(metrics.SourceGCP, "compute.Disks.Get", isThrottlingError, func() error {
+			var err error
+			disk, err = client.Get(ctx, &computepb.GetDiskRequest{Project: project, Zone: zone, Disk: name})
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return newDiskEncryptionGKE(disk), nil
+	}
+
+	client, err := compute.NewRegionDisksRESTClient(ctx, clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	err = metrics.ObserveCall(metrics.SourceGCP, "compute.RegionDisks.Get", isThrottlingError, func() error {
+		var err error
+		disk, err = client.Get(ctx, &computepb.GetRegionDiskRequest{Project: project, Region: region, Disk: name})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newDiskEncryptionGKE(disk), nil
+}
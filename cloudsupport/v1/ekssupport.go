@@ -2,6 +2,7 @@ package v1
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -16,10 +17,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	//"github.com/aws/aws-sdk-go-v2/aws/session"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ecr"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/logging"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
 )
 
 type IEKSSupport interface {
@@ -32,7 +35,52 @@ type IEKSSupport interface {
 	GetPolicyVersion(region string) (*ListPolicyVersion, error)
 }
 
+// The optional capabilities of the EKS support, implemented by EKSSupport (the registry tokens by IRegistryTokenProvider).
+// They are not part of IEKSSupport so its implementations do not break, check for them with a type assertion
+type (
+	IEKSLoadBalancerSupport interface {
+		GetLoadBalancer(ctx context.Context, region string, address string) (*LoadBalancer, error)
+	}
+	IEKSSecretsEncryptionSupport interface {
+		GetSecretsEncryption(ctx context.Context, cluster string, region string) (*SecretsEncryption, error)
+	}
+	IEKSDiskEncryptionSupport interface {
+		GetDiskEncryption(ctx context.Context, region string, volumeID string) (*DiskEncryption, error)
+	}
+	IEKSNetworkExposureSupport interface {
+		GetControlPlaneNetworkExposure(ctx context.Context, cluster string, region string) (*ControlPlaneNetworkExposure, error)
+	}
+	IEKSVersionSupport interface {
+		GetVersionSupport(ctx context.Context, cluster string, region string) (*VersionSupport, error)
+	}
+	IEKSNodePoolsSupport interface {
+		GetNodePools(ctx context.Context, cluster string, region string) ([]NodePool, error)
+	}
+	IEKSWorkloadIdentitySupport interface {
+		GetWorkloadIdentities(ctx context.Context, kapi *k8sinterface.KubernetesApi, cluster string, region string) ([]WorkloadIdentity, error)
+	}
+	IEKSCloudPermissionsSupport interface {
+		PreflightCloudPermissions(ctx context.Context, region string) (*CloudPermissionsReport, error)
+	}
+)
+
+var (
+	_ IEKSSupport                  = &EKSSupport{}
+	_ IRegistryTokenProvider       = &EKSSupport{}
+	_ IEKSLoadBalancerSupport      = &EKSSupport{}
+	_ IEKSSecretsEncryptionSupport = &EKSSupport{}
+	_ IEKSDiskEncryptionSupport    = &EKSSupport{}
+	_ IEKSNetworkExposureSupport   = &EKSSupport{}
+	_ IEKSVersionSupport           = &EKSSupport{}
+	_ IEKSNodePoolsSupport         = &EKSSupport{}
+	_ IEKSWorkloadIdentitySupport  = &EKSSupport{}
+	_ IEKSCloudPermissionsSupport  = &EKSSupport{}
+)
+
 type EKSSupport struct {
+	logger  logging.Logger
+	ctx     context.Context
+	options cloudSupportOptions
 }
 
 const (
@@ -80,14 +128,40 @@ type ListPolicyVersion struct {
 }
 
 // NewEKSSupport returns EKSSupport type
-func NewEKSSupport() *EKSSupport {
-	return &EKSSupport{}
+func NewEKSSupport(opts ...CloudSupportOption) *EKSSupport {
+	return &EKSSupport{options: newCloudSupportOptions(opts)}
+}
+
+// SetLogger sets the logger of the EKS support, the logging package default logger is used if not set
+func (eksSupport *EKSSupport) SetLogger(logger logging.Logger) {
+	eksSupport.logger = logger
+}
+
+// GetLogger returns the logger of the EKS support
+func (eksSupport *EKSSupport) GetLogger() logging.Logger {
+	return logging.OrDefault(eksSupport.logger)
+}
+
+// SetContext sets the context of the EKS calls that do not take one, so they are traced as children of the span of the context and are canceled with it
+func (eksSupport *EKSSupport) SetContext(ctx context.Context) {
+	eksSupport.ctx = ctx
+}
+
+// GetContext returns the context of the EKS calls, context.Background() if not set
+func (eksSupport *EKSSupport) GetContext() context.Context {
+	if eksSupport.ctx == nil {
+		return context.Background()
+	}
+	return eksSupport.ctx
 }
 
 // GetClusterDescribe returns the descriptive info about the cluster running in EKS.
-func (eksSupport *EKSSupport) GetClusterDescribe(cluster string, region string) (*eks.DescribeClusterOutput, error) {
+func (eksSupport *EKSSupport) GetClusterDescribe(cluster string, region string) (_ *eks.DescribeClusterOutput, err error) {
+	ctx, span := tracing.StartSpan(eksSupport.GetContext(), "eks.DescribeCluster", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	// Configure cluster name and region for request
-	awsConfig, err := config.LoadDefaultConfig(context.TODO())
+	awsConfig, err := eksSupport.options.loadAWSConfig(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error: fail to load AWS SDK default %v", err)
 	}
@@ -97,7 +171,12 @@ func (eksSupport *EKSSupport) GetClusterDescribe(cluster string, region string)
 		Name: aws.String(cluster),
 	}
 
-	result, err := svc.DescribeCluster(context.TODO(), input)
+	var result *eks.DescribeClusterOutput
+	err = metrics.ObserveCall(metrics.SourceAWS, "eks.DescribeCluster", isThrottlingError, func() error {
+		var err error
+		result, err = svc.DescribeCluster(ctx, input)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -205,9 +284,12 @@ func (EKSSupport *EKSSupport) GetEKSCfgMap(kapi *k8sinterface.KubernetesApi, nam
 }
 
 // GetDescribeRepositories returns the descriptive info about the repositories in EKS.
-func (eksSupport *EKSSupport) GetDescribeRepositories(region string) (*ecr.DescribeRepositoriesOutput, error) {
+func (eksSupport *EKSSupport) GetDescribeRepositories(region string) (_ *ecr.DescribeRepositoriesOutput, err error) {
+	ctx, span := tracing.StartSpan(eksSupport.GetContext(), "ecr.DescribeRepositories", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	// Configure region for request
-	awsConfig, err := config.LoadDefaultConfig(context.TODO())
+	awsConfig, err := eksSupport.options.loadAWSConfig(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error: fail to load AWS SDK default %v", err)
 	}
@@ -217,24 +299,78 @@ func (eksSupport *EKSSupport) GetDescribeRepositories(region string) (*ecr.Descr
 		MaxResults: aws.Int32(100),
 	}
 
-	result, err := svc.DescribeRepositories(context.TODO(), input)
+	result, err := svc.DescribeRepositories(ctx, input)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
 
+// GetRegistryToken returns a token for the ECR registry (<account>.dkr.ecr.<region>.amazonaws.com) using the default AWS credentials.
+// ECR tokens are valid for 12 hours
+func (eksSupport *EKSSupport) GetRegistryToken(ctx context.Context, registryHost string) (_ *RegistryToken, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ecr.GetRegistryToken", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeRegistry.String(registryHost))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	accountID, region, err := parseECRRegistryHost(registryHost)
+	if err != nil {
+		return nil, err
+	}
+	awsConfig, err := eksSupport.options.loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error: fail to load AWS SDK default %v", err)
+	}
+	awsConfig.Region = region
+	svc := ecr.NewFromConfig(awsConfig)
+	var result *ecr.GetAuthorizationTokenOutput
+	err = metrics.ObserveCall(metrics.SourceAWS, "ecr.GetAuthorizationToken", isThrottlingError, func() error {
+		var err error
+		result, err = svc.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{
+			RegistryIds: []string{accountID},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ECR authorization token: %w", err)
+	}
+	if len(result.AuthorizationData) == 0 || result.AuthorizationData[0].AuthorizationToken == nil {
+		return nil, fmt.Errorf("ECR returned no authorization data for registry '%s'", registryHost)
+	}
+	authorizationData := result.AuthorizationData[0]
+	decoded, err := base64.StdEncoding.DecodeString(*authorizationData.AuthorizationToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ECR authorization token: %w", err)
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid ECR authorization token")
+	}
+	token := &RegistryToken{
+		Registry: registryHost,
+		Username: username,
+		Password: password,
+	}
+	if authorizationData.ExpiresAt != nil {
+		token.ExpiresAt = *authorizationData.ExpiresAt
+	}
+	eksSupport.GetLogger().Debug("minted registry token", "registry", registryHost, "expiresAt", token.ExpiresAt)
+	return token, nil
+}
+
 // GetListEntitiesForPolicies returns the list of roles in EKS.
-func (eksSupport *EKSSupport) GetListEntitiesForPolicies(region string) (*ListEntitiesForPolicies, error) {
+func (eksSupport *EKSSupport) GetListEntitiesForPolicies(region string) (_ *ListEntitiesForPolicies, err error) {
+	ctx, span := tracing.StartSpan(eksSupport.GetContext(), "iam.ListEntitiesForPolicies", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	// Configure region for request
-	awsConfig, err := config.LoadDefaultConfig(context.TODO())
+	awsConfig, err := eksSupport.options.loadAWSConfig(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error: fail to load AWS SDK default %v", err)
 	}
 	svc := iam.NewFromConfig(awsConfig)
 	input := &iam.ListPoliciesInput{}
 
-	result, err := listPoliciesWithPagination(svc, input)
+	result, err := listPoliciesWithPagination(ctx, svc, input)
 	if err != nil {
 		return nil, err
 	}
@@ -243,7 +379,7 @@ func (eksSupport *EKSSupport) GetListEntitiesForPolicies(region string) (*ListEn
 		inp := &iam.ListEntitiesForPolicyInput{
 			PolicyArn: policy.Arn,
 		}
-		entitiesForPolicy, err := svc.ListEntitiesForPolicy(context.TODO(), inp)
+		entitiesForPolicy, err := svc.ListEntitiesForPolicy(ctx, inp)
 		if err != nil {
 			return nil, err
 		}
@@ -254,8 +390,11 @@ func (eksSupport *EKSSupport) GetListEntitiesForPolicies(region string) (*ListEn
 
 // GetPolicyVersion retrieves policy contents based on their default version.
 // It returns a struct that contains a map where the key is the policy Arn, and the value is its content.
-func (eksSupport *EKSSupport) GetPolicyVersion(region string) (*ListPolicyVersion, error) {
-	awsConfig, err := config.LoadDefaultConfig(context.TODO())
+func (eksSupport *EKSSupport) GetPolicyVersion(region string) (_ *ListPolicyVersion, err error) {
+	ctx, span := tracing.StartSpan(eksSupport.GetContext(), "iam.GetPolicyVersions", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	awsConfig, err := eksSupport.options.loadAWSConfig(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error: fail to load AWS SDK default %v", err)
 	}
@@ -265,7 +404,7 @@ func (eksSupport *EKSSupport) GetPolicyVersion(region string) (*ListPolicyVersio
 	// retrieve the list of policies currently used on aws.
 	// cmd example: `aws iam list-policies`
 	input := &iam.ListPoliciesInput{}
-	result, err := listPoliciesWithPagination(svc, input)
+	result, err := listPoliciesWithPagination(ctx, svc, input)
 	if err != nil {
 		return nil, fmt.Errorf("error: fail to list policies: %v", err)
 	}
@@ -282,7 +421,7 @@ func (eksSupport *EKSSupport) GetPolicyVersion(region string) (*ListPolicyVersio
 			PolicyArn: policy.Arn,
 			VersionId: policy.DefaultVersionId,
 		}
-		policyVersionContent, err := svc.GetPolicyVersion(context.TODO(), policyVersionInput)
+		policyVersionContent, err := svc.GetPolicyVersion(ctx, policyVersionInput)
 		if err != nil {
 			return nil, fmt.Errorf("error: fail to get policy version: %v", err)
 		}
@@ -303,12 +442,12 @@ func (eksSupport *EKSSupport) GetPolicyVersion(region string) (*ListPolicyVersio
 // listPoliciesWithPagination iterate over the aws policies.
 // It return the list of the whole policies on aws in case of success.
 // Return an error otherwise.
-func listPoliciesWithPagination(svc *iam.Client, input *iam.ListPoliciesInput) ([]types.Policy, error) {
+func listPoliciesWithPagination(ctx context.Context, svc *iam.Client, input *iam.ListPoliciesInput) ([]types.Policy, error) {
 	paginator := iam.NewListPoliciesPaginator(svc, input)
 
 	var policiesList []types.Policy
 	for paginator.HasMorePages() {
-		output, err := paginator.NextPage(context.TODO())
+		output, err := paginator.NextPage(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("error: fail to list policies: %v", err)
 		}
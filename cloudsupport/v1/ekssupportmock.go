@@ -1,13 +1,18 @@
 package v1
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
+	"time"
 
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"github.com/aws/aws-sdk-go-v2/service/ecr"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
 	"github.com/kubescape/k8s-interface/cloudsupport/mockobjects"
 	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/tracing"
 )
 
 func NewEKSSupportMock() *EKSSupportMock {
@@ -75,3 +80,77 @@ func (eksSupport *EKSSupportMock) GetContextName(cluster string) string {
 	}
 	return ""
 }
+
+func (eksSupportM *EKSSupportMock) GetRegistryToken(ctx context.Context, registryHost string) (*RegistryToken, error) {
+	return &RegistryToken{
+		Registry:  registryHost,
+		Username:  "AWS",
+		Password:  "mock-ecr-token",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil
+}
+
+func (eksSupportM *EKSSupportMock) GetLoadBalancer(ctx context.Context, region string, address string) (*LoadBalancer, error) {
+	return &LoadBalancer{
+		Provider:       tracing.CloudProviderAWS,
+		Type:           LoadBalancerTypeAWSNetwork,
+		ID:             "arn:aws:elasticloadbalancing:" + region + ":015253967648:loadbalancer/net/a1b2c3d4/0123456789abcdef",
+		Name:           "a1b2c3d4",
+		Address:        address,
+		InternetFacing: true,
+	}, nil
+}
+
+func (eksSupportM *EKSSupportMock) GetSecretsEncryption(ctx context.Context, cluster string, region string) (*SecretsEncryption, error) {
+	clusterDescribe, err := eksSupportM.GetClusterDescribe(cluster, region)
+	if err != nil {
+		return nil, err
+	}
+	return newSecretsEncryptionEKS(clusterDescribe)
+}
+
+func (eksSupportM *EKSSupportMock) GetDiskEncryption(ctx context.Context, region string, volumeID string) (*DiskEncryption, error) {
+	encrypted, keyID := true, "arn:aws:kms:"+region+":015253967648:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	encryption := newDiskEncryptionEKS(&ec2types.Volume{VolumeId: &volumeID, Encrypted: &encrypted, KmsKeyId: &keyID})
+	encryption.CustomerManagedKey = true
+	return encryption, nil
+}
+
+func (eksSupportM *EKSSupportMock) GetControlPlaneNetworkExposure(ctx context.Context, cluster string, region string) (*ControlPlaneNetworkExposure, error) {
+	clusterDescribe, err := eksSupportM.GetClusterDescribe(cluster, region)
+	if err != nil {
+		return nil, err
+	}
+	return newControlPlaneNetworkExposureEKS(clusterDescribe)
+}
+
+func (eksSupportM *EKSSupportMock) GetVersionSupport(ctx context.Context, cluster string, region string) (*VersionSupport, error) {
+	clusterDescribe, err := eksSupportM.GetClusterDescribe(cluster, region)
+	if err != nil {
+		return nil, err
+	}
+	// a fixed date, so the mock does not depend on the release calendar being up to date
+	return newVersionSupportEKS(clusterDescribe, nil, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func (eksSupportM *EKSSupportMock) GetNodePools(ctx context.Context, cluster string, region string) ([]NodePool, error) {
+	nodegroupName, version, minSize, maxSize := "ng-default", "0.0", int32(1), int32(3)
+	return []NodePool{newNodePoolEKS(&ekstypes.Nodegroup{
+		NodegroupName: &nodegroupName,
+		Version:       &version,
+		CapacityType:  ekstypes.CapacityTypesOnDemand,
+		InstanceTypes: []string{"t3.medium"},
+		ScalingConfig: &ekstypes.NodegroupScalingConfig{MinSize: &minSize, MaxSize: &maxSize},
+	})}, nil
+}
+
+func (eksSupportM *EKSSupportMock) GetWorkloadIdentities(ctx context.Context, kapi *k8sinterface.KubernetesApi, cluster string, region string) ([]WorkloadIdentity, error) {
+	return []WorkloadIdentity{
+		{Type: WorkloadIdentityTypeIRSA, Namespace: "default", ServiceAccount: "irsa-sa", RoleARN: "arn:aws:iam::123456789012:role/irsa-role", OIDCProviderARN: "arn:aws:iam::123456789012:oidc-provider/oidc.eks.eu-north-1.amazonaws.com/id/EXAMPLE"},
+		{Type: WorkloadIdentityTypePodIdentity, Namespace: "default", ServiceAccount: "pod-identity-sa", RoleARN: "arn:aws:iam::123456789012:role/pod-identity-role", AssociationID: "a-example"},
+	}, nil
+}
+
+func (eksSupportM *EKSSupportMock) PreflightCloudPermissions(ctx context.Context, region string) (*CloudPermissionsReport, error) {
+	return newCloudPermissionsReport(tracing.CloudProviderAWS, "", region, EKSRequiredPermissions, func(string) bool { return true }), nil
+}
@@ -0,0 +1,296 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// the types of the EKS workload identities
+const (
+	// WorkloadIdentityTypeIRSA is an IAM role for service accounts: the role ARN annotation of the service account, assumed with the OIDC provider of the cluster
+	WorkloadIdentityTypeIRSA = "irsa"
+	// WorkloadIdentityTypePodIdentity is an EKS Pod Identity association of a service account
+	WorkloadIdentityTypePodIdentity = "podIdentity"
+)
+
+const (
+	irsaRoleARNAnnotation = "eks.amazonaws.com/role-arn"
+	// the SHA-256 of the empty payload of the signed requests
+	emptyPayloadSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+var podIdentityHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// WorkloadIdentity is an IAM role assumed by the pods of a service account
+type WorkloadIdentity struct {
+	// Type is the mechanism of the identity, see the WorkloadIdentityType constants
+	Type           string `json:"type"`
+	Namespace      string `json:"namespace"`
+	ServiceAccount string `json:"serviceAccount"`
+	RoleARN        string `json:"roleArn"`
+	// OIDCProviderARN is the ARN of the IAM OIDC provider of the cluster (IRSA), empty if the provider is not registered in IAM: the role cannot be assumed
+	OIDCProviderARN string `json:"oidcProviderArn,omitempty"`
+	// AssociationID is the ID of the EKS Pod Identity association
+	AssociationID string `json:"associationId,omitempty"`
+}
+
+// OIDCProvider is the OIDC provider of an EKS cluster
+type OIDCProvider struct {
+	IssuerURL string `json:"issuerUrl"`
+	// ARN is the ARN of the IAM OIDC provider of the issuer, empty if the issuer is not registered in IAM
+	ARN string `json:"arn,omitempty"`
+}
+
+type podIdentityAssociation struct {
+	AssociationID  string `json:"associationId"`
+	Namespace      string `json:"namespace"`
+	ServiceAccount string `json:"serviceAccount"`
+	RoleARN        string `json:"roleArn"`
+}
+
+// GetWorkloadIdentities returns the IAM roles of the service accounts of the cluster: the IRSA annotations of the service accounts and the EKS Pod Identity associations
+func (eksSupport *EKSSupport) GetWorkloadIdentities(ctx context.Context, kapi *k8sinterface.KubernetesApi, cluster string, region string) (_ []WorkloadIdentity, err error) {
+	ctx, span := tracing.StartSpan(ctx, "eks.GetWorkloadIdentities", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	identities, err := eksSupport.ListIRSAServiceAccounts(ctx, kapi, cluster, region)
+	if err != nil {
+		return nil, err
+	}
+	podIdentities, err := eksSupport.ListPodIdentityAssociations(ctx, cluster, region)
+	if err != nil {
+		return nil, err
+	}
+	return append(identities, podIdentities...), nil
+}
+
+// ListIRSAServiceAccounts returns the service accounts of the cluster annotated with an IAM role (IAM roles for service accounts)
+func (eksSupport *EKSSupport) ListIRSAServiceAccounts(ctx context.Context, kapi *k8sinterface.KubernetesApi, cluster string, region string) (_ []WorkloadIdentity, err error) {
+	ctx, span := tracing.StartSpan(ctx, "eks.ListIRSAServiceAccounts", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	serviceAccounts, err := kapi.KubernetesClient.CoreV1().ServiceAccounts("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the service accounts, reason: %s", err.Error())
+	}
+	oidcProvider, err := eksSupport.GetOIDCProvider(ctx, cluster, region)
+	if err != nil {
+		return nil, err
+	}
+	return irsaWorkloadIdentities(serviceAccounts.Items, oidcProvider.ARN), nil
+}
+
+// GetOIDCProvider returns the OIDC issuer of the cluster and the IAM OIDC provider registered for it
+func (eksSupport *EKSSupport) GetOIDCProvider(ctx context.Context, cluster string, region string) (_ *OIDCProvider, err error) {
+	ctx, span := tracing.StartSpan(ctx, "eks.GetOIDCProvider", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	awsConfig, err := eksSupport.options.loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error: fail to load AWS SDK default %v", err)
+	}
+	awsConfig.Region = region
+
+	var describe *eks.DescribeClusterOutput
+	err = metrics.ObserveCall(metrics.SourceAWS, "eks.DescribeCluster", isThrottlingError, func() error {
+		var err error
+		describe, err = eks.NewFromConfig(awsConfig).DescribeCluster(ctx, &eks.DescribeClusterInput{Name: aws.String(cluster)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	oidcProvider := &OIDCProvider{}
+	if describe.Cluster != nil && describe.Cluster.Identity != nil && describe.Cluster.Identity.Oidc != nil {
+		oidcProvider.IssuerURL = aws.ToString(describe.Cluster.Identity.Oidc.Issuer)
+	}
+	if oidcProvider.IssuerURL == "" {
+		return oidcProvider, nil
+	}
+
+	var providers *iam.ListOpenIDConnectProvidersOutput
+	err = metrics.ObserveCall(metrics.SourceAWS, "iam.ListOpenIDConnectProviders", isThrottlingError, func() error {
+		var err error
+		providers, err = iam.NewFromConfig(awsConfig).ListOpenIDConnectProviders(ctx, &iam.ListOpenIDConnectProvidersInput{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	providerARNs := make([]string, 0, len(providers.OpenIDConnectProviderList))
+	for i := range providers.OpenIDConnectProviderList {
+		providerARNs = append(providerARNs, aws.ToString(providers.OpenIDConnectProviderList[i].Arn))
+	}
+	oidcProvider.ARN = oidcProviderARNForIssuer(providerARNs, oidcProvider.IssuerURL)
+	return oidcProvider, nil
+}
+
+// ListPodIdentityAssociations returns the EKS Pod Identity associations of the cluster
+func (eksSupport *EKSSupport) ListPodIdentityAssociations(ctx context.Context, cluster string, region string) (_ []WorkloadIdentity, err error) {
+	ctx, span := tracing.StartSpan(ctx, "eks.ListPodIdentityAssociations", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	awsConfig, err := eksSupport.options.loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error: fail to load AWS SDK default %v", err)
+	}
+	credentials, err := awsConfig.Credentials.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the AWS credentials: %w", err)
+	}
+	endpoint, err := eks.NewDefaultEndpointResolver().ResolveEndpoint(region, eks.EndpointResolverOptions{})
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := eksSupport.options.httpClient(podIdentityHTTPClient)
+	if err != nil {
+		return nil, err
+	}
+	if eksSupport.options.identity != nil {
+		withIdentity := *httpClient
+		withIdentity.Transport = eksSupport.options.identity.WrapTransport(httpClient.Transport)
+		httpClient = &withIdentity
+	}
+	client := &podIdentityClient{httpClient: httpClient, endpoint: endpoint.URL, region: region, credentials: credentials}
+	return client.listWorkloadIdentities(ctx, cluster)
+}
+
+// podIdentityClient calls the EKS Pod Identity API, the version of the EKS SDK does not support it
+type podIdentityClient struct {
+	httpClient  *http.Client
+	endpoint    string
+	region      string
+	credentials aws.Credentials
+}
+
+func (client *podIdentityClient) listWorkloadIdentities(ctx context.Context, cluster string) ([]WorkloadIdentity, error) {
+	basePath := fmt.Sprintf("/clusters/%s/pod-identity-associations", url.PathEscape(cluster))
+	var associations []podIdentityAssociation
+	nextToken := ""
+	for {
+		query := url.Values{}
+		if nextToken != "" {
+			query.Set("nextToken", nextToken)
+		}
+		page := struct {
+			Associations []podIdentityAssociation `json:"associations"`
+			NextToken    string                   `json:"nextToken"`
+		}{}
+		err := metrics.ObserveCall(metrics.SourceAWS, "eks.ListPodIdentityAssociations", isThrottlingError, func() error {
+			return client.get(ctx, basePath, query, &page)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the pod identity associations of cluster '%s': %w", cluster, err)
+		}
+		associations = append(associations, page.Associations...)
+		if page.NextToken == "" {
+			break
+		}
+		nextToken = page.NextToken
+	}
+
+	// the role of the associations is returned by DescribePodIdentityAssociation only
+	identities := make([]WorkloadIdentity, 0, len(associations))
+	for i := range associations {
+		described := struct {
+			Association podIdentityAssociation `json:"association"`
+		}{}
+		err := metrics.ObserveCall(metrics.SourceAWS, "eks.DescribePodIdentityAssociation", isThrottlingError, func() error {
+			return client.get(ctx, basePath+"/"+url.PathEscape(associations[i].AssociationID), nil, &described)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe the pod identity association '%s': %w", associations[i].AssociationID, err)
+		}
+		identities = append(identities, WorkloadIdentity{
+			Type:           WorkloadIdentityTypePodIdentity,
+			Namespace:      associations[i].Namespace,
+			ServiceAccount: associations[i].ServiceAccount,
+			RoleARN:        described.Association.RoleARN,
+			AssociationID:  associations[i].AssociationID,
+		})
+	}
+	return identities, nil
+}
+
+// get sends the signed GET request and unmarshals the response
+func (client *podIdentityClient) get(ctx context.Context, path string, query url.Values, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(client.endpoint, "/")+path, nil)
+	if err != nil {
+		return fmt.Errorf("creating HTTP request: %w", err)
+	}
+	req.URL.RawQuery = query.Encode()
+	req.Header.Add("Accept", "application/json")
+	if err := v4.NewSigner().SignHTTP(ctx, client.credentials, req, emptyPayloadSHA256, "eks", client.region, time.Now()); err != nil {
+		return fmt.Errorf("signing the request: %w", err)
+	}
+
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling the EKS API: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newHTTPResponseError("EKS API", resp, body)
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("unmarshalling the response: %w", err)
+	}
+	return nil
+}
+
+// irsaWorkloadIdentities returns the identities of the service accounts annotated with an IAM role, sorted by namespace and name
+func irsaWorkloadIdentities(serviceAccounts []corev1.ServiceAccount, oidcProviderARN string) []WorkloadIdentity {
+	identities := []WorkloadIdentity{}
+	for i := range serviceAccounts {
+		roleARN := strings.TrimSpace(serviceAccounts[i].Annotations[irsaRoleARNAnnotation])
+		if roleARN == "" {
+			continue
+		}
+		identities = append(identities, WorkloadIdentity{
+			Type:            WorkloadIdentityTypeIRSA,
+			Namespace:       serviceAccounts[i].Namespace,
+			ServiceAccount:  serviceAccounts[i].Name,
+			RoleARN:         roleARN,
+			OIDCProviderARN: oidcProviderARN,
+		})
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		if identities[i].Namespace != identities[j].Namespace {
+			return identities[i].Namespace < identities[j].Namespace
+		}
+		return identities[i].ServiceAccount < identities[j].ServiceAccount
+	})
+	return identities
+}
+
+// oidcProviderARNForIssuer returns the ARN of the IAM OIDC provider of the issuer, arn:<partition>:iam::<account>:oidc-provider/<issuer host and path>
+func oidcProviderARNForIssuer(providerARNs []string, issuerURL string) string {
+	issuer := strings.TrimSuffix(strings.TrimPrefix(issuerURL, "https://"), "/")
+	for _, providerARN := range providerARNs {
+		if strings.HasSuffix(providerARN, ":oidc-provider/"+issuer) {
+			return providerARN
+		}
+	}
+	return ""
+}
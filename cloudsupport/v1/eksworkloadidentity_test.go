@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIRSAWorkloadIdentities(t *testing.T) {
+	serviceAccounts := []corev1.ServiceAccount{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "api", Annotations: map[string]string{irsaRoleARNAnnotation: "arn:aws:iam::123456789012:role/payments"}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "default"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "s3-reader", Annotations: map[string]string{irsaRoleARNAnnotation: "arn:aws:iam::123456789012:role/s3-reader"}}},
+	}
+	providerARN := "arn:aws:iam::123456789012:oidc-provider/oidc.eks.eu-north-1.amazonaws.com/id/EXAMPLE"
+	assert.Equal(t, []WorkloadIdentity{
+		{Type: WorkloadIdentityTypeIRSA, Namespace: "default", ServiceAccount: "s3-reader", RoleARN: "arn:aws:iam::123456789012:role/s3-reader", OIDCProviderARN: providerARN},
+		{Type: WorkloadIdentityTypeIRSA, Namespace: "payments", ServiceAccount: "api", RoleARN: "arn:aws:iam::123456789012:role/payments", OIDCProviderARN: providerARN},
+	}, irsaWorkloadIdentities(serviceAccounts, providerARN))
+	assert.Empty(t, irsaWorkloadIdentities(nil, providerARN))
+}
+
+func TestOIDCProviderARNForIssuer(t *testing.T) {
+	providerARNs := []string{
+		"arn:aws:iam::123456789012:oidc-provider/oidc.eks.eu-north-1.amazonaws.com/id/OTHER",
+		"arn:aws:iam::123456789012:oidc-provider/oidc.eks.eu-north-1.amazonaws.com/id/EXAMPLE",
+	}
+	assert.Equal(t, providerARNs[1], oidcProviderARNForIssuer(providerARNs, "https://oidc.eks.eu-north-1.amazonaws.com/id/EXAMPLE"))
+	assert.Equal(t, "", oidcProviderARNForIssuer(providerARNs, "https://oidc.eks.eu-north-1.amazonaws.com/id/EXAMPLE2"))
+}
+
+func TestListPodIdentityAssociations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-north-1/eks/aws4_request")
+		switch r.URL.Path {
+		case "/clusters/prod/pod-identity-associations":
+			if r.URL.Query().Get("nextToken") == "" {
+				fmt.Fprint(w, `{"associations": [{"associationId": "a-1", "namespace": "default", "serviceAccount": "s3-reader", "clusterName": "prod"}], "nextToken": "page2"}`)
+				return
+			}
+			fmt.Fprint(w, `{"associations": [{"associationId": "a-2", "namespace": "payments", "serviceAccount": "api", "clusterName": "prod"}]}`)
+		case "/clusters/prod/pod-identity-associations/a-1":
+			fmt.Fprint(w, `{"association": {"associationId": "a-1", "roleArn": "arn:aws:iam::123456789012:role/s3-reader"}}`)
+		case "/clusters/prod/pod-identity-associations/a-2":
+			fmt.Fprint(w, `{"association": {"associationId": "a-2", "roleArn": "arn:aws:iam::123456789012:role/payments"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "No cluster found"}`)
+		}
+	}))
+	defer server.Close()
+
+	client := &podIdentityClient{httpClient: server.Client(), endpoint: server.URL, region: "eu-north-1", credentials: aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}}
+	identities, err := client.listWorkloadIdentities(context.Background(), "prod")
+	require.NoError(t, err)
+	assert.Equal(t, []WorkloadIdentity{
+		{Type: WorkloadIdentityTypePodIdentity, Namespace: "default", ServiceAccount: "s3-reader", RoleARN: "arn:aws:iam::123456789012:role/s3-reader", AssociationID: "a-1"},
+		{Type: WorkloadIdentityTypePodIdentity, Namespace: "payments", ServiceAccount: "api", RoleARN: "arn:aws:iam::123456789012:role/payments", AssociationID: "a-2"},
+	}, identities)
+
+	_, err = client.listWorkloadIdentities(context.Background(), "unknown")
+	assert.ErrorContains(t, err, "404")
+}
@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/kubescape/k8s-interface/tracing"
+	containerpb "google.golang.org/genproto/googleapis/container/v1"
+)
+
+// awsKeyRotationPeriod is the period of the automatic rotation of the AWS KMS keys
+const awsKeyRotationPeriod = 365 * 24 * time.Hour
+
+// SecretsEncryption is the encryption at rest of the secrets of a cluster, normalized across the providers
+type SecretsEncryption struct {
+	Provider string `json:"provider"`
+	// Enabled is true if the secrets are encrypted with a KMS key (envelope encryption), on top of the storage encryption the providers always apply
+	Enabled bool            `json:"enabled"`
+	Keys    []EncryptionKey `json:"keys,omitempty"`
+}
+
+// EncryptionKey is a KMS key encrypting the secrets, with its rotation metadata
+type EncryptionKey struct {
+	// ID is the key ARN (AWS), the key identifier URL (Azure) or the crypto key resource name (GCP)
+	ID string `json:"id"`
+	// State is the state of the key (version) as the KMS reports it, e.g. Enabled (AWS), enabled (Azure), ENABLED (GCP)
+	State           string `json:"state,omitempty"`
+	RotationEnabled bool   `json:"rotationEnabled"`
+	// RotationPeriod is the period of the automatic rotation, 0 if the key is not rotated automatically
+	RotationPeriod time.Duration `json:"rotationPeriod,omitempty"`
+	// LastRotation is the creation of the current key version, nil if the KMS does not report it
+	LastRotation *time.Time `json:"lastRotation,omitempty"`
+	NextRotation *time.Time `json:"nextRotation,omitempty"`
+	// Error is set if the metadata of the key could not be fetched from the KMS, e.g. missing permissions
+	Error string `json:"error,omitempty"`
+}
+
+// newSecretsEncryptionEKS returns the KMS keys of the encryption config of the cluster encrypting the secrets
+func newSecretsEncryptionEKS(clusterDescribe *eks.DescribeClusterOutput) (*SecretsEncryption, error) {
+	if clusterDescribe == nil || clusterDescribe.Cluster == nil {
+		return nil, fmt.Errorf("error getting cluster descriptive information")
+	}
+	encryption := &SecretsEncryption{Provider: tracing.CloudProviderAWS}
+	for _, encryptionConfig := range clusterDescribe.Cluster.EncryptionConfig {
+		if !contains(encryptionConfig.Resources, secretsResource) || encryptionConfig.Provider == nil || encryptionConfig.Provider.KeyArn == nil {
+			continue
+		}
+		encryption.Enabled = true
+		encryption.Keys = append(encryption.Keys, EncryptionKey{ID: *encryptionConfig.Provider.KeyArn})
+	}
+	return encryption, nil
+}
+
+// newSecretsEncryptionAKS returns the Azure Key Vault KMS key of the security profile of the cluster
+func newSecretsEncryptionAKS(managedCluster *armcontainerservice.ManagedCluster) (*SecretsEncryption, error) {
+	if managedCluster == nil || managedCluster.Properties == nil {
+		return nil, fmt.Errorf("error getting cluster descriptive information")
+	}
+	encryption := &SecretsEncryption{Provider: tracing.CloudProviderAzure}
+	if securityProfile := managedCluster.Properties.SecurityProfile; securityProfile != nil && securityProfile.AzureKeyVaultKms != nil {
+		kms := securityProfile.AzureKeyVaultKms
+		if kms.Enabled != nil && *kms.Enabled && kms.KeyID != nil {
+			encryption.Enabled = true
+			encryption.Keys = append(encryption.Keys, EncryptionKey{ID: *kms.KeyID})
+		}
+	}
+	return encryption, nil
+}
+
+// newSecretsEncryptionGKE returns the Cloud KMS key of the application-layer secrets encryption of the cluster
+func newSecretsEncryptionGKE(cluster *containerpb.Cluster) (*SecretsEncryption, error) {
+	if cluster == nil {
+		return nil, fmt.Errorf("error getting cluster descriptive information")
+	}
+	encryption := &SecretsEncryption{Provider: tracing.CloudProviderGCP}
+	if databaseEncryption := cluster.GetDatabaseEncryption(); databaseEncryption.GetState() == containerpb.DatabaseEncryption_ENCRYPTED && databaseEncryption.GetKeyName() != "" {
+		encryption.Enabled = true
+		encryption.Keys = append(encryption.Keys, EncryptionKey{ID: databaseEncryption.GetKeyName()})
+	}
+	return encryption, nil
+}
+
+// parseAzureKeyID returns the vault URL, the name and the version of an Azure Key Vault key identifier, https://<vault>.vault.azure.net/keys/<name>/<version>
+func parseAzureKeyID(keyID string) (string, string, string, error) {
+	keyURL, err := url.Parse(keyID)
+	if err != nil {
+		return "", "", "", err
+	}
+	parts := strings.Split(strings.Trim(keyURL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "keys" {
+		return "", "", "", fmt.Errorf("invalid key vault key identifier '%s'", keyID)
+	}
+	version := ""
+	if len(parts) > 2 {
+		version = parts[2]
+	}
+	return keyURL.Scheme + "://" + keyURL.Host, parts[1], version, nil
+}
+
+var iso8601DurationRegex = regexp.MustCompile(`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?$`)
+
+// parseISO8601Duration parses the date durations of the Key Vault rotation policies, e.g. P90D, P1Y. Years are 365 days and months 30 days
+func parseISO8601Duration(duration string) (time.Duration, error) {
+	matches := iso8601DurationRegex.FindStringSubmatch(strings.ToUpper(duration))
+	if matches == nil || duration == "P" {
+		return 0, fmt.Errorf("unsupported duration '%s'", duration)
+	}
+	days := 0
+	for i, unit := range []int{365, 30, 7, 1} {
+		if matches[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return 0, err
+		}
+		days += n * unit
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/kubescape/k8s-interface/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	containerpb "google.golang.org/genproto/googleapis/container/v1"
+)
+
+func TestNewSecretsEncryption(t *testing.T) {
+	encryption, err := newSecretsEncryptionEKS(&eks.DescribeClusterOutput{Cluster: &ekstypes.Cluster{EncryptionConfig: []ekstypes.EncryptionConfig{
+		{Resources: []string{"secrets"}, Provider: &ekstypes.Provider{KeyArn: strPtr("arn:aws:kms:eu-west-1:123456789012:key/abc")}},
+	}}})
+	require.NoError(t, err)
+	assert.Equal(t, &SecretsEncryption{Provider: tracing.CloudProviderAWS, Enabled: true, Keys: []EncryptionKey{{ID: "arn:aws:kms:eu-west-1:123456789012:key/abc"}}}, encryption)
+
+	encryption, err = newSecretsEncryptionAKS(&armcontainerservice.ManagedCluster{Properties: &armcontainerservice.ManagedClusterProperties{
+		SecurityProfile: &armcontainerservice.ManagedClusterSecurityProfile{AzureKeyVaultKms: &armcontainerservice.AzureKeyVaultKms{Enabled: boolPtr(false), KeyID: strPtr("https://vault.vault.azure.net/keys/k8s/1")}},
+	}})
+	require.NoError(t, err)
+	assert.False(t, encryption.Enabled)
+	assert.Empty(t, encryption.Keys)
+
+	encryption, err = newSecretsEncryptionGKE(&containerpb.Cluster{DatabaseEncryption: &containerpb.DatabaseEncryption{State: containerpb.DatabaseEncryption_ENCRYPTED, KeyName: "projects/p/locations/l/keyRings/r/cryptoKeys/k"}})
+	require.NoError(t, err)
+	assert.Equal(t, tracing.CloudProviderGCP, encryption.Provider)
+	assert.True(t, encryption.Enabled)
+	assert.Equal(t, "projects/p/locations/l/keyRings/r/cryptoKeys/k", encryption.Keys[0].ID)
+}
+
+func TestGetSecretsEncryptionMocks(t *testing.T) {
+	encryption, err := NewEKSSupportMock().GetSecretsEncryption(context.Background(), "", "")
+	require.NoError(t, err)
+	assert.False(t, encryption.Enabled, "the encryption config of the mock is null")
+
+	encryption, err = NewAKSSupportMock().GetSecretsEncryption(context.Background(), "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, tracing.CloudProviderAzure, encryption.Provider)
+	assert.False(t, encryption.Enabled)
+
+	encryption, err = NewGKESupportMock().GetSecretsEncryption(context.Background(), "", "", "")
+	require.NoError(t, err)
+	assert.False(t, encryption.Enabled, "the database encryption of the mock is decrypted")
+}
+
+func TestParseAzureKeyID(t *testing.T) {
+	vaultURL, name, version, err := parseAzureKeyID("https://my-vault.vault.azure.net/keys/k8s-kms/0123456789abcdef")
+	require.NoError(t, err)
+	assert.Equal(t, "https://my-vault.vault.azure.net", vaultURL)
+	assert.Equal(t, "k8s-kms", name)
+	assert.Equal(t, "0123456789abcdef", version)
+
+	_, _, _, err = parseAzureKeyID("https://my-vault.vault.azure.net/secrets/k8s-kms")
+	assert.Error(t, err)
+}
+
+func TestParseISO8601Duration(t *testing.T) {
+	for duration, expected := range map[string]time.Duration{
+		"P90D":  90 * 24 * time.Hour,
+		"P1Y":   365 * 24 * time.Hour,
+		"P18M":  540 * 24 * time.Hour,
+		"P2W":   14 * 24 * time.Hour,
+		"P1Y6D": 371 * 24 * time.Hour,
+	} {
+		actual, err := parseISO8601Duration(duration)
+		assert.NoError(t, err, duration)
+		assert.Equal(t, expected, actual, duration)
+	}
+	for _, duration := range []string{"P", "90D", "PT1H"} {
+		_, err := parseISO8601Duration(duration)
+		assert.Error(t, err, duration)
+	}
+}
@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+// GetSecretsEncryption returns the KMS keys of the encryption config of the cluster with their state and automatic rotation.
+// AWS rotates the keys yearly and does not report the last rotation
+func (eksSupport *EKSSupport) GetSecretsEncryption(ctx context.Context, cluster string, region string) (_ *SecretsEncryption, err error) {
+	ctx, span := tracing.StartSpan(ctx, "kms.GetSecretsEncryption", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	clusterDescribe, err := eksSupport.GetClusterDescribe(cluster, region)
+	if err != nil {
+		return nil, err
+	}
+	encryption, err := newSecretsEncryptionEKS(clusterDescribe)
+	if err != nil || !encryption.Enabled {
+		return encryption, err
+	}
+
+	awsConfig, err := eksSupport.options.loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error: fail to load AWS SDK default %v", err)
+	}
+	awsConfig.Region = region
+	svc := kms.NewFromConfig(awsConfig)
+	for i := range encryption.Keys {
+		if err := describeKMSKey(ctx, svc, &encryption.Keys[i]); err != nil {
+			encryption.Keys[i].Error = err.Error()
+		}
+	}
+	return encryption, nil
+}
+
+func describeKMSKey(ctx context.Context, svc *kms.Client, key *EncryptionKey) error {
+	var describeKey *kms.DescribeKeyOutput
+	err := metrics.ObserveCall(metrics.SourceAWS, "kms.DescribeKey", isThrottlingError, func() error {
+		var err error
+		describeKey, err = svc.DescribeKey(ctx, &kms.DescribeKeyInput{KeyId: aws.String(key.ID)})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if describeKey.KeyMetadata != nil {
+		key.State = string(describeKey.KeyMetadata.KeyState)
+	}
+
+	var rotationStatus *kms.GetKeyRotationStatusOutput
+	err = metrics.ObserveCall(metrics.SourceAWS, "kms.GetKeyRotationStatus", isThrottlingError, func() error {
+		var err error
+		rotationStatus, err = svc.GetKeyRotationStatus(ctx, &kms.GetKeyRotationStatusInput{KeyId: aws.String(key.ID)})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	key.RotationEnabled = rotationStatus.KeyRotationEnabled
+	if key.RotationEnabled {
+		key.RotationPeriod = awsKeyRotationPeriod
+	}
+	return nil
+}
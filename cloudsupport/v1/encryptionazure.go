@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/keyvault/azkeys"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+// GetSecretsEncryption returns the Azure Key Vault KMS key of the cluster with its state and rotation policy.
+// Without KMS, the secrets are only encrypted by the platform-managed etcd encryption of AKS and Enabled is false
+func (AKSSupport *AKSSupport) GetSecretsEncryption(ctx context.Context, subscriptionId string, clusterName string, resourceGroup string) (_ *SecretsEncryption, err error) {
+	ctx, span := tracing.StartSpan(ctx, "keyvault.GetSecretsEncryption", tracing.AttributeCloudProvider.String(tracing.CloudProviderAzure), tracing.AttributeCloudCluster.String(clusterName))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	managedCluster, err := AKSSupport.GetClusterDescribe(subscriptionId, clusterName, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	encryption, err := newSecretsEncryptionAKS(managedCluster)
+	if err != nil || !encryption.Enabled {
+		return encryption, err
+	}
+
+	cred, err := AKSSupport.options.newAzureCredential()
+	if err != nil {
+		return nil, err
+	}
+	for i := range encryption.Keys {
+		if err := getKeyVaultKey(ctx, cred, AKSSupport.options.azureClientOptions(), &encryption.Keys[i]); err != nil {
+			encryption.Keys[i].Error = err.Error()
+		}
+	}
+	return encryption, nil
+}
+
+func getKeyVaultKey(ctx context.Context, cred azcore.TokenCredential, clientOptions azcore.ClientOptions, key *EncryptionKey) error {
+	vaultURL, name, version, err := parseAzureKeyID(key.ID)
+	if err != nil {
+		return err
+	}
+	client, err := azkeys.NewClient(vaultURL, cred, &azkeys.ClientOptions{ClientOptions: clientOptions})
+	if err != nil {
+		return err
+	}
+
+	var keyResponse azkeys.GetKeyResponse
+	err = metrics.ObserveCall(metrics.SourceAzure, "keyvault.GetKey", isThrottlingError, func() error {
+		var err error
+		keyResponse, err = client.GetKey(ctx, name, version, nil)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if attributes := keyResponse.Attributes; attributes != nil {
+		key.State = "disabled"
+		if attributes.Enabled != nil && *attributes.Enabled {
+			key.State = "enabled"
+		}
+		key.LastRotation = attributes.Created
+	}
+
+	var policyResponse azkeys.GetKeyRotationPolicyResponse
+	err = metrics.ObserveCall(metrics.SourceAzure, "keyvault.GetKeyRotationPolicy", isThrottlingError, func() error {
+		var err error
+		policyResponse, err = client.GetKeyRotationPolicy(ctx, name, nil)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for _, action := range policyResponse.LifetimeActions {
+		if action == nil || action.Action == nil || action.Action.Type == nil || !strings.EqualFold(string(*action.Action.Type), string(azkeys.ActionTypeRotate)) {
+			continue
+		}
+		if action.Trigger == nil || action.Trigger.TimeAfterCreate == nil {
+			continue
+		}
+		if key.RotationPeriod, err = parseISO8601Duration(*action.Trigger.TimeAfterCreate); err != nil {
+			return err
+		}
+		key.RotationEnabled = true
+		if key.LastRotation != nil {
+			nextRotation := key.LastRotation.Add(key.RotationPeriod)
+			key.NextRotation = &nextRotation
+		}
+	}
+	return nil
+}
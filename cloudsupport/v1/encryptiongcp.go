@@ -0,0 +1,70 @@
+package v1
+
+import (
+	"context"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+)
+
+// GetSecretsEncryption returns the Cloud KMS key of the application-layer secrets encryption of the cluster with its primary version and rotation schedule
+func (gkeSupport *GKESupport) GetSecretsEncryption(ctx context.Context, cluster string, region string, project string) (_ *SecretsEncryption, err error) {
+	ctx, span := tracing.StartSpan(ctx, "kms.GetSecretsEncryption", tracing.AttributeCloudProvider.String(tracing.CloudProviderGCP), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx = gkeSupport.options.gcpContext(ctx)
+	clientOptions, err := gkeSupport.options.gcpClientOptions(GCPServiceKMS)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterDescribe, err := gkeSupport.GetClusterDescribe(cluster, region, project)
+	if err != nil {
+		return nil, err
+	}
+	encryption, err := newSecretsEncryptionGKE(clusterDescribe)
+	if err != nil || !encryption.Enabled {
+		return encryption, err
+	}
+
+	client, err := kms.NewKeyManagementClient(ctx, clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	for i := range encryption.Keys {
+		if err := getCryptoKey(ctx, client, &encryption.Keys[i]); err != nil {
+			encryption.Keys[i].Error = err.Error()
+		}
+	}
+	return encryption, nil
+}
+
+func getCryptoKey(ctx context.Context, client *kms.KeyManagementClient, key *EncryptionKey) error {
+	var cryptoKey *kmspb.CryptoKey
+	err := metrics.ObserveCall(metrics.SourceGCP, "kms.GetCryptoKey", isThrottlingError, func() error {
+		var err error
+		cryptoKey, err = client.GetCryptoKey(ctx, &kmspb.GetCryptoKeyRequest{Name: key.ID})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if primary := cryptoKey.GetPrimary(); primary != nil {
+		key.State = primary.GetState().String()
+		if primary.GetCreateTime() != nil {
+			lastRotation := primary.GetCreateTime().AsTime()
+			key.LastRotation = &lastRotation
+		}
+	}
+	if rotationPeriod := cryptoKey.GetRotationPeriod(); rotationPeriod != nil {
+		key.RotationEnabled = true
+		key.RotationPeriod = rotationPeriod.AsDuration()
+	}
+	if cryptoKey.GetNextRotationTime() != nil {
+		nextRotation := cryptoKey.GetNextRotationTime().AsTime()
+		key.NextRotation = &nextRotation
+	}
+	return nil
+}
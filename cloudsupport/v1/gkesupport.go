@@ -8,6 +8,10 @@ import (
 
 	container "cloud.google.com/go/container/apiv1"
 	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/logging"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	containerpb "google.golang.org/genproto/googleapis/container/v1"
 )
@@ -19,15 +23,82 @@ type IGKESupport interface {
 	GetRegion(cluster string) (string, error)
 	GetContextName(cluster string) string
 }
+
+// The optional capabilities of the GKE support, implemented by GKESupport (the registry tokens by IRegistryTokenProvider).
+// They are not part of IGKESupport so its implementations do not break, check for them with a type assertion
+type (
+	IGKELoadBalancerSupport interface {
+		GetLoadBalancer(ctx context.Context, project string, region string, address string) (*LoadBalancer, error)
+	}
+	IGKESecretsEncryptionSupport interface {
+		GetSecretsEncryption(ctx context.Context, cluster string, region string, project string) (*SecretsEncryption, error)
+	}
+	IGKEDiskEncryptionSupport interface {
+		GetDiskEncryption(ctx context.Context, project string, diskID string) (*DiskEncryption, error)
+	}
+	IGKENetworkExposureSupport interface {
+		GetControlPlaneNetworkExposure(ctx context.Context, cluster string, region string, project string) (*ControlPlaneNetworkExposure, error)
+	}
+	IGKEVersionSupport interface {
+		GetVersionSupport(ctx context.Context, cluster string, region string, project string) (*VersionSupport, error)
+	}
+	IGKENodePoolsSupport interface {
+		GetNodePools(ctx context.Context, cluster string, region string, project string) ([]NodePool, error)
+	}
+	IGKECloudPermissionsSupport interface {
+		PreflightCloudPermissions(ctx context.Context, project string) (*CloudPermissionsReport, error)
+	}
+)
+
+var (
+	_ IGKESupport                  = &GKESupport{}
+	_ IRegistryTokenProvider       = &GKESupport{}
+	_ IGKELoadBalancerSupport      = &GKESupport{}
+	_ IGKESecretsEncryptionSupport = &GKESupport{}
+	_ IGKEDiskEncryptionSupport    = &GKESupport{}
+	_ IGKENetworkExposureSupport   = &GKESupport{}
+	_ IGKEVersionSupport           = &GKESupport{}
+	_ IGKENodePoolsSupport         = &GKESupport{}
+	_ IGKECloudPermissionsSupport  = &GKESupport{}
+)
+
 type GKESupport struct {
+	logger  logging.Logger
+	ctx     context.Context
+	options cloudSupportOptions
 }
 
 var (
 	KS_GKE_PROJECT_ENV_VAR = "KS_GKE_PROJECT"
 )
 
-func NewGKESupport() *GKESupport {
-	return &GKESupport{}
+const gcpCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+func NewGKESupport(opts ...CloudSupportOption) *GKESupport {
+	return &GKESupport{options: newCloudSupportOptions(opts)}
+}
+
+// SetLogger sets the logger of the GKE support, the logging package default logger is used if not set
+func (gkeSupport *GKESupport) SetLogger(logger logging.Logger) {
+	gkeSupport.logger = logger
+}
+
+// GetLogger returns the logger of the GKE support
+func (gkeSupport *GKESupport) GetLogger() logging.Logger {
+	return logging.OrDefault(gkeSupport.logger)
+}
+
+// SetContext sets the context of the GKE calls that do not take one, so they are traced as children of the span of the context and are canceled with it
+func (gkeSupport *GKESupport) SetContext(ctx context.Context) {
+	gkeSupport.ctx = ctx
+}
+
+// GetContext returns the context of the GKE calls, context.Background() if not set
+func (gkeSupport *GKESupport) GetContext() context.Context {
+	if gkeSupport.ctx == nil {
+		return context.Background()
+	}
+	return gkeSupport.ctx
 }
 
 func (gkeSupport *GKESupport) GetRegion(cluster string) (string, error) {
@@ -57,9 +128,16 @@ func (gkeSupport *GKESupport) GetProject(cluster string) (string, error) {
 }
 
 // Get descriptive info about cluster running in GKE.
-func (gkeSupport *GKESupport) GetClusterDescribe(cluster string, region string, project string) (*containerpb.Cluster, error) {
-	ctx := context.Background()
-	c, err := container.NewClusterManagerClient(ctx)
+func (gkeSupport *GKESupport) GetClusterDescribe(cluster string, region string, project string) (_ *containerpb.Cluster, err error) {
+	ctx, span := tracing.StartSpan(gkeSupport.GetContext(), "gke.DescribeCluster", tracing.AttributeCloudProvider.String(tracing.CloudProviderGCP), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx = gkeSupport.options.gcpContext(ctx)
+	clientOptions, err := gkeSupport.options.gcpClientOptions(GCPServiceContainer)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := container.NewClusterManagerClient(ctx, clientOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +147,12 @@ func (gkeSupport *GKESupport) GetClusterDescribe(cluster string, region string,
 	req := &containerpb.GetClusterRequest{
 		Name: clusterName,
 	}
-	result, err := c.GetCluster(ctx, req)
+	var result *containerpb.Cluster
+	err = metrics.ObserveCall(metrics.SourceGCP, "container.GetCluster", isThrottlingError, func() error {
+		var err error
+		result, err = c.GetCluster(ctx, req)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -94,6 +177,40 @@ func (gkeSupport *GKESupport) GetAuthorizationKey() (string, error) {
 	return t.AccessToken, nil
 }
 
+// GetRegistryToken returns a token for the Google Artifact Registry (<location>-docker.pkg.dev) or Container Registry (gcr.io) using the default Google credentials
+func (gkeSupport *GKESupport) GetRegistryToken(ctx context.Context, registryHost string) (_ *RegistryToken, err error) {
+	ctx, span := tracing.StartSpan(ctx, "gar.GetRegistryToken", tracing.AttributeCloudProvider.String(tracing.CloudProviderGCP), tracing.AttributeRegistry.String(registryHost))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !IsGARRegistry(registryHost) {
+		return nil, fmt.Errorf("registry '%s' is not a Google registry", registryHost)
+	}
+	if gkeSupport.options.err != nil {
+		return nil, gkeSupport.options.err
+	}
+	ctx = gkeSupport.options.gcpContext(ctx)
+	tokenSource, err := google.DefaultTokenSource(ctx, gcpCloudPlatformScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find creds: %w", err)
+	}
+	var t *oauth2.Token
+	err = metrics.ObserveCall(metrics.SourceGCP, "oauth2.Token", isThrottlingError, func() error {
+		var err error
+		t, err = tokenSource.Token()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+	gkeSupport.GetLogger().Debug("minted registry token", "registry", registryHost, "expiresAt", t.Expiry)
+	return &RegistryToken{
+		Registry:  registryHost,
+		Username:  gcrTokenUsername,
+		Password:  t.AccessToken,
+		ExpiresAt: t.Expiry,
+	}, nil
+}
+
 func (gkeSupport *GKESupport) GetContextName(cluster string) string {
 
 	parsedName := strings.Split(cluster, "_")
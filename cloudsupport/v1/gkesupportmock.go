@@ -1,11 +1,15 @@
 package v1
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
+	"time"
 
 	"github.com/kubescape/k8s-interface/cloudsupport/mockobjects"
 	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/tracing"
+	computepb "google.golang.org/genproto/googleapis/cloud/compute/v1"
 	containerpb "google.golang.org/genproto/googleapis/container/v1"
 )
 
@@ -51,3 +55,72 @@ func (gkeSupportM *GKESupportMock) GetContextName(cluster string) string {
 	}
 	return parsedName[3]
 }
+
+func (gkeSupportM *GKESupportMock) GetRegistryToken(ctx context.Context, registryHost string) (*RegistryToken, error) {
+	return &RegistryToken{
+		Registry:  registryHost,
+		Username:  gcrTokenUsername,
+		Password:  "mock-gcr-access-token",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil
+}
+
+func (gkeSupportM *GKESupportMock) GetLoadBalancer(ctx context.Context, project string, region string, address string) (*LoadBalancer, error) {
+	return &LoadBalancer{
+		Provider:       tracing.CloudProviderGCP,
+		Type:           LoadBalancerTypeGCPForwardingRule,
+		ID:             "https://www.googleapis.com/compute/v1/projects/" + project + "/regions/" + region + "/forwardingRules/a1b2c3d4",
+		Name:           "a1b2c3d4",
+		Address:        address,
+		InternetFacing: true,
+	}, nil
+}
+
+func (gkeSupportM *GKESupportMock) GetSecretsEncryption(ctx context.Context, cluster string, region string, project string) (*SecretsEncryption, error) {
+	clusterDescribe, err := gkeSupportM.GetClusterDescribe(cluster, region, project)
+	if err != nil {
+		return nil, err
+	}
+	return newSecretsEncryptionGKE(clusterDescribe)
+}
+
+func (gkeSupportM *GKESupportMock) GetDiskEncryption(ctx context.Context, project string, diskID string) (*DiskEncryption, error) {
+	project, zone, region, name, err := parseGCPDiskID(diskID, project)
+	if err != nil {
+		return nil, err
+	}
+	location := "zones/" + zone
+	if region != "" {
+		location = "regions/" + region
+	}
+	selfLink := "https://www.googleapis.com/compute/v1/projects/" + project + "/" + location + "/disks/" + name
+	return newDiskEncryptionGKE(&computepb.Disk{SelfLink: &selfLink}), nil
+}
+
+func (gkeSupportM *GKESupportMock) GetControlPlaneNetworkExposure(ctx context.Context, cluster string, region string, project string) (*ControlPlaneNetworkExposure, error) {
+	clusterDescribe, err := gkeSupportM.GetClusterDescribe(cluster, region, project)
+	if err != nil {
+		return nil, err
+	}
+	return newControlPlaneNetworkExposureGKE(clusterDescribe)
+}
+
+func (gkeSupportM *GKESupportMock) GetVersionSupport(ctx context.Context, cluster string, region string, project string) (*VersionSupport, error) {
+	clusterDescribe, err := gkeSupportM.GetClusterDescribe(cluster, region, project)
+	if err != nil {
+		return nil, err
+	}
+	return newVersionSupportGKE(clusterDescribe, &containerpb.ServerConfig{ValidMasterVersions: []string{clusterDescribe.GetCurrentMasterVersion()}})
+}
+
+func (gkeSupportM *GKESupportMock) GetNodePools(ctx context.Context, cluster string, region string, project string) ([]NodePool, error) {
+	clusterDescribe, err := gkeSupportM.GetClusterDescribe(cluster, region, project)
+	if err != nil {
+		return nil, err
+	}
+	return newNodePoolsGKE(clusterDescribe)
+}
+
+func (gkeSupportM *GKESupportMock) PreflightCloudPermissions(ctx context.Context, project string) (*CloudPermissionsReport, error) {
+	return newCloudPermissionsReport(tracing.CloudProviderGCP, "", project, GKERequiredPermissions, func(string) bool { return true }), nil
+}
@@ -0,0 +1,95 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	LoadBalancerTypeAWSClassic              = "classic"
+	LoadBalancerTypeAWSApplication          = "application"
+	LoadBalancerTypeAWSNetwork              = "network"
+	LoadBalancerTypeAzureLoadBalancer       = "loadBalancer"
+	LoadBalancerTypeAzureApplicationGateway = "applicationGateway"
+	LoadBalancerTypeGCPForwardingRule       = "forwardingRule"
+)
+
+// LoadBalancer is the cloud load balancer behind the address of a Service of type LoadBalancer
+type LoadBalancer struct {
+	// Provider is one of aws/azure/gcp
+	Provider string `json:"provider"`
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	// Address is the IP/hostname of the Service status the load balancer was found by
+	Address string `json:"address"`
+	// InternetFacing is true if the load balancer has a public address, false for internal load balancers
+	InternetFacing bool `json:"internetFacing"`
+	// FirewallRules are the inbound rules of the security groups/network security groups/firewalls of the load balancer.
+	// AWS network load balancers without security groups and Azure load balancers are filtered by the rules of the nodes
+	FirewallRules []FirewallRule `json:"firewallRules,omitempty"`
+}
+
+// FirewallRule is an inbound rule of a security group, network security group or firewall
+type FirewallRule struct {
+	// Source is the name of the security group/network security group/firewall of the rule
+	Source   string `json:"source"`
+	Name     string `json:"name,omitempty"`
+	Allow    bool   `json:"allow"`
+	Priority int32  `json:"priority,omitempty"`
+	// Protocol is empty for all the protocols
+	Protocol string `json:"protocol,omitempty"`
+	// Ports are port numbers or ranges (e.g. "443", "30000-32767"), empty for all the ports
+	Ports []string `json:"ports,omitempty"`
+	// SourceRanges are the CIDRs (or Azure service tags) the rule applies to
+	SourceRanges []string `json:"sourceRanges,omitempty"`
+}
+
+// IsOpenToInternet returns true if the rule allows traffic from any address
+func (rule *FirewallRule) IsOpenToInternet() bool {
+	if !rule.Allow {
+		return false
+	}
+	for _, sourceRange := range rule.SourceRanges {
+		switch sourceRange {
+		case "0.0.0.0/0", "::/0", "*", "Internet", "Any":
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceLoadBalancerAddresses returns the IPs/hostnames of the load balancer of the Service, empty if the Service is not of type LoadBalancer or the load balancer is not provisioned yet
+func ServiceLoadBalancerAddresses(service *corev1.Service) []string {
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return []string{}
+	}
+	addresses := []string{}
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			addresses = append(addresses, ingress.IP)
+		}
+		if ingress.Hostname != "" {
+			addresses = append(addresses, ingress.Hostname)
+		}
+	}
+	return addresses
+}
+
+func loadBalancerNotFoundError(address string) error {
+	return fmt.Errorf("no load balancer with address '%s' found", address)
+}
+
+// portRange returns the "from-to" range of the ports, or the port if both are equal
+func portRange(from, to int32) string {
+	if from == to {
+		return fmt.Sprintf("%d", from)
+	}
+	return fmt.Sprintf("%d-%d", from, to)
+}
+
+func equalAddress(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
@@ -0,0 +1,159 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	elbtypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing/types"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/stretchr/testify/assert"
+	computepb "google.golang.org/genproto/googleapis/cloud/compute/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestServiceLoadBalancerAddresses(t *testing.T) {
+	service := &corev1.Service{
+		Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{
+			{IP: "20.1.2.3"},
+			{Hostname: "a1b2c3d4-0123456789.elb.eu-central-1.amazonaws.com"},
+		}}},
+	}
+	assert.Equal(t, []string{"20.1.2.3", "a1b2c3d4-0123456789.elb.eu-central-1.amazonaws.com"}, ServiceLoadBalancerAddresses(service))
+
+	service.Spec.Type = corev1.ServiceTypeNodePort
+	assert.Empty(t, ServiceLoadBalancerAddresses(service))
+}
+
+func TestFirewallRuleIsOpenToInternet(t *testing.T) {
+	assert.True(t, (&FirewallRule{Allow: true, SourceRanges: []string{"10.0.0.0/8", "0.0.0.0/0"}}).IsOpenToInternet())
+	assert.True(t, (&FirewallRule{Allow: true, SourceRanges: []string{"Internet"}}).IsOpenToInternet())
+	assert.False(t, (&FirewallRule{Allow: false, SourceRanges: []string{"0.0.0.0/0"}}).IsOpenToInternet())
+	assert.False(t, (&FirewallRule{Allow: true, SourceRanges: []string{"10.0.0.0/8"}}).IsOpenToInternet())
+}
+
+func TestELBLoadBalancers(t *testing.T) {
+	loadBalancer := elbv2LoadBalancer(&elbv2types.LoadBalancer{
+		LoadBalancerArn:  aws.String("arn:aws:elasticloadbalancing:eu-central-1:015253967648:loadbalancer/net/a1b2c3d4/0123456789abcdef"),
+		LoadBalancerName: aws.String("a1b2c3d4"),
+		DNSName:          aws.String("a1b2c3d4-0123456789abcdef.elb.eu-central-1.amazonaws.com"),
+		Type:             elbv2types.LoadBalancerTypeEnumNetwork,
+		Scheme:           elbv2types.LoadBalancerSchemeEnumInternal,
+	})
+	assert.Equal(t, LoadBalancerTypeAWSNetwork, loadBalancer.Type)
+	assert.Equal(t, "a1b2c3d4", loadBalancer.Name)
+	assert.False(t, loadBalancer.InternetFacing)
+
+	loadBalancer = classicLoadBalancer(&elbtypes.LoadBalancerDescription{
+		LoadBalancerName: aws.String("a5e6f7"),
+		DNSName:          aws.String("a5e6f7-987654321.eu-central-1.elb.amazonaws.com"),
+		Scheme:           aws.String("internet-facing"),
+	})
+	assert.Equal(t, LoadBalancerTypeAWSClassic, loadBalancer.Type)
+	assert.True(t, loadBalancer.InternetFacing)
+
+	assert.True(t, equalAddress("A5E6F7-987654321.eu-central-1.elb.amazonaws.com.", loadBalancer.Address))
+}
+
+func TestSecurityGroupsFirewallRules(t *testing.T) {
+	rules := securityGroupsFirewallRules([]ec2types.SecurityGroup{{
+		GroupId:   aws.String("sg-0123456789"),
+		GroupName: aws.String("k8s-elb-a5e6f7"),
+		IpPermissions: []ec2types.IpPermission{
+			{IpProtocol: aws.String("tcp"), FromPort: aws.Int32(443), ToPort: aws.Int32(443), IpRanges: []ec2types.IpRange{{CidrIp: aws.String("0.0.0.0/0")}}},
+			{IpProtocol: aws.String("-1"), UserIdGroupPairs: []ec2types.UserIdGroupPair{{GroupId: aws.String("sg-9876543210")}}},
+		},
+	}})
+	assert.Equal(t, []FirewallRule{
+		{Source: "sg-0123456789", Name: "k8s-elb-a5e6f7", Allow: true, Protocol: "tcp", Ports: []string{"443"}, SourceRanges: []string{"0.0.0.0/0"}},
+		{Source: "sg-0123456789", Name: "k8s-elb-a5e6f7", Allow: true, SourceRanges: []string{"sg-9876543210"}},
+	}, rules)
+	assert.True(t, rules[0].IsOpenToInternet())
+	assert.False(t, rules[1].IsOpenToInternet())
+}
+
+func TestAzureLoadBalancer(t *testing.T) {
+	publicIPID := "/subscriptions/sub/resourceGroups/mc_rg/providers/Microsoft.Network/publicIPAddresses/kubernetes-a1b2c3d4"
+	publicIPs := map[string]string{publicIPID: "20.1.2.3"}
+	loadBalancer := &armnetwork.LoadBalancer{
+		ID:   aws.String("/subscriptions/sub/resourceGroups/mc_rg/providers/Microsoft.Network/loadBalancers/kubernetes"),
+		Name: aws.String("kubernetes"),
+		Properties: &armnetwork.LoadBalancerPropertiesFormat{FrontendIPConfigurations: []*armnetwork.FrontendIPConfiguration{
+			{Properties: &armnetwork.FrontendIPConfigurationPropertiesFormat{PublicIPAddress: &armnetwork.PublicIPAddress{ID: aws.String(publicIPID)}}},
+			{Properties: &armnetwork.FrontendIPConfigurationPropertiesFormat{PrivateIPAddress: aws.String("10.224.0.10")}},
+		}},
+	}
+
+	result := azureLoadBalancer(loadBalancer, publicIPs, "20.1.2.3")
+	if assert.NotNil(t, result) {
+		assert.Equal(t, LoadBalancerTypeAzureLoadBalancer, result.Type)
+		assert.Equal(t, "kubernetes", result.Name)
+		assert.True(t, result.InternetFacing)
+	}
+	result = azureLoadBalancer(loadBalancer, publicIPs, "10.224.0.10")
+	if assert.NotNil(t, result) {
+		assert.False(t, result.InternetFacing)
+	}
+	assert.Nil(t, azureLoadBalancer(loadBalancer, publicIPs, "20.9.9.9"))
+}
+
+func TestNetworkSecurityGroupsFirewallRules(t *testing.T) {
+	inbound := armnetwork.SecurityRuleDirectionInbound
+	outbound := armnetwork.SecurityRuleDirectionOutbound
+	allow := armnetwork.SecurityRuleAccessAllow
+	deny := armnetwork.SecurityRuleAccessDeny
+	tcp := armnetwork.SecurityRuleProtocolTCP
+	anyProtocol := armnetwork.SecurityRuleProtocolAsterisk
+	rules := networkSecurityGroupsFirewallRules([]*armnetwork.SecurityGroup{{
+		Name: aws.String("aks-agentpool-nsg"),
+		Properties: &armnetwork.SecurityGroupPropertiesFormat{SecurityRules: []*armnetwork.SecurityRule{
+			{Name: aws.String("allow-https"), Properties: &armnetwork.SecurityRulePropertiesFormat{
+				Direction: &inbound, Access: &allow, Protocol: &tcp, Priority: aws.Int32(500),
+				DestinationPortRange: aws.String("443"), SourceAddressPrefix: aws.String("Internet"),
+			}},
+			{Name: aws.String("deny-all"), Properties: &armnetwork.SecurityRulePropertiesFormat{
+				Direction: &inbound, Access: &deny, Protocol: &anyProtocol, Priority: aws.Int32(4000),
+				DestinationPortRange: aws.String("*"), SourceAddressPrefixes: []*string{aws.String("10.0.0.0/8"), aws.String("192.168.0.0/16")},
+			}},
+			{Name: aws.String("outbound"), Properties: &armnetwork.SecurityRulePropertiesFormat{
+				Direction: &outbound, Access: &allow, Protocol: &anyProtocol, Priority: aws.Int32(100),
+			}},
+		}},
+	}})
+	assert.Equal(t, []FirewallRule{
+		{Source: "aks-agentpool-nsg", Name: "allow-https", Allow: true, Priority: 500, Protocol: "Tcp", Ports: []string{"443"}, SourceRanges: []string{"Internet"}},
+		{Source: "aks-agentpool-nsg", Name: "deny-all", Priority: 4000, SourceRanges: []string{"10.0.0.0/8", "192.168.0.0/16"}},
+	}, rules)
+	assert.True(t, rules[0].IsOpenToInternet())
+}
+
+func TestForwardingRuleFirewalls(t *testing.T) {
+	forwardingRule := &computepb.ForwardingRule{
+		Name:                aws.String("a1b2c3d4"),
+		IPAddress:           aws.String("34.1.2.3"),
+		LoadBalancingScheme: aws.String(computepb.ForwardingRule_EXTERNAL.String()),
+	}
+	loadBalancer := forwardingRuleLoadBalancer(forwardingRule)
+	assert.Equal(t, LoadBalancerTypeGCPForwardingRule, loadBalancer.Type)
+	assert.True(t, loadBalancer.InternetFacing)
+
+	ingress := aws.String(computepb.Firewall_INGRESS.String())
+	firewall := &computepb.Firewall{
+		Name:         aws.String("k8s-fw-a1b2c3d4"),
+		Direction:    ingress,
+		Priority:     aws.Int32(1000),
+		SourceRanges: []string{"0.0.0.0/0"},
+		Allowed:      []*computepb.Allowed{{IPProtocol: aws.String("tcp"), Ports: []string{"80", "443"}}},
+		Denied:       []*computepb.Denied{{IPProtocol: aws.String("all")}},
+	}
+	assert.True(t, isForwardingRuleFirewall(forwardingRule, firewall))
+	assert.False(t, isForwardingRuleFirewall(forwardingRule, &computepb.Firewall{Name: aws.String("k8s-fw-e5f6"), Direction: ingress}))
+	assert.False(t, isForwardingRuleFirewall(forwardingRule, &computepb.Firewall{Name: aws.String("k8s-fw-a1b2c3d4"), Direction: ingress, Disabled: aws.Bool(true)}))
+
+	assert.Equal(t, []FirewallRule{
+		{Source: "k8s-fw-a1b2c3d4", Name: "k8s-fw-a1b2c3d4", Allow: true, Priority: 1000, Protocol: "tcp", Ports: []string{"80", "443"}, SourceRanges: []string{"0.0.0.0/0"}},
+		{Source: "k8s-fw-a1b2c3d4", Name: "k8s-fw-a1b2c3d4", Priority: 1000, SourceRanges: []string{"0.0.0.0/0"}},
+	}, firewallsFirewallRules([]*computepb.Firewall{firewall}))
+}
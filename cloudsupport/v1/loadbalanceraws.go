@@ -0,0 +1,159 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	elb "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing"
+	elbtypes "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancing/types"
+	elbv2 "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	elbv2types "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2/types"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+// GetLoadBalancer returns the ELB (application/network, or classic) with the DNS name of a Service of type LoadBalancer, with the inbound rules of its security groups
+func (eksSupport *EKSSupport) GetLoadBalancer(ctx context.Context, region string, address string) (_ *LoadBalancer, err error) {
+	ctx, span := tracing.StartSpan(ctx, "elb.GetLoadBalancer", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	awsConfig, err := eksSupport.options.loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error: fail to load AWS SDK default %v", err)
+	}
+	awsConfig.Region = region
+
+	loadBalancer, securityGroupIDs, err := findELBv2LoadBalancer(ctx, elbv2.NewFromConfig(awsConfig), address)
+	if err != nil {
+		return nil, err
+	}
+	if loadBalancer == nil {
+		if loadBalancer, securityGroupIDs, err = findClassicLoadBalancer(ctx, elb.NewFromConfig(awsConfig), address); err != nil {
+			return nil, err
+		}
+	}
+	if loadBalancer == nil {
+		return nil, loadBalancerNotFoundError(address)
+	}
+	if len(securityGroupIDs) == 0 {
+		return loadBalancer, nil
+	}
+
+	svc := ec2.NewFromConfig(awsConfig)
+	paginator := ec2.NewDescribeSecurityGroupsPaginator(svc, &ec2.DescribeSecurityGroupsInput{GroupIds: securityGroupIDs})
+	for paginator.HasMorePages() {
+		var page *ec2.DescribeSecurityGroupsOutput
+		err = metrics.ObserveCall(metrics.SourceAWS, "ec2.DescribeSecurityGroups", isThrottlingError, func() error {
+			var err error
+			page, err = paginator.NextPage(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		loadBalancer.FirewallRules = append(loadBalancer.FirewallRules, securityGroupsFirewallRules(page.SecurityGroups)...)
+	}
+	return loadBalancer, nil
+}
+
+func findELBv2LoadBalancer(ctx context.Context, svc *elbv2.Client, address string) (*LoadBalancer, []string, error) {
+	paginator := elbv2.NewDescribeLoadBalancersPaginator(svc, &elbv2.DescribeLoadBalancersInput{})
+	for paginator.HasMorePages() {
+		var page *elbv2.DescribeLoadBalancersOutput
+		err := metrics.ObserveCall(metrics.SourceAWS, "elbv2.DescribeLoadBalancers", isThrottlingError, func() error {
+			var err error
+			page, err = paginator.NextPage(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range page.LoadBalancers {
+			if equalAddress(aws.ToString(page.LoadBalancers[i].DNSName), address) {
+				return elbv2LoadBalancer(&page.LoadBalancers[i]), page.LoadBalancers[i].SecurityGroups, nil
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+func findClassicLoadBalancer(ctx context.Context, svc *elb.Client, address string) (*LoadBalancer, []string, error) {
+	paginator := elb.NewDescribeLoadBalancersPaginator(svc, &elb.DescribeLoadBalancersInput{})
+	for paginator.HasMorePages() {
+		var page *elb.DescribeLoadBalancersOutput
+		err := metrics.ObserveCall(metrics.SourceAWS, "elb.DescribeLoadBalancers", isThrottlingError, func() error {
+			var err error
+			page, err = paginator.NextPage(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range page.LoadBalancerDescriptions {
+			if equalAddress(aws.ToString(page.LoadBalancerDescriptions[i].DNSName), address) {
+				return classicLoadBalancer(&page.LoadBalancerDescriptions[i]), page.LoadBalancerDescriptions[i].SecurityGroups, nil
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+func elbv2LoadBalancer(loadBalancer *elbv2types.LoadBalancer) *LoadBalancer {
+	return &LoadBalancer{
+		Provider:       tracing.CloudProviderAWS,
+		Type:           string(loadBalancer.Type),
+		ID:             aws.ToString(loadBalancer.LoadBalancerArn),
+		Name:           aws.ToString(loadBalancer.LoadBalancerName),
+		Address:        aws.ToString(loadBalancer.DNSName),
+		InternetFacing: loadBalancer.Scheme == elbv2types.LoadBalancerSchemeEnumInternetFacing,
+	}
+}
+
+func classicLoadBalancer(loadBalancer *elbtypes.LoadBalancerDescription) *LoadBalancer {
+	return &LoadBalancer{
+		Provider:       tracing.CloudProviderAWS,
+		Type:           LoadBalancerTypeAWSClassic,
+		ID:             aws.ToString(loadBalancer.LoadBalancerName),
+		Name:           aws.ToString(loadBalancer.LoadBalancerName),
+		Address:        aws.ToString(loadBalancer.DNSName),
+		InternetFacing: aws.ToString(loadBalancer.Scheme) == "internet-facing",
+	}
+}
+
+// securityGroupsFirewallRules returns the inbound rules of the security groups. Security groups only have allow rules
+func securityGroupsFirewallRules(securityGroups []ec2types.SecurityGroup) []FirewallRule {
+	var rules []FirewallRule
+	for i := range securityGroups {
+		for _, permission := range securityGroups[i].IpPermissions {
+			rule := FirewallRule{
+				Source: aws.ToString(securityGroups[i].GroupId),
+				Name:   aws.ToString(securityGroups[i].GroupName),
+				Allow:  true,
+			}
+			// "-1" is all the protocols and ports
+			if protocol := aws.ToString(permission.IpProtocol); protocol != "-1" {
+				rule.Protocol = protocol
+				if permission.FromPort != nil && permission.ToPort != nil && *permission.FromPort >= 0 {
+					rule.Ports = []string{portRange(*permission.FromPort, *permission.ToPort)}
+				}
+			}
+			for _, ipRange := range permission.IpRanges {
+				rule.SourceRanges = append(rule.SourceRanges, aws.ToString(ipRange.CidrIp))
+			}
+			for _, ipv6Range := range permission.Ipv6Ranges {
+				rule.SourceRanges = append(rule.SourceRanges, aws.ToString(ipv6Range.CidrIpv6))
+			}
+			for _, prefixList := range permission.PrefixListIds {
+				rule.SourceRanges = append(rule.SourceRanges, aws.ToString(prefixList.PrefixListId))
+			}
+			for _, group := range permission.UserIdGroupPairs {
+				rule.SourceRanges = append(rule.SourceRanges, aws.ToString(group.GroupId))
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
@@ -0,0 +1,243 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+// GetLoadBalancer returns the load balancer or application gateway with the IP of a Service of type LoadBalancer, with the inbound rules of the network security groups.
+// The resource group is the node resource group of the cluster (MC_<resource group>_<cluster>_<location> by default), where the cloud provider creates the load balancers, public IPs and network security groups
+func (AKSSupport *AKSSupport) GetLoadBalancer(ctx context.Context, subscriptionId string, resourceGroup string, address string) (_ *LoadBalancer, err error) {
+	ctx, span := tracing.StartSpan(ctx, "network.GetLoadBalancer", tracing.AttributeCloudProvider.String(tracing.CloudProviderAzure))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	cred, err := AKSSupport.options.newAzureCredential()
+	if err != nil {
+		return nil, err
+	}
+
+	// the frontend IP configurations only reference the public IPs
+	publicIPs := map[string]string{}
+	publicIPClient, err := armnetwork.NewPublicIPAddressesClient(subscriptionId, cred, AKSSupport.options.armClientOptions())
+	if err != nil {
+		return nil, err
+	}
+	publicIPPager := publicIPClient.NewListPager(resourceGroup, nil)
+	for publicIPPager.More() {
+		var page armnetwork.PublicIPAddressesClientListResponse
+		err = metrics.ObserveCall(metrics.SourceAzure, "network.PublicIPAddresses.List", isThrottlingError, func() error {
+			var err error
+			page, err = publicIPPager.NextPage(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, publicIP := range page.Value {
+			if publicIP != nil && publicIP.ID != nil && publicIP.Properties != nil && publicIP.Properties.IPAddress != nil {
+				publicIPs[*publicIP.ID] = *publicIP.Properties.IPAddress
+			}
+		}
+	}
+
+	loadBalancer, err := findAzureLoadBalancer(ctx, subscriptionId, resourceGroup, cred, AKSSupport.options.armClientOptions(), publicIPs, address)
+	if err != nil {
+		return nil, err
+	}
+	if loadBalancer == nil {
+		if loadBalancer, err = findAzureApplicationGateway(ctx, subscriptionId, resourceGroup, cred, AKSSupport.options.armClientOptions(), publicIPs, address); err != nil {
+			return nil, err
+		}
+	}
+	if loadBalancer == nil {
+		return nil, loadBalancerNotFoundError(address)
+	}
+
+	securityGroupsClient, err := armnetwork.NewSecurityGroupsClient(subscriptionId, cred, AKSSupport.options.armClientOptions())
+	if err != nil {
+		return nil, err
+	}
+	securityGroupsPager := securityGroupsClient.NewListPager(resourceGroup, nil)
+	for securityGroupsPager.More() {
+		var page armnetwork.SecurityGroupsClientListResponse
+		err = metrics.ObserveCall(metrics.SourceAzure, "network.SecurityGroups.List", isThrottlingError, func() error {
+			var err error
+			page, err = securityGroupsPager.NextPage(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		loadBalancer.FirewallRules = append(loadBalancer.FirewallRules, networkSecurityGroupsFirewallRules(page.Value)...)
+	}
+	return loadBalancer, nil
+}
+
+func findAzureLoadBalancer(ctx context.Context, subscriptionId, resourceGroup string, cred azcore.TokenCredential, clientOptions *arm.ClientOptions, publicIPs map[string]string, address string) (*LoadBalancer, error) {
+	client, err := armnetwork.NewLoadBalancersClient(subscriptionId, cred, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	pager := client.NewListPager(resourceGroup, nil)
+	for pager.More() {
+		var page armnetwork.LoadBalancersClientListResponse
+		err = metrics.ObserveCall(metrics.SourceAzure, "network.LoadBalancers.List", isThrottlingError, func() error {
+			var err error
+			page, err = pager.NextPage(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, loadBalancer := range page.Value {
+			if result := azureLoadBalancer(loadBalancer, publicIPs, address); result != nil {
+				return result, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func findAzureApplicationGateway(ctx context.Context, subscriptionId, resourceGroup string, cred azcore.TokenCredential, clientOptions *arm.ClientOptions, publicIPs map[string]string, address string) (*LoadBalancer, error) {
+	client, err := armnetwork.NewApplicationGatewaysClient(subscriptionId, cred, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	pager := client.NewListPager(resourceGroup, nil)
+	for pager.More() {
+		var page armnetwork.ApplicationGatewaysClientListResponse
+		err = metrics.ObserveCall(metrics.SourceAzure, "network.ApplicationGateways.List", isThrottlingError, func() error {
+			var err error
+			page, err = pager.NextPage(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, applicationGateway := range page.Value {
+			if result := azureApplicationGateway(applicationGateway, publicIPs, address); result != nil {
+				return result, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// azureLoadBalancer returns the load balancer if one of its frontend IPs is the address, nil otherwise
+func azureLoadBalancer(loadBalancer *armnetwork.LoadBalancer, publicIPs map[string]string, address string) *LoadBalancer {
+	if loadBalancer == nil || loadBalancer.Properties == nil {
+		return nil
+	}
+	for _, frontend := range loadBalancer.Properties.FrontendIPConfigurations {
+		if frontend == nil || frontend.Properties == nil {
+			continue
+		}
+		var publicIPID *string
+		if frontend.Properties.PublicIPAddress != nil {
+			publicIPID = frontend.Properties.PublicIPAddress.ID
+		}
+		if internetFacing, ok := matchAzureFrontend(frontend.Properties.PrivateIPAddress, publicIPID, publicIPs, address); ok {
+			return &LoadBalancer{
+				Provider:       tracing.CloudProviderAzure,
+				Type:           LoadBalancerTypeAzureLoadBalancer,
+				ID:             stringValue(loadBalancer.ID),
+				Name:           stringValue(loadBalancer.Name),
+				Address:        address,
+				InternetFacing: internetFacing,
+			}
+		}
+	}
+	return nil
+}
+
+// azureApplicationGateway returns the application gateway if one of its frontend IPs is the address, nil otherwise
+func azureApplicationGateway(applicationGateway *armnetwork.ApplicationGateway, publicIPs map[string]string, address string) *LoadBalancer {
+	if applicationGateway == nil || applicationGateway.Properties == nil {
+		return nil
+	}
+	for _, frontend := range applicationGateway.Properties.FrontendIPConfigurations {
+		if frontend == nil || frontend.Properties == nil {
+			continue
+		}
+		var publicIPID *string
+		if frontend.Properties.PublicIPAddress != nil {
+			publicIPID = frontend.Properties.PublicIPAddress.ID
+		}
+		if internetFacing, ok := matchAzureFrontend(frontend.Properties.PrivateIPAddress, publicIPID, publicIPs, address); ok {
+			return &LoadBalancer{
+				Provider:       tracing.CloudProviderAzure,
+				Type:           LoadBalancerTypeAzureApplicationGateway,
+				ID:             stringValue(applicationGateway.ID),
+				Name:           stringValue(applicationGateway.Name),
+				Address:        address,
+				InternetFacing: internetFacing,
+			}
+		}
+	}
+	return nil
+}
+
+// matchAzureFrontend returns whether the frontend has the address, and whether the address is public
+func matchAzureFrontend(privateIP, publicIPID *string, publicIPs map[string]string, address string) (bool, bool) {
+	if publicIPID != nil && publicIPs[*publicIPID] == address {
+		return true, true
+	}
+	if privateIP != nil && *privateIP == address {
+		return false, true
+	}
+	return false, false
+}
+
+// networkSecurityGroupsFirewallRules returns the inbound rules of the network security groups, default rules excluded
+func networkSecurityGroupsFirewallRules(securityGroups []*armnetwork.SecurityGroup) []FirewallRule {
+	var rules []FirewallRule
+	for _, securityGroup := range securityGroups {
+		if securityGroup == nil || securityGroup.Properties == nil {
+			continue
+		}
+		for _, securityRule := range securityGroup.Properties.SecurityRules {
+			if securityRule == nil || securityRule.Properties == nil {
+				continue
+			}
+			properties := securityRule.Properties
+			if properties.Direction == nil || *properties.Direction != armnetwork.SecurityRuleDirectionInbound {
+				continue
+			}
+			rule := FirewallRule{
+				Source: stringValue(securityGroup.Name),
+				Name:   stringValue(securityRule.Name),
+				Allow:  properties.Access != nil && *properties.Access == armnetwork.SecurityRuleAccessAllow,
+			}
+			if properties.Priority != nil {
+				rule.Priority = *properties.Priority
+			}
+			if properties.Protocol != nil && *properties.Protocol != armnetwork.SecurityRuleProtocolAsterisk {
+				rule.Protocol = string(*properties.Protocol)
+			}
+			for _, portRange := range append([]*string{properties.DestinationPortRange}, properties.DestinationPortRanges...) {
+				if portRange != nil && *portRange != "" && *portRange != "*" {
+					rule.Ports = append(rule.Ports, *portRange)
+				}
+			}
+			for _, sourceRange := range append([]*string{properties.SourceAddressPrefix}, properties.SourceAddressPrefixes...) {
+				if sourceRange != nil && *sourceRange != "" {
+					rule.SourceRanges = append(rule.SourceRanges, *sourceRange)
+				}
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,130 @@
+package v1
+
+import (
+	"context"
+	"strings"
+
+	compute "cloud.google.com/go/compute/apiv1"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+	"google.golang.org/api/iterator"
+	computepb "google.golang.org/genproto/googleapis/cloud/compute/v1"
+)
+
+// GetLoadBalancer returns the forwarding rule with the IP of a Service of type LoadBalancer, with the rules of the firewalls GKE creates for it (k8s-fw-<forwarding rule name>)
+func (gkeSupport *GKESupport) GetLoadBalancer(ctx context.Context, project string, region string, address string) (_ *LoadBalancer, err error) {
+	ctx, span := tracing.StartSpan(ctx, "compute.GetLoadBalancer", tracing.AttributeCloudProvider.String(tracing.CloudProviderGCP), tracing.AttributeCloudRegion.String(region))
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx = gkeSupport.options.gcpContext(ctx)
+	clientOptions, err := gkeSupport.options.gcpRESTClientOptions(ctx, GCPServiceCompute)
+	if err != nil {
+		return nil, err
+	}
+
+	forwardingRulesClient, err := compute.NewForwardingRulesRESTClient(ctx, clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	defer forwardingRulesClient.Close()
+
+	var forwardingRule *computepb.ForwardingRule
+	forwardingRules := forwardingRulesClient.List(ctx, &computepb.ListForwardingRulesRequest{Project: project, Region: region})
+	for forwardingRule == nil {
+		var rule *computepb.ForwardingRule
+		err = metrics.ObserveCall(metrics.SourceGCP, "compute.ForwardingRules.List", isThrottlingError, func() error {
+			var err error
+			rule, err = forwardingRules.Next()
+			return err
+		})
+		if err == iterator.Done {
+			return nil, loadBalancerNotFoundError(address)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if rule.GetIPAddress() == address {
+			forwardingRule = rule
+		}
+	}
+	loadBalancer := forwardingRuleLoadBalancer(forwardingRule)
+
+	firewallsClient, err := compute.NewFirewallsRESTClient(ctx, clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	defer firewallsClient.Close()
+
+	var firewalls []*computepb.Firewall
+	firewallsIterator := firewallsClient.List(ctx, &computepb.ListFirewallsRequest{Project: project})
+	for {
+		var firewall *computepb.Firewall
+		err = metrics.ObserveCall(metrics.SourceGCP, "compute.Firewalls.List", isThrottlingError, func() error {
+			var err error
+			firewall, err = firewallsIterator.Next()
+			return err
+		})
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isForwardingRuleFirewall(forwardingRule, firewall) {
+			firewalls = append(firewalls, firewall)
+		}
+	}
+	loadBalancer.FirewallRules = firewallsFirewallRules(firewalls)
+	return loadBalancer, nil
+}
+
+func forwardingRuleLoadBalancer(forwardingRule *computepb.ForwardingRule) *LoadBalancer {
+	scheme := forwardingRule.GetLoadBalancingScheme()
+	return &LoadBalancer{
+		Provider:       tracing.CloudProviderGCP,
+		Type:           LoadBalancerTypeGCPForwardingRule,
+		ID:             forwardingRule.GetSelfLink(),
+		Name:           forwardingRule.GetName(),
+		Address:        forwardingRule.GetIPAddress(),
+		InternetFacing: scheme == computepb.ForwardingRule_EXTERNAL.String() || scheme == computepb.ForwardingRule_EXTERNAL_MANAGED.String(),
+	}
+}
+
+// isForwardingRuleFirewall returns true for the enabled ingress firewalls named after the forwarding rule.
+// External forwarding rules have no network, in which case the firewall network is not checked
+func isForwardingRuleFirewall(forwardingRule *computepb.ForwardingRule, firewall *computepb.Firewall) bool {
+	if firewall.GetDisabled() || firewall.GetDirection() != computepb.Firewall_INGRESS.String() {
+		return false
+	}
+	if network := forwardingRule.GetNetwork(); network != "" && network != firewall.GetNetwork() {
+		return false
+	}
+	return strings.Contains(firewall.GetName(), forwardingRule.GetName())
+}
+
+// firewallsFirewallRules returns a rule per protocol of the allowed/denied protocols of the firewalls
+func firewallsFirewallRules(firewalls []*computepb.Firewall) []FirewallRule {
+	var rules []FirewallRule
+	for _, firewall := range firewalls {
+		newRule := func(allow bool, protocol string, ports []string) FirewallRule {
+			rule := FirewallRule{
+				Source:       firewall.GetName(),
+				Name:         firewall.GetName(),
+				Allow:        allow,
+				Priority:     firewall.GetPriority(),
+				Ports:        ports,
+				SourceRanges: firewall.GetSourceRanges(),
+			}
+			if protocol != "all" {
+				rule.Protocol = protocol
+			}
+			return rule
+		}
+		for _, allowed := range firewall.GetAllowed() {
+			rules = append(rules, newRule(true, allowed.GetIPProtocol(), allowed.GetPorts()))
+		}
+		for _, denied := range firewall.GetDenied() {
+			rules = append(rules, newRule(false, denied.GetIPProtocol(), denied.GetPorts()))
+		}
+	}
+	return rules
+}
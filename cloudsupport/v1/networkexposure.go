@@ -0,0 +1,140 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/kubescape/k8s-interface/tracing"
+	containerpb "google.golang.org/genproto/googleapis/container/v1"
+)
+
+// ControlPlaneNetworkExposure is the network exposure of the API server of a managed cluster, normalized across the providers
+type ControlPlaneNetworkExposure struct {
+	Provider string `json:"provider"`
+	// Endpoint is the public endpoint of the API server (the private one for the private clusters of AKS), PrivateEndpointAddress the private one if known
+	Endpoint               string `json:"endpoint,omitempty"`
+	PrivateEndpointAddress string `json:"privateEndpointAddress,omitempty"`
+	// PublicEndpoint is true if the API server is reachable from the internet, PrivateEndpoint if it is reachable from the network of the cluster
+	PublicEndpoint  bool `json:"publicEndpoint"`
+	PrivateEndpoint bool `json:"privateEndpoint"`
+	// PrivateCluster is true for the private clusters: EKS private endpoint only, AKS private cluster (private link), GKE private nodes
+	PrivateCluster bool `json:"privateCluster"`
+	// AuthorizedNetworksEnabled is true if the public endpoint is restricted to AllowedCIDRs
+	AuthorizedNetworksEnabled bool     `json:"authorizedNetworksEnabled"`
+	AllowedCIDRs              []string `json:"allowedCIDRs,omitempty"`
+}
+
+// IsOpenToInternet returns true if the public endpoint is reachable from any address
+func (exposure *ControlPlaneNetworkExposure) IsOpenToInternet() bool {
+	return exposure.PublicEndpoint && authorizedNetworks(exposure.AllowedCIDRs) == nil
+}
+
+// GetControlPlaneNetworkExposure returns the endpoint access and the public access CIDRs of the cluster
+func (eksSupport *EKSSupport) GetControlPlaneNetworkExposure(ctx context.Context, cluster string, region string) (_ *ControlPlaneNetworkExposure, err error) {
+	_, span := tracing.StartSpan(ctx, "eks.GetControlPlaneNetworkExposure", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	clusterDescribe, err := eksSupport.GetClusterDescribe(cluster, region)
+	if err != nil {
+		return nil, err
+	}
+	return newControlPlaneNetworkExposureEKS(clusterDescribe)
+}
+
+// GetControlPlaneNetworkExposure returns the API server access profile of the cluster: private cluster and authorized IP ranges
+func (AKSSupport *AKSSupport) GetControlPlaneNetworkExposure(ctx context.Context, subscriptionId string, clusterName string, resourceGroup string) (_ *ControlPlaneNetworkExposure, err error) {
+	_, span := tracing.StartSpan(ctx, "aks.GetControlPlaneNetworkExposure", tracing.AttributeCloudProvider.String(tracing.CloudProviderAzure), tracing.AttributeCloudCluster.String(clusterName))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	managedCluster, err := AKSSupport.GetClusterDescribe(subscriptionId, clusterName, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	return newControlPlaneNetworkExposureAKS(managedCluster)
+}
+
+// GetControlPlaneNetworkExposure returns the private cluster config and the master authorized networks of the cluster
+func (gkeSupport *GKESupport) GetControlPlaneNetworkExposure(ctx context.Context, cluster string, region string, project string) (_ *ControlPlaneNetworkExposure, err error) {
+	_, span := tracing.StartSpan(ctx, "gke.GetControlPlaneNetworkExposure", tracing.AttributeCloudProvider.String(tracing.CloudProviderGCP), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	clusterDescribe, err := gkeSupport.GetClusterDescribe(cluster, region, project)
+	if err != nil {
+		return nil, err
+	}
+	return newControlPlaneNetworkExposureGKE(clusterDescribe)
+}
+
+func newControlPlaneNetworkExposureEKS(clusterDescribe *eks.DescribeClusterOutput) (*ControlPlaneNetworkExposure, error) {
+	if clusterDescribe == nil || clusterDescribe.Cluster == nil {
+		return nil, fmt.Errorf("error getting cluster descriptive information")
+	}
+	exposure := &ControlPlaneNetworkExposure{Provider: tracing.CloudProviderAWS}
+	if clusterDescribe.Cluster.Endpoint != nil {
+		exposure.Endpoint = *clusterDescribe.Cluster.Endpoint
+	}
+	if vpcConfig := clusterDescribe.Cluster.ResourcesVpcConfig; vpcConfig != nil {
+		exposure.PublicEndpoint = vpcConfig.EndpointPublicAccess
+		exposure.PrivateEndpoint = vpcConfig.EndpointPrivateAccess
+		exposure.PrivateCluster = !vpcConfig.EndpointPublicAccess && vpcConfig.EndpointPrivateAccess
+		if vpcConfig.EndpointPublicAccess {
+			exposure.AllowedCIDRs = vpcConfig.PublicAccessCidrs
+		}
+	}
+	exposure.AuthorizedNetworksEnabled = exposure.PublicEndpoint && authorizedNetworks(exposure.AllowedCIDRs) != nil
+	return exposure, nil
+}
+
+func newControlPlaneNetworkExposureAKS(managedCluster *armcontainerservice.ManagedCluster) (*ControlPlaneNetworkExposure, error) {
+	if managedCluster == nil || managedCluster.Properties == nil {
+		return nil, fmt.Errorf("error getting cluster descriptive information")
+	}
+	properties := managedCluster.Properties
+	exposure := &ControlPlaneNetworkExposure{Provider: tracing.CloudProviderAzure, PublicEndpoint: true}
+	if properties.Fqdn != nil {
+		exposure.Endpoint = *properties.Fqdn
+	}
+	if accessProfile := properties.APIServerAccessProfile; accessProfile != nil {
+		if accessProfile.EnablePrivateCluster != nil && *accessProfile.EnablePrivateCluster {
+			// the public FQDN of a private cluster resolves to the private endpoint
+			exposure.PublicEndpoint = false
+			exposure.PrivateEndpoint = true
+			exposure.PrivateCluster = true
+			if properties.PrivateFQDN != nil {
+				exposure.Endpoint = *properties.PrivateFQDN
+				exposure.PrivateEndpointAddress = *properties.PrivateFQDN
+			}
+		}
+		for _, ipRange := range accessProfile.AuthorizedIPRanges {
+			if ipRange != nil {
+				exposure.AllowedCIDRs = append(exposure.AllowedCIDRs, *ipRange)
+			}
+		}
+	}
+	exposure.AuthorizedNetworksEnabled = exposure.PublicEndpoint && authorizedNetworks(exposure.AllowedCIDRs) != nil
+	return exposure, nil
+}
+
+func newControlPlaneNetworkExposureGKE(cluster *containerpb.Cluster) (*ControlPlaneNetworkExposure, error) {
+	if cluster == nil {
+		return nil, fmt.Errorf("error getting cluster descriptive information")
+	}
+	privateClusterConfig := cluster.GetPrivateClusterConfig()
+	exposure := &ControlPlaneNetworkExposure{
+		Provider:               tracing.CloudProviderGCP,
+		Endpoint:               cluster.GetEndpoint(),
+		PrivateEndpointAddress: privateClusterConfig.GetPrivateEndpoint(),
+		PublicEndpoint:         !privateClusterConfig.GetEnablePrivateEndpoint(),
+		PrivateEndpoint:        privateClusterConfig.GetEnablePrivateNodes() || privateClusterConfig.GetEnablePrivateEndpoint(),
+		PrivateCluster:         privateClusterConfig.GetEnablePrivateNodes(),
+	}
+	if authorizedNetworksConfig := cluster.GetMasterAuthorizedNetworksConfig(); authorizedNetworksConfig.GetEnabled() {
+		for _, cidrBlock := range authorizedNetworksConfig.GetCidrBlocks() {
+			exposure.AllowedCIDRs = append(exposure.AllowedCIDRs, cidrBlock.GetCidrBlock())
+		}
+	}
+	exposure.AuthorizedNetworksEnabled = exposure.PublicEndpoint && authorizedNetworks(exposure.AllowedCIDRs) != nil
+	return exposure, nil
+}
@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/kubescape/k8s-interface/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	containerpb "google.golang.org/genproto/googleapis/container/v1"
+)
+
+func TestGetControlPlaneNetworkExposureMocks(t *testing.T) {
+	exposure, err := NewEKSSupportMock().GetControlPlaneNetworkExposure(context.Background(), "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://XXXXXXXXXXXX.r45.eu-west-23.eks.amazonaws.com", exposure.Endpoint)
+	assert.True(t, exposure.PublicEndpoint)
+	assert.True(t, exposure.PrivateEndpoint)
+	assert.False(t, exposure.PrivateCluster)
+	assert.False(t, exposure.AuthorizedNetworksEnabled)
+	assert.True(t, exposure.IsOpenToInternet())
+
+	exposure, err = NewAKSSupportMock().GetControlPlaneNetworkExposure(context.Background(), "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, tracing.CloudProviderAzure, exposure.Provider)
+	assert.True(t, exposure.IsOpenToInternet())
+
+	exposure, err = NewGKESupportMock().GetControlPlaneNetworkExposure(context.Background(), "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "0.0.0.0", exposure.Endpoint)
+	assert.False(t, exposure.PrivateCluster)
+	assert.True(t, exposure.IsOpenToInternet())
+}
+
+func TestNewControlPlaneNetworkExposure(t *testing.T) {
+	exposure, err := newControlPlaneNetworkExposureEKS(&eks.DescribeClusterOutput{Cluster: &ekstypes.Cluster{
+		ResourcesVpcConfig: &ekstypes.VpcConfigResponse{EndpointPrivateAccess: true, PublicAccessCidrs: []string{"0.0.0.0/0"}},
+	}})
+	require.NoError(t, err)
+	assert.True(t, exposure.PrivateCluster)
+	assert.Nil(t, exposure.AllowedCIDRs, "the public access CIDRs do not apply to a private endpoint")
+	assert.False(t, exposure.IsOpenToInternet())
+
+	exposure, err = newControlPlaneNetworkExposureAKS(&armcontainerservice.ManagedCluster{Properties: &armcontainerservice.ManagedClusterProperties{
+		Fqdn:                   strPtr("aks-dns.hcp.westeurope.azmk8s.io"),
+		APIServerAccessProfile: &armcontainerservice.ManagedClusterAPIServerAccessProfile{AuthorizedIPRanges: []*string{strPtr("198.51.100.0/24")}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "aks-dns.hcp.westeurope.azmk8s.io", exposure.Endpoint)
+	assert.True(t, exposure.PublicEndpoint)
+	assert.True(t, exposure.AuthorizedNetworksEnabled)
+	assert.Equal(t, []string{"198.51.100.0/24"}, exposure.AllowedCIDRs)
+	assert.False(t, exposure.IsOpenToInternet())
+
+	exposure, err = newControlPlaneNetworkExposureAKS(&armcontainerservice.ManagedCluster{Properties: &armcontainerservice.ManagedClusterProperties{
+		Fqdn:                   strPtr("aks-dns.hcp.westeurope.azmk8s.io"),
+		PrivateFQDN:            strPtr("aks-dns.privatelink.westeurope.azmk8s.io"),
+		APIServerAccessProfile: &armcontainerservice.ManagedClusterAPIServerAccessProfile{EnablePrivateCluster: boolPtr(true)},
+	}})
+	require.NoError(t, err)
+	assert.False(t, exposure.PublicEndpoint)
+	assert.True(t, exposure.PrivateCluster)
+	assert.Equal(t, "aks-dns.privatelink.westeurope.azmk8s.io", exposure.Endpoint)
+
+	exposure, err = newControlPlaneNetworkExposureGKE(&containerpb.Cluster{
+		Endpoint:             "34.1.2.3",
+		PrivateClusterConfig: &containerpb.PrivateClusterConfig{EnablePrivateNodes: true, PrivateEndpoint: "172.16.0.2"},
+		MasterAuthorizedNetworksConfig: &containerpb.MasterAuthorizedNetworksConfig{
+			Enabled:    true,
+			CidrBlocks: []*containerpb.MasterAuthorizedNetworksConfig_CidrBlock{{CidrBlock: "0.0.0.0/0"}},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, exposure.PublicEndpoint)
+	assert.True(t, exposure.PrivateEndpoint)
+	assert.True(t, exposure.PrivateCluster)
+	assert.Equal(t, "172.16.0.2", exposure.PrivateEndpointAddress)
+	assert.False(t, exposure.AuthorizedNetworksEnabled, "0.0.0.0/0 allows any network")
+	assert.True(t, exposure.IsOpenToInternet())
+}
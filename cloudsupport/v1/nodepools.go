@@ -0,0 +1,176 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/kubescape/k8s-interface/tracing"
+	containerpb "google.golang.org/genproto/googleapis/container/v1"
+)
+
+// NodePool is a node pool (EKS managed node group, AKS agent pool, GKE node pool) with its lifecycle settings, normalized across the providers
+type NodePool struct {
+	Provider      string   `json:"provider"`
+	Name          string   `json:"name"`
+	Version       string   `json:"version,omitempty"`
+	InstanceTypes []string `json:"instanceTypes,omitempty"`
+	// Spot is true for the spot (AWS/Azure/GCP) and preemptible (GCP) nodes, which the provider can reclaim at any time
+	Spot        bool  `json:"spot"`
+	Autoscaling bool  `json:"autoscaling"`
+	MinCount    int32 `json:"minCount,omitempty"`
+	MaxCount    int32 `json:"maxCount,omitempty"`
+	// MaxSurge and MaxUnavailable are the number (e.g. 1) or percentage (e.g. 33%) of nodes added and removed at once during an upgrade,
+	// empty if the provider does not support the setting or uses its default
+	MaxSurge       string `json:"maxSurge,omitempty"`
+	MaxUnavailable string `json:"maxUnavailable,omitempty"`
+	// AutoRepair and AutoUpgrade are nil if the provider does not report them
+	AutoRepair  *bool `json:"autoRepair,omitempty"`
+	AutoUpgrade *bool `json:"autoUpgrade,omitempty"`
+	// UpgradeChannel is the AKS auto-upgrade channel or the GKE release channel of the cluster, empty if none
+	UpgradeChannel string `json:"upgradeChannel,omitempty"`
+}
+
+// GetNodePools returns the agent pools of the cluster. AKS always repairs the unhealthy nodes
+func (AKSSupport *AKSSupport) GetNodePools(ctx context.Context, subscriptionId string, clusterName string, resourceGroup string) (_ []NodePool, err error) {
+	_, span := tracing.StartSpan(ctx, "aks.GetNodePools", tracing.AttributeCloudProvider.String(tracing.CloudProviderAzure), tracing.AttributeCloudCluster.String(clusterName))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	managedCluster, err := AKSSupport.GetClusterDescribe(subscriptionId, clusterName, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	return newNodePoolsAKS(managedCluster)
+}
+
+// GetNodePools returns the node pools of the cluster
+func (gkeSupport *GKESupport) GetNodePools(ctx context.Context, cluster string, region string, project string) (_ []NodePool, err error) {
+	_, span := tracing.StartSpan(ctx, "gke.GetNodePools", tracing.AttributeCloudProvider.String(tracing.CloudProviderGCP), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	clusterDescribe, err := gkeSupport.GetClusterDescribe(cluster, region, project)
+	if err != nil {
+		return nil, err
+	}
+	return newNodePoolsGKE(clusterDescribe)
+}
+
+// newNodePoolEKS returns the node pool of the managed node group. EKS does not upgrade the node groups automatically
+// and does not surge, it replaces up to MaxUnavailable nodes at once (1 by default)
+func newNodePoolEKS(nodegroup *ekstypes.Nodegroup) NodePool {
+	nodePool := NodePool{
+		Provider:      tracing.CloudProviderAWS,
+		InstanceTypes: nodegroup.InstanceTypes,
+		Spot:          nodegroup.CapacityType == ekstypes.CapacityTypesSpot,
+		AutoUpgrade:   boolPtr(false),
+	}
+	if nodegroup.NodegroupName != nil {
+		nodePool.Name = *nodegroup.NodegroupName
+	}
+	if nodegroup.Version != nil {
+		nodePool.Version = *nodegroup.Version
+	}
+	if scalingConfig := nodegroup.ScalingConfig; scalingConfig != nil {
+		if scalingConfig.MinSize != nil {
+			nodePool.MinCount = *scalingConfig.MinSize
+		}
+		if scalingConfig.MaxSize != nil {
+			nodePool.MaxCount = *scalingConfig.MaxSize
+		}
+		nodePool.Autoscaling = nodePool.MaxCount > nodePool.MinCount
+	}
+	if updateConfig := nodegroup.UpdateConfig; updateConfig != nil {
+		if updateConfig.MaxUnavailable != nil {
+			nodePool.MaxUnavailable = strconv.Itoa(int(*updateConfig.MaxUnavailable))
+		} else if updateConfig.MaxUnavailablePercentage != nil {
+			nodePool.MaxUnavailable = strconv.Itoa(int(*updateConfig.MaxUnavailablePercentage)) + "%"
+		}
+	}
+	return nodePool
+}
+
+func newNodePoolsAKS(managedCluster *armcontainerservice.ManagedCluster) ([]NodePool, error) {
+	if managedCluster == nil || managedCluster.Properties == nil {
+		return nil, fmt.Errorf("error getting cluster descriptive information")
+	}
+	upgradeChannel := ""
+	if autoUpgradeProfile := managedCluster.Properties.AutoUpgradeProfile; autoUpgradeProfile != nil && autoUpgradeProfile.UpgradeChannel != nil &&
+		*autoUpgradeProfile.UpgradeChannel != armcontainerservice.UpgradeChannelNone {
+		upgradeChannel = string(*autoUpgradeProfile.UpgradeChannel)
+	}
+
+	nodePools := []NodePool{}
+	for _, agentPool := range managedCluster.Properties.AgentPoolProfiles {
+		if agentPool == nil {
+			continue
+		}
+		nodePool := NodePool{
+			Provider:       tracing.CloudProviderAzure,
+			Spot:           agentPool.ScaleSetPriority != nil && *agentPool.ScaleSetPriority == armcontainerservice.ScaleSetPrioritySpot,
+			Autoscaling:    agentPool.EnableAutoScaling != nil && *agentPool.EnableAutoScaling,
+			AutoRepair:     boolPtr(true),
+			AutoUpgrade:    boolPtr(upgradeChannel != ""),
+			UpgradeChannel: upgradeChannel,
+		}
+		if agentPool.Name != nil {
+			nodePool.Name = *agentPool.Name
+		}
+		if agentPool.CurrentOrchestratorVersion != nil {
+			nodePool.Version = *agentPool.CurrentOrchestratorVersion
+		} else if agentPool.OrchestratorVersion != nil {
+			nodePool.Version = *agentPool.OrchestratorVersion
+		}
+		if agentPool.VMSize != nil {
+			nodePool.InstanceTypes = []string{*agentPool.VMSize}
+		}
+		if agentPool.MinCount != nil {
+			nodePool.MinCount = *agentPool.MinCount
+		}
+		if agentPool.MaxCount != nil {
+			nodePool.MaxCount = *agentPool.MaxCount
+		}
+		if agentPool.UpgradeSettings != nil && agentPool.UpgradeSettings.MaxSurge != nil {
+			nodePool.MaxSurge = *agentPool.UpgradeSettings.MaxSurge
+		}
+		nodePools = append(nodePools, nodePool)
+	}
+	return nodePools, nil
+}
+
+func newNodePoolsGKE(cluster *containerpb.Cluster) ([]NodePool, error) {
+	if cluster == nil {
+		return nil, fmt.Errorf("error getting cluster descriptive information")
+	}
+	upgradeChannel := ""
+	if channel := cluster.GetReleaseChannel().GetChannel(); channel != containerpb.ReleaseChannel_UNSPECIFIED {
+		upgradeChannel = channel.String()
+	}
+
+	nodePools := []NodePool{}
+	for _, gkeNodePool := range cluster.GetNodePools() {
+		nodePool := NodePool{
+			Provider:       tracing.CloudProviderGCP,
+			Name:           gkeNodePool.GetName(),
+			Version:        gkeNodePool.GetVersion(),
+			Spot:           gkeNodePool.GetConfig().GetSpot() || gkeNodePool.GetConfig().GetPreemptible(),
+			Autoscaling:    gkeNodePool.GetAutoscaling().GetEnabled(),
+			MinCount:       gkeNodePool.GetAutoscaling().GetMinNodeCount(),
+			MaxCount:       gkeNodePool.GetAutoscaling().GetMaxNodeCount(),
+			AutoRepair:     boolPtr(gkeNodePool.GetManagement().GetAutoRepair()),
+			AutoUpgrade:    boolPtr(gkeNodePool.GetManagement().GetAutoUpgrade()),
+			UpgradeChannel: upgradeChannel,
+		}
+		if machineType := gkeNodePool.GetConfig().GetMachineType(); machineType != "" {
+			nodePool.InstanceTypes = []string{machineType}
+		}
+		// the blue-green upgrades replace the whole pool, the surge settings do not apply
+		if upgradeSettings := gkeNodePool.GetUpgradeSettings(); upgradeSettings != nil && upgradeSettings.GetStrategy() != containerpb.NodePoolUpdateStrategy_BLUE_GREEN {
+			nodePool.MaxSurge = strconv.Itoa(int(upgradeSettings.GetMaxSurge()))
+			nodePool.MaxUnavailable = strconv.Itoa(int(upgradeSettings.GetMaxUnavailable()))
+		}
+		nodePools = append(nodePools, nodePool)
+	}
+	return nodePools, nil
+}
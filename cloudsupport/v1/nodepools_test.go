@@ -0,0 +1,120 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/kubescape/k8s-interface/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	containerpb "google.golang.org/genproto/googleapis/container/v1"
+)
+
+func TestGetNodePoolsMocks(t *testing.T) {
+	nodePools, err := NewEKSSupportMock().GetNodePools(context.Background(), "", "")
+	require.NoError(t, err)
+	require.Len(t, nodePools, 1)
+	assert.Equal(t, tracing.CloudProviderAWS, nodePools[0].Provider)
+	assert.False(t, nodePools[0].Spot)
+	assert.True(t, nodePools[0].Autoscaling)
+
+	nodePools, err = NewAKSSupportMock().GetNodePools(context.Background(), "", "", "")
+	require.NoError(t, err)
+	require.Len(t, nodePools, 1)
+	assert.Equal(t, "agentpool", nodePools[0].Name)
+	assert.Equal(t, "1.21.9", nodePools[0].Version)
+	assert.Equal(t, []string{"Standard_B2s"}, nodePools[0].InstanceTypes)
+	assert.True(t, nodePools[0].Autoscaling)
+	assert.Equal(t, int32(1), nodePools[0].MinCount)
+	assert.Equal(t, int32(5), nodePools[0].MaxCount)
+	assert.False(t, nodePools[0].Spot)
+	assert.Empty(t, nodePools[0].MaxSurge)
+
+	nodePools, err = NewGKESupportMock().GetNodePools(context.Background(), "", "", "")
+	require.NoError(t, err)
+	require.Len(t, nodePools, 1)
+	assert.Equal(t, "default-pool", nodePools[0].Name)
+	assert.Equal(t, []string{"e2-medium"}, nodePools[0].InstanceTypes)
+	assert.Equal(t, "1", nodePools[0].MaxSurge)
+	assert.Equal(t, "0", nodePools[0].MaxUnavailable)
+	require.NotNil(t, nodePools[0].AutoRepair)
+	assert.True(t, *nodePools[0].AutoRepair)
+	require.NotNil(t, nodePools[0].AutoUpgrade)
+	assert.True(t, *nodePools[0].AutoUpgrade)
+	assert.Equal(t, "REGULAR", nodePools[0].UpgradeChannel)
+}
+
+func TestNewNodePoolEKS(t *testing.T) {
+	maxUnavailablePercentage := int32(33)
+	nodePool := newNodePoolEKS(&ekstypes.Nodegroup{
+		NodegroupName: strPtr("spot"),
+		CapacityType:  ekstypes.CapacityTypesSpot,
+		UpdateConfig:  &ekstypes.NodegroupUpdateConfig{MaxUnavailablePercentage: &maxUnavailablePercentage},
+	})
+	assert.Equal(t, "spot", nodePool.Name)
+	assert.True(t, nodePool.Spot)
+	assert.False(t, nodePool.Autoscaling)
+	assert.Equal(t, "33%", nodePool.MaxUnavailable)
+	assert.Nil(t, nodePool.AutoRepair)
+	require.NotNil(t, nodePool.AutoUpgrade)
+	assert.False(t, *nodePool.AutoUpgrade)
+}
+
+func TestNewNodePoolsAKS(t *testing.T) {
+	spot := armcontainerservice.ScaleSetPrioritySpot
+	channel := armcontainerservice.UpgradeChannelStable
+	nodePools, err := newNodePoolsAKS(&armcontainerservice.ManagedCluster{
+		Properties: &armcontainerservice.ManagedClusterProperties{
+			AutoUpgradeProfile: &armcontainerservice.ManagedClusterAutoUpgradeProfile{UpgradeChannel: &channel},
+			AgentPoolProfiles: []*armcontainerservice.ManagedClusterAgentPoolProfile{
+				{Name: strPtr("spotpool"), ScaleSetPriority: &spot, UpgradeSettings: &armcontainerservice.AgentPoolUpgradeSettings{MaxSurge: strPtr("33%")}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, nodePools, 1)
+	assert.True(t, nodePools[0].Spot)
+	assert.Equal(t, "33%", nodePools[0].MaxSurge)
+	assert.Equal(t, "stable", nodePools[0].UpgradeChannel)
+	assert.True(t, *nodePools[0].AutoUpgrade)
+	assert.True(t, *nodePools[0].AutoRepair)
+
+	none := armcontainerservice.UpgradeChannelNone
+	nodePools, err = newNodePoolsAKS(&armcontainerservice.ManagedCluster{
+		Properties: &armcontainerservice.ManagedClusterProperties{
+			AutoUpgradeProfile: &armcontainerservice.ManagedClusterAutoUpgradeProfile{UpgradeChannel: &none},
+			AgentPoolProfiles:  []*armcontainerservice.ManagedClusterAgentPoolProfile{{Name: strPtr("regular")}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, nodePools[0].UpgradeChannel)
+	assert.False(t, *nodePools[0].AutoUpgrade)
+
+	_, err = newNodePoolsAKS(&armcontainerservice.ManagedCluster{})
+	assert.Error(t, err)
+}
+
+func TestNewNodePoolsGKE(t *testing.T) {
+	blueGreen := containerpb.NodePoolUpdateStrategy_BLUE_GREEN
+	nodePools, err := newNodePoolsGKE(&containerpb.Cluster{
+		NodePools: []*containerpb.NodePool{
+			{Name: "preemptible", Config: &containerpb.NodeConfig{Preemptible: true}},
+			{Name: "spot", Config: &containerpb.NodeConfig{Spot: true}, Autoscaling: &containerpb.NodePoolAutoscaling{Enabled: true, MinNodeCount: 0, MaxNodeCount: 10}},
+			{Name: "blue-green", UpgradeSettings: &containerpb.NodePool_UpgradeSettings{Strategy: &blueGreen, MaxSurge: 1}},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, nodePools, 3)
+	assert.True(t, nodePools[0].Spot)
+	assert.True(t, nodePools[1].Spot)
+	assert.True(t, nodePools[1].Autoscaling)
+	assert.Equal(t, int32(10), nodePools[1].MaxCount)
+	assert.False(t, nodePools[2].Spot)
+	assert.Empty(t, nodePools[2].MaxSurge)
+	assert.Empty(t, nodePools[2].UpgradeChannel)
+
+	_, err = newNodePoolsGKE(nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+// GetNodePools returns the managed node groups of the cluster. The self-managed node groups and Fargate profiles are not listed
+func (eksSupport *EKSSupport) GetNodePools(ctx context.Context, cluster string, region string) (_ []NodePool, err error) {
+	ctx, span := tracing.StartSpan(ctx, "eks.GetNodePools", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	awsConfig, err := eksSupport.options.loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error: fail to load AWS SDK default %v", err)
+	}
+	awsConfig.Region = region
+	svc := eks.NewFromConfig(awsConfig)
+
+	nodegroupNames := []string{}
+	paginator := eks.NewListNodegroupsPaginator(svc, &eks.ListNodegroupsInput{ClusterName: aws.String(cluster)})
+	for paginator.HasMorePages() {
+		var page *eks.ListNodegroupsOutput
+		err = metrics.ObserveCall(metrics.SourceAWS, "eks.ListNodegroups", isThrottlingError, func() error {
+			var err error
+			page, err = paginator.NextPage(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		nodegroupNames = append(nodegroupNames, page.Nodegroups...)
+	}
+
+	nodePools := []NodePool{}
+	for _, nodegroupName := range nodegroupNames {
+		var nodegroup *eks.DescribeNodegroupOutput
+		err = metrics.ObserveCall(metrics.SourceAWS, "eks.DescribeNodegroup", isThrottlingError, func() error {
+			var err error
+			nodegroup, err = svc.DescribeNodegroup(ctx, &eks.DescribeNodegroupInput{ClusterName: aws.String(cluster), NodegroupName: aws.String(nodegroupName)})
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if nodegroup.Nodegroup != nil {
+			nodePools = append(nodePools, newNodePoolEKS(nodegroup.Nodegroup))
+		}
+	}
+	return nodePools, nil
+}
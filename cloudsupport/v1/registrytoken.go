@@ -0,0 +1,293 @@
+package v1
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/kubescape/k8s-interface/metrics"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	registryTokenCacheName = "registryToken"
+
+	// DefaultRegistryTokenRefreshBefore is how long before expiry a cached registry token is refreshed
+	DefaultRegistryTokenRefreshBefore = 5 * time.Minute
+
+	acrTokenUsername = "00000000-0000-0000-0000-000000000000"
+	gcrTokenUsername = "oauth2accesstoken"
+)
+
+var (
+	// <account>.dkr.ecr[-fips].<region>.amazonaws.com[.cn]
+	ecrHostRegexp = regexp.MustCompile(`^(\d{12})\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?$`)
+
+	registryTokenHTTPClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// RegistryToken is a short-lived registry credential minted from the cloud identity
+type RegistryToken struct {
+	Registry  string    `json:"registry"`
+	Username  string    `json:"username"`
+	Password  string    `json:"password"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// IsExpired returns true if the token expires within the given duration. A token without an expiry never expires
+func (token *RegistryToken) IsExpired(within time.Duration) bool {
+	if token.ExpiresAt.IsZero() {
+		return false
+	}
+	return !time.Now().Add(within).Before(token.ExpiresAt)
+}
+
+// IRegistryTokenProvider mints registry tokens for a registry host
+type IRegistryTokenProvider interface {
+	GetRegistryToken(ctx context.Context, registryHost string) (*RegistryToken, error)
+}
+
+// IsECRRegistry returns true if the host is an AWS ECR registry
+func IsECRRegistry(registryHost string) bool {
+	return ecrHostRegexp.MatchString(registryHost)
+}
+
+// IsACRRegistry returns true if the host is an Azure ACR registry
+func IsACRRegistry(registryHost string) bool {
+	return strings.HasSuffix(registryHost, ".azurecr.io") || strings.HasSuffix(registryHost, ".azurecr.cn") || strings.HasSuffix(registryHost, ".azurecr.us")
+}
+
+// IsGARRegistry returns true if the host is a Google Artifact Registry or Container Registry
+func IsGARRegistry(registryHost string) bool {
+	return registryHost == "gcr.io" || strings.HasSuffix(registryHost, ".gcr.io") || strings.HasSuffix(registryHost, "-docker.pkg.dev")
+}
+
+// GetRegistryTokenProvider returns the cloud provider support able to mint tokens for the registry host
+func GetRegistryTokenProvider(registryHost string) (IRegistryTokenProvider, error) {
+	switch {
+	case IsECRRegistry(registryHost):
+		return NewEKSSupport(), nil
+	case IsACRRegistry(registryHost):
+		return NewAKSSupport(), nil
+	case IsGARRegistry(registryHost):
+		return NewGKESupport(), nil
+	}
+	return nil, fmt.Errorf("registry '%s' is not a supported cloud registry", registryHost)
+}
+
+// CloudRegistryTokenProvider mints registry tokens using the cloud provider matching the registry host
+type CloudRegistryTokenProvider struct {
+}
+
+func NewCloudRegistryTokenProvider() *CloudRegistryTokenProvider {
+	return &CloudRegistryTokenProvider{}
+}
+
+func (cloudRegistryTokenProvider *CloudRegistryTokenProvider) GetRegistryToken(ctx context.Context, registryHost string) (*RegistryToken, error) {
+	provider, err := GetRegistryTokenProvider(registryHost)
+	if err != nil {
+		return nil, err
+	}
+	return provider.GetRegistryToken(ctx, registryHost)
+}
+
+// RegistryTokenCache caches registry tokens per registry host and refreshes them before they expire
+type RegistryTokenCache struct {
+	provider      IRegistryTokenProvider
+	refreshBefore time.Duration
+	tokens        map[string]*RegistryToken
+	// hostLocks serializes the fetches of a registry host, so the tokens of the other hosts are returned while a token is fetched
+	hostLocks map[string]*sync.Mutex
+	// mutex guards tokens and hostLocks, it is not held while fetching
+	mutex sync.Mutex
+}
+
+// NewRegistryTokenCache returns a cache over the provider. When the provider is nil, the cloud provider matching the registry host is used
+func NewRegistryTokenCache(provider IRegistryTokenProvider, refreshBefore time.Duration) *RegistryTokenCache {
+	if provider == nil {
+		provider = NewCloudRegistryTokenProvider()
+	}
+	return &RegistryTokenCache{
+		provider:      provider,
+		refreshBefore: refreshBefore,
+		tokens:        map[string]*RegistryToken{},
+		hostLocks:     map[string]*sync.Mutex{},
+	}
+}
+
+// GetRegistryToken returns the cached token of the registry, minting a new one if there is none or it is about to expire
+func (cache *RegistryTokenCache) GetRegistryToken(ctx context.Context, registryHost string) (*RegistryToken, error) {
+	if token := cache.cachedToken(registryHost); token != nil {
+		metrics.GetRecorder().ObserveCache(registryTokenCacheName, true)
+		return token, nil
+	}
+
+	hostLock := cache.hostLock(registryHost)
+	hostLock.Lock()
+	defer hostLock.Unlock()
+	// fetched by a concurrent call while waiting for the lock
+	if token := cache.cachedToken(registryHost); token != nil {
+		metrics.GetRecorder().ObserveCache(registryTokenCacheName, true)
+		return token, nil
+	}
+	metrics.GetRecorder().ObserveCache(registryTokenCacheName, false)
+	token, err := cache.provider.GetRegistryToken(ctx, registryHost)
+	if err != nil {
+		return nil, err
+	}
+	cache.mutex.Lock()
+	cache.tokens[registryHost] = token
+	cache.mutex.Unlock()
+	return token, nil
+}
+
+// cachedToken returns the cached token of the registry, nil if there is none or it is about to expire
+func (cache *RegistryTokenCache) cachedToken(registryHost string) *RegistryToken {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if token, ok := cache.tokens[registryHost]; ok && !token.IsExpired(cache.refreshBefore) {
+		return token
+	}
+	return nil
+}
+
+func (cache *RegistryTokenCache) hostLock(registryHost string) *sync.Mutex {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	hostLock, ok := cache.hostLocks[registryHost]
+	if !ok {
+		hostLock = &sync.Mutex{}
+		cache.hostLocks[registryHost] = hostLock
+	}
+	return hostLock
+}
+
+// Invalidate removes the cached token of the registry, e.g. after the registry rejected it
+func (cache *RegistryTokenCache) Invalidate(registryHost string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	delete(cache.tokens, registryHost)
+}
+
+// parseECRRegistryHost returns the account ID and region of an ECR registry host
+func parseECRRegistryHost(registryHost string) (string, string, error) {
+	match := ecrHostRegexp.FindStringSubmatch(registryHost)
+	if match == nil {
+		return "", "", fmt.Errorf("registry '%s' is not an ECR registry", registryHost)
+	}
+	return match[1], match[2], nil
+}
+
+// jwtClaims returns the claims of a JWT without verifying it
+func jwtClaims(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid JWT: expected at least 2 parts, got %d", len(parts))
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT payload: %w", err)
+	}
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("invalid JWT claims: %w", err)
+	}
+	return claims, nil
+}
+
+// jwtExpiry returns the expiry of a JWT, or the zero time if it has none
+func jwtExpiry(token string) time.Time {
+	claims, err := jwtClaims(token)
+	if err != nil {
+		return time.Time{}
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		return time.Unix(int64(exp), 0)
+	}
+	return time.Time{}
+}
+
+// exchangeAADTokenForACRRefreshToken exchanges an AAD access token for an ACR refresh token, see https://github.com/Azure/acr/blob/main/docs/AAD-OAuth.md
+func exchangeAADTokenForACRRefreshToken(ctx context.Context, httpClient *http.Client, registryHost, tenantID, aadAccessToken string) (string, error) {
+	params := url.Values{}
+	params.Add("grant_type", "access_token")
+	params.Add("service", registryHost)
+	params.Add("tenant", tenantID)
+	params.Add("access_token", aadAccessToken)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://%s/oauth2/exchange", registryHost), strings.NewReader(params.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("creating HTTP request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("calling ACR exchange endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", newHTTPResponseError("ACR exchange", resp, body)
+	}
+	result := struct {
+		RefreshToken string `json:"refresh_token"`
+	}{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("unmarshalling the response: %w", err)
+	}
+	if result.RefreshToken == "" {
+		return "", fmt.Errorf("ACR exchange response has no refresh token")
+	}
+	return result.RefreshToken, nil
+}
+
+// isThrottlingError returns true if the cloud provider rejected the call because of rate limiting, from the error code or the HTTP status of the
+// errors of the SDKs, see cloudErrorStatus
+func isThrottlingError(err error) bool {
+	if err == nil {
+		return false
+	}
+	code, httpStatus := cloudErrorStatus(err)
+	if _, ok := retry.DefaultThrottleErrorCodes[code]; ok {
+		return true
+	}
+	switch code {
+	case "TooManyRequests", "rateLimitExceeded", "userRateLimitExceeded", codes.ResourceExhausted.String():
+		return true
+	}
+	return httpStatus == http.StatusTooManyRequests
+}
+
+// httpResponseError is the error of an unsuccessful response of the APIs called without their SDK, with its HTTP status
+type httpResponseError struct {
+	api        string
+	status     string
+	statusCode int
+	body       string
+}
+
+func newHTTPResponseError(api string, resp *http.Response, body []byte) error {
+	return &httpResponseError{api: api, status: resp.Status, statusCode: resp.StatusCode, body: string(body)}
+}
+
+func (e *httpResponseError) Error() string {
+	return fmt.Sprintf("%s response: %s, %s", e.api, e.status, e.body)
+}
+
+// HTTPStatusCode returns the HTTP status of the response, like the errors of the AWS SDK
+func (e *httpResponseError) HTTPStatusCode() int {
+	return e.statusCode
+}
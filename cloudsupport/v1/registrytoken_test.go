@@ -0,0 +1,179 @@
+package v1
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type countingRegistryTokenProvider struct {
+	calls   int
+	expires time.Duration
+}
+
+func (provider *countingRegistryTokenProvider) GetRegistryToken(ctx context.Context, registryHost string) (*RegistryToken, error) {
+	provider.calls++
+	return &RegistryToken{
+		Registry:  registryHost,
+		Password:  fmt.Sprintf("token-%d", provider.calls),
+		ExpiresAt: time.Now().Add(provider.expires),
+	}, nil
+}
+
+func TestRegistryTokenCache(t *testing.T) {
+	provider := &countingRegistryTokenProvider{expires: time.Hour}
+	cache := NewRegistryTokenCache(provider, DefaultRegistryTokenRefreshBefore)
+
+	token, err := cache.GetRegistryToken(context.Background(), "myregistry.azurecr.io")
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", token.Password)
+
+	// cached
+	token, err = cache.GetRegistryToken(context.Background(), "myregistry.azurecr.io")
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", token.Password)
+
+	// per registry
+	token, err = cache.GetRegistryToken(context.Background(), "gcr.io")
+	assert.NoError(t, err)
+	assert.Equal(t, "token-2", token.Password)
+
+	cache.Invalidate("gcr.io")
+	token, err = cache.GetRegistryToken(context.Background(), "gcr.io")
+	assert.NoError(t, err)
+	assert.Equal(t, "token-3", token.Password)
+
+	// refreshed when about to expire
+	provider.expires = time.Minute
+	cache.Invalidate("gcr.io")
+	_, err = cache.GetRegistryToken(context.Background(), "gcr.io")
+	assert.NoError(t, err)
+	token, err = cache.GetRegistryToken(context.Background(), "gcr.io")
+	assert.NoError(t, err)
+	assert.Equal(t, "token-5", token.Password)
+	assert.Equal(t, 5, provider.calls)
+}
+
+// blockingRegistryTokenProvider blocks the fetches of the registry host until unblocked
+type blockingRegistryTokenProvider struct {
+	blockedHost string
+	blocked     chan struct{}
+	unblock     chan struct{}
+	calls       int32
+}
+
+func (provider *blockingRegistryTokenProvider) GetRegistryToken(ctx context.Context, registryHost string) (*RegistryToken, error) {
+	atomic.AddInt32(&provider.calls, 1)
+	if registryHost == provider.blockedHost {
+		close(provider.blocked)
+		<-provider.unblock
+	}
+	return &RegistryToken{Registry: registryHost, Password: registryHost, ExpiresAt: time.Now().Add(time.Hour)}, nil
+}
+
+func TestRegistryTokenCacheConcurrent(t *testing.T) {
+	provider := &blockingRegistryTokenProvider{blockedHost: "gcr.io", blocked: make(chan struct{}), unblock: make(chan struct{})}
+	cache := NewRegistryTokenCache(provider, DefaultRegistryTokenRefreshBefore)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := cache.GetRegistryToken(context.Background(), "gcr.io")
+			assert.NoError(t, err)
+			assert.Equal(t, "gcr.io", token.Password)
+		}()
+	}
+	<-provider.blocked
+
+	// the other registries are not blocked by the fetch
+	token, err := cache.GetRegistryToken(context.Background(), "myregistry.azurecr.io")
+	assert.NoError(t, err)
+	assert.Equal(t, "myregistry.azurecr.io", token.Password)
+
+	// the concurrent calls of the registry fetch a single token
+	close(provider.unblock)
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&provider.calls))
+}
+
+func TestRegistryTokenIsExpired(t *testing.T) {
+	assert.False(t, (&RegistryToken{}).IsExpired(time.Hour))
+	assert.True(t, (&RegistryToken{ExpiresAt: time.Now().Add(-time.Second)}).IsExpired(0))
+	assert.True(t, (&RegistryToken{ExpiresAt: time.Now().Add(time.Minute)}).IsExpired(DefaultRegistryTokenRefreshBefore))
+	assert.False(t, (&RegistryToken{ExpiresAt: time.Now().Add(time.Hour)}).IsExpired(DefaultRegistryTokenRefreshBefore))
+}
+
+func TestGetRegistryTokenProvider(t *testing.T) {
+	provider, err := GetRegistryTokenProvider("015253967648.dkr.ecr.eu-central-1.amazonaws.com")
+	assert.NoError(t, err)
+	assert.IsType(t, &EKSSupport{}, provider)
+
+	provider, err = GetRegistryTokenProvider("myregistry.azurecr.io")
+	assert.NoError(t, err)
+	assert.IsType(t, &AKSSupport{}, provider)
+
+	provider, err = GetRegistryTokenProvider("europe-west1-docker.pkg.dev")
+	assert.NoError(t, err)
+	assert.IsType(t, &GKESupport{}, provider)
+
+	provider, err = GetRegistryTokenProvider("eu.gcr.io")
+	assert.NoError(t, err)
+	assert.IsType(t, &GKESupport{}, provider)
+
+	_, err = GetRegistryTokenProvider("quay.io")
+	assert.Error(t, err)
+}
+
+func TestParseECRRegistryHost(t *testing.T) {
+	accountID, region, err := parseECRRegistryHost("015253967648.dkr.ecr.eu-central-1.amazonaws.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "015253967648", accountID)
+	assert.Equal(t, "eu-central-1", region)
+
+	accountID, region, err = parseECRRegistryHost("015253967648.dkr.ecr-fips.us-gov-west-1.amazonaws.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "015253967648", accountID)
+	assert.Equal(t, "us-gov-west-1", region)
+
+	_, _, err = parseECRRegistryHost("dkr.ecr.eu-central-1.amazonaws.com")
+	assert.Error(t, err)
+}
+
+func TestJwtExpiry(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"tid":"tenant","exp":1700000000}`))
+	token := "header." + payload + ".signature"
+	assert.Equal(t, time.Unix(1700000000, 0), jwtExpiry(token))
+
+	claims, err := jwtClaims(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant", claims["tid"])
+
+	assert.True(t, jwtExpiry("invalid").IsZero())
+}
+
+func TestIsThrottlingError(t *testing.T) {
+	assert.True(t, isThrottlingError(&smithy.OperationError{ServiceID: "ECR", OperationName: "GetAuthorizationToken", Err: &smithy.GenericAPIError{Code: "ThrottlingException"}}))
+	assert.True(t, isThrottlingError(&azcore.ResponseError{StatusCode: http.StatusTooManyRequests, ErrorCode: "TooManyRequests"}))
+	assert.True(t, isThrottlingError(fmt.Errorf("failed: %w", &googleapi.Error{Code: http.StatusTooManyRequests})))
+	assert.True(t, isThrottlingError(status.Error(codes.ResourceExhausted, "quota exceeded")))
+	assert.True(t, isThrottlingError(newHTTPResponseError("Microsoft Graph", &http.Response{Status: "429 Too Many Requests", StatusCode: http.StatusTooManyRequests}, nil)))
+	// the messages are not matched
+	assert.False(t, isThrottlingError(errors.New("registry 'registry-429.example.com' is not a supported cloud registry")))
+	assert.False(t, isThrottlingError(newHTTPResponseError("EKS API", &http.Response{Status: "404 Not Found", StatusCode: http.StatusNotFound}, []byte("request 429 not found"))))
+	assert.False(t, isThrottlingError(nil))
+}
@@ -0,0 +1,245 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	"github.com/kubescape/k8s-interface/tracing"
+	containerpb "google.golang.org/genproto/googleapis/container/v1"
+)
+
+// VersionSupport is the support status of the Kubernetes version of a managed cluster, normalized across the providers
+type VersionSupport struct {
+	Provider string `json:"provider"`
+	// CurrentVersion is the version of the control plane, e.g. 1.27 (EKS), 1.27.7 (AKS), 1.27.3-gke.100 (GKE)
+	CurrentVersion string `json:"currentVersion"`
+	// AvailableVersions are the versions the provider currently supports, the ones of the release channel of the cluster for GKE
+	AvailableVersions []string `json:"availableVersions,omitempty"`
+	// UpgradeVersions are the versions the control plane can be upgraded to
+	UpgradeVersions []string `json:"upgradeVersions,omitempty"`
+	// ReleaseChannel is the GKE release channel (RAPID/REGULAR/STABLE), empty if the cluster is not enrolled
+	ReleaseChannel string `json:"releaseChannel,omitempty"`
+	// Supported is false if the minor version of the cluster is no longer supported by the provider
+	Supported bool `json:"supported"`
+	// EndOfSupport is the end of the standard support of the minor version, nil if the provider does not report it
+	EndOfSupport *time.Time `json:"endOfSupport,omitempty"`
+}
+
+// ErrEKSReleaseCalendarStale is returned by the EKS GetVersionSupport when no version of the release calendar is still in standard support:
+// the calendar must be updated, see WithEKSReleaseCalendar. Use errors.Is
+var ErrEKSReleaseCalendarStale = errors.New("the EKS release calendar is stale")
+
+// EKSStandardSupportEnd is the end of the standard support of the EKS minor versions, from the EKS release calendar. It is the default
+// calendar of the EKS GetVersionSupport, see WithEKSReleaseCalendar to provide an up to date one.
+// Versions newer than the calendar are considered supported, versions older than it unsupported
+var EKSStandardSupportEnd = map[string]time.Time{
+	"1.20": time.Date(2022, time.November, 1, 0, 0, 0, 0, time.UTC),
+	"1.21": time.Date(2023, time.February, 15, 0, 0, 0, 0, time.UTC),
+	"1.22": time.Date(2023, time.June, 4, 0, 0, 0, 0, time.UTC),
+	"1.23": time.Date(2023, time.October, 11, 0, 0, 0, 0, time.UTC),
+	"1.24": time.Date(2024, time.January, 31, 0, 0, 0, 0, time.UTC),
+	"1.25": time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC),
+	"1.26": time.Date(2024, time.June, 11, 0, 0, 0, 0, time.UTC),
+	"1.27": time.Date(2024, time.July, 24, 0, 0, 0, 0, time.UTC),
+	"1.28": time.Date(2024, time.November, 26, 0, 0, 0, 0, time.UTC),
+	"1.29": time.Date(2025, time.March, 23, 0, 0, 0, 0, time.UTC),
+	"1.30": time.Date(2025, time.July, 23, 0, 0, 0, 0, time.UTC),
+	"1.31": time.Date(2025, time.November, 26, 0, 0, 0, 0, time.UTC),
+	"1.32": time.Date(2026, time.March, 23, 0, 0, 0, 0, time.UTC),
+	"1.33": time.Date(2026, time.July, 29, 0, 0, 0, 0, time.UTC),
+	"1.34": time.Date(2026, time.December, 2, 0, 0, 0, 0, time.UTC),
+}
+
+// WithEKSReleaseCalendar sets the end of the standard support of the EKS minor versions used by the EKS GetVersionSupport,
+// EKSStandardSupportEnd if not set. EKS does not list its versions in the API version of the SDK, the calendar is kept by the caller
+func WithEKSReleaseCalendar(standardSupportEnd map[string]time.Time) CloudSupportOption {
+	return func(options *cloudSupportOptions) {
+		options.eksReleaseCalendar = standardSupportEnd
+	}
+}
+
+// aksKubernetesVersion is an item of the AKS kubernetesVersions API
+type aksKubernetesVersion struct {
+	Version       string                        `json:"version"`
+	IsPreview     bool                          `json:"isPreview"`
+	PatchVersions map[string]aksKubernetesPatch `json:"patchVersions"`
+}
+
+type aksKubernetesPatch struct {
+	Upgrades []string `json:"upgrades"`
+}
+
+// newVersionSupportEKS returns the support of the version of the cluster according to the release calendar at now, EKSStandardSupportEnd if nil.
+// ErrEKSReleaseCalendarStale is returned if no version of the calendar is still supported
+func newVersionSupportEKS(clusterDescribe *eks.DescribeClusterOutput, standardSupportEnd map[string]time.Time, now time.Time) (*VersionSupport, error) {
+	if clusterDescribe == nil || clusterDescribe.Cluster == nil {
+		return nil, fmt.Errorf("error getting cluster descriptive information")
+	}
+	if standardSupportEnd == nil {
+		standardSupportEnd = EKSStandardSupportEnd
+	}
+	versionSupport := &VersionSupport{Provider: tracing.CloudProviderAWS}
+	if clusterDescribe.Cluster.Version != nil {
+		versionSupport.CurrentVersion = *clusterDescribe.Cluster.Version
+	}
+	current := minorVersion(versionSupport.CurrentVersion)
+
+	newest := ""
+	for version, end := range standardSupportEnd {
+		if newest == "" || compareMinorVersions(version, newest) > 0 {
+			newest = version
+		}
+		if now.Before(end) {
+			versionSupport.AvailableVersions = append(versionSupport.AvailableVersions, version)
+			// EKS upgrades one minor version at a time
+			if nextMinorVersion(current) == version {
+				versionSupport.UpgradeVersions = append(versionSupport.UpgradeVersions, version)
+			}
+		}
+	}
+	if len(versionSupport.AvailableVersions) == 0 {
+		return nil, fmt.Errorf("failed to get the support of the EKS version '%s', reason: %w, its newest version '%s' is no longer supported", versionSupport.CurrentVersion, ErrEKSReleaseCalendarStale, newest)
+	}
+	sort.Slice(versionSupport.AvailableVersions, func(i, j int) bool {
+		return compareMinorVersions(versionSupport.AvailableVersions[i], versionSupport.AvailableVersions[j]) < 0
+	})
+
+	if end, ok := standardSupportEnd[current]; ok {
+		versionSupport.EndOfSupport = &end
+		versionSupport.Supported = now.Before(end)
+	} else {
+		versionSupport.Supported = newest != "" && compareMinorVersions(current, newest) > 0
+	}
+	return versionSupport, nil
+}
+
+// newVersionSupportAKS returns the support of the version of the cluster, supported if its minor version is a GA version of the AKS kubernetesVersions of the location
+func newVersionSupportAKS(managedCluster *armcontainerservice.ManagedCluster, upgradeProfile *armcontainerservice.ManagedClusterUpgradeProfile, kubernetesVersions []aksKubernetesVersion) (*VersionSupport, error) {
+	if managedCluster == nil || managedCluster.Properties == nil {
+		return nil, fmt.Errorf("error getting cluster descriptive information")
+	}
+	versionSupport := &VersionSupport{Provider: tracing.CloudProviderAzure}
+	if managedCluster.Properties.CurrentKubernetesVersion != nil {
+		versionSupport.CurrentVersion = *managedCluster.Properties.CurrentKubernetesVersion
+	} else if managedCluster.Properties.KubernetesVersion != nil {
+		versionSupport.CurrentVersion = *managedCluster.Properties.KubernetesVersion
+	}
+	current := minorVersion(versionSupport.CurrentVersion)
+	for _, kubernetesVersion := range kubernetesVersions {
+		if kubernetesVersion.IsPreview {
+			continue
+		}
+		for patch := range kubernetesVersion.PatchVersions {
+			versionSupport.AvailableVersions = append(versionSupport.AvailableVersions, patch)
+		}
+		if minorVersion(kubernetesVersion.Version) == current {
+			versionSupport.Supported = true
+		}
+	}
+	sort.Slice(versionSupport.AvailableVersions, func(i, j int) bool {
+		return compareVersions(versionSupport.AvailableVersions[i], versionSupport.AvailableVersions[j]) < 0
+	})
+	if upgradeProfile != nil && upgradeProfile.Properties != nil && upgradeProfile.Properties.ControlPlaneProfile != nil {
+		for _, upgrade := range upgradeProfile.Properties.ControlPlaneProfile.Upgrades {
+			if upgrade != nil && upgrade.KubernetesVersion != nil && (upgrade.IsPreview == nil || !*upgrade.IsPreview) {
+				versionSupport.UpgradeVersions = append(versionSupport.UpgradeVersions, *upgrade.KubernetesVersion)
+			}
+		}
+	}
+	return versionSupport, nil
+}
+
+// newVersionSupportGKE returns the support of the version of the cluster, supported if its minor version is a valid master version
+// of the server config (of its release channel if enrolled)
+func newVersionSupportGKE(cluster *containerpb.Cluster, serverConfig *containerpb.ServerConfig) (*VersionSupport, error) {
+	if cluster == nil {
+		return nil, fmt.Errorf("error getting cluster descriptive information")
+	}
+	versionSupport := &VersionSupport{Provider: tracing.CloudProviderGCP, CurrentVersion: cluster.GetCurrentMasterVersion()}
+	channel := cluster.GetReleaseChannel().GetChannel()
+	if channel != containerpb.ReleaseChannel_UNSPECIFIED {
+		versionSupport.ReleaseChannel = channel.String()
+	}
+
+	versionSupport.AvailableVersions = serverConfig.GetValidMasterVersions()
+	for _, channelConfig := range serverConfig.GetChannels() {
+		if channel != containerpb.ReleaseChannel_UNSPECIFIED && channelConfig.GetChannel() == channel {
+			versionSupport.AvailableVersions = channelConfig.GetValidVersions()
+		}
+	}
+	current := minorVersion(versionSupport.CurrentVersion)
+	for _, version := range versionSupport.AvailableVersions {
+		if minorVersion(version) == current {
+			versionSupport.Supported = true
+		}
+		if compareVersions(version, versionSupport.CurrentVersion) > 0 {
+			versionSupport.UpgradeVersions = append(versionSupport.UpgradeVersions, version)
+		}
+	}
+	return versionSupport, nil
+}
+
+// minorVersion returns the major.minor of a version, e.g. 1.27 of v1.27.3-gke.100
+func minorVersion(version string) string {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// nextMinorVersion returns the next minor version of major.minor, empty if the version cannot be parsed
+func nextMinorVersion(version string) string {
+	parts := versionNumbers(version)
+	if len(parts) < 2 {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d", parts[0], parts[1]+1)
+}
+
+func compareMinorVersions(a, b string) int {
+	return compareVersions(minorVersion(a), minorVersion(b))
+}
+
+// compareVersions compares the numeric parts of two versions, ignoring the suffixes after a dash
+func compareVersions(a, b string) int {
+	partsA, partsB := versionNumbers(a), versionNumbers(b)
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		var numberA, numberB int
+		if i < len(partsA) {
+			numberA = partsA[i]
+		}
+		if i < len(partsB) {
+			numberB = partsB[i]
+		}
+		if numberA != numberB {
+			if numberA < numberB {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionNumbers(version string) []int {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.Index(version, "-"); i >= 0 {
+		version = version[:i]
+	}
+	var numbers []int
+	for _, part := range strings.Split(version, ".") {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			break
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers
+}
@@ -0,0 +1,164 @@
+package v1
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/kubescape/k8s-interface/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	containerpb "google.golang.org/genproto/googleapis/container/v1"
+)
+
+func TestGetVersionSupportMocks(t *testing.T) {
+	versionSupport, err := NewEKSSupportMock().GetVersionSupport(context.Background(), "", "")
+	require.NoError(t, err)
+	assert.Equal(t, tracing.CloudProviderAWS, versionSupport.Provider)
+	assert.Equal(t, "0.0", versionSupport.CurrentVersion)
+	assert.False(t, versionSupport.Supported)
+
+	versionSupport, err = NewAKSSupportMock().GetVersionSupport(context.Background(), "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, tracing.CloudProviderAzure, versionSupport.Provider)
+	assert.Equal(t, "1.21.9", versionSupport.CurrentVersion)
+	assert.True(t, versionSupport.Supported)
+	assert.Equal(t, []string{"1.21.9", "1.22.6"}, versionSupport.AvailableVersions)
+
+	versionSupport, err = NewGKESupportMock().GetVersionSupport(context.Background(), "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, tracing.CloudProviderGCP, versionSupport.Provider)
+	assert.Equal(t, "0-gke.0", versionSupport.CurrentVersion)
+	assert.True(t, versionSupport.Supported)
+}
+
+func TestNewVersionSupportEKS(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	versionSupport, err := newVersionSupportEKS(&eks.DescribeClusterOutput{Cluster: &ekstypes.Cluster{Version: strPtr("1.25")}}, nil, now)
+	require.NoError(t, err)
+	assert.True(t, versionSupport.Supported)
+	require.NotNil(t, versionSupport.EndOfSupport)
+	assert.Equal(t, EKSStandardSupportEnd["1.25"], *versionSupport.EndOfSupport)
+	assert.Equal(t, []string{"1.26"}, versionSupport.UpgradeVersions)
+	assert.Equal(t, "1.25", versionSupport.AvailableVersions[0])
+
+	versionSupport, err = newVersionSupportEKS(&eks.DescribeClusterOutput{Cluster: &ekstypes.Cluster{Version: strPtr("1.23")}}, nil, now)
+	require.NoError(t, err)
+	assert.False(t, versionSupport.Supported)
+	assert.Empty(t, versionSupport.UpgradeVersions)
+
+	// newer than the release calendar
+	versionSupport, err = newVersionSupportEKS(&eks.DescribeClusterOutput{Cluster: &ekstypes.Cluster{Version: strPtr("1.99")}}, nil, now)
+	require.NoError(t, err)
+	assert.True(t, versionSupport.Supported)
+	assert.Nil(t, versionSupport.EndOfSupport)
+
+	_, err = newVersionSupportEKS(&eks.DescribeClusterOutput{}, nil, now)
+	assert.Error(t, err)
+}
+
+func TestNewVersionSupportEKSReleaseCalendar(t *testing.T) {
+	cluster := &eks.DescribeClusterOutput{Cluster: &ekstypes.Cluster{Version: strPtr("1.40")}}
+	calendar := map[string]time.Time{
+		"1.40": time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC),
+		"1.41": time.Date(2030, time.June, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// the calendar of the caller
+	versionSupport, err := newVersionSupportEKS(cluster, calendar, time.Date(2029, time.March, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.True(t, versionSupport.Supported)
+	assert.Equal(t, []string{"1.40", "1.41"}, versionSupport.AvailableVersions)
+	assert.Equal(t, []string{"1.41"}, versionSupport.UpgradeVersions)
+
+	// no version of the calendar is supported anymore
+	_, err = newVersionSupportEKS(cluster, calendar, time.Date(2031, time.January, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, ErrEKSReleaseCalendarStale)
+
+	eksSupport := NewEKSSupport(WithEKSReleaseCalendar(calendar))
+	assert.Equal(t, calendar, eksSupport.options.eksReleaseCalendar)
+}
+
+func TestNewVersionSupportAKS(t *testing.T) {
+	kubernetesVersions := []aksKubernetesVersion{
+		{Version: "1.27", PatchVersions: map[string]aksKubernetesPatch{"1.27.9": {}, "1.27.10": {}}},
+		{Version: "1.28", PatchVersions: map[string]aksKubernetesPatch{"1.28.5": {}}},
+		{Version: "1.29", IsPreview: true, PatchVersions: map[string]aksKubernetesPatch{"1.29.0": {}}},
+	}
+	upgradeProfile := &armcontainerservice.ManagedClusterUpgradeProfile{
+		Properties: &armcontainerservice.ManagedClusterUpgradeProfileProperties{
+			ControlPlaneProfile: &armcontainerservice.ManagedClusterPoolUpgradeProfile{
+				Upgrades: []*armcontainerservice.ManagedClusterPoolUpgradeProfileUpgradesItem{
+					{KubernetesVersion: strPtr("1.28.5")},
+					{KubernetesVersion: strPtr("1.29.0"), IsPreview: boolPtr(true)},
+				},
+			},
+		},
+	}
+
+	versionSupport, err := newVersionSupportAKS(&armcontainerservice.ManagedCluster{
+		Properties: &armcontainerservice.ManagedClusterProperties{KubernetesVersion: strPtr("1.27"), CurrentKubernetesVersion: strPtr("1.27.9")},
+	}, upgradeProfile, kubernetesVersions)
+	require.NoError(t, err)
+	assert.Equal(t, "1.27.9", versionSupport.CurrentVersion)
+	assert.True(t, versionSupport.Supported)
+	assert.Equal(t, []string{"1.27.9", "1.27.10", "1.28.5"}, versionSupport.AvailableVersions)
+	assert.Equal(t, []string{"1.28.5"}, versionSupport.UpgradeVersions)
+
+	// only in preview
+	versionSupport, err = newVersionSupportAKS(&armcontainerservice.ManagedCluster{
+		Properties: &armcontainerservice.ManagedClusterProperties{KubernetesVersion: strPtr("1.26.3")},
+	}, nil, kubernetesVersions)
+	require.NoError(t, err)
+	assert.Equal(t, "1.26.3", versionSupport.CurrentVersion)
+	assert.False(t, versionSupport.Supported)
+	assert.Empty(t, versionSupport.UpgradeVersions)
+
+	_, err = newVersionSupportAKS(&armcontainerservice.ManagedCluster{}, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestNewVersionSupportGKE(t *testing.T) {
+	serverConfig := &containerpb.ServerConfig{
+		ValidMasterVersions: []string{"1.28.3-gke.1200", "1.27.8-gke.1000", "1.26.11-gke.100"},
+		Channels: []*containerpb.ServerConfig_ReleaseChannelConfig{
+			{Channel: containerpb.ReleaseChannel_STABLE, ValidVersions: []string{"1.27.8-gke.1000", "1.27.7-gke.1100"}},
+			{Channel: containerpb.ReleaseChannel_RAPID, ValidVersions: []string{"1.29.0-gke.1000"}},
+		},
+	}
+
+	versionSupport, err := newVersionSupportGKE(&containerpb.Cluster{
+		CurrentMasterVersion: "1.27.7-gke.1100",
+		ReleaseChannel:       &containerpb.ReleaseChannel{Channel: containerpb.ReleaseChannel_STABLE},
+	}, serverConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "STABLE", versionSupport.ReleaseChannel)
+	assert.True(t, versionSupport.Supported)
+	assert.Equal(t, []string{"1.27.8-gke.1000", "1.27.7-gke.1100"}, versionSupport.AvailableVersions)
+	assert.Equal(t, []string{"1.27.8-gke.1000"}, versionSupport.UpgradeVersions)
+
+	// not enrolled in a release channel
+	versionSupport, err = newVersionSupportGKE(&containerpb.Cluster{CurrentMasterVersion: "1.25.16-gke.100"}, serverConfig)
+	require.NoError(t, err)
+	assert.Empty(t, versionSupport.ReleaseChannel)
+	assert.False(t, versionSupport.Supported)
+	assert.Equal(t, serverConfig.ValidMasterVersions, versionSupport.AvailableVersions)
+	assert.Len(t, versionSupport.UpgradeVersions, 3)
+
+	_, err = newVersionSupportGKE(nil, nil)
+	assert.Error(t, err)
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, 0, compareVersions("1.27", "v1.27.0"))
+	assert.Equal(t, -1, compareVersions("1.27.9", "1.27.10"))
+	assert.Equal(t, 1, compareVersions("1.28.3-gke.1200", "1.27.8-gke.1000"))
+	assert.Equal(t, 0, compareMinorVersions("1.27.3-gke.100", "1.27.9"))
+	assert.Equal(t, "1.27", minorVersion("v1.27.3-gke.100"))
+	assert.Equal(t, "1.28", nextMinorVersion("1.27"))
+	assert.Empty(t, nextMinorVersion("latest"))
+}
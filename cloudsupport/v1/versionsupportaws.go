@@ -0,0 +1,22 @@
+package v1
+
+import (
+	"context"
+	"time"
+
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+// GetVersionSupport returns the support of the version of the cluster according to the EKS release calendar, see WithEKSReleaseCalendar.
+// EKS does not list its versions, the available versions are the ones of the calendar still in standard support. ErrEKSReleaseCalendarStale
+// is returned if none is
+func (eksSupport *EKSSupport) GetVersionSupport(ctx context.Context, cluster string, region string) (_ *VersionSupport, err error) {
+	_, span := tracing.StartSpan(ctx, "eks.GetVersionSupport", tracing.AttributeCloudProvider.String(tracing.CloudProviderAWS), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	clusterDescribe, err := eksSupport.GetClusterDescribe(cluster, region)
+	if err != nil {
+		return nil, err
+	}
+	return newVersionSupportEKS(clusterDescribe, eksSupport.options.eksReleaseCalendar, time.Now())
+}
@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	armruntime "github.com/Azure/azure-sdk-for-go/sdk/azcore/arm/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+const (
+	// the kubernetesVersions API is not part of the containerservice client version
+	aksKubernetesVersionsPath       = "/subscriptions/%s/providers/Microsoft.ContainerService/locations/%s/kubernetesVersions"
+	aksKubernetesVersionsAPIVersion = "2024-02-01"
+	aksKubernetesVersionsModule     = "k8s-interface"
+)
+
+// GetVersionSupport returns the support of the version of the cluster from the kubernetesVersions of its location, with the upgrades of its upgrade profile
+func (AKSSupport *AKSSupport) GetVersionSupport(ctx context.Context, subscriptionId string, clusterName string, resourceGroup string) (_ *VersionSupport, err error) {
+	ctx, span := tracing.StartSpan(ctx, "aks.GetVersionSupport", tracing.AttributeCloudProvider.String(tracing.CloudProviderAzure), tracing.AttributeCloudCluster.String(clusterName))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	managedCluster, err := AKSSupport.GetClusterDescribe(subscriptionId, clusterName, resourceGroup)
+	if err != nil {
+		return nil, err
+	}
+	if managedCluster.Location == nil {
+		return nil, fmt.Errorf("error getting the location of the cluster '%s'", clusterName)
+	}
+
+	cred, err := AKSSupport.options.newAzureCredential()
+	if err != nil {
+		return nil, err
+	}
+	aksclient, err := armcontainerservice.NewManagedClustersClient(subscriptionId, cred, AKSSupport.options.armClientOptions())
+	if err != nil {
+		return nil, err
+	}
+	var upgradeProfile armcontainerservice.ManagedClustersClientGetUpgradeProfileResponse
+	err = metrics.ObserveCall(metrics.SourceAzure, "containerservice.ManagedClusters.GetUpgradeProfile", isThrottlingError, func() error {
+		var err error
+		upgradeProfile, err = aksclient.GetUpgradeProfile(ctx, resourceGroup, clusterName, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	kubernetesVersions, err := listAKSKubernetesVersions(ctx, cred, AKSSupport.options.armClientOptions(), subscriptionId, *managedCluster.Location)
+	if err != nil {
+		return nil, err
+	}
+	return newVersionSupportAKS(managedCluster, &upgradeProfile.ManagedClusterUpgradeProfile, kubernetesVersions)
+}
+
+func listAKSKubernetesVersions(ctx context.Context, cred azcore.TokenCredential, clientOptions *arm.ClientOptions, subscriptionId string, location string) ([]aksKubernetesVersion, error) {
+	pipeline, err := armruntime.NewPipeline(aksKubernetesVersionsModule, Version, cred, runtime.PipelineOptions{}, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+	path := fmt.Sprintf(aksKubernetesVersionsPath, url.PathEscape(subscriptionId), url.PathEscape(location))
+	req, err := runtime.NewRequest(ctx, http.MethodGet, runtime.JoinPaths(clientOptions.Cloud.Services[cloud.ResourceManager].Endpoint, path))
+	if err != nil {
+		return nil, err
+	}
+	query := req.Raw().URL.Query()
+	query.Set("api-version", aksKubernetesVersionsAPIVersion)
+	req.Raw().URL.RawQuery = query.Encode()
+	req.Raw().Header["Accept"] = []string{"application/json"}
+
+	result := struct {
+		Values []aksKubernetesVersion `json:"values"`
+	}{}
+	err = metrics.ObserveCall(metrics.SourceAzure, "containerservice.ManagedClusters.ListKubernetesVersions", isThrottlingError, func() error {
+		resp, err := pipeline.Do(req)
+		if err != nil {
+			return err
+		}
+		if !runtime.HasStatusCode(resp, http.StatusOK) {
+			return runtime.NewResponseError(resp)
+		}
+		return runtime.UnmarshalAsJSON(resp, &result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Values, nil
+}
@@ -0,0 +1,44 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	container "cloud.google.com/go/container/apiv1"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/tracing"
+	containerpb "google.golang.org/genproto/googleapis/container/v1"
+)
+
+// GetVersionSupport returns the support of the version of the cluster from the server config of its location and its release channel
+func (gkeSupport *GKESupport) GetVersionSupport(ctx context.Context, cluster string, region string, project string) (_ *VersionSupport, err error) {
+	ctx, span := tracing.StartSpan(ctx, "gke.GetVersionSupport", tracing.AttributeCloudProvider.String(tracing.CloudProviderGCP), tracing.AttributeCloudRegion.String(region), tracing.AttributeCloudCluster.String(cluster))
+	defer func() { tracing.EndSpan(span, err) }()
+	ctx = gkeSupport.options.gcpContext(ctx)
+	clientOptions, err := gkeSupport.options.gcpClientOptions(GCPServiceContainer)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterDescribe, err := gkeSupport.GetClusterDescribe(cluster, region, project)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := container.NewClusterManagerClient(ctx, clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	var serverConfig *containerpb.ServerConfig
+	err = metrics.ObserveCall(metrics.SourceGCP, "container.GetServerConfig", isThrottlingError, func() error {
+		var err error
+		serverConfig, err = c.GetServerConfig(ctx, &containerpb.GetServerConfigRequest{Name: fmt.Sprintf("projects/%s/locations/%s", project, region)})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newVersionSupportGKE(clusterDescribe, serverConfig)
+}
@@ -0,0 +1,187 @@
+package imageref
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kubescape/k8s-interface/names"
+)
+
+const (
+	DefaultRegistry  = "docker.io"
+	DefaultTag       = "latest"
+	officialRepoName = "library"
+	legacyRegistry   = "index.docker.io"
+	slugDigestLength = 6
+)
+
+var (
+	// path component of a repository, see https://github.com/distribution/distribution/blob/main/reference/reference.go
+	pathComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]+)[a-z0-9]+)*$`)
+	tagRegexp           = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestRegexp        = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+)
+
+// ImageReference is a parsed container image reference, e.g. quay.io/kubescape/kubevuln:v0.1.0@sha256:<hex>
+type ImageReference struct {
+	Registry   string `json:"registry,omitempty"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+// Parse parses an image string into registry/repository/tag/digest as written, without applying defaults
+func Parse(image string) (*ImageReference, error) {
+	if image == "" {
+		return nil, fmt.Errorf("invalid image reference: empty string")
+	}
+	ref := &ImageReference{}
+	remainder := image
+
+	if i := strings.Index(remainder, "@"); i >= 0 {
+		ref.Digest = remainder[i+1:]
+		remainder = remainder[:i]
+		if !digestRegexp.MatchString(ref.Digest) {
+			return nil, fmt.Errorf("invalid image reference '%s': invalid digest '%s'", image, ref.Digest)
+		}
+	}
+
+	// the tag is after the last ':' that follows the last '/', otherwise it is a registry port
+	if i := strings.LastIndex(remainder, ":"); i >= 0 && i > strings.LastIndex(remainder, "/") {
+		ref.Tag = remainder[i+1:]
+		remainder = remainder[:i]
+		if !tagRegexp.MatchString(ref.Tag) {
+			return nil, fmt.Errorf("invalid image reference '%s': invalid tag '%s'", image, ref.Tag)
+		}
+	}
+
+	if i := strings.Index(remainder, "/"); i >= 0 && isRegistry(remainder[:i]) {
+		ref.Registry = remainder[:i]
+		remainder = remainder[i+1:]
+	}
+
+	if remainder == "" {
+		return nil, fmt.Errorf("invalid image reference '%s': missing repository", image)
+	}
+	for _, component := range strings.Split(remainder, "/") {
+		if !pathComponentRegexp.MatchString(component) {
+			return nil, fmt.Errorf("invalid image reference '%s': invalid repository '%s'", image, remainder)
+		}
+	}
+	ref.Repository = remainder
+
+	return ref, nil
+}
+
+// ParseNormalized parses an image string and normalizes it, see Normalize
+func ParseNormalized(image string) (*ImageReference, error) {
+	ref, err := Parse(image)
+	if err != nil {
+		return nil, err
+	}
+	return ref.Normalize(), nil
+}
+
+// Normalize returns a copy of the reference with the defaults applied the same way the container runtime does:
+// the docker.io registry, the library/ prefix for official docker.io images and the latest tag when no tag and no digest are set
+func (ref *ImageReference) Normalize() *ImageReference {
+	normalized := *ref
+	if normalized.Registry == "" || normalized.Registry == legacyRegistry {
+		normalized.Registry = DefaultRegistry
+	}
+	if normalized.Registry == DefaultRegistry && !strings.Contains(normalized.Repository, "/") {
+		normalized.Repository = officialRepoName + "/" + normalized.Repository
+	}
+	if normalized.Tag == "" && normalized.Digest == "" {
+		normalized.Tag = DefaultTag
+	}
+	return &normalized
+}
+
+// Name returns the registry and repository, e.g. docker.io/library/nginx
+func (ref *ImageReference) Name() string {
+	if ref.Registry == "" {
+		return ref.Repository
+	}
+	return ref.Registry + "/" + ref.Repository
+}
+
+// String returns the full image reference
+func (ref *ImageReference) String() string {
+	s := ref.Name()
+	if ref.Tag != "" {
+		s += ":" + ref.Tag
+	}
+	if ref.Digest != "" {
+		s += "@" + ref.Digest
+	}
+	return s
+}
+
+// Equal returns true if both references point to the same image after normalization.
+// When both references have a digest, the digests are compared and the tags are ignored
+func (ref *ImageReference) Equal(other *ImageReference) bool {
+	if other == nil {
+		return false
+	}
+	a, b := ref.Normalize(), other.Normalize()
+	if a.Name() != b.Name() {
+		return false
+	}
+	if a.Digest != "" && b.Digest != "" {
+		return strings.EqualFold(a.Digest, b.Digest)
+	}
+	return a.Tag == b.Tag && a.Digest == b.Digest
+}
+
+// Slug returns a name derived from the image that is a valid Kubernetes object name, used for naming storage objects.
+// When the reference has a digest, a short part of it is appended so different builds of the same tag get different slugs
+func (ref *ImageReference) Slug() string {
+	normalized := ref.Normalize()
+	s := normalized.Name()
+	if normalized.Tag != "" {
+		s += "-" + normalized.Tag
+	}
+	if normalized.Digest != "" {
+		hex := normalized.Digest[strings.Index(normalized.Digest, ":")+1:]
+		s += "-" + strings.ToLower(hex[:slugDigestLength])
+	}
+	return names.SanitizeToValidName(s, names.DNS1123SubdomainMaxLength, true)
+}
+
+// Equal returns true if both image strings point to the same image, see ImageReference.Equal
+func Equal(imageA, imageB string) (bool, error) {
+	a, err := Parse(imageA)
+	if err != nil {
+		return false, err
+	}
+	b, err := Parse(imageB)
+	if err != nil {
+		return false, err
+	}
+	return a.Equal(b), nil
+}
+
+// Normalize returns the normalized string of an image, e.g. nginx -> docker.io/library/nginx:latest
+func Normalize(image string) (string, error) {
+	ref, err := ParseNormalized(image)
+	if err != nil {
+		return "", err
+	}
+	return ref.String(), nil
+}
+
+// ImageToSlug returns the slug of an image string, see ImageReference.Slug
+func ImageToSlug(image string) (string, error) {
+	ref, err := Parse(image)
+	if err != nil {
+		return "", err
+	}
+	return ref.Slug(), nil
+}
+
+// isRegistry returns true if the first component of the image is a registry host and not part of the repository
+func isRegistry(component string) bool {
+	return strings.ContainsAny(component, ".:") || component == "localhost" || strings.ToLower(component) != component
+}
@@ -0,0 +1,130 @@
+package imageref
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/names"
+	"github.com/stretchr/testify/assert"
+)
+
+var testDigest = "sha256:" + strings.Repeat("ab", 32)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name           string
+		image          string
+		want           ImageReference
+		wantNormalized string
+		wantErr        bool
+	}{
+		{
+			name:           "official image",
+			image:          "nginx",
+			want:           ImageReference{Repository: "nginx"},
+			wantNormalized: "docker.io/library/nginx:latest",
+		},
+		{
+			name:           "docker hub user image with tag",
+			image:          "bitnami/redis:7.0",
+			want:           ImageReference{Repository: "bitnami/redis", Tag: "7.0"},
+			wantNormalized: "docker.io/bitnami/redis:7.0",
+		},
+		{
+			name:           "legacy docker hub registry",
+			image:          "index.docker.io/nginx:1.23",
+			want:           ImageReference{Registry: "index.docker.io", Repository: "nginx", Tag: "1.23"},
+			wantNormalized: "docker.io/library/nginx:1.23",
+		},
+		{
+			name:           "registry with port and digest",
+			image:          "localhost:5000/team/app@" + testDigest,
+			want:           ImageReference{Registry: "localhost:5000", Repository: "team/app", Digest: testDigest},
+			wantNormalized: "localhost:5000/team/app@" + testDigest,
+		},
+		{
+			name:           "tag and digest",
+			image:          "quay.io/kubescape/kubevuln:v0.1.0@" + testDigest,
+			want:           ImageReference{Registry: "quay.io", Repository: "kubescape/kubevuln", Tag: "v0.1.0", Digest: testDigest},
+			wantNormalized: "quay.io/kubescape/kubevuln:v0.1.0@" + testDigest,
+		},
+		{
+			name:           "ecr",
+			image:          "015253967648.dkr.ecr.eu-central-1.amazonaws.com/armo:1",
+			want:           ImageReference{Registry: "015253967648.dkr.ecr.eu-central-1.amazonaws.com", Repository: "armo", Tag: "1"},
+			wantNormalized: "015253967648.dkr.ecr.eu-central-1.amazonaws.com/armo:1",
+		},
+		{
+			name:    "empty",
+			image:   "",
+			wantErr: true,
+		},
+		{
+			name:    "upper case repository",
+			image:   "docker.io/Nginx",
+			wantErr: true,
+		},
+		{
+			name:    "invalid digest",
+			image:   "nginx@sha256:xyz",
+			wantErr: true,
+		},
+		{
+			name:    "invalid tag",
+			image:   "nginx:-latest",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, tt.want, *got)
+			assert.Equal(t, tt.image, got.String())
+			normalized, err := Normalize(tt.image)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantNormalized, normalized)
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	equal, err := Equal("nginx", "docker.io/library/nginx:latest")
+	assert.NoError(t, err)
+	assert.True(t, equal)
+
+	equal, err = Equal("nginx:1.23@"+testDigest, "docker.io/library/nginx@"+testDigest)
+	assert.NoError(t, err)
+	assert.True(t, equal)
+
+	equal, err = Equal("nginx:1.23", "nginx:1.24")
+	assert.NoError(t, err)
+	assert.False(t, equal)
+
+	equal, err = Equal("quay.io/nginx", "nginx")
+	assert.NoError(t, err)
+	assert.False(t, equal)
+
+	_, err = Equal("nginx", "")
+	assert.Error(t, err)
+}
+
+func TestImageToSlug(t *testing.T) {
+	slug, err := ImageToSlug("nginx")
+	assert.NoError(t, err)
+	assert.Equal(t, "docker-io-library-nginx-latest", slug)
+
+	slug, err = ImageToSlug("quay.io/kubescape/kubevuln:v0.1.0@" + testDigest)
+	assert.NoError(t, err)
+	assert.Equal(t, "quay-io-kubescape-kubevuln-v0-1-0-ababab", slug)
+	assert.NoError(t, names.ValidateDNS1123Subdomain(slug))
+
+	_, err = ImageToSlug("Invalid Image")
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package instanceidhandler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	wlidpkg "github.com/armosec/utils-k8s-go/wlid"
+	"github.com/kubescape/k8s-interface/instanceidhandler"
+	"github.com/kubescape/k8s-interface/names"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TemplateHashMetadataKey is the label of the hash of the pod template revision of the instance
+const TemplateHashMetadataKey = metadataPrefix + "/instance-template-hash"
+
+const (
+	slugHashLength          = 4
+	templateHashLength      = 10
+	controllerUIDLabel      = "controller-uid"
+	batchControllerUIDLabel = "batch.kubernetes.io/controller-uid"
+)
+
+// Identity is the identity of a container instance, set by the components on the objects they create for it (e.g. SBOMs, profiles), so all the components
+// agree on the instance an object belongs to. See GenerateIdentities, ApplyIdentity and ExtractIdentity
+type Identity struct {
+	InstanceID instanceidhandler.IInstanceID
+	// Wlid is the workload ID of the parent of the instance
+	Wlid string
+	// Slug is the name of the objects of the instance, a valid Kubernetes object name
+	Slug string
+	// TemplateHash is the hash of the pod template revision of the instance, objects of an older revision of the workload have another hash
+	TemplateHash string
+}
+
+// GenerateIdentities generates the identities of the containers of a pod
+func GenerateIdentities(pod workloadinterface.IWorkload, clusterName string) ([]Identity, error) {
+	instanceIDs, err := GenerateInstanceID(pod)
+	if err != nil {
+		return nil, err
+	}
+	templateHash, err := GetTemplateHash(pod)
+	if err != nil {
+		return nil, err
+	}
+	identities := make([]Identity, 0, len(instanceIDs))
+	for i := range instanceIDs {
+		identities = append(identities, newIdentity(instanceIDs[i], clusterName, templateHash))
+	}
+	return identities, nil
+}
+
+func newIdentity(instanceID instanceidhandler.IInstanceID, clusterName, templateHash string) Identity {
+	return Identity{
+		InstanceID:   instanceID,
+		Wlid:         wlidpkg.GetK8sWLID(clusterName, instanceID.GetNamespace(), instanceID.GetKind(), instanceID.GetName()),
+		Slug:         GetInstanceIDSlug(instanceID),
+		TemplateHash: templateHash,
+	}
+}
+
+// GetInstanceIDSlug returns the name of the objects of the instance: <kind>-<name>-<container>-<hash>, sanitized to a valid Kubernetes object name.
+// The hash is a short prefix of the hashed instance ID, so names that are equal after the sanitization remain distinct
+func GetInstanceIDSlug(instanceID instanceidhandler.IInstanceID) string {
+	return names.SanitizeDNS1123Subdomain(fmt.Sprintf("%s-%s-%s-%s", strings.ToLower(instanceID.GetKind()), instanceID.GetName(), instanceID.GetContainerName(), instanceID.GetHashed()[:slugHashLength]))
+}
+
+// GetTemplateHash returns the hash of the pod template revision of the pod: the pod-template-hash (ReplicaSets) or the controller-revision-hash (StatefulSets,
+// DaemonSets) label, the controller uid of Job pods, the hash of the names and images of the containers for other pods
+func GetTemplateHash(pod workloadinterface.IWorkload) (string, error) {
+	for _, label := range []string{appsv1.DefaultDeploymentUniqueLabelKey, appsv1.ControllerRevisionHashLabelKey, batchControllerUIDLabel, controllerUIDLabel} {
+		if value, ok := pod.GetLabel(label); ok && value != "" {
+			return value, nil
+		}
+	}
+	containers, err := pod.GetContainers()
+	if err != nil {
+		return "", err
+	}
+	images := make([]string, 0, len(containers))
+	for i := range containers {
+		images = append(images, containers[i].Name+"="+containers[i].Image)
+	}
+	data, err := json.Marshal(images)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])[:templateHashLength], nil
+}
+
+// Labels returns the identity labels: the instance ID labels (see GetInstanceIDLabels) and the template hash
+func (identity *Identity) Labels() map[string]string {
+	labels := GetInstanceIDLabels(identity.InstanceID)
+	if identity.TemplateHash != "" {
+		labels[TemplateHashMetadataKey] = labelValue(identity.TemplateHash)
+	}
+	return labels
+}
+
+// Annotations returns the identity annotations: the full instance ID and the wlid
+func (identity *Identity) Annotations() map[string]string {
+	annotations := GetInstanceIDAnnotations(identity.InstanceID)
+	if identity.Wlid != "" {
+		annotations[WlidMetadataKey] = identity.Wlid
+	}
+	return annotations
+}
+
+// ApplyIdentity sets the identity labels and annotations on the object, and its name to the slug of the identity if the object has no name nor generateName.
+// The other labels and annotations of the object are kept
+func ApplyIdentity(obj metav1.Object, identity Identity) {
+	obj.SetLabels(mergeMetadata(obj.GetLabels(), identity.Labels()))
+	obj.SetAnnotations(mergeMetadata(obj.GetAnnotations(), identity.Annotations()))
+	if obj.GetName() == "" && obj.GetGenerateName() == "" {
+		obj.SetName(identity.Slug)
+	}
+}
+
+// ExtractIdentity returns the identity of an object the identity was applied to, see ApplyIdentity
+func ExtractIdentity(obj metav1.Object) (*Identity, error) {
+	instanceID, err := GenerateInstanceIDFromLabels(obj.GetLabels(), obj.GetAnnotations())
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{
+		InstanceID:   instanceID,
+		Wlid:         obj.GetAnnotations()[WlidMetadataKey],
+		Slug:         GetInstanceIDSlug(instanceID),
+		TemplateHash: obj.GetLabels()[TemplateHashMetadataKey],
+	}, nil
+}
+
+func mergeMetadata(current, identity map[string]string) map[string]string {
+	merged := make(map[string]string, len(current)+len(identity))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range identity {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,100 @@
+package instanceidhandler
+
+import (
+	"testing"
+
+	"github.com/kubescape/k8s-interface/names"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGenerateIdentities(t *testing.T) {
+	pod, err := workloadinterface.NewWorkload([]byte(deployment))
+	require.NoError(t, err)
+
+	identities, err := GenerateIdentities(pod, "minikube")
+	require.NoError(t, err)
+	require.Len(t, identities, 1)
+	identity := identities[0]
+	assert.Equal(t, "wlid://cluster-minikube/namespace-default/replicaset-nginx-84f5585d68", identity.Wlid)
+	assert.Equal(t, "replicaset-nginx-84f5585d68-nginx-5736", identity.Slug)
+	assert.NoError(t, names.ValidateDNS1123Subdomain(identity.Slug))
+	assert.Equal(t, "84f5585d68", identity.TemplateHash)
+
+	labels := identity.Labels()
+	assert.Equal(t, "84f5585d68", labels[TemplateHashMetadataKey])
+	assert.Equal(t, "ReplicaSet", labels[KindMetadataKey])
+	annotations := identity.Annotations()
+	assert.Equal(t, identity.Wlid, annotations[WlidMetadataKey])
+	assert.Equal(t, identity.InstanceID.GetStringFormatted(), annotations[InstanceIDMetadataKey])
+
+	jobWorkload, err := workloadinterface.NewWorkload([]byte(jobPod))
+	require.NoError(t, err)
+	identities, err = GenerateIdentities(jobWorkload, "minikube")
+	require.NoError(t, err)
+	assert.Equal(t, "1ca79890-1432-48fd-8e04-bd6189c194b7", identities[0].TemplateHash)
+
+	// the pods of other kinds are not supported
+	serviceWorkload, err := workloadinterface.NewWorkload([]byte(service))
+	require.NoError(t, err)
+	_, err = GenerateIdentities(serviceWorkload, "minikube")
+	assert.Error(t, err)
+}
+
+func TestGetTemplateHash(t *testing.T) {
+	newPod := func(image string) workloadinterface.IWorkload {
+		return workloadinterface.NewWorkloadObj(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]interface{}{"name": "nginx", "namespace": "default"},
+			"spec":       map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "nginx", "image": image}}},
+		})
+	}
+	hash, err := GetTemplateHash(newPod("nginx:1.25"))
+	require.NoError(t, err)
+	assert.Len(t, hash, templateHashLength)
+
+	sameHash, err := GetTemplateHash(newPod("nginx:1.25"))
+	require.NoError(t, err)
+	assert.Equal(t, hash, sameHash)
+
+	otherHash, err := GetTemplateHash(newPod("nginx:1.26"))
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, otherHash)
+}
+
+func TestApplyAndExtractIdentity(t *testing.T) {
+	pod, err := workloadinterface.NewWorkload([]byte(deployment))
+	require.NoError(t, err)
+	identities, err := GenerateIdentities(pod, "minikube")
+	require.NoError(t, err)
+
+	// typed objects
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "nginx"}}}
+	ApplyIdentity(configMap, identities[0])
+	assert.Equal(t, identities[0].Slug, configMap.Name)
+	assert.Equal(t, "nginx", configMap.Labels["app"])
+	assert.Equal(t, "84f5585d68", configMap.Labels[TemplateHashMetadataKey])
+
+	extracted, err := ExtractIdentity(configMap)
+	require.NoError(t, err)
+	assert.Equal(t, identities[0].InstanceID.GetStringFormatted(), extracted.InstanceID.GetStringFormatted())
+	assert.Equal(t, identities[0].Wlid, extracted.Wlid)
+	assert.Equal(t, identities[0].Slug, extracted.Slug)
+	assert.Equal(t, identities[0].TemplateHash, extracted.TemplateHash)
+
+	// unstructured objects, the name is kept
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "custom"}}}
+	ApplyIdentity(obj, identities[0])
+	assert.Equal(t, "custom", obj.GetName())
+	extracted, err = ExtractIdentity(obj)
+	require.NoError(t, err)
+	assert.Equal(t, identities[0].Wlid, extracted.Wlid)
+
+	_, err = ExtractIdentity(&corev1.ConfigMap{})
+	assert.Error(t, err)
+}
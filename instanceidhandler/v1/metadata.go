@@ -0,0 +1,113 @@
+package instanceidhandler
+
+import (
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/instanceidhandler"
+	"github.com/kubescape/k8s-interface/names"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+
+	core1 "k8s.io/api/core/v1"
+)
+
+// LabelsTruncatedMetadataKey marks the instance ID labels of which a value was truncated or sanitized, the instance ID cannot be rebuilt from these labels
+const LabelsTruncatedMetadataKey = metadataPrefix + "/labels-truncated"
+
+// InstanceIDMetadata holds the labels and annotations that describe a single instance ID
+type InstanceIDMetadata struct {
+	InstanceID  instanceidhandler.IInstanceID
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// GetInstanceIDLabels returns the instance ID labels with values that are valid Kubernetes label values.
+// The valid values are kept as is, the values longer than 63 characters are truncated and suffixed with a hash of the full value.
+// The labels are marked with LabelsTruncatedMetadataKey if a value was rewritten
+func GetInstanceIDLabels(instanceID instanceidhandler.IInstanceID) map[string]string {
+	labels := instanceID.GetLabels()
+	truncated := false
+	for k := range labels {
+		value := labelValue(labels[k])
+		if value != labels[k] {
+			truncated = true
+		}
+		labels[k] = value
+	}
+	if truncated {
+		labels[LabelsTruncatedMetadataKey] = "true"
+	}
+	return labels
+}
+
+// labelValue returns the value if it is a valid label value, sanitized otherwise. The sanitization would rewrite valid values, e.g. collapse "--"
+func labelValue(value string) string {
+	if value == "" || names.ValidateLabelValue(value) == nil {
+		return value
+	}
+	return names.SanitizeLabelValue(value)
+}
+
+// GetInstanceIDAnnotations returns the instance ID annotations. Annotations are not size-limited, so they hold the full (non-truncated) instance ID
+func GetInstanceIDAnnotations(instanceID instanceidhandler.IInstanceID) map[string]string {
+	return map[string]string{
+		InstanceIDMetadataKey: instanceID.GetStringFormatted(),
+	}
+}
+
+// GenerateInstanceIDMetadata generates the instance IDs of a workload together with their labels and annotations
+func GenerateInstanceIDMetadata(w workloadinterface.IWorkload) ([]InstanceIDMetadata, error) {
+	instanceIDs, err := GenerateInstanceID(w)
+	if err != nil {
+		return nil, err
+	}
+	return listInstanceIDMetadata(instanceIDs), nil
+}
+
+// GenerateInstanceIDMetadataFromPod generates the instance IDs of a pod together with their labels and annotations
+func GenerateInstanceIDMetadataFromPod(pod *core1.Pod) ([]InstanceIDMetadata, error) {
+	instanceIDs, err := GenerateInstanceIDFromPod(pod)
+	if err != nil {
+		return nil, err
+	}
+	return listInstanceIDMetadata(instanceIDs), nil
+}
+
+// GenerateInstanceIDFromLabels reconstructs an instance ID from the labels and annotations of an object.
+// The instance ID annotation is preferred since it is never truncated. When it is missing, the instance ID is rebuilt from the labels,
+// and an error is returned if one of the label values was truncated (see LabelsTruncatedMetadataKey)
+func GenerateInstanceIDFromLabels(labels, annotations map[string]string) (instanceidhandler.IInstanceID, error) {
+	if formatted, ok := annotations[InstanceIDMetadataKey]; ok && formatted != "" {
+		return GenerateInstanceIDFromString(formatted)
+	}
+	if labels[LabelsTruncatedMetadataKey] == "true" {
+		return nil, fmt.Errorf("failed to generate instance ID from labels: the label values were truncated")
+	}
+
+	apiVersion := labels[ApiVersionMetadataKey]
+	if group := labels[ApiGroupMetadataKey]; group != "" {
+		apiVersion = group + stringFormatSeparator + apiVersion
+	}
+	instanceID := &InstanceID{
+		apiVersion:    apiVersion,
+		namespace:     labels[NamespaceMetadataKey],
+		kind:          labels[KindMetadataKey],
+		name:          labels[NameMetadataKey],
+		containerName: labels[ContainerNameMetadataKey],
+	}
+	if err := validateInstanceID(instanceID); err != nil {
+		return nil, fmt.Errorf("failed to generate instance ID from labels: %w", err)
+	}
+	return instanceID, nil
+}
+
+func listInstanceIDMetadata(instanceIDs []instanceidhandler.IInstanceID) []InstanceIDMetadata {
+	metadata := make([]InstanceIDMetadata, 0, len(instanceIDs))
+	for i := range instanceIDs {
+		metadata = append(metadata, InstanceIDMetadata{
+			InstanceID:  instanceIDs[i],
+			Labels:      GetInstanceIDLabels(instanceIDs[i]),
+			Annotations: GetInstanceIDAnnotations(instanceIDs[i]),
+		})
+	}
+	return metadata
+}
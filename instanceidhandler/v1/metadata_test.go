@@ -0,0 +1,151 @@
+package instanceidhandler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/names"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateInstanceIDMetadata(t *testing.T) {
+	wp, err := workloadinterface.NewWorkload([]byte(deployment))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	metadata, err := GenerateInstanceIDMetadata(wp)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Equal(t, 1, len(metadata))
+	assert.Equal(t, "apiVersion-apps/v1/namespace-default/kind-ReplicaSet/name-nginx-84f5585d68/containerName-nginx", metadata[0].Annotations[InstanceIDMetadataKey])
+	assert.Equal(t, metadata[0].InstanceID.GetLabels(), metadata[0].Labels)
+
+	// round trip from labels and from annotations
+	fromLabels, err := GenerateInstanceIDFromLabels(metadata[0].Labels, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, metadata[0].InstanceID.GetStringFormatted(), fromLabels.GetStringFormatted())
+
+	fromAnnotations, err := GenerateInstanceIDFromLabels(nil, metadata[0].Annotations)
+	assert.NoError(t, err)
+	assert.Equal(t, metadata[0].InstanceID.GetStringFormatted(), fromAnnotations.GetStringFormatted())
+}
+
+func TestGenerateInstanceIDFromLabels(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name: "core group",
+			labels: map[string]string{
+				ApiGroupMetadataKey:      "",
+				ApiVersionMetadataKey:    "v1",
+				NamespaceMetadataKey:     "default",
+				KindMetadataKey:          "Pod",
+				NameMetadataKey:          "nginx",
+				ContainerNameMetadataKey: "nginx",
+			},
+			want: "apiVersion-v1/namespace-default/kind-Pod/name-nginx/containerName-nginx",
+		},
+		{
+			name: "missing container",
+			labels: map[string]string{
+				ApiVersionMetadataKey: "v1",
+				NamespaceMetadataKey:  "default",
+				KindMetadataKey:       "Pod",
+				NameMetadataKey:       "nginx",
+			},
+			wantErr: true,
+		},
+		{
+			name: "63 characters name",
+			labels: map[string]string{
+				ApiGroupMetadataKey:      "apps",
+				ApiVersionMetadataKey:    "v1",
+				NamespaceMetadataKey:     "default",
+				KindMetadataKey:          "ReplicaSet",
+				NameMetadataKey:          strings.Repeat("a", 63),
+				ContainerNameMetadataKey: "nginx",
+			},
+			want: "apiVersion-apps/v1/namespace-default/kind-ReplicaSet/name-" + strings.Repeat("a", 63) + "/containerName-nginx",
+		},
+		{
+			name:        "invalid annotation",
+			annotations: map[string]string{InstanceIDMetadataKey: "invalid"},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateInstanceIDFromLabels(tt.labels, tt.annotations)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GenerateInstanceIDFromLabels() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.want, got.GetStringFormatted())
+			}
+		})
+	}
+}
+
+func TestGetInstanceIDLabelsTruncated(t *testing.T) {
+	longName := strings.Repeat("nginx-", 20)
+	instanceID := &InstanceID{
+		apiVersion:    "apps/v1",
+		namespace:     "default",
+		kind:          "ReplicaSet",
+		name:          longName,
+		containerName: "nginx",
+	}
+	labels := GetInstanceIDLabels(instanceID)
+	assert.LessOrEqual(t, len(labels[NameMetadataKey]), names.LabelValueMaxLength)
+	assert.True(t, strings.HasPrefix(labels[NameMetadataKey], "nginx-nginx"))
+	assert.NotEqual(t, labels[NameMetadataKey], GetInstanceIDLabels(&InstanceID{name: longName + "a"})[NameMetadataKey])
+
+	// the instance ID cannot be rebuilt from the labels only
+	_, err := GenerateInstanceIDFromLabels(labels, nil)
+	assert.Error(t, err)
+
+	// the full instance ID is kept in the annotations
+	got, err := GenerateInstanceIDFromLabels(labels, GetInstanceIDAnnotations(instanceID))
+	assert.NoError(t, err)
+	assert.Equal(t, longName, got.GetName())
+}
+
+func TestGetInstanceIDLabelsTruncatedOnSeparator(t *testing.T) {
+	// the name is cut right after a '-', which is trimmed: the value is shorter than 63 characters
+	prefixLength := names.LabelValueMaxLength - 8 - 1
+	name := strings.Repeat("a", prefixLength-1) + "-" + strings.Repeat("b", 30)
+	labels := GetInstanceIDLabels(&InstanceID{apiVersion: "apps/v1", namespace: "default", kind: "ReplicaSet", name: name, containerName: "nginx"})
+	assert.Less(t, len(labels[NameMetadataKey]), names.LabelValueMaxLength)
+	assert.Equal(t, "true", labels[LabelsTruncatedMetadataKey])
+
+	_, err := GenerateInstanceIDFromLabels(labels, nil)
+	assert.Error(t, err)
+}
+
+func TestGetInstanceIDLabelsValid(t *testing.T) {
+	instanceID := &InstanceID{
+		apiVersion:    "apps/v1",
+		namespace:     "default",
+		kind:          "ReplicaSet",
+		name:          "my--app",
+		containerName: "my--app",
+	}
+	// the valid values are not rewritten
+	labels := GetInstanceIDLabels(instanceID)
+	assert.Equal(t, "my--app", labels[NameMetadataKey])
+	assert.Equal(t, "my--app", labels[ContainerNameMetadataKey])
+	assert.Equal(t, instanceID.GetLabels(), labels)
+	assert.NotContains(t, labels, LabelsTruncatedMetadataKey)
+
+	got, err := GenerateInstanceIDFromLabels(labels, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, instanceID.GetStringFormatted(), got.GetStringFormatted())
+}
@@ -0,0 +1,234 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	metadatafake "k8s.io/client-go/metadata/fake"
+)
+
+// NewKubernetesApi returns a KubernetesApi over the client-go fake clients seeded with the objects, so code that takes a *KubernetesApi can be unit tested without a cluster.
+// Objects can be typed (e.g. *corev1.Pod) or *unstructured.Unstructured. All the objects are served by the dynamic and metadata clients,
+// objects of kinds known to the client-go scheme are served by the typed clientset as well.
+// The k8sinterface resource mapping is initialized with the mock resources, kinds of the objects that are not part of it (e.g. custom resources) are added to it
+func NewKubernetesApi(objects ...runtime.Object) (*k8sinterface.KubernetesApi, error) {
+	k8sinterface.InitializeMapResourcesMock()
+	resourceLists, err := k8sinterface.GetResourceListMock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the mock resources: %w", err)
+	}
+	return newKubernetesApi(resourceLists, objects)
+}
+
+// NewKubernetesApiFromDirectory returns a fake KubernetesApi seeded with the fixtures of the directory (see LoadFixtures) and the objects
+func NewKubernetesApiFromDirectory(dir string, objects ...runtime.Object) (*k8sinterface.KubernetesApi, error) {
+	fixtures, err := LoadFixtures(dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewKubernetesApi(append(fixtures, objects...)...)
+}
+
+// NewKubernetesApiFromSnapshot returns a fake KubernetesApi serving the objects, the discovery and the server version of a snapshot (see k8sinterface.ReadSnapshot),
+// so scans can run offline against a captured cluster. The discovery of the snapshot is added to the k8sinterface resource mapping, the mock resources are used
+// for snapshots without discovery
+func NewKubernetesApiFromSnapshot(snapshot *k8sinterface.Snapshot) (*k8sinterface.KubernetesApi, error) {
+	resourceLists := snapshot.APIResourceLists
+	if len(resourceLists) == 0 {
+		k8sinterface.InitializeMapResourcesMock()
+		mockResourceLists, err := k8sinterface.GetResourceListMock()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the mock resources: %w", err)
+		}
+		resourceLists = mockResourceLists
+	} else {
+		k8sinterface.AddMapResources(resourceLists)
+	}
+
+	var objects []runtime.Object
+	for _, workloads := range snapshot.Objects {
+		for _, workload := range workloads {
+			objects = append(objects, &unstructured.Unstructured{Object: workload.GetObject()})
+		}
+	}
+	k8sAPI, err := newKubernetesApi(resourceLists, objects)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Metadata.ServerVersion != nil {
+		k8sAPI.DiscoveryClient.(*fakediscovery.FakeDiscovery).FakedServerVersion = snapshot.Metadata.ServerVersion
+	}
+	return k8sAPI, nil
+}
+
+func newKubernetesApi(resourceLists []*metav1.APIResourceList, objects []runtime.Object) (*k8sinterface.KubernetesApi, error) {
+	unstructuredObjects := make([]*unstructured.Unstructured, 0, len(objects))
+	for i := range objects {
+		obj, err := toUnstructured(objects[i])
+		if err != nil {
+			return nil, err
+		}
+		unstructuredObjects = append(unstructuredObjects, obj)
+	}
+
+	resources := newResourceMapping(resourceLists)
+	if customResourceLists := resources.addUnknownKinds(unstructuredObjects); len(customResourceLists) > 0 {
+		k8sinterface.AddMapResources(customResourceLists)
+		resourceLists = append(resourceLists, customResourceLists...)
+	}
+
+	kubernetesClient := kubernetesfake.NewSimpleClientset()
+	kubernetesClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = resourceLists
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), resources.listKinds())
+	metadataScheme := metadatafake.NewTestScheme()
+	if err := metav1.AddMetaToScheme(metadataScheme); err != nil {
+		return nil, err
+	}
+	metadataClient := metadatafake.NewSimpleMetadataClient(metadataScheme)
+
+	for _, obj := range unstructuredObjects {
+		gvr := resources.groupVersionResource(obj.GroupVersionKind())
+		namespace := obj.GetNamespace()
+		if err := dynamicClient.Tracker().Create(gvr, obj, namespace); err != nil {
+			return nil, fmt.Errorf("failed to add %s '%s/%s': %w", obj.GetKind(), namespace, obj.GetName(), err)
+		}
+		partialObjectMetadata := meta.AsPartialObjectMetadata(obj)
+		partialObjectMetadata.TypeMeta = metav1.TypeMeta{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind()}
+		if err := metadataClient.Tracker().Create(gvr, partialObjectMetadata, namespace); err != nil {
+			return nil, fmt.Errorf("failed to add %s '%s/%s' metadata: %w", obj.GetKind(), namespace, obj.GetName(), err)
+		}
+		typed, err := toTyped(obj)
+		if err != nil {
+			return nil, err
+		}
+		if typed == nil {
+			continue
+		}
+		if err := kubernetesClient.Tracker().Create(gvr, typed, namespace); err != nil {
+			return nil, fmt.Errorf("failed to add typed %s '%s/%s': %w", obj.GetKind(), namespace, obj.GetName(), err)
+		}
+	}
+
+	return &k8sinterface.KubernetesApi{
+		KubernetesClient: kubernetesClient,
+		DynamicClient:    dynamicClient,
+		DiscoveryClient:  kubernetesClient.Discovery(),
+		MetadataClient:   metadataClient,
+		Context:          context.Background(),
+	}, nil
+}
+
+// toUnstructured returns a copy of the object as unstructured, with the apiVersion and kind set from the client-go scheme if the typed object has none
+func toUnstructured(obj runtime.Object) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		if u.GetKind() == "" || u.GetAPIVersion() == "" {
+			return nil, fmt.Errorf("object '%s' has no apiVersion or kind", u.GetName())
+		}
+		return u.DeepCopy(), nil
+	}
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Empty() {
+		gvks, _, err := scheme.Scheme.ObjectKinds(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the kind of %T, set the object apiVersion and kind: %w", obj, err)
+		}
+		gvk = gvks[0]
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %T to unstructured: %w", obj, err)
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+	return u, nil
+}
+
+// toTyped returns the typed object of the unstructured object, or nil if the kind is not known to the client-go scheme
+func toTyped(obj *unstructured.Unstructured) (runtime.Object, error) {
+	typed, err := scheme.Scheme.New(obj.GroupVersionKind())
+	if err != nil {
+		return nil, nil
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, typed); err != nil {
+		return nil, fmt.Errorf("failed to convert %s '%s' to %T: %w", obj.GetKind(), obj.GetName(), typed, err)
+	}
+	return typed, nil
+}
+
+// resourceMapping maps the kinds to their resources
+type resourceMapping struct {
+	resources map[schema.GroupVersionKind]schema.GroupVersionResource
+}
+
+func newResourceMapping(resourceLists []*metav1.APIResourceList) *resourceMapping {
+	mapping := &resourceMapping{resources: map[schema.GroupVersionKind]schema.GroupVersionResource{}}
+	for i := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceLists[i].GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, apiResource := range resourceLists[i].APIResources {
+			if strings.Contains(apiResource.Name, "/") {
+				// subresource
+				continue
+			}
+			gvk := gv.WithKind(apiResource.Kind)
+			if _, ok := mapping.resources[gvk]; !ok {
+				mapping.resources[gvk] = gv.WithResource(apiResource.Name)
+			}
+		}
+	}
+	return mapping
+}
+
+// addUnknownKinds adds the kinds of the objects that are not mapped and returns their resource lists, the resource names are guessed from the kinds
+func (mapping *resourceMapping) addUnknownKinds(objects []*unstructured.Unstructured) []*metav1.APIResourceList {
+	resourceLists := map[schema.GroupVersion]*metav1.APIResourceList{}
+	var ordered []*metav1.APIResourceList
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		if _, ok := mapping.resources[gvk]; ok {
+			continue
+		}
+		gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+		mapping.resources[gvk] = gvr
+
+		resourceList, ok := resourceLists[gvk.GroupVersion()]
+		if !ok {
+			resourceList = &metav1.APIResourceList{GroupVersion: gvk.GroupVersion().String()}
+			resourceLists[gvk.GroupVersion()] = resourceList
+			ordered = append(ordered, resourceList)
+		}
+		resourceList.APIResources = append(resourceList.APIResources, metav1.APIResource{
+			Name:       gvr.Resource,
+			Namespaced: obj.GetNamespace() != "",
+			Kind:       gvk.Kind,
+			Verbs:      metav1.Verbs{"create", "delete", "get", "list", "patch", "update", "watch"},
+		})
+	}
+	return ordered
+}
+
+func (mapping *resourceMapping) groupVersionResource(gvk schema.GroupVersionKind) schema.GroupVersionResource {
+	return mapping.resources[gvk]
+}
+
+// listKinds returns the list kind of each resource, the fake dynamic client needs them to list resources that have no objects
+func (mapping *resourceMapping) listKinds() map[schema.GroupVersionResource]string {
+	listKinds := make(map[schema.GroupVersionResource]string, len(mapping.resources))
+	for gvk, gvr := range mapping.resources {
+		listKinds[gvr] = gvk.Kind + "List"
+	}
+	return listKinds
+}
@@ -0,0 +1,135 @@
+package fake
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	objects, err := LoadFixtures("testdata/fixtures")
+	assert.NoError(t, err)
+
+	var names []string
+	for _, obj := range objects {
+		u := obj.(*unstructured.Unstructured)
+		names = append(names, u.GetKind()+"/"+u.GetNamespace()+"/"+u.GetName())
+	}
+	assert.Equal(t, []string{
+		"ConfigMap/team-a/settings",
+		"ConfigMap/default/settings",
+		"RuntimeRuleBinding/team-a/default-rules",
+		"Namespace//team-a",
+		"Deployment/team-a/nginx",
+	}, names)
+
+	_, err = LoadFixtures("testdata/missing")
+	assert.Error(t, err)
+}
+
+func TestNewKubernetesApiFromDirectory(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nginx-1", Namespace: "team-a", Labels: map[string]string{"app": "nginx"}}}
+	k8sAPI, err := NewKubernetesApiFromDirectory("testdata/fixtures", pod)
+	assert.NoError(t, err)
+
+	// dynamic client
+	workload, err := k8sAPI.GetWorkload("team-a", "Deployment", "nginx")
+	assert.NoError(t, err)
+	assert.Equal(t, "nginx", workload.GetName())
+
+	pods, err := k8sAPI.ListWorkloads(&schema.GroupVersionResource{Version: "v1", Resource: "pods"}, "team-a", map[string]string{"app": "nginx"}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, pods, 1)
+	assert.Equal(t, "Pod", pods[0].GetKind())
+
+	configMaps, err := k8sAPI.ListWorkloads(&schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "", nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, configMaps, 2)
+
+	secrets, err := k8sAPI.ListWorkloads(&schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, "", nil, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, secrets)
+
+	// custom resources are added to the resource mapping
+	bindings, err := k8sAPI.ListWorkloads2("team-a", "RuntimeRuleBinding")
+	assert.NoError(t, err)
+	assert.Len(t, bindings, 1)
+	assert.True(t, k8sinterface.IsResourceInNamespaceScope("runtimerulebindings"))
+
+	// typed clientset
+	deployment, err := k8sAPI.KubernetesClient.AppsV1().Deployments("team-a").Get(context.Background(), "nginx", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "nginx:1.25", deployment.Spec.Template.Spec.Containers[0].Image)
+	podList, err := k8sAPI.ListPods("team-a", nil)
+	assert.NoError(t, err)
+	assert.Len(t, podList.Items, 1)
+
+	// metadata client
+	metadata, err := k8sAPI.ListResourcesMetadataOnly(&schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, "team-a", metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, metadata, 1)
+	assert.Equal(t, "settings", metadata[0].GetName())
+
+	// discovery
+	resources, err := k8sAPI.DiscoveryClient.ServerResourcesForGroupVersion("kubescape.io/v1")
+	assert.NoError(t, err)
+	assert.Equal(t, "runtimerulebindings", resources.APIResources[0].Name)
+}
+
+func TestNewKubernetesApi(t *testing.T) {
+	_, err := NewKubernetesApi(&unstructured.Unstructured{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "no-kind"}}})
+	assert.Error(t, err)
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"}}
+	_, err = NewKubernetesApi(pod, pod)
+	assert.Error(t, err, "duplicate objects")
+
+	k8sAPI, err := NewKubernetesApi()
+	assert.NoError(t, err)
+	_, err = k8sAPI.GetWorkload("default", "Pod", "nginx")
+	assert.Error(t, err)
+}
+
+func TestNewKubernetesApiFromSnapshot(t *testing.T) {
+	exported, err := NewKubernetesApiFromDirectory("testdata/fixtures")
+	require.NoError(t, err)
+	fakeDiscovery := exported.DiscoveryClient.(*fakediscovery.FakeDiscovery)
+	fakeDiscovery.FakedServerVersion = &version.Info{Major: "1", Minor: "27", GitVersion: "v1.27.4-eks-2d98532"}
+
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	snapshot, err := exported.CollectSnapshot(context.Background(), []schema.GroupVersionResource{configMaps, deployments}, nil)
+	require.NoError(t, err)
+	// the fake discovery does not serve the preferred resources
+	snapshot.APIResourceLists = fakeDiscovery.Resources
+	buffer := bytes.Buffer{}
+	require.NoError(t, snapshot.Write(&buffer))
+	snapshot, err = k8sinterface.ReadSnapshot(&buffer)
+	require.NoError(t, err)
+
+	k8sAPI, err := NewKubernetesApiFromSnapshot(snapshot)
+	require.NoError(t, err)
+	workloads, err := k8sAPI.ListWorkloads(&configMaps, "", nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, workloads, 2)
+	deployment, err := k8sAPI.KubernetesClient.AppsV1().Deployments("team-a").Get(context.Background(), "nginx", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "nginx:1.25", deployment.Spec.Template.Spec.Containers[0].Image)
+	secrets, err := k8sAPI.ListWorkloads(&schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, "", nil, nil)
+	assert.NoError(t, err, "resources of the discovery are served without objects")
+	assert.Empty(t, secrets)
+
+	clusterVersion, err := k8sAPI.GetClusterVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, k8sinterface.DistributionEKS, clusterVersion.Distribution)
+}
@@ -0,0 +1,219 @@
+package k8sinterface
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	// DefaultAccessCheckParallelism is the number of access reviews created concurrently by CheckAccessMatrix
+	DefaultAccessCheckParallelism = 10
+	// DefaultAccessCheckQPS and DefaultAccessCheckBurst rate limit the access reviews created by CheckAccessMatrix
+	DefaultAccessCheckQPS   = 20
+	DefaultAccessCheckBurst = 40
+)
+
+// Subject is the subject of an access review: a user, a group or a service account (rbacv1.UserKind, rbacv1.GroupKind, rbacv1.ServiceAccountKind)
+type Subject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"` // namespace of the service account
+	// Groups are the groups of a user, the groups of a service account are added automatically
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Action is a request to authorize: a verb on a resource (namespaced if Namespace is set) or on a non-resource URL, e.g. /metrics
+type Action struct {
+	Verb           string `json:"verb"`
+	Group          string `json:"group,omitempty"`
+	Resource       string `json:"resource,omitempty"`
+	Subresource    string `json:"subresource,omitempty"`
+	Name           string `json:"name,omitempty"`
+	Namespace      string `json:"namespace,omitempty"`
+	NonResourceURL string `json:"nonResourceURL,omitempty"`
+}
+
+// AccessResult is the result of the access review of an action for a subject
+type AccessResult struct {
+	Allowed bool `json:"allowed"`
+	// Denied is true if an authorizer explicitly denied the action, Allowed is then false
+	Denied bool   `json:"denied,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	// Error is set if the access review could not be created, or if the authorizer failed to evaluate it
+	Error string `json:"error,omitempty"`
+}
+
+// AccessMatrix is the access of the subjects (rows) to the actions (columns)
+type AccessMatrix struct {
+	Subjects []Subject        `json:"subjects"`
+	Actions  []Action         `json:"actions"`
+	Results  [][]AccessResult `json:"results"`
+}
+
+// IsAllowed returns true if the subject is allowed to perform the action, false if either is not part of the matrix
+func (accessMatrix *AccessMatrix) IsAllowed(subject Subject, action Action) bool {
+	for i := range accessMatrix.Subjects {
+		if !subjectEqual(&accessMatrix.Subjects[i], &subject) {
+			continue
+		}
+		for j := range accessMatrix.Actions {
+			if accessMatrix.Actions[j] == action {
+				return accessMatrix.Results[i][j].Allowed
+			}
+		}
+	}
+	return false
+}
+
+// AccessCheckOptions configures CheckAccessMatrixWithOptions
+type AccessCheckOptions struct {
+	// Parallelism is the maximum number of access reviews created concurrently, DefaultAccessCheckParallelism if not set
+	Parallelism int
+	// QPS and Burst rate limit the access reviews, DefaultAccessCheckQPS and DefaultAccessCheckBurst if not set
+	QPS   float32
+	Burst int
+	// LocalSubjectAccessReviews reviews the namespaced actions with LocalSubjectAccessReviews, which only require
+	// permissions in the namespace of the action
+	LocalSubjectAccessReviews bool
+}
+
+// CheckAccessMatrix reviews the access of each subject to each action with SubjectAccessReviews.
+// The API server evaluates the reviews with all its authorizers, including the aggregated ClusterRoles and the webhooks
+func (k8sAPI *KubernetesApi) CheckAccessMatrix(subjects []Subject, actions []Action) (*AccessMatrix, error) {
+	return k8sAPI.CheckAccessMatrixWithOptions(k8sAPI.Context, subjects, actions, nil)
+}
+
+// CheckAccessMatrixWithOptions reviews the access of each subject to each action concurrently.
+// A review that fails does not fail the others: its result holds the error, and the returned error counts the failed reviews
+func (k8sAPI *KubernetesApi) CheckAccessMatrixWithOptions(ctx context.Context, subjects []Subject, actions []Action, opts *AccessCheckOptions) (*AccessMatrix, error) {
+	if opts == nil {
+		opts = &AccessCheckOptions{}
+	}
+	ctx, span := tracing.StartSpan(ctx, "k8s.CheckAccessMatrix", tracing.AttributeCount.Int(len(subjects)*len(actions)))
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = DefaultAccessCheckParallelism
+	}
+	qps, burst := opts.QPS, opts.Burst
+	if qps <= 0 {
+		qps = DefaultAccessCheckQPS
+	}
+	if burst <= 0 {
+		burst = DefaultAccessCheckBurst
+	}
+	rateLimiter := flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+	defer rateLimiter.Stop()
+
+	accessMatrix := &AccessMatrix{Subjects: subjects, Actions: actions, Results: make([][]AccessResult, len(subjects))}
+	failed := 0
+	var firstErr error
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	semaphore := make(chan struct{}, parallelism)
+
+	for i := range subjects {
+		accessMatrix.Results[i] = make([]AccessResult, len(actions))
+		for j := range actions {
+			i, j := i, j
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				semaphore <- struct{}{}
+				defer func() { <-semaphore }()
+
+				err := rateLimiter.Wait(ctx)
+				if err == nil {
+					err = k8sAPI.reviewAccess(ctx, &subjects[i], &actions[j], opts.LocalSubjectAccessReviews, &accessMatrix.Results[i][j])
+				}
+				if err != nil {
+					accessMatrix.Results[i][j].Error = err.Error()
+					mutex.Lock()
+					defer mutex.Unlock()
+					if failed++; firstErr == nil {
+						firstErr = err
+					}
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	if failed > 0 {
+		err := fmt.Errorf("failed to review %d of %d accesses, first error: %s", failed, len(subjects)*len(actions), firstErr.Error())
+		tracing.EndSpan(span, err)
+		return accessMatrix, err
+	}
+	tracing.EndSpan(span, nil)
+	return accessMatrix, nil
+}
+
+func (k8sAPI *KubernetesApi) reviewAccess(ctx context.Context, subject *Subject, action *Action, local bool, result *AccessResult) error {
+	spec := newSubjectAccessReviewSpec(subject, action)
+	var status authorizationv1.SubjectAccessReviewStatus
+	if local && action.Namespace != "" && action.NonResourceURL == "" {
+		review, err := k8sAPI.KubernetesClient.AuthorizationV1().LocalSubjectAccessReviews(action.Namespace).Create(ctx,
+			&authorizationv1.LocalSubjectAccessReview{ObjectMeta: metav1.ObjectMeta{Namespace: action.Namespace}, Spec: spec}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to CREATE localsubjectaccessreviews, reason: %s", err.Error())
+		}
+		status = review.Status
+	} else {
+		review, err := k8sAPI.KubernetesClient.AuthorizationV1().SubjectAccessReviews().Create(ctx,
+			&authorizationv1.SubjectAccessReview{Spec: spec}, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to CREATE subjectaccessreviews, reason: %s", err.Error())
+		}
+		status = review.Status
+	}
+	result.Allowed = status.Allowed
+	result.Denied = status.Denied
+	result.Reason = status.Reason
+	result.Error = status.EvaluationError
+	return nil
+}
+
+// newSubjectAccessReviewSpec returns the spec reviewing the action as the subject, with the user and groups the API server authenticates it with
+func newSubjectAccessReviewSpec(subject *Subject, action *Action) authorizationv1.SubjectAccessReviewSpec {
+	spec := authorizationv1.SubjectAccessReviewSpec{Groups: append([]string{}, subject.Groups...)}
+	switch subject.Kind {
+	case rbacv1.ServiceAccountKind:
+		spec.User = fmt.Sprintf("system:serviceaccount:%s:%s", subject.Namespace, subject.Name)
+		spec.Groups = append(spec.Groups, "system:serviceaccounts", "system:serviceaccounts:"+subject.Namespace, "system:authenticated")
+	case rbacv1.GroupKind:
+		spec.Groups = append(spec.Groups, subject.Name)
+	default:
+		spec.User = subject.Name
+	}
+	if action.NonResourceURL != "" {
+		spec.NonResourceAttributes = &authorizationv1.NonResourceAttributes{Path: action.NonResourceURL, Verb: action.Verb}
+	} else {
+		spec.ResourceAttributes = &authorizationv1.ResourceAttributes{
+			Namespace:   action.Namespace,
+			Verb:        action.Verb,
+			Group:       action.Group,
+			Resource:    action.Resource,
+			Subresource: action.Subresource,
+			Name:        action.Name,
+		}
+	}
+	return spec
+}
+
+func subjectEqual(a, b *Subject) bool {
+	if a.Kind != b.Kind || a.Name != b.Name || a.Namespace != b.Namespace || len(a.Groups) != len(b.Groups) {
+		return false
+	}
+	for i := range a.Groups {
+		if a.Groups[i] != b.Groups[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package k8sinterface
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCheckAccessMatrix(t *testing.T) {
+	client := kubernetesfake.NewSimpleClientset()
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		if review.Spec.User == "broken" {
+			return true, nil, errors.New("webhook unavailable")
+		}
+		review.Status.Allowed = review.Spec.User == "system:serviceaccount:default:admin" ||
+			(review.Spec.ResourceAttributes != nil && review.Spec.ResourceAttributes.Verb == "get" && contains(review.Spec.Groups, "viewers"))
+		return true, review, nil
+	})
+	client.PrependReactor("create", "localsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.LocalSubjectAccessReview)
+		review.Status.Allowed = review.Spec.ResourceAttributes.Namespace == "default"
+		review.Status.Reason = "local"
+		return true, review, nil
+	})
+	k8sAPI := &KubernetesApi{KubernetesClient: client, Context: context.Background()}
+
+	admin := Subject{Kind: rbacv1.ServiceAccountKind, Name: "admin", Namespace: "default"}
+	viewers := Subject{Kind: rbacv1.GroupKind, Name: "viewers"}
+	getPods := Action{Verb: "get", Resource: "pods", Namespace: "default"}
+	deleteNodes := Action{Verb: "delete", Resource: "nodes"}
+	metrics := Action{Verb: "get", NonResourceURL: "/metrics"}
+
+	accessMatrix, err := k8sAPI.CheckAccessMatrix([]Subject{admin, viewers}, []Action{getPods, deleteNodes, metrics})
+	require.NoError(t, err)
+	require.Len(t, accessMatrix.Results, 2)
+	require.Len(t, accessMatrix.Results[0], 3)
+	assert.True(t, accessMatrix.IsAllowed(admin, getPods))
+	assert.True(t, accessMatrix.IsAllowed(admin, deleteNodes))
+	assert.True(t, accessMatrix.IsAllowed(viewers, getPods))
+	assert.False(t, accessMatrix.IsAllowed(viewers, deleteNodes))
+	assert.False(t, accessMatrix.IsAllowed(viewers, metrics))
+	assert.False(t, accessMatrix.IsAllowed(Subject{Kind: rbacv1.UserKind, Name: "unknown"}, getPods))
+
+	// namespaced actions with LocalSubjectAccessReviews, the cluster scoped ones with SubjectAccessReviews
+	accessMatrix, err = k8sAPI.CheckAccessMatrixWithOptions(context.Background(), []Subject{viewers}, []Action{getPods, deleteNodes},
+		&AccessCheckOptions{Parallelism: 1, QPS: 100, Burst: 1, LocalSubjectAccessReviews: true})
+	require.NoError(t, err)
+	assert.Equal(t, AccessResult{Allowed: true, Reason: "local"}, accessMatrix.Results[0][0])
+	assert.Equal(t, AccessResult{}, accessMatrix.Results[0][1])
+
+	broken := Subject{Kind: rbacv1.UserKind, Name: "broken"}
+	accessMatrix, err = k8sAPI.CheckAccessMatrix([]Subject{admin, broken}, []Action{getPods})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to review 1 of 2 accesses")
+	assert.True(t, accessMatrix.Results[0][0].Allowed)
+	assert.Contains(t, accessMatrix.Results[1][0].Error, "webhook unavailable")
+}
+
+func TestNewSubjectAccessReviewSpec(t *testing.T) {
+	spec := newSubjectAccessReviewSpec(&Subject{Kind: rbacv1.ServiceAccountKind, Name: "default", Namespace: "kube-system"}, &Action{Verb: "list", Group: "apps", Resource: "deployments"})
+	assert.Equal(t, "system:serviceaccount:kube-system:default", spec.User)
+	assert.Equal(t, []string{"system:serviceaccounts", "system:serviceaccounts:kube-system", "system:authenticated"}, spec.Groups)
+	assert.Equal(t, &authorizationv1.ResourceAttributes{Verb: "list", Group: "apps", Resource: "deployments"}, spec.ResourceAttributes)
+	assert.Nil(t, spec.NonResourceAttributes)
+
+	spec = newSubjectAccessReviewSpec(&Subject{Kind: rbacv1.UserKind, Name: "jane", Groups: []string{"dev"}}, &Action{Verb: "get", NonResourceURL: "/healthz"})
+	assert.Equal(t, "jane", spec.User)
+	assert.Equal(t, []string{"dev"}, spec.Groups)
+	assert.Equal(t, &authorizationv1.NonResourceAttributes{Verb: "get", Path: "/healthz"}, spec.NonResourceAttributes)
+	assert.Nil(t, spec.ResourceAttributes)
+
+	spec = newSubjectAccessReviewSpec(&Subject{Kind: rbacv1.GroupKind, Name: "system:masters"}, &Action{Verb: "*", Resource: "*"})
+	assert.Empty(t, spec.User)
+	assert.Equal(t, []string{"system:masters"}, spec.Groups)
+}
@@ -0,0 +1,380 @@
+package k8sinterface
+
+import (
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	ValidatingWebhookConfigurationKind     = "ValidatingWebhookConfiguration"
+	MutatingWebhookConfigurationKind       = "MutatingWebhookConfiguration"
+	ValidatingAdmissionPolicyKind          = "ValidatingAdmissionPolicy"
+	ValidatingAdmissionPolicyBindingKind   = "ValidatingAdmissionPolicyBinding"
+	admissionregistrationGroup             = "admissionregistration.k8s.io"
+	validatingWebhookConfigurationResource = "validatingwebhookconfigurations"
+	mutatingWebhookConfigurationResource   = "mutatingwebhookconfigurations"
+)
+
+// AdmissionRule is a rule of the resources and operations an admission webhook or policy applies to
+type AdmissionRule struct {
+	Operations    []string `json:"operations,omitempty"`
+	APIGroups     []string `json:"apiGroups,omitempty"`
+	APIVersions   []string `json:"apiVersions,omitempty"`
+	Resources     []string `json:"resources,omitempty"`
+	ResourceNames []string `json:"resourceNames,omitempty"`
+	Scope         string   `json:"scope,omitempty"`
+}
+
+// WebhookService is the in-cluster service an admission webhook calls
+type WebhookService struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Path      string `json:"path,omitempty"`
+	Port      int32  `json:"port,omitempty"`
+}
+
+// WebhookSummary is a webhook of a ValidatingWebhookConfiguration or MutatingWebhookConfiguration.
+// Optional fields the API server defaults are empty if they were not set
+type WebhookSummary struct {
+	ConfigurationKind       string                `json:"configurationKind"`
+	ConfigurationName       string                `json:"configurationName"`
+	Name                    string                `json:"name"`
+	Rules                   []AdmissionRule       `json:"rules,omitempty"`
+	FailurePolicy           string                `json:"failurePolicy,omitempty"`
+	MatchPolicy             string                `json:"matchPolicy,omitempty"`
+	SideEffects             string                `json:"sideEffects,omitempty"`
+	ReinvocationPolicy      string                `json:"reinvocationPolicy,omitempty"`
+	TimeoutSeconds          int32                 `json:"timeoutSeconds,omitempty"`
+	AdmissionReviewVersions []string              `json:"admissionReviewVersions,omitempty"`
+	NamespaceSelector       *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	ObjectSelector          *metav1.LabelSelector `json:"objectSelector,omitempty"`
+	// Service is the in-cluster service the webhook calls, nil if the webhook calls an URL
+	Service *WebhookService `json:"service,omitempty"`
+	URL     string          `json:"url,omitempty"`
+	// CABundle is the PEM bundle the API server verifies the webhook certificate with, empty if the system trust roots are used
+	CABundle []byte `json:"caBundle,omitempty"`
+}
+
+// IsMutating returns true if the webhook is of a MutatingWebhookConfiguration
+func (webhook *WebhookSummary) IsMutating() bool {
+	return webhook.ConfigurationKind == MutatingWebhookConfigurationKind
+}
+
+// ParamKind is the kind of the parameter resources of a ValidatingAdmissionPolicy
+type ParamKind struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// PolicyValidation is a CEL validation of a ValidatingAdmissionPolicy
+type PolicyValidation struct {
+	Expression string `json:"expression"`
+	Message    string `json:"message,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// ValidatingAdmissionPolicySummary is a ValidatingAdmissionPolicy of any served version (v1alpha1, v1beta1, v1)
+type ValidatingAdmissionPolicySummary struct {
+	Name              string                `json:"name"`
+	APIVersion        string                `json:"apiVersion"`
+	FailurePolicy     string                `json:"failurePolicy,omitempty"`
+	ParamKind         *ParamKind            `json:"paramKind,omitempty"`
+	MatchRules        []AdmissionRule       `json:"matchRules,omitempty"`
+	ExcludeRules      []AdmissionRule       `json:"excludeRules,omitempty"`
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	ObjectSelector    *metav1.LabelSelector `json:"objectSelector,omitempty"`
+	Validations       []PolicyValidation    `json:"validations,omitempty"`
+}
+
+// ValidatingAdmissionPolicyBindingSummary is a ValidatingAdmissionPolicyBinding of any served version (v1alpha1, v1beta1, v1)
+type ValidatingAdmissionPolicyBindingSummary struct {
+	Name              string                `json:"name"`
+	APIVersion        string                `json:"apiVersion"`
+	PolicyName        string                `json:"policyName"`
+	ParamRefName      string                `json:"paramRefName,omitempty"`
+	ParamRefNamespace string                `json:"paramRefNamespace,omitempty"`
+	ValidationActions []string              `json:"validationActions,omitempty"`
+	MatchRules        []AdmissionRule       `json:"matchRules,omitempty"`
+	ExcludeRules      []AdmissionRule       `json:"excludeRules,omitempty"`
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	ObjectSelector    *metav1.LabelSelector `json:"objectSelector,omitempty"`
+}
+
+// ListValidatingWebhooks returns the webhooks of all the ValidatingWebhookConfigurations of the cluster
+func (k8sAPI *KubernetesApi) ListValidatingWebhooks() ([]WebhookSummary, error) {
+	ctx, span := k8sAPI.startSpan("k8s.ListValidatingWebhooks", &schema.GroupVersionResource{Group: admissionregistrationGroup, Version: "v1", Resource: validatingWebhookConfigurationResource}, "", "")
+	configurations, err := k8sAPI.KubernetesClient.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	webhooks := []WebhookSummary{}
+	for i := range configurations.Items {
+		webhooks = append(webhooks, SummarizeValidatingWebhookConfiguration(&configurations.Items[i])...)
+	}
+	return webhooks, nil
+}
+
+// ListMutatingWebhooks returns the webhooks of all the MutatingWebhookConfigurations of the cluster
+func (k8sAPI *KubernetesApi) ListMutatingWebhooks() ([]WebhookSummary, error) {
+	ctx, span := k8sAPI.startSpan("k8s.ListMutatingWebhooks", &schema.GroupVersionResource{Group: admissionregistrationGroup, Version: "v1", Resource: mutatingWebhookConfigurationResource}, "", "")
+	configurations, err := k8sAPI.KubernetesClient.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	webhooks := []WebhookSummary{}
+	for i := range configurations.Items {
+		webhooks = append(webhooks, SummarizeMutatingWebhookConfiguration(&configurations.Items[i])...)
+	}
+	return webhooks, nil
+}
+
+// ListValidatingAdmissionPolicies returns the ValidatingAdmissionPolicies of the cluster, using the version the discovery prefers.
+// Returns an empty list if the cluster does not serve ValidatingAdmissionPolicies
+func (k8sAPI *KubernetesApi) ListValidatingAdmissionPolicies() ([]ValidatingAdmissionPolicySummary, error) {
+	items, err := k8sAPI.listAdmissionPolicyObjects(ValidatingAdmissionPolicyKind)
+	if err != nil {
+		return nil, err
+	}
+	policies := make([]ValidatingAdmissionPolicySummary, 0, len(items))
+	for i := range items {
+		policies = append(policies, *ParseValidatingAdmissionPolicy(items[i].Object))
+	}
+	return policies, nil
+}
+
+// ListValidatingAdmissionPolicyBindings returns the ValidatingAdmissionPolicyBindings of the cluster, using the version the discovery prefers.
+// Returns an empty list if the cluster does not serve ValidatingAdmissionPolicyBindings
+func (k8sAPI *KubernetesApi) ListValidatingAdmissionPolicyBindings() ([]ValidatingAdmissionPolicyBindingSummary, error) {
+	items, err := k8sAPI.listAdmissionPolicyObjects(ValidatingAdmissionPolicyBindingKind)
+	if err != nil {
+		return nil, err
+	}
+	bindings := make([]ValidatingAdmissionPolicyBindingSummary, 0, len(items))
+	for i := range items {
+		bindings = append(bindings, *ParseValidatingAdmissionPolicyBinding(items[i].Object))
+	}
+	return bindings, nil
+}
+
+// listAdmissionPolicyObjects lists the objects of the kind with the dynamic client. The typed client does not support all the versions of the admission policies
+func (k8sAPI *KubernetesApi) listAdmissionPolicyObjects(kind string) ([]unstructured.Unstructured, error) {
+	info, ok := GetKindInfo(kind)
+	if !ok || info.GroupVersionResource.Group != admissionregistrationGroup {
+		return nil, nil
+	}
+	ctx, span := k8sAPI.startSpan("k8s.List"+kind+"s", &info.GroupVersionResource, "", "")
+	list, err := k8sAPI.DynamicClient.Resource(info.GroupVersionResource).List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST %s, reason: %s", info.GroupVersionResource.Resource, err.Error())
+	}
+	return list.Items, nil
+}
+
+// SummarizeValidatingWebhookConfiguration returns the webhooks of the configuration
+func SummarizeValidatingWebhookConfiguration(configuration *admissionregistrationv1.ValidatingWebhookConfiguration) []WebhookSummary {
+	webhooks := make([]WebhookSummary, 0, len(configuration.Webhooks))
+	for i := range configuration.Webhooks {
+		webhook := &configuration.Webhooks[i]
+		summary := WebhookSummary{
+			ConfigurationKind:       ValidatingWebhookConfigurationKind,
+			ConfigurationName:       configuration.GetName(),
+			Name:                    webhook.Name,
+			Rules:                   ruleWithOperationsToAdmissionRules(webhook.Rules),
+			FailurePolicy:           stringValue((*string)(webhook.FailurePolicy)),
+			MatchPolicy:             stringValue((*string)(webhook.MatchPolicy)),
+			SideEffects:             stringValue((*string)(webhook.SideEffects)),
+			TimeoutSeconds:          int32Value(webhook.TimeoutSeconds),
+			AdmissionReviewVersions: webhook.AdmissionReviewVersions,
+			NamespaceSelector:       webhook.NamespaceSelector,
+			ObjectSelector:          webhook.ObjectSelector,
+		}
+		setWebhookClientConfig(&summary, &webhook.ClientConfig)
+		webhooks = append(webhooks, summary)
+	}
+	return webhooks
+}
+
+// SummarizeMutatingWebhookConfiguration returns the webhooks of the configuration
+func SummarizeMutatingWebhookConfiguration(configuration *admissionregistrationv1.MutatingWebhookConfiguration) []WebhookSummary {
+	webhooks := make([]WebhookSummary, 0, len(configuration.Webhooks))
+	for i := range configuration.Webhooks {
+		webhook := &configuration.Webhooks[i]
+		summary := WebhookSummary{
+			ConfigurationKind:       MutatingWebhookConfigurationKind,
+			ConfigurationName:       configuration.GetName(),
+			Name:                    webhook.Name,
+			Rules:                   ruleWithOperationsToAdmissionRules(webhook.Rules),
+			FailurePolicy:           stringValue((*string)(webhook.FailurePolicy)),
+			MatchPolicy:             stringValue((*string)(webhook.MatchPolicy)),
+			SideEffects:             stringValue((*string)(webhook.SideEffects)),
+			ReinvocationPolicy:      stringValue((*string)(webhook.ReinvocationPolicy)),
+			TimeoutSeconds:          int32Value(webhook.TimeoutSeconds),
+			AdmissionReviewVersions: webhook.AdmissionReviewVersions,
+			NamespaceSelector:       webhook.NamespaceSelector,
+			ObjectSelector:          webhook.ObjectSelector,
+		}
+		setWebhookClientConfig(&summary, &webhook.ClientConfig)
+		webhooks = append(webhooks, summary)
+	}
+	return webhooks
+}
+
+// SummarizeWebhookConfiguration returns the webhooks of an unstructured ValidatingWebhookConfiguration or MutatingWebhookConfiguration,
+// e.g. an object of IWorkload.GetObject()
+func SummarizeWebhookConfiguration(object map[string]interface{}) ([]WebhookSummary, error) {
+	switch kind, _, _ := unstructured.NestedString(object, "kind"); kind {
+	case ValidatingWebhookConfigurationKind:
+		configuration := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object, configuration); err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %w", kind, err)
+		}
+		return SummarizeValidatingWebhookConfiguration(configuration), nil
+	case MutatingWebhookConfigurationKind:
+		configuration := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object, configuration); err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %w", kind, err)
+		}
+		return SummarizeMutatingWebhookConfiguration(configuration), nil
+	default:
+		return nil, fmt.Errorf("kind '%s' is not a webhook configuration", kind)
+	}
+}
+
+// ParseValidatingAdmissionPolicy returns the summary of an unstructured ValidatingAdmissionPolicy. Missing fields are left empty
+func ParseValidatingAdmissionPolicy(object map[string]interface{}) *ValidatingAdmissionPolicySummary {
+	policy := &ValidatingAdmissionPolicySummary{}
+	policy.Name, _, _ = unstructured.NestedString(object, "metadata", "name")
+	policy.APIVersion, _, _ = unstructured.NestedString(object, "apiVersion")
+	policy.FailurePolicy, _, _ = unstructured.NestedString(object, "spec", "failurePolicy")
+
+	if paramKind, ok, _ := unstructured.NestedMap(object, "spec", "paramKind"); ok {
+		policy.ParamKind = &ParamKind{}
+		policy.ParamKind.APIVersion, _, _ = unstructured.NestedString(paramKind, "apiVersion")
+		policy.ParamKind.Kind, _, _ = unstructured.NestedString(paramKind, "kind")
+	}
+	if matchConstraints, ok, _ := unstructured.NestedMap(object, "spec", "matchConstraints"); ok {
+		policy.MatchRules, policy.ExcludeRules, policy.NamespaceSelector, policy.ObjectSelector = parseMatchResources(matchConstraints)
+	}
+	validations, _, _ := unstructured.NestedSlice(object, "spec", "validations")
+	for i := range validations {
+		validation, ok := validations[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		policyValidation := PolicyValidation{}
+		policyValidation.Expression, _, _ = unstructured.NestedString(validation, "expression")
+		policyValidation.Message, _, _ = unstructured.NestedString(validation, "message")
+		policyValidation.Reason, _, _ = unstructured.NestedString(validation, "reason")
+		policy.Validations = append(policy.Validations, policyValidation)
+	}
+	return policy
+}
+
+// ParseValidatingAdmissionPolicyBinding returns the summary of an unstructured ValidatingAdmissionPolicyBinding. Missing fields are left empty
+func ParseValidatingAdmissionPolicyBinding(object map[string]interface{}) *ValidatingAdmissionPolicyBindingSummary {
+	binding := &ValidatingAdmissionPolicyBindingSummary{}
+	binding.Name, _, _ = unstructured.NestedString(object, "metadata", "name")
+	binding.APIVersion, _, _ = unstructured.NestedString(object, "apiVersion")
+	binding.PolicyName, _, _ = unstructured.NestedString(object, "spec", "policyName")
+	binding.ParamRefName, _, _ = unstructured.NestedString(object, "spec", "paramRef", "name")
+	binding.ParamRefNamespace, _, _ = unstructured.NestedString(object, "spec", "paramRef", "namespace")
+	binding.ValidationActions, _, _ = unstructured.NestedStringSlice(object, "spec", "validationActions")
+	if matchResources, ok, _ := unstructured.NestedMap(object, "spec", "matchResources"); ok {
+		binding.MatchRules, binding.ExcludeRules, binding.NamespaceSelector, binding.ObjectSelector = parseMatchResources(matchResources)
+	}
+	return binding
+}
+
+// parseMatchResources returns the resource rules, excluded resource rules and selectors of the matchConstraints/matchResources of a policy or binding
+func parseMatchResources(matchResources map[string]interface{}) ([]AdmissionRule, []AdmissionRule, *metav1.LabelSelector, *metav1.LabelSelector) {
+	return parseAdmissionRules(matchResources, "resourceRules"),
+		parseAdmissionRules(matchResources, "excludeResourceRules"),
+		parseLabelSelector(matchResources, "namespaceSelector"),
+		parseLabelSelector(matchResources, "objectSelector")
+}
+
+func parseAdmissionRules(object map[string]interface{}, field string) []AdmissionRule {
+	rules, _, _ := unstructured.NestedSlice(object, field)
+	var admissionRules []AdmissionRule
+	for i := range rules {
+		rule, ok := rules[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		admissionRule := AdmissionRule{}
+		admissionRule.Operations, _, _ = unstructured.NestedStringSlice(rule, "operations")
+		admissionRule.APIGroups, _, _ = unstructured.NestedStringSlice(rule, "apiGroups")
+		admissionRule.APIVersions, _, _ = unstructured.NestedStringSlice(rule, "apiVersions")
+		admissionRule.Resources, _, _ = unstructured.NestedStringSlice(rule, "resources")
+		admissionRule.ResourceNames, _, _ = unstructured.NestedStringSlice(rule, "resourceNames")
+		admissionRule.Scope, _, _ = unstructured.NestedString(rule, "scope")
+		admissionRules = append(admissionRules, admissionRule)
+	}
+	return admissionRules
+}
+
+func parseLabelSelector(object map[string]interface{}, field string) *metav1.LabelSelector {
+	selector, ok, _ := unstructured.NestedMap(object, field)
+	if !ok {
+		return nil
+	}
+	labelSelector := &metav1.LabelSelector{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(selector, labelSelector); err != nil {
+		return nil
+	}
+	return labelSelector
+}
+
+func ruleWithOperationsToAdmissionRules(rules []admissionregistrationv1.RuleWithOperations) []AdmissionRule {
+	admissionRules := make([]AdmissionRule, 0, len(rules))
+	for i := range rules {
+		operations := make([]string, 0, len(rules[i].Operations))
+		for _, operation := range rules[i].Operations {
+			operations = append(operations, string(operation))
+		}
+		admissionRules = append(admissionRules, AdmissionRule{
+			Operations:  operations,
+			APIGroups:   rules[i].APIGroups,
+			APIVersions: rules[i].APIVersions,
+			Resources:   rules[i].Resources,
+			Scope:       stringValue((*string)(rules[i].Scope)),
+		})
+	}
+	return admissionRules
+}
+
+func setWebhookClientConfig(summary *WebhookSummary, clientConfig *admissionregistrationv1.WebhookClientConfig) {
+	summary.URL = stringValue(clientConfig.URL)
+	summary.CABundle = clientConfig.CABundle
+	if clientConfig.Service != nil {
+		summary.Service = &WebhookService{
+			Namespace: clientConfig.Service.Namespace,
+			Name:      clientConfig.Service.Name,
+			Path:      stringValue(clientConfig.Service.Path),
+			Port:      int32Value(clientConfig.Service.Port),
+		}
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func int32Value(i *int32) int32 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
@@ -0,0 +1,201 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestListWebhooks(t *testing.T) {
+	fail := admissionregistrationv1.Fail
+	ignore := admissionregistrationv1.Ignore
+	none := admissionregistrationv1.SideEffectClassNone
+	namespacedScope := admissionregistrationv1.NamespacedScope
+	path := "/validate"
+	port := int32(8443)
+	url := "https://webhook.example.com/mutate"
+	timeout := int32(5)
+
+	validating := &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "gatekeeper"},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+			Name: "validation.gatekeeper.sh",
+			Rules: []admissionregistrationv1.RuleWithOperations{{
+				Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+				Rule:       admissionregistrationv1.Rule{APIGroups: []string{"*"}, APIVersions: []string{"*"}, Resources: []string{"*"}, Scope: &namespacedScope},
+			}},
+			FailurePolicy:  &ignore,
+			SideEffects:    &none,
+			TimeoutSeconds: &timeout,
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				Service:  &admissionregistrationv1.ServiceReference{Namespace: "gatekeeper-system", Name: "gatekeeper-webhook-service", Path: &path, Port: &port},
+				CABundle: []byte("ca"),
+			},
+		}},
+	}
+	mutating := &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "injector"},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{{
+			Name:          "inject.example.com",
+			FailurePolicy: &fail,
+			ClientConfig:  admissionregistrationv1.WebhookClientConfig{URL: &url},
+		}},
+	}
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesfake.NewSimpleClientset(validating, mutating), Context: context.Background()}
+
+	validatingWebhooks, err := k8sAPI.ListValidatingWebhooks()
+	require.NoError(t, err)
+	require.Len(t, validatingWebhooks, 1)
+	webhook := validatingWebhooks[0]
+	assert.Equal(t, "gatekeeper", webhook.ConfigurationName)
+	assert.Equal(t, "validation.gatekeeper.sh", webhook.Name)
+	assert.False(t, webhook.IsMutating())
+	assert.Equal(t, "Ignore", webhook.FailurePolicy)
+	assert.Equal(t, "None", webhook.SideEffects)
+	assert.Equal(t, int32(5), webhook.TimeoutSeconds)
+	assert.Equal(t, []AdmissionRule{{Operations: []string{"CREATE", "UPDATE"}, APIGroups: []string{"*"}, APIVersions: []string{"*"}, Resources: []string{"*"}, Scope: "Namespaced"}}, webhook.Rules)
+	assert.Equal(t, &WebhookService{Namespace: "gatekeeper-system", Name: "gatekeeper-webhook-service", Path: "/validate", Port: 8443}, webhook.Service)
+	assert.Equal(t, []byte("ca"), webhook.CABundle)
+
+	mutatingWebhooks, err := k8sAPI.ListMutatingWebhooks()
+	require.NoError(t, err)
+	require.Len(t, mutatingWebhooks, 1)
+	webhook = mutatingWebhooks[0]
+	assert.True(t, webhook.IsMutating())
+	assert.Equal(t, "Fail", webhook.FailurePolicy)
+	assert.Equal(t, "", webhook.SideEffects)
+	assert.Nil(t, webhook.Service)
+	assert.Equal(t, url, webhook.URL)
+	assert.Empty(t, webhook.Rules)
+}
+
+func TestSummarizeWebhookConfiguration(t *testing.T) {
+	webhooks, err := SummarizeWebhookConfiguration(map[string]interface{}{
+		"apiVersion": "admissionregistration.k8s.io/v1",
+		"kind":       "ValidatingWebhookConfiguration",
+		"metadata":   map[string]interface{}{"name": "kyverno"},
+		"webhooks": []interface{}{
+			map[string]interface{}{
+				"name":         "validate.kyverno.svc",
+				"clientConfig": map[string]interface{}{"service": map[string]interface{}{"namespace": "kyverno", "name": "kyverno-svc"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, "kyverno", webhooks[0].ConfigurationName)
+	assert.Equal(t, "", webhooks[0].FailurePolicy)
+	assert.Equal(t, int32(0), webhooks[0].Service.Port)
+	assert.Equal(t, "", webhooks[0].Service.Path)
+
+	_, err = SummarizeWebhookConfiguration(map[string]interface{}{"kind": "Pod"})
+	assert.Error(t, err)
+}
+
+func TestParseValidatingAdmissionPolicy(t *testing.T) {
+	policy := ParseValidatingAdmissionPolicy(map[string]interface{}{
+		"apiVersion": "admissionregistration.k8s.io/v1beta1",
+		"kind":       "ValidatingAdmissionPolicy",
+		"metadata":   map[string]interface{}{"name": "replica-limit"},
+		"spec": map[string]interface{}{
+			"failurePolicy": "Fail",
+			"paramKind":     map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"},
+			"matchConstraints": map[string]interface{}{
+				"resourceRules": []interface{}{
+					map[string]interface{}{"apiGroups": []interface{}{"apps"}, "apiVersions": []interface{}{"v1"}, "operations": []interface{}{"CREATE"}, "resources": []interface{}{"deployments"}},
+					"not a rule",
+				},
+				"namespaceSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"env": "prod"}},
+			},
+			"validations": []interface{}{
+				map[string]interface{}{"expression": "object.spec.replicas <= 5", "reason": "Invalid"},
+			},
+		},
+	})
+	assert.Equal(t, "replica-limit", policy.Name)
+	assert.Equal(t, "admissionregistration.k8s.io/v1beta1", policy.APIVersion)
+	assert.Equal(t, "Fail", policy.FailurePolicy)
+	assert.Equal(t, &ParamKind{APIVersion: "v1", Kind: "ConfigMap"}, policy.ParamKind)
+	assert.Equal(t, []AdmissionRule{{Operations: []string{"CREATE"}, APIGroups: []string{"apps"}, APIVersions: []string{"v1"}, Resources: []string{"deployments"}}}, policy.MatchRules)
+	assert.Empty(t, policy.ExcludeRules)
+	assert.Equal(t, map[string]string{"env": "prod"}, policy.NamespaceSelector.MatchLabels)
+	assert.Nil(t, policy.ObjectSelector)
+	assert.Equal(t, []PolicyValidation{{Expression: "object.spec.replicas <= 5", Reason: "Invalid"}}, policy.Validations)
+
+	// fields with unexpected types are ignored instead of panicking
+	policy = ParseValidatingAdmissionPolicy(map[string]interface{}{"metadata": "invalid", "spec": map[string]interface{}{"validations": "invalid"}})
+	assert.Equal(t, "", policy.Name)
+	assert.Nil(t, policy.ParamKind)
+	assert.Empty(t, policy.Validations)
+}
+
+func TestListValidatingAdmissionPolicies(t *testing.T) {
+	InitializeMapResourcesMock()
+
+	policyGVR := schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1beta1", Resource: "validatingadmissionpolicies"}
+	bindingGVR := schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1beta1", Resource: "validatingadmissionpolicybindings"}
+	k8sAPI := &KubernetesApi{Context: context.Background()}
+
+	if _, ok := GetKindInfo(ValidatingAdmissionPolicyKind); !ok {
+		// the cluster does not serve admission policies
+		policies, err := k8sAPI.ListValidatingAdmissionPolicies()
+		require.NoError(t, err)
+		assert.Empty(t, policies)
+	}
+
+	AddMapResources([]*metav1.APIResourceList{{
+		GroupVersion: "admissionregistration.k8s.io/v1beta1",
+		APIResources: []metav1.APIResource{
+			{Name: "validatingadmissionpolicies", Kind: "ValidatingAdmissionPolicy", Verbs: metav1.Verbs{"list"}},
+			{Name: "validatingadmissionpolicybindings", Kind: "ValidatingAdmissionPolicyBinding", Verbs: metav1.Verbs{"list"}},
+		},
+	}})
+
+	policy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "admissionregistration.k8s.io/v1beta1",
+		"kind":       "ValidatingAdmissionPolicy",
+		"metadata":   map[string]interface{}{"name": "replica-limit"},
+		"spec":       map[string]interface{}{"failurePolicy": "Ignore"},
+	}}
+	binding := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "admissionregistration.k8s.io/v1beta1",
+		"kind":       "ValidatingAdmissionPolicyBinding",
+		"metadata":   map[string]interface{}{"name": "replica-limit-binding"},
+		"spec": map[string]interface{}{
+			"policyName":        "replica-limit",
+			"paramRef":          map[string]interface{}{"name": "limits", "namespace": "default"},
+			"validationActions": []interface{}{"Deny", "Audit"},
+		},
+	}}
+	k8sAPI.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{policyGVR: "ValidatingAdmissionPolicyList", bindingGVR: "ValidatingAdmissionPolicyBindingList"},
+		policy, binding,
+	)
+
+	policies, err := k8sAPI.ListValidatingAdmissionPolicies()
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, "replica-limit", policies[0].Name)
+	assert.Equal(t, "Ignore", policies[0].FailurePolicy)
+
+	bindings, err := k8sAPI.ListValidatingAdmissionPolicyBindings()
+	require.NoError(t, err)
+	require.Len(t, bindings, 1)
+	assert.Equal(t, ValidatingAdmissionPolicyBindingSummary{
+		Name:              "replica-limit-binding",
+		APIVersion:        "admissionregistration.k8s.io/v1beta1",
+		PolicyName:        "replica-limit",
+		ParamRefName:      "limits",
+		ParamRefNamespace: "default",
+		ValidationActions: []string{"Deny", "Audit"},
+	}, bindings[0])
+}
@@ -0,0 +1,90 @@
+package k8sinterface
+
+import (
+	"net/http"
+
+	restclient "k8s.io/client-go/rest"
+)
+
+// ClientIdentity identifies the component in the requests to the API server and to the cloud providers, so cluster admins can attribute the traffic in the audit logs
+type ClientIdentity struct {
+	// Component is the name of the component, e.g. kubescape
+	Component string
+	// Version is the version of the component, optional
+	Version string
+	// Headers are extra headers set on all the requests, optional
+	Headers map[string]string
+}
+
+// UserAgent returns the User-Agent of the identity: <component>/<version>, <component> if the version is not set, empty if the component is not set
+func (identity *ClientIdentity) UserAgent() string {
+	if identity == nil || identity.Component == "" {
+		return ""
+	}
+	if identity.Version == "" {
+		return identity.Component
+	}
+	return identity.Component + "/" + identity.Version
+}
+
+// WrapConfig returns a copy of the config setting the User-Agent and the headers of the identity on the requests
+func (identity *ClientIdentity) WrapConfig(config *restclient.Config) *restclient.Config {
+	if config == nil {
+		return nil
+	}
+	wrapped := restclient.CopyConfig(config)
+	if identity == nil {
+		return wrapped
+	}
+	if userAgent := identity.UserAgent(); userAgent != "" {
+		wrapped.UserAgent = userAgent
+	}
+	if len(identity.Headers) > 0 {
+		wrapped.Wrap(identity.WrapTransport)
+	}
+	return wrapped
+}
+
+// WrapTransport returns a round tripper setting the headers of the identity on the requests, and the User-Agent if the request has none.
+// Used for the HTTP clients that are not built from a rest config
+func (identity *ClientIdentity) WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if identity == nil {
+		return rt
+	}
+	return &identityRoundTripper{next: rt, userAgent: identity.UserAgent(), headers: identity.Headers}
+}
+
+type identityRoundTripper struct {
+	next      http.RoundTripper
+	userAgent string
+	headers   map[string]string
+}
+
+func (rt *identityRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// the round trippers must not modify the request
+	req = req.Clone(req.Context())
+	for key, value := range rt.headers {
+		req.Header.Set(key, value)
+	}
+	if rt.userAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", rt.userAgent)
+	}
+	return rt.next.RoundTrip(req)
+}
+
+// KubernetesApiOption is an option of NewKubernetesApi
+type KubernetesApiOption func(*kubernetesApiOptions)
+
+type kubernetesApiOptions struct {
+	identity *ClientIdentity
+}
+
+// WithClientIdentity sets the User-Agent and the extra headers of the requests of the clients
+func WithClientIdentity(identity ClientIdentity) KubernetesApiOption {
+	return func(options *kubernetesApiOptions) {
+		options.identity = &identity
+	}
+}
@@ -0,0 +1,76 @@
+package k8sinterface
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+func TestClientIdentityUserAgent(t *testing.T) {
+	assert.Equal(t, "kubescape/v3.0.0", (&ClientIdentity{Component: "kubescape", Version: "v3.0.0"}).UserAgent())
+	assert.Equal(t, "kubescape", (&ClientIdentity{Component: "kubescape"}).UserAgent())
+	assert.Equal(t, "", (&ClientIdentity{Version: "v3.0.0"}).UserAgent())
+	var identity *ClientIdentity
+	assert.Equal(t, "", identity.UserAgent())
+}
+
+func TestClientIdentityWrapConfig(t *testing.T) {
+	var userAgent, scanID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		scanID = r.Header.Get("X-Scan-Id")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"kind":"NamespaceList","apiVersion":"v1","items":[]}`))
+	}))
+	defer server.Close()
+
+	identity := &ClientIdentity{Component: "kubescape", Version: "v3.0.0", Headers: map[string]string{"X-Scan-Id": "1234"}}
+	config := &restclient.Config{Host: server.URL}
+	wrapped := identity.WrapConfig(config)
+	assert.Empty(t, config.UserAgent, "the config must not be modified")
+
+	client, err := kubernetes.NewForConfig(wrapped)
+	require.NoError(t, err)
+	_, err = client.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "kubescape/v3.0.0", userAgent)
+	assert.Equal(t, "1234", scanID)
+
+	assert.Nil(t, identity.WrapConfig(nil))
+}
+
+func TestClientIdentityWrapTransport(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+	}))
+	defer server.Close()
+
+	identity := &ClientIdentity{Component: "kubescape", Headers: map[string]string{"X-Scan-Id": "1234"}}
+	client := &http.Client{Transport: identity.WrapTransport(nil)}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "kubescape", headers.Get("User-Agent"))
+	assert.Equal(t, "1234", headers.Get("X-Scan-Id"))
+	assert.Empty(t, req.Header.Get("X-Scan-Id"), "the request must not be modified")
+
+	// the User-Agent of the request is kept
+	req, err = http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "sdk/1.0")
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "sdk/1.0", headers.Get("User-Agent"))
+}
@@ -3,13 +3,17 @@ package k8sinterface
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
+	"sync"
 
-	logger "github.com/kubescape/go-logger"
-	"github.com/kubescape/go-logger/helpers"
+	"github.com/kubescape/k8s-interface/logging"
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/kubescape/k8s-interface/recording"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
@@ -22,6 +26,9 @@ import (
 var connectedToCluster = true
 var clusterContextName = ""
 
+// k8sConfigLock guards the lazy loading of K8SConfig, connectedToCluster and RunningIncluster
+var k8sConfigLock = sync.Mutex{}
+
 // K8SConfig pointer to k8s config
 var K8SConfig *restclient.Config
 
@@ -30,40 +37,100 @@ type KubernetesApi struct {
 	KubernetesClient kubernetes.Interface
 	DynamicClient    dynamic.Interface
 	DiscoveryClient  discovery.DiscoveryInterface
+	MetadataClient   metadata.Interface
 	Context          context.Context
+	// Config is the configuration of the clients, used by the streaming requests (exec, attach, port-forward). GetK8sConfig() if nil
+	Config *restclient.Config
+	// Logger receives the debug summaries of the API requests, the logging package default logger if nil
+	Logger logging.Logger
+
+	// openAPIV3Schemas caches the OpenAPI v3 schemas of the cluster, see ValidateAgainstCluster
+	openAPIV3Schemas *openAPIV3SchemaCache
+}
+
+// GetLogger returns the logger of the KubernetesApi, or the logging package default logger if none was set
+func (k8sAPI *KubernetesApi) GetLogger() logging.Logger {
+	if k8sAPI == nil {
+		return logging.L()
+	}
+	return logging.OrDefault(k8sAPI.Logger)
 }
 
 // NewKubernetesApi -
-func NewKubernetesApi() *KubernetesApi {
+// If the clients cannot be created, e.g. when not connected to a cluster, the error is logged and the process exits. See NewKubernetesApiWithError
+func NewKubernetesApi(opts ...KubernetesApiOption) *KubernetesApi {
+	k8sAPI, err := NewKubernetesApiWithError(opts...)
+	if err != nil {
+		logging.L().Error("failed to create the kubernetes api", err)
+		os.Exit(1)
+	}
+	return k8sAPI
+}
+
+// NewKubernetesApiWithError returns a KubernetesApi over the config of GetK8sConfig, or an error if the clients cannot be created
+func NewKubernetesApiWithError(opts ...KubernetesApiOption) (*KubernetesApi, error) {
 	var kubernetesClient *kubernetes.Clientset
 	var err error
 
-	if !IsConnectedToCluster() {
-		logger.L().Fatal("failed to load kubernetes config: no configuration has been provided, try setting KUBECONFIG environment variable")
+	options := &kubernetesApiOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	// KS_RECORD_DIR records the API server interactions, KS_REPLAY_DIR replays them without a cluster
+	recordingMode, recordingDir := recording.ModeFromEnv()
+	if recordingMode != recording.ModeReplay && !IsConnectedToCluster() {
+		return nil, fmt.Errorf("failed to load kubernetes config: no configuration has been provided, try setting KUBECONFIG environment variable")
+	}
+	k8sConfig := GetK8sConfig()
+	switch recordingMode {
+	case recording.ModeReplay:
+		k8sConfig, err = recording.ReplayConfig(recordingDir)
+	case recording.ModeRecord:
+		k8sConfig, err = recording.WrapConfig(k8sConfig, recording.ModeRecord, recordingDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize the recording mode '%s', reason: %s", recordingMode, err.Error())
 	}
 
-	kubernetesClient, err = kubernetes.NewForConfig(GetK8sConfig())
+	k8sAPI := &KubernetesApi{
+		Context: context.Background(),
+	}
+
+	// requests are recorded by the metrics package recorder, a no-op unless metrics.SetRecorder was called,
+	// and logged at debug level by the logger of the KubernetesApi, resolved per request so it can be set after the clients were created
+	k8sConfig = logging.WrapConfig(metrics.WrapConfig(k8sConfig), k8sAPI.GetLogger)
+	if options.identity != nil {
+		k8sConfig = options.identity.WrapConfig(k8sConfig)
+	}
+
+	kubernetesClient, err = kubernetes.NewForConfig(k8sConfig)
 	if err != nil {
-		logger.L().Fatal("failed to initialize a new kubernetes client", helpers.Error(err))
+		return nil, fmt.Errorf("failed to initialize a new kubernetes client, reason: %s", err.Error())
 	}
-	dynamicClient, err := dynamic.NewForConfig(K8SConfig)
+	dynamicClient, err := dynamic.NewForConfig(k8sConfig)
 	if err != nil {
-		logger.L().Fatal("failed to initialize a new dynamic client", helpers.Error(err))
+		return nil, fmt.Errorf("failed to initialize a new dynamic client, reason: %s", err.Error())
 	}
 
-	discoveryClient, err := discovery.NewDiscoveryClientForConfig(GetK8sConfig())
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(k8sConfig)
 	if err != nil {
-		logger.L().Fatal("failed to initialize a new discovery client", helpers.Error(err))
+		return nil, fmt.Errorf("failed to initialize a new discovery client, reason: %s", err.Error())
+	}
+
+	metadataClient, err := metadata.NewForConfig(k8sConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize a new metadata client, reason: %s", err.Error())
 	}
 	restclient.SetDefaultWarningHandler(restclient.NoWarnings{})
 	InitializeMapResources(discoveryClient)
 
-	return &KubernetesApi{
-		KubernetesClient: kubernetesClient,
-		DynamicClient:    dynamicClient,
-		DiscoveryClient:  discoveryClient,
-		Context:          context.Background(),
-	}
+	k8sAPI.KubernetesClient = kubernetesClient
+	k8sAPI.DynamicClient = dynamicClient
+	k8sAPI.DiscoveryClient = discoveryClient
+	k8sAPI.MetadataClient = metadataClient
+	k8sAPI.Config = k8sConfig
+	return k8sAPI, nil
 }
 
 // RunningIncluster whether running in cluster
@@ -71,6 +138,23 @@ var RunningIncluster bool
 
 // LoadK8sConfig load config from local file or from cluster
 func LoadK8sConfig() error {
+	k8sConfigLock.Lock()
+	defer k8sConfigLock.Unlock()
+	return loadK8sConfig()
+}
+
+// ReloadK8sConfig loads the config again, e.g. after the kubeconfig or the service account token were rotated. The KubernetesApi objects created before keep their config
+func ReloadK8sConfig() error {
+	k8sConfigLock.Lock()
+	defer k8sConfigLock.Unlock()
+	if err := loadK8sConfig(); err != nil {
+		return err
+	}
+	connectedToCluster = true
+	return nil
+}
+
+func loadK8sConfig() error {
 	kubeconfig, err := config.GetConfigWithContext(clusterContextName)
 	if err != nil {
 		return fmt.Errorf("failed to load kubernetes config: %s", strings.ReplaceAll(err.Error(), "KUBERNETES_MASTER", "KUBECONFIG"))
@@ -90,6 +174,8 @@ func GetK8sConfig() *restclient.Config {
 	if !IsConnectedToCluster() {
 		return nil
 	}
+	k8sConfigLock.Lock()
+	defer k8sConfigLock.Unlock()
 	return K8SConfig
 }
 
@@ -107,9 +193,12 @@ func GetCurrentContext() *clientcmdapi.Context {
 	return nil
 }
 
+// IsConnectedToCluster loads the config on the first call, safe for concurrent use
 func IsConnectedToCluster() bool {
+	k8sConfigLock.Lock()
+	defer k8sConfigLock.Unlock()
 	if K8SConfig == nil {
-		if err := LoadK8sConfig(); err != nil {
+		if err := loadK8sConfig(); err != nil {
 			connectedToCluster = false
 		}
 	}
@@ -130,8 +219,10 @@ func GetContextName() string {
 
 // get config from ~/.kube/config
 func GetConfig() *clientcmdapi.Config {
-
-	if !connectedToCluster {
+	k8sConfigLock.Lock()
+	connected := connectedToCluster
+	k8sConfigLock.Unlock()
+	if !connected {
 		return nil
 	}
 
@@ -0,0 +1,29 @@
+package k8sinterface
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewKubernetesApiWithError(t *testing.T) {
+	// not connected to a cluster
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "")
+	k8sConfigLock.Lock()
+	k8sConfig, connected := K8SConfig, connectedToCluster
+	K8SConfig, connectedToCluster = nil, true
+	k8sConfigLock.Unlock()
+	defer func() {
+		k8sConfigLock.Lock()
+		K8SConfig, connectedToCluster = k8sConfig, connected
+		k8sConfigLock.Unlock()
+	}()
+
+	k8sAPI, err := NewKubernetesApiWithError()
+	assert.Error(t, err)
+	assert.Nil(t, k8sAPI)
+}
@@ -0,0 +1,226 @@
+package k8sinterface
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	ControlPlaneAPIServer         = "kube-apiserver"
+	ControlPlaneControllerManager = "kube-controller-manager"
+	ControlPlaneScheduler         = "kube-scheduler"
+	ControlPlaneEtcd              = "etcd"
+
+	controlPlaneNamespace      = "kube-system"
+	controlPlaneComponentLabel = "component"
+	staticPodMirrorAnnotation  = "kubernetes.io/config.mirror"
+)
+
+// ControlPlaneComponents are the control plane components discovered from the static pods
+var ControlPlaneComponents = []string{ControlPlaneAPIServer, ControlPlaneControllerManager, ControlPlaneScheduler, ControlPlaneEtcd}
+
+// ControlPlaneComponent is a control plane component running as a static pod, e.g. on kubeadm clusters
+type ControlPlaneComponent struct {
+	// Component is one of ControlPlaneComponents
+	Component string `json:"component"`
+	Namespace string `json:"namespace"`
+	PodName   string `json:"podName"`
+	NodeName  string `json:"nodeName"`
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	// StaticPod is true for the mirror pods of the static pods of the kubelet
+	StaticPod bool `json:"staticPod"`
+	// Flags are the command line flags of the component, without the leading dashes. A flag without value is "true",
+	// the values of a flag set more than once are joined with commas
+	Flags map[string]string `json:"flags"`
+}
+
+// GetFlag returns the value of the flag, without the leading dashes
+func (component *ControlPlaneComponent) GetFlag(name string) (string, bool) {
+	value, ok := component.Flags[strings.TrimLeft(name, "-")]
+	return value, ok
+}
+
+// GetFlagValues returns the comma separated values of the flag, e.g. the plugins of --enable-admission-plugins. nil if the flag is not set
+func (component *ControlPlaneComponent) GetFlagValues(name string) []string {
+	value, ok := component.GetFlag(name)
+	if !ok {
+		return nil
+	}
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// HasFlagValue returns true if the flag is set with the value, or with a comma separated list containing it
+func (component *ControlPlaneComponent) HasFlagValue(name, value string) bool {
+	for _, v := range component.GetFlagValues(name) {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// GetControlPlaneComponents returns the API server, controller manager, scheduler and etcd static pods of kube-system with their flags.
+// Managed clusters (EKS/AKS/GKE) do not expose their control plane and return no components
+func (k8sAPI *KubernetesApi) GetControlPlaneComponents() ([]ControlPlaneComponent, error) {
+	pods, err := k8sAPI.ListPods(controlPlaneNamespace, nil)
+	if err != nil {
+		return nil, err
+	}
+	components := []ControlPlaneComponent{}
+	for i := range pods.Items {
+		if component := NewControlPlaneComponent(&pods.Items[i]); component != nil {
+			components = append(components, *component)
+		}
+	}
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Component != components[j].Component {
+			return components[i].Component < components[j].Component
+		}
+		return components[i].NodeName < components[j].NodeName
+	})
+	return components, nil
+}
+
+// NewControlPlaneComponent returns the control plane component the pod runs, nil if the pod does not run one.
+// The component is identified by the component label kubeadm sets, or by the name or binary of a container
+func NewControlPlaneComponent(pod *corev1.Pod) *ControlPlaneComponent {
+	componentLabel := pod.GetLabels()[controlPlaneComponentLabel]
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		args := append(append([]string{}, container.Command...), container.Args...)
+		component := ""
+		switch {
+		case isControlPlaneComponent(componentLabel) && (len(pod.Spec.Containers) == 1 || container.Name == componentLabel):
+			component = componentLabel
+		case isControlPlaneComponent(container.Name):
+			component = container.Name
+		case len(args) > 0 && isControlPlaneComponent(path.Base(args[0])):
+			component = path.Base(args[0])
+		default:
+			continue
+		}
+		if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+			args = args[1:]
+		}
+		_, staticPod := pod.GetAnnotations()[staticPodMirrorAnnotation]
+		return &ControlPlaneComponent{
+			Component: component,
+			Namespace: pod.GetNamespace(),
+			PodName:   pod.GetName(),
+			NodeName:  pod.Spec.NodeName,
+			Container: container.Name,
+			Image:     container.Image,
+			StaticPod: staticPod,
+			Flags:     ParseCommandLineFlags(args),
+		}
+	}
+	return nil
+}
+
+// ParseCommandLineFlags returns the flags of the arguments, supporting --flag=value, --flag value and boolean --flag.
+// Positional arguments are ignored
+func ParseCommandLineFlags(args []string) map[string]string {
+	flags := map[string]string{}
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if name == "" {
+			continue
+		}
+		if !hasValue {
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+				value = args[i]
+			} else {
+				value = "true"
+			}
+		}
+		if previous, ok := flags[name]; ok {
+			value = previous + "," + value
+		}
+		flags[name] = value
+	}
+	return flags
+}
+
+func isControlPlaneComponent(name string) bool {
+	for _, component := range ControlPlaneComponents {
+		if name == component {
+			return true
+		}
+	}
+	return false
+}
+
+// ControlPlaneConfig are the hardening relevant settings of the control plane, normalized for the self-managed (see NewControlPlaneConfig)
+// and the managed clusters (see the cloudsupport package). A nil field is a setting the source does not expose
+type ControlPlaneConfig struct {
+	Distribution Distribution `json:"distribution"`
+	// Managed is true for the control planes operated by the cloud provider
+	Managed bool `json:"managed"`
+	// PublicEndpoint is true if the API server is reachable from the internet, AuthorizedNetworks are the CIDRs allowed to reach it, empty for any
+	PublicEndpoint     *bool    `json:"publicEndpoint,omitempty"`
+	PrivateEndpoint    *bool    `json:"privateEndpoint,omitempty"`
+	AuthorizedNetworks []string `json:"authorizedNetworks,omitempty"`
+	AnonymousAuth      *bool    `json:"anonymousAuth,omitempty"`
+	// LocalAccounts is true if static credentials (basic auth, client certificates, token files, AKS local accounts) are enabled
+	LocalAccounts      *bool    `json:"localAccounts,omitempty"`
+	AuthorizationModes []string `json:"authorizationModes,omitempty"`
+	AdmissionPlugins   []string `json:"admissionPlugins,omitempty"`
+	AuditLogging       *bool    `json:"auditLogging,omitempty"`
+	Profiling          *bool    `json:"profiling,omitempty"`
+	// SecretsEncryption is true if the secrets are encrypted at rest beyond the storage encryption, EncryptionKey is the KMS key if known
+	SecretsEncryption *bool  `json:"secretsEncryption,omitempty"`
+	EncryptionKey     string `json:"encryptionKey,omitempty"`
+}
+
+// NewControlPlaneConfig returns the control plane configuration of a self-managed cluster from the flags of its API server,
+// with the defaults of the API server for the unset flags. Returns nil if the components have no API server
+func NewControlPlaneConfig(components []ControlPlaneComponent, distribution Distribution) *ControlPlaneConfig {
+	for i := range components {
+		if components[i].Component != ControlPlaneAPIServer {
+			continue
+		}
+		apiServer := &components[i]
+		_, auditLog := apiServer.GetFlag("audit-log-path")
+		_, auditWebhook := apiServer.GetFlag("audit-webhook-config-file")
+		_, encryption := apiServer.GetFlag("encryption-provider-config")
+		_, basicAuth := apiServer.GetFlag("basic-auth-file")
+		_, tokenAuth := apiServer.GetFlag("token-auth-file")
+		return &ControlPlaneConfig{
+			Distribution:       distribution,
+			AnonymousAuth:      boolFlag(apiServer, "anonymous-auth", true),
+			LocalAccounts:      boolPtr(basicAuth || tokenAuth),
+			AuthorizationModes: apiServer.GetFlagValues("authorization-mode"),
+			AdmissionPlugins:   apiServer.GetFlagValues("enable-admission-plugins"),
+			AuditLogging:       boolPtr(auditLog || auditWebhook),
+			Profiling:          boolFlag(apiServer, "profiling", true),
+			SecretsEncryption:  boolPtr(encryption),
+		}
+	}
+	return nil
+}
+
+func boolFlag(component *ControlPlaneComponent, name string, defaultValue bool) *bool {
+	value, ok := component.GetFlag(name)
+	if !ok {
+		return boolPtr(defaultValue)
+	}
+	return boolPtr(value == "true")
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
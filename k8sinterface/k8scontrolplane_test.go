@@ -0,0 +1,104 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseCommandLineFlags(t *testing.T) {
+	flags := ParseCommandLineFlags([]string{"--authorization-mode=Node,RBAC", "--profiling", "--secure-port", "6443", "-v=2", "--tls-cipher-suites=A", "--tls-cipher-suites=B", "--anonymous-auth=false"})
+	assert.Equal(t, map[string]string{
+		"authorization-mode": "Node,RBAC",
+		"profiling":          "true",
+		"secure-port":        "6443",
+		"v":                  "2",
+		"tls-cipher-suites":  "A,B",
+		"anonymous-auth":     "false",
+	}, flags)
+}
+
+func TestGetControlPlaneComponents(t *testing.T) {
+	staticPod := func(name, component string, command ...string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "kube-system",
+				Name:        name,
+				Labels:      map[string]string{"component": component, "tier": "control-plane"},
+				Annotations: map[string]string{"kubernetes.io/config.mirror": "ced911db"},
+			},
+			Spec: corev1.PodSpec{
+				NodeName:   "master-1",
+				Containers: []corev1.Container{{Name: component, Image: "registry.k8s.io/" + component + ":v1.25.3", Command: command}},
+			},
+		}
+	}
+	etcd := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "etcd-0"},
+		Spec: corev1.PodSpec{NodeName: "master-1", Containers: []corev1.Container{
+			{Name: "server", Command: []string{"/usr/local/bin/etcd"}, Args: []string{"--client-cert-auth=true", "--peer-auto-tls"}},
+		}},
+	}
+	coredns := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "coredns-1", Labels: map[string]string{"k8s-app": "kube-dns"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "coredns", Args: []string{"-conf", "/etc/coredns/Corefile"}}}},
+	}
+	k8sAPI := &KubernetesApi{
+		KubernetesClient: kubernetesfake.NewSimpleClientset(
+			staticPod("kube-apiserver-master-1", ControlPlaneAPIServer, "kube-apiserver", "--enable-admission-plugins=NodeRestriction,PodSecurity", "--profiling=false"),
+			staticPod("kube-scheduler-master-1", ControlPlaneScheduler, "kube-scheduler", "--bind-address=127.0.0.1"),
+			etcd,
+			coredns,
+		),
+		Context: context.Background(),
+	}
+
+	components, err := k8sAPI.GetControlPlaneComponents()
+	require.NoError(t, err)
+	require.Len(t, components, 3)
+
+	etcdComponent := components[0]
+	assert.Equal(t, ControlPlaneEtcd, etcdComponent.Component)
+	assert.False(t, etcdComponent.StaticPod)
+	assert.Equal(t, map[string]string{"client-cert-auth": "true", "peer-auto-tls": "true"}, etcdComponent.Flags)
+
+	apiServer := components[1]
+	assert.Equal(t, ControlPlaneAPIServer, apiServer.Component)
+	assert.True(t, apiServer.StaticPod)
+	assert.Equal(t, "master-1", apiServer.NodeName)
+	assert.True(t, apiServer.HasFlagValue("--enable-admission-plugins", "PodSecurity"))
+	assert.False(t, apiServer.HasFlagValue("enable-admission-plugins", "AlwaysPullImages"))
+	profiling, ok := apiServer.GetFlag("profiling")
+	assert.True(t, ok)
+	assert.Equal(t, "false", profiling)
+	assert.Nil(t, apiServer.GetFlagValues("anonymous-auth"))
+
+	assert.Equal(t, ControlPlaneScheduler, components[2].Component)
+}
+
+func TestNewControlPlaneConfig(t *testing.T) {
+	components := []ControlPlaneComponent{
+		{Component: ControlPlaneEtcd, Flags: map[string]string{"client-cert-auth": "true"}},
+		{Component: ControlPlaneAPIServer, Flags: ParseCommandLineFlags([]string{
+			"--authorization-mode=Node,RBAC", "--enable-admission-plugins=NodeRestriction", "--anonymous-auth=false", "--audit-log-path=/var/log/audit.log",
+		})},
+	}
+	config := NewControlPlaneConfig(components, DistributionKubernetes)
+	require.NotNil(t, config)
+	assert.False(t, config.Managed)
+	assert.False(t, *config.AnonymousAuth)
+	assert.True(t, *config.Profiling, "profiling defaults to true")
+	assert.True(t, *config.AuditLogging)
+	assert.False(t, *config.SecretsEncryption)
+	assert.False(t, *config.LocalAccounts)
+	assert.Equal(t, []string{"Node", "RBAC"}, config.AuthorizationModes)
+	assert.Equal(t, []string{"NodeRestriction"}, config.AdmissionPlugins)
+	assert.Nil(t, config.PublicEndpoint)
+
+	assert.Nil(t, NewControlPlaneConfig(components[:1], DistributionKubernetes))
+}
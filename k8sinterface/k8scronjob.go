@@ -0,0 +1,346 @@
+package k8sinterface
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// maxCronScheduleSearch bounds the search of the next run of a schedule, e.g. "0 0 30 2 *" never runs
+const maxCronScheduleSearch = 5 * 366 * 24 * time.Hour
+
+// JobExecution is a Job of a CronJob and the pods it created
+type JobExecution struct {
+	Job IWorkload
+	// Pods are the pods of the Job, pods of finished Jobs may have been deleted
+	Pods []IWorkload
+	// ScheduledTime is the time the CronJob scheduled the Job at, zero if the Job was created manually (e.g. kubectl create job --from)
+	ScheduledTime time.Time
+	Active        bool
+	Succeeded     bool
+	Failed        bool
+}
+
+// ExpandCronJob returns the Job the CronJob creates on each run, with the labels, annotations and spec of its jobTemplate and
+// the CronJob as controller. The Job is named as the CronJob, see CronJobJobName for the names of the actual Jobs.
+// The pods of the Job are then reached as for any other workload, e.g. with GetPodSpec
+func ExpandCronJob(cronJob IWorkload) (IWorkload, error) {
+	if cronJob.GetKind() != "CronJob" {
+		return nil, fmt.Errorf("kind '%s' is not CronJob", cronJob.GetKind())
+	}
+	jobTemplate, ok := workloadinterface.InspectMap(cronJob.GetObject(), "spec", "jobTemplate")
+	if !ok {
+		return nil, fmt.Errorf("CronJob '%s/%s' has no jobTemplate", cronJob.GetNamespace(), cronJob.GetName())
+	}
+	// the Job must not share the maps of the CronJob
+	data, err := json.Marshal(jobTemplate)
+	if err != nil {
+		return nil, err
+	}
+	template := map[string]interface{}{}
+	if err := json.Unmarshal(data, &template); err != nil {
+		return nil, err
+	}
+
+	metadata, _ := template["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	delete(metadata, "creationTimestamp")
+	metadata["name"] = cronJob.GetName()
+	metadata["namespace"] = cronJob.GetNamespace()
+	ownerReference := map[string]interface{}{
+		"apiVersion":         cronJob.GetApiVersion(),
+		"kind":               cronJob.GetKind(),
+		"name":               cronJob.GetName(),
+		"controller":         true,
+		"blockOwnerDeletion": true,
+	}
+	if uid := cronJob.GetUID(); uid != "" {
+		ownerReference["uid"] = uid
+	}
+	metadata["ownerReferences"] = []interface{}{ownerReference}
+
+	return workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata":   metadata,
+		"spec":       template["spec"],
+	}), nil
+}
+
+// CronJobJobName returns the name of the Job the CronJob controller creates for the scheduled time
+func CronJobJobName(cronJobName string, scheduledTime time.Time) string {
+	return fmt.Sprintf("%s-%d", cronJobName, scheduledTime.Unix()/60)
+}
+
+// GetCronJobExecutions returns the Jobs of the CronJob, the most recent first, with their pods. The CronJob controller keeps the
+// active Jobs and the last successfulJobsHistoryLimit/failedJobsHistoryLimit finished Jobs
+func (k8sAPI *KubernetesApi) GetCronJobExecutions(cronJob IWorkload) ([]JobExecution, error) {
+	jobs, err := k8sAPI.ListWorkloads(&schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, cronJob.GetNamespace(), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	executions := []JobExecution{}
+	for i := range jobs {
+		if !isOwnedBy(jobs[i], cronJob) {
+			continue
+		}
+		execution := newJobExecution(jobs[i], cronJob.GetName())
+		selector, err := jobs[i].GetSelector()
+		if err != nil {
+			return nil, err
+		}
+		podLabels := selector.MatchLabels
+		if len(podLabels) == 0 {
+			podLabels = map[string]string{"job-name": jobs[i].GetName()}
+		}
+		execution.Pods, err = k8sAPI.ListWorkloads(&schema.GroupVersionResource{Version: "v1", Resource: "pods"}, cronJob.GetNamespace(), podLabels, nil)
+		if err != nil {
+			return nil, err
+		}
+		executions = append(executions, execution)
+	}
+	sort.SliceStable(executions, func(i, j int) bool {
+		return creationTimestamp(executions[i].Job).After(creationTimestamp(executions[j].Job))
+	})
+	return executions, nil
+}
+
+func newJobExecution(job IWorkload, cronJobName string) JobExecution {
+	execution := JobExecution{Job: job}
+	if strings.HasPrefix(job.GetName(), cronJobName+"-") {
+		if minutes, err := strconv.ParseInt(strings.TrimPrefix(job.GetName(), cronJobName+"-"), 10, 64); err == nil {
+			execution.ScheduledTime = time.Unix(minutes*60, 0).UTC()
+		}
+	}
+	conditions, _ := workloadinterface.InspectMap(job.GetObject(), "status", "conditions")
+	conditionList, _ := conditions.([]interface{})
+	for i := range conditionList {
+		condition, ok := conditionList[i].(map[string]interface{})
+		if !ok || condition["status"] != "True" {
+			continue
+		}
+		switch condition["type"] {
+		case "Complete":
+			execution.Succeeded = true
+		case "Failed":
+			execution.Failed = true
+		}
+	}
+	execution.Active = !execution.Succeeded && !execution.Failed
+	return execution
+}
+
+// isOwnedBy returns true if the owner is an owner of the object, by UID if the owner has one
+func isOwnedBy(obj IWorkload, owner IWorkload) bool {
+	ownerReferences, err := obj.GetOwnerReferences()
+	if err != nil {
+		return false
+	}
+	for i := range ownerReferences {
+		if ownerReferences[i].Kind != owner.GetKind() || ownerReferences[i].Name != owner.GetName() {
+			continue
+		}
+		if uid := owner.GetUID(); uid == "" || string(ownerReferences[i].UID) == uid {
+			return true
+		}
+	}
+	return false
+}
+
+func creationTimestamp(obj IWorkload) time.Time {
+	value, _ := workloadinterface.InspectMap(obj.GetObject(), "metadata", "creationTimestamp")
+	timestamp, _ := value.(string)
+	t := metav1.Time{}
+	_ = t.UnmarshalQueryParameter(timestamp)
+	return t.Time
+}
+
+// NextCronJobSchedule returns the next time after the given time the CronJob is scheduled at, in the time zone of the CronJob
+// (spec.timeZone or a CRON_TZ=/TZ= prefix of the schedule, UTC otherwise). Returns a zero time if the CronJob is suspended
+func NextCronJobSchedule(cronJob IWorkload, after time.Time) (time.Time, error) {
+	if suspend, _ := workloadinterface.InspectMap(cronJob.GetObject(), "spec", "suspend"); suspend == true {
+		return time.Time{}, nil
+	}
+	value, _ := workloadinterface.InspectMap(cronJob.GetObject(), "spec", "schedule")
+	schedule, _ := value.(string)
+	value, _ = workloadinterface.InspectMap(cronJob.GetObject(), "spec", "timeZone")
+	timeZone, _ := value.(string)
+
+	cronSchedule, err := parseCronSchedule(schedule, timeZone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule of CronJob '%s/%s': %w", cronJob.GetNamespace(), cronJob.GetName(), err)
+	}
+	next, ok := cronSchedule.next(after)
+	if !ok {
+		return time.Time{}, fmt.Errorf("schedule '%s' of CronJob '%s/%s' never runs", schedule, cronJob.GetNamespace(), cronJob.GetName())
+	}
+	return next, nil
+}
+
+// cronSchedule is a standard cron schedule, as supported by the CronJob controller. Each field is a bitmask of the matching values
+type cronSchedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+	// as in cron, if either day field is '*' the day must match both fields, otherwise either field
+	anyDayOfMonth, anyDayOfWeek bool
+	location                    *time.Location
+}
+
+var cronScheduleMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	cronMonthNames     = map[string]int{"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12}
+	cronDayOfWeekNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+func parseCronSchedule(schedule, timeZone string) (*cronSchedule, error) {
+	schedule = strings.TrimSpace(schedule)
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(schedule, prefix) {
+			zone, rest, _ := strings.Cut(strings.TrimPrefix(schedule, prefix), " ")
+			timeZone, schedule = zone, strings.TrimSpace(rest)
+			break
+		}
+	}
+	location := time.UTC
+	if timeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(timeZone); err != nil {
+			return nil, fmt.Errorf("unknown time zone '%s'", timeZone)
+		}
+	}
+	if macro, ok := cronScheduleMacros[strings.ToLower(schedule)]; ok {
+		schedule = macro
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, found %d: '%s'", len(fields), schedule)
+	}
+	cronSchedule := &cronSchedule{location: location}
+	var err error
+	if cronSchedule.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if cronSchedule.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if cronSchedule.dayOfMonth, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if cronSchedule.month, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, err
+	}
+	// 7 is also sunday
+	if cronSchedule.dayOfWeek, err = parseCronField(fields[4], 0, 7, cronDayOfWeekNames); err != nil {
+		return nil, err
+	}
+	if cronSchedule.dayOfWeek&(1<<7) != 0 {
+		cronSchedule.dayOfWeek |= 1
+	}
+	cronSchedule.anyDayOfMonth = fields[2] == "*" || fields[2] == "?"
+	cronSchedule.anyDayOfWeek = fields[4] == "*" || fields[4] == "?"
+	return cronSchedule, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps, e.g. "*/15", "1-5", "MON,WED" or "0-30/10"
+func parseCronField(field string, low, high int, names map[string]int) (uint64, error) {
+	parseValue := func(value string) (int, error) {
+		if n, ok := names[strings.ToLower(value)]; ok {
+			return n, nil
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < low || n > high {
+			return 0, fmt.Errorf("invalid value '%s' of field '%s', expected %d-%d", value, field, low, high)
+		}
+		return n, nil
+	}
+
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step '%s' of field '%s'", stepPart, field)
+			}
+		}
+		start, end := low, high
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			first, last, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = parseValue(first); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(last); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range '%s' of field '%s'", rangePart, field)
+			}
+		default:
+			var err error
+			if start, err = parseValue(rangePart); err != nil {
+				return 0, err
+			}
+			// "5/10" is every 10 from 5 to the last value
+			if !hasStep {
+				end = start
+			}
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+// next returns the first minute after t matching the schedule, false if there is none within maxCronScheduleSearch
+func (s *cronSchedule) next(t time.Time) (time.Time, bool) {
+	t = t.In(s.location)
+	limit := t.Add(maxCronScheduleSearch)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, s.location)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, s.location)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	dayOfMonth := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if s.anyDayOfMonth || s.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
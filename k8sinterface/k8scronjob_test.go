@@ -0,0 +1,183 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newTestCronJob(schedule string) IWorkload {
+	return workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "CronJob",
+		"metadata":   map[string]interface{}{"namespace": "default", "name": "backup", "uid": "1111"},
+		"spec": map[string]interface{}{
+			"schedule": schedule,
+			"jobTemplate": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "backup"}, "creationTimestamp": nil},
+				"spec": map[string]interface{}{
+					"backoffLimit": 2,
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "backup"}},
+						"spec": map[string]interface{}{
+							"restartPolicy": "OnFailure",
+							"containers":    []interface{}{map[string]interface{}{"name": "backup", "image": "backup:1.0"}},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestExpandCronJob(t *testing.T) {
+	cronJob := newTestCronJob("0 2 * * *")
+	job, err := ExpandCronJob(cronJob)
+	require.NoError(t, err)
+	assert.Equal(t, "Job", job.GetKind())
+	assert.Equal(t, "batch/v1", job.GetApiVersion())
+	assert.Equal(t, "default", job.GetNamespace())
+	assert.Equal(t, "backup", job.GetName())
+	assert.Equal(t, map[string]string{"app": "backup"}, job.GetLabels())
+	assert.Equal(t, map[string]string{"app": "backup"}, job.GetPodLabels())
+
+	containers, err := job.GetContainers()
+	require.NoError(t, err)
+	require.Len(t, containers, 1)
+	assert.Equal(t, "backup:1.0", containers[0].Image)
+
+	ownerReferences, err := job.GetOwnerReferences()
+	require.NoError(t, err)
+	require.Len(t, ownerReferences, 1)
+	assert.Equal(t, "CronJob", ownerReferences[0].Kind)
+	assert.Equal(t, "1111", string(ownerReferences[0].UID))
+	assert.True(t, *ownerReferences[0].Controller)
+
+	// the Job does not share the maps of the CronJob
+	job.SetLabel("app", "changed")
+	assert.Equal(t, map[string]string{"app": "backup"}, job.GetPodLabels())
+	jobTemplateLabels, _ := workloadinterface.InspectMap(cronJob.GetObject(), "spec", "jobTemplate", "metadata", "labels", "app")
+	assert.Equal(t, "backup", jobTemplateLabels)
+
+	_, err = ExpandCronJob(newTestPod())
+	assert.Error(t, err)
+}
+
+func TestCronJobJobName(t *testing.T) {
+	assert.Equal(t, "backup-28000000", CronJobJobName("backup", time.Unix(28000000*60, 0)))
+}
+
+func TestNextCronJobSchedule(t *testing.T) {
+	// Wednesday
+	now := time.Date(2023, time.March, 15, 10, 30, 45, 0, time.UTC)
+	tests := []struct {
+		schedule string
+		expected time.Time
+	}{
+		{schedule: "*/15 * * * *", expected: time.Date(2023, time.March, 15, 10, 45, 0, 0, time.UTC)},
+		{schedule: "30 10 * * *", expected: time.Date(2023, time.March, 16, 10, 30, 0, 0, time.UTC)},
+		{schedule: "0 8 * * *", expected: time.Date(2023, time.March, 16, 8, 0, 0, 0, time.UTC)},
+		{schedule: "@hourly", expected: time.Date(2023, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{schedule: "@monthly", expected: time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{schedule: "@weekly", expected: time.Date(2023, time.March, 19, 0, 0, 0, 0, time.UTC)},
+		{schedule: "0 9 * * MON-FRI", expected: time.Date(2023, time.March, 16, 9, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 * * 7", expected: time.Date(2023, time.March, 19, 0, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 1 JAN,jul *", expected: time.Date(2023, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{schedule: "5/20 12 * * *", expected: time.Date(2023, time.March, 15, 12, 5, 0, 0, time.UTC)},
+		// day of month or day of week when both are restricted
+		{schedule: "0 0 20 * 5", expected: time.Date(2023, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 29 2 *", expected: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{schedule: "TZ=UTC 0 12 * * *", expected: time.Date(2023, time.March, 15, 12, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			next, err := NextCronJobSchedule(newTestCronJob(tt.schedule), now)
+			require.NoError(t, err)
+			assert.True(t, tt.expected.Equal(next), "expected %s, got %s", tt.expected, next)
+		})
+	}
+
+	for _, schedule := range []string{"", "* * * *", "60 * * * *", "0 0 * * MONDAY", "*/0 * * * *", "5-1 * * * *", "@every 1h", "CRON_TZ=Nowhere/City * * * * *"} {
+		_, err := NextCronJobSchedule(newTestCronJob(schedule), now)
+		assert.Error(t, err, schedule)
+	}
+	_, err := NextCronJobSchedule(newTestCronJob("0 0 30 2 *"), now)
+	assert.ErrorContains(t, err, "never runs")
+
+	suspended := newTestCronJob("* * * * *")
+	workloadinterface.SetInMap(suspended.GetObject(), []string{"spec"}, "suspend", true)
+	next, err := NextCronJobSchedule(suspended, now)
+	require.NoError(t, err)
+	assert.True(t, next.IsZero())
+}
+
+func TestGetCronJobExecutions(t *testing.T) {
+	cronJob := newTestCronJob("0 2 * * *")
+	newJob := func(name, creationTimestamp, ownerUID string, conditionType string) runtime.Object {
+		job := map[string]interface{}{
+			"apiVersion": "batch/v1",
+			"kind":       "Job",
+			"metadata": map[string]interface{}{
+				"namespace":         "default",
+				"name":              name,
+				"creationTimestamp": creationTimestamp,
+				"ownerReferences":   []interface{}{map[string]interface{}{"apiVersion": "batch/v1", "kind": "CronJob", "name": "backup", "uid": ownerUID}},
+			},
+			"spec": map[string]interface{}{"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"job-name": name}}},
+		}
+		if conditionType != "" {
+			job["status"] = map[string]interface{}{"conditions": []interface{}{map[string]interface{}{"type": conditionType, "status": "True"}}}
+		}
+		return &unstructured.Unstructured{Object: job}
+	}
+	newPod := func(name, jobName string) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]interface{}{"namespace": "default", "name": name, "labels": map[string]interface{}{"job-name": jobName}},
+		}}
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			{Group: "batch", Version: "v1", Resource: "jobs"}: "JobList",
+			{Version: "v1", Resource: "pods"}:                 "PodList",
+		},
+		newJob("backup-27979320", "2023-03-14T02:00:00Z", "1111", "Complete"),
+		newJob("backup-27980760", "2023-03-15T02:00:00Z", "1111", ""),
+		newJob("backup-manual", "2023-03-14T12:00:00Z", "1111", "Failed"),
+		// a previous CronJob of the same name
+		newJob("backup-27977880", "2023-03-13T02:00:00Z", "0000", "Complete"),
+		newPod("backup-27980760-abcde", "backup-27980760"),
+		newPod("backup-manual-fghij", "backup-manual"),
+		newPod("backup-manual-klmno", "backup-manual"),
+	)
+	k8sAPI := &KubernetesApi{DynamicClient: dynamicClient, Context: context.Background()}
+
+	executions, err := k8sAPI.GetCronJobExecutions(cronJob)
+	require.NoError(t, err)
+	require.Len(t, executions, 3)
+
+	assert.Equal(t, "backup-27980760", executions[0].Job.GetName())
+	assert.True(t, executions[0].Active)
+	assert.True(t, time.Date(2023, time.March, 15, 2, 0, 0, 0, time.UTC).Equal(executions[0].ScheduledTime))
+	require.Len(t, executions[0].Pods, 1)
+	assert.Equal(t, "backup-27980760-abcde", executions[0].Pods[0].GetName())
+
+	assert.Equal(t, "backup-manual", executions[1].Job.GetName())
+	assert.True(t, executions[1].Failed)
+	assert.False(t, executions[1].Active)
+	assert.True(t, executions[1].ScheduledTime.IsZero())
+	assert.Len(t, executions[1].Pods, 2)
+
+	assert.Equal(t, "backup-27979320", executions[2].Job.GetName())
+	assert.True(t, executions[2].Succeeded)
+	assert.Empty(t, executions[2].Pods)
+}
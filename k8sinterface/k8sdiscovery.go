@@ -18,7 +18,9 @@ const (
 
 // ResourceGroupMapping mapping of all supported Kubernetes cluster resources to apiVersion
 var resourceGroupMapping = map[string]string{}
-var resourceNamesapcedScope = []string{} // use this to determan if the resource is namespaced
+var resourceNamesapcedScope = []string{}     // use this to determan if the resource is namespaced
+var kindMapping = map[string]KindInfo{}      // lower case kind -> kind info, see GetKindInfo
+var groupKindMapping = map[string]KindInfo{} // lower case group/kind -> kind info, see GetGroupKindInfo
 
 // RW locker to ensure we won't read/write concurrently the map/slice of resources
 var resourcesInfoLock = sync.RWMutex{}
@@ -100,6 +102,14 @@ func InitializeMapResources(discoveryClient discovery.DiscoveryInterface) {
 	InitializeMapResourcesMock()
 
 }
+
+// AddMapResources adds resources to the resource mapping, resources already in the mapping are not overridden.
+// Use it to register resources the discovery did not return, e.g. custom resources of a fake cluster.
+// The mapping is not loaded from discovery once it holds resources, call InitializeMapResources first
+func AddMapResources(resourceList []*metav1.APIResourceList) {
+	setMapResources(resourceList)
+}
+
 func setMapResources(resourceList []*metav1.APIResourceList) {
 	for i := range resourceList {
 		if resourceList[i] == nil {
@@ -123,6 +133,7 @@ func setMapResources(resourceList []*metav1.APIResourceList) {
 			if len(apiResource.Verbs) == 0 {
 				continue
 			}
+			setKindMapping(gv, &apiResource)
 
 			resourcesInfoLock.RLock()
 			_, ok := resourceGroupMapping[apiResource.Name]
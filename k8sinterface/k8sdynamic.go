@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	wlidpkg "github.com/armosec/utils-k8s-go/wlid"
+	"github.com/kubescape/k8s-interface/tracing"
 	"github.com/kubescape/k8s-interface/workloadinterface"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -55,7 +56,9 @@ func (k8sAPI *KubernetesApi) GetWorkload(namespace, kind, name string) (IWorkloa
 		return nil, err
 	}
 
-	w, err := k8sAPI.ResourceInterface(&groupVersionResource, namespace).Get(k8sAPI.Context, name, metav1.GetOptions{})
+	ctx, span := k8sAPI.startSpan("k8s.GetWorkload", &groupVersionResource, namespace, name)
+	w, err := k8sAPI.ResourceInterface(&groupVersionResource, namespace).Get(ctx, name, metav1.GetOptions{})
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to GET resource, kind: '%s', namespace: '%s', name: '%s', reason: %s", kind, namespace, name, err.Error())
 	}
@@ -68,7 +71,9 @@ func (k8sAPI *KubernetesApi) ListWorkloads2(namespace, kind string) ([]IWorkload
 		return nil, err
 	}
 
-	uList, err := k8sAPI.ResourceInterface(&groupVersionResource, namespace).List(k8sAPI.Context, metav1.ListOptions{})
+	ctx, span := k8sAPI.startSpan("k8s.ListWorkloads", &groupVersionResource, namespace, "")
+	uList, err := k8sAPI.ResourceInterface(&groupVersionResource, namespace).List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to LIST resources, reason: %s", err.Error())
 	}
@@ -89,7 +94,9 @@ func (k8sAPI *KubernetesApi) ListWorkloads(groupVersionResource *schema.GroupVer
 		set := labels.Set(fieldSelector)
 		listOptions.FieldSelector = SelectorToString(set)
 	}
-	uList, err := k8sAPI.ResourceInterface(groupVersionResource, namespace).List(k8sAPI.Context, listOptions)
+	ctx, span := k8sAPI.startSpan("k8s.ListWorkloads", groupVersionResource, namespace, "")
+	uList, err := k8sAPI.ResourceInterface(groupVersionResource, namespace).List(ctx, listOptions)
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to LIST resources, reason: %s", err.Error())
 	}
@@ -105,7 +112,9 @@ func (k8sAPI *KubernetesApi) DeleteWorkloadByWlid(wlid string) error {
 	if err != nil {
 		return err
 	}
-	err = k8sAPI.ResourceInterface(&groupVersionResource, wlidpkg.GetNamespaceFromWlid(wlid)).Delete(k8sAPI.Context, wlidpkg.GetNameFromWlid(wlid), metav1.DeleteOptions{})
+	ctx, span := k8sAPI.startSpan("k8s.DeleteWorkload", &groupVersionResource, wlidpkg.GetNamespaceFromWlid(wlid), wlidpkg.GetNameFromWlid(wlid))
+	err = k8sAPI.ResourceInterface(&groupVersionResource, wlidpkg.GetNamespaceFromWlid(wlid)).Delete(ctx, wlidpkg.GetNameFromWlid(wlid), metav1.DeleteOptions{})
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return fmt.Errorf("failed to DELETE resource, workloadID: '%s', reason: %s", wlid, err.Error())
 	}
@@ -121,7 +130,9 @@ func (k8sAPI *KubernetesApi) CreateWorkload(workload IWorkload) (IWorkload, erro
 	if err != nil {
 		return nil, err
 	}
-	w, err := k8sAPI.ResourceInterface(&groupVersionResource, workload.GetNamespace()).Create(k8sAPI.Context, obj, metav1.CreateOptions{})
+	ctx, span := k8sAPI.startSpan("k8s.CreateWorkload", &groupVersionResource, workload.GetNamespace(), workload.GetName())
+	w, err := k8sAPI.ResourceInterface(&groupVersionResource, workload.GetNamespace()).Create(ctx, obj, metav1.CreateOptions{})
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to CREATE resource, workload: '%s', reason: %s", workload.ToString(), err.Error())
 	}
@@ -139,7 +150,9 @@ func (k8sAPI *KubernetesApi) UpdateWorkload(workload IWorkload) (IWorkload, erro
 		return nil, err
 	}
 
-	w, err := k8sAPI.ResourceInterface(&groupVersionResource, workload.GetNamespace()).Update(k8sAPI.Context, obj, metav1.UpdateOptions{})
+	ctx, span := k8sAPI.startSpan("k8s.UpdateWorkload", &groupVersionResource, workload.GetNamespace(), workload.GetName())
+	w, err := k8sAPI.ResourceInterface(&groupVersionResource, workload.GetNamespace()).Update(ctx, obj, metav1.UpdateOptions{})
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to UPDATE resource, workload: '%s', reason: %s", workload.ToString(), err.Error())
 	}
@@ -151,7 +164,9 @@ func (k8sAPI *KubernetesApi) GetNamespace(ns string) (IWorkload, error) {
 	if err != nil {
 		return nil, err
 	}
-	w, err := k8sAPI.DynamicClient.Resource(groupVersionResource).Get(k8sAPI.Context, ns, metav1.GetOptions{})
+	ctx, span := k8sAPI.startSpan("k8s.GetNamespace", &groupVersionResource, "", ns)
+	w, err := k8sAPI.DynamicClient.Resource(groupVersionResource).Get(ctx, ns, metav1.GetOptions{})
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get namespace: '%s', reason: %s", ns, err.Error())
 	}
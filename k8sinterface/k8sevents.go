@@ -0,0 +1,228 @@
+package k8sinterface
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Reasons of the events relevant to the runtime state of the workloads
+const (
+	EventReasonBackOff          = "BackOff"
+	EventReasonFailedMount      = "FailedMount"
+	EventReasonFailedScheduling = "FailedScheduling"
+	EventReasonOOMKilling       = "OOMKilling" // reported on the node by the kubelet
+	EventReasonEvicted          = "Evicted"
+	EventReasonUnhealthy        = "Unhealthy"
+)
+
+// Event is an event of core/v1 or events.k8s.io/v1, normalized
+type Event struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Type is Normal or Warning
+	Type    string `json:"type"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	// Source is the reporting controller (e.g. kubelet), and the host for the kubelet events of core/v1
+	Source string `json:"source,omitempty"`
+	Host   string `json:"host,omitempty"`
+	// Count is the number of occurrences of the event, 1 if it occurred once
+	Count          int32                  `json:"count"`
+	FirstTimestamp time.Time              `json:"firstTimestamp"`
+	LastTimestamp  time.Time              `json:"lastTimestamp"`
+	InvolvedObject corev1.ObjectReference `json:"involvedObject"`
+}
+
+// IsWarning returns true for the Warning events
+func (event *Event) IsWarning() bool {
+	return event.Type == corev1.EventTypeWarning
+}
+
+// NewEventFromCoreV1 returns the event of a core/v1 event
+func NewEventFromCoreV1(coreEvent *corev1.Event) Event {
+	event := Event{
+		Namespace:      coreEvent.Namespace,
+		Name:           coreEvent.Name,
+		Type:           coreEvent.Type,
+		Reason:         coreEvent.Reason,
+		Message:        coreEvent.Message,
+		Source:         coreEvent.ReportingController,
+		Host:           coreEvent.Source.Host,
+		Count:          coreEvent.Count,
+		FirstTimestamp: coreEvent.FirstTimestamp.Time,
+		LastTimestamp:  coreEvent.LastTimestamp.Time,
+		InvolvedObject: coreEvent.InvolvedObject,
+	}
+	if event.Source == "" {
+		event.Source = coreEvent.Source.Component
+	}
+	if coreEvent.Series != nil {
+		event.Count = coreEvent.Series.Count
+		event.LastTimestamp = coreEvent.Series.LastObservedTime.Time
+	}
+	setEventTimestamps(&event, coreEvent.EventTime.Time, coreEvent.CreationTimestamp.Time)
+	return event
+}
+
+// NewEventFromEventsV1 returns the event of an events.k8s.io/v1 event
+func NewEventFromEventsV1(eventsEvent *eventsv1.Event) Event {
+	event := Event{
+		Namespace:      eventsEvent.Namespace,
+		Name:           eventsEvent.Name,
+		Type:           eventsEvent.Type,
+		Reason:         eventsEvent.Reason,
+		Message:        eventsEvent.Note,
+		Source:         eventsEvent.ReportingController,
+		Host:           eventsEvent.DeprecatedSource.Host,
+		Count:          eventsEvent.DeprecatedCount,
+		FirstTimestamp: eventsEvent.DeprecatedFirstTimestamp.Time,
+		LastTimestamp:  eventsEvent.DeprecatedLastTimestamp.Time,
+		InvolvedObject: eventsEvent.Regarding,
+	}
+	if eventsEvent.Series != nil {
+		event.Count = eventsEvent.Series.Count
+		event.LastTimestamp = eventsEvent.Series.LastObservedTime.Time
+	}
+	setEventTimestamps(&event, eventsEvent.EventTime.Time, eventsEvent.CreationTimestamp.Time)
+	return event
+}
+
+// setEventTimestamps fills the missing timestamps with the event time, or the creation of the event
+func setEventTimestamps(event *Event, eventTime, creationTime time.Time) {
+	if eventTime.IsZero() {
+		eventTime = creationTime
+	}
+	if event.FirstTimestamp.IsZero() {
+		event.FirstTimestamp = eventTime
+	}
+	if event.LastTimestamp.IsZero() {
+		event.LastTimestamp = event.FirstTimestamp
+	}
+	if event.Count == 0 {
+		event.Count = 1
+	}
+}
+
+// EventsSnapshot are events indexed by their involved object
+type EventsSnapshot struct {
+	Events   []Event
+	byUID    map[string][]int
+	byObject map[string][]int
+}
+
+// NewEventsSnapshot indexes the events by the UID and the kind/namespace/name of their involved object. The events are copied, the slice is not modified
+func NewEventsSnapshot(events []Event) *EventsSnapshot {
+	events = append(make([]Event, 0, len(events)), events...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].LastTimestamp.Before(events[j].LastTimestamp) })
+	snapshot := &EventsSnapshot{Events: events, byUID: map[string][]int{}, byObject: map[string][]int{}}
+	for i := range events {
+		involvedObject := &events[i].InvolvedObject
+		if involvedObject.UID != "" {
+			snapshot.byUID[string(involvedObject.UID)] = append(snapshot.byUID[string(involvedObject.UID)], i)
+		}
+		key := eventObjectKey(involvedObject.Kind, involvedObject.Namespace, involvedObject.Name)
+		snapshot.byObject[key] = append(snapshot.byObject[key], i)
+	}
+	return snapshot
+}
+
+// GetEventsForObject returns the events of the object that last occurred at or after since (all if zero), oldest first.
+// The events are matched by UID if the object has one, so the events of a previous object of the same name are excluded
+func (snapshot *EventsSnapshot) GetEventsForObject(obj workloadinterface.IMetadata, since time.Time) []Event {
+	indexes := snapshot.byObject[eventObjectKey(obj.GetKind(), obj.GetNamespace(), obj.GetName())]
+	uid := objectUID(obj)
+	if uid != "" {
+		indexes = snapshot.byUID[uid]
+	}
+	events := []Event{}
+	for _, i := range indexes {
+		if since.IsZero() || !snapshot.Events[i].LastTimestamp.Before(since) {
+			events = append(events, snapshot.Events[i])
+		}
+	}
+	return events
+}
+
+// GetEventsSnapshot lists the events of the namespace (all namespaces if empty) from events.k8s.io/v1, or from core/v1 if the cluster
+// does not serve it, and indexes them by their involved object
+func (k8sAPI *KubernetesApi) GetEventsSnapshot(namespace string) (*EventsSnapshot, error) {
+	events, err := k8sAPI.listEvents(namespace, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return NewEventsSnapshot(events), nil
+}
+
+// GetEventsForObject returns the events of the object that last occurred at or after since (all if zero), oldest first
+func (k8sAPI *KubernetesApi) GetEventsForObject(obj workloadinterface.IMetadata, since time.Time) ([]Event, error) {
+	eventsFields := fields.Set{"regarding.kind": obj.GetKind(), "regarding.name": obj.GetName()}
+	coreFields := fields.Set{"involvedObject.kind": obj.GetKind(), "involvedObject.name": obj.GetName()}
+	if uid := objectUID(obj); uid != "" {
+		eventsFields["regarding.uid"] = uid
+		coreFields["involvedObject.uid"] = uid
+	}
+	events, err := k8sAPI.listEvents(obj.GetNamespace(), eventsFields.String(), coreFields.String())
+	if err != nil {
+		return nil, err
+	}
+	return NewEventsSnapshot(events).GetEventsForObject(obj, since), nil
+}
+
+// FilterEventsByReason returns the events of the reasons
+func FilterEventsByReason(events []Event, reasons ...string) []Event {
+	filtered := []Event{}
+	for i := range events {
+		if contains(reasons, events[i].Reason) {
+			filtered = append(filtered, events[i])
+		}
+	}
+	return filtered
+}
+
+func (k8sAPI *KubernetesApi) listEvents(namespace, eventsFieldSelector, coreFieldSelector string) ([]Event, error) {
+	ctx, span := k8sAPI.startSpan("k8s.ListEvents", &schema.GroupVersionResource{Group: eventsv1.GroupName, Version: "v1", Resource: "events"}, namespace, "")
+	eventsList, err := k8sAPI.KubernetesClient.EventsV1().Events(namespace).List(ctx, metav1.ListOptions{FieldSelector: eventsFieldSelector})
+	tracing.EndSpan(span, err)
+	if err == nil {
+		events := make([]Event, 0, len(eventsList.Items))
+		for i := range eventsList.Items {
+			events = append(events, NewEventFromEventsV1(&eventsList.Items[i]))
+		}
+		return events, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	ctx, span = k8sAPI.startSpan("k8s.ListEvents", &schema.GroupVersionResource{Version: "v1", Resource: "events"}, namespace, "")
+	coreList, err := k8sAPI.KubernetesClient.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{FieldSelector: coreFieldSelector})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]Event, 0, len(coreList.Items))
+	for i := range coreList.Items {
+		events = append(events, NewEventFromCoreV1(&coreList.Items[i]))
+	}
+	return events, nil
+}
+
+func eventObjectKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+func objectUID(obj workloadinterface.IMetadata) string {
+	if withUID, ok := obj.(interface{ GetUID() string }); ok {
+		return withUID.GetUID()
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestGetEventsForObject(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	nginx := corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "nginx", UID: "1111"}
+	previousNginx := corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "nginx", UID: "0000"}
+	client := kubernetesfake.NewSimpleClientset(
+		&eventsv1.Event{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nginx.1"}, Regarding: nginx, Type: corev1.EventTypeWarning, Reason: EventReasonBackOff,
+			Note: "Back-off restarting failed container", ReportingController: "kubelet", Series: &eventsv1.EventSeries{Count: 5, LastObservedTime: metav1.NewMicroTime(now)},
+			EventTime: metav1.NewMicroTime(now.Add(-time.Hour))},
+		&eventsv1.Event{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nginx.2"}, Regarding: nginx, Type: corev1.EventTypeNormal, Reason: "Pulled",
+			EventTime: metav1.NewMicroTime(now.Add(-2 * time.Hour))},
+		&eventsv1.Event{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "nginx.3"}, Regarding: previousNginx, Type: corev1.EventTypeWarning, Reason: EventReasonFailedMount,
+			EventTime: metav1.NewMicroTime(now)},
+	)
+	k8sAPI := &KubernetesApi{KubernetesClient: client, Context: context.Background()}
+
+	pod := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"namespace": "default", "name": "nginx", "uid": "1111"},
+	})
+	events, err := k8sAPI.GetEventsForObject(pod, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "Pulled", events[0].Reason)
+	assert.Equal(t, int32(1), events[0].Count)
+	assert.Equal(t, EventReasonBackOff, events[1].Reason)
+	assert.Equal(t, "Back-off restarting failed container", events[1].Message)
+	assert.Equal(t, "kubelet", events[1].Source)
+	assert.Equal(t, int32(5), events[1].Count)
+	assert.Equal(t, now, events[1].LastTimestamp)
+	assert.Equal(t, now.Add(-time.Hour), events[1].FirstTimestamp)
+	assert.True(t, events[1].IsWarning())
+
+	events, err = k8sAPI.GetEventsForObject(pod, now.Add(-time.Minute))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventReasonBackOff, events[0].Reason)
+
+	snapshot, err := k8sAPI.GetEventsSnapshot("")
+	require.NoError(t, err)
+	assert.Len(t, snapshot.Events, 3)
+	assert.Len(t, snapshot.GetEventsForObject(pod, time.Time{}), 2)
+	// without UID, all the events of the pods named nginx
+	pod.SetObject(map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"namespace": "default", "name": "nginx"}})
+	assert.Len(t, snapshot.GetEventsForObject(pod, time.Time{}), 3)
+	assert.Len(t, FilterEventsByReason(snapshot.Events, EventReasonFailedMount, EventReasonBackOff), 2)
+}
+
+func TestNewEventsSnapshot(t *testing.T) {
+	now := time.Now()
+	events := []Event{{Name: "nginx.2", LastTimestamp: now}, {Name: "nginx.1", LastTimestamp: now.Add(-time.Hour)}}
+	snapshot := NewEventsSnapshot(events)
+	assert.Equal(t, "nginx.1", snapshot.Events[0].Name)
+	// the slice of the caller is not sorted
+	assert.Equal(t, "nginx.2", events[0].Name)
+}
+
+func TestGetEventsSnapshotCoreV1(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	node := corev1.ObjectReference{Kind: "Node", Name: "node-1"}
+	client := kubernetesfake.NewSimpleClientset(
+		&corev1.Event{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "node-1.1"}, InvolvedObject: node, Type: corev1.EventTypeWarning, Reason: EventReasonOOMKilling,
+			Source: corev1.EventSource{Component: "kubelet", Host: "node-1"}, Count: 2, FirstTimestamp: metav1.NewTime(now.Add(-time.Minute)), LastTimestamp: metav1.NewTime(now)},
+	)
+	client.PrependReactor("list", "events", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetResource().Group == eventsv1.GroupName {
+			return true, nil, apierrors.NewNotFound(schema.GroupResource{Group: eventsv1.GroupName, Resource: "events"}, "")
+		}
+		return false, nil, nil
+	})
+	k8sAPI := &KubernetesApi{KubernetesClient: client, Context: context.Background()}
+
+	snapshot, err := k8sAPI.GetEventsSnapshot("")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Events, 1)
+	assert.Equal(t, Event{
+		Namespace:      "default",
+		Name:           "node-1.1",
+		Type:           corev1.EventTypeWarning,
+		Reason:         EventReasonOOMKilling,
+		Source:         "kubelet",
+		Host:           "node-1",
+		Count:          2,
+		FirstTimestamp: now.Add(-time.Minute),
+		LastTimestamp:  now,
+		InvolvedObject: node,
+	}, snapshot.Events[0])
+
+	nodeObject := workloadinterface.NewWorkloadObj(map[string]interface{}{"apiVersion": "v1", "kind": "Node", "metadata": map[string]interface{}{"name": "node-1"}})
+	assert.Len(t, snapshot.GetEventsForObject(nodeObject, time.Time{}), 1)
+}
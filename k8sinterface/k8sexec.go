@@ -0,0 +1,321 @@
+package k8sinterface
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes/scheme"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+	"k8s.io/client-go/util/exec"
+)
+
+// the streaming requests are upgraded to SPDY, the WebSocket executor is not available in this version of client-go.
+// Overridden by the tests
+var (
+	newExecutor = remotecommand.NewSPDYExecutorForTransports
+	newDialer   = func(config *restclient.Config, method string, url *url.URL) (httpstream.Dialer, error) {
+		transport, upgrader, err := spdy.RoundTripperFor(config)
+		if err != nil {
+			return nil, err
+		}
+		return spdy.NewDialer(upgrader, &http.Client{Transport: transport}, method, url), nil
+	}
+)
+
+// ExecOptions configures ExecInPod and AttachToPod
+type ExecOptions struct {
+	// Stdin is sent to the command, no stdin if nil
+	Stdin io.Reader
+	// Stdout and Stderr receive the output of the command as it is written, instead of ExecResult
+	Stdout io.Writer
+	Stderr io.Writer
+	// Tty allocates a terminal, the stderr of the command is then written to stdout
+	Tty bool
+}
+
+// ExecResult is the result of a command run by ExecInPod
+type ExecResult struct {
+	// Stdout and Stderr are the output of the command, nil if it was written to ExecOptions.Stdout and ExecOptions.Stderr
+	Stdout []byte
+	Stderr []byte
+	// ExitCode is the exit code of the command, a non-zero exit code is not an error
+	ExitCode int
+}
+
+// ExecInPod runs the command in the container of the pod, the only container of the pod if empty, and returns its output.
+// The command is not run in a shell. Canceling ctx returns immediately and closes the connection, the output is no longer written
+// to ExecOptions.Stdout and ExecOptions.Stderr
+func (k8sAPI *KubernetesApi) ExecInPod(ctx context.Context, pod workloadinterface.IMetadata, container string, command []string, opts *ExecOptions) (*ExecResult, error) {
+	if opts == nil {
+		opts = &ExecOptions{}
+	}
+	execOptions := &corev1.PodExecOptions{
+		Container: container,
+		Command:   command,
+		Stdin:     opts.Stdin != nil,
+		Stdout:    true,
+		Stderr:    !opts.Tty,
+		TTY:       opts.Tty,
+	}
+	ctx, span := startSpan(ctx, "k8s.ExecInPod", &schema.GroupVersionResource{Version: "v1", Resource: "pods"}, pod.GetNamespace(), pod.GetName())
+	result, err := k8sAPI.stream(ctx, pod, "exec", execOptions, opts)
+	tracing.EndSpan(span, err)
+	return result, err
+}
+
+// AttachToPod attaches to the running process of the container of the pod until it ends or ctx is canceled
+func (k8sAPI *KubernetesApi) AttachToPod(ctx context.Context, pod workloadinterface.IMetadata, container string, opts *ExecOptions) (*ExecResult, error) {
+	if opts == nil {
+		opts = &ExecOptions{}
+	}
+	attachOptions := &corev1.PodAttachOptions{
+		Container: container,
+		Stdin:     opts.Stdin != nil,
+		Stdout:    true,
+		Stderr:    !opts.Tty,
+		TTY:       opts.Tty,
+	}
+	ctx, span := startSpan(ctx, "k8s.AttachToPod", &schema.GroupVersionResource{Version: "v1", Resource: "pods"}, pod.GetNamespace(), pod.GetName())
+	result, err := k8sAPI.stream(ctx, pod, "attach", attachOptions, opts)
+	tracing.EndSpan(span, err)
+	return result, err
+}
+
+func (k8sAPI *KubernetesApi) stream(ctx context.Context, pod workloadinterface.IMetadata, subresource string, podOptions runtime.Object, opts *ExecOptions) (*ExecResult, error) {
+	config := k8sAPI.getConfig()
+	if config == nil {
+		return nil, fmt.Errorf("failed to %s in pod '%s/%s', reason: no kubernetes config", subresource, pod.GetNamespace(), pod.GetName())
+	}
+	streamURL, err := podSubresourceURL(config, pod, subresource, podOptions)
+	if err != nil {
+		return nil, err
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s in pod '%s/%s', reason: %s", subresource, pod.GetNamespace(), pod.GetName(), err.Error())
+	}
+	executor, err := newExecutor(transport, &contextUpgrader{ctx: ctx, upgrader: upgrader}, http.MethodPost, streamURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to %s in pod '%s/%s', reason: %s", subresource, pod.GetNamespace(), pod.GetName(), err.Error())
+	}
+
+	result := &ExecResult{}
+	stdout, stderr := opts.Stdout, opts.Stderr
+	var stdoutBuffer, stderrBuffer *bytes.Buffer
+	if stdout == nil {
+		stdoutBuffer = &bytes.Buffer{}
+		stdout = stdoutBuffer
+	}
+	if stderr == nil && !opts.Tty {
+		stderrBuffer = &bytes.Buffer{}
+		stderr = stderrBuffer
+	}
+	if opts.Tty {
+		stderr = nil
+	}
+
+	// the executor of this version of client-go does not support cancellation: the connection is closed by the upgrader when ctx is
+	// canceled, which ends the stream, and the writers are closed so the executor does not write to them after stream returns
+	stdoutWriter := &closableWriter{writer: stdout}
+	streamOptions := remotecommand.StreamOptions{Stdin: opts.Stdin, Stdout: stdoutWriter, Tty: opts.Tty}
+	var stderrWriter *closableWriter
+	if stderr != nil {
+		stderrWriter = &closableWriter{writer: stderr}
+		streamOptions.Stderr = stderrWriter
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- executor.Stream(streamOptions)
+	}()
+	select {
+	case <-ctx.Done():
+		stdoutWriter.Close()
+		if stderrWriter != nil {
+			stderrWriter.Close()
+		}
+		return nil, ctx.Err()
+	case err = <-done:
+	}
+
+	if stdoutBuffer != nil {
+		result.Stdout = stdoutBuffer.Bytes()
+	}
+	if stderrBuffer != nil {
+		result.Stderr = stderrBuffer.Bytes()
+	}
+	var exitError exec.CodeExitError
+	if errors.As(err, &exitError) {
+		result.ExitCode = exitError.ExitStatus()
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to %s in pod '%s/%s', reason: %s", subresource, pod.GetNamespace(), pod.GetName(), err.Error())
+	}
+	return result, nil
+}
+
+// contextUpgrader closes the upgraded connections when the context is done
+type contextUpgrader struct {
+	ctx      context.Context
+	upgrader spdy.Upgrader
+}
+
+func (u *contextUpgrader) NewConnection(resp *http.Response) (httpstream.Connection, error) {
+	conn, err := u.upgrader.NewConnection(resp)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		select {
+		case <-u.ctx.Done():
+			conn.Close()
+		case <-conn.CloseChan():
+		}
+	}()
+	return conn, nil
+}
+
+// closableWriter is a writer that fails once closed. Close waits for the write in progress, no write reaches the writer after it returns
+type closableWriter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	closed bool
+}
+
+func (w *closableWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.closed {
+		return 0, io.ErrClosedPipe
+	}
+	return w.writer.Write(p)
+}
+
+func (w *closableWriter) Close() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.closed = true
+}
+
+// PortForwardSession forwards local ports to the ports of a pod until it is closed
+type PortForwardSession struct {
+	// Ports are the forwarded ports, with the local ports allocated for the ports requested as 0 or without local port
+	Ports    []portforward.ForwardedPort
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+	err      error
+}
+
+// Close stops forwarding the ports, it can be called several times and concurrently
+func (session *PortForwardSession) Close() {
+	session.stopOnce.Do(func() { close(session.stop) })
+	<-session.done
+}
+
+// Done is closed when the ports are no longer forwarded, Err then returns the error that stopped the forwarding, if any
+func (session *PortForwardSession) Done() <-chan struct{} {
+	return session.done
+}
+
+func (session *PortForwardSession) Err() error {
+	<-session.done
+	return session.err
+}
+
+// PortForward forwards the local ports to the ports of the pod until ctx is canceled or the session is closed. The ports are
+// [local:]remote, e.g. 8080:80, :80 or 80 for a random local port. It returns once the local ports listen on localhost
+func (k8sAPI *KubernetesApi) PortForward(ctx context.Context, pod workloadinterface.IMetadata, ports []string) (*PortForwardSession, error) {
+	ctx, span := startSpan(ctx, "k8s.PortForward", &schema.GroupVersionResource{Version: "v1", Resource: "pods"}, pod.GetNamespace(), pod.GetName())
+	session, err := k8sAPI.portForward(ctx, pod, ports)
+	tracing.EndSpan(span, err)
+	return session, err
+}
+
+func (k8sAPI *KubernetesApi) portForward(ctx context.Context, pod workloadinterface.IMetadata, ports []string) (*PortForwardSession, error) {
+	config := k8sAPI.getConfig()
+	if config == nil {
+		return nil, fmt.Errorf("failed to port-forward to pod '%s/%s', reason: no kubernetes config", pod.GetNamespace(), pod.GetName())
+	}
+	portForwardURL, err := podSubresourceURL(config, pod, "portforward", nil)
+	if err != nil {
+		return nil, err
+	}
+	dialer, err := newDialer(config, http.MethodPost, portForwardURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to port-forward to pod '%s/%s', reason: %s", pod.GetNamespace(), pod.GetName(), err.Error())
+	}
+
+	session := &PortForwardSession{stop: make(chan struct{}), done: make(chan struct{})}
+	ready := make(chan struct{})
+	forwarder, err := portforward.New(dialer, ports, session.stop, ready, io.Discard, io.Discard)
+	if err != nil {
+		return nil, fmt.Errorf("failed to port-forward to pod '%s/%s', reason: %s", pod.GetNamespace(), pod.GetName(), err.Error())
+	}
+	go func() {
+		defer close(session.done)
+		session.err = forwarder.ForwardPorts()
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			session.Close()
+		case <-session.done:
+		}
+	}()
+
+	select {
+	case <-ready:
+	case <-session.done:
+		return nil, fmt.Errorf("failed to port-forward to pod '%s/%s', reason: %v", pod.GetNamespace(), pod.GetName(), session.err)
+	}
+	session.Ports, err = forwarder.GetPorts()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+	return session, nil
+}
+
+func (k8sAPI *KubernetesApi) getConfig() *restclient.Config {
+	if k8sAPI.Config != nil {
+		return k8sAPI.Config
+	}
+	return GetK8sConfig()
+}
+
+// podSubresourceURL returns the URL of the subresource of the pod with the options as query parameters
+func podSubresourceURL(config *restclient.Config, pod workloadinterface.IMetadata, subresource string, podOptions runtime.Object) (*url.URL, error) {
+	baseURL, versionedAPIPath, err := restclient.DefaultServerURL(config.Host, config.APIPath, corev1.SchemeGroupVersion, restclient.IsConfigTransportTLS(*config))
+	if err != nil {
+		return nil, err
+	}
+	if config.APIPath == "" {
+		versionedAPIPath = restclient.DefaultVersionedAPIPath("/api", corev1.SchemeGroupVersion)
+	}
+	baseURL.Path = path.Join(baseURL.Path, versionedAPIPath, "namespaces", pod.GetNamespace(), "pods", pod.GetName(), subresource)
+	if podOptions != nil {
+		query, err := scheme.ParameterCodec.EncodeParameters(podOptions, corev1.SchemeGroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		baseURL.RawQuery = query.Encode()
+	}
+	return baseURL, nil
+}
@@ -0,0 +1,266 @@
+package k8sinterface
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	spdystream "k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	remotecommandconsts "k8s.io/apimachinery/pkg/util/remotecommand"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+	"k8s.io/client-go/util/exec"
+)
+
+type fakeExecutor struct {
+	stdout, stderr string
+	err            error
+	block          chan struct{}
+	// streamed is closed when Stream returns, if set
+	streamed chan struct{}
+}
+
+func (executor *fakeExecutor) Stream(options remotecommand.StreamOptions) error {
+	if executor.streamed != nil {
+		defer close(executor.streamed)
+	}
+	if executor.block != nil {
+		<-executor.block
+	}
+	if options.Stdin != nil {
+		stdin, _ := io.ReadAll(options.Stdin)
+		_, _ = options.Stdout.Write(stdin)
+	}
+	_, _ = io.WriteString(options.Stdout, executor.stdout)
+	if options.Stderr != nil {
+		_, _ = io.WriteString(options.Stderr, executor.stderr)
+	}
+	return executor.err
+}
+
+func withFakeExecutor(t *testing.T, executor remotecommand.Executor) *url.URL {
+	streamURL := &url.URL{}
+	newExecutorOrig := newExecutor
+	newExecutor = func(transport http.RoundTripper, upgrader spdy.Upgrader, method string, u *url.URL) (remotecommand.Executor, error) {
+		*streamURL = *u
+		return executor, nil
+	}
+	t.Cleanup(func() { newExecutor = newExecutorOrig })
+	return streamURL
+}
+
+func newTestPod() IWorkload {
+	return workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"namespace": "default", "name": "nginx"},
+	})
+}
+
+func TestExecInPod(t *testing.T) {
+	k8sAPI := &KubernetesApi{Config: &restclient.Config{Host: "https://10.0.0.1:6443"}, Context: context.Background()}
+	streamURL := withFakeExecutor(t, &fakeExecutor{stdout: "root\n", stderr: "warning\n"})
+
+	result, err := k8sAPI.ExecInPod(context.Background(), newTestPod(), "nginx", []string{"cat", "/etc/passwd"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "root\n", string(result.Stdout))
+	assert.Equal(t, "warning\n", string(result.Stderr))
+	assert.Equal(t, 0, result.ExitCode)
+	assert.Equal(t, "https", streamURL.Scheme)
+	assert.Equal(t, "10.0.0.1:6443", streamURL.Host)
+	assert.Equal(t, "/api/v1/namespaces/default/pods/nginx/exec", streamURL.Path)
+	assert.Equal(t, url.Values{"container": {"nginx"}, "command": {"cat", "/etc/passwd"}, "stdout": {"true"}, "stderr": {"true"}}, streamURL.Query())
+
+	// stdin and output writers, tty
+	stdout := &strings.Builder{}
+	result, err = k8sAPI.ExecInPod(context.Background(), newTestPod(), "", []string{"sh"}, &ExecOptions{Stdin: strings.NewReader("id\n"), Stdout: stdout, Tty: true})
+	require.NoError(t, err)
+	assert.Nil(t, result.Stdout)
+	assert.Nil(t, result.Stderr)
+	assert.Equal(t, "id\nroot\n", stdout.String())
+	assert.Equal(t, url.Values{"command": {"sh"}, "stdin": {"true"}, "stdout": {"true"}, "tty": {"true"}}, streamURL.Query())
+}
+
+func TestExecInPodErrors(t *testing.T) {
+	k8sAPI := &KubernetesApi{Config: &restclient.Config{Host: "10.0.0.1:6443", APIPath: "/api"}, Context: context.Background()}
+
+	withFakeExecutor(t, &fakeExecutor{stderr: "No such file or directory", err: exec.CodeExitError{Err: errors.New("command terminated with exit code 1"), Code: 1}})
+	result, err := k8sAPI.ExecInPod(context.Background(), newTestPod(), "", []string{"cat", "/missing"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.ExitCode)
+	assert.Equal(t, "No such file or directory", string(result.Stderr))
+
+	withFakeExecutor(t, &fakeExecutor{err: errors.New("container not found")})
+	_, err = k8sAPI.ExecInPod(context.Background(), newTestPod(), "missing", []string{"id"}, nil)
+	assert.ErrorContains(t, err, "container not found")
+
+	block, streamed := make(chan struct{}), make(chan struct{})
+	withFakeExecutor(t, &fakeExecutor{stdout: "done\n", block: block, streamed: streamed})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	stdout := &strings.Builder{}
+	_, err = k8sAPI.ExecInPod(ctx, newTestPod(), "", []string{"sleep", "infinity"}, &ExecOptions{Stdout: stdout})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	// the output written once canceled does not reach the writer
+	close(block)
+	<-streamed
+	assert.Empty(t, stdout.String())
+}
+
+// streamedExecutor closes streamed when the Stream of the executor returns
+type streamedExecutor struct {
+	executor remotecommand.Executor
+	streamed chan struct{}
+}
+
+func (executor *streamedExecutor) Stream(options remotecommand.StreamOptions) error {
+	defer close(executor.streamed)
+	return executor.executor.Stream(options)
+}
+
+func TestExecInPodCancelClosesConnection(t *testing.T) {
+	// the command never ends, e.g. tail -f
+	serverClosed := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, err := httpstream.Handshake(req, w, []string{remotecommandconsts.StreamProtocolV4Name}); err != nil {
+			return
+		}
+		conn := spdystream.NewResponseUpgrader().UpgradeResponse(w, req, func(httpstream.Stream, <-chan struct{}) error { return nil })
+		if conn == nil {
+			return
+		}
+		<-conn.CloseChan()
+		close(serverClosed)
+	}))
+	defer server.Close()
+
+	streamed := make(chan struct{})
+	newExecutorOrig := newExecutor
+	newExecutor = func(transport http.RoundTripper, upgrader spdy.Upgrader, method string, u *url.URL) (remotecommand.Executor, error) {
+		executor, err := newExecutorOrig(transport, upgrader, method, u)
+		return &streamedExecutor{executor: executor, streamed: streamed}, err
+	}
+	t.Cleanup(func() { newExecutor = newExecutorOrig })
+
+	k8sAPI := &KubernetesApi{Config: &restclient.Config{Host: server.URL}, Context: context.Background()}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := k8sAPI.ExecInPod(ctx, newTestPod(), "", []string{"tail", "-f", "/var/log/app.log"}, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the stream of the executor returns and the connection is closed
+	select {
+	case <-streamed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stream did not return after the context was canceled")
+	}
+	select {
+	case <-serverClosed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the connection was not closed after the context was canceled")
+	}
+}
+
+func TestAttachToPod(t *testing.T) {
+	k8sAPI := &KubernetesApi{Config: &restclient.Config{Host: "https://10.0.0.1:6443"}, Context: context.Background()}
+	streamURL := withFakeExecutor(t, &fakeExecutor{stdout: "started\n"})
+
+	result, err := k8sAPI.AttachToPod(context.Background(), newTestPod(), "nginx", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "started\n", string(result.Stdout))
+	assert.Equal(t, "/api/v1/namespaces/default/pods/nginx/attach", streamURL.Path)
+	assert.Equal(t, url.Values{"container": {"nginx"}, "stdout": {"true"}, "stderr": {"true"}}, streamURL.Query())
+}
+
+type fakeConnection struct {
+	closed chan bool
+}
+
+func (connection *fakeConnection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	return nil, errors.New("not implemented")
+}
+func (connection *fakeConnection) Close() error {
+	select {
+	case <-connection.closed:
+	default:
+		close(connection.closed)
+	}
+	return nil
+}
+func (connection *fakeConnection) CloseChan() <-chan bool                     { return connection.closed }
+func (connection *fakeConnection) SetIdleTimeout(timeout time.Duration)       {}
+func (connection *fakeConnection) RemoveStreams(streams ...httpstream.Stream) {}
+
+type fakeDialer struct {
+	err error
+}
+
+func (dialer *fakeDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	if dialer.err != nil {
+		return nil, "", dialer.err
+	}
+	return &fakeConnection{closed: make(chan bool)}, protocols[0], nil
+}
+
+func withFakeDialer(t *testing.T, dialer httpstream.Dialer) *url.URL {
+	portForwardURL := &url.URL{}
+	newDialerOrig := newDialer
+	newDialer = func(config *restclient.Config, method string, u *url.URL) (httpstream.Dialer, error) {
+		*portForwardURL = *u
+		return dialer, nil
+	}
+	t.Cleanup(func() { newDialer = newDialerOrig })
+	return portForwardURL
+}
+
+func TestPortForward(t *testing.T) {
+	k8sAPI := &KubernetesApi{Config: &restclient.Config{Host: "https://10.0.0.1:6443"}, Context: context.Background()}
+	portForwardURL := withFakeDialer(t, &fakeDialer{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session, err := k8sAPI.PortForward(ctx, newTestPod(), []string{":8080"})
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/namespaces/default/pods/nginx/portforward", portForwardURL.Path)
+	require.Len(t, session.Ports, 1)
+	assert.Equal(t, uint16(8080), session.Ports[0].Remote)
+	assert.NotZero(t, session.Ports[0].Local)
+
+	cancel()
+	select {
+	case <-session.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("port forwarding not stopped")
+	}
+	assert.NoError(t, session.Err())
+	session.Close()
+
+	// the session can be closed concurrently
+	session, err = k8sAPI.PortForward(context.Background(), newTestPod(), []string{":8080"})
+	require.NoError(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			session.Close()
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, session.Err())
+
+	withFakeDialer(t, &fakeDialer{err: errors.New("pod not running")})
+	_, err = k8sAPI.PortForward(context.Background(), newTestPod(), []string{"8080:80"})
+	assert.ErrorContains(t, err, "pod not running")
+}
@@ -0,0 +1,370 @@
+package k8sinterface
+
+import (
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GatewayAPIRouteKinds are the Gateway API route kinds that can route to services
+var GatewayAPIRouteKinds = []string{"HTTPRoute", "GRPCRoute", "TLSRoute", "TCPRoute", "UDPRoute"}
+
+const gatewayAPIGroup = "gateway.networking.k8s.io"
+
+// ExposureInfo is the report of the Services, Ingresses, Gateway API routes, OpenShift Routes and Istio VirtualServices exposing a workload
+type ExposureInfo struct {
+	Namespace       string                   `json:"namespace"`
+	Kind            string                   `json:"kind"`
+	Name            string                   `json:"name"`
+	Services        []ServiceExposure        `json:"services"`
+	Ingresses       []IngressExposure        `json:"ingresses"`
+	Routes          []RouteExposure          `json:"routes"`
+	OpenShiftRoutes []OpenShiftRouteExposure `json:"openShiftRoutes"`
+	VirtualServices []VirtualServiceExposure `json:"virtualServices"`
+	// Gateways are the Gateway API and Istio gateways the Routes and the VirtualServices are bound to
+	Gateways []GatewayExposure `json:"gateways"`
+}
+
+// ServiceExposure is a Service selecting the pods of the workload
+type ServiceExposure struct {
+	Name        string        `json:"name"`
+	Type        string        `json:"type"`
+	ClusterIP   string        `json:"clusterIP,omitempty"`
+	ExternalIPs []string      `json:"externalIPs,omitempty"`
+	Ports       []ServicePort `json:"ports,omitempty"`
+	// LoadBalancerIngress are the IPs/hostnames of the load balancer, empty until the load balancer is provisioned
+	LoadBalancerIngress []string `json:"loadBalancerIngress,omitempty"`
+}
+
+// ServicePort is a port of a Service
+type ServicePort struct {
+	Name       string `json:"name,omitempty"`
+	Protocol   string `json:"protocol"`
+	Port       int32  `json:"port"`
+	TargetPort string `json:"targetPort,omitempty"`
+	NodePort   int32  `json:"nodePort,omitempty"`
+}
+
+// IngressExposure is an Ingress routing to a Service of the workload
+type IngressExposure struct {
+	Name             string   `json:"name"`
+	IngressClassName string   `json:"ingressClassName,omitempty"`
+	ServiceName      string   `json:"serviceName"`
+	Hosts            []string `json:"hosts,omitempty"`
+	Paths            []string `json:"paths,omitempty"`
+	TLS              bool     `json:"tls"`
+	// LoadBalancerIngress are the IPs/hostnames the ingress controller exposes the Ingress on
+	LoadBalancerIngress []string `json:"loadBalancerIngress,omitempty"`
+}
+
+// RouteExposure is a Gateway API route with a backend of a Service of the workload
+type RouteExposure struct {
+	Kind        string   `json:"kind"`
+	Namespace   string   `json:"namespace"`
+	Name        string   `json:"name"`
+	ServiceName string   `json:"serviceName"`
+	Hostnames   []string `json:"hostnames,omitempty"`
+	// Gateways are the <namespace>/<name> of the parent gateways of the route
+	Gateways []string `json:"gateways,omitempty"`
+}
+
+// IsExternallyExposed returns true if the workload is reachable from outside the cluster through a NodePort/LoadBalancer Service,
+// an external IP, an Ingress, a Gateway API route, an OpenShift Route or a VirtualService bound to an Istio gateway
+func (exposureInfo *ExposureInfo) IsExternallyExposed() bool {
+	for i := range exposureInfo.Services {
+		switch corev1.ServiceType(exposureInfo.Services[i].Type) {
+		case corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+			return true
+		}
+		if len(exposureInfo.Services[i].ExternalIPs) > 0 {
+			return true
+		}
+	}
+	for i := range exposureInfo.VirtualServices {
+		if len(exposureInfo.VirtualServices[i].Gateways) > 0 {
+			return true
+		}
+	}
+	return len(exposureInfo.Ingresses) > 0 || len(exposureInfo.Routes) > 0 || len(exposureInfo.OpenShiftRoutes) > 0
+}
+
+// ServiceSelectsWorkload returns true if the Service selects the pods of the workload. Services without a selector select no workload
+func ServiceSelectsWorkload(service *corev1.Service, workload IWorkload) bool {
+	if len(service.Spec.Selector) == 0 || service.GetNamespace() != workload.GetNamespace() {
+		return false
+	}
+	return labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(workload.GetPodLabels()))
+}
+
+// GetExposureInfo returns the Services selecting the pods of the workload, the Ingresses, Gateway API routes, OpenShift Routes and Istio VirtualServices
+// routing to these Services, and the gateways of the routes and the VirtualServices
+func (k8sAPI *KubernetesApi) GetExposureInfo(workload IWorkload) (*ExposureInfo, error) {
+	namespace := workload.GetNamespace()
+	exposureInfo := &ExposureInfo{
+		Namespace:       namespace,
+		Kind:            workload.GetKind(),
+		Name:            workload.GetName(),
+		Services:        []ServiceExposure{},
+		Ingresses:       []IngressExposure{},
+		Routes:          []RouteExposure{},
+		OpenShiftRoutes: []OpenShiftRouteExposure{},
+		VirtualServices: []VirtualServiceExposure{},
+		Gateways:        []GatewayExposure{},
+	}
+
+	ctx, span := k8sAPI.startSpan("k8s.ListServices", &schema.GroupVersionResource{Version: "v1", Resource: "services"}, namespace, "")
+	services, err := k8sAPI.KubernetesClient.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST services, reason: %s", err.Error())
+	}
+	serviceNames := map[string]bool{}
+	for i := range services.Items {
+		if ServiceSelectsWorkload(&services.Items[i], workload) {
+			exposureInfo.Services = append(exposureInfo.Services, newServiceExposure(&services.Items[i]))
+			serviceNames[services.Items[i].GetName()] = true
+		}
+	}
+	if len(serviceNames) == 0 {
+		return exposureInfo, nil
+	}
+
+	ctx, span = k8sAPI.startSpan("k8s.ListIngresses", &schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, namespace, "")
+	ingresses, err := k8sAPI.KubernetesClient.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST ingresses, reason: %s", err.Error())
+	}
+	for i := range ingresses.Items {
+		exposureInfo.Ingresses = append(exposureInfo.Ingresses, newIngressExposures(&ingresses.Items[i], serviceNames)...)
+	}
+
+	routes, err := k8sAPI.listGatewayAPIRoutes()
+	if err != nil {
+		return nil, err
+	}
+	for i := range routes {
+		exposureInfo.Routes = append(exposureInfo.Routes, newRouteExposures(&routes[i], namespace, serviceNames)...)
+	}
+
+	openShiftRoutes, err := k8sAPI.listOpenShiftRoutes(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i := range openShiftRoutes {
+		exposureInfo.OpenShiftRoutes = append(exposureInfo.OpenShiftRoutes, newOpenShiftRouteExposures(&openShiftRoutes[i], serviceNames)...)
+	}
+
+	virtualServices, err := k8sAPI.listVirtualServices(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i := range virtualServices {
+		exposureInfo.VirtualServices = append(exposureInfo.VirtualServices, newVirtualServiceExposures(&virtualServices[i], namespace, serviceNames)...)
+	}
+
+	exposureInfo.Gateways, err = k8sAPI.getGatewayExposures(exposureInfo)
+	if err != nil {
+		return nil, err
+	}
+	return exposureInfo, nil
+}
+
+// listGatewayAPIRoutes lists the routes of all the namespaces, routes may have backends in other namespaces. Route kinds the cluster does not serve are skipped
+func (k8sAPI *KubernetesApi) listGatewayAPIRoutes() ([]unstructured.Unstructured, error) {
+	var routes []unstructured.Unstructured
+	for _, kind := range GatewayAPIRouteKinds {
+		info, ok := GetGroupKindInfo(gatewayAPIGroup, kind)
+		if !ok {
+			continue
+		}
+		ctx, span := k8sAPI.startSpan("k8s.List"+kind+"s", &info.GroupVersionResource, "", "")
+		list, err := k8sAPI.DynamicClient.Resource(info.GroupVersionResource).Namespace("").List(ctx, metav1.ListOptions{})
+		tracing.EndSpan(span, err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to LIST %s, reason: %s", info.GroupVersionResource.Resource, err.Error())
+		}
+		routes = append(routes, list.Items...)
+	}
+	return routes, nil
+}
+
+func newServiceExposure(service *corev1.Service) ServiceExposure {
+	serviceExposure := ServiceExposure{
+		Name:        service.GetName(),
+		Type:        string(service.Spec.Type),
+		ClusterIP:   service.Spec.ClusterIP,
+		ExternalIPs: service.Spec.ExternalIPs,
+	}
+	if serviceExposure.Type == "" {
+		serviceExposure.Type = string(corev1.ServiceTypeClusterIP)
+	}
+	for _, servicePort := range service.Spec.Ports {
+		protocol := string(servicePort.Protocol)
+		if protocol == "" {
+			protocol = string(corev1.ProtocolTCP)
+		}
+		serviceExposure.Ports = append(serviceExposure.Ports, ServicePort{
+			Name:       servicePort.Name,
+			Protocol:   protocol,
+			Port:       servicePort.Port,
+			TargetPort: servicePort.TargetPort.String(),
+			NodePort:   servicePort.NodePort,
+		})
+	}
+	serviceExposure.LoadBalancerIngress = loadBalancerIngress(service.Status.LoadBalancer.Ingress)
+	return serviceExposure
+}
+
+// newIngressExposures returns an exposure per Service of the workload the Ingress routes to
+func newIngressExposures(ingress *networkingv1.Ingress, serviceNames map[string]bool) []IngressExposure {
+	exposures := map[string]*IngressExposure{}
+	var order []string
+	addBackend := func(backend *networkingv1.IngressBackend, host, path string) {
+		if backend == nil || backend.Service == nil || !serviceNames[backend.Service.Name] {
+			return
+		}
+		exposure, ok := exposures[backend.Service.Name]
+		if !ok {
+			exposure = &IngressExposure{
+				Name:                ingress.GetName(),
+				ServiceName:         backend.Service.Name,
+				TLS:                 len(ingress.Spec.TLS) > 0,
+				LoadBalancerIngress: loadBalancerIngress(ingress.Status.LoadBalancer.Ingress),
+			}
+			if ingress.Spec.IngressClassName != nil {
+				exposure.IngressClassName = *ingress.Spec.IngressClassName
+			}
+			exposures[backend.Service.Name] = exposure
+			order = append(order, backend.Service.Name)
+		}
+		if host != "" && !contains(exposure.Hosts, host) {
+			exposure.Hosts = append(exposure.Hosts, host)
+		}
+		if path != "" && !contains(exposure.Paths, path) {
+			exposure.Paths = append(exposure.Paths, path)
+		}
+	}
+
+	addBackend(ingress.Spec.DefaultBackend, "", "/")
+	for _, ingressRule := range ingress.Spec.Rules {
+		if ingressRule.HTTP == nil {
+			continue
+		}
+		for i := range ingressRule.HTTP.Paths {
+			path := ingressRule.HTTP.Paths[i].Path
+			if path == "" {
+				path = "/"
+			}
+			addBackend(&ingressRule.HTTP.Paths[i].Backend, ingressRule.Host, path)
+		}
+	}
+
+	result := make([]IngressExposure, 0, len(order))
+	for _, serviceName := range order {
+		result = append(result, *exposures[serviceName])
+	}
+	return result
+}
+
+// newRouteExposures returns an exposure per Service of the workload the route has a backend of
+func newRouteExposures(route *unstructured.Unstructured, namespace string, serviceNames map[string]bool) []RouteExposure {
+	var exposures []RouteExposure
+	seen := map[string]bool{}
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	for i := range rules {
+		rule, ok := rules[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		backendRefs, _, _ := unstructured.NestedSlice(rule, "backendRefs")
+		for j := range backendRefs {
+			backendRef, ok := backendRefs[j].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			serviceName, ok := routeBackendService(backendRef, route.GetNamespace(), namespace)
+			if !ok || !serviceNames[serviceName] || seen[serviceName] {
+				continue
+			}
+			seen[serviceName] = true
+			hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+			exposures = append(exposures, RouteExposure{
+				Kind:        route.GetKind(),
+				Namespace:   route.GetNamespace(),
+				Name:        route.GetName(),
+				ServiceName: serviceName,
+				Hostnames:   hostnames,
+				Gateways:    routeParentGateways(route),
+			})
+		}
+	}
+	return exposures
+}
+
+// routeBackendService returns the name of the Service of the backend if the backend is a Service of the namespace
+func routeBackendService(backendRef map[string]interface{}, routeNamespace, namespace string) (string, bool) {
+	group, _, _ := unstructured.NestedString(backendRef, "group")
+	kind, _, _ := unstructured.NestedString(backendRef, "kind")
+	if group != "" || (kind != "" && kind != "Service") {
+		return "", false
+	}
+	backendNamespace, _, _ := unstructured.NestedString(backendRef, "namespace")
+	if backendNamespace == "" {
+		backendNamespace = routeNamespace
+	}
+	if backendNamespace != namespace {
+		return "", false
+	}
+	name, _, _ := unstructured.NestedString(backendRef, "name")
+	return name, name != ""
+}
+
+func routeParentGateways(route *unstructured.Unstructured) []string {
+	var gateways []string
+	parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	for i := range parentRefs {
+		parentRef, ok := parentRefs[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if kind, _, _ := unstructured.NestedString(parentRef, "kind"); kind != "" && kind != "Gateway" {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(parentRef, "name")
+		parentNamespace, _, _ := unstructured.NestedString(parentRef, "namespace")
+		if parentNamespace == "" {
+			parentNamespace = route.GetNamespace()
+		}
+		gateways = append(gateways, parentNamespace+"/"+name)
+	}
+	return gateways
+}
+
+func loadBalancerIngress(ingresses []corev1.LoadBalancerIngress) []string {
+	var result []string
+	for i := range ingresses {
+		if ingresses[i].IP != "" {
+			result = append(result, ingresses[i].IP)
+		}
+		if ingresses[i].Hostname != "" {
+			result = append(result, ingresses[i].Hostname)
+		}
+	}
+	return result
+}
+
+func contains(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
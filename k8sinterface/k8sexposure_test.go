@@ -0,0 +1,163 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newExposureTestDeployment() IWorkload {
+	return workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "frontend"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "frontend", "tier": "web"}},
+			},
+		},
+	})
+}
+
+// newExposureDynamicClient returns a fake dynamic client serving the kinds listed by GetExposureInfo, the kind mapping is shared by the tests
+func newExposureDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			{Group: "gateway.networking.k8s.io", Version: "v1beta1", Resource: "httproutes"}: "HTTPRouteList",
+			{Group: "networking.istio.io", Version: "v1beta1", Resource: "virtualservices"}:  "VirtualServiceList",
+			OpenShiftRouteGroupVersionResource:                                               "RouteList",
+		},
+		objects...,
+	)
+}
+
+func TestServiceSelectsWorkload(t *testing.T) {
+	workload := newExposureTestDeployment()
+	newService := func(namespace string, selector map[string]string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "svc"}, Spec: corev1.ServiceSpec{Selector: selector}}
+	}
+	assert.True(t, ServiceSelectsWorkload(newService("shop", map[string]string{"app": "frontend"}), workload))
+	assert.False(t, ServiceSelectsWorkload(newService("shop", map[string]string{"app": "frontend", "tier": "db"}), workload))
+	assert.False(t, ServiceSelectsWorkload(newService("other", map[string]string{"app": "frontend"}), workload))
+	assert.False(t, ServiceSelectsWorkload(newService("shop", nil), workload), "services without a selector select nothing")
+}
+
+func TestGetExposureInfo(t *testing.T) {
+	InitializeMapResourcesMock()
+	AddMapResources([]*metav1.APIResourceList{{
+		GroupVersion: "gateway.networking.k8s.io/v1beta1",
+		APIResources: []metav1.APIResource{{Name: "httproutes", Kind: "HTTPRoute", Namespaced: true, Verbs: metav1.Verbs{"list"}}},
+	}})
+	className := "nginx"
+	pathType := networkingv1.PathTypePrefix
+
+	kubernetesClient := kubernetesfake.NewSimpleClientset(
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "frontend-lb"},
+			Spec: corev1.ServiceSpec{
+				Type:     corev1.ServiceTypeLoadBalancer,
+				Selector: map[string]string{"app": "frontend"},
+				Ports:    []corev1.ServicePort{{Name: "https", Port: 443, TargetPort: intstr.FromString("https"), NodePort: 30443}},
+			},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: "34.1.2.3"}}}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "frontend"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"tier": "web"}, Ports: []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "backend"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "backend"}},
+		},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "shop"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: &className,
+				TLS:              []networkingv1.IngressTLS{{Hosts: []string{"shop.example.com"}}},
+				Rules: []networkingv1.IngressRule{{
+					Host: "shop.example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: []networkingv1.HTTPIngressPath{
+						{Path: "/", PathType: &pathType, Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: "frontend"}}},
+						{Path: "/api", PathType: &pathType, Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: "backend"}}},
+					}}},
+				}},
+			},
+		},
+	)
+	route := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1beta1",
+		"kind":       "HTTPRoute",
+		"metadata":   map[string]interface{}{"namespace": "gateways", "name": "shop"},
+		"spec": map[string]interface{}{
+			"parentRefs": []interface{}{map[string]interface{}{"name": "public"}},
+			"hostnames":  []interface{}{"shop.example.com"},
+			"rules": []interface{}{
+				map[string]interface{}{"backendRefs": []interface{}{
+					map[string]interface{}{"name": "frontend", "namespace": "shop", "port": int64(80)},
+					map[string]interface{}{"name": "frontend", "port": int64(80)},
+				}},
+			},
+		},
+	}}
+	dynamicClient := newExposureDynamicClient(route)
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesClient, DynamicClient: dynamicClient, Context: context.Background()}
+
+	exposureInfo, err := k8sAPI.GetExposureInfo(newExposureTestDeployment())
+	require.NoError(t, err)
+	assert.True(t, exposureInfo.IsExternallyExposed())
+
+	require.Len(t, exposureInfo.Services, 2)
+	services := map[string]ServiceExposure{}
+	for _, service := range exposureInfo.Services {
+		services[service.Name] = service
+	}
+	assert.Equal(t, ServiceExposure{
+		Name:                "frontend-lb",
+		Type:                "LoadBalancer",
+		Ports:               []ServicePort{{Name: "https", Protocol: "TCP", Port: 443, TargetPort: "https", NodePort: 30443}},
+		LoadBalancerIngress: []string{"34.1.2.3"},
+	}, services["frontend-lb"])
+	assert.Equal(t, "ClusterIP", services["frontend"].Type)
+	assert.Equal(t, "8080", services["frontend"].Ports[0].TargetPort)
+
+	assert.Equal(t, []IngressExposure{{
+		Name:             "shop",
+		IngressClassName: "nginx",
+		ServiceName:      "frontend",
+		Hosts:            []string{"shop.example.com"},
+		Paths:            []string{"/"},
+		TLS:              true,
+	}}, exposureInfo.Ingresses)
+
+	// the backend in the namespace of the route is not a service of the workload
+	assert.Equal(t, []RouteExposure{{
+		Kind:        "HTTPRoute",
+		Namespace:   "gateways",
+		Name:        "shop",
+		ServiceName: "frontend",
+		Hostnames:   []string{"shop.example.com"},
+		Gateways:    []string{"gateways/public"},
+	}}, exposureInfo.Routes)
+}
+
+func TestGetExposureInfoNotExposed(t *testing.T) {
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesfake.NewSimpleClientset(), Context: context.Background()}
+
+	exposureInfo, err := k8sAPI.GetExposureInfo(newExposureTestDeployment())
+	require.NoError(t, err)
+	assert.False(t, exposureInfo.IsExternallyExposed())
+	assert.Empty(t, exposureInfo.Services)
+	assert.Equal(t, "Deployment", exposureInfo.Kind)
+}
@@ -0,0 +1,249 @@
+package k8sinterface
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	istioNetworkingGroup = "networking.istio.io"
+	// istioMeshGateway is the reserved gateway of the VirtualServices applying to the sidecars of the mesh, it does not expose the services
+	istioMeshGateway = "mesh"
+)
+
+// VirtualServiceExposure is an Istio VirtualService with a destination of a Service of the workload
+type VirtualServiceExposure struct {
+	Namespace   string   `json:"namespace"`
+	Name        string   `json:"name"`
+	ServiceName string   `json:"serviceName"`
+	Hosts       []string `json:"hosts,omitempty"`
+	// Gateways are the <namespace>/<name> of the Istio gateways the VirtualService is bound to
+	Gateways []string `json:"gateways,omitempty"`
+	// Mesh is true if the VirtualService applies to the traffic of the sidecars of the mesh
+	Mesh bool `json:"mesh"`
+}
+
+// GatewayExposure is a Gateway API Gateway or an Istio Gateway a route or a VirtualService of the workload is bound to
+type GatewayExposure struct {
+	// Group is gateway.networking.k8s.io for the Gateway API and networking.istio.io for Istio
+	Group     string `json:"group"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// GatewayClassName is the class of the Gateway API gateway
+	GatewayClassName string `json:"gatewayClassName,omitempty"`
+	// Selector selects the pods of the Istio ingress gateway implementing the gateway
+	Selector map[string]string `json:"selector,omitempty"`
+	// Addresses are the addresses the Gateway API gateway is reachable on, empty until the gateway is programmed
+	Addresses []string          `json:"addresses,omitempty"`
+	Listeners []GatewayListener `json:"listeners,omitempty"`
+}
+
+// GatewayListener is a listener of a Gateway API gateway or a server of an Istio gateway
+type GatewayListener struct {
+	Name      string   `json:"name,omitempty"`
+	Port      int64    `json:"port"`
+	Protocol  string   `json:"protocol,omitempty"`
+	Hostnames []string `json:"hostnames,omitempty"`
+}
+
+// listVirtualServices lists the VirtualServices of all the namespaces, VirtualServices may have destinations in other namespaces.
+// If listing them cluster-wide is forbidden, only the VirtualServices of the namespace are listed, and none if this is forbidden as well.
+// No VirtualServices if the cluster does not serve them
+func (k8sAPI *KubernetesApi) listVirtualServices(namespace string) ([]unstructured.Unstructured, error) {
+	info, ok := GetGroupKindInfo(istioNetworkingGroup, "VirtualService")
+	if !ok {
+		return nil, nil
+	}
+	for _, listNamespace := range []string{"", namespace} {
+		ctx, span := k8sAPI.startSpan("k8s.ListVirtualServices", &info.GroupVersionResource, listNamespace, "")
+		list, err := k8sAPI.DynamicClient.Resource(info.GroupVersionResource).Namespace(listNamespace).List(ctx, metav1.ListOptions{})
+		tracing.EndSpan(span, err)
+		if err == nil {
+			return list.Items, nil
+		}
+		if !apierrors.IsForbidden(err) {
+			return nil, fmt.Errorf("failed to LIST virtualservices, reason: %s", err.Error())
+		}
+		k8sAPI.GetLogger().Debug("forbidden to LIST virtualservices", "namespace", listNamespace, "error", err.Error())
+	}
+	k8sAPI.GetLogger().Warning("forbidden to LIST virtualservices, the exposure through Istio is not reported", "namespace", namespace)
+	return nil, nil
+}
+
+// newVirtualServiceExposures returns an exposure per Service of the workload the VirtualService has an HTTP, TLS or TCP route destination of
+func newVirtualServiceExposures(virtualService *unstructured.Unstructured, namespace string, serviceNames map[string]bool) []VirtualServiceExposure {
+	var exposures []VirtualServiceExposure
+	seen := map[string]bool{}
+	for _, protocol := range []string{"http", "tls", "tcp"} {
+		routes, _, _ := unstructured.NestedSlice(virtualService.Object, "spec", protocol)
+		for i := range routes {
+			route, ok := routes[i].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			destinations, _, _ := unstructured.NestedSlice(route, "route")
+			for j := range destinations {
+				destination, ok := destinations[j].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				host, _, _ := unstructured.NestedString(destination, "destination", "host")
+				serviceNamespace, serviceName, ok := istioDestinationService(host, virtualService.GetNamespace())
+				if !ok || serviceNamespace != namespace || !serviceNames[serviceName] || seen[serviceName] {
+					continue
+				}
+				seen[serviceName] = true
+				hosts, _, _ := unstructured.NestedStringSlice(virtualService.Object, "spec", "hosts")
+				exposure := VirtualServiceExposure{
+					Namespace:   virtualService.GetNamespace(),
+					Name:        virtualService.GetName(),
+					ServiceName: serviceName,
+					Hosts:       hosts,
+				}
+				exposure.Gateways, exposure.Mesh = virtualServiceGateways(virtualService)
+				exposures = append(exposures, exposure)
+			}
+		}
+	}
+	return exposures
+}
+
+// istioDestinationService returns the namespace and the name of the Service of the destination host. Short names are relative to the namespace
+// of the VirtualService, hosts that are not Services of the cluster (e.g. ServiceEntries) are ignored
+func istioDestinationService(host, virtualServiceNamespace string) (string, string, bool) {
+	if host == "" || strings.Contains(host, "*") {
+		return "", "", false
+	}
+	parts := strings.Split(host, ".")
+	switch {
+	case len(parts) == 1:
+		return virtualServiceNamespace, parts[0], true
+	case len(parts) == 2, parts[2] == "svc":
+		return parts[1], parts[0], true
+	}
+	return "", "", false
+}
+
+// virtualServiceGateways returns the <namespace>/<name> of the gateways of the VirtualService and whether it applies to the mesh.
+// A VirtualService without gateways applies to the mesh only
+func virtualServiceGateways(virtualService *unstructured.Unstructured) ([]string, bool) {
+	gateways, _, _ := unstructured.NestedStringSlice(virtualService.Object, "spec", "gateways")
+	if len(gateways) == 0 {
+		return nil, true
+	}
+	var result []string
+	mesh := false
+	for _, gateway := range gateways {
+		if gateway == istioMeshGateway {
+			mesh = true
+			continue
+		}
+		if !strings.Contains(gateway, "/") {
+			gateway = virtualService.GetNamespace() + "/" + gateway
+		}
+		if !contains(result, gateway) {
+			result = append(result, gateway)
+		}
+	}
+	return result, mesh
+}
+
+// getGatewayExposures returns the gateways the routes and the VirtualServices of the exposure are bound to. Gateways that do not exist are skipped
+func (k8sAPI *KubernetesApi) getGatewayExposures(exposureInfo *ExposureInfo) ([]GatewayExposure, error) {
+	type gatewayRef struct{ group, namespacedName string }
+	var refs []gatewayRef
+	seen := map[gatewayRef]bool{}
+	addRefs := func(group string, namespacedNames []string) {
+		for _, namespacedName := range namespacedNames {
+			ref := gatewayRef{group: group, namespacedName: namespacedName}
+			if !seen[ref] {
+				seen[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	for i := range exposureInfo.Routes {
+		addRefs(gatewayAPIGroup, exposureInfo.Routes[i].Gateways)
+	}
+	for i := range exposureInfo.VirtualServices {
+		addRefs(istioNetworkingGroup, exposureInfo.VirtualServices[i].Gateways)
+	}
+
+	gateways := []GatewayExposure{}
+	for _, ref := range refs {
+		info, ok := GetGroupKindInfo(ref.group, "Gateway")
+		if !ok {
+			continue
+		}
+		namespace, name, _ := strings.Cut(ref.namespacedName, "/")
+		ctx, span := k8sAPI.startSpan("k8s.GetGateway", &info.GroupVersionResource, namespace, name)
+		gateway, err := k8sAPI.DynamicClient.Resource(info.GroupVersionResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		tracing.EndSpan(span, err)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to GET gateway '%s', reason: %s", ref.namespacedName, err.Error())
+		}
+		gateways = append(gateways, newGatewayExposure(ref.group, gateway))
+	}
+	return gateways, nil
+}
+
+func newGatewayExposure(group string, gateway *unstructured.Unstructured) GatewayExposure {
+	gatewayExposure := GatewayExposure{
+		Group:     group,
+		Namespace: gateway.GetNamespace(),
+		Name:      gateway.GetName(),
+	}
+	if group == istioNetworkingGroup {
+		gatewayExposure.Selector, _, _ = unstructured.NestedStringMap(gateway.Object, "spec", "selector")
+		servers, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "servers")
+		for i := range servers {
+			server, ok := servers[i].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			listener := GatewayListener{}
+			listener.Name, _, _ = unstructured.NestedString(server, "port", "name")
+			listener.Port, _, _ = unstructured.NestedInt64(server, "port", "number")
+			listener.Protocol, _, _ = unstructured.NestedString(server, "port", "protocol")
+			listener.Hostnames, _, _ = unstructured.NestedStringSlice(server, "hosts")
+			gatewayExposure.Listeners = append(gatewayExposure.Listeners, listener)
+		}
+		return gatewayExposure
+	}
+
+	gatewayExposure.GatewayClassName, _, _ = unstructured.NestedString(gateway.Object, "spec", "gatewayClassName")
+	listeners, _, _ := unstructured.NestedSlice(gateway.Object, "spec", "listeners")
+	for i := range listeners {
+		gatewayListener, ok := listeners[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		listener := GatewayListener{}
+		listener.Name, _, _ = unstructured.NestedString(gatewayListener, "name")
+		listener.Port, _, _ = unstructured.NestedInt64(gatewayListener, "port")
+		listener.Protocol, _, _ = unstructured.NestedString(gatewayListener, "protocol")
+		if hostname, _, _ := unstructured.NestedString(gatewayListener, "hostname"); hostname != "" {
+			listener.Hostnames = []string{hostname}
+		}
+		gatewayExposure.Listeners = append(gatewayExposure.Listeners, listener)
+	}
+	addresses, _, _ := unstructured.NestedSlice(gateway.Object, "status", "addresses")
+	for i := range addresses {
+		address, ok := addresses[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if value, _, _ := unstructured.NestedString(address, "value"); value != "" {
+			gatewayExposure.Addresses = append(gatewayExposure.Addresses, value)
+		}
+	}
+	return gatewayExposure
+}
@@ -0,0 +1,186 @@
+package k8sinterface
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestIstioDestinationService(t *testing.T) {
+	tests := []struct {
+		host      string
+		namespace string
+		name      string
+		ok        bool
+	}{
+		{host: "frontend", namespace: "mesh-config", name: "frontend", ok: true},
+		{host: "frontend.shop", namespace: "shop", name: "frontend", ok: true},
+		{host: "frontend.shop.svc", namespace: "shop", name: "frontend", ok: true},
+		{host: "frontend.shop.svc.cluster.local", namespace: "shop", name: "frontend", ok: true},
+		{host: "api.example.com"},
+		{host: "*.shop.svc.cluster.local"},
+		{host: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			namespace, name, ok := istioDestinationService(tt.host, "mesh-config")
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.namespace, namespace)
+			assert.Equal(t, tt.name, name)
+		})
+	}
+}
+
+func TestGetExposureInfoGateways(t *testing.T) {
+	InitializeMapResourcesMock()
+	AddMapResources([]*metav1.APIResourceList{
+		{
+			GroupVersion: "gateway.networking.k8s.io/v1beta1",
+			APIResources: []metav1.APIResource{
+				{Name: "httproutes", Kind: "HTTPRoute", Namespaced: true, Verbs: metav1.Verbs{"get", "list"}},
+				{Name: "gateways", Kind: "Gateway", Namespaced: true, Verbs: metav1.Verbs{"get", "list"}},
+			},
+		},
+		{
+			GroupVersion: "networking.istio.io/v1beta1",
+			APIResources: []metav1.APIResource{
+				{Name: "virtualservices", Kind: "VirtualService", Namespaced: true, Verbs: metav1.Verbs{"get", "list"}},
+				{Name: "gateways", Kind: "Gateway", Namespaced: true, Verbs: metav1.Verbs{"get", "list"}},
+			},
+		},
+	})
+
+	kubernetesClient := kubernetesfake.NewSimpleClientset(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "frontend"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "frontend"}},
+	})
+	gateways := []*unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1beta1",
+			"kind":       "Gateway",
+			"metadata":   map[string]interface{}{"namespace": "gateways", "name": "public"},
+			"spec": map[string]interface{}{
+				"gatewayClassName": "gke-l7-global-external-managed",
+				"listeners":        []interface{}{map[string]interface{}{"name": "https", "port": int64(443), "protocol": "HTTPS", "hostname": "shop.example.com"}},
+			},
+			"status": map[string]interface{}{"addresses": []interface{}{map[string]interface{}{"type": "IPAddress", "value": "34.1.2.3"}}},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "networking.istio.io/v1beta1",
+			"kind":       "Gateway",
+			"metadata":   map[string]interface{}{"namespace": "istio-system", "name": "ingressgateway"},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"istio": "ingressgateway"},
+				"servers": []interface{}{map[string]interface{}{
+					"port":  map[string]interface{}{"number": int64(443), "name": "https", "protocol": "HTTPS"},
+					"hosts": []interface{}{"shop.example.com"},
+				}},
+			},
+		}},
+	}
+	objects := []runtime.Object{
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1beta1",
+			"kind":       "HTTPRoute",
+			"metadata":   map[string]interface{}{"namespace": "shop", "name": "frontend"},
+			"spec": map[string]interface{}{
+				"parentRefs": []interface{}{map[string]interface{}{"name": "public", "namespace": "gateways"}, map[string]interface{}{"name": "deleted"}},
+				"rules":      []interface{}{map[string]interface{}{"backendRefs": []interface{}{map[string]interface{}{"name": "frontend", "port": int64(80)}}}},
+			},
+		}},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "networking.istio.io/v1beta1",
+			"kind":       "VirtualService",
+			"metadata":   map[string]interface{}{"namespace": "istio-system", "name": "shop"},
+			"spec": map[string]interface{}{
+				"hosts":    []interface{}{"shop.example.com"},
+				"gateways": []interface{}{"ingressgateway", "mesh"},
+				"http": []interface{}{map[string]interface{}{"route": []interface{}{
+					map[string]interface{}{"destination": map[string]interface{}{"host": "frontend.shop.svc.cluster.local", "port": map[string]interface{}{"number": int64(80)}}, "weight": int64(90)},
+					map[string]interface{}{"destination": map[string]interface{}{"host": "frontend-canary.shop.svc.cluster.local"}, "weight": int64(10)},
+				}}},
+			},
+		}},
+		// mesh only, the service is not exposed
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "networking.istio.io/v1beta1",
+			"kind":       "VirtualService",
+			"metadata":   map[string]interface{}{"namespace": "shop", "name": "frontend-retries"},
+			"spec": map[string]interface{}{
+				"hosts": []interface{}{"frontend"},
+				"tcp":   []interface{}{map[string]interface{}{"route": []interface{}{map[string]interface{}{"destination": map[string]interface{}{"host": "frontend"}}}}},
+			},
+		}},
+	}
+	dynamicClient := newExposureDynamicClient(objects...)
+	// the fake client guesses the wrong plural of the gateways
+	for _, gateway := range gateways {
+		gvr := schema.GroupVersionResource{Group: gateway.GroupVersionKind().Group, Version: "v1beta1", Resource: "gateways"}
+		require.NoError(t, dynamicClient.Tracker().Create(gvr, gateway, gateway.GetNamespace()))
+	}
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesClient, DynamicClient: dynamicClient, Context: context.Background()}
+
+	exposureInfo, err := k8sAPI.GetExposureInfo(newExposureTestDeployment())
+	require.NoError(t, err)
+	assert.True(t, exposureInfo.IsExternallyExposed())
+
+	require.Len(t, exposureInfo.Routes, 1)
+	assert.Equal(t, []string{"gateways/public", "shop/deleted"}, exposureInfo.Routes[0].Gateways)
+	assert.ElementsMatch(t, []VirtualServiceExposure{
+		{Namespace: "istio-system", Name: "shop", ServiceName: "frontend", Hosts: []string{"shop.example.com"}, Gateways: []string{"istio-system/ingressgateway"}, Mesh: true},
+		{Namespace: "shop", Name: "frontend-retries", ServiceName: "frontend", Hosts: []string{"frontend"}, Mesh: true},
+	}, exposureInfo.VirtualServices)
+
+	// the deleted gateway of the route is skipped
+	assert.Equal(t, []GatewayExposure{
+		{
+			Group:            "gateway.networking.k8s.io",
+			Namespace:        "gateways",
+			Name:             "public",
+			GatewayClassName: "gke-l7-global-external-managed",
+			Addresses:        []string{"34.1.2.3"},
+			Listeners:        []GatewayListener{{Name: "https", Port: 443, Protocol: "HTTPS", Hostnames: []string{"shop.example.com"}}},
+		},
+		{
+			Group:     "networking.istio.io",
+			Namespace: "istio-system",
+			Name:      "ingressgateway",
+			Selector:  map[string]string{"istio": "ingressgateway"},
+			Listeners: []GatewayListener{{Name: "https", Port: 443, Protocol: "HTTPS", Hostnames: []string{"shop.example.com"}}},
+		},
+	}, exposureInfo.Gateways)
+
+	meshOnly := &ExposureInfo{VirtualServices: []VirtualServiceExposure{{Namespace: "shop", Name: "frontend-retries", ServiceName: "frontend", Mesh: true}}}
+	assert.False(t, meshOnly.IsExternallyExposed())
+
+	// forbidden to list the VirtualServices cluster-wide: the VirtualServices of the namespace of the workload
+	forbiddenNamespaces := map[string]bool{"": true}
+	dynamicClient.PrependReactor("list", "virtualservices", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if forbiddenNamespaces[action.GetNamespace()] {
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: istioNetworkingGroup, Resource: "virtualservices"}, "", errors.New("forbidden"))
+		}
+		return false, nil, nil
+	})
+	exposureInfo, err = k8sAPI.GetExposureInfo(newExposureTestDeployment())
+	require.NoError(t, err)
+	require.Len(t, exposureInfo.VirtualServices, 1)
+	assert.Equal(t, "frontend-retries", exposureInfo.VirtualServices[0].Name)
+
+	// forbidden in the namespace as well: no VirtualServices
+	forbiddenNamespaces["shop"] = true
+	exposureInfo, err = k8sAPI.GetExposureInfo(newExposureTestDeployment())
+	require.NoError(t, err)
+	assert.Empty(t, exposureInfo.VirtualServices)
+	assert.Len(t, exposureInfo.Routes, 1)
+}
@@ -0,0 +1,55 @@
+package k8sinterface
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kubescape/k8s-interface/tracing"
+)
+
+// DefaultPingTimeout is the timeout of Ping when the context has no deadline
+const DefaultPingTimeout = 5 * time.Second
+
+// Ping checks the API server is reachable and accepts the credentials of the client by requesting its version. The request is bounded by the deadline of the
+// context, or DefaultPingTimeout if it has none, so daemons detect an API server outage or rotated credentials instead of hanging on the next request
+func (k8sAPI *KubernetesApi) Ping(ctx context.Context) error {
+	if k8sAPI == nil || k8sAPI.DiscoveryClient == nil {
+		return fmt.Errorf("failed to ping the API server, reason: the discovery client is not initialized")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultPingTimeout)
+		defer cancel()
+	}
+
+	ctx, span := startSpan(ctx, "k8s.Ping", nil, "", "")
+	err := k8sAPI.ping(ctx)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return fmt.Errorf("failed to ping the API server, reason: %s", err.Error())
+	}
+	return nil
+}
+
+func (k8sAPI *KubernetesApi) ping(ctx context.Context) error {
+	restClient := k8sAPI.DiscoveryClient.RESTClient()
+	if restClient == nil {
+		// the fake discovery clients have no REST client, they serve the version
+		_, err := k8sAPI.DiscoveryClient.ServerVersion()
+		return err
+	}
+	return restClient.Get().AbsPath("/version").Do(ctx).Error()
+}
+
+// IsReachable returns true if the API server answers the Ping within DefaultPingTimeout
+func (k8sAPI *KubernetesApi) IsReachable() bool {
+	var ctx context.Context
+	if k8sAPI != nil {
+		ctx = k8sAPI.Context
+	}
+	return k8sAPI.Ping(ctx) == nil
+}
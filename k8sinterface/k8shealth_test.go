@@ -0,0 +1,83 @@
+package k8sinterface
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	restclient "k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newPingTestApi(t *testing.T, handler http.HandlerFunc) *KubernetesApi {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(&restclient.Config{Host: server.URL})
+	require.NoError(t, err)
+	return &KubernetesApi{DiscoveryClient: discoveryClient, Context: context.Background()}
+}
+
+func TestPing(t *testing.T) {
+	k8sAPI := newPingTestApi(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/version", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"major":"1","minor":"25","gitVersion":"v1.25.3"}`))
+	})
+	assert.NoError(t, k8sAPI.Ping(context.Background()))
+	assert.True(t, k8sAPI.IsReachable())
+
+	// rotated credentials
+	k8sAPI = newPingTestApi(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	assert.Error(t, k8sAPI.Ping(context.Background()))
+	assert.False(t, k8sAPI.IsReachable())
+
+	// the fake discovery clients
+	k8sAPI = &KubernetesApi{DiscoveryClient: &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{}}}
+	assert.True(t, k8sAPI.IsReachable())
+
+	assert.False(t, (&KubernetesApi{}).IsReachable())
+	var nilAPI *KubernetesApi
+	assert.False(t, nilAPI.IsReachable())
+}
+
+func TestPingTimeout(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	k8sAPI := newPingTestApi(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.Error(t, k8sAPI.Ping(ctx))
+	assert.Less(t, time.Since(start), DefaultPingTimeout)
+}
+
+func TestIsConnectedToClusterConcurrent(t *testing.T) {
+	wg := sync.WaitGroup{}
+	results := make([]bool, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = IsConnectedToCluster()
+			_ = GetK8sConfig()
+		}(i)
+	}
+	wg.Wait()
+	for i := range results {
+		assert.Equal(t, results[0], results[i])
+	}
+}
@@ -0,0 +1,203 @@
+package k8sinterface
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// WorkloadKinds are the kinds that run pods
+var WorkloadKinds = []string{"Pod", "Deployment", "ReplicaSet", "StatefulSet", "DaemonSet", "Job", "CronJob", "ReplicationController"}
+
+// CustomResourceDefinitionGroupVersionResource is the resource of the CustomResourceDefinitions
+var CustomResourceDefinitionGroupVersionResource = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// KindInfo is the group/version/resource mapping of a kind
+type KindInfo struct {
+	GroupVersionKind     schema.GroupVersionKind
+	GroupVersionResource schema.GroupVersionResource
+	Namespaced           bool
+}
+
+// setKindMapping adds the kind of the resource to the kind mapping, kinds already in the mapping are not overridden so the preferred version wins
+func setKindMapping(gv schema.GroupVersion, apiResource *metav1.APIResource) {
+	// subresources (e.g. deployments/scale) are not kinds of their own
+	if apiResource.Kind == "" || strings.Contains(apiResource.Name, "/") {
+		return
+	}
+	info := KindInfo{
+		GroupVersionKind:     gv.WithKind(apiResource.Kind),
+		GroupVersionResource: gv.WithResource(apiResource.Name),
+		Namespaced:           apiResource.Namespaced,
+	}
+	key := strings.ToLower(apiResource.Kind)
+	groupKey := groupKindKey(gv.Group, apiResource.Kind)
+	resourcesInfoLock.Lock()
+	defer resourcesInfoLock.Unlock()
+	if _, ok := groupKindMapping[groupKey]; !ok {
+		groupKindMapping[groupKey] = info
+	}
+	if _, ok := kindMapping[key]; ok {
+		return
+	}
+	kindMapping[key] = info
+}
+
+func groupKindKey(group, kind string) string {
+	return strings.ToLower(group + "/" + kind)
+}
+
+// GetKindInfo returns the mapping of the kind (case insensitive, e.g. "Deployment" or "deployment").
+// This is the single mapping of kinds to resources, do not hardcode copies of it elsewhere
+func GetKindInfo(kind string) (*KindInfo, bool) {
+	resourcesInfoLock.RLock()
+	kindMappingLength := len(kindMapping)
+	resourcesInfoLock.RUnlock()
+
+	if kindMappingLength == 0 {
+		InitializeMapResources(nil)
+	}
+	resourcesInfoLock.RLock()
+	defer resourcesInfoLock.RUnlock()
+	info, ok := kindMapping[strings.ToLower(kind)]
+	if !ok {
+		return nil, false
+	}
+	return &info, true
+}
+
+// GetGroupKindInfo returns the mapping of the kind of the group. Use it for kinds more than one group defines,
+// e.g. the Gateway of the Gateway API (gateway.networking.k8s.io) and the Gateway of Istio (networking.istio.io)
+func GetGroupKindInfo(group, kind string) (*KindInfo, bool) {
+	resourcesInfoLock.RLock()
+	kindMappingLength := len(kindMapping)
+	resourcesInfoLock.RUnlock()
+
+	if kindMappingLength == 0 {
+		InitializeMapResources(nil)
+	}
+	resourcesInfoLock.RLock()
+	defer resourcesInfoLock.RUnlock()
+	info, ok := groupKindMapping[groupKindKey(group, kind)]
+	if !ok {
+		return nil, false
+	}
+	return &info, true
+}
+
+// GVRForKind returns the group/version/resource of the kind
+func GVRForKind(kind string) (schema.GroupVersionResource, error) {
+	info, ok := GetKindInfo(kind)
+	if !ok {
+		return schema.GroupVersionResource{}, fmt.Errorf("%s. kind '%s' unknown. Make sure the kind is found at `kubectl api-resources`", ResourceNotFoundErr, kind)
+	}
+	return info.GroupVersionResource, nil
+}
+
+// GVKForKind returns the group/version/kind of the kind, e.g. "deployment" -> apps/v1, Kind=Deployment
+func GVKForKind(kind string) (schema.GroupVersionKind, error) {
+	info, ok := GetKindInfo(kind)
+	if !ok {
+		return schema.GroupVersionKind{}, fmt.Errorf("%s. kind '%s' unknown. Make sure the kind is found at `kubectl api-resources`", ResourceNotFoundErr, kind)
+	}
+	return info.GroupVersionKind, nil
+}
+
+// IsNamespaced returns true if the kind is namespaced. Unknown kinds are not namespaced
+func IsNamespaced(kind string) bool {
+	info, ok := GetKindInfo(kind)
+	return ok && info.Namespaced
+}
+
+// IsWorkloadKind returns true if the kind runs pods, see WorkloadKinds
+func IsWorkloadKind(kind string) bool {
+	for i := range WorkloadKinds {
+		if strings.EqualFold(WorkloadKinds[i], kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListKinds returns the mapping of all the known kinds, sorted by group and kind
+func ListKinds() []KindInfo {
+	GetResourceGroupMapping() // initialize the mapping if needed
+
+	resourcesInfoLock.RLock()
+	kinds := make([]KindInfo, 0, len(kindMapping))
+	for _, info := range kindMapping {
+		kinds = append(kinds, info)
+	}
+	resourcesInfoLock.RUnlock()
+
+	sort.Slice(kinds, func(i, j int) bool {
+		if kinds[i].GroupVersionKind.Group != kinds[j].GroupVersionKind.Group {
+			return kinds[i].GroupVersionKind.Group < kinds[j].GroupVersionKind.Group
+		}
+		return kinds[i].GroupVersionKind.Kind < kinds[j].GroupVersionKind.Kind
+	})
+	return kinds
+}
+
+// DiscoverCustomResources lists the CustomResourceDefinitions of the cluster and adds the served versions of their resources to the mapping,
+// so custom resources installed after the mapping was initialized can be queried. Resources already in the mapping are not overridden
+func (k8sAPI *KubernetesApi) DiscoverCustomResources(ctx context.Context) error {
+	crds, err := k8sAPI.DynamicClient.Resource(CustomResourceDefinitionGroupVersionResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to LIST CustomResourceDefinitions, reason: %s", err.Error())
+	}
+	GetResourceGroupMapping() // the mapping must be initialized first, see AddMapResources
+	AddMapResources(customResourceLists(crds.Items))
+	return nil
+}
+
+// customResourceLists returns the resource lists of the served versions of the CustomResourceDefinitions, the storage version first
+func customResourceLists(crds []unstructured.Unstructured) []*metav1.APIResourceList {
+	var resourceLists []*metav1.APIResourceList
+	for i := range crds {
+		group, _, _ := unstructured.NestedString(crds[i].Object, "spec", "group")
+		plural, _, _ := unstructured.NestedString(crds[i].Object, "spec", "names", "plural")
+		kind, _, _ := unstructured.NestedString(crds[i].Object, "spec", "names", "kind")
+		scope, _, _ := unstructured.NestedString(crds[i].Object, "spec", "scope")
+		versions, _, _ := unstructured.NestedSlice(crds[i].Object, "spec", "versions")
+		if group == "" || plural == "" || kind == "" {
+			continue
+		}
+
+		var served []string
+		for _, v := range versions {
+			version, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(version, "name")
+			isServed, _, _ := unstructured.NestedBool(version, "served")
+			isStorage, _, _ := unstructured.NestedBool(version, "storage")
+			if name == "" || !isServed {
+				continue
+			}
+			if isStorage {
+				served = append([]string{name}, served...)
+			} else {
+				served = append(served, name)
+			}
+		}
+		for _, version := range served {
+			resourceLists = append(resourceLists, &metav1.APIResourceList{
+				GroupVersion: JoinGroupVersion(group, version),
+				APIResources: []metav1.APIResource{{
+					Name:       plural,
+					Kind:       kind,
+					Namespaced: scope == "Namespaced",
+					Verbs:      metav1.Verbs{"create", "delete", "deletecollection", "get", "list", "patch", "update", "watch"},
+				}},
+			})
+		}
+	}
+	return resourceLists
+}
@@ -0,0 +1,156 @@
+package k8sinterface
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestGVRForKind(t *testing.T) {
+	InitializeMapResourcesMock()
+
+	gvr, err := GVRForKind("Deployment")
+	require.NoError(t, err)
+	assert.Equal(t, schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, gvr)
+
+	gvr, err = GVRForKind("pod")
+	require.NoError(t, err)
+	assert.Equal(t, schema.GroupVersionResource{Version: "v1", Resource: "pods"}, gvr)
+
+	gvk, err := GVKForKind("cronjob")
+	require.NoError(t, err)
+	assert.Equal(t, schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"}, gvk)
+
+	_, err = GVRForKind("NoSuchKind")
+	assert.Error(t, err)
+	_, ok := GetKindInfo("NoSuchKind")
+	assert.False(t, ok)
+}
+
+func TestIsNamespaced(t *testing.T) {
+	InitializeMapResourcesMock()
+
+	assert.True(t, IsNamespaced("Pod"))
+	assert.True(t, IsNamespaced("Role"))
+	assert.False(t, IsNamespaced("Node"))
+	assert.False(t, IsNamespaced("ClusterRole"))
+	assert.False(t, IsNamespaced("NoSuchKind"))
+}
+
+func TestIsWorkloadKind(t *testing.T) {
+	assert.True(t, IsWorkloadKind("Deployment"))
+	assert.True(t, IsWorkloadKind("cronjob"))
+	assert.True(t, IsWorkloadKind("Pod"))
+	assert.False(t, IsWorkloadKind("ConfigMap"))
+	assert.False(t, IsWorkloadKind(""))
+}
+
+func TestListKinds(t *testing.T) {
+	InitializeMapResourcesMock()
+
+	kinds := ListKinds()
+	require.NotEmpty(t, kinds)
+	assert.True(t, sort.SliceIsSorted(kinds, func(i, j int) bool {
+		if kinds[i].GroupVersionKind.Group != kinds[j].GroupVersionKind.Group {
+			return kinds[i].GroupVersionKind.Group < kinds[j].GroupVersionKind.Group
+		}
+		return kinds[i].GroupVersionKind.Kind < kinds[j].GroupVersionKind.Kind
+	}))
+}
+
+func newCustomResourceDefinition(group, kind, plural, scope string, versions ...map[string]interface{}) *unstructured.Unstructured {
+	versionList := make([]interface{}, len(versions))
+	for i := range versions {
+		versionList[i] = versions[i]
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": plural + "." + group},
+		"spec": map[string]interface{}{
+			"group":    group,
+			"scope":    scope,
+			"names":    map[string]interface{}{"kind": kind, "plural": plural},
+			"versions": versionList,
+		},
+	}}
+}
+
+func TestDiscoverCustomResources(t *testing.T) {
+	InitializeMapResourcesMock()
+
+	crd := newCustomResourceDefinition("kinds.example.io", "KindsTestWidget", "kindstestwidgets", "Namespaced",
+		map[string]interface{}{"name": "v1", "served": true, "storage": true},
+	)
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{CustomResourceDefinitionGroupVersionResource: "CustomResourceDefinitionList"},
+		crd,
+	)
+	k8sAPI := &KubernetesApi{DynamicClient: dynamicClient, Context: context.Background()}
+
+	require.NoError(t, k8sAPI.DiscoverCustomResources(context.Background()))
+
+	gvr, err := GVRForKind("KindsTestWidget")
+	require.NoError(t, err)
+	assert.Equal(t, schema.GroupVersionResource{Group: "kinds.example.io", Version: "v1", Resource: "kindstestwidgets"}, gvr)
+	assert.True(t, IsNamespaced("kindstestwidget"))
+	assert.False(t, IsWorkloadKind("KindsTestWidget"))
+
+	// the existing resource mapping knows the custom resource as well
+	resources, err := GetGroupVersionResource("kindstestwidgets")
+	require.NoError(t, err)
+	assert.Equal(t, gvr, resources)
+}
+
+func TestCustomResourceLists(t *testing.T) {
+	crd := newCustomResourceDefinition("example.io", "Gadget", "gadgets", "Cluster",
+		map[string]interface{}{"name": "v1alpha1", "served": true, "storage": false},
+		map[string]interface{}{"name": "v1beta1", "served": false, "storage": false},
+		map[string]interface{}{"name": "v1", "served": true, "storage": true},
+	)
+	invalid := newCustomResourceDefinition("", "Gadget", "gadgets", "Cluster")
+
+	resourceLists := customResourceLists([]unstructured.Unstructured{*crd, *invalid})
+	require.Len(t, resourceLists, 2)
+	assert.Equal(t, "example.io/v1", resourceLists[0].GroupVersion)
+	assert.Equal(t, "example.io/v1alpha1", resourceLists[1].GroupVersion)
+	assert.Equal(t, "gadgets", resourceLists[0].APIResources[0].Name)
+	assert.False(t, resourceLists[0].APIResources[0].Namespaced)
+}
+
+func TestGetGroupKindInfo(t *testing.T) {
+	InitializeMapResourcesMock()
+	AddMapResources([]*metav1.APIResourceList{
+		{
+			GroupVersion: "gateway.networking.k8s.io/v1beta1",
+			APIResources: []metav1.APIResource{{Name: "gateways", Kind: "Gateway", Namespaced: true, Verbs: metav1.Verbs{"list"}}},
+		},
+		{
+			GroupVersion: "networking.istio.io/v1beta1",
+			APIResources: []metav1.APIResource{{Name: "gateways", Kind: "Gateway", Namespaced: true, Verbs: metav1.Verbs{"list"}}},
+		},
+	})
+
+	info, ok := GetGroupKindInfo("networking.istio.io", "gateway")
+	require.True(t, ok)
+	assert.Equal(t, schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1beta1", Resource: "gateways"}, info.GroupVersionResource)
+
+	info, ok = GetGroupKindInfo("gateway.networking.k8s.io", "Gateway")
+	require.True(t, ok)
+	assert.Equal(t, "gateway.networking.k8s.io", info.GroupVersionKind.Group)
+
+	info, ok = GetGroupKindInfo("", "Pod")
+	require.True(t, ok)
+	assert.Equal(t, "pods", info.GroupVersionResource.Resource)
+
+	_, ok = GetGroupKindInfo("apps", "Pod")
+	assert.False(t, ok)
+}
@@ -0,0 +1,129 @@
+package k8sinterface
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	nodeProxyKubeletStatsSummary = "stats/summary"
+	nodeProxyKubeletPods         = "pods"
+)
+
+// KubeletStatsSummary is the subset of the kubelet stats summary (stats/v1alpha1 Summary) of the node and of its pods
+type KubeletStatsSummary struct {
+	Node KubeletNodeStats  `json:"node"`
+	Pods []KubeletPodStats `json:"pods"`
+}
+
+type KubeletNodeStats struct {
+	NodeName         string                  `json:"nodeName"`
+	StartTime        time.Time               `json:"startTime"`
+	SystemContainers []KubeletContainerStats `json:"systemContainers,omitempty"`
+	CPU              *KubeletCPUStats        `json:"cpu,omitempty"`
+	Memory           *KubeletMemoryStats     `json:"memory,omitempty"`
+	Fs               *KubeletFsStats         `json:"fs,omitempty"`
+	Runtime          *struct {
+		ImageFs *KubeletFsStats `json:"imageFs,omitempty"`
+	} `json:"runtime,omitempty"`
+	Rlimit *struct {
+		MaxPID  *int64 `json:"maxpid,omitempty"`
+		CurProc *int64 `json:"curproc,omitempty"`
+	} `json:"rlimit,omitempty"`
+}
+
+type KubeletPodStats struct {
+	PodRef struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+		UID       string `json:"uid"`
+	} `json:"podRef"`
+	StartTime        time.Time               `json:"startTime"`
+	Containers       []KubeletContainerStats `json:"containers"`
+	CPU              *KubeletCPUStats        `json:"cpu,omitempty"`
+	Memory           *KubeletMemoryStats     `json:"memory,omitempty"`
+	EphemeralStorage *KubeletFsStats         `json:"ephemeral-storage,omitempty"`
+	ProcessStats     *struct {
+		ProcessCount *uint64 `json:"process_count,omitempty"`
+	} `json:"process_stats,omitempty"`
+}
+
+type KubeletContainerStats struct {
+	Name      string              `json:"name"`
+	StartTime time.Time           `json:"startTime"`
+	CPU       *KubeletCPUStats    `json:"cpu,omitempty"`
+	Memory    *KubeletMemoryStats `json:"memory,omitempty"`
+	Rootfs    *KubeletFsStats     `json:"rootfs,omitempty"`
+	Logs      *KubeletFsStats     `json:"logs,omitempty"`
+}
+
+type KubeletCPUStats struct {
+	Time                 time.Time `json:"time"`
+	UsageNanoCores       *uint64   `json:"usageNanoCores,omitempty"`
+	UsageCoreNanoSeconds *uint64   `json:"usageCoreNanoSeconds,omitempty"`
+}
+
+type KubeletMemoryStats struct {
+	Time            time.Time `json:"time"`
+	AvailableBytes  *uint64   `json:"availableBytes,omitempty"`
+	UsageBytes      *uint64   `json:"usageBytes,omitempty"`
+	WorkingSetBytes *uint64   `json:"workingSetBytes,omitempty"`
+	RSSBytes        *uint64   `json:"rssBytes,omitempty"`
+	PageFaults      *uint64   `json:"pageFaults,omitempty"`
+	MajorPageFaults *uint64   `json:"majorPageFaults,omitempty"`
+}
+
+type KubeletFsStats struct {
+	Time           time.Time `json:"time"`
+	AvailableBytes *uint64   `json:"availableBytes,omitempty"`
+	CapacityBytes  *uint64   `json:"capacityBytes,omitempty"`
+	UsedBytes      *uint64   `json:"usedBytes,omitempty"`
+	InodesFree     *uint64   `json:"inodesFree,omitempty"`
+	Inodes         *uint64   `json:"inodes,omitempty"`
+	InodesUsed     *uint64   `json:"inodesUsed,omitempty"`
+}
+
+// GetNodeProxy returns the response of the kubelet endpoint of the node, e.g. healthz, through the node proxy of the API server.
+// The request is authenticated as the client of the KubernetesApi and requires the nodes/proxy permission
+func (k8sAPI *KubernetesApi) GetNodeProxy(nodeName, path string) ([]byte, error) {
+	ctx, span := k8sAPI.startSpan("k8s.GetNodeProxy", &schema.GroupVersionResource{Version: "v1", Resource: nodeResource}, "", nodeName)
+	data, err := k8sAPI.KubernetesClient.CoreV1().RESTClient().Get().Resource(nodeResource).Name(nodeName).SubResource("proxy").Suffix(strings.TrimPrefix(path, "/")).DoRaw(ctx)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GET kubelet %s of node '%s', reason: %s", path, nodeName, err.Error())
+	}
+	return data, nil
+}
+
+// GetKubeletStatsSummary returns the resource usage of the node and of its pods, from the /stats/summary endpoint of its kubelet
+func (k8sAPI *KubernetesApi) GetKubeletStatsSummary(nodeName string) (*KubeletStatsSummary, error) {
+	data, err := k8sAPI.GetNodeProxy(nodeName, nodeProxyKubeletStatsSummary)
+	if err != nil {
+		return nil, err
+	}
+	statsSummary := &KubeletStatsSummary{}
+	if err := json.Unmarshal(data, statsSummary); err != nil {
+		return nil, fmt.Errorf("failed to parse kubelet stats summary of node '%s', reason: %s", nodeName, err.Error())
+	}
+	return statsSummary, nil
+}
+
+// GetKubeletPods returns the pods the kubelet of the node runs, from its /pods endpoint. Unlike the API server,
+// the kubelet returns the static pods as they are defined on the node, even if their mirror pods were deleted
+func (k8sAPI *KubernetesApi) GetKubeletPods(nodeName string) (*corev1.PodList, error) {
+	data, err := k8sAPI.GetNodeProxy(nodeName, nodeProxyKubeletPods)
+	if err != nil {
+		return nil, err
+	}
+	pods := &corev1.PodList{}
+	if err := json.Unmarshal(data, pods); err != nil {
+		return nil, fmt.Errorf("failed to parse kubelet pods of node '%s', reason: %s", nodeName, err.Error())
+	}
+	return pods, nil
+}
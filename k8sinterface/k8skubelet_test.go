@@ -0,0 +1,92 @@
+package k8sinterface
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+// newNodeProxyServer returns a KubernetesApi of an API server serving the kubelet endpoints of node-1 on its node proxy
+func newNodeProxyServer(t *testing.T) *KubernetesApi {
+	responses := map[string]string{
+		"/api/v1/nodes/node-1/proxy/configz": `{"kubeletconfig":{"readOnlyPort":0,"authentication":{"anonymous":{"enabled":false}}}}`,
+		"/api/v1/nodes/node-1/proxy/stats/summary": `{
+			"node": {"nodeName": "node-1", "startTime": "2023-01-01T00:00:00Z", "cpu": {"time": "2023-01-02T00:00:00Z", "usageNanoCores": 250000000},
+				"memory": {"time": "2023-01-02T00:00:00Z", "workingSetBytes": 1073741824, "availableBytes": 3221225472}, "rlimit": {"maxpid": 4194304, "curproc": 512}},
+			"pods": [{"podRef": {"name": "nginx", "namespace": "default", "uid": "1111"}, "startTime": "2023-01-01T01:00:00Z",
+				"containers": [{"name": "nginx", "startTime": "2023-01-01T01:00:00Z", "memory": {"time": "2023-01-02T00:00:00Z", "workingSetBytes": 10485760}}],
+				"ephemeral-storage": {"time": "2023-01-02T00:00:00Z", "usedBytes": 4096}}]
+		}`,
+		"/api/v1/nodes/node-1/proxy/pods": `{"kind":"PodList","apiVersion":"v1","items":[{"metadata":{"name":"kube-apiserver-node-1","namespace":"kube-system",
+			"annotations":{"kubernetes.io/config.source":"file"}},"spec":{"containers":[{"name":"kube-apiserver","image":"registry.k8s.io/kube-apiserver:v1.25.3"}]}}]}`,
+		"/api/v1/nodes/node-1/proxy/healthz": `ok`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	client, err := kubernetes.NewForConfig(&restclient.Config{Host: server.URL})
+	require.NoError(t, err)
+	return &KubernetesApi{KubernetesClient: client, Context: context.Background()}
+}
+
+func TestGetNodeProxy(t *testing.T) {
+	k8sAPI := newNodeProxyServer(t)
+
+	data, err := k8sAPI.GetNodeProxy("node-1", "/healthz")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(data))
+
+	kubeletConfig, err := k8sAPI.GetKubeletConfig("node-1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), kubeletConfig.ReadOnlyPort)
+	require.NotNil(t, kubeletConfig.Authentication.Anonymous.Enabled)
+	assert.False(t, *kubeletConfig.Authentication.Anonymous.Enabled)
+
+	_, err = k8sAPI.GetNodeProxy("node-2", "healthz")
+	assert.ErrorContains(t, err, "failed to GET kubelet healthz of node 'node-2'")
+}
+
+func TestGetKubeletStatsSummary(t *testing.T) {
+	k8sAPI := newNodeProxyServer(t)
+
+	statsSummary, err := k8sAPI.GetKubeletStatsSummary("node-1")
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", statsSummary.Node.NodeName)
+	require.NotNil(t, statsSummary.Node.CPU)
+	assert.Equal(t, uint64(250000000), *statsSummary.Node.CPU.UsageNanoCores)
+	assert.Equal(t, uint64(1073741824), *statsSummary.Node.Memory.WorkingSetBytes)
+	require.NotNil(t, statsSummary.Node.Rlimit)
+	assert.Equal(t, int64(512), *statsSummary.Node.Rlimit.CurProc)
+	require.Len(t, statsSummary.Pods, 1)
+	assert.Equal(t, "nginx", statsSummary.Pods[0].PodRef.Name)
+	assert.Equal(t, uint64(10485760), *statsSummary.Pods[0].Containers[0].Memory.WorkingSetBytes)
+	assert.Equal(t, uint64(4096), *statsSummary.Pods[0].EphemeralStorage.UsedBytes)
+
+	_, err = k8sAPI.GetKubeletStatsSummary("node-2")
+	assert.Error(t, err)
+}
+
+func TestGetKubeletPods(t *testing.T) {
+	k8sAPI := newNodeProxyServer(t)
+
+	pods, err := k8sAPI.GetKubeletPods("node-1")
+	require.NoError(t, err)
+	require.Len(t, pods.Items, 1)
+	assert.Equal(t, "kube-apiserver-node-1", pods.Items[0].Name)
+	assert.Equal(t, "file", pods.Items[0].Annotations["kubernetes.io/config.source"])
+	component := NewControlPlaneComponent(&pods.Items[0])
+	require.NotNil(t, component)
+	assert.Equal(t, ControlPlaneAPIServer, component.Component)
+}
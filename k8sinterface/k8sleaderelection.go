@@ -0,0 +1,296 @@
+package k8sinterface
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	// DefaultLeaseDuration is the duration of the leases if LeaderElectionOptions.LeaseDuration or the duration of the LeaseLock is not set
+	DefaultLeaseDuration = 15 * time.Second
+	// DefaultLeaseRenewDeadline is the duration the leader retries to renew its lease before losing the leadership
+	DefaultLeaseRenewDeadline = 10 * time.Second
+	// DefaultLeaseRetryPeriod is the duration between two attempts to acquire or renew a lease
+	DefaultLeaseRetryPeriod = 2 * time.Second
+	// DefaultLeaseNamespace is the namespace of the leases if the namespace is not set
+	DefaultLeaseNamespace = "default"
+)
+
+// ErrLeadershipLost is returned by RunWithLeaderElection when the lease was lost before the function returned, use errors.Is
+var ErrLeadershipLost = errors.New("leader election lost")
+
+// LeaderElectionOptions configures RunWithLeaderElectionWithOptions
+type LeaderElectionOptions struct {
+	// Namespace is the namespace of the lease, DefaultLeaseNamespace if not set
+	Namespace string
+	// Identity is the holder identity of this instance, the hostname (the name of the pod) if not set. The identities of the instances must be unique
+	Identity string
+	// LeaseDuration, RenewDeadline and RetryPeriod are DefaultLeaseDuration, DefaultLeaseRenewDeadline and DefaultLeaseRetryPeriod if not set
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+	// OnNewLeader is called with the identity of the leader when it changes, including this instance
+	OnNewLeader func(identity string)
+}
+
+// RunWithLeaderElection runs the function once this instance is elected as the leader of the election id, the name of its lease.
+// See RunWithLeaderElectionWithOptions
+func (k8sAPI *KubernetesApi) RunWithLeaderElection(ctx context.Context, id string, fn func(ctx context.Context) error) error {
+	return k8sAPI.RunWithLeaderElectionWithOptions(ctx, id, fn, nil)
+}
+
+// RunWithLeaderElectionWithOptions waits until this instance holds the lease of the election id, then runs the function while renewing the lease.
+// The lease is released when the function returns, so another instance is elected without waiting for the lease to expire.
+// The context of the function is cancelled when the lease is lost, in which case ErrLeadershipLost is returned. The error of the function is returned otherwise
+func (k8sAPI *KubernetesApi) RunWithLeaderElectionWithOptions(ctx context.Context, id string, fn func(ctx context.Context) error, opts *LeaderElectionOptions) error {
+	if opts == nil {
+		opts = &LeaderElectionOptions{}
+	}
+	identity, err := leaseIdentity(opts.Identity)
+	if err != nil {
+		return err
+	}
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = DefaultLeaseNamespace
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// OnStartedLeading runs in a goroutine of the elector, which may start after Run returned: the function does not run once RunWithLeaderElection
+	// gave up (abandoned), and RunWithLeaderElection always waits for the function once it started
+	var startedLock sync.Mutex
+	started, abandoned := false, false
+	done := make(chan error, 1)
+	callbacks := leaderelection.LeaderCallbacks{
+		OnStartedLeading: func(leaderCtx context.Context) {
+			startedLock.Lock()
+			if abandoned {
+				startedLock.Unlock()
+				return
+			}
+			started = true
+			startedLock.Unlock()
+			done <- fn(leaderCtx)
+			// stops renewing and releases the lease
+			cancel()
+		},
+		OnStoppedLeading: func() {},
+	}
+	if opts.OnNewLeader != nil {
+		callbacks.OnNewLeader = opts.OnNewLeader
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta:  metav1.ObjectMeta{Namespace: namespace, Name: id},
+			Client:     k8sAPI.KubernetesClient.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+		},
+		LeaseDuration:   durationOrDefault(opts.LeaseDuration, DefaultLeaseDuration),
+		RenewDeadline:   durationOrDefault(opts.RenewDeadline, DefaultLeaseRenewDeadline),
+		RetryPeriod:     durationOrDefault(opts.RetryPeriod, DefaultLeaseRetryPeriod),
+		Callbacks:       callbacks,
+		ReleaseOnCancel: true,
+		Name:            id,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create the leader election '%s', reason: %s", id, err.Error())
+	}
+	elector.Run(runCtx)
+
+	startedLock.Lock()
+	if !started {
+		// the lease was not acquired, or the function did not start, before the context was done
+		abandoned = true
+		startedLock.Unlock()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return ErrLeadershipLost
+	}
+	startedLock.Unlock()
+	select {
+	case fnErr := <-done:
+		// the function returned before the lease was released
+		return fnErr
+	default:
+	}
+	// the lease was lost or the context was done, the context of the function is cancelled
+	<-done
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return ErrLeadershipLost
+}
+
+// LeaseLock is a distributed lock held by a single holder at a time, backed by a coordination.k8s.io Lease.
+// The lock expires if the holder does not renew it within its duration: the holder renews it by calling TryLock again
+type LeaseLock struct {
+	client      coordinationv1client.LeasesGetter
+	namespace   string
+	name        string
+	holder      string
+	duration    time.Duration
+	retryPeriod time.Duration
+}
+
+// NewLeaseLock returns the lock of the lease, which is created when the lock is first acquired.
+// The namespace is DefaultLeaseNamespace, the holder is the hostname and the duration is DefaultLeaseDuration if not set
+func (k8sAPI *KubernetesApi) NewLeaseLock(namespace, name, holder string, duration time.Duration) (*LeaseLock, error) {
+	holder, err := leaseIdentity(holder)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		namespace = DefaultLeaseNamespace
+	}
+	return &LeaseLock{
+		client:      k8sAPI.KubernetesClient.CoordinationV1(),
+		namespace:   namespace,
+		name:        name,
+		holder:      holder,
+		duration:    durationOrDefault(duration, DefaultLeaseDuration),
+		retryPeriod: DefaultLeaseRetryPeriod,
+	}, nil
+}
+
+// Holder returns the holder identity of this lock
+func (lock *LeaseLock) Holder() string {
+	return lock.holder
+}
+
+// TryLock acquires or renews the lock and returns true if it is held by this holder. False is returned without error if the lock is held by another holder
+// that has not expired, or if another holder acquired it concurrently
+func (lock *LeaseLock) TryLock(ctx context.Context) (bool, error) {
+	now := metav1.NewMicroTime(time.Now())
+	leaseDurationSeconds := int32(lock.duration.Round(time.Second) / time.Second)
+	if leaseDurationSeconds < 1 {
+		leaseDurationSeconds = 1
+	}
+
+	lease, err := lock.client.Leases(lock.namespace).Get(ctx, lock.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: lock.namespace, Name: lock.name},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &lock.holder,
+				LeaseDurationSeconds: &leaseDurationSeconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		if _, err := lock.client.Leases(lock.namespace).Create(ctx, lease, metav1.CreateOptions{}); err != nil {
+			if k8serrors.IsAlreadyExists(err) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to CREATE lease '%s/%s', reason: %s", lock.namespace, lock.name, err.Error())
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to GET lease '%s/%s', reason: %s", lock.namespace, lock.name, err.Error())
+	}
+
+	heldByUs := lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == lock.holder
+	if !heldByUs && !isLeaseExpired(lease, now.Time) {
+		return false, nil
+	}
+	if !heldByUs {
+		lease.Spec.AcquireTime = &now
+		transitions := int32(1)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions = *lease.Spec.LeaseTransitions + 1
+		}
+		lease.Spec.LeaseTransitions = &transitions
+	}
+	lease.Spec.HolderIdentity = &lock.holder
+	lease.Spec.LeaseDurationSeconds = &leaseDurationSeconds
+	lease.Spec.RenewTime = &now
+	// the update fails with a conflict if another holder updated the lease since it was read
+	if _, err := lock.client.Leases(lock.namespace).Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		if k8serrors.IsConflict(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to UPDATE lease '%s/%s', reason: %s", lock.namespace, lock.name, err.Error())
+	}
+	return true, nil
+}
+
+// Lock waits until the lock is acquired or the context is done
+func (lock *LeaseLock) Lock(ctx context.Context) error {
+	ticker := time.NewTicker(lock.retryPeriod)
+	defer ticker.Stop()
+	for {
+		acquired, err := lock.TryLock(ctx)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Unlock releases the lock if it is held by this holder, so another holder acquires it without waiting for it to expire
+func (lock *LeaseLock) Unlock(ctx context.Context) error {
+	lease, err := lock.client.Leases(lock.namespace).Get(ctx, lock.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to GET lease '%s/%s', reason: %s", lock.namespace, lock.name, err.Error())
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != lock.holder {
+		return nil
+	}
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.AcquireTime = nil
+	lease.Spec.RenewTime = nil
+	if _, err := lock.client.Leases(lock.namespace).Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to UPDATE lease '%s/%s', reason: %s", lock.namespace, lock.name, err.Error())
+	}
+	return nil
+}
+
+// isLeaseExpired returns true if the lease has no holder or was not renewed within its duration
+func isLeaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" || lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second).Before(now)
+}
+
+// leaseIdentity returns the identity, the hostname if empty
+func leaseIdentity(identity string) (string, error) {
+	if identity != "" {
+		return identity, nil
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the hostname for the lease holder identity, reason: %s", err.Error())
+	}
+	return hostname, nil
+}
+
+func durationOrDefault(duration, defaultDuration time.Duration) time.Duration {
+	if duration <= 0 {
+		return defaultDuration
+	}
+	return duration
+}
@@ -0,0 +1,152 @@
+package k8sinterface
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRunWithLeaderElection(t *testing.T) {
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesfake.NewSimpleClientset(), Context: context.Background()}
+	opts := &LeaderElectionOptions{Namespace: "kubescape", Identity: "scanner-0", LeaseDuration: time.Second, RenewDeadline: 500 * time.Millisecond, RetryPeriod: 100 * time.Millisecond}
+
+	// OnNewLeader is called asynchronously
+	leaders := make(chan string, 10)
+	opts.OnNewLeader = func(identity string) { leaders <- identity }
+	ran := false
+	err := k8sAPI.RunWithLeaderElectionWithOptions(context.Background(), "scan", func(ctx context.Context) error {
+		ran = true
+		lease, err := k8sAPI.KubernetesClient.CoordinationV1().Leases("kubescape").Get(ctx, "scan", metav1.GetOptions{})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "scanner-0", *lease.Spec.HolderIdentity)
+		}
+		return nil
+	}, opts)
+	require.NoError(t, err)
+	assert.True(t, ran)
+	select {
+	case leader := <-leaders:
+		assert.Equal(t, "scanner-0", leader)
+	case <-time.After(time.Second):
+		t.Error("OnNewLeader was not called")
+	}
+
+	// the lease is released when the function returns
+	lease, err := k8sAPI.KubernetesClient.CoordinationV1().Leases("kubescape").Get(context.Background(), "scan", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, lease.Spec.HolderIdentity)
+
+	// the error of the function is returned
+	fnErr := errors.New("scan failed")
+	err = k8sAPI.RunWithLeaderElectionWithOptions(context.Background(), "scan", func(context.Context) error { return fnErr }, opts)
+	assert.ErrorIs(t, err, fnErr)
+}
+
+func TestRunWithLeaderElectionCancelled(t *testing.T) {
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesfake.NewSimpleClientset(), Context: context.Background()}
+	opts := &LeaderElectionOptions{Identity: "scanner-0", LeaseDuration: time.Second, RenewDeadline: 500 * time.Millisecond, RetryPeriod: 100 * time.Millisecond}
+
+	// the context is done right after the lease is acquired: the function is waited for
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var finished int32
+	err := k8sAPI.RunWithLeaderElectionWithOptions(ctx, "scan", func(leaderCtx context.Context) error {
+		cancel()
+		<-leaderCtx.Done()
+		time.Sleep(50 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+		return nil
+	}, opts)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&finished))
+}
+
+func TestRunWithLeaderElectionLeaseHeld(t *testing.T) {
+	holder := "scanner-1"
+	leaseDurationSeconds := int32(60)
+	now := metav1.NewMicroTime(time.Now())
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: DefaultLeaseNamespace, Name: "scan"},
+		Spec:       coordinationv1.LeaseSpec{HolderIdentity: &holder, LeaseDurationSeconds: &leaseDurationSeconds, AcquireTime: &now, RenewTime: &now},
+	}
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesfake.NewSimpleClientset(lease), Context: context.Background()}
+
+	// the context is done before the lease held by another instance expires
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	err := k8sAPI.RunWithLeaderElectionWithOptions(ctx, "scan", func(context.Context) error {
+		t.Error("the function must not run")
+		return nil
+	}, &LeaderElectionOptions{Identity: "scanner-0", LeaseDuration: time.Second, RenewDeadline: 500 * time.Millisecond, RetryPeriod: 100 * time.Millisecond})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestLeaseLock(t *testing.T) {
+	ctx := context.Background()
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesfake.NewSimpleClientset(), Context: ctx}
+
+	first, err := k8sAPI.NewLeaseLock("kubescape", "writes", "scanner-0", time.Second)
+	require.NoError(t, err)
+	second, err := k8sAPI.NewLeaseLock("kubescape", "writes", "scanner-1", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "scanner-1", second.Holder())
+
+	acquired, err := first.TryLock(ctx)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// held by another holder
+	acquired, err = second.TryLock(ctx)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	// renewed by its holder
+	acquired, err = first.TryLock(ctx)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// unlocking a lock held by another holder does nothing
+	require.NoError(t, second.Unlock(ctx))
+	acquired, err = second.TryLock(ctx)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	require.NoError(t, first.Unlock(ctx))
+	acquired, err = second.TryLock(ctx)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	lease, err := k8sAPI.KubernetesClient.CoordinationV1().Leases("kubescape").Get(ctx, "writes", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "scanner-1", *lease.Spec.HolderIdentity)
+	assert.Equal(t, int32(1), *lease.Spec.LeaseTransitions)
+}
+
+func TestLeaseLockExpired(t *testing.T) {
+	holder := "scanner-1"
+	leaseDurationSeconds := int32(15)
+	renewTime := metav1.NewMicroTime(time.Now().Add(-time.Minute))
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: DefaultLeaseNamespace, Name: "writes"},
+		Spec:       coordinationv1.LeaseSpec{HolderIdentity: &holder, LeaseDurationSeconds: &leaseDurationSeconds, RenewTime: &renewTime},
+	}
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesfake.NewSimpleClientset(lease), Context: context.Background()}
+
+	lock, err := k8sAPI.NewLeaseLock("", "writes", "scanner-0", 0)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, lock.Lock(ctx))
+
+	assert.True(t, isLeaseExpired(lease, time.Now()))
+	renewTime = metav1.NewMicroTime(time.Now())
+	assert.False(t, isLeaseExpired(lease, time.Now()))
+}
@@ -0,0 +1,134 @@
+package k8sinterface
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DefaultPodLogsLimitBytes is the maximum size of the logs read by GetPodLogs if PodLogsOptions.LimitBytes is not set
+const DefaultPodLogsLimitBytes = 10 * 1024 * 1024
+
+// PodLogsOptions configures GetPodLogs
+type PodLogsOptions struct {
+	// TailLines is the number of lines from the end of the logs, all the lines if nil
+	TailLines *int64
+	// SinceTime returns the logs written at or after the time, all the logs if zero
+	SinceTime time.Time
+	// Previous returns the logs of the previous instance of the container, e.g. the one that crashed
+	Previous   bool
+	Timestamps bool
+	// Follow streams the logs until the container stops, ctx is canceled or LimitBytes is reached. Requires Output
+	Follow bool
+	// LimitBytes is the maximum size of the logs read, DefaultPodLogsLimitBytes if not set, unlimited if negative
+	LimitBytes int64
+	// Output receives the logs as they are read, instead of PodLogs.Logs
+	Output io.Writer
+}
+
+// PodLogs are the logs read by GetPodLogs
+type PodLogs struct {
+	// Logs are the logs read, nil if they were written to PodLogsOptions.Output
+	Logs []byte
+	// Bytes is the size of the logs read
+	Bytes int64
+	// Truncated is true if the logs were cut at PodLogsOptions.LimitBytes
+	Truncated bool
+}
+
+// GetPodLogs returns the logs of the container of the pod, the only container of the pod if empty
+func (k8sAPI *KubernetesApi) GetPodLogs(ctx context.Context, pod workloadinterface.IMetadata, container string, opts *PodLogsOptions) (*PodLogs, error) {
+	if opts == nil {
+		opts = &PodLogsOptions{}
+	}
+	if opts.Follow && opts.Output == nil {
+		return nil, fmt.Errorf("following the logs of pod '%s/%s' requires an output", pod.GetNamespace(), pod.GetName())
+	}
+	limitBytes := opts.LimitBytes
+	if limitBytes == 0 {
+		limitBytes = DefaultPodLogsLimitBytes
+	}
+	podLogOptions := &corev1.PodLogOptions{
+		Container:  container,
+		Follow:     opts.Follow,
+		Previous:   opts.Previous,
+		Timestamps: opts.Timestamps,
+		TailLines:  opts.TailLines,
+	}
+	if !opts.SinceTime.IsZero() {
+		sinceTime := metav1.NewTime(opts.SinceTime)
+		podLogOptions.SinceTime = &sinceTime
+	}
+	if limitBytes > 0 {
+		// one more byte than the limit to know if the logs were truncated
+		podLogOptions.LimitBytes = int64Ptr(limitBytes + 1)
+	}
+
+	ctx, span := startSpan(ctx, "k8s.GetPodLogs", &schema.GroupVersionResource{Version: "v1", Resource: "pods"}, pod.GetNamespace(), pod.GetName())
+	podLogs, err := k8sAPI.readPodLogs(ctx, pod, podLogOptions, limitBytes, opts.Output)
+	if podLogs != nil {
+		span.SetAttributes(tracing.AttributeCount.Int(int(podLogs.Bytes)))
+	}
+	tracing.EndSpan(span, err)
+	return podLogs, err
+}
+
+func (k8sAPI *KubernetesApi) readPodLogs(ctx context.Context, pod workloadinterface.IMetadata, podLogOptions *corev1.PodLogOptions, limitBytes int64, output io.Writer) (*PodLogs, error) {
+	stream, err := k8sAPI.KubernetesClient.CoreV1().Pods(pod.GetNamespace()).GetLogs(pod.GetName(), podLogOptions).Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GET logs of pod '%s/%s', reason: %s", pod.GetNamespace(), pod.GetName(), err.Error())
+	}
+	defer stream.Close()
+
+	podLogs := &PodLogs{}
+	var buffer *bytes.Buffer
+	if output == nil {
+		buffer = &bytes.Buffer{}
+		output = buffer
+	}
+	writer := &limitedWriter{writer: output, limited: limitBytes > 0, remaining: limitBytes}
+	_, err = io.Copy(writer, stream)
+	podLogs.Bytes, podLogs.Truncated = writer.written, writer.truncated
+	if buffer != nil {
+		podLogs.Logs = buffer.Bytes()
+	}
+	if err != nil && !writer.truncated && ctx.Err() == nil {
+		return podLogs, fmt.Errorf("failed to read logs of pod '%s/%s', reason: %s", pod.GetNamespace(), pod.GetName(), err.Error())
+	}
+	return podLogs, nil
+}
+
+// limitedWriter writes up to remaining bytes if limited, and fails the writes beyond to stop following the logs
+type limitedWriter struct {
+	writer    io.Writer
+	limited   bool
+	remaining int64
+	written   int64
+	truncated bool
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.limited && int64(len(p)) > w.remaining {
+		p = p[:w.remaining]
+		w.truncated = true
+	}
+	n, err := w.writer.Write(p)
+	w.written += int64(n)
+	w.remaining -= int64(n)
+	if err == nil && w.truncated {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
@@ -0,0 +1,71 @@
+package k8sinterface
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestGetPodLogs(t *testing.T) {
+	client := kubernetesfake.NewSimpleClientset()
+	var podLogOptions *corev1.PodLogOptions
+	client.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() == "log" {
+			podLogOptions = action.(k8stesting.GenericAction).GetValue().(*corev1.PodLogOptions)
+		}
+		return false, nil, nil
+	})
+	k8sAPI := &KubernetesApi{KubernetesClient: client, Context: context.Background()}
+	pod := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"namespace": "default", "name": "nginx"},
+	})
+
+	// the fake client returns "fake logs"
+	podLogs, err := k8sAPI.GetPodLogs(context.Background(), pod, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "fake logs", string(podLogs.Logs))
+	assert.Equal(t, int64(9), podLogs.Bytes)
+	assert.False(t, podLogs.Truncated)
+	require.NotNil(t, podLogOptions.LimitBytes)
+	assert.Equal(t, int64(DefaultPodLogsLimitBytes+1), *podLogOptions.LimitBytes)
+
+	tailLines := int64(100)
+	sinceTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	podLogs, err = k8sAPI.GetPodLogs(context.Background(), pod, "nginx", &PodLogsOptions{TailLines: &tailLines, SinceTime: sinceTime, Previous: true, LimitBytes: 4})
+	require.NoError(t, err)
+	assert.Equal(t, "fake", string(podLogs.Logs))
+	assert.True(t, podLogs.Truncated)
+	assert.Equal(t, "nginx", podLogOptions.Container)
+	assert.True(t, podLogOptions.Previous)
+	assert.Equal(t, &tailLines, podLogOptions.TailLines)
+	assert.True(t, sinceTime.Equal(podLogOptions.SinceTime.Time))
+
+	// exactly the limit
+	podLogs, err = k8sAPI.GetPodLogs(context.Background(), pod, "", &PodLogsOptions{LimitBytes: 9})
+	require.NoError(t, err)
+	assert.Equal(t, "fake logs", string(podLogs.Logs))
+	assert.False(t, podLogs.Truncated)
+
+	output := &bytes.Buffer{}
+	podLogs, err = k8sAPI.GetPodLogs(context.Background(), pod, "", &PodLogsOptions{Follow: true, Output: output, LimitBytes: -1})
+	require.NoError(t, err)
+	assert.Nil(t, podLogs.Logs)
+	assert.Equal(t, int64(9), podLogs.Bytes)
+	assert.Equal(t, "fake logs", output.String())
+	assert.True(t, podLogOptions.Follow)
+	assert.Nil(t, podLogOptions.LimitBytes)
+
+	_, err = k8sAPI.GetPodLogs(context.Background(), pod, "", &PodLogsOptions{Follow: true})
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+package k8sinterface
+
+import (
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/metadata"
+)
+
+// ListResourcesMetadataOnly lists only the metadata (names, labels, annotations, ownerReferences, etc.) of the resources, without the spec and status.
+// Use it instead of ListWorkloads when the objects content is not needed, the API server returns a fraction of the payload.
+// When listOptions.Limit is set, all pages are fetched
+func (k8sAPI *KubernetesApi) ListResourcesMetadataOnly(groupVersionResource *schema.GroupVersionResource, namespace string, listOptions metav1.ListOptions) ([]metav1.PartialObjectMetadata, error) {
+	if k8sAPI.MetadataClient == nil {
+		return nil, fmt.Errorf("failed to LIST resources metadata, reason: metadata client is not initialized")
+	}
+	resourceInterface := k8sAPI.metadataResourceInterface(groupVersionResource, namespace)
+
+	ctx, span := k8sAPI.startSpan("k8s.ListResourcesMetadataOnly", groupVersionResource, namespace, "")
+	var items []metav1.PartialObjectMetadata
+	for {
+		list, err := resourceInterface.List(ctx, listOptions)
+		if err != nil {
+			tracing.EndSpan(span, err)
+			return nil, fmt.Errorf("failed to LIST resources metadata, resource: '%s', reason: %s", groupVersionResource.String(), err.Error())
+		}
+		items = append(items, list.Items...)
+		if list.GetContinue() == "" {
+			break
+		}
+		listOptions.Continue = list.GetContinue()
+	}
+	span.SetAttributes(tracing.AttributeCount.Int(len(items)))
+	tracing.EndSpan(span, nil)
+	return items, nil
+}
+
+func (k8sAPI *KubernetesApi) metadataResourceInterface(resource *schema.GroupVersionResource, namespace string) metadata.ResourceInterface {
+	if IsNamespaceScope(resource) {
+		return k8sAPI.MetadataClient.Resource(*resource).Namespace(namespace)
+	}
+	return k8sAPI.MetadataClient.Resource(*resource)
+}
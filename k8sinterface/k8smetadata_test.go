@@ -0,0 +1,51 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	metadatafake "k8s.io/client-go/metadata/fake"
+)
+
+func newPartialObjectMetadata(apiVersion, kind, namespace, name string, labels map[string]string) *metav1.PartialObjectMetadata {
+	return &metav1.PartialObjectMetadata{
+		TypeMeta:   metav1.TypeMeta{APIVersion: apiVersion, Kind: kind},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+	}
+}
+
+func TestListResourcesMetadataOnly(t *testing.T) {
+	InitializeMapResourcesMock()
+	scheme := metadatafake.NewTestScheme()
+	metav1.AddMetaToScheme(scheme)
+	k8sAPI := &KubernetesApi{
+		MetadataClient: metadatafake.NewSimpleMetadataClient(scheme,
+			newPartialObjectMetadata("v1", "Pod", "default", "nginx", map[string]string{"app": "nginx"}),
+			newPartialObjectMetadata("v1", "Pod", "default", "redis", map[string]string{"app": "redis"}),
+			newPartialObjectMetadata("v1", "Pod", "kube-system", "coredns", nil),
+			newPartialObjectMetadata("v1", "Node", "", "node-1", nil),
+		),
+		Context: context.Background(),
+	}
+
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	items, err := k8sAPI.ListResourcesMetadataOnly(&pods, "", metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(items))
+
+	items, err = k8sAPI.ListResourcesMetadataOnly(&pods, "default", metav1.ListOptions{LabelSelector: "app=nginx"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(items))
+	assert.Equal(t, "nginx", items[0].GetName())
+
+	nodes := schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+	items, err = k8sAPI.ListResourcesMetadataOnly(&nodes, "", metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(items))
+
+	_, err = (&KubernetesApi{}).ListResourcesMetadataOnly(&pods, "", metav1.ListOptions{})
+	assert.Error(t, err)
+}
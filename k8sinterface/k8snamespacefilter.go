@@ -0,0 +1,167 @@
+package k8sinterface
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kubescape/k8s-interface/names"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// SystemNamespaces are the namespaces managed by Kubernetes itself
+var SystemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+// systemNamespacePrefixes are prefixes of namespaces managed by the distribution
+var systemNamespacePrefixes = []string{"openshift-"}
+
+// IsSystemNamespace returns true if the namespace is managed by Kubernetes or by the distribution.
+// This is the single definition of system namespaces, do not hardcode the list elsewhere
+func IsSystemNamespace(namespace string) bool {
+	if StringInSlice(SystemNamespaces, namespace) != ValueNotFound {
+		return true
+	}
+	for _, prefix := range systemNamespacePrefixes {
+		if strings.HasPrefix(namespace, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// NamespaceFilter selects namespaces by include/exclude lists and regexes.
+// A namespace matches if it is not excluded and, when any include is set, it is included.
+// A namespace explicitly listed in Include matches even if it is a system namespace. A nil filter matches all namespaces
+type NamespaceFilter struct {
+	Include              []string
+	Exclude              []string
+	IncludeRegex         []*regexp.Regexp
+	ExcludeRegex         []*regexp.Regexp
+	SkipSystemNamespaces bool
+}
+
+// ParseNamespaceFilter builds a filter from comma-separated include/exclude strings (e.g. "default,team-.*").
+// Entries that are valid namespace names are matched exactly, other entries are compiled as anchored regexes
+func ParseNamespaceFilter(include, exclude string, skipSystemNamespaces bool) (*NamespaceFilter, error) {
+	filter := &NamespaceFilter{SkipSystemNamespaces: skipSystemNamespaces}
+	var err error
+	if filter.Include, filter.IncludeRegex, err = parseNamespaceList(include); err != nil {
+		return nil, err
+	}
+	if filter.Exclude, filter.ExcludeRegex, err = parseNamespaceList(exclude); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func parseNamespaceList(s string) ([]string, []*regexp.Regexp, error) {
+	var namespaces []string
+	var regexes []*regexp.Regexp
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if names.ValidateDNS1123Label(entry) == nil {
+			namespaces = append(namespaces, entry)
+			continue
+		}
+		r, err := regexp.Compile("^(?:" + entry + ")$")
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid namespace pattern '%s': %w", entry, err)
+		}
+		regexes = append(regexes, r)
+	}
+	return namespaces, regexes, nil
+}
+
+// Matches returns true if the namespace passes the filter
+func (filter *NamespaceFilter) Matches(namespace string) bool {
+	if filter == nil {
+		return true
+	}
+	if StringInSlice(filter.Exclude, namespace) != ValueNotFound || matchAnyRegex(filter.ExcludeRegex, namespace) {
+		return false
+	}
+	if StringInSlice(filter.Include, namespace) != ValueNotFound {
+		return true
+	}
+	if filter.SkipSystemNamespaces && IsSystemNamespace(namespace) {
+		return false
+	}
+	if len(filter.Include) == 0 && len(filter.IncludeRegex) == 0 {
+		return true
+	}
+	return matchAnyRegex(filter.IncludeRegex, namespace)
+}
+
+// MatchesObject returns true if the object passes the filter. Cluster scoped objects (without a namespace) always pass
+func (filter *NamespaceFilter) MatchesObject(namespace string) bool {
+	return namespace == "" || filter.Matches(namespace)
+}
+
+// FilterWorkloads returns the workloads that pass the filter, cluster scoped workloads are kept
+func (filter *NamespaceFilter) FilterWorkloads(workloads []IWorkload) []IWorkload {
+	if filter == nil {
+		return workloads
+	}
+	filtered := make([]IWorkload, 0, len(workloads))
+	for i := range workloads {
+		if filter.MatchesObject(workloads[i].GetNamespace()) {
+			filtered = append(filtered, workloads[i])
+		}
+	}
+	return filtered
+}
+
+// listNamespace returns the namespace to list from the API server: the namespace when the filter selects exactly one namespace, otherwise all namespaces
+func (filter *NamespaceFilter) listNamespace() string {
+	if filter != nil && len(filter.Include) == 1 && len(filter.IncludeRegex) == 0 {
+		return filter.Include[0]
+	}
+	return ""
+}
+
+func matchAnyRegex(regexes []*regexp.Regexp, s string) bool {
+	for i := range regexes {
+		if regexes[i].MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListWorkloadsWithNamespaceFilter lists the resources in the namespaces selected by the filter, see ListWorkloads
+func (k8sAPI *KubernetesApi) ListWorkloadsWithNamespaceFilter(groupVersionResource *schema.GroupVersionResource, filter *NamespaceFilter, podLabels, fieldSelector map[string]string) ([]IWorkload, error) {
+	workloads, err := k8sAPI.ListWorkloads(groupVersionResource, filter.listNamespace(), podLabels, fieldSelector)
+	if err != nil {
+		return nil, err
+	}
+	return filter.FilterWorkloads(workloads), nil
+}
+
+// ListAllWorkloadWithNamespaceFilter lists all the resources in the namespaces selected by the filter, see ListAllWorkload
+func (k8sAPI *KubernetesApi) ListAllWorkloadWithNamespaceFilter(filter *NamespaceFilter) ([]IWorkload, error) {
+	workloads, err := k8sAPI.ListAllWorkload()
+	return filter.FilterWorkloads(workloads), err
+}
+
+// ListPodsWithNamespaceFilter lists the pods in the namespaces selected by the filter, see ListPods
+func (k8sAPI *KubernetesApi) ListPodsWithNamespaceFilter(filter *NamespaceFilter, podLabels map[string]string) (*corev1.PodList, error) {
+	pods, err := k8sAPI.ListPods(filter.listNamespace(), podLabels)
+	if err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		return pods, nil
+	}
+	items := make([]corev1.Pod, 0, len(pods.Items))
+	for i := range pods.Items {
+		if filter.Matches(pods.Items[i].GetNamespace()) {
+			items = append(items, pods.Items[i])
+		}
+	}
+	pods.Items = items
+	return pods, nil
+}
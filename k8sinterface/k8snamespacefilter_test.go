@@ -0,0 +1,117 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestIsSystemNamespace(t *testing.T) {
+	assert.True(t, IsSystemNamespace("kube-system"))
+	assert.True(t, IsSystemNamespace("kube-node-lease"))
+	assert.True(t, IsSystemNamespace("openshift-monitoring"))
+	assert.False(t, IsSystemNamespace("default"))
+	assert.False(t, IsSystemNamespace("kubescape"))
+}
+
+func TestNamespaceFilterMatches(t *testing.T) {
+	tests := []struct {
+		name       string
+		include    string
+		exclude    string
+		skipSystem bool
+		matches    []string
+		notMatches []string
+	}{
+		{
+			name:    "empty filter",
+			matches: []string{"default", "kube-system"},
+		},
+		{
+			name:       "include list",
+			include:    "default, kubescape",
+			matches:    []string{"default", "kubescape"},
+			notMatches: []string{"kube-system", "team-a"},
+		},
+		{
+			name:       "include regex",
+			include:    "team-.*",
+			matches:    []string{"team-a", "team-b"},
+			notMatches: []string{"default", "my-team-a"},
+		},
+		{
+			name:       "exclude list and regex",
+			exclude:    "default,team-.*",
+			matches:    []string{"kube-system", "kubescape"},
+			notMatches: []string{"default", "team-a"},
+		},
+		{
+			name:       "exclude wins over include",
+			include:    "team-.*",
+			exclude:    "team-b",
+			matches:    []string{"team-a"},
+			notMatches: []string{"team-b"},
+		},
+		{
+			name:       "skip system namespaces",
+			skipSystem: true,
+			matches:    []string{"default"},
+			notMatches: []string{"kube-system", "kube-public", "openshift-etcd"},
+		},
+		{
+			name:       "explicit include of a system namespace",
+			include:    "kube-system,kube-.*",
+			skipSystem: true,
+			matches:    []string{"kube-system"},
+			notMatches: []string{"kube-public"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := ParseNamespaceFilter(tt.include, tt.exclude, tt.skipSystem)
+			assert.NoError(t, err)
+			for _, ns := range tt.matches {
+				assert.True(t, filter.Matches(ns), ns)
+			}
+			for _, ns := range tt.notMatches {
+				assert.False(t, filter.Matches(ns), ns)
+			}
+		})
+	}
+
+	_, err := ParseNamespaceFilter("team-[", "", false)
+	assert.Error(t, err)
+
+	var nilFilter *NamespaceFilter
+	assert.True(t, nilFilter.Matches("kube-system"))
+}
+
+func TestListPodsWithNamespaceFilter(t *testing.T) {
+	k8sAPI := &KubernetesApi{
+		KubernetesClient: kubernetesfake.NewSimpleClientset(
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"}},
+			&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team-a"}},
+		),
+		Context: context.Background(),
+	}
+
+	pods, err := k8sAPI.ListPodsWithNamespaceFilter(&NamespaceFilter{SkipSystemNamespaces: true}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(pods.Items))
+
+	filter, err := ParseNamespaceFilter("team-a", "", false)
+	assert.NoError(t, err)
+	pods, err = k8sAPI.ListPodsWithNamespaceFilter(filter, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(pods.Items))
+	assert.Equal(t, "app", pods.Items[0].GetName())
+
+	pods, err = k8sAPI.ListPodsWithNamespaceFilter(nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(pods.Items))
+}
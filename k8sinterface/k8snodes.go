@@ -0,0 +1,233 @@
+package k8sinterface
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+
+	// labels of the nodes, with the deprecated beta labels older clusters and cloud providers still set
+	topologyRegionLabel     = "topology.kubernetes.io/region"
+	topologyZoneLabel       = "topology.kubernetes.io/zone"
+	instanceTypeLabel       = "node.kubernetes.io/instance-type"
+	betaRegionLabel         = "failure-domain.beta.kubernetes.io/region"
+	betaZoneLabel           = "failure-domain.beta.kubernetes.io/zone"
+	betaInstanceTypeLabel   = "beta.kubernetes.io/instance-type"
+	hostnameLabel           = "kubernetes.io/hostname"
+	nodeGroupLabelEKS       = "eks.amazonaws.com/nodegroup"
+	nodePoolLabelAKS        = "kubernetes.azure.com/agentpool"
+	nodePoolLabelGKE        = "cloud.google.com/gke-nodepool"
+	nodeResource            = "nodes"
+	nodeProxyKubeletConfigz = "configz"
+)
+
+// NodeInfo is the metadata of a node relevant to the node hardening controls
+type NodeInfo struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	// Roles are the node-role.kubernetes.io/<role> labels, e.g. control-plane
+	Roles        []string `json:"roles,omitempty"`
+	Region       string   `json:"region,omitempty"`
+	Zone         string   `json:"zone,omitempty"`
+	InstanceType string   `json:"instanceType,omitempty"`
+	// NodePool is the EKS node group, AKS agent pool or GKE node pool of the node
+	NodePool      string         `json:"nodePool,omitempty"`
+	Hostname      string         `json:"hostname,omitempty"`
+	ProviderID    string         `json:"providerID,omitempty"`
+	Taints        []corev1.Taint `json:"taints,omitempty"`
+	Unschedulable bool           `json:"unschedulable"`
+	Ready         bool           `json:"ready"`
+	InternalIPs   []string       `json:"internalIPs,omitempty"`
+	ExternalIPs   []string       `json:"externalIPs,omitempty"`
+
+	KubeletVersion   string `json:"kubeletVersion"`
+	KubeProxyVersion string `json:"kubeProxyVersion,omitempty"`
+	// ContainerRuntime/ContainerRuntimeVersion are parsed from the runtime version of the node info, e.g. containerd://1.6.8
+	ContainerRuntime        string `json:"containerRuntime"`
+	ContainerRuntimeVersion string `json:"containerRuntimeVersion"`
+	KernelVersion           string `json:"kernelVersion,omitempty"`
+	OSImage                 string `json:"osImage,omitempty"`
+	OperatingSystem         string `json:"operatingSystem"`
+	Architecture            string `json:"architecture"`
+
+	Capacity    corev1.ResourceList `json:"capacity,omitempty"`
+	Allocatable corev1.ResourceList `json:"allocatable,omitempty"`
+
+	// KubeletConfig is the configuration of the kubelet, nil if not fetched or if fetching failed (see KubeletConfigError)
+	KubeletConfig      *KubeletConfig `json:"kubeletConfig,omitempty"`
+	KubeletConfigError string         `json:"kubeletConfigError,omitempty"`
+}
+
+// KubeletConfig is the subset of the kubelet configuration (kubelet.config.k8s.io KubeletConfiguration) the node hardening controls check.
+// Raw is the full configuration
+type KubeletConfig struct {
+	Authentication                 KubeletAuthentication  `json:"authentication"`
+	Authorization                  KubeletAuthorization   `json:"authorization"`
+	ReadOnlyPort                   int32                  `json:"readOnlyPort"`
+	Port                           int32                  `json:"port,omitempty"`
+	ProtectKernelDefaults          bool                   `json:"protectKernelDefaults"`
+	MakeIPTablesUtilChains         *bool                  `json:"makeIPTablesUtilChains,omitempty"`
+	EventRecordQPS                 *int32                 `json:"eventRecordQPS,omitempty"`
+	StreamingConnectionIdleTimeout string                 `json:"streamingConnectionIdleTimeout,omitempty"`
+	RotateCertificates             bool                   `json:"rotateCertificates"`
+	ServerTLSBootstrap             bool                   `json:"serverTLSBootstrap"`
+	TLSCertFile                    string                 `json:"tlsCertFile,omitempty"`
+	TLSPrivateKeyFile              string                 `json:"tlsPrivateKeyFile,omitempty"`
+	TLSCipherSuites                []string               `json:"tlsCipherSuites,omitempty"`
+	TLSMinVersion                  string                 `json:"tlsMinVersion,omitempty"`
+	SeccompDefault                 bool                   `json:"seccompDefault"`
+	FeatureGates                   map[string]bool        `json:"featureGates,omitempty"`
+	Raw                            map[string]interface{} `json:"-"`
+}
+
+type KubeletAuthentication struct {
+	Anonymous struct {
+		Enabled *bool `json:"enabled,omitempty"`
+	} `json:"anonymous"`
+	Webhook struct {
+		Enabled *bool `json:"enabled,omitempty"`
+	} `json:"webhook"`
+	X509 struct {
+		ClientCAFile string `json:"clientCAFile,omitempty"`
+	} `json:"x509"`
+}
+
+type KubeletAuthorization struct {
+	// Mode is AlwaysAllow or Webhook
+	Mode string `json:"mode"`
+}
+
+// ParseKubeletConfigz returns the kubelet configuration of the response of the kubelet /configz endpoint
+func ParseKubeletConfigz(data []byte) (*KubeletConfig, error) {
+	configz := struct {
+		KubeletConfig json.RawMessage `json:"kubeletconfig"`
+	}{}
+	if err := json.Unmarshal(data, &configz); err != nil {
+		return nil, err
+	}
+	if len(configz.KubeletConfig) == 0 {
+		return nil, fmt.Errorf("no kubeletconfig in configz response")
+	}
+	kubeletConfig := &KubeletConfig{}
+	if err := json.Unmarshal(configz.KubeletConfig, kubeletConfig); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(configz.KubeletConfig, &kubeletConfig.Raw); err != nil {
+		return nil, err
+	}
+	return kubeletConfig, nil
+}
+
+// NewNodeInfo returns the metadata of the node
+func NewNodeInfo(node *corev1.Node) *NodeInfo {
+	labels := node.GetLabels()
+	nodeInfo := &NodeInfo{
+		Name:             node.GetName(),
+		Labels:           labels,
+		Region:           firstLabel(labels, topologyRegionLabel, betaRegionLabel),
+		Zone:             firstLabel(labels, topologyZoneLabel, betaZoneLabel),
+		InstanceType:     firstLabel(labels, instanceTypeLabel, betaInstanceTypeLabel),
+		NodePool:         firstLabel(labels, nodeGroupLabelEKS, nodePoolLabelAKS, nodePoolLabelGKE),
+		Hostname:         labels[hostnameLabel],
+		ProviderID:       node.Spec.ProviderID,
+		Taints:           node.Spec.Taints,
+		Unschedulable:    node.Spec.Unschedulable,
+		KubeletVersion:   node.Status.NodeInfo.KubeletVersion,
+		KubeProxyVersion: node.Status.NodeInfo.KubeProxyVersion,
+		KernelVersion:    node.Status.NodeInfo.KernelVersion,
+		OSImage:          node.Status.NodeInfo.OSImage,
+		OperatingSystem:  node.Status.NodeInfo.OperatingSystem,
+		Architecture:     node.Status.NodeInfo.Architecture,
+		Capacity:         node.Status.Capacity,
+		Allocatable:      node.Status.Allocatable,
+	}
+	for label := range labels {
+		if strings.HasPrefix(label, nodeRoleLabelPrefix) {
+			nodeInfo.Roles = append(nodeInfo.Roles, strings.TrimPrefix(label, nodeRoleLabelPrefix))
+		}
+	}
+	sort.Strings(nodeInfo.Roles)
+
+	nodeInfo.ContainerRuntime, nodeInfo.ContainerRuntimeVersion = parseContainerRuntimeVersion(node.Status.NodeInfo.ContainerRuntimeVersion)
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			nodeInfo.Ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+	for _, address := range node.Status.Addresses {
+		switch address.Type {
+		case corev1.NodeInternalIP:
+			nodeInfo.InternalIPs = append(nodeInfo.InternalIPs, address.Address)
+		case corev1.NodeExternalIP:
+			nodeInfo.ExternalIPs = append(nodeInfo.ExternalIPs, address.Address)
+		}
+	}
+	return nodeInfo
+}
+
+// HasTaint returns true if the node has a taint with the key and effect. An empty effect matches any effect
+func (nodeInfo *NodeInfo) HasTaint(key string, effect corev1.TaintEffect) bool {
+	for i := range nodeInfo.Taints {
+		if nodeInfo.Taints[i].Key == key && (effect == "" || nodeInfo.Taints[i].Effect == effect) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListNodesInfo returns the metadata of the nodes of the cluster. If kubeletConfig is set, the configuration of the kubelet of each node is fetched
+// through the node proxy of the API server (requires the nodes/proxy permission), a node failing to return it has KubeletConfigError set
+func (k8sAPI *KubernetesApi) ListNodesInfo(kubeletConfig bool) ([]NodeInfo, error) {
+	ctx, span := k8sAPI.startSpan("k8s.ListNodes", &schema.GroupVersionResource{Version: "v1", Resource: nodeResource}, "", "")
+	nodes, err := k8sAPI.KubernetesClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST nodes, reason: %s", err.Error())
+	}
+	nodesInfo := make([]NodeInfo, 0, len(nodes.Items))
+	for i := range nodes.Items {
+		nodeInfo := NewNodeInfo(&nodes.Items[i])
+		if kubeletConfig {
+			if nodeInfo.KubeletConfig, err = k8sAPI.GetKubeletConfig(nodeInfo.Name); err != nil {
+				nodeInfo.KubeletConfigError = err.Error()
+			}
+		}
+		nodesInfo = append(nodesInfo, *nodeInfo)
+	}
+	return nodesInfo, nil
+}
+
+// GetKubeletConfig returns the configuration of the kubelet of the node, from its /configz endpoint through the node proxy of the API server
+func (k8sAPI *KubernetesApi) GetKubeletConfig(nodeName string) (*KubeletConfig, error) {
+	data, err := k8sAPI.GetNodeProxy(nodeName, nodeProxyKubeletConfigz)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKubeletConfigz(data)
+}
+
+// parseContainerRuntimeVersion splits <runtime>://<version>, e.g. containerd://1.6.8
+func parseContainerRuntimeVersion(runtimeVersion string) (string, string) {
+	if runtime, version, ok := strings.Cut(runtimeVersion, "://"); ok {
+		return runtime, version
+	}
+	return runtimeVersion, ""
+}
+
+func firstLabel(labels map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := labels[key]; ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestNode() *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "ip-10-0-1-12",
+			Labels: map[string]string{
+				"topology.kubernetes.io/region":    "eu-west-1",
+				"topology.kubernetes.io/zone":      "eu-west-1a",
+				"beta.kubernetes.io/instance-type": "m5.large",
+				"eks.amazonaws.com/nodegroup":      "workers",
+				"kubernetes.io/hostname":           "ip-10-0-1-12",
+				"node-role.kubernetes.io/worker":   "",
+				"node-role.kubernetes.io/ingress":  "",
+			},
+		},
+		Spec: corev1.NodeSpec{
+			ProviderID: "aws:///eu-west-1a/i-0123456789",
+			Taints:     []corev1.Taint{{Key: "dedicated", Value: "ingress", Effect: corev1.TaintEffectNoSchedule}},
+		},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			NodeInfo: corev1.NodeSystemInfo{
+				KubeletVersion:          "v1.25.3-eks-1",
+				ContainerRuntimeVersion: "containerd://1.6.8",
+				OperatingSystem:         "linux",
+				Architecture:            "amd64",
+			},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.1.12"},
+				{Type: corev1.NodeExternalIP, Address: "54.1.2.3"},
+			},
+		},
+	}
+}
+
+func TestNewNodeInfo(t *testing.T) {
+	nodeInfo := NewNodeInfo(newTestNode())
+	assert.Equal(t, "eu-west-1", nodeInfo.Region)
+	assert.Equal(t, "eu-west-1a", nodeInfo.Zone)
+	assert.Equal(t, "m5.large", nodeInfo.InstanceType, "falls back to the beta label")
+	assert.Equal(t, "workers", nodeInfo.NodePool)
+	assert.Equal(t, []string{"ingress", "worker"}, nodeInfo.Roles)
+	assert.Equal(t, "containerd", nodeInfo.ContainerRuntime)
+	assert.Equal(t, "1.6.8", nodeInfo.ContainerRuntimeVersion)
+	assert.True(t, nodeInfo.Ready)
+	assert.Equal(t, []string{"10.0.1.12"}, nodeInfo.InternalIPs)
+	assert.Equal(t, []string{"54.1.2.3"}, nodeInfo.ExternalIPs)
+	assert.True(t, nodeInfo.HasTaint("dedicated", ""))
+	assert.False(t, nodeInfo.HasTaint("dedicated", corev1.TaintEffectNoExecute))
+}
+
+func TestListNodesInfo(t *testing.T) {
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesfake.NewSimpleClientset(newTestNode()), Context: context.Background()}
+	nodesInfo, err := k8sAPI.ListNodesInfo(false)
+	require.NoError(t, err)
+	require.Len(t, nodesInfo, 1)
+	assert.Equal(t, "v1.25.3-eks-1", nodesInfo[0].KubeletVersion)
+	assert.Nil(t, nodesInfo[0].KubeletConfig)
+}
+
+func TestParseKubeletConfigz(t *testing.T) {
+	kubeletConfig, err := ParseKubeletConfigz([]byte(`{"kubeletconfig":{"authentication":{"anonymous":{"enabled":false},"webhook":{"enabled":true},"x509":{"clientCAFile":"/etc/kubernetes/pki/ca.crt"}},"authorization":{"mode":"Webhook"},"readOnlyPort":10255,"protectKernelDefaults":true,"eventRecordQPS":5,"tlsCipherSuites":["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"],"cgroupDriver":"systemd"}}`))
+	require.NoError(t, err)
+	require.NotNil(t, kubeletConfig.Authentication.Anonymous.Enabled)
+	assert.False(t, *kubeletConfig.Authentication.Anonymous.Enabled)
+	assert.True(t, *kubeletConfig.Authentication.Webhook.Enabled)
+	assert.Equal(t, "/etc/kubernetes/pki/ca.crt", kubeletConfig.Authentication.X509.ClientCAFile)
+	assert.Equal(t, "Webhook", kubeletConfig.Authorization.Mode)
+	assert.Equal(t, int32(10255), kubeletConfig.ReadOnlyPort)
+	assert.True(t, kubeletConfig.ProtectKernelDefaults)
+	assert.Equal(t, int32(5), *kubeletConfig.EventRecordQPS)
+	assert.Nil(t, kubeletConfig.MakeIPTablesUtilChains)
+	assert.Equal(t, "systemd", kubeletConfig.Raw["cgroupDriver"])
+
+	_, err = ParseKubeletConfigz([]byte(`{}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,164 @@
+package k8sinterface
+
+import (
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	// OpenShiftRouteGroupVersionResource is the resource of the OpenShift Routes, the OpenShift predecessor of Ingresses
+	OpenShiftRouteGroupVersionResource = schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}
+	// SecurityContextConstraintsGroupVersionResource is the resource of the cluster scoped OpenShift SecurityContextConstraints
+	SecurityContextConstraintsGroupVersionResource = schema.GroupVersionResource{Group: "security.openshift.io", Version: "v1", Resource: "securitycontextconstraints"}
+	// ProjectGroupVersionResource is the resource of the OpenShift projects, each project is a namespace of the same name
+	ProjectGroupVersionResource = schema.GroupVersionResource{Group: "project.openshift.io", Version: "v1", Resource: "projects"}
+)
+
+// annotations OpenShift sets on the namespace of a project
+const (
+	projectDisplayNameAnnotation = "openshift.io/display-name"
+	projectDescriptionAnnotation = "openshift.io/description"
+	projectRequesterAnnotation   = "openshift.io/requester"
+)
+
+// OpenShiftRouteExposure is an OpenShift Route to a Service of the workload. Routes only route to Services of their own namespace
+type OpenShiftRouteExposure struct {
+	Name        string `json:"name"`
+	ServiceName string `json:"serviceName"`
+	Host        string `json:"host,omitempty"`
+	Path        string `json:"path,omitempty"`
+	// TLSTermination is one of edge/passthrough/reencrypt, empty if the route is not secured
+	TLSTermination string `json:"tlsTermination,omitempty"`
+	// RouterHostnames are the canonical hostnames of the routers that admitted the route
+	RouterHostnames []string `json:"routerHostnames,omitempty"`
+}
+
+// Project is the OpenShift view of a namespace
+type Project struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Requester is the user who requested the project, empty if the namespace was not created as a project
+	Requester string `json:"requester,omitempty"`
+}
+
+// NewProject returns the project of the namespace
+func NewProject(namespace *corev1.Namespace) *Project {
+	annotations := namespace.GetAnnotations()
+	return &Project{
+		Name:        namespace.GetName(),
+		DisplayName: annotations[projectDisplayNameAnnotation],
+		Description: annotations[projectDescriptionAnnotation],
+		Requester:   annotations[projectRequesterAnnotation],
+	}
+}
+
+// NamespaceTerm returns how the distribution names namespaces to its users, "project" on OpenShift and "namespace" otherwise
+func NamespaceTerm(distribution Distribution) string {
+	if distribution == DistributionOpenShift {
+		return "project"
+	}
+	return "namespace"
+}
+
+// IsOpenShift returns true if the API server serves the OpenShift projects. Unlike the node labels, this does not require permissions on the nodes
+func (k8sAPI *KubernetesApi) IsOpenShift() (bool, error) {
+	return k8sAPI.SupportsResource(ProjectGroupVersionResource)
+}
+
+// ListSecurityContextConstraints returns the SecurityContextConstraints of the cluster, see workloadinterface.NewSecurityContextConstraints for their typed view.
+// Returns no SCCs if the cluster is not OpenShift
+func (k8sAPI *KubernetesApi) ListSecurityContextConstraints() ([]IWorkload, error) {
+	ctx, span := k8sAPI.startSpan("k8s.ListSecurityContextConstraints", &SecurityContextConstraintsGroupVersionResource, "", "")
+	list, err := k8sAPI.DynamicClient.Resource(SecurityContextConstraintsGroupVersionResource).List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return []IWorkload{}, nil
+		}
+		return nil, fmt.Errorf("failed to LIST securitycontextconstraints, reason: %s", err.Error())
+	}
+	sccs := make([]IWorkload, 0, len(list.Items))
+	for i := range list.Items {
+		sccs = append(sccs, workloadinterface.NewWorkloadObj(list.Items[i].Object))
+	}
+	return sccs, nil
+}
+
+// listOpenShiftRoutes lists the Routes of the namespace, no Routes if the cluster does not serve them
+func (k8sAPI *KubernetesApi) listOpenShiftRoutes(namespace string) ([]unstructured.Unstructured, error) {
+	info, ok := GetGroupKindInfo(OpenShiftRouteGroupVersionResource.Group, "Route")
+	if !ok {
+		return nil, nil
+	}
+	ctx, span := k8sAPI.startSpan("k8s.ListRoutes", &info.GroupVersionResource, namespace, "")
+	list, err := k8sAPI.DynamicClient.Resource(info.GroupVersionResource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST routes, reason: %s", err.Error())
+	}
+	return list.Items, nil
+}
+
+// newOpenShiftRouteExposures returns an exposure per Service of the workload the Route routes to, through spec.to or spec.alternateBackends
+func newOpenShiftRouteExposures(route *unstructured.Unstructured, serviceNames map[string]bool) []OpenShiftRouteExposure {
+	var backends []interface{}
+	if to, ok, _ := unstructured.NestedMap(route.Object, "spec", "to"); ok {
+		backends = append(backends, to)
+	}
+	alternateBackends, _, _ := unstructured.NestedSlice(route.Object, "spec", "alternateBackends")
+	backends = append(backends, alternateBackends...)
+
+	host, _, _ := unstructured.NestedString(route.Object, "spec", "host")
+	path, _, _ := unstructured.NestedString(route.Object, "spec", "path")
+	termination, _, _ := unstructured.NestedString(route.Object, "spec", "tls", "termination")
+	routerHostnames := routeRouterHostnames(route)
+
+	var exposures []OpenShiftRouteExposure
+	seen := map[string]bool{}
+	for i := range backends {
+		backend, ok := backends[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if kind, _, _ := unstructured.NestedString(backend, "kind"); kind != "" && kind != "Service" {
+			continue
+		}
+		serviceName, _, _ := unstructured.NestedString(backend, "name")
+		if !serviceNames[serviceName] || seen[serviceName] {
+			continue
+		}
+		seen[serviceName] = true
+		exposures = append(exposures, OpenShiftRouteExposure{
+			Name:            route.GetName(),
+			ServiceName:     serviceName,
+			Host:            host,
+			Path:            path,
+			TLSTermination:  termination,
+			RouterHostnames: routerHostnames,
+		})
+	}
+	return exposures
+}
+
+func routeRouterHostnames(route *unstructured.Unstructured) []string {
+	var hostnames []string
+	ingresses, _, _ := unstructured.NestedSlice(route.Object, "status", "ingress")
+	for i := range ingresses {
+		ingress, ok := ingresses[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if hostname, _, _ := unstructured.NestedString(ingress, "routerCanonicalHostname"); hostname != "" && !contains(hostnames, hostname) {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	return hostnames
+}
@@ -0,0 +1,142 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestIsOpenShift(t *testing.T) {
+	client := kubernetesfake.NewSimpleClientset()
+	discoveryClient := client.Discovery().(*fakediscovery.FakeDiscovery)
+	discoveryClient.FakedServerVersion = &version.Info{Major: "1", Minor: "25", GitVersion: "v1.25.7+eb9cd9f"}
+	k8sAPI := &KubernetesApi{KubernetesClient: client, DiscoveryClient: discoveryClient, Context: context.Background()}
+
+	isOpenShift, err := k8sAPI.IsOpenShift()
+	require.NoError(t, err)
+	assert.False(t, isOpenShift)
+
+	discoveryClient.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "project.openshift.io/v1",
+		APIResources: []metav1.APIResource{{Name: "projects", Kind: "Project"}},
+	}}
+	isOpenShift, err = k8sAPI.IsOpenShift()
+	require.NoError(t, err)
+	assert.True(t, isOpenShift)
+
+	// no permissions on the nodes
+	clusterVersion, err := k8sAPI.GetClusterVersion()
+	require.NoError(t, err)
+	assert.Equal(t, DistributionOpenShift, clusterVersion.Distribution)
+	assert.Equal(t, "project", NamespaceTerm(clusterVersion.Distribution))
+	assert.Equal(t, "namespace", NamespaceTerm(DistributionEKS))
+}
+
+func TestNewProject(t *testing.T) {
+	project := NewProject(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop", Annotations: map[string]string{
+		"openshift.io/display-name": "Shop",
+		"openshift.io/description":  "The shop frontend",
+		"openshift.io/requester":    "alice",
+	}}})
+	assert.Equal(t, &Project{Name: "shop", DisplayName: "Shop", Description: "The shop frontend", Requester: "alice"}, project)
+
+	assert.Equal(t, &Project{Name: "default"}, NewProject(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}))
+}
+
+func TestListSecurityContextConstraints(t *testing.T) {
+	scc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion":               "security.openshift.io/v1",
+		"kind":                     "SecurityContextConstraints",
+		"metadata":                 map[string]interface{}{"name": "anyuid"},
+		"allowPrivilegedContainer": false,
+		"runAsUser":                map[string]interface{}{"type": "RunAsAny"},
+		"groups":                   []interface{}{"system:cluster-admins"},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{SecurityContextConstraintsGroupVersionResource: "SecurityContextConstraintsList"},
+	)
+	// the fake client guesses the wrong plural of the kind
+	require.NoError(t, dynamicClient.Tracker().Create(SecurityContextConstraintsGroupVersionResource, scc, ""))
+	k8sAPI := &KubernetesApi{DynamicClient: dynamicClient, Context: context.Background()}
+
+	sccs, err := k8sAPI.ListSecurityContextConstraints()
+	require.NoError(t, err)
+	require.Len(t, sccs, 1)
+	assert.Equal(t, "anyuid", sccs[0].GetName())
+
+	typed, err := workloadinterface.NewSecurityContextConstraints(sccs[0])
+	require.NoError(t, err)
+	assert.True(t, typed.AllowsRunAsRoot())
+	assert.Equal(t, []string{"system:cluster-admins"}, typed.Groups)
+}
+
+func TestGetExposureInfoOpenShiftRoutes(t *testing.T) {
+	InitializeMapResourcesMock()
+	AddMapResources([]*metav1.APIResourceList{{
+		GroupVersion: "route.openshift.io/v1",
+		APIResources: []metav1.APIResource{{Name: "routes", Kind: "Route", Namespaced: true, Verbs: metav1.Verbs{"list"}}},
+	}})
+
+	kubernetesClient := kubernetesfake.NewSimpleClientset(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "frontend"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "frontend"}},
+	})
+	newRoute := func(name string, spec map[string]interface{}) runtime.Object {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "route.openshift.io/v1",
+			"kind":       "Route",
+			"metadata":   map[string]interface{}{"namespace": "shop", "name": name},
+			"spec":       spec,
+			"status": map[string]interface{}{"ingress": []interface{}{
+				map[string]interface{}{"host": "shop.apps.example.com", "routerCanonicalHostname": "router-default.apps.example.com"},
+			}},
+		}}
+	}
+	dynamicClient := newExposureDynamicClient(
+		newRoute("shop", map[string]interface{}{
+			"host": "shop.apps.example.com",
+			"to":   map[string]interface{}{"kind": "Service", "name": "frontend", "weight": int64(100)},
+			"tls":  map[string]interface{}{"termination": "edge"},
+		}),
+		// canary route, the workload is an alternate backend
+		newRoute("canary", map[string]interface{}{
+			"path":              "/beta",
+			"to":                map[string]interface{}{"kind": "Service", "name": "frontend-v2"},
+			"alternateBackends": []interface{}{map[string]interface{}{"kind": "Service", "name": "frontend", "weight": int64(10)}},
+		}),
+		newRoute("backend", map[string]interface{}{"to": map[string]interface{}{"kind": "Service", "name": "backend"}}),
+	)
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesClient, DynamicClient: dynamicClient, Context: context.Background()}
+
+	exposureInfo, err := k8sAPI.GetExposureInfo(newExposureTestDeployment())
+	require.NoError(t, err)
+	assert.True(t, exposureInfo.IsExternallyExposed())
+	assert.Empty(t, exposureInfo.Routes)
+	assert.ElementsMatch(t, []OpenShiftRouteExposure{
+		{
+			Name:            "shop",
+			ServiceName:     "frontend",
+			Host:            "shop.apps.example.com",
+			TLSTermination:  "edge",
+			RouterHostnames: []string{"router-default.apps.example.com"},
+		},
+		{
+			Name:            "canary",
+			ServiceName:     "frontend",
+			Path:            "/beta",
+			RouterHostnames: []string{"router-default.apps.example.com"},
+		},
+	}, exposureInfo.OpenShiftRoutes)
+}
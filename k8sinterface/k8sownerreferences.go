@@ -0,0 +1,125 @@
+package k8sinterface
+
+import (
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// OwnerReferenceOptions configures the owner references set by CreateWorkloadWithOwner and SetOwnerReference
+type OwnerReferenceOptions struct {
+	// Controller marks the owner as the controller of the object, an object has one controller at most
+	Controller bool
+	// BlockOwnerDeletion keeps the owner until the object is deleted when the owner is deleted in the foreground
+	BlockOwnerDeletion bool
+}
+
+// NewOwnerReference returns the reference to the owner. The owner must have been created: the reference requires its uid
+func NewOwnerReference(owner IWorkload, opts OwnerReferenceOptions) (*metav1.OwnerReference, error) {
+	if owner.GetApiVersion() == "" || owner.GetKind() == "" || owner.GetName() == "" {
+		return nil, fmt.Errorf("the owner must have an apiVersion, a kind and a name")
+	}
+	if owner.GetUID() == "" {
+		return nil, fmt.Errorf("the owner '%s' has no uid, it must be created before the objects it owns", owner.GetID())
+	}
+	return &metav1.OwnerReference{
+		APIVersion:         owner.GetApiVersion(),
+		Kind:               owner.GetKind(),
+		Name:               owner.GetName(),
+		UID:                types.UID(owner.GetUID()),
+		Controller:         &opts.Controller,
+		BlockOwnerDeletion: &opts.BlockOwnerDeletion,
+	}, nil
+}
+
+// ValidateOwner returns an error if the garbage collector does not support the owner of the object: a namespaced owner of a cluster-scoped object,
+// or a namespaced owner in another namespace. The garbage collector deletes the objects with such owners as if the owner was deleted
+func ValidateOwner(obj IWorkload, owner IWorkload) error {
+	ownerNamespaced, err := isNamespacedKind(owner)
+	if err != nil {
+		return err
+	}
+	if !ownerNamespaced {
+		return nil
+	}
+	objNamespaced, err := isNamespacedKind(obj)
+	if err != nil {
+		return err
+	}
+	if !objNamespaced {
+		return fmt.Errorf("the cluster-scoped object '%s' cannot be owned by the namespaced object '%s'", obj.GetID(), owner.GetID())
+	}
+	if obj.GetNamespace() != owner.GetNamespace() {
+		return fmt.Errorf("the object '%s' cannot be owned by the object '%s' of another namespace", obj.GetID(), owner.GetID())
+	}
+	return nil
+}
+
+// SetOwnerReference validates the owner of the object (see ValidateOwner) and sets its reference in the object, replacing the existing reference to the owner.
+// The namespace of a namespaced object without namespace is set to the namespace of the owner.
+// An error is returned if the reference is a controller reference and the object has another controller
+func SetOwnerReference(obj IWorkload, owner IWorkload, opts OwnerReferenceOptions) (IWorkload, error) {
+	ownerReference, err := NewOwnerReference(owner, opts)
+	if err != nil {
+		return nil, err
+	}
+	u, err := obj.ToUnstructured()
+	if err != nil {
+		return nil, err
+	}
+	if u.GetNamespace() == "" && owner.GetNamespace() != "" {
+		if namespaced, err := isNamespacedKind(obj); err == nil && namespaced {
+			u.SetNamespace(owner.GetNamespace())
+		}
+	}
+	withOwner := workloadinterface.NewWorkloadObj(u.Object)
+	if err := ValidateOwner(withOwner, owner); err != nil {
+		return nil, err
+	}
+	if err := setOwnerReference(u, *ownerReference); err != nil {
+		return nil, fmt.Errorf("failed to set the owner of '%s': %s", obj.GetID(), err.Error())
+	}
+	return workloadinterface.NewWorkloadObj(u.Object), nil
+}
+
+// CreateWorkloadWithOwner creates the object with a reference to its owner, so the garbage collector deletes it with its owner. See SetOwnerReference
+func (k8sAPI *KubernetesApi) CreateWorkloadWithOwner(workload IWorkload, owner IWorkload, opts OwnerReferenceOptions) (IWorkload, error) {
+	withOwner, err := SetOwnerReference(workload, owner, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to CREATE resource, workload: '%s', reason: %s", workload.GetID(), err.Error())
+	}
+	return k8sAPI.CreateWorkload(withOwner)
+}
+
+func setOwnerReference(u *unstructured.Unstructured, ownerReference metav1.OwnerReference) error {
+	ownerReferences := u.GetOwnerReferences()
+	index := -1
+	for i := range ownerReferences {
+		if ownerReferences[i].UID == ownerReference.UID {
+			index = i
+			continue
+		}
+		if *ownerReference.Controller && ownerReferences[i].Controller != nil && *ownerReferences[i].Controller {
+			return fmt.Errorf("the object is already controlled by %s '%s'", ownerReferences[i].Kind, ownerReferences[i].Name)
+		}
+	}
+	if index == -1 {
+		ownerReferences = append(ownerReferences, ownerReference)
+	} else {
+		ownerReferences[index] = ownerReference
+	}
+	u.SetOwnerReferences(ownerReferences)
+	return nil
+}
+
+// isNamespacedKind returns true if the kind of the object is namespaced, according to the resource mapping
+func isNamespacedKind(obj IWorkload) (bool, error) {
+	groupVersionResource, err := GetGroupVersionResource(obj.GetKind())
+	if err != nil {
+		return false, err
+	}
+	return IsNamespaceScope(&groupVersionResource), nil
+}
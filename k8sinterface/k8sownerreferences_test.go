@@ -0,0 +1,92 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newOwnerReferencesTestObject(kind, namespace, name, uid string) IWorkload {
+	metadata := map[string]interface{}{"name": name}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	if uid != "" {
+		metadata["uid"] = uid
+	}
+	apiVersion := "v1"
+	if kind == "Deployment" {
+		apiVersion = "apps/v1"
+	}
+	return workloadinterface.NewWorkloadObj(map[string]interface{}{"apiVersion": apiVersion, "kind": kind, "metadata": metadata})
+}
+
+func TestSetOwnerReference(t *testing.T) {
+	InitializeMapResourcesMock()
+	owner := newOwnerReferencesTestObject("Deployment", "shop", "frontend", "1234")
+
+	// the namespace of the owner is set
+	obj, err := SetOwnerReference(newOwnerReferencesTestObject("ConfigMap", "", "frontend-config", ""), owner, OwnerReferenceOptions{Controller: true, BlockOwnerDeletion: true})
+	require.NoError(t, err)
+	assert.Equal(t, "shop", obj.GetNamespace())
+	ownerReferences, err := obj.GetOwnerReferences()
+	require.NoError(t, err)
+	require.Len(t, ownerReferences, 1)
+	assert.Equal(t, "apps/v1", ownerReferences[0].APIVersion)
+	assert.Equal(t, "Deployment", ownerReferences[0].Kind)
+	assert.Equal(t, "frontend", ownerReferences[0].Name)
+	assert.Equal(t, "1234", string(ownerReferences[0].UID))
+	assert.True(t, *ownerReferences[0].Controller)
+	assert.True(t, *ownerReferences[0].BlockOwnerDeletion)
+
+	// the reference to the same owner is replaced
+	obj, err = SetOwnerReference(obj, owner, OwnerReferenceOptions{Controller: true})
+	require.NoError(t, err)
+	ownerReferences, _ = obj.GetOwnerReferences()
+	require.Len(t, ownerReferences, 1)
+	assert.False(t, *ownerReferences[0].BlockOwnerDeletion)
+
+	// one controller at most
+	_, err = SetOwnerReference(obj, newOwnerReferencesTestObject("Deployment", "shop", "backend", "5678"), OwnerReferenceOptions{Controller: true})
+	assert.ErrorContains(t, err, "already controlled by Deployment 'frontend'")
+	obj, err = SetOwnerReference(obj, newOwnerReferencesTestObject("Deployment", "shop", "backend", "5678"), OwnerReferenceOptions{})
+	require.NoError(t, err)
+	ownerReferences, _ = obj.GetOwnerReferences()
+	assert.Len(t, ownerReferences, 2)
+
+	// the owner must be created
+	_, err = SetOwnerReference(newOwnerReferencesTestObject("ConfigMap", "shop", "frontend-config", ""), newOwnerReferencesTestObject("Deployment", "shop", "frontend", ""), OwnerReferenceOptions{})
+	assert.ErrorContains(t, err, "no uid")
+}
+
+func TestValidateOwner(t *testing.T) {
+	InitializeMapResourcesMock()
+	namespace := newOwnerReferencesTestObject("Namespace", "", "shop", "1")
+	deployment := newOwnerReferencesTestObject("Deployment", "shop", "frontend", "2")
+
+	assert.NoError(t, ValidateOwner(newOwnerReferencesTestObject("ConfigMap", "shop", "config", ""), deployment))
+	assert.NoError(t, ValidateOwner(newOwnerReferencesTestObject("ConfigMap", "other", "config", ""), namespace), "cluster-scoped owners may own objects of any namespace")
+	assert.NoError(t, ValidateOwner(newOwnerReferencesTestObject("Node", "", "node-1", ""), namespace))
+	assert.ErrorContains(t, ValidateOwner(newOwnerReferencesTestObject("ConfigMap", "other", "config", ""), deployment), "another namespace")
+	assert.ErrorContains(t, ValidateOwner(newOwnerReferencesTestObject("Node", "", "node-1", ""), deployment), "cluster-scoped")
+}
+
+func TestCreateWorkloadWithOwner(t *testing.T) {
+	InitializeMapResourcesMock()
+	k8sAPI := &KubernetesApi{DynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()), Context: context.Background()}
+	owner := newOwnerReferencesTestObject("Deployment", "shop", "frontend", "1234")
+
+	created, err := k8sAPI.CreateWorkloadWithOwner(newOwnerReferencesTestObject("ConfigMap", "", "frontend-config", ""), owner, OwnerReferenceOptions{Controller: true})
+	require.NoError(t, err)
+	assert.Equal(t, "shop", created.GetNamespace())
+	ownerReferences, _ := created.GetOwnerReferences()
+	assert.Len(t, ownerReferences, 1)
+
+	_, err = k8sAPI.CreateWorkloadWithOwner(newOwnerReferencesTestObject("ConfigMap", "other", "frontend-config", ""), owner, OwnerReferenceOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,335 @@
+package k8sinterface
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+	"github.com/kubescape/k8s-interface/tracing"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/kube-openapi/pkg/spec3"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+const (
+	extensionGroupVersionKind      = "x-kubernetes-group-version-kind"
+	extensionPreserveUnknownFields = "x-kubernetes-preserve-unknown-fields"
+	extensionIntOrString           = "x-kubernetes-int-or-string"
+	openAPIV3SchemaRefPrefix       = "#/components/schemas/"
+)
+
+// openAPIV3SchemaCache caches the OpenAPI v3 schemas of the group versions of the cluster of a KubernetesApi, by path
+type openAPIV3SchemaCache struct {
+	lock    sync.RWMutex
+	schemas map[string]*spec3.OpenAPI
+}
+
+// openAPIV3SchemaCacheInitLock guards the lazy creation of the caches of the KubernetesApis, which are created as struct literals
+var openAPIV3SchemaCacheInitLock = sync.Mutex{}
+
+// SchemaValidationError is an error of a manifest against the OpenAPI schema of the cluster
+type SchemaValidationError struct {
+	// Path is the path of the field, e.g. spec.template.spec.containers[0].imagePullPolicy
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e SchemaValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidateAgainstCluster validates the manifest against the OpenAPI v3 schema of its kind in the cluster: the types, the unknown fields, the enum values and the
+// required fields. The schema of a group version is fetched once per KubernetesApi (and its copies of WithContext), see InvalidateOpenAPISchemas. The returned errors are the errors of the manifest, the error is set if the
+// schema could not be fetched
+func (k8sAPI *KubernetesApi) ValidateAgainstCluster(obj workloadinterface.IMetadata) ([]SchemaValidationError, error) {
+	gvk := schema.FromAPIVersionAndKind(obj.GetApiVersion(), obj.GetKind())
+	if gvk.Kind == "" || gvk.Version == "" {
+		return []SchemaValidationError{{Message: "apiVersion and kind are required"}}, nil
+	}
+	openAPI, err := k8sAPI.getOpenAPIV3Schema(gvk.GroupVersion())
+	if err != nil {
+		return nil, err
+	}
+	kindSchema := findOpenAPIV3KindSchema(openAPI, gvk)
+	if kindSchema == nil {
+		return []SchemaValidationError{{Path: "kind", Message: fmt.Sprintf("kind '%s' is not served by the cluster in version '%s'", gvk.Kind, gvk.GroupVersion().String())}}, nil
+	}
+	validator := &schemaValidator{schemas: openAPI.Components.Schemas}
+	validator.validate("", obj.GetObject(), kindSchema)
+	return validator.errors, nil
+}
+
+// getOpenAPIV3Schema returns the OpenAPI v3 schema of the group version, nil if the cluster does not serve the group version
+func (k8sAPI *KubernetesApi) getOpenAPIV3Schema(gv schema.GroupVersion) (*spec3.OpenAPI, error) {
+	path := "apis/" + gv.String()
+	if gv.Group == "" {
+		path = "api/" + gv.Version
+	}
+
+	cache := k8sAPI.openAPIV3SchemaCache()
+	cache.lock.RLock()
+	openAPI, ok := cache.schemas[path]
+	cache.lock.RUnlock()
+	if ok {
+		return openAPI, nil
+	}
+
+	_, span := k8sAPI.startSpan("k8s.GetOpenAPIV3Schema", &schema.GroupVersionResource{Group: gv.Group, Version: gv.Version}, "", "")
+	openAPI, err := fetchOpenAPIV3Schema(k8sAPI.DiscoveryClient, path)
+	tracing.EndSpan(span, err)
+	if err != nil || openAPI == nil {
+		// the group versions the cluster does not serve are not cached, they may be served later (e.g. a CRD installed since)
+		return nil, err
+	}
+
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.schemas[path] = openAPI
+	return openAPI, nil
+}
+
+// InvalidateOpenAPISchemas drops the cached OpenAPI schemas of the cluster, so they are fetched again on the next validation.
+// Call it when the APIs of the cluster changed, e.g. after a CRD or a Kubernetes version upgrade
+func (k8sAPI *KubernetesApi) InvalidateOpenAPISchemas() {
+	cache := k8sAPI.openAPIV3SchemaCache()
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.schemas = map[string]*spec3.OpenAPI{}
+}
+
+// openAPIV3SchemaCache returns the cache of the OpenAPI v3 schemas of the KubernetesApi, created on the first call
+func (k8sAPI *KubernetesApi) openAPIV3SchemaCache() *openAPIV3SchemaCache {
+	openAPIV3SchemaCacheInitLock.Lock()
+	defer openAPIV3SchemaCacheInitLock.Unlock()
+	if k8sAPI.openAPIV3Schemas == nil {
+		k8sAPI.openAPIV3Schemas = &openAPIV3SchemaCache{schemas: map[string]*spec3.OpenAPI{}}
+	}
+	return k8sAPI.openAPIV3Schemas
+}
+
+func fetchOpenAPIV3Schema(discoveryClient discovery.DiscoveryInterface, path string) (*spec3.OpenAPI, error) {
+	openAPIClient := discoveryClient.OpenAPIV3()
+	if openAPIClient == nil {
+		return nil, fmt.Errorf("the discovery client does not serve the OpenAPI v3 schema")
+	}
+	paths, err := openAPIClient.Paths()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the OpenAPI v3 paths, reason: %s", err.Error())
+	}
+	groupVersion, ok := paths[path]
+	if !ok {
+		return nil, nil
+	}
+	document, err := groupVersion.Schema()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the OpenAPI v3 schema of '%s', reason: %s", path, err.Error())
+	}
+	return toOpenAPIV3(document)
+}
+
+// toOpenAPIV3 converts the gnostic document of the discovery client to the kube-openapi types
+func toOpenAPIV3(document *openapi_v3.Document) (*spec3.OpenAPI, error) {
+	yamlData, err := yaml.Marshal(document.ToRawInfo())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the OpenAPI v3 schema, reason: %s", err.Error())
+	}
+	jsonData, err := sigsyaml.YAMLToJSON(yamlData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the OpenAPI v3 schema, reason: %s", err.Error())
+	}
+	openAPI := &spec3.OpenAPI{}
+	if err := json.Unmarshal(jsonData, openAPI); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the OpenAPI v3 schema, reason: %s", err.Error())
+	}
+	return openAPI, nil
+}
+
+// findOpenAPIV3KindSchema returns the schema of the kind, nil if the kind is not part of the schema
+func findOpenAPIV3KindSchema(openAPI *spec3.OpenAPI, gvk schema.GroupVersionKind) *spec.Schema {
+	if openAPI == nil || openAPI.Components == nil {
+		return nil
+	}
+	for _, kindSchema := range openAPI.Components.Schemas {
+		gvks, _ := kindSchema.Extensions[extensionGroupVersionKind].([]interface{})
+		for i := range gvks {
+			m, _ := gvks[i].(map[string]interface{})
+			if m["group"] == gvk.Group && m["version"] == gvk.Version && m["kind"] == gvk.Kind {
+				return kindSchema
+			}
+		}
+	}
+	return nil
+}
+
+// schemaValidator validates the objects against the schemas, resolving the references to the component schemas
+type schemaValidator struct {
+	schemas map[string]*spec.Schema
+	errors  []SchemaValidationError
+}
+
+func (v *schemaValidator) addError(path, format string, a ...interface{}) {
+	v.errors = append(v.errors, SchemaValidationError{Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+func (v *schemaValidator) validate(path string, value interface{}, s *spec.Schema) {
+	if s == nil {
+		return
+	}
+	if ref := s.Ref.String(); ref != "" {
+		v.validate(path, value, v.schemas[strings.TrimPrefix(ref, openAPIV3SchemaRefPrefix)])
+		return
+	}
+	for i := range s.AllOf {
+		v.validate(path, value, &s.AllOf[i])
+	}
+	// null is an unset field
+	if value == nil {
+		return
+	}
+	if isTrue(s.Extensions[extensionIntOrString]) {
+		if _, ok := value.(string); !ok && jsonType(value) != "integer" {
+			v.addError(path, "expected an integer or a string, got %s", jsonType(value))
+		}
+		return
+	}
+	if len(s.OneOf) > 0 && !v.matchesAny(path, value, s.OneOf) {
+		v.addError(path, "%s does not match any of the allowed schemas", jsonType(value))
+		return
+	}
+	if len(s.AnyOf) > 0 && !v.matchesAny(path, value, s.AnyOf) {
+		v.addError(path, "%s does not match any of the allowed schemas", jsonType(value))
+		return
+	}
+	if len(s.Type) > 0 && !matchesType(value, s.Type) {
+		v.addError(path, "expected %s, got %s", strings.Join(s.Type, " or "), jsonType(value))
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(value, s.Enum) {
+		v.addError(path, "unsupported value '%v', supported values: %s", value, enumString(s.Enum))
+	}
+
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(path, typedValue, s)
+	case []interface{}:
+		if s.Items != nil && s.Items.Schema != nil {
+			for i := range typedValue {
+				v.validate(fmt.Sprintf("%s[%d]", path, i), typedValue[i], s.Items.Schema)
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(path string, obj map[string]interface{}, s *spec.Schema) {
+	for _, required := range s.Required {
+		if _, ok := obj[required]; !ok {
+			v.addError(joinSchemaPath(path, required), "required field is missing")
+		}
+	}
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	preserveUnknownFields := isTrue(s.Extensions[extensionPreserveUnknownFields])
+	for _, key := range keys {
+		fieldPath := joinSchemaPath(path, key)
+		if property, ok := s.Properties[key]; ok {
+			v.validate(fieldPath, obj[key], &property)
+			continue
+		}
+		switch {
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil:
+			v.validate(fieldPath, obj[key], s.AdditionalProperties.Schema)
+		case s.AdditionalProperties != nil && s.AdditionalProperties.Allows, preserveUnknownFields, len(s.Properties) == 0:
+		default:
+			v.addError(fieldPath, "unknown field")
+		}
+	}
+}
+
+// matchesAny returns true if the value is valid against one of the schemas
+func (v *schemaValidator) matchesAny(path string, value interface{}, schemas []spec.Schema) bool {
+	for i := range schemas {
+		branch := &schemaValidator{schemas: v.schemas}
+		branch.validate(path, value, &schemas[i])
+		if len(branch.errors) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func joinSchemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func isTrue(extension interface{}) bool {
+	b, ok := extension.(bool)
+	return ok && b
+}
+
+// jsonType returns the JSON schema type of the value
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int32, int64:
+		return "integer"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case float32:
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func matchesType(value interface{}, types spec.StringOrArray) bool {
+	valueType := jsonType(value)
+	for _, t := range types {
+		if t == valueType || (t == "number" && valueType == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func inEnum(value interface{}, enum []interface{}) bool {
+	for i := range enum {
+		if fmt.Sprint(enum[i]) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumString(enum []interface{}) string {
+	values := make([]string, 0, len(enum))
+	for i := range enum {
+		values = append(values, fmt.Sprintf("'%v'", enum[i]))
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,202 @@
+package k8sinterface
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	openapi_v3 "github.com/google/gnostic/openapiv3"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/openapi"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const openAPIV3AppsV1Mock = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Kubernetes", "version": "v1.25.3"},
+  "paths": {},
+  "components": {
+    "schemas": {
+      "io.k8s.api.apps.v1.Deployment": {
+        "type": "object",
+        "properties": {
+          "apiVersion": {"type": "string"},
+          "kind": {"type": "string"},
+          "metadata": {"default": {}, "allOf": [{"$ref": "#/components/schemas/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}]},
+          "spec": {"default": {}, "allOf": [{"$ref": "#/components/schemas/io.k8s.api.apps.v1.DeploymentSpec"}]}
+        },
+        "x-kubernetes-group-version-kind": [{"group": "apps", "kind": "Deployment", "version": "v1"}]
+      },
+      "io.k8s.api.apps.v1.DeploymentSpec": {
+        "type": "object",
+        "required": ["selector", "template"],
+        "properties": {
+          "replicas": {"type": "integer", "format": "int32"},
+          "selector": {"type": "object", "x-kubernetes-preserve-unknown-fields": true},
+          "template": {"default": {}, "allOf": [{"$ref": "#/components/schemas/io.k8s.api.core.v1.PodTemplateSpec"}]}
+        }
+      },
+      "io.k8s.api.core.v1.PodTemplateSpec": {
+        "type": "object",
+        "properties": {
+          "metadata": {"default": {}, "allOf": [{"$ref": "#/components/schemas/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"}]},
+          "spec": {"allOf": [{"$ref": "#/components/schemas/io.k8s.api.core.v1.PodSpec"}]}
+        }
+      },
+      "io.k8s.api.core.v1.PodSpec": {
+        "type": "object",
+        "required": ["containers"],
+        "properties": {
+          "containers": {"type": "array", "items": {"default": {}, "allOf": [{"$ref": "#/components/schemas/io.k8s.api.core.v1.Container"}]}},
+          "hostNetwork": {"type": "boolean"}
+        }
+      },
+      "io.k8s.api.core.v1.Container": {
+        "type": "object",
+        "required": ["name"],
+        "properties": {
+          "name": {"type": "string", "default": ""},
+          "image": {"type": "string"},
+          "imagePullPolicy": {"type": "string", "enum": ["Always", "IfNotPresent", "Never"]},
+          "ports": {"type": "array", "items": {"type": "object", "properties": {"containerPort": {"allOf": [{"$ref": "#/components/schemas/io.k8s.apimachinery.pkg.util.intstr.IntOrString"}]}}}},
+          "resources": {"type": "object", "properties": {"limits": {"type": "object", "additionalProperties": {"allOf": [{"$ref": "#/components/schemas/io.k8s.apimachinery.pkg.api.resource.Quantity"}]}}}}
+        }
+      },
+      "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "namespace": {"type": "string"},
+          "labels": {"type": "object", "additionalProperties": {"type": "string", "default": ""}},
+          "creationTimestamp": {"type": "string", "format": "date-time"}
+        }
+      },
+      "io.k8s.apimachinery.pkg.util.intstr.IntOrString": {"type": "string", "format": "int-or-string", "x-kubernetes-int-or-string": true},
+      "io.k8s.apimachinery.pkg.api.resource.Quantity": {"oneOf": [{"type": "string"}, {"type": "number"}]}
+    }
+  }
+}`
+
+type openAPIV3ClientMock struct {
+	documents map[string]string
+	fetches   int
+}
+
+func (c *openAPIV3ClientMock) Paths() (map[string]openapi.GroupVersion, error) {
+	paths := map[string]openapi.GroupVersion{}
+	for path := range c.documents {
+		paths[path] = &openAPIV3GroupVersionMock{client: c, document: c.documents[path]}
+	}
+	return paths, nil
+}
+
+type openAPIV3GroupVersionMock struct {
+	client   *openAPIV3ClientMock
+	document string
+}
+
+func (gv *openAPIV3GroupVersionMock) Schema() (*openapi_v3.Document, error) {
+	gv.client.fetches++
+	return openapi_v3.ParseDocument([]byte(gv.document))
+}
+
+type openAPIV3DiscoveryMock struct {
+	*fakediscovery.FakeDiscovery
+	openAPIClient *openAPIV3ClientMock
+}
+
+func (d *openAPIV3DiscoveryMock) OpenAPIV3() openapi.Client {
+	return d.openAPIClient
+}
+
+func TestValidateAgainstCluster(t *testing.T) {
+	openAPIClient := &openAPIV3ClientMock{documents: map[string]string{"apis/apps/v1": openAPIV3AppsV1Mock}}
+	k8sAPI := &KubernetesApi{DiscoveryClient: &openAPIV3DiscoveryMock{FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{}}, openAPIClient: openAPIClient}}
+
+	valid := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "nginx", "labels": map[string]interface{}{"app": "nginx"}, "creationTimestamp": nil},
+		"spec": map[string]interface{}{
+			"replicas": 2,
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "nginx"}},
+			"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{map[string]interface{}{
+				"name":            "nginx",
+				"image":           "nginx",
+				"imagePullPolicy": "IfNotPresent",
+				"ports":           []interface{}{map[string]interface{}{"containerPort": 80}, map[string]interface{}{"containerPort": "http"}},
+				"resources":       map[string]interface{}{"limits": map[string]interface{}{"cpu": 1, "memory": "128Mi"}},
+			}}}},
+		},
+	})
+	errs, err := k8sAPI.ValidateAgainstCluster(valid)
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	invalid := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "nginx", "labels": map[string]interface{}{"app": 1}},
+		"spec": map[string]interface{}{
+			"replicas": "2",
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "nginx"}},
+			"template": map[string]interface{}{"spec": map[string]interface{}{
+				"hostNetwork": true,
+				"containers": []interface{}{map[string]interface{}{
+					"image":           "nginx",
+					"imagePullPolicy": "Sometimes",
+					"imagePulPolicy":  "Always",
+					"ports":           []interface{}{map[string]interface{}{"containerPort": true}},
+					"resources":       map[string]interface{}{"limits": map[string]interface{}{"cpu": []interface{}{}}},
+				}},
+			}},
+		},
+	})
+	errs, err = k8sAPI.ValidateAgainstCluster(invalid)
+	require.NoError(t, err)
+	assert.Equal(t, []SchemaValidationError{
+		{Path: "metadata.labels.app", Message: "expected string, got integer"},
+		{Path: "spec.replicas", Message: "expected integer, got string"},
+		{Path: "spec.template.spec.containers[0].name", Message: "required field is missing"},
+		{Path: "spec.template.spec.containers[0].imagePulPolicy", Message: "unknown field"},
+		{Path: "spec.template.spec.containers[0].imagePullPolicy", Message: "unsupported value 'Sometimes', supported values: 'Always', 'IfNotPresent', 'Never'"},
+		{Path: "spec.template.spec.containers[0].ports[0].containerPort", Message: "expected an integer or a string, got boolean"},
+		{Path: "spec.template.spec.containers[0].resources.limits.cpu", Message: "array does not match any of the allowed schemas"},
+	}, errs)
+	assert.Equal(t, "spec.replicas: expected integer, got string", fmt.Sprint(errs[1]))
+
+	// the kinds and the group versions the cluster does not serve
+	errs, err = k8sAPI.ValidateAgainstCluster(workloadinterface.NewWorkloadObj(map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deploymentt"}))
+	require.NoError(t, err)
+	assert.Equal(t, []SchemaValidationError{{Path: "kind", Message: "kind 'Deploymentt' is not served by the cluster in version 'apps/v1'"}}, errs)
+	errs, err = k8sAPI.ValidateAgainstCluster(workloadinterface.NewWorkloadObj(map[string]interface{}{"apiVersion": "extensions/v1beta1", "kind": "Deployment"}))
+	require.NoError(t, err)
+	assert.Len(t, errs, 1)
+
+	// the schema is fetched once, by the copies of the KubernetesApi too
+	assert.Equal(t, 1, openAPIClient.fetches)
+	_, err = k8sAPI.WithContext(context.Background()).ValidateAgainstCluster(valid)
+	require.NoError(t, err)
+	assert.Equal(t, 1, openAPIClient.fetches)
+
+	// the group versions the cluster does not serve are not cached
+	openAPIClient.documents["apis/extensions/v1beta1"] = openAPIV3AppsV1Mock
+	_, err = k8sAPI.ValidateAgainstCluster(workloadinterface.NewWorkloadObj(map[string]interface{}{"apiVersion": "extensions/v1beta1", "kind": "Deployment"}))
+	require.NoError(t, err)
+	assert.Equal(t, 2, openAPIClient.fetches)
+
+	// the schemas are fetched again once invalidated
+	k8sAPI.InvalidateOpenAPISchemas()
+	_, err = k8sAPI.ValidateAgainstCluster(valid)
+	require.NoError(t, err)
+	assert.Equal(t, 3, openAPIClient.fetches)
+
+	// the caches are not shared by the KubernetesApis
+	other := &KubernetesApi{DiscoveryClient: k8sAPI.DiscoveryClient}
+	_, err = other.ValidateAgainstCluster(valid)
+	require.NoError(t, err)
+	assert.Equal(t, 4, openAPIClient.fetches)
+}
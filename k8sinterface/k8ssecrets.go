@@ -0,0 +1,167 @@
+package k8sinterface
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// DefaultSecretsPageSize is the number of secrets requested per page by ListSecretsFiltered if SecretsListOptions.PageSize is not set
+const DefaultSecretsPageSize = 100
+
+// SecretTypeHelmRelease is the type of the secrets Helm stores the releases in, their data holds the compressed chart and values of the release
+const SecretTypeHelmRelease corev1.SecretType = "helm.sh/release.v1"
+
+// DefaultExcludedSecretTypes are the types of the secrets that are usually not relevant to the secret hygiene scans: the service account tokens and the Helm releases
+var DefaultExcludedSecretTypes = []corev1.SecretType{corev1.SecretTypeServiceAccountToken, SecretTypeHelmRelease}
+
+// SecretsListOptions configures ListSecretsFiltered
+type SecretsListOptions struct {
+	// Namespace is the namespace of the secrets, all the namespaces if empty
+	Namespace     string
+	LabelSelector string
+	// ExcludeTypes are the types of the secrets that are not listed, see DefaultExcludedSecretTypes. The secrets are filtered by the API server
+	ExcludeTypes []corev1.SecretType
+	// MetadataOnly lists the metadata of the secrets only, without their type and data
+	MetadataOnly bool
+	// MaxDataBytes is the maximum size of the data of a secret, the secrets with larger data are returned without their data (see SecretsList.Oversized).
+	// Not limited if not set
+	MaxDataBytes int64
+	// PageSize is the number of secrets requested per page, DefaultSecretsPageSize if not set
+	PageSize int64
+	// Limit is the maximum number of secrets returned, the remaining secrets are listed by passing SecretsList.Continue in Continue. All the secrets are listed if not set
+	Limit    int64
+	Continue string
+	// QPS and Burst limit the rate of the page requests, not limited if QPS is not set
+	QPS   float32
+	Burst int
+}
+
+// SecretsList is the result of ListSecretsFiltered
+type SecretsList struct {
+	Items []corev1.Secret
+	// Oversized are the secrets (<namespace>/<name>) returned without their data, their data is larger than SecretsListOptions.MaxDataBytes
+	Oversized []string
+	// Continue is the token of the next secrets when SecretsListOptions.Limit is set, empty if all the secrets were listed
+	Continue string
+}
+
+// ListSecretsFiltered lists the secrets page by page, filtering their types with a field selector so the API server does not send the excluded secrets,
+// e.g. the multi-MB Helm release secrets. The data of the oversized secrets is dropped as soon as their page is received
+func (k8sAPI *KubernetesApi) ListSecretsFiltered(ctx context.Context, opts SecretsListOptions) (*SecretsList, error) {
+	secretsResource := &schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	ctx, span := startSpan(ctx, "k8s.ListSecretsFiltered", secretsResource, opts.Namespace, "")
+
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultSecretsPageSize
+	}
+	var rateLimiter flowcontrol.RateLimiter
+	if opts.QPS > 0 {
+		burst := opts.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		rateLimiter = flowcontrol.NewTokenBucketRateLimiter(opts.QPS, burst)
+		defer rateLimiter.Stop()
+	}
+
+	listOptions := metav1.ListOptions{LabelSelector: opts.LabelSelector, FieldSelector: secretTypesFieldSelector(opts.ExcludeTypes), Continue: opts.Continue}
+	secretsList := &SecretsList{Items: []corev1.Secret{}}
+	for {
+		listOptions.Limit = pageSize
+		if opts.Limit > 0 && opts.Limit-int64(len(secretsList.Items)) < pageSize {
+			listOptions.Limit = opts.Limit - int64(len(secretsList.Items))
+		}
+		if rateLimiter != nil {
+			if err := rateLimiter.Wait(ctx); err != nil {
+				tracing.EndSpan(span, err)
+				return nil, err
+			}
+		}
+		continueToken, err := k8sAPI.listSecretsPage(ctx, opts, listOptions, secretsList)
+		if err != nil {
+			tracing.EndSpan(span, err)
+			return nil, fmt.Errorf("failed to LIST secrets, reason: %s", err.Error())
+		}
+		listOptions.Continue = continueToken
+		if continueToken == "" || (opts.Limit > 0 && int64(len(secretsList.Items)) >= opts.Limit) {
+			secretsList.Continue = continueToken
+			break
+		}
+	}
+	span.SetAttributes(tracing.AttributeCount.Int(len(secretsList.Items)))
+	tracing.EndSpan(span, nil)
+	return secretsList, nil
+}
+
+// listSecretsPage appends the secrets of the page to the list and returns the continue token of the next page
+func (k8sAPI *KubernetesApi) listSecretsPage(ctx context.Context, opts SecretsListOptions, listOptions metav1.ListOptions, secretsList *SecretsList) (string, error) {
+	if opts.MetadataOnly {
+		if k8sAPI.MetadataClient == nil {
+			return "", fmt.Errorf("metadata client is not initialized")
+		}
+		list, err := k8sAPI.MetadataClient.Resource(corev1.SchemeGroupVersion.WithResource("secrets")).Namespace(opts.Namespace).List(ctx, listOptions)
+		if err != nil {
+			return "", err
+		}
+		for i := range list.Items {
+			secretsList.Items = append(secretsList.Items, corev1.Secret{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}, ObjectMeta: list.Items[i].ObjectMeta})
+		}
+		return list.GetContinue(), nil
+	}
+
+	list, err := k8sAPI.KubernetesClient.CoreV1().Secrets(opts.Namespace).List(ctx, listOptions)
+	if err != nil {
+		return "", err
+	}
+	for i := range list.Items {
+		// the API servers that ignore the field selector
+		if isExcludedSecretType(list.Items[i].Type, opts.ExcludeTypes) {
+			continue
+		}
+		if opts.MaxDataBytes > 0 && SecretDataSize(&list.Items[i]) > opts.MaxDataBytes {
+			list.Items[i].Data = nil
+			list.Items[i].StringData = nil
+			secretsList.Oversized = append(secretsList.Oversized, list.Items[i].Namespace+"/"+list.Items[i].Name)
+		}
+		secretsList.Items = append(secretsList.Items, list.Items[i])
+	}
+	return list.GetContinue(), nil
+}
+
+// SecretDataSize returns the size of the data of the secret: its keys and values
+func SecretDataSize(secret *corev1.Secret) int64 {
+	var size int64
+	for key, value := range secret.Data {
+		size += int64(len(key) + len(value))
+	}
+	for key, value := range secret.StringData {
+		size += int64(len(key) + len(value))
+	}
+	return size
+}
+
+// secretTypesFieldSelector returns the field selector excluding the types, e.g. type!=helm.sh/release.v1
+func secretTypesFieldSelector(excludeTypes []corev1.SecretType) string {
+	selectors := make([]fields.Selector, 0, len(excludeTypes))
+	for _, secretType := range excludeTypes {
+		selectors = append(selectors, fields.OneTermNotEqualSelector("type", string(secretType)))
+	}
+	return fields.AndSelectors(selectors...).String()
+}
+
+func isExcludedSecretType(secretType corev1.SecretType, excludeTypes []corev1.SecretType) bool {
+	for i := range excludeTypes {
+		if secretType == excludeTypes[i] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package k8sinterface
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestListSecretsFiltered(t *testing.T) {
+	secrets := []corev1.Secret{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db"}, Type: corev1.SecretTypeOpaque, Data: map[string][]byte{"password": []byte("secret")}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sh.helm.release.v1.nginx.v1"}, Type: SecretTypeHelmRelease, Data: map[string][]byte{"release": make([]byte, 1024)}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "tls"}, Type: corev1.SecretTypeTLS, Data: map[string][]byte{"tls.crt": make([]byte, 512), "tls.key": make([]byte, 128)}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "token"}, Type: corev1.SecretTypeServiceAccountToken, Data: map[string][]byte{"token": []byte("token")}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "registry"}, Type: corev1.SecretTypeDockerConfigJson, Data: map[string][]byte{".dockerconfigjson": []byte("{}")}},
+	}
+	kubernetesClient := kubernetesfake.NewSimpleClientset()
+	var listOptions []metav1.ListOptions
+	// the fake clientset does not page the lists, the pages are served by the index of their first secret
+	kubernetesClient.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		restrictions := action.(k8stesting.ListActionImpl).GetListRestrictions()
+		options := metav1.ListOptions{FieldSelector: restrictions.Fields.String(), Limit: 2}
+		start := 0
+		if len(listOptions) > 0 && listOptions[len(listOptions)-1].Continue != "" {
+			start, _ = strconv.Atoi(listOptions[len(listOptions)-1].Continue)
+		}
+		end := start + 2
+		list := &corev1.SecretList{}
+		if end < len(secrets) {
+			list.Continue = strconv.Itoa(end)
+		} else {
+			end = len(secrets)
+		}
+		list.Items = append(list.Items, secrets[start:end]...)
+		options.Continue = list.Continue
+		listOptions = append(listOptions, options)
+		return true, list, nil
+	})
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesClient, Context: context.Background()}
+
+	secretsList, err := k8sAPI.ListSecretsFiltered(context.Background(), SecretsListOptions{ExcludeTypes: DefaultExcludedSecretTypes, MaxDataBytes: 256, PageSize: 2, QPS: 100})
+	require.NoError(t, err)
+	names := []string{}
+	for i := range secretsList.Items {
+		names = append(names, secretsList.Items[i].Name)
+	}
+	assert.Equal(t, []string{"db", "tls", "registry"}, names)
+	assert.Equal(t, []string{"default/tls"}, secretsList.Oversized)
+	assert.Nil(t, secretsList.Items[1].Data)
+	assert.Equal(t, []byte("secret"), secretsList.Items[0].Data["password"])
+	assert.Empty(t, secretsList.Continue)
+	require.Len(t, listOptions, 3)
+	assert.ElementsMatch(t, []string{"type!=kubernetes.io/service-account-token", "type!=helm.sh/release.v1"}, strings.Split(listOptions[0].FieldSelector, ","))
+
+	// the limit returns the continue token of the next secrets
+	listOptions = nil
+	secretsList, err = k8sAPI.ListSecretsFiltered(context.Background(), SecretsListOptions{PageSize: 2, Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, secretsList.Items, 2)
+	assert.Equal(t, "2", secretsList.Continue)
+}
+
+func TestListSecretsFilteredMetadataOnly(t *testing.T) {
+	scheme := metadatafake.NewTestScheme()
+	metav1.AddMetaToScheme(scheme)
+	k8sAPI := &KubernetesApi{
+		MetadataClient: metadatafake.NewSimpleMetadataClient(scheme,
+			newPartialObjectMetadata("v1", "Secret", "default", "db", nil),
+			newPartialObjectMetadata("v1", "Secret", "kube-system", "registry", nil),
+		),
+		Context: context.Background(),
+	}
+	secretsList, err := k8sAPI.ListSecretsFiltered(context.Background(), SecretsListOptions{Namespace: "default", MetadataOnly: true})
+	require.NoError(t, err)
+	require.Len(t, secretsList.Items, 1)
+	assert.Equal(t, "db", secretsList.Items[0].Name)
+	assert.Nil(t, secretsList.Items[0].Data)
+
+	_, err = (&KubernetesApi{}).ListSecretsFiltered(context.Background(), SecretsListOptions{MetadataOnly: true})
+	assert.Error(t, err)
+}
+
+func TestSecretDataSize(t *testing.T) {
+	assert.Equal(t, int64(14), SecretDataSize(&corev1.Secret{Data: map[string][]byte{"password": []byte("secret")}}))
+	assert.Equal(t, int64(0), SecretDataSize(&corev1.Secret{}))
+}
@@ -0,0 +1,38 @@
+package k8sinterface
+
+import (
+	"github.com/kubescape/k8s-interface/workloadinterface"
+)
+
+// GetSecretsUsageGraph returns the usage graph of the Secrets/ConfigMaps/SecretProviderClasses of the workloads of the namespace (all the namespaces if empty).
+// Workloads owned by another workload (e.g. the pods of a ReplicaSet) are skipped, their references are the ones of the pod template of the owner
+func (k8sAPI *KubernetesApi) GetSecretsUsageGraph(namespace string) (*workloadinterface.SecretsUsageGraph, error) {
+	var workloads []workloadinterface.IWorkload
+	for _, kind := range WorkloadKinds {
+		list, err := k8sAPI.ListWorkloads2(namespace, kind)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list {
+			if IsOwnedByWorkload(list[i]) {
+				continue
+			}
+			workloads = append(workloads, list[i])
+		}
+	}
+	return workloadinterface.NewSecretsUsageGraph(workloads)
+}
+
+// IsOwnedByWorkload returns true if an owner of the workload is a workload, e.g. the pods of a ReplicaSet or the ReplicaSets of a Deployment
+func IsOwnedByWorkload(workload IWorkload) bool {
+	ownerReferences, err := workload.GetOwnerReferences()
+	if err != nil {
+		return false
+	}
+	for i := range ownerReferences {
+		if IsWorkloadKind(ownerReferences[i].Kind) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestGetSecretsUsageGraph(t *testing.T) {
+	InitializeMapResourcesMock()
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, kind := range WorkloadKinds {
+		gvr, err := GetGroupVersionResource(kind)
+		require.NoError(t, err)
+		listKinds[gvr] = kind + "List"
+	}
+	podTemplate := map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{
+			"name":    "nginx",
+			"envFrom": []interface{}{map[string]interface{}{"secretRef": map[string]interface{}{"name": "db"}}},
+		}},
+	}
+	replicaSet := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "ReplicaSet",
+		"metadata":   map[string]interface{}{"namespace": "default", "name": "nginx-5d8f6b7c9", "ownerReferences": []interface{}{map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "nginx", "uid": "1"}}},
+		"spec":       map[string]interface{}{"template": map[string]interface{}{"spec": podTemplate}},
+	}}
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"namespace": "default", "name": "nginx"},
+		"spec":       map[string]interface{}{"template": map[string]interface{}{"spec": podTemplate}},
+	}}
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"namespace": "default", "name": "debug"},
+		"spec": map[string]interface{}{"containers": []interface{}{map[string]interface{}{
+			"name": "debug",
+			"env":  []interface{}{map[string]interface{}{"name": "PASSWORD", "valueFrom": map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": "db", "key": "password"}}}},
+		}}},
+	}}
+	k8sAPI := &KubernetesApi{
+		DynamicClient: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, replicaSet, deployment, pod),
+		Context:       context.Background(),
+	}
+
+	graph, err := k8sAPI.GetSecretsUsageGraph("")
+	require.NoError(t, err)
+	consumers := graph.GetConsumers(workloadinterface.SecretKind, "default", "db")
+	require.Len(t, consumers, 2)
+	workloads := []string{consumers[0].WorkloadKind + "/" + consumers[0].WorkloadName, consumers[1].WorkloadKind + "/" + consumers[1].WorkloadName}
+	assert.ElementsMatch(t, []string{"Pod/debug", "Deployment/nginx"}, workloads)
+	assert.Empty(t, graph.GetReferences("ReplicaSet", "default", "nginx-5d8f6b7c9"), "owned workloads are skipped")
+}
@@ -0,0 +1,177 @@
+package k8sinterface
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// field selector keys are dot separated paths, e.g. metadata.name, spec.nodeName, status.phase
+var fieldSelectorKeyRegexp = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*(\.[a-zA-Z][a-zA-Z0-9]*)*$`)
+
+// SelectorBuilder builds label and field selectors for ListOptions.
+// Keys and values are validated as they are added, the first errors are returned when rendering the selectors
+//
+//	listOptions, err := NewSelectorBuilder().
+//		Equals("app", "nginx").
+//		NotIn("tier", "cache", "db").
+//		DoesNotExist("kubescape.io/ignore").
+//		FieldEquals("status.phase", "Running").
+//		ListOptions()
+type SelectorBuilder struct {
+	labelRequirements []labels.Requirement
+	fieldSelectors    []fields.Selector
+	errs              []string
+}
+
+// NewSelectorBuilder returns an empty selector builder
+func NewSelectorBuilder() *SelectorBuilder {
+	return &SelectorBuilder{}
+}
+
+// Equals requires the label to equal the value
+func (builder *SelectorBuilder) Equals(key, value string) *SelectorBuilder {
+	return builder.addRequirement(key, selection.Equals, []string{value})
+}
+
+// NotEquals requires the label to be missing or not equal the value
+func (builder *SelectorBuilder) NotEquals(key, value string) *SelectorBuilder {
+	return builder.addRequirement(key, selection.NotEquals, []string{value})
+}
+
+// In requires the label to equal one of the values
+func (builder *SelectorBuilder) In(key string, values ...string) *SelectorBuilder {
+	return builder.addRequirement(key, selection.In, values)
+}
+
+// NotIn requires the label to be missing or not equal any of the values
+func (builder *SelectorBuilder) NotIn(key string, values ...string) *SelectorBuilder {
+	return builder.addRequirement(key, selection.NotIn, values)
+}
+
+// Exists requires the label to be set
+func (builder *SelectorBuilder) Exists(key string) *SelectorBuilder {
+	return builder.addRequirement(key, selection.Exists, nil)
+}
+
+// DoesNotExist requires the label not to be set
+func (builder *SelectorBuilder) DoesNotExist(key string) *SelectorBuilder {
+	return builder.addRequirement(key, selection.DoesNotExist, nil)
+}
+
+// MatchLabels requires all the labels to equal the values, e.g. a workload selector matchLabels
+func (builder *SelectorBuilder) MatchLabels(matchLabels map[string]string) *SelectorBuilder {
+	keys := make([]string, 0, len(matchLabels))
+	for key := range matchLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		builder.Equals(key, matchLabels[key])
+	}
+	return builder
+}
+
+// LabelSelectorRequirements adds the requirements of a metav1.LabelSelector (matchLabels and matchExpressions)
+func (builder *SelectorBuilder) LabelSelectorRequirements(labelSelector *metav1.LabelSelector) *SelectorBuilder {
+	if labelSelector == nil {
+		return builder
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		builder.errs = append(builder.errs, err.Error())
+		return builder
+	}
+	requirements, _ := selector.Requirements()
+	builder.labelRequirements = append(builder.labelRequirements, requirements...)
+	return builder
+}
+
+// FieldEquals requires the field to equal the value
+func (builder *SelectorBuilder) FieldEquals(key, value string) *SelectorBuilder {
+	if builder.validateFieldKey(key) {
+		builder.fieldSelectors = append(builder.fieldSelectors, fields.OneTermEqualSelector(key, value))
+	}
+	return builder
+}
+
+// FieldNotEquals requires the field not to equal the value
+func (builder *SelectorBuilder) FieldNotEquals(key, value string) *SelectorBuilder {
+	if builder.validateFieldKey(key) {
+		builder.fieldSelectors = append(builder.fieldSelectors, fields.OneTermNotEqualSelector(key, value))
+	}
+	return builder
+}
+
+// MatchFields requires all the fields to equal the values
+func (builder *SelectorBuilder) MatchFields(matchFields map[string]string) *SelectorBuilder {
+	keys := make([]string, 0, len(matchFields))
+	for key := range matchFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		builder.FieldEquals(key, matchFields[key])
+	}
+	return builder
+}
+
+// LabelSelector returns the rendered label selector
+func (builder *SelectorBuilder) LabelSelector() (string, error) {
+	if err := builder.err(); err != nil {
+		return "", err
+	}
+	return labels.NewSelector().Add(builder.labelRequirements...).String(), nil
+}
+
+// FieldSelector returns the rendered field selector
+func (builder *SelectorBuilder) FieldSelector() (string, error) {
+	if err := builder.err(); err != nil {
+		return "", err
+	}
+	return fields.AndSelectors(builder.fieldSelectors...).String(), nil
+}
+
+// ListOptions returns ListOptions with the rendered label and field selectors
+func (builder *SelectorBuilder) ListOptions() (metav1.ListOptions, error) {
+	labelSelector, err := builder.LabelSelector()
+	if err != nil {
+		return metav1.ListOptions{}, err
+	}
+	fieldSelector, err := builder.FieldSelector()
+	if err != nil {
+		return metav1.ListOptions{}, err
+	}
+	return metav1.ListOptions{LabelSelector: labelSelector, FieldSelector: fieldSelector}, nil
+}
+
+func (builder *SelectorBuilder) addRequirement(key string, op selection.Operator, values []string) *SelectorBuilder {
+	requirement, err := labels.NewRequirement(key, op, values)
+	if err != nil {
+		builder.errs = append(builder.errs, err.Error())
+		return builder
+	}
+	builder.labelRequirements = append(builder.labelRequirements, *requirement)
+	return builder
+}
+
+func (builder *SelectorBuilder) validateFieldKey(key string) bool {
+	if !fieldSelectorKeyRegexp.MatchString(key) {
+		builder.errs = append(builder.errs, fmt.Sprintf("invalid field selector key '%s'", key))
+		return false
+	}
+	return true
+}
+
+func (builder *SelectorBuilder) err() error {
+	if len(builder.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid selector: %s", strings.Join(builder.errs, "; "))
+}
@@ -0,0 +1,68 @@
+package k8sinterface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestSelectorBuilder(t *testing.T) {
+	listOptions, err := NewSelectorBuilder().
+		Equals("app", "nginx").
+		NotIn("tier", "db", "cache").
+		DoesNotExist("kubescape.io/ignore").
+		FieldEquals("status.phase", "Running").
+		FieldNotEquals("spec.nodeName", "node-1").
+		ListOptions()
+	assert.NoError(t, err)
+	assert.Equal(t, "app=nginx,!kubescape.io/ignore,tier notin (cache,db)", listOptions.LabelSelector)
+	assert.Equal(t, "status.phase=Running,spec.nodeName!=node-1", listOptions.FieldSelector)
+
+	// the rendered selector is parsable by the API server
+	_, err = labels.Parse(listOptions.LabelSelector)
+	assert.NoError(t, err)
+}
+
+func TestSelectorBuilderMatchLabels(t *testing.T) {
+	labelSelector, err := NewSelectorBuilder().MatchLabels(map[string]string{"b": "2", "a": "1"}).Exists("c").LabelSelector()
+	assert.NoError(t, err)
+	assert.Equal(t, "a=1,b=2,c", labelSelector)
+
+	labelSelector, err = NewSelectorBuilder().LabelSelectorRequirements(&metav1.LabelSelector{
+		MatchLabels:      map[string]string{"app": "nginx"},
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: metav1.LabelSelectorOpIn, Values: []string{"prod"}}},
+	}).LabelSelector()
+	assert.NoError(t, err)
+	assert.Equal(t, "app=nginx,env in (prod)", labelSelector)
+
+	fieldSelector, err := NewSelectorBuilder().MatchFields(map[string]string{"metadata.namespace": "default", "metadata.name": "nginx"}).FieldSelector()
+	assert.NoError(t, err)
+	assert.Equal(t, "metadata.name=nginx,metadata.namespace=default", fieldSelector)
+
+	empty, err := NewSelectorBuilder().ListOptions()
+	assert.NoError(t, err)
+	assert.Equal(t, metav1.ListOptions{}, empty)
+}
+
+func TestSelectorBuilderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *SelectorBuilder
+	}{
+		{name: "invalid label key", builder: NewSelectorBuilder().Equals("in valid", "a")},
+		{name: "invalid label value", builder: NewSelectorBuilder().Equals("app", "in valid")},
+		{name: "in without values", builder: NewSelectorBuilder().In("app")},
+		{name: "invalid field key", builder: NewSelectorBuilder().FieldEquals("status.phase=Running,spec", "a")},
+		{name: "invalid label selector", builder: NewSelectorBuilder().LabelSelectorRequirements(&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "app", Operator: "bad"}}})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.ListOptions()
+			assert.Error(t, err)
+			_, err = tt.builder.LabelSelector()
+			assert.Error(t, err)
+		})
+	}
+}
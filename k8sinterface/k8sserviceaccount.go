@@ -0,0 +1,194 @@
+package k8sinterface
+
+import (
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	DefaultServiceAccountName = "default"
+
+	// DefaultProjectedTokenExpirationSeconds is the expiration the API server defaults the projected tokens to
+	DefaultProjectedTokenExpirationSeconds int64 = 3600
+
+	AutomountSourcePod            = "pod"
+	AutomountSourceServiceAccount = "serviceAccount"
+	AutomountSourceDefault        = "default"
+)
+
+// ServiceAccountTokenAudit is the report of the service account token of a workload
+type ServiceAccountTokenAudit struct {
+	Namespace          string `json:"namespace"`
+	Kind               string `json:"kind"`
+	Name               string `json:"name"`
+	ServiceAccountName string `json:"serviceAccountName"`
+	// ServiceAccountExists is false if the service account of the workload is not found
+	ServiceAccountExists bool `json:"serviceAccountExists"`
+	// AutomountServiceAccountToken is true if the token is mounted into the containers
+	AutomountServiceAccountToken bool `json:"automountServiceAccountToken"`
+	// AutomountSource is where the automount setting comes from, one of pod/serviceAccount/default
+	AutomountSource string `json:"automountSource"`
+	// ProjectedTokens are the service account tokens of the projected volumes of the pod spec
+	ProjectedTokens []ProjectedServiceAccountToken `json:"projectedTokens,omitempty"`
+	// ClusterAdminEquivalent is true if a ClusterRoleBinding grants the service account all the verbs on all the resources
+	ClusterAdminEquivalent bool `json:"clusterAdminEquivalent"`
+	// ClusterAdminBindings are the names of the ClusterRoleBindings granting the cluster-admin-equivalent permissions
+	ClusterAdminBindings []string `json:"clusterAdminBindings,omitempty"`
+}
+
+// ProjectedServiceAccountToken is a service account token of a projected volume
+type ProjectedServiceAccountToken struct {
+	Volume string `json:"volume"`
+	Path   string `json:"path"`
+	// Audience is empty for the audience of the API server
+	Audience          string `json:"audience,omitempty"`
+	ExpirationSeconds int64  `json:"expirationSeconds"`
+}
+
+// GetServiceAccountTokenAutomount returns whether the service account token is mounted into the containers of the pod spec and where the setting comes from.
+// The pod spec setting takes precedence over the service account one, the token is mounted if none is set
+func GetServiceAccountTokenAutomount(podSpec *corev1.PodSpec, serviceAccount *corev1.ServiceAccount) (bool, string) {
+	if podSpec.AutomountServiceAccountToken != nil {
+		return *podSpec.AutomountServiceAccountToken, AutomountSourcePod
+	}
+	if serviceAccount != nil && serviceAccount.AutomountServiceAccountToken != nil {
+		return *serviceAccount.AutomountServiceAccountToken, AutomountSourceServiceAccount
+	}
+	return true, AutomountSourceDefault
+}
+
+// GetProjectedServiceAccountTokens returns the service account tokens of the projected volumes of the pod spec.
+// On pods, this includes the kube-api-access-* volume the API server adds when the token is auto-mounted
+func GetProjectedServiceAccountTokens(podSpec *corev1.PodSpec) []ProjectedServiceAccountToken {
+	var tokens []ProjectedServiceAccountToken
+	for i := range podSpec.Volumes {
+		if podSpec.Volumes[i].Projected == nil {
+			continue
+		}
+		for _, source := range podSpec.Volumes[i].Projected.Sources {
+			if source.ServiceAccountToken == nil {
+				continue
+			}
+			token := ProjectedServiceAccountToken{
+				Volume:            podSpec.Volumes[i].Name,
+				Path:              source.ServiceAccountToken.Path,
+				Audience:          source.ServiceAccountToken.Audience,
+				ExpirationSeconds: DefaultProjectedTokenExpirationSeconds,
+			}
+			if source.ServiceAccountToken.ExpirationSeconds != nil {
+				token.ExpirationSeconds = *source.ServiceAccountToken.ExpirationSeconds
+			}
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// IsClusterAdminEquivalent returns true if a rule grants all the verbs on all the resources of all the API groups
+func IsClusterAdminEquivalent(rules []rbacv1.PolicyRule) bool {
+	for i := range rules {
+		if contains(rules[i].Verbs, rbacv1.VerbAll) && contains(rules[i].APIGroups, rbacv1.APIGroupAll) && contains(rules[i].Resources, rbacv1.ResourceAll) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsServiceAccountSubject returns true if the subject is the service account, or a group the service account belongs to
+func IsServiceAccountSubject(subject *rbacv1.Subject, namespace, name string) bool {
+	switch subject.Kind {
+	case rbacv1.ServiceAccountKind:
+		return subject.Namespace == namespace && subject.Name == name
+	case rbacv1.UserKind:
+		return subject.Name == fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name)
+	case rbacv1.GroupKind:
+		return subject.Name == "system:serviceaccounts" || subject.Name == "system:serviceaccounts:"+namespace || subject.Name == "system:authenticated"
+	}
+	return false
+}
+
+// GetServiceAccountTokenAudit returns the service account token report of the workload
+func (k8sAPI *KubernetesApi) GetServiceAccountTokenAudit(workload IWorkload) (*ServiceAccountTokenAudit, error) {
+	podSpec, err := workload.GetPodSpec()
+	if err != nil {
+		return nil, err
+	}
+	audit := &ServiceAccountTokenAudit{
+		Namespace:          workload.GetNamespace(),
+		Kind:               workload.GetKind(),
+		Name:               workload.GetName(),
+		ServiceAccountName: podSpec.ServiceAccountName,
+		ProjectedTokens:    GetProjectedServiceAccountTokens(podSpec),
+	}
+	if audit.ServiceAccountName == "" {
+		audit.ServiceAccountName = DefaultServiceAccountName
+	}
+
+	ctx, span := k8sAPI.startSpan("k8s.GetServiceAccount", &schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}, audit.Namespace, audit.ServiceAccountName)
+	serviceAccount, err := k8sAPI.KubernetesClient.CoreV1().ServiceAccounts(audit.Namespace).Get(ctx, audit.ServiceAccountName, metav1.GetOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to GET service account '%s', reason: %s", audit.ServiceAccountName, err.Error())
+		}
+		serviceAccount = nil
+	}
+	audit.ServiceAccountExists = serviceAccount != nil
+	audit.AutomountServiceAccountToken, audit.AutomountSource = GetServiceAccountTokenAutomount(podSpec, serviceAccount)
+
+	audit.ClusterAdminBindings, err = k8sAPI.getClusterAdminBindings(audit.Namespace, audit.ServiceAccountName)
+	if err != nil {
+		return nil, err
+	}
+	audit.ClusterAdminEquivalent = len(audit.ClusterAdminBindings) > 0
+	return audit, nil
+}
+
+// getClusterAdminBindings returns the ClusterRoleBindings granting the service account a cluster-admin-equivalent ClusterRole
+func (k8sAPI *KubernetesApi) getClusterAdminBindings(namespace, name string) ([]string, error) {
+	ctx, span := k8sAPI.startSpan("k8s.ListClusterRoleBindings", &schema.GroupVersionResource{Group: rbacv1.GroupName, Version: "v1", Resource: "clusterrolebindings"}, "", "")
+	clusterRoleBindings, err := k8sAPI.KubernetesClient.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST clusterrolebindings, reason: %s", err.Error())
+	}
+
+	clusterRoles := map[string]bool{}
+	var bindings []string
+	for i := range clusterRoleBindings.Items {
+		binding := &clusterRoleBindings.Items[i]
+		if binding.RoleRef.Kind != "ClusterRole" || !bindsServiceAccount(binding.Subjects, namespace, name) {
+			continue
+		}
+		clusterAdmin, ok := clusterRoles[binding.RoleRef.Name]
+		if !ok {
+			ctx, span := k8sAPI.startSpan("k8s.GetClusterRole", &schema.GroupVersionResource{Group: rbacv1.GroupName, Version: "v1", Resource: "clusterroles"}, "", binding.RoleRef.Name)
+			clusterRole, err := k8sAPI.KubernetesClient.RbacV1().ClusterRoles().Get(ctx, binding.RoleRef.Name, metav1.GetOptions{})
+			tracing.EndSpan(span, err)
+			if err != nil && !errors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to GET clusterrole '%s', reason: %s", binding.RoleRef.Name, err.Error())
+			}
+			clusterAdmin = err == nil && IsClusterAdminEquivalent(clusterRole.Rules)
+			clusterRoles[binding.RoleRef.Name] = clusterAdmin
+		}
+		if clusterAdmin {
+			bindings = append(bindings, binding.GetName())
+		}
+	}
+	return bindings, nil
+}
+
+func bindsServiceAccount(subjects []rbacv1.Subject, namespace, name string) bool {
+	for i := range subjects {
+		if IsServiceAccountSubject(&subjects[i], namespace, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetServiceAccountTokenAutomount(t *testing.T) {
+	disabled, enabled := false, true
+	automount, source := GetServiceAccountTokenAutomount(&corev1.PodSpec{}, nil)
+	assert.True(t, automount)
+	assert.Equal(t, AutomountSourceDefault, source)
+
+	automount, source = GetServiceAccountTokenAutomount(&corev1.PodSpec{}, &corev1.ServiceAccount{AutomountServiceAccountToken: &disabled})
+	assert.False(t, automount)
+	assert.Equal(t, AutomountSourceServiceAccount, source)
+
+	automount, source = GetServiceAccountTokenAutomount(&corev1.PodSpec{AutomountServiceAccountToken: &enabled}, &corev1.ServiceAccount{AutomountServiceAccountToken: &disabled})
+	assert.True(t, automount)
+	assert.Equal(t, AutomountSourcePod, source)
+}
+
+func TestGetProjectedServiceAccountTokens(t *testing.T) {
+	expiration := int64(600)
+	podSpec := &corev1.PodSpec{Volumes: []corev1.Volume{
+		{Name: "vault-token", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+			{ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Path: "token", Audience: "vault", ExpirationSeconds: &expiration}},
+		}}}},
+		{Name: "kube-api-access-x7k2p", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+			{ServiceAccountToken: &corev1.ServiceAccountTokenProjection{Path: "token"}},
+			{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "kube-root-ca.crt"}}},
+		}}}},
+	}}
+	assert.Equal(t, []ProjectedServiceAccountToken{
+		{Volume: "vault-token", Path: "token", Audience: "vault", ExpirationSeconds: 600},
+		{Volume: "kube-api-access-x7k2p", Path: "token", ExpirationSeconds: DefaultProjectedTokenExpirationSeconds},
+	}, GetProjectedServiceAccountTokens(podSpec))
+}
+
+func TestIsServiceAccountSubject(t *testing.T) {
+	assert.True(t, IsServiceAccountSubject(&rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "ci", Name: "deployer"}, "ci", "deployer"))
+	assert.False(t, IsServiceAccountSubject(&rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "default", Name: "deployer"}, "ci", "deployer"))
+	assert.True(t, IsServiceAccountSubject(&rbacv1.Subject{Kind: rbacv1.UserKind, Name: "system:serviceaccount:ci:deployer"}, "ci", "deployer"))
+	assert.True(t, IsServiceAccountSubject(&rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts:ci"}, "ci", "deployer"))
+	assert.False(t, IsServiceAccountSubject(&rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "system:masters"}, "ci", "deployer"))
+}
+
+func TestGetServiceAccountTokenAudit(t *testing.T) {
+	disabled := false
+	kubernetesClient := kubernetesfake.NewSimpleClientset(
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "deployer"}, AutomountServiceAccountToken: &disabled},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"}, Rules: []rbacv1.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}}},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "view"}, Rules: []rbacv1.PolicyRule{{Verbs: []string{"get", "list"}, APIGroups: []string{"*"}, Resources: []string{"*"}}}},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployer-admin"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Namespace: "ci", Name: "deployer"}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "serviceaccounts-view"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "view"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "system:serviceaccounts"}},
+		},
+	)
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesClient, Context: context.Background()}
+
+	deployment := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"namespace": "ci", "name": "runner"},
+		"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+			"serviceAccountName": "deployer",
+			"containers":         []interface{}{map[string]interface{}{"name": "runner"}},
+		}}},
+	})
+	audit, err := k8sAPI.GetServiceAccountTokenAudit(deployment)
+	require.NoError(t, err)
+	assert.Equal(t, "deployer", audit.ServiceAccountName)
+	assert.True(t, audit.ServiceAccountExists)
+	assert.False(t, audit.AutomountServiceAccountToken)
+	assert.Equal(t, AutomountSourceServiceAccount, audit.AutomountSource)
+	assert.True(t, audit.ClusterAdminEquivalent)
+	assert.Equal(t, []string{"deployer-admin"}, audit.ClusterAdminBindings)
+
+	pod := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"namespace": "ci", "name": "debug"},
+		"spec":       map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "debug"}}},
+	})
+	audit, err = k8sAPI.GetServiceAccountTokenAudit(pod)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultServiceAccountName, audit.ServiceAccountName)
+	assert.False(t, audit.ServiceAccountExists)
+	assert.True(t, audit.AutomountServiceAccountToken)
+	assert.False(t, audit.ClusterAdminEquivalent)
+}
@@ -0,0 +1,130 @@
+package k8sinterface
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DefaultSnapshotParallelism is the number of resources listed concurrently by SnapshotCluster
+const DefaultSnapshotParallelism = 10
+
+// SnapshotProgress is reported after each resource of the snapshot was listed
+type SnapshotProgress struct {
+	GroupVersionResource schema.GroupVersionResource
+	Count                int   // number of objects listed
+	Err                  error // error listing the resource, if any
+	Done                 int   // number of resources listed so far
+	Total                int   // number of resources in the snapshot
+}
+
+// SnapshotOptions configures SnapshotCluster
+type SnapshotOptions struct {
+	// Parallelism is the maximum number of resources listed concurrently, DefaultSnapshotParallelism if not set
+	Parallelism int
+	// NamespaceFilter selects the namespaces to list, all namespaces if nil
+	NamespaceFilter *NamespaceFilter
+	// ListOptions are passed to each list request. When Limit is set, all pages are fetched
+	ListOptions metav1.ListOptions
+	// OnProgress is called after each resource was listed. Calls are serialized
+	OnProgress func(progress SnapshotProgress)
+}
+
+// SnapshotErrors are the errors of the resources that failed to be listed
+type SnapshotErrors map[schema.GroupVersionResource]error
+
+func (snapshotErrors SnapshotErrors) Error() string {
+	errs := make([]string, 0, len(snapshotErrors))
+	for gvr, err := range snapshotErrors {
+		errs = append(errs, fmt.Sprintf("%s: %s", GroupVersionResourceToString(&gvr), err.Error()))
+	}
+	sort.Strings(errs)
+	return fmt.Sprintf("failed to list %d resources: %s", len(errs), strings.Join(errs, "; "))
+}
+
+// SnapshotCluster lists the resources concurrently and returns the objects keyed by resource.
+// A resource that fails to be listed does not fail the others: the returned map holds all the resources that were listed
+// and the error, if not nil, is a SnapshotErrors with the error of each failed resource
+func (k8sAPI *KubernetesApi) SnapshotCluster(ctx context.Context, gvrs []schema.GroupVersionResource, opts *SnapshotOptions) (map[schema.GroupVersionResource][]IWorkload, error) {
+	if opts == nil {
+		opts = &SnapshotOptions{}
+	}
+	ctx, span := tracing.StartSpan(ctx, "k8s.SnapshotCluster", tracing.AttributeCount.Int(len(gvrs)))
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = DefaultSnapshotParallelism
+	}
+
+	snapshot := make(map[schema.GroupVersionResource][]IWorkload, len(gvrs))
+	snapshotErrors := SnapshotErrors{}
+	done := 0
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	semaphore := make(chan struct{}, parallelism)
+
+	for i := range gvrs {
+		gvr := gvrs[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			var workloads []IWorkload
+			err := ctx.Err()
+			if err == nil {
+				workloads, err = k8sAPI.listAllPages(ctx, &gvr, opts.NamespaceFilter, opts.ListOptions)
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			done++
+			if err != nil {
+				snapshotErrors[gvr] = err
+			} else {
+				snapshot[gvr] = workloads
+			}
+			if opts.OnProgress != nil {
+				opts.OnProgress(SnapshotProgress{GroupVersionResource: gvr, Count: len(workloads), Err: err, Done: done, Total: len(gvrs)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(snapshotErrors) > 0 {
+		tracing.EndSpan(span, snapshotErrors)
+		return snapshot, snapshotErrors
+	}
+	tracing.EndSpan(span, nil)
+	return snapshot, nil
+}
+
+func (k8sAPI *KubernetesApi) listAllPages(ctx context.Context, groupVersionResource *schema.GroupVersionResource, filter *NamespaceFilter, listOptions metav1.ListOptions) ([]IWorkload, error) {
+	resourceInterface := k8sAPI.ResourceInterface(groupVersionResource, filter.listNamespace())
+	ctx, span := startSpan(ctx, "k8s.ListWorkloads", groupVersionResource, filter.listNamespace(), "")
+	var workloads []IWorkload
+	for {
+		uList, err := resourceInterface.List(ctx, listOptions)
+		if err != nil {
+			tracing.EndSpan(span, err)
+			return nil, fmt.Errorf("failed to LIST resources, reason: %s", err.Error())
+		}
+		for i := range uList.Items {
+			workloads = append(workloads, workloadinterface.NewWorkloadObj(uList.Items[i].Object))
+		}
+		if uList.GetContinue() == "" {
+			break
+		}
+		listOptions.Continue = uList.GetContinue()
+	}
+	span.SetAttributes(tracing.AttributeCount.Int(len(workloads)))
+	tracing.EndSpan(span, nil)
+	return filter.FilterWorkloads(workloads), nil
+}
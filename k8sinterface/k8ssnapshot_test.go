@@ -0,0 +1,71 @@
+package k8sinterface
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newUnstructured(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"namespace": namespace, "name": name},
+	}}
+}
+
+func TestSnapshotCluster(t *testing.T) {
+	InitializeMapResourcesMock()
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	nodes := schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{pods: "PodList", deployments: "DeploymentList", nodes: "NodeList", secrets: "SecretList"},
+		newUnstructured("v1", "Pod", "default", "nginx"),
+		newUnstructured("v1", "Pod", "kube-system", "coredns"),
+		newUnstructured("apps/v1", "Deployment", "default", "nginx"),
+		newUnstructured("v1", "Node", "", "node-1"),
+	)
+	dynamicClient.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+	k8sAPI := &KubernetesApi{DynamicClient: dynamicClient, Context: context.Background()}
+
+	var progress []SnapshotProgress
+	snapshot, err := k8sAPI.SnapshotCluster(context.Background(), []schema.GroupVersionResource{pods, deployments, nodes, secrets}, &SnapshotOptions{
+		Parallelism:     2,
+		NamespaceFilter: &NamespaceFilter{SkipSystemNamespaces: true},
+		OnProgress:      func(p SnapshotProgress) { progress = append(progress, p) },
+	})
+
+	var snapshotErrors SnapshotErrors
+	assert.True(t, errors.As(err, &snapshotErrors))
+	assert.Equal(t, 1, len(snapshotErrors))
+	assert.Contains(t, snapshotErrors[secrets].Error(), "forbidden")
+
+	assert.Equal(t, 3, len(snapshot))
+	assert.Equal(t, 1, len(snapshot[pods]))
+	assert.Equal(t, "nginx", snapshot[pods][0].GetName())
+	assert.Equal(t, 1, len(snapshot[deployments]))
+	assert.Equal(t, 1, len(snapshot[nodes]))
+
+	assert.Equal(t, 4, len(progress))
+	assert.Equal(t, 4, progress[3].Done)
+	assert.Equal(t, 4, progress[3].Total)
+
+	// cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	snapshot, err = k8sAPI.SnapshotCluster(ctx, []schema.GroupVersionResource{pods}, nil)
+	assert.Error(t, err)
+	assert.Equal(t, 0, len(snapshot))
+}
@@ -0,0 +1,321 @@
+package k8sinterface
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+)
+
+// SnapshotFormatVersion is the version of the snapshot archives written by Snapshot.Write. ReadSnapshot reads the archives of this version and older ones
+const SnapshotFormatVersion = 1
+
+// entries of the snapshot archive. The objects of a resource are in resources/<group>/<version>/<resource>.jsonl, one object per line, the core group being "core"
+const (
+	snapshotMetadataEntry  = "metadata.json"
+	snapshotDiscoveryEntry = "discovery.json"
+	snapshotResourcesDir   = "resources"
+	snapshotCloudDir       = "cloud"
+	snapshotCoreGroup      = "core"
+)
+
+// Snapshot is a captured state of a cluster: the objects of its resources, the discovery and the version of its API server and the describe outputs of its cloud provider.
+// It is stored as a gzipped tar archive (see Snapshot.Write and ReadSnapshot) and served offline by fake.NewKubernetesApiFromSnapshot
+type Snapshot struct {
+	Metadata SnapshotMetadata
+	// APIResourceLists are the preferred resources of the API server
+	APIResourceLists []*metav1.APIResourceList
+	Objects          map[schema.GroupVersionResource][]IWorkload
+	// Cloud are the describe outputs of the cloud provider (e.g. the EKS DescribeCluster output) by name, as JSON
+	Cloud map[string]json.RawMessage
+}
+
+// SnapshotMetadata describes the snapshot
+type SnapshotMetadata struct {
+	FormatVersion int           `json:"formatVersion"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	ClusterName   string        `json:"clusterName,omitempty"`
+	ServerVersion *version.Info `json:"serverVersion,omitempty"`
+	// Resources are the resources that were listed, with their number of objects. A resource that failed to be listed has its error set and no objects
+	Resources []SnapshotResource `json:"resources"`
+}
+
+// SnapshotResource is a resource of a snapshot
+type SnapshotResource struct {
+	Group    string `json:"group,omitempty"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+	Count    int    `json:"count"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ExportSnapshotOptions configures CollectSnapshot and ExportSnapshot
+type ExportSnapshotOptions struct {
+	SnapshotOptions
+	ClusterName string
+	// Cloud are the describe outputs of the cloud provider to add to the snapshot by name, they are marshalled to JSON
+	Cloud map[string]interface{}
+	// IncludeSecretData keeps the data of the secrets. By default the secrets are not part of the resources listed when none are passed,
+	// and the secrets that are listed are stored without their data
+	IncludeSecretData bool
+}
+
+// secretsResource is the resource of the secrets, their data is not part of the snapshots unless ExportSnapshotOptions.IncludeSecretData is set
+var secretsResource = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+// ExportSnapshot collects the snapshot of the resources (see CollectSnapshot) and writes its archive to w
+func (k8sAPI *KubernetesApi) ExportSnapshot(ctx context.Context, w io.Writer, gvrs []schema.GroupVersionResource, opts *ExportSnapshotOptions) error {
+	snapshot, err := k8sAPI.CollectSnapshot(ctx, gvrs, opts)
+	if err != nil {
+		return err
+	}
+	return snapshot.Write(w)
+}
+
+// CollectSnapshot lists the resources (all the listable preferred resources of the API server if none, except the secrets unless ExportSnapshotOptions.IncludeSecretData is set)
+// with SnapshotCluster, along with the discovery and the version of the API server. Resources that fail to be listed do not fail the snapshot, their error is kept in the metadata
+func (k8sAPI *KubernetesApi) CollectSnapshot(ctx context.Context, gvrs []schema.GroupVersionResource, opts *ExportSnapshotOptions) (*Snapshot, error) {
+	if opts == nil {
+		opts = &ExportSnapshotOptions{}
+	}
+	snapshot := &Snapshot{
+		Metadata: SnapshotMetadata{FormatVersion: SnapshotFormatVersion, CreatedAt: time.Now().UTC(), ClusterName: opts.ClusterName},
+		Cloud:    map[string]json.RawMessage{},
+	}
+
+	resourceLists, err := k8sAPI.DiscoveryClient.ServerPreferredResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, fmt.Errorf("failed to discover the API resources, reason: %s", err.Error())
+	}
+	snapshot.APIResourceLists = resourceLists
+	if snapshot.Metadata.ServerVersion, err = k8sAPI.DiscoveryClient.ServerVersion(); err != nil {
+		return nil, fmt.Errorf("failed to get the server version, reason: %s", err.Error())
+	}
+	if len(gvrs) == 0 {
+		for _, gvr := range listableResources(resourceLists) {
+			if gvr == secretsResource && !opts.IncludeSecretData {
+				continue
+			}
+			gvrs = append(gvrs, gvr)
+		}
+	}
+
+	objects, err := k8sAPI.SnapshotCluster(ctx, gvrs, &opts.SnapshotOptions)
+	var snapshotErrors SnapshotErrors
+	if err != nil && !errors.As(err, &snapshotErrors) {
+		return nil, err
+	}
+	if !opts.IncludeSecretData {
+		for _, secret := range objects[secretsResource] {
+			secret.RemoveSecretData()
+			delete(secret.GetObject(), "stringData")
+		}
+	}
+	snapshot.Objects = objects
+	for _, gvr := range gvrs {
+		resource := SnapshotResource{Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource, Count: len(objects[gvr])}
+		if err, ok := snapshotErrors[gvr]; ok {
+			resource.Error = err.Error()
+		}
+		snapshot.Metadata.Resources = append(snapshot.Metadata.Resources, resource)
+	}
+
+	for name, output := range opts.Cloud {
+		data, err := json.Marshal(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal cloud output '%s', reason: %s", name, err.Error())
+		}
+		snapshot.Cloud[name] = data
+	}
+	return snapshot, nil
+}
+
+// Write writes the gzipped tar archive of the snapshot
+func (snapshot *Snapshot) Write(w io.Writer) error {
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	writeEntry := func(name string, data []byte) error {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: snapshot.Metadata.CreatedAt}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tarWriter.Write(data)
+		return err
+	}
+	writeJSONEntry := func(name string, v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal '%s', reason: %s", name, err.Error())
+		}
+		return writeEntry(name, data)
+	}
+
+	if err := writeJSONEntry(snapshotMetadataEntry, snapshot.Metadata); err != nil {
+		return err
+	}
+	if err := writeJSONEntry(snapshotDiscoveryEntry, snapshot.APIResourceLists); err != nil {
+		return err
+	}
+	gvrs := make([]schema.GroupVersionResource, 0, len(snapshot.Objects))
+	for gvr := range snapshot.Objects {
+		gvrs = append(gvrs, gvr)
+	}
+	sort.Slice(gvrs, func(i, j int) bool { return gvrs[i].String() < gvrs[j].String() })
+	for _, gvr := range gvrs {
+		buffer := bytes.Buffer{}
+		encoder := json.NewEncoder(&buffer)
+		for _, obj := range snapshot.Objects[gvr] {
+			if err := encoder.Encode(obj.GetObject()); err != nil {
+				return fmt.Errorf("failed to marshal %s '%s/%s', reason: %s", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err.Error())
+			}
+		}
+		if err := writeEntry(snapshotResourceEntry(gvr), buffer.Bytes()); err != nil {
+			return err
+		}
+	}
+	names := make([]string, 0, len(snapshot.Cloud))
+	for name := range snapshot.Cloud {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeEntry(path.Join(snapshotCloudDir, name+".json"), snapshot.Cloud[name]); err != nil {
+			return err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// ReadSnapshot reads the gzipped tar archive of a snapshot. Archives of a newer format version are rejected
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot, reason: %s", err.Error())
+	}
+	defer gzipReader.Close()
+
+	snapshot := &Snapshot{Objects: map[schema.GroupVersionResource][]IWorkload{}, Cloud: map[string]json.RawMessage{}}
+	hasMetadata := false
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshot, reason: %s", err.Error())
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		switch name := path.Clean(header.Name); {
+		case name == snapshotMetadataEntry:
+			if err := json.NewDecoder(tarReader).Decode(&snapshot.Metadata); err != nil {
+				return nil, fmt.Errorf("failed to read snapshot metadata, reason: %s", err.Error())
+			}
+			if snapshot.Metadata.FormatVersion > SnapshotFormatVersion {
+				return nil, fmt.Errorf("snapshot format version %d is not supported, the latest supported version is %d", snapshot.Metadata.FormatVersion, SnapshotFormatVersion)
+			}
+			hasMetadata = true
+		case name == snapshotDiscoveryEntry:
+			if err := json.NewDecoder(tarReader).Decode(&snapshot.APIResourceLists); err != nil {
+				return nil, fmt.Errorf("failed to read snapshot discovery, reason: %s", err.Error())
+			}
+		case strings.HasPrefix(name, snapshotResourcesDir+"/"):
+			gvr, ok := parseSnapshotResourceEntry(name)
+			if !ok {
+				return nil, fmt.Errorf("invalid snapshot entry '%s'", name)
+			}
+			objects, err := readSnapshotObjects(tarReader)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read snapshot entry '%s', reason: %s", name, err.Error())
+			}
+			snapshot.Objects[gvr] = objects
+		case strings.HasPrefix(name, snapshotCloudDir+"/") && strings.HasSuffix(name, ".json"):
+			data, err := io.ReadAll(tarReader)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read snapshot entry '%s', reason: %s", name, err.Error())
+			}
+			snapshot.Cloud[strings.TrimSuffix(strings.TrimPrefix(name, snapshotCloudDir+"/"), ".json")] = data
+		}
+	}
+	if !hasMetadata {
+		return nil, fmt.Errorf("invalid snapshot, %s not found", snapshotMetadataEntry)
+	}
+	return snapshot, nil
+}
+
+func readSnapshotObjects(r io.Reader) ([]IWorkload, error) {
+	var objects []IWorkload
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	for {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err != nil {
+			if err == io.EOF {
+				return objects, nil
+			}
+			return nil, err
+		}
+		objects = append(objects, workloadinterface.NewWorkloadObj(obj))
+	}
+}
+
+func snapshotResourceEntry(gvr schema.GroupVersionResource) string {
+	group := gvr.Group
+	if group == "" {
+		group = snapshotCoreGroup
+	}
+	return path.Join(snapshotResourcesDir, group, gvr.Version, gvr.Resource+".jsonl")
+}
+
+func parseSnapshotResourceEntry(name string) (schema.GroupVersionResource, bool) {
+	parts := strings.Split(strings.TrimSuffix(name, ".jsonl"), "/")
+	if len(parts) != 4 || !strings.HasSuffix(name, ".jsonl") {
+		return schema.GroupVersionResource{}, false
+	}
+	group := parts[1]
+	if group == snapshotCoreGroup {
+		group = ""
+	}
+	return schema.GroupVersionResource{Group: group, Version: parts[2], Resource: parts[3]}, true
+}
+
+// listableResources returns the resources of the lists that support the list verb, subresources excluded
+func listableResources(resourceLists []*metav1.APIResourceList) []schema.GroupVersionResource {
+	var gvrs []schema.GroupVersionResource
+	for i := range resourceLists {
+		if resourceLists[i] == nil {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(resourceLists[i].GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, apiResource := range resourceLists[i].APIResources {
+			if strings.Contains(apiResource.Name, "/") || !contains(apiResource.Verbs, "list") {
+				continue
+			}
+			gvrs = append(gvrs, gv.WithResource(apiResource.Name))
+		}
+	}
+	return gvrs
+}
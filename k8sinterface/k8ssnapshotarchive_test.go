@@ -0,0 +1,169 @@
+package k8sinterface
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestExportSnapshot(t *testing.T) {
+	InitializeMapResourcesMock()
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{pods: "PodList", deployments: "DeploymentList", secrets: "SecretList"},
+		newUnstructured("v1", "Pod", "default", "nginx"),
+		newUnstructured("v1", "Pod", "default", "redis"),
+	)
+	dynamicClient.PrependReactor("list", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+	discoveryClient := kubernetesfake.NewSimpleClientset().Discovery()
+	discoveryClient.(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{Major: "1", Minor: "25", GitVersion: "v1.25.3"}
+	k8sAPI := &KubernetesApi{DynamicClient: dynamicClient, DiscoveryClient: discoveryClient, Context: context.Background()}
+
+	buffer := bytes.Buffer{}
+	err := k8sAPI.ExportSnapshot(context.Background(), &buffer, []schema.GroupVersionResource{pods, deployments, secrets}, &ExportSnapshotOptions{
+		ClusterName: "prod",
+		Cloud:       map[string]interface{}{"eks-describe-cluster": map[string]interface{}{"Cluster": map[string]interface{}{"Name": "prod"}}},
+	})
+	require.NoError(t, err)
+
+	snapshot, err := ReadSnapshot(&buffer)
+	require.NoError(t, err)
+	assert.Equal(t, SnapshotFormatVersion, snapshot.Metadata.FormatVersion)
+	assert.Equal(t, "prod", snapshot.Metadata.ClusterName)
+	assert.Equal(t, "v1.25.3", snapshot.Metadata.ServerVersion.GitVersion)
+	assert.Equal(t, []SnapshotResource{
+		{Version: "v1", Resource: "pods", Count: 2},
+		{Group: "apps", Version: "v1", Resource: "deployments"},
+		{Version: "v1", Resource: "secrets", Error: "failed to LIST resources, reason: forbidden"},
+	}, snapshot.Metadata.Resources)
+
+	require.Len(t, snapshot.Objects[pods], 2)
+	names := []string{snapshot.Objects[pods][0].GetName(), snapshot.Objects[pods][1].GetName()}
+	assert.ElementsMatch(t, []string{"nginx", "redis"}, names)
+	objects, ok := snapshot.Objects[deployments]
+	assert.True(t, ok, "resources without objects are part of the snapshot")
+	assert.Empty(t, objects)
+	_, ok = snapshot.Objects[secrets]
+	assert.False(t, ok)
+	assert.JSONEq(t, `{"Cluster":{"Name":"prod"}}`, string(snapshot.Cloud["eks-describe-cluster"]))
+}
+
+func TestExportSnapshotSecrets(t *testing.T) {
+	InitializeMapResourcesMock()
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	secret := newUnstructured("v1", "Secret", "default", "db")
+	secret.Object["data"] = map[string]interface{}{"password": "c2VjcmV0"}
+	secret.SetAnnotations(map[string]string{"kubectl.kubernetes.io/last-applied-configuration": `{"data":{"password":"c2VjcmV0"}}`})
+	newAPI := func() *KubernetesApi {
+		dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{pods: "PodList", secrets: "SecretList"},
+			newUnstructured("v1", "Pod", "default", "nginx"), secret.DeepCopy())
+		discoveryClient := kubernetesfake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+		discoveryClient.Resources = []*metav1.APIResourceList{{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: metav1.Verbs{"list"}},
+			{Name: "secrets", Kind: "Secret", Namespaced: true, Verbs: metav1.Verbs{"list"}},
+		}}}
+		return &KubernetesApi{DynamicClient: dynamicClient, DiscoveryClient: &preferredResourcesDiscovery{discoveryClient}, Context: context.Background()}
+	}
+	export := func(gvrs []schema.GroupVersionResource, opts *ExportSnapshotOptions) (*Snapshot, string) {
+		buffer := bytes.Buffer{}
+		require.NoError(t, newAPI().ExportSnapshot(context.Background(), &buffer, gvrs, opts))
+		archive := buffer.String()
+		snapshot, err := ReadSnapshot(&buffer)
+		require.NoError(t, err)
+		return snapshot, archive
+	}
+
+	// the secrets are not part of the default resources
+	snapshot, _ := export(nil, nil)
+	assert.Equal(t, []SnapshotResource{{Version: "v1", Resource: "pods", Count: 1}}, snapshot.Metadata.Resources)
+	_, ok := snapshot.Objects[secrets]
+	assert.False(t, ok)
+
+	// the secrets that are listed have no data
+	snapshot, archive := export([]schema.GroupVersionResource{secrets}, nil)
+	require.Len(t, snapshot.Objects[secrets], 1)
+	assert.Equal(t, "db", snapshot.Objects[secrets][0].GetName())
+	assert.NotContains(t, snapshot.Objects[secrets][0].GetObject(), "data")
+	_, ok = snapshot.Objects[secrets][0].GetAnnotation("kubectl.kubernetes.io/last-applied-configuration")
+	assert.False(t, ok)
+	assert.NotContains(t, archive, "c2VjcmV0")
+
+	// opt-in
+	snapshot, _ = export(nil, &ExportSnapshotOptions{IncludeSecretData: true})
+	require.Len(t, snapshot.Objects[secrets], 1)
+	assert.Equal(t, map[string]interface{}{"password": "c2VjcmV0"}, snapshot.Objects[secrets][0].GetObject()["data"])
+}
+
+func TestReadSnapshotErrors(t *testing.T) {
+	newArchive := func(entries map[string]string) *bytes.Buffer {
+		buffer := &bytes.Buffer{}
+		gzipWriter := gzip.NewWriter(buffer)
+		tarWriter := tar.NewWriter(gzipWriter)
+		for name, content := range entries {
+			require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+			_, err := tarWriter.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tarWriter.Close())
+		require.NoError(t, gzipWriter.Close())
+		return buffer
+	}
+
+	_, err := ReadSnapshot(newArchive(map[string]string{"metadata.json": `{"formatVersion": 2}`}))
+	assert.ErrorContains(t, err, "snapshot format version 2 is not supported")
+
+	_, err = ReadSnapshot(newArchive(map[string]string{"discovery.json": `[]`}))
+	assert.ErrorContains(t, err, "metadata.json not found")
+
+	_, err = ReadSnapshot(newArchive(map[string]string{"metadata.json": `{"formatVersion": 1}`, "resources/pods.jsonl": ``}))
+	assert.ErrorContains(t, err, "invalid snapshot entry")
+
+	_, err = ReadSnapshot(bytes.NewBufferString("not a snapshot"))
+	assert.Error(t, err)
+}
+
+func TestListableResources(t *testing.T) {
+	resourceLists := []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "pods", Kind: "Pod", Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "pods/log", Kind: "Pod", Verbs: metav1.Verbs{"get"}},
+			{Name: "bindings", Kind: "Binding", Verbs: metav1.Verbs{"create"}},
+		}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "deployments", Kind: "Deployment", Verbs: metav1.Verbs{"list"}}}},
+		nil,
+	}
+	assert.Equal(t, []schema.GroupVersionResource{
+		{Version: "v1", Resource: "pods"},
+		{Group: "apps", Version: "v1", Resource: "deployments"},
+	}, listableResources(resourceLists))
+}
+
+// preferredResourcesDiscovery serves the resources of the fake discovery as the preferred resources, the fake discovery has none
+type preferredResourcesDiscovery struct {
+	*fakediscovery.FakeDiscovery
+}
+
+func (discoveryClient *preferredResourcesDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return discoveryClient.Resources, nil
+}
@@ -1,13 +1,13 @@
 package k8sinterface
 
 import (
-	"context"
-
 	"github.com/armosec/utils-go/boolutils"
+	"github.com/kubescape/k8s-interface/tracing"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func IsLabel(labels map[string]string, key string) *bool {
@@ -38,7 +38,9 @@ func (k8sAPI *KubernetesApi) ListPods(namespace string, podLabels map[string]str
 		set := labels.Set(podLabels)
 		listOptions.LabelSelector = set.AsSelector().String()
 	}
-	pods, err := k8sAPI.KubernetesClient.CoreV1().Pods(namespace).List(context.Background(), listOptions)
+	ctx, span := k8sAPI.startSpan("k8s.ListPods", &schema.GroupVersionResource{Version: "v1", Resource: "pods"}, namespace, "")
+	pods, err := k8sAPI.KubernetesClient.CoreV1().Pods(namespace).List(ctx, listOptions)
+	tracing.EndSpan(span, err)
 	if err != nil {
 		return nil, err
 	}
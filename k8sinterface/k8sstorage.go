@@ -0,0 +1,327 @@
+package k8sinterface
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// provisioners/CSI drivers of the cloud disks, in-tree and CSI
+const (
+	awsEBSCSIDriver        = "ebs.csi.aws.com"
+	awsEBSInTreePlugin     = "kubernetes.io/aws-ebs"
+	azureDiskCSIDriver     = "disk.csi.azure.com"
+	azureDiskInTreePlugin  = "kubernetes.io/azure-disk"
+	gcePDCSIDriver         = "pd.csi.storage.gke.io"
+	gcePDInTreePlugin      = "kubernetes.io/gce-pd"
+	zoneTopologyLabel      = "topology.kubernetes.io/zone"
+	gcePDZoneTopologyLabel = "topology.gke.io/zone"
+)
+
+// StorageInventory is the persistent storage of a namespace (or of the cluster): the claims with their volumes, storage classes and CSI drivers, and the claims each workload mounts
+type StorageInventory struct {
+	Storage   []PersistentStorage `json:"storage"`
+	Workloads []WorkloadStorage   `json:"workloads"`
+}
+
+// WorkloadStorage are the claims a workload mounts
+type WorkloadStorage struct {
+	WorkloadID string `json:"workloadID"`
+	Namespace  string `json:"namespace"`
+	// Claims are the names of the claims of the namespace of the workload, see StorageInventory.Storage for their volumes
+	Claims []string `json:"claims"`
+}
+
+// PersistentStorage is a persistent volume claim with the volume bound to it, its storage class and CSI driver
+type PersistentStorage struct {
+	ClaimNamespace string `json:"claimNamespace"`
+	ClaimName      string `json:"claimName"`
+	// VolumeName is empty while the claim is not bound
+	VolumeName       string                               `json:"volumeName,omitempty"`
+	StorageClassName string                               `json:"storageClassName,omitempty"`
+	Capacity         string                               `json:"capacity,omitempty"`
+	AccessModes      []corev1.PersistentVolumeAccessMode  `json:"accessModes,omitempty"`
+	ReclaimPolicy    corev1.PersistentVolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+	// Provisioner is the provisioner of the storage class, the CSI driver or the in-tree volume plugin
+	Provisioner string `json:"provisioner,omitempty"`
+	// CSIDriver is the CSI driver of the volume, nil for in-tree volumes or if the driver is not registered in the cluster
+	CSIDriver *CSIDriverInfo `json:"csiDriver,omitempty"`
+	// Disk is the cloud disk backing the volume, nil if the volume is not an AWS EBS volume, an Azure disk or a GCE persistent disk
+	Disk       *CloudDisk        `json:"disk,omitempty"`
+	Encryption StorageEncryption `json:"encryption"`
+}
+
+// CSIDriverInfo are the attributes of a CSI driver registered in the cluster
+type CSIDriverInfo struct {
+	Name                 string                          `json:"name"`
+	AttachRequired       bool                            `json:"attachRequired"`
+	FSGroupPolicy        string                          `json:"fsGroupPolicy,omitempty"`
+	VolumeLifecycleModes []storagev1.VolumeLifecycleMode `json:"volumeLifecycleModes,omitempty"`
+}
+
+// CloudDisk identifies the cloud disk backing a volume, for the lookup of its encryption with the cloudsupport GetDiskEncryption of the provider
+type CloudDisk struct {
+	// Provider is one of aws/azure/gcp
+	Provider string `json:"provider"`
+	// ID is the EBS volume ID (AWS), the disk resource ID (Azure) or the projects/<project>/(zones|regions)/<location>/disks/<name> of the disk (GCP).
+	// In-tree GCE persistent disks have no project (zones/<zone>/disks/<name>), they are in the project of the cluster
+	ID string `json:"id"`
+}
+
+// StorageEncryption is the encryption of the storage as the storage class requests it. The cloud side encryption of the disk is the authority, the storage class
+// does not apply to statically provisioned volumes and the account/project may enforce a default encryption
+type StorageEncryption struct {
+	// Encrypted is nil if the encryption is unknown (not a cloud disk). Azure disks and GCE persistent disks are always encrypted
+	Encrypted *bool `json:"encrypted,omitempty"`
+	// CustomerManagedKey is true if the storage class requests an encryption with a key of the customer
+	CustomerManagedKey bool `json:"customerManagedKey"`
+	// KeyID is the KMS key ID/ARN (AWS), the disk encryption set ID (Azure) or the Cloud KMS key (GCP) of the storage class
+	KeyID string `json:"keyID,omitempty"`
+}
+
+// GetStorageInventory returns the claims of the namespace ("" for all the namespaces) with their volumes, storage classes and CSI drivers, and the claims the workloads mount
+func (k8sAPI *KubernetesApi) GetStorageInventory(namespace string, workloads []IWorkload) (*StorageInventory, error) {
+	ctx, span := k8sAPI.startSpan("k8s.ListPersistentVolumeClaims", &schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}, namespace, "")
+	claims, err := k8sAPI.KubernetesClient.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST persistentvolumeclaims, reason: %s", err.Error())
+	}
+
+	ctx, span = k8sAPI.startSpan("k8s.ListPersistentVolumes", &schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"}, "", "")
+	volumes, err := k8sAPI.KubernetesClient.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST persistentvolumes, reason: %s", err.Error())
+	}
+
+	ctx, span = k8sAPI.startSpan("k8s.ListStorageClasses", &schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}, "", "")
+	storageClasses, err := k8sAPI.KubernetesClient.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST storageclasses, reason: %s", err.Error())
+	}
+
+	ctx, span = k8sAPI.startSpan("k8s.ListCSIDrivers", &schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "csidrivers"}, "", "")
+	csiDrivers, err := k8sAPI.KubernetesClient.StorageV1().CSIDrivers().List(ctx, metav1.ListOptions{})
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST csidrivers, reason: %s", err.Error())
+	}
+
+	volumesByName := map[string]*corev1.PersistentVolume{}
+	for i := range volumes.Items {
+		volumesByName[volumes.Items[i].Name] = &volumes.Items[i]
+	}
+	storageClassesByName := map[string]*storagev1.StorageClass{}
+	for i := range storageClasses.Items {
+		storageClassesByName[storageClasses.Items[i].Name] = &storageClasses.Items[i]
+	}
+	csiDriversByName := map[string]*storagev1.CSIDriver{}
+	for i := range csiDrivers.Items {
+		csiDriversByName[csiDrivers.Items[i].Name] = &csiDrivers.Items[i]
+	}
+
+	inventory := &StorageInventory{Storage: []PersistentStorage{}, Workloads: []WorkloadStorage{}}
+	for i := range claims.Items {
+		claim := &claims.Items[i]
+		volume := volumesByName[claim.Spec.VolumeName]
+		storageClassName := claimStorageClassName(claim, volume)
+		var csiDriver *storagev1.CSIDriver
+		if volume != nil && volume.Spec.CSI != nil {
+			csiDriver = csiDriversByName[volume.Spec.CSI.Driver]
+		}
+		inventory.Storage = append(inventory.Storage, NewPersistentStorage(claim, volume, storageClassesByName[storageClassName], csiDriver))
+	}
+	for _, workload := range workloads {
+		workloadClaims := WorkloadClaimNames(workload, claims.Items)
+		if len(workloadClaims) == 0 {
+			continue
+		}
+		inventory.Workloads = append(inventory.Workloads, WorkloadStorage{
+			WorkloadID: workload.GetID(),
+			Namespace:  workload.GetNamespace(),
+			Claims:     workloadClaims,
+		})
+	}
+	return inventory, nil
+}
+
+// NewPersistentStorage returns the storage of the claim. The volume (nil while the claim is not bound), the storage class and the CSI driver may be nil
+func NewPersistentStorage(claim *corev1.PersistentVolumeClaim, volume *corev1.PersistentVolume, storageClass *storagev1.StorageClass, csiDriver *storagev1.CSIDriver) PersistentStorage {
+	storage := PersistentStorage{
+		ClaimNamespace:   claim.GetNamespace(),
+		ClaimName:        claim.GetName(),
+		StorageClassName: claimStorageClassName(claim, volume),
+		AccessModes:      claim.Spec.AccessModes,
+	}
+	if capacity, ok := claim.Status.Capacity[corev1.ResourceStorage]; ok {
+		storage.Capacity = capacity.String()
+	}
+	if storageClass != nil {
+		storage.Provisioner = storageClass.Provisioner
+		if storageClass.ReclaimPolicy != nil {
+			storage.ReclaimPolicy = *storageClass.ReclaimPolicy
+		}
+	}
+	if volume != nil {
+		storage.VolumeName = volume.GetName()
+		storage.ReclaimPolicy = volume.Spec.PersistentVolumeReclaimPolicy
+		if volume.Spec.CSI != nil {
+			storage.Provisioner = volume.Spec.CSI.Driver
+		}
+		storage.Disk = newCloudDisk(volume)
+	}
+	if csiDriver != nil {
+		storage.CSIDriver = newCSIDriverInfo(csiDriver)
+	}
+	storage.Encryption = newStorageEncryption(storage.Provisioner, storageClass)
+	return storage
+}
+
+// WorkloadClaimNames returns the names of the claims the workload mounts: the claims of its volumes, the claims of its generic ephemeral volumes (pods only)
+// and the claims of the volume claim templates of a StatefulSet (<template>-<statefulset>-<ordinal>). Claims are of the namespace of the workload
+func WorkloadClaimNames(workload IWorkload, claims []corev1.PersistentVolumeClaim) []string {
+	names := []string{}
+	add := func(name string) {
+		if !contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	if podSpec, err := workload.GetPodSpec(); err == nil && podSpec != nil {
+		for _, volume := range podSpec.Volumes {
+			switch {
+			case volume.PersistentVolumeClaim != nil:
+				add(volume.PersistentVolumeClaim.ClaimName)
+			case volume.Ephemeral != nil && workload.GetKind() == "Pod":
+				add(workload.GetName() + "-" + volume.Name)
+			}
+		}
+	}
+	if workload.GetKind() == "StatefulSet" {
+		templates, _, _ := unstructured.NestedSlice(workload.GetObject(), "spec", "volumeClaimTemplates")
+		for i := range templates {
+			template, ok := templates[i].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			templateName, _, _ := unstructured.NestedString(template, "metadata", "name")
+			if templateName == "" {
+				continue
+			}
+			prefix := templateName + "-" + workload.GetName() + "-"
+			for j := range claims {
+				if claims[j].GetNamespace() != workload.GetNamespace() || !strings.HasPrefix(claims[j].GetName(), prefix) {
+					continue
+				}
+				if _, err := strconv.Atoi(strings.TrimPrefix(claims[j].GetName(), prefix)); err == nil {
+					add(claims[j].GetName())
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// claimStorageClassName returns the storage class of the claim, or of its volume for claims without class
+func claimStorageClassName(claim *corev1.PersistentVolumeClaim, volume *corev1.PersistentVolume) string {
+	if claim.Spec.StorageClassName != nil && *claim.Spec.StorageClassName != "" {
+		return *claim.Spec.StorageClassName
+	}
+	if volume != nil {
+		return volume.Spec.StorageClassName
+	}
+	return ""
+}
+
+func newCSIDriverInfo(csiDriver *storagev1.CSIDriver) *CSIDriverInfo {
+	info := &CSIDriverInfo{
+		Name:                 csiDriver.GetName(),
+		AttachRequired:       csiDriver.Spec.AttachRequired == nil || *csiDriver.Spec.AttachRequired,
+		VolumeLifecycleModes: csiDriver.Spec.VolumeLifecycleModes,
+	}
+	if csiDriver.Spec.FSGroupPolicy != nil {
+		info.FSGroupPolicy = string(*csiDriver.Spec.FSGroupPolicy)
+	}
+	return info
+}
+
+// newCloudDisk returns the cloud disk of a CSI or in-tree EBS/Azure disk/GCE PD volume, nil for the other volumes
+func newCloudDisk(volume *corev1.PersistentVolume) *CloudDisk {
+	source := volume.Spec.PersistentVolumeSource
+	switch {
+	case source.CSI != nil && source.CSI.Driver == awsEBSCSIDriver:
+		return &CloudDisk{Provider: tracing.CloudProviderAWS, ID: source.CSI.VolumeHandle}
+	case source.CSI != nil && source.CSI.Driver == azureDiskCSIDriver:
+		return &CloudDisk{Provider: tracing.CloudProviderAzure, ID: source.CSI.VolumeHandle}
+	case source.CSI != nil && source.CSI.Driver == gcePDCSIDriver:
+		return &CloudDisk{Provider: tracing.CloudProviderGCP, ID: source.CSI.VolumeHandle}
+	case source.AWSElasticBlockStore != nil:
+		// aws://<zone>/<volume ID> or the volume ID
+		volumeID := source.AWSElasticBlockStore.VolumeID
+		return &CloudDisk{Provider: tracing.CloudProviderAWS, ID: volumeID[strings.LastIndex(volumeID, "/")+1:]}
+	case source.AzureDisk != nil:
+		return &CloudDisk{Provider: tracing.CloudProviderAzure, ID: source.AzureDisk.DataDiskURI}
+	case source.GCEPersistentDisk != nil:
+		zone := volume.GetLabels()[zoneTopologyLabel]
+		if zone == "" {
+			zone = volumeNodeAffinityZone(volume)
+		}
+		return &CloudDisk{Provider: tracing.CloudProviderGCP, ID: "zones/" + zone + "/disks/" + source.GCEPersistentDisk.PDName}
+	}
+	return nil
+}
+
+// volumeNodeAffinityZone returns the zone the node affinity of the volume requires, empty if none or several
+func volumeNodeAffinityZone(volume *corev1.PersistentVolume) string {
+	if volume.Spec.NodeAffinity == nil || volume.Spec.NodeAffinity.Required == nil {
+		return ""
+	}
+	for _, term := range volume.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, expression := range term.MatchExpressions {
+			if (expression.Key == zoneTopologyLabel || expression.Key == gcePDZoneTopologyLabel) && expression.Operator == corev1.NodeSelectorOpIn && len(expression.Values) == 1 {
+				return expression.Values[0]
+			}
+		}
+	}
+	return ""
+}
+
+// newStorageEncryption returns the encryption the parameters of the storage class request for the disks of the provisioner
+func newStorageEncryption(provisioner string, storageClass *storagev1.StorageClass) StorageEncryption {
+	// the parameters of the provisioners are case insensitive
+	parameters := map[string]string{}
+	if storageClass != nil {
+		for key, value := range storageClass.Parameters {
+			parameters[strings.ToLower(key)] = value
+		}
+	}
+	encryption := StorageEncryption{}
+	switch provisioner {
+	case awsEBSCSIDriver, awsEBSInTreePlugin:
+		encrypted, _ := strconv.ParseBool(parameters["encrypted"])
+		encryption.Encrypted = boolPtr(encrypted)
+		if encrypted {
+			encryption.KeyID = parameters["kmskeyid"]
+			encryption.CustomerManagedKey = encryption.KeyID != ""
+		}
+	case azureDiskCSIDriver, azureDiskInTreePlugin:
+		encryption.Encrypted = boolPtr(true)
+		encryption.KeyID = parameters["diskencryptionsetid"]
+		encryption.CustomerManagedKey = encryption.KeyID != ""
+	case gcePDCSIDriver, gcePDInTreePlugin:
+		encryption.Encrypted = boolPtr(true)
+		encryption.KeyID = parameters["disk-encryption-kms-key"]
+		encryption.CustomerManagedKey = encryption.KeyID != ""
+	}
+	return encryption
+}
@@ -0,0 +1,211 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestClaim(name, volumeName string, storageClassName *string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "db", Name: name},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: storageClassName,
+			VolumeName:       volumeName,
+		},
+		Status: corev1.PersistentVolumeClaimStatus{Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")}},
+	}
+}
+
+func TestGetStorageInventory(t *testing.T) {
+	retain := corev1.PersistentVolumeReclaimRetain
+	gp3, standard := "gp3", ""
+	fsGroupPolicy := storagev1.FileFSGroupPolicy
+	kubernetesClient := kubernetesfake.NewSimpleClientset(
+		newTestClaim("data-postgres-0", "pv-1", &gp3),
+		newTestClaim("data-postgres-1", "pv-2", &standard),
+		newTestClaim("data-postgres-backup", "", &gp3),
+		newTestClaim("uploads", "", nil),
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+			Spec: corev1.PersistentVolumeSpec{
+				StorageClassName:              "gp3",
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{Driver: "ebs.csi.aws.com", VolumeHandle: "vol-0123456789abcdef0"},
+				},
+			},
+		},
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-2"},
+			Spec: corev1.PersistentVolumeSpec{
+				StorageClassName:              "legacy",
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					AWSElasticBlockStore: &corev1.AWSElasticBlockStoreVolumeSource{VolumeID: "aws://us-east-1a/vol-0fedcba9876543210"},
+				},
+			},
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:    metav1.ObjectMeta{Name: "gp3"},
+			Provisioner:   "ebs.csi.aws.com",
+			ReclaimPolicy: &retain,
+			Parameters:    map[string]string{"type": "gp3", "Encrypted": "true", "kmsKeyId": "arn:aws:kms:us-east-1:015253967648:key/1234abcd"},
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "legacy"},
+			Provisioner: "kubernetes.io/aws-ebs",
+		},
+		&storagev1.CSIDriver{
+			ObjectMeta: metav1.ObjectMeta{Name: "ebs.csi.aws.com"},
+			Spec:       storagev1.CSIDriverSpec{FSGroupPolicy: &fsGroupPolicy},
+		},
+	)
+	k8sAPI := &KubernetesApi{KubernetesClient: kubernetesClient, Context: context.Background()}
+
+	statefulSet := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "StatefulSet",
+		"metadata":   map[string]interface{}{"namespace": "db", "name": "postgres"},
+		"spec": map[string]interface{}{
+			"volumeClaimTemplates": []interface{}{map[string]interface{}{"metadata": map[string]interface{}{"name": "data"}}},
+			"template":             map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{}}},
+		},
+	})
+	deployment := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"namespace": "db", "name": "api"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{},
+				"volumes": []interface{}{
+					map[string]interface{}{"name": "uploads", "persistentVolumeClaim": map[string]interface{}{"claimName": "uploads"}},
+					map[string]interface{}{"name": "tmp", "emptyDir": map[string]interface{}{}},
+				},
+			}},
+		},
+	})
+	noStorage := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"namespace": "db", "name": "stateless"},
+		"spec":       map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{}}}},
+	})
+
+	inventory, err := k8sAPI.GetStorageInventory("db", []IWorkload{statefulSet, deployment, noStorage})
+	require.NoError(t, err)
+	require.Len(t, inventory.Storage, 4)
+	storage := map[string]PersistentStorage{}
+	for _, s := range inventory.Storage {
+		storage[s.ClaimName] = s
+	}
+
+	dynamic := storage["data-postgres-0"]
+	assert.Equal(t, "pv-1", dynamic.VolumeName)
+	assert.Equal(t, "gp3", dynamic.StorageClassName)
+	assert.Equal(t, "10Gi", dynamic.Capacity)
+	assert.Equal(t, corev1.PersistentVolumeReclaimDelete, dynamic.ReclaimPolicy)
+	assert.Equal(t, "ebs.csi.aws.com", dynamic.Provisioner)
+	require.NotNil(t, dynamic.CSIDriver)
+	assert.True(t, dynamic.CSIDriver.AttachRequired)
+	assert.Equal(t, "File", dynamic.CSIDriver.FSGroupPolicy)
+	assert.Equal(t, &CloudDisk{Provider: "aws", ID: "vol-0123456789abcdef0"}, dynamic.Disk)
+	require.NotNil(t, dynamic.Encryption.Encrypted)
+	assert.True(t, *dynamic.Encryption.Encrypted)
+	assert.True(t, dynamic.Encryption.CustomerManagedKey)
+	assert.Equal(t, "arn:aws:kms:us-east-1:015253967648:key/1234abcd", dynamic.Encryption.KeyID)
+
+	// the class of the volume for a claim without class
+	static := storage["data-postgres-1"]
+	assert.Equal(t, "legacy", static.StorageClassName)
+	assert.Equal(t, "kubernetes.io/aws-ebs", static.Provisioner)
+	assert.Equal(t, corev1.PersistentVolumeReclaimRetain, static.ReclaimPolicy)
+	assert.Nil(t, static.CSIDriver)
+	assert.Equal(t, &CloudDisk{Provider: "aws", ID: "vol-0fedcba9876543210"}, static.Disk)
+	require.NotNil(t, static.Encryption.Encrypted)
+	assert.False(t, *static.Encryption.Encrypted)
+
+	pending := storage["data-postgres-backup"]
+	assert.Empty(t, pending.VolumeName)
+	assert.Equal(t, corev1.PersistentVolumeReclaimRetain, pending.ReclaimPolicy)
+	assert.Nil(t, pending.Disk)
+
+	assert.Nil(t, storage["uploads"].Encryption.Encrypted)
+
+	require.Len(t, inventory.Workloads, 2)
+	assert.Equal(t, statefulSet.GetID(), inventory.Workloads[0].WorkloadID)
+	assert.Equal(t, []string{"data-postgres-0", "data-postgres-1"}, inventory.Workloads[0].Claims)
+	assert.Equal(t, []string{"uploads"}, inventory.Workloads[1].Claims)
+}
+
+func TestWorkloadClaimNames(t *testing.T) {
+	pod := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"namespace": "default", "name": "builder"},
+		"spec": map[string]interface{}{
+			"containers": []interface{}{},
+			"volumes": []interface{}{
+				map[string]interface{}{"name": "scratch", "ephemeral": map[string]interface{}{"volumeClaimTemplate": map[string]interface{}{}}},
+				map[string]interface{}{"name": "cache", "persistentVolumeClaim": map[string]interface{}{"claimName": "cache"}},
+				map[string]interface{}{"name": "cache-again", "persistentVolumeClaim": map[string]interface{}{"claimName": "cache"}},
+			},
+		},
+	})
+	assert.Equal(t, []string{"builder-scratch", "cache"}, WorkloadClaimNames(pod, nil))
+}
+
+func TestNewPersistentStorageCloudDisks(t *testing.T) {
+	tests := []struct {
+		name         string
+		volume       *corev1.PersistentVolume
+		storageClass *storagev1.StorageClass
+		disk         *CloudDisk
+		encryption   StorageEncryption
+	}{
+		{
+			name: "Azure disk CSI with disk encryption set",
+			volume: &corev1.PersistentVolume{Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{Driver: "disk.csi.azure.com", VolumeHandle: "/subscriptions/sub/resourceGroups/mc_rg/providers/Microsoft.Compute/disks/pvc-1"},
+			}}},
+			storageClass: &storagev1.StorageClass{Provisioner: "disk.csi.azure.com", Parameters: map[string]string{"diskEncryptionSetID": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/diskEncryptionSets/des"}},
+			disk:         &CloudDisk{Provider: "azure", ID: "/subscriptions/sub/resourceGroups/mc_rg/providers/Microsoft.Compute/disks/pvc-1"},
+			encryption:   StorageEncryption{Encrypted: boolPtr(true), CustomerManagedKey: true, KeyID: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/diskEncryptionSets/des"},
+		},
+		{
+			name: "GCE PD in-tree with zone affinity",
+			volume: &corev1.PersistentVolume{Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{GCEPersistentDisk: &corev1.GCEPersistentDiskVolumeSource{PDName: "pd-1"}},
+				NodeAffinity: &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "topology.kubernetes.io/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"europe-west1-b"}}},
+				}}}},
+			}},
+			storageClass: &storagev1.StorageClass{Provisioner: "kubernetes.io/gce-pd"},
+			disk:         &CloudDisk{Provider: "gcp", ID: "zones/europe-west1-b/disks/pd-1"},
+			encryption:   StorageEncryption{Encrypted: boolPtr(true)},
+		},
+		{
+			name: "NFS",
+			volume: &corev1.PersistentVolume{Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{
+				NFS: &corev1.NFSVolumeSource{Server: "nfs", Path: "/"},
+			}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := NewPersistentStorage(newTestClaim("claim", "", nil), tt.volume, tt.storageClass, nil)
+			assert.Equal(t, tt.disk, storage.Disk)
+			assert.Equal(t, tt.encryption, storage.Encryption)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package k8sinterface
+
+import (
+	"context"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// WithContext returns a shallow copy of the KubernetesApi using the context for its calls,
+// so the calls are traced as children of the span of the context and are canceled with it
+func (k8sAPI *KubernetesApi) WithContext(ctx context.Context) *KubernetesApi {
+	// the copies share the caches of the KubernetesApi
+	k8sAPI.openAPIV3SchemaCache()
+	k8sAPICopy := *k8sAPI
+	k8sAPICopy.Context = ctx
+	return &k8sAPICopy
+}
+
+// startSpan starts the span of an API call, child of the span of the KubernetesApi context
+func (k8sAPI *KubernetesApi) startSpan(name string, groupVersionResource *schema.GroupVersionResource, namespace, objectName string) (context.Context, trace.Span) {
+	return startSpan(k8sAPI.Context, name, groupVersionResource, namespace, objectName)
+}
+
+func startSpan(ctx context.Context, name string, groupVersionResource *schema.GroupVersionResource, namespace, objectName string) (context.Context, trace.Span) {
+	ctx, span := tracing.StartSpan(ctx, name)
+	if span.IsRecording() {
+		if groupVersionResource != nil {
+			span.SetAttributes(tracing.AttributeResource.String(GroupVersionResourceToString(groupVersionResource)))
+		}
+		if namespace != "" {
+			span.SetAttributes(tracing.AttributeNamespace.String(namespace))
+		}
+		if objectName != "" {
+			span.SetAttributes(tracing.AttributeName.String(objectName))
+		}
+	}
+	return ctx, span
+}
@@ -0,0 +1,57 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestKubernetesApiSpans(t *testing.T) {
+	InitializeMapResourcesMock()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{pods: "PodList"},
+		newUnstructured("v1", "Pod", "default", "nginx"),
+	)
+	k8sAPI := &KubernetesApi{DynamicClient: dynamicClient, Context: context.Background()}
+
+	// the calls are children of the span of the caller's context
+	ctx, parent := tracing.StartSpan(context.Background(), "caller")
+	tracedAPI := k8sAPI.WithContext(ctx)
+	assert.Equal(t, context.Background(), k8sAPI.Context)
+
+	workloads, err := tracedAPI.ListWorkloads(&pods, "default", nil, nil)
+	assert.NoError(t, err)
+	assert.Len(t, workloads, 1)
+	_, err = tracedAPI.GetWorkload("default", "Pod", "missing")
+	assert.Error(t, err)
+	tracing.EndSpan(parent, nil)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+
+	assert.Equal(t, "k8s.ListWorkloads", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Contains(t, spans[0].Attributes(), tracing.AttributeResource.String("/v1/pods"))
+	assert.Contains(t, spans[0].Attributes(), tracing.AttributeNamespace.String("default"))
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	assert.Equal(t, "k8s.GetWorkload", spans[1].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[1].Parent().SpanID())
+	assert.Contains(t, spans[1].Attributes(), tracing.AttributeName.String("missing"))
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
@@ -0,0 +1,161 @@
+package k8sinterface
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// Distribution is the Kubernetes distribution the cluster is running
+type Distribution string
+
+const (
+	DistributionKubernetes Distribution = "kubernetes"
+	DistributionOpenShift  Distribution = "openshift"
+	DistributionK3s        Distribution = "k3s"
+	DistributionRKE2       Distribution = "rke2"
+	DistributionEKS        Distribution = "eks"
+	DistributionAKS        Distribution = "aks"
+	DistributionGKE        Distribution = "gke"
+)
+
+// node labels set by the distributions, in order of precedence: e.g. the nodes of OpenShift on a managed service (ARO, ROSA) also have the labels of the service
+var distributionNodeLabels = []struct {
+	label        string
+	distribution Distribution
+}{
+	{label: "node.openshift.io/os_id", distribution: DistributionOpenShift},
+	{label: "eks.amazonaws.com/nodegroup", distribution: DistributionEKS},
+	{label: "alpha.eksctl.io/cluster-name", distribution: DistributionEKS},
+	{label: "kubernetes.azure.com/cluster", distribution: DistributionAKS},
+	{label: "kubernetes.azure.com/role", distribution: DistributionAKS},
+	{label: "cloud.google.com/gke-nodepool", distribution: DistributionGKE},
+	{label: "cloud.google.com/gke-os-distribution", distribution: DistributionGKE},
+}
+
+// ClusterVersion is the parsed version of the API server
+type ClusterVersion struct {
+	Major        int          `json:"major"`
+	Minor        int          `json:"minor"`
+	Patch        int          `json:"patch"`
+	GitVersion   string       `json:"gitVersion"`
+	Distribution Distribution `json:"distribution"`
+}
+
+// AtLeast returns true if the cluster version is equal or greater than major.minor
+func (clusterVersion *ClusterVersion) AtLeast(major, minor int) bool {
+	if clusterVersion.Major != major {
+		return clusterVersion.Major > major
+	}
+	return clusterVersion.Minor >= minor
+}
+
+// String returns the version as major.minor.patch
+func (clusterVersion *ClusterVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", clusterVersion.Major, clusterVersion.Minor, clusterVersion.Patch)
+}
+
+// ParseClusterVersion parses the version info returned by the API server. The distribution is detected from the git version only
+func ParseClusterVersion(info *version.Info) (*ClusterVersion, error) {
+	if info == nil {
+		return nil, fmt.Errorf("version info is nil")
+	}
+	clusterVersion := &ClusterVersion{
+		GitVersion:   info.GitVersion,
+		Distribution: DetectDistribution(info.GitVersion, nil),
+	}
+	if v, err := utilversion.ParseGeneric(info.GitVersion); err == nil {
+		clusterVersion.Major = int(v.Major())
+		clusterVersion.Minor = int(v.Minor())
+		clusterVersion.Patch = int(v.Patch())
+		return clusterVersion, nil
+	}
+
+	// fallback to the major/minor fields, the minor may have a '+' suffix (e.g. "27+")
+	major, err := strconv.Atoi(strings.TrimSuffix(info.Major, "+"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version '%s': %w", info.GitVersion, err)
+	}
+	minor, err := strconv.Atoi(strings.TrimSuffix(info.Minor, "+"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version '%s': %w", info.GitVersion, err)
+	}
+	clusterVersion.Major = major
+	clusterVersion.Minor = minor
+	return clusterVersion, nil
+}
+
+// DetectDistribution returns the distribution based on the API server git version (e.g. v1.27.3-eks-a5565ad, v1.26.4+k3s1) and the labels of a node.
+// Returns DistributionKubernetes if the distribution is unknown
+func DetectDistribution(gitVersion string, nodeLabels map[string]string) Distribution {
+	switch {
+	case strings.Contains(gitVersion, "+k3s"):
+		return DistributionK3s
+	case strings.Contains(gitVersion, "+rke2"):
+		return DistributionRKE2
+	case strings.Contains(gitVersion, "-eks-"):
+		return DistributionEKS
+	case strings.Contains(gitVersion, "-gke."):
+		return DistributionGKE
+	}
+	for i := range distributionNodeLabels {
+		if _, ok := nodeLabels[distributionNodeLabels[i].label]; ok {
+			return distributionNodeLabels[i].distribution
+		}
+	}
+	if nodeLabels["node.kubernetes.io/instance-type"] == string(DistributionK3s) {
+		return DistributionK3s
+	}
+	if nodeLabels["node.kubernetes.io/instance-type"] == string(DistributionRKE2) {
+		return DistributionRKE2
+	}
+	return DistributionKubernetes
+}
+
+// GetClusterVersion returns the parsed version of the API server. The distribution is detected from the git version, the labels of one of the nodes
+// and the OpenShift API groups
+func (k8sAPI *KubernetesApi) GetClusterVersion() (*ClusterVersion, error) {
+	info, err := k8sAPI.DiscoveryClient.ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+	clusterVersion, err := ParseClusterVersion(info)
+	if err != nil {
+		return nil, err
+	}
+	if clusterVersion.Distribution == DistributionKubernetes && k8sAPI.KubernetesClient != nil {
+		// node labels are best effort, the component may not have permissions to list nodes
+		if nodes, err := k8sAPI.KubernetesClient.CoreV1().Nodes().List(k8sAPI.Context, metav1.ListOptions{Limit: 1}); err == nil && len(nodes.Items) > 0 {
+			clusterVersion.Distribution = DetectDistribution(info.GitVersion, nodes.Items[0].GetLabels())
+		}
+	}
+	if clusterVersion.Distribution == DistributionKubernetes {
+		if isOpenShift, err := k8sAPI.IsOpenShift(); err == nil && isOpenShift {
+			clusterVersion.Distribution = DistributionOpenShift
+		}
+	}
+	return clusterVersion, nil
+}
+
+// SupportsResource returns true if the API server serves the resource
+func (k8sAPI *KubernetesApi) SupportsResource(gvr schema.GroupVersionResource) (bool, error) {
+	resourceList, err := k8sAPI.DiscoveryClient.ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	for i := range resourceList.APIResources {
+		if resourceList.APIResources[i].Name == gvr.Resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,133 @@
+package k8sinterface
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseClusterVersion(t *testing.T) {
+	tests := []struct {
+		name             string
+		info             *version.Info
+		want             string
+		wantDistribution Distribution
+		wantErr          bool
+	}{
+		{
+			name:             "vanilla",
+			info:             &version.Info{Major: "1", Minor: "27", GitVersion: "v1.27.3"},
+			want:             "1.27.3",
+			wantDistribution: DistributionKubernetes,
+		},
+		{
+			name:             "eks",
+			info:             &version.Info{Major: "1", Minor: "27+", GitVersion: "v1.27.3-eks-a5565ad"},
+			want:             "1.27.3",
+			wantDistribution: DistributionEKS,
+		},
+		{
+			name:             "gke",
+			info:             &version.Info{Major: "1", Minor: "22", GitVersion: "v1.22.11-gke.400"},
+			want:             "1.22.11",
+			wantDistribution: DistributionGKE,
+		},
+		{
+			name:             "k3s",
+			info:             &version.Info{Major: "1", Minor: "26", GitVersion: "v1.26.4+k3s1"},
+			want:             "1.26.4",
+			wantDistribution: DistributionK3s,
+		},
+		{
+			name:             "fallback to major/minor",
+			info:             &version.Info{Major: "1", Minor: "25+"},
+			want:             "1.25.0",
+			wantDistribution: DistributionKubernetes,
+		},
+		{
+			name:    "invalid",
+			info:    &version.Info{GitVersion: "invalid"},
+			wantErr: true,
+		},
+		{
+			name:    "nil",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseClusterVersion(tt.info)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseClusterVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+			assert.Equal(t, tt.wantDistribution, got.Distribution)
+		})
+	}
+}
+
+func TestClusterVersionAtLeast(t *testing.T) {
+	v := &ClusterVersion{Major: 1, Minor: 27, Patch: 3}
+	assert.True(t, v.AtLeast(1, 27))
+	assert.True(t, v.AtLeast(1, 20))
+	assert.False(t, v.AtLeast(1, 28))
+	assert.False(t, v.AtLeast(2, 0))
+	assert.True(t, v.AtLeast(0, 99))
+}
+
+func TestDetectDistribution(t *testing.T) {
+	assert.Equal(t, DistributionOpenShift, DetectDistribution("v1.25.4+77bec7a", map[string]string{"node.openshift.io/os_id": "rhcos"}))
+	assert.Equal(t, DistributionAKS, DetectDistribution("v1.26.3", map[string]string{"kubernetes.azure.com/cluster": "MC_rg_cluster_eastus"}))
+	assert.Equal(t, DistributionEKS, DetectDistribution("v1.26.3", map[string]string{"eks.amazonaws.com/nodegroup": "ng-1"}))
+	assert.Equal(t, DistributionK3s, DetectDistribution("v1.26.3", map[string]string{"node.kubernetes.io/instance-type": "k3s"}))
+	assert.Equal(t, DistributionRKE2, DetectDistribution("v1.26.3+rke2r1", nil))
+	assert.Equal(t, DistributionKubernetes, DetectDistribution("v1.26.3", map[string]string{"kubernetes.io/os": "linux"}))
+
+	// OpenShift on a managed service (ARO) is always OpenShift
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, DistributionOpenShift, DetectDistribution("v1.25.4+77bec7a", map[string]string{"node.openshift.io/os_id": "rhcos", "kubernetes.azure.com/cluster": "aro"}))
+	}
+}
+
+func TestGetClusterVersion(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: map[string]string{"kubernetes.azure.com/cluster": "MC_rg_cluster_eastus"}}}
+	client := kubernetesfake.NewSimpleClientset(node)
+	discoveryClient := client.Discovery().(*fakediscovery.FakeDiscovery)
+	discoveryClient.FakedServerVersion = &version.Info{Major: "1", Minor: "26", GitVersion: "v1.26.3"}
+	discoveryClient.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{{Name: "deployments", Kind: "Deployment", Namespaced: true}},
+		},
+	}
+	k8sAPI := &KubernetesApi{KubernetesClient: client, DiscoveryClient: discoveryClient, Context: context.Background()}
+
+	clusterVersion, err := k8sAPI.GetClusterVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "1.26.3", clusterVersion.String())
+	assert.Equal(t, DistributionAKS, clusterVersion.Distribution)
+	assert.True(t, clusterVersion.AtLeast(1, 25))
+
+	supported, err := k8sAPI.SupportsResource(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"})
+	assert.NoError(t, err)
+	assert.True(t, supported)
+
+	supported, err = k8sAPI.SupportsResource(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"})
+	assert.NoError(t, err)
+	assert.False(t, supported)
+
+	supported, err = k8sAPI.SupportsResource(schema.GroupVersionResource{Group: "policy", Version: "v1beta1", Resource: "podsecuritypolicies"})
+	assert.NoError(t, err)
+	assert.False(t, supported)
+}
@@ -0,0 +1,310 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	metadataclient "k8s.io/client-go/metadata"
+	"k8s.io/client-go/openapi"
+	restclient "k8s.io/client-go/rest"
+)
+
+// errReadOnly is returned by the write and watch requests, the proxy only serves list, get and describe
+var errReadOnly = errors.New("the cluster data proxy is read-only, only list and get are supported")
+
+// ClientOptions configures the Client
+type ClientOptions struct {
+	// Token is the bearer token sent to the server
+	Token string
+}
+
+// Client is a client of the cluster data Server. It implements dynamic.Interface (list and get only) and discovery.DiscoveryInterface (the discovery is fetched
+// once from the server), so it can back the DynamicClient and the DiscoveryClient of a KubernetesApi, see NewKubernetesApi
+type Client struct {
+	conn  grpc.ClientConnInterface
+	token string
+
+	describeMutex sync.Mutex
+	describe      *DescribeResponse
+}
+
+var (
+	_ dynamic.Interface            = &Client{}
+	_ discovery.DiscoveryInterface = &Client{}
+)
+
+// NewClient returns a client of the server of the connection
+func NewClient(conn grpc.ClientConnInterface, opts *ClientOptions) *Client {
+	if opts == nil {
+		opts = &ClientOptions{}
+	}
+	return &Client{conn: conn, token: opts.Token}
+}
+
+// NewKubernetesApi returns a KubernetesApi reading the cluster data through the server of the connection. All its clients are served by the proxy:
+// the list and get requests of the typed and the metadata clients are sent to the server, their other requests fail
+func NewKubernetesApi(conn grpc.ClientConnInterface, opts *ClientOptions) (*k8sinterface.KubernetesApi, error) {
+	client := NewClient(conn, opts)
+	config := &restclient.Config{Host: "http://cluster-data-proxy", Transport: &transport{client: client}}
+	kubernetesClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the kubernetes client of the cluster data proxy, reason: %s", err.Error())
+	}
+	metadataClient, err := metadataclient.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the metadata client of the cluster data proxy, reason: %s", err.Error())
+	}
+	return &k8sinterface.KubernetesApi{
+		KubernetesClient: kubernetesClient,
+		DynamicClient:    client,
+		DiscoveryClient:  client,
+		MetadataClient:   metadataClient,
+		Context:          context.Background(),
+	}, nil
+}
+
+func (client *Client) invoke(ctx context.Context, method string, request, response interface{}) error {
+	if client.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, authorizationHeader, "Bearer "+client.token)
+	}
+	return fromGRPCError(client.conn.Invoke(ctx, fullMethod(method), request, response, grpc.ForceCodec(jsonCodec{})))
+}
+
+// Resource returns the interface of the resource, only List and Get are supported
+func (client *Client) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &resourceClient{client: client, resource: resource}
+}
+
+// resourceClient is the dynamic client of a resource
+type resourceClient struct {
+	client    *Client
+	resource  schema.GroupVersionResource
+	namespace string
+}
+
+func (c *resourceClient) Namespace(namespace string) dynamic.ResourceInterface {
+	return &resourceClient{client: c.client, resource: c.resource, namespace: namespace}
+}
+
+func (c *resourceClient) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	response := &ListResponse{}
+	if err := c.client.invoke(ctx, listMethod, &ListRequest{Resource: c.resource, Namespace: c.namespace, Options: opts}, response); err != nil {
+		return nil, err
+	}
+	list := &unstructured.UnstructuredList{Object: map[string]interface{}{}, Items: make([]unstructured.Unstructured, 0, len(response.Items))}
+	list.SetAPIVersion(response.APIVersion)
+	list.SetKind(response.Kind)
+	list.SetResourceVersion(response.ResourceVersion)
+	list.SetContinue(response.Continue)
+	list.SetRemainingItemCount(response.RemainingItemCount)
+	for i := range response.Items {
+		obj, err := decodeObject(response.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, *obj)
+	}
+	return list, nil
+}
+
+func (c *resourceClient) Get(ctx context.Context, name string, options metav1.GetOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(subresources) > 0 {
+		return nil, errReadOnly
+	}
+	response := &GetResponse{}
+	if err := c.client.invoke(ctx, getMethod, &GetRequest{Resource: c.resource, Namespace: c.namespace, Name: name, Options: options}, response); err != nil {
+		return nil, err
+	}
+	return decodeObject(response.Object)
+}
+
+// decodeObject decodes the JSON of an object, with the numbers converted to int64/float64 as the Kubernetes clients do
+func decodeObject(data []byte) (*unstructured.Unstructured, error) {
+	obj := map[string]interface{}{}
+	if err := utiljson.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("failed to decode the object of the proxy, reason: %s", err.Error())
+	}
+	return &unstructured.Unstructured{Object: obj}, nil
+}
+
+func (c *resourceClient) Create(context.Context, *unstructured.Unstructured, metav1.CreateOptions, ...string) (*unstructured.Unstructured, error) {
+	return nil, errReadOnly
+}
+
+func (c *resourceClient) Update(context.Context, *unstructured.Unstructured, metav1.UpdateOptions, ...string) (*unstructured.Unstructured, error) {
+	return nil, errReadOnly
+}
+
+func (c *resourceClient) UpdateStatus(context.Context, *unstructured.Unstructured, metav1.UpdateOptions) (*unstructured.Unstructured, error) {
+	return nil, errReadOnly
+}
+
+func (c *resourceClient) Delete(context.Context, string, metav1.DeleteOptions, ...string) error {
+	return errReadOnly
+}
+
+func (c *resourceClient) DeleteCollection(context.Context, metav1.DeleteOptions, metav1.ListOptions) error {
+	return errReadOnly
+}
+
+func (c *resourceClient) Watch(context.Context, metav1.ListOptions) (watch.Interface, error) {
+	return nil, errReadOnly
+}
+
+func (c *resourceClient) Patch(context.Context, string, types.PatchType, []byte, metav1.PatchOptions, ...string) (*unstructured.Unstructured, error) {
+	return nil, errReadOnly
+}
+
+func (c *resourceClient) Apply(context.Context, string, *unstructured.Unstructured, metav1.ApplyOptions, ...string) (*unstructured.Unstructured, error) {
+	return nil, errReadOnly
+}
+
+func (c *resourceClient) ApplyStatus(context.Context, string, *unstructured.Unstructured, metav1.ApplyOptions) (*unstructured.Unstructured, error) {
+	return nil, errReadOnly
+}
+
+// getDescribe returns the description of the API server, fetched on the first successful call: the failed calls are retried by the next call
+func (client *Client) getDescribe() (*DescribeResponse, error) {
+	client.describeMutex.Lock()
+	defer client.describeMutex.Unlock()
+	if client.describe != nil {
+		return client.describe, nil
+	}
+	response := &DescribeResponse{}
+	if err := client.invoke(context.Background(), describeMethod, &DescribeRequest{}, response); err != nil {
+		return nil, err
+	}
+	client.describe = response
+	return response, nil
+}
+
+// RESTClient returns nil, the proxy has no REST client
+func (client *Client) RESTClient() restclient.Interface {
+	return nil
+}
+
+func (client *Client) ServerVersion() (*version.Info, error) {
+	describe, err := client.getDescribe()
+	if err != nil {
+		return nil, err
+	}
+	return describe.ServerVersion, nil
+}
+
+// ServerGroups returns the groups of the preferred resources, the proxy does not serve the other versions
+func (client *Client) ServerGroups() (*metav1.APIGroupList, error) {
+	describe, err := client.getDescribe()
+	if err != nil {
+		return nil, err
+	}
+	groupList := &metav1.APIGroupList{}
+	groups := map[string]*metav1.APIGroup{}
+	for _, resourceList := range describe.APIResourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+		groupVersion := metav1.GroupVersionForDiscovery{GroupVersion: resourceList.GroupVersion, Version: gv.Version}
+		group, ok := groups[gv.Group]
+		if !ok {
+			groupList.Groups = append(groupList.Groups, metav1.APIGroup{Name: gv.Group, PreferredVersion: groupVersion})
+			group = &groupList.Groups[len(groupList.Groups)-1]
+			groups[gv.Group] = group
+		}
+		if !containsGroupVersion(group.Versions, groupVersion) {
+			group.Versions = append(group.Versions, groupVersion)
+		}
+	}
+	return groupList, nil
+}
+
+func containsGroupVersion(versions []metav1.GroupVersionForDiscovery, groupVersion metav1.GroupVersionForDiscovery) bool {
+	for i := range versions {
+		if versions[i] == groupVersion {
+			return true
+		}
+	}
+	return false
+}
+
+func (client *Client) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	describe, err := client.getDescribe()
+	if err != nil {
+		return nil, err
+	}
+	for _, resourceList := range describe.APIResourceLists {
+		if resourceList.GroupVersion == groupVersion {
+			return resourceList, nil
+		}
+	}
+	gv, _ := schema.ParseGroupVersion(groupVersion)
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: gv.Group, Resource: "apiresources"}, groupVersion)
+}
+
+func (client *Client) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	groupList, err := client.ServerGroups()
+	if err != nil {
+		return nil, nil, err
+	}
+	groups := make([]*metav1.APIGroup, 0, len(groupList.Groups))
+	for i := range groupList.Groups {
+		groups = append(groups, &groupList.Groups[i])
+	}
+	resourceLists, err := client.ServerPreferredResources()
+	return groups, resourceLists, err
+}
+
+func (client *Client) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	describe, err := client.getDescribe()
+	if err != nil {
+		return nil, err
+	}
+	return describe.APIResourceLists, nil
+}
+
+func (client *Client) ServerPreferredNamespacedResources() ([]*metav1.APIResourceList, error) {
+	resourceLists, err := client.ServerPreferredResources()
+	if err != nil {
+		return nil, err
+	}
+	var namespaced []*metav1.APIResourceList
+	for _, resourceList := range resourceLists {
+		namespacedList := &metav1.APIResourceList{GroupVersion: resourceList.GroupVersion}
+		for _, apiResource := range resourceList.APIResources {
+			if apiResource.Namespaced {
+				namespacedList.APIResources = append(namespacedList.APIResources, apiResource)
+			}
+		}
+		if len(namespacedList.APIResources) > 0 {
+			namespaced = append(namespaced, namespacedList)
+		}
+	}
+	return namespaced, nil
+}
+
+// OpenAPISchema is not supported by the proxy
+func (client *Client) OpenAPISchema() (*openapi_v2.Document, error) {
+	return nil, errors.New("the cluster data proxy does not serve the OpenAPI schema")
+}
+
+// OpenAPIV3 returns nil, the proxy does not serve the OpenAPI schema
+func (client *Client) OpenAPIV3() openapi.Client {
+	return nil
+}
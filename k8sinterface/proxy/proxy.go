@@ -0,0 +1,159 @@
+// Package proxy serves the read-only cluster data of a KubernetesApi (list, get and describe of the API server) over gRPC, so an in-cluster agent can serve
+// an external scanner without sharing kubeconfig credentials. The client implements the dynamic and the discovery interfaces of the KubernetesApi.
+//
+// The messages are JSON encoded, there is no protobuf definition of the service. The codec is not registered globally: the client selects it per call,
+// and the gRPC server serving the proxy must be created with ServerCodec
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+const (
+	// ServiceName is the gRPC service of the cluster data
+	ServiceName = "kubescape.k8sinterface.proxy.v1.ClusterData"
+
+	codecName = "k8sinterface-proxy-json"
+
+	listMethod     = "List"
+	getMethod      = "Get"
+	describeMethod = "Describe"
+
+	// authorizationHeader is the metadata of the bearer token of the requests
+	authorizationHeader = "authorization"
+)
+
+// ServerCodec is the option of the gRPC server serving the proxy, it decodes and encodes the messages with the JSON codec of the proxy.
+// The codec is forced for all the services of the server, serve the proxy on a dedicated server
+func ServerCodec() grpc.ServerOption {
+	return grpc.ForceServerCodec(jsonCodec{})
+}
+
+// jsonCodec encodes the messages as JSON
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return codecName
+}
+
+// ListRequest lists the objects of a resource, in all the namespaces if the namespace is empty
+type ListRequest struct {
+	Resource  schema.GroupVersionResource `json:"resource"`
+	Namespace string                      `json:"namespace,omitempty"`
+	Options   metav1.ListOptions          `json:"options"`
+}
+
+// ListResponse is a page of objects. The objects are kept as raw JSON, the client decodes them with the Kubernetes number conversion
+type ListResponse struct {
+	APIVersion         string            `json:"apiVersion,omitempty"`
+	Kind               string            `json:"kind,omitempty"`
+	ResourceVersion    string            `json:"resourceVersion,omitempty"`
+	Continue           string            `json:"continue,omitempty"`
+	RemainingItemCount *int64            `json:"remainingItemCount,omitempty"`
+	Items              []json.RawMessage `json:"items"`
+}
+
+// GetRequest gets an object of a resource
+type GetRequest struct {
+	Resource  schema.GroupVersionResource `json:"resource"`
+	Namespace string                      `json:"namespace,omitempty"`
+	Name      string                      `json:"name"`
+	Options   metav1.GetOptions           `json:"options"`
+}
+
+// GetResponse is the object, as raw JSON
+type GetResponse struct {
+	Object json.RawMessage `json:"object"`
+}
+
+// DescribeRequest describes the API server
+type DescribeRequest struct{}
+
+// DescribeResponse is the version and the preferred resources of the API server, restricted to the resources the server serves
+type DescribeResponse struct {
+	ServerVersion    *version.Info             `json:"serverVersion,omitempty"`
+	APIResourceLists []*metav1.APIResourceList `json:"apiResourceLists"`
+}
+
+// toGRPCError returns the gRPC status of the error. The status of Kubernetes API errors is passed in the message, see fromGRPCError
+func toGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var apiStatus apierrors.APIStatus
+	if !errors.As(err, &apiStatus) {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	data, marshalErr := json.Marshal(apiStatus.Status())
+	if marshalErr != nil {
+		return status.Error(codes.Unknown, err.Error())
+	}
+	return status.Error(statusCode(apiStatus.Status().Code), string(data))
+}
+
+// fromGRPCError returns the Kubernetes API error of the gRPC status, so apierrors.IsNotFound and the like work on the errors of the client
+func fromGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	grpcStatus, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	apiStatus := metav1.Status{}
+	if json.Unmarshal([]byte(grpcStatus.Message()), &apiStatus) == nil && apiStatus.Status == metav1.StatusFailure {
+		return &apierrors.StatusError{ErrStatus: apiStatus}
+	}
+	switch grpcStatus.Code() {
+	case codes.Unauthenticated:
+		return apierrors.NewUnauthorized(grpcStatus.Message())
+	case codes.DeadlineExceeded:
+		return apierrors.NewTimeoutError(grpcStatus.Message(), 0)
+	case codes.Unavailable:
+		return apierrors.NewServiceUnavailable(grpcStatus.Message())
+	}
+	return fmt.Errorf("proxy request failed, reason: %s", grpcStatus.Message())
+}
+
+func statusCode(httpCode int32) codes.Code {
+	switch httpCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusGone:
+		return codes.OutOfRange
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+	return codes.Unknown
+}
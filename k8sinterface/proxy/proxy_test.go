@@ -0,0 +1,264 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/k8sinterface/fake"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/test/bufconn"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	podsGVR        = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	secretsGVR     = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+	testResources = []schema.GroupVersionResource{podsGVR, deploymentsGVR}
+)
+
+// newTestProxy serves a fake KubernetesApi over an in-memory connection and returns the connection to the server
+func newTestProxy(t *testing.T, opts *ServerOptions) *grpc.ClientConn {
+	k8sAPI, err := fake.NewKubernetesApi(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nginx-1", Namespace: "default", Labels: map[string]string{"app": "nginx"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "redis-1", Namespace: "cache", Labels: map[string]string{"app": "redis"}}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"}, Spec: appsv1.DeploymentSpec{Replicas: int32Ptr(3)}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "default"}},
+	)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer(ServerCodec())
+	server, err := NewServer(k8sAPI, opts)
+	require.NoError(t, err)
+	server.Register(grpcServer)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+// newTestKubernetesApi returns a KubernetesApi reading the cluster data through the connection
+func newTestKubernetesApi(t *testing.T, conn *grpc.ClientConn, opts *ClientOptions) *k8sinterface.KubernetesApi {
+	k8sAPI, err := NewKubernetesApi(conn, opts)
+	require.NoError(t, err)
+	return k8sAPI
+}
+
+func TestNewServer(t *testing.T) {
+	k8sAPI, err := fake.NewKubernetesApi()
+	require.NoError(t, err)
+
+	_, err = NewServer(k8sAPI, nil)
+	assert.Error(t, err)
+	_, err = NewServer(k8sAPI, &ServerOptions{Resources: testResources})
+	assert.Error(t, err)
+	_, err = NewServer(k8sAPI, &ServerOptions{Token: "secret"})
+	assert.Error(t, err)
+
+	_, err = NewServer(k8sAPI, &ServerOptions{Token: "secret", Resources: testResources})
+	assert.NoError(t, err)
+	_, err = NewServer(k8sAPI, &ServerOptions{TokenOptional: true, Resources: testResources})
+	assert.NoError(t, err)
+}
+
+func TestProxyCodecNotRegistered(t *testing.T) {
+	// the codec of the proxy does not replace the codecs of the importing binary
+	assert.Nil(t, encoding.GetCodec("json"))
+	assert.Nil(t, encoding.GetCodec(codecName))
+}
+
+func TestProxyListAndGet(t *testing.T) {
+	conn := newTestProxy(t, &ServerOptions{Token: "secret", Resources: testResources})
+	k8sAPI := newTestKubernetesApi(t, conn, &ClientOptions{Token: "secret"})
+
+	pods, err := k8sAPI.ListWorkloads(&podsGVR, "", nil, nil)
+	require.NoError(t, err)
+	assert.Len(t, pods, 2)
+
+	pods, err = k8sAPI.ListWorkloads(&podsGVR, "default", map[string]string{"app": "nginx"}, nil)
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.Equal(t, "nginx-1", pods[0].GetName())
+
+	workload, err := k8sAPI.GetWorkload("default", "Deployment", "nginx")
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", workload.GetName())
+	// numbers are decoded as int64, as by the Kubernetes clients
+	replicas, found, err := unstructured.NestedInt64(workload.GetObject(), "spec", "replicas")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(3), replicas)
+
+	_, err = k8sAPI.DynamicClient.Resource(deploymentsGVR).Namespace("default").Get(context.Background(), "missing", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func TestProxyAuthentication(t *testing.T) {
+	conn := newTestProxy(t, &ServerOptions{Token: "secret", Resources: testResources})
+
+	_, err := NewClient(conn, &ClientOptions{Token: "wrong"}).Resource(podsGVR).List(context.Background(), metav1.ListOptions{})
+	assert.True(t, apierrors.IsUnauthorized(err))
+
+	_, err = NewClient(conn, nil).ServerVersion()
+	assert.True(t, apierrors.IsUnauthorized(err))
+
+	// a failed discovery is not cached
+	client := NewClient(conn, nil)
+	_, err = client.ServerVersion()
+	assert.True(t, apierrors.IsUnauthorized(err))
+	client.token = "secret"
+	_, err = client.ServerVersion()
+	assert.NoError(t, err)
+}
+
+func TestProxyResources(t *testing.T) {
+	conn := newTestProxy(t, &ServerOptions{TokenOptional: true, Resources: testResources})
+	client := NewClient(conn, nil)
+
+	_, err := client.Resource(secretsGVR).Namespace("default").Get(context.Background(), "token", metav1.GetOptions{})
+	assert.True(t, apierrors.IsForbidden(err))
+
+	_, err = client.Resource(secretsGVR).List(context.Background(), metav1.ListOptions{})
+	assert.True(t, apierrors.IsForbidden(err))
+
+	pods, err := client.Resource(podsGVR).Namespace("cache").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, pods.Items, 1)
+	assert.Equal(t, "redis-1", pods.Items[0].GetName())
+}
+
+func TestProxyDiscovery(t *testing.T) {
+	conn := newTestProxy(t, &ServerOptions{TokenOptional: true, Resources: testResources})
+	client := NewClient(conn, nil)
+
+	serverVersion, err := client.ServerVersion()
+	require.NoError(t, err)
+	assert.NotNil(t, serverVersion)
+
+	resourceList, err := client.ServerResourcesForGroupVersion("apps/v1")
+	require.NoError(t, err)
+	require.Len(t, resourceList.APIResources, 1)
+	assert.Equal(t, "deployments", resourceList.APIResources[0].Name)
+
+	_, err = client.ServerResourcesForGroupVersion("rbac.authorization.k8s.io/v1")
+	assert.True(t, apierrors.IsNotFound(err))
+
+	groups, err := client.ServerGroups()
+	require.NoError(t, err)
+	var names []string
+	for _, group := range groups.Groups {
+		names = append(names, group.Name)
+	}
+	assert.ElementsMatch(t, []string{"", "apps"}, names)
+
+	namespaced, err := client.ServerPreferredNamespacedResources()
+	require.NoError(t, err)
+	assert.Len(t, namespaced, 2)
+}
+
+func TestProxyReadOnly(t *testing.T) {
+	conn := newTestProxy(t, &ServerOptions{TokenOptional: true, Resources: testResources})
+	k8sAPI := newTestKubernetesApi(t, conn, nil)
+
+	pod := workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "new", "namespace": "default"},
+	})
+	_, err := k8sAPI.CreateWorkload(pod)
+	assert.ErrorContains(t, err, errReadOnly.Error())
+	assert.ErrorContains(t, k8sAPI.DeleteWorkloadByWlid("wlid://cluster-test/namespace-default/pod-nginx-1"), errReadOnly.Error())
+
+	_, err = k8sAPI.DynamicClient.Resource(podsGVR).Watch(context.Background(), metav1.ListOptions{})
+	assert.ErrorIs(t, err, errReadOnly)
+
+	_, err = k8sAPI.KubernetesClient.CoreV1().Pods("default").Create(context.Background(), &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "new"}}, metav1.CreateOptions{})
+	assert.True(t, apierrors.IsMethodNotSupported(err))
+	_, err = k8sAPI.KubernetesClient.CoreV1().Pods("default").Watch(context.Background(), metav1.ListOptions{})
+	assert.True(t, apierrors.IsMethodNotSupported(err))
+}
+
+func TestProxyTypedAndMetadataClients(t *testing.T) {
+	conn := newTestProxy(t, &ServerOptions{Token: "secret", Resources: testResources})
+	k8sAPI := newTestKubernetesApi(t, conn, &ClientOptions{Token: "secret"})
+	ctx := context.Background()
+
+	pods, err := k8sAPI.KubernetesClient.CoreV1().Pods("").List(ctx, metav1.ListOptions{LabelSelector: "app=nginx"})
+	require.NoError(t, err)
+	require.Len(t, pods.Items, 1)
+	assert.Equal(t, "nginx-1", pods.Items[0].Name)
+
+	deployment, err := k8sAPI.KubernetesClient.AppsV1().Deployments("default").Get(ctx, "nginx", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), *deployment.Spec.Replicas)
+
+	_, err = k8sAPI.KubernetesClient.CoreV1().Pods("default").Get(ctx, "missing", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// the resources not served by the server are forbidden
+	_, err = k8sAPI.KubernetesClient.CoreV1().Secrets("default").List(ctx, metav1.ListOptions{})
+	assert.True(t, apierrors.IsForbidden(err))
+
+	metadataList, err := k8sAPI.MetadataClient.Resource(podsGVR).Namespace("cache").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, metadataList.Items, 1)
+	assert.Equal(t, "redis-1", metadataList.Items[0].Name)
+
+	metadata, err := k8sAPI.MetadataClient.Resource(deploymentsGVR).Namespace("default").Get(ctx, "nginx", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", metadata.Name)
+}
+
+func TestParseResourcePath(t *testing.T) {
+	tests := []struct {
+		path      string
+		gvr       schema.GroupVersionResource
+		namespace string
+		name      string
+		wantErr   bool
+	}{
+		{path: "/api/v1/pods", gvr: podsGVR},
+		{path: "/api/v1/namespaces", gvr: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}},
+		{path: "/api/v1/namespaces/default", gvr: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, name: "default"},
+		{path: "/api/v1/namespaces/default/pods", gvr: podsGVR, namespace: "default"},
+		{path: "/apis/apps/v1/namespaces/default/deployments/nginx", gvr: deploymentsGVR, namespace: "default", name: "nginx"},
+		{path: "/api/v1/namespaces/default/pods/nginx/log", wantErr: true},
+		{path: "/version", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			gvr, namespace, name, err := parseResourcePath(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.gvr, gvr)
+			assert.Equal(t, tt.namespace, namespace)
+			assert.Equal(t, tt.name, name)
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package proxy
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// ServerOptions configures the Server
+type ServerOptions struct {
+	// Token is the bearer token the clients must send, required unless TokenOptional is set
+	Token string
+	// TokenOptional must be set to serve unauthenticated requests when Token is empty, e.g. when the connection is already authenticated
+	TokenOptional bool
+	// Resources are the resources the server serves, required. Only the listed resources are served, so e.g. the secrets stay in the cluster
+	Resources []schema.GroupVersionResource
+}
+
+// Server serves the list, get and describe requests with the clients of a KubernetesApi. It never writes to the cluster
+type Server struct {
+	k8sAPI    *k8sinterface.KubernetesApi
+	token     string
+	resources map[schema.GroupVersionResource]bool
+}
+
+// NewServer returns a server of the cluster data of the KubernetesApi. An error is returned if the options do not set the token (or TokenOptional)
+// and the resources, the server never serves the whole cluster to unauthenticated clients by default
+func NewServer(k8sAPI *k8sinterface.KubernetesApi, opts *ServerOptions) (*Server, error) {
+	if opts == nil {
+		return nil, fmt.Errorf("the cluster data proxy requires options with a token and the served resources")
+	}
+	if opts.Token == "" && !opts.TokenOptional {
+		return nil, fmt.Errorf("the cluster data proxy requires a token, set TokenOptional to serve unauthenticated requests")
+	}
+	if len(opts.Resources) == 0 {
+		return nil, fmt.Errorf("the cluster data proxy requires the resources to serve")
+	}
+	server := &Server{k8sAPI: k8sAPI, token: opts.Token, resources: map[schema.GroupVersionResource]bool{}}
+	for _, gvr := range opts.Resources {
+		server.resources[gvr] = true
+	}
+	return server, nil
+}
+
+// Register registers the service of the server on the gRPC server, which must be created with ServerCodec
+func (server *Server) Register(registrar grpc.ServiceRegistrar) {
+	registrar.RegisterService(&serviceDesc, server)
+}
+
+// List lists the objects of the resource
+func (server *Server) List(ctx context.Context, request *ListRequest) (*ListResponse, error) {
+	if err := server.authorize(ctx, request.Resource, ""); err != nil {
+		return nil, err
+	}
+	list, err := server.k8sAPI.DynamicClient.Resource(request.Resource).Namespace(request.Namespace).List(ctx, request.Options)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	response := &ListResponse{
+		APIVersion:         list.GetAPIVersion(),
+		Kind:               list.GetKind(),
+		ResourceVersion:    list.GetResourceVersion(),
+		Continue:           list.GetContinue(),
+		RemainingItemCount: list.GetRemainingItemCount(),
+		Items:              make([]json.RawMessage, 0, len(list.Items)),
+	}
+	for i := range list.Items {
+		data, err := list.Items[i].MarshalJSON()
+		if err != nil {
+			return nil, toGRPCError(err)
+		}
+		response.Items = append(response.Items, data)
+	}
+	return response, nil
+}
+
+// Get gets the object of the resource
+func (server *Server) Get(ctx context.Context, request *GetRequest) (*GetResponse, error) {
+	if err := server.authorize(ctx, request.Resource, request.Name); err != nil {
+		return nil, err
+	}
+	obj, err := server.k8sAPI.DynamicClient.Resource(request.Resource).Namespace(request.Namespace).Get(ctx, request.Name, request.Options)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &GetResponse{Object: data}, nil
+}
+
+// Describe returns the version and the preferred resources of the API server, restricted to the resources the server serves
+func (server *Server) Describe(ctx context.Context, _ *DescribeRequest) (*DescribeResponse, error) {
+	if err := server.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	serverVersion, err := server.k8sAPI.DiscoveryClient.ServerVersion()
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	resourceLists, err := discovery.ServerPreferredResources(server.k8sAPI.DiscoveryClient)
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, toGRPCError(err)
+	}
+	return &DescribeResponse{ServerVersion: serverVersion, APIResourceLists: server.filterResourceLists(resourceLists)}, nil
+}
+
+// authorize authenticates the request and checks the resource is served
+func (server *Server) authorize(ctx context.Context, gvr schema.GroupVersionResource, name string) error {
+	if err := server.authenticate(ctx); err != nil {
+		return err
+	}
+	if !server.resources[gvr] {
+		return toGRPCError(apierrors.NewForbidden(gvr.GroupResource(), name, fmt.Errorf("resource '%s' is not served by the proxy", k8sinterface.GroupVersionResourceToString(&gvr))))
+	}
+	return nil
+}
+
+func (server *Server) authenticate(ctx context.Context) error {
+	if server.token == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get(authorizationHeader) {
+		token := strings.TrimPrefix(value, "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(server.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid or missing bearer token")
+}
+
+// filterResourceLists returns the resources of the lists the server serves
+func (server *Server) filterResourceLists(resourceLists []*metav1.APIResourceList) []*metav1.APIResourceList {
+	var filtered []*metav1.APIResourceList
+	for i := range resourceLists {
+		if resourceLists[i] == nil {
+			continue
+		}
+		gv, err := schema.ParseGroupVersion(resourceLists[i].GroupVersion)
+		if err != nil {
+			continue
+		}
+		resourceList := &metav1.APIResourceList{TypeMeta: resourceLists[i].TypeMeta, GroupVersion: resourceLists[i].GroupVersion}
+		for _, apiResource := range resourceLists[i].APIResources {
+			if server.resources[gv.WithResource(apiResource.Name)] {
+				resourceList.APIResources = append(resourceList.APIResources, apiResource)
+			}
+		}
+		if len(resourceList.APIResources) > 0 {
+			filtered = append(filtered, resourceList)
+		}
+	}
+	return filtered
+}
+
+// clusterDataServer is the handler type of the service
+type clusterDataServer interface {
+	List(context.Context, *ListRequest) (*ListResponse, error)
+	Get(context.Context, *GetRequest) (*GetResponse, error)
+	Describe(context.Context, *DescribeRequest) (*DescribeResponse, error)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*clusterDataServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: listMethod, Handler: listHandler},
+		{MethodName: getMethod, Handler: getHandler},
+		{MethodName: describeMethod, Handler: describeHandler},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+func listHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	request := &ListRequest{}
+	if err := dec(request); err != nil {
+		return nil, err
+	}
+	handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return srv.(clusterDataServer).List(ctx, request.(*ListRequest))
+	}
+	if interceptor == nil {
+		return handler(ctx, request)
+	}
+	return interceptor(ctx, request, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(listMethod)}, handler)
+}
+
+func getHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	request := &GetRequest{}
+	if err := dec(request); err != nil {
+		return nil, err
+	}
+	handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return srv.(clusterDataServer).Get(ctx, request.(*GetRequest))
+	}
+	if interceptor == nil {
+		return handler(ctx, request)
+	}
+	return interceptor(ctx, request, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(getMethod)}, handler)
+}
+
+func describeHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	request := &DescribeRequest{}
+	if err := dec(request); err != nil {
+		return nil, err
+	}
+	handler := func(ctx context.Context, request interface{}) (interface{}, error) {
+		return srv.(clusterDataServer).Describe(ctx, request.(*DescribeRequest))
+	}
+	if interceptor == nil {
+		return handler(ctx, request)
+	}
+	return interceptor(ctx, request, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod(describeMethod)}, handler)
+}
+
+func fullMethod(method string) string {
+	return "/" + ServiceName + "/" + method
+}
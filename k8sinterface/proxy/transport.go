@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// transport serves the REST requests of the typed and the metadata clients with the proxy: the list and get requests of the resources are sent to the server,
+// the other requests fail with errReadOnly
+type transport struct {
+	client *Client
+}
+
+func (t *transport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Body != nil {
+		request.Body.Close()
+	}
+	if request.Method != http.MethodGet {
+		return errorResponse(request, apierrors.NewMethodNotSupported(schema.GroupResource{Resource: request.URL.Path}, request.Method)), nil
+	}
+	gvr, namespace, name, err := parseResourcePath(request.URL.Path)
+	if err != nil {
+		return errorResponse(request, apierrors.NewBadRequest(err.Error())), nil
+	}
+	query := request.URL.Query()
+	if query.Get("watch") == "true" || query.Get("watch") == "1" {
+		return errorResponse(request, apierrors.NewMethodNotSupported(gvr.GroupResource(), "watch")), nil
+	}
+
+	var body interface{}
+	if name == "" {
+		listOptions := metav1.ListOptions{}
+		if err := metav1.Convert_url_Values_To_v1_ListOptions(&query, &listOptions, nil); err != nil {
+			return errorResponse(request, apierrors.NewBadRequest(err.Error())), nil
+		}
+		response := &ListResponse{}
+		if err := t.client.invoke(request.Context(), listMethod, &ListRequest{Resource: gvr, Namespace: namespace, Options: listOptions}, response); err != nil {
+			return errorResponse(request, err), nil
+		}
+		listMetadata := metav1.ListMeta{ResourceVersion: response.ResourceVersion, Continue: response.Continue, RemainingItemCount: response.RemainingItemCount}
+		body = map[string]interface{}{"apiVersion": response.APIVersion, "kind": response.Kind, "metadata": listMetadata, "items": response.Items}
+	} else {
+		getOptions := metav1.GetOptions{}
+		if err := metav1.Convert_url_Values_To_v1_GetOptions(&query, &getOptions, nil); err != nil {
+			return errorResponse(request, apierrors.NewBadRequest(err.Error())), nil
+		}
+		response := &GetResponse{}
+		if err := t.client.invoke(request.Context(), getMethod, &GetRequest{Resource: gvr, Namespace: namespace, Name: name, Options: getOptions}, response); err != nil {
+			return errorResponse(request, err), nil
+		}
+		body = response.Object
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return newResponse(request, http.StatusOK, data), nil
+}
+
+// parseResourcePath returns the resource, the namespace and the name of the path of a request: /api/v1/[namespaces/<namespace>/]<resource>[/<name>]
+// or /apis/<group>/<version>/[namespaces/<namespace>/]<resource>[/<name>]. The subresources are not supported
+func parseResourcePath(path string) (schema.GroupVersionResource, string, string, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	var gvr schema.GroupVersionResource
+	switch {
+	case len(segments) >= 3 && segments[0] == "api":
+		gvr.Version, segments = segments[1], segments[2:]
+	case len(segments) >= 4 && segments[0] == "apis":
+		gvr.Group, gvr.Version, segments = segments[1], segments[2], segments[3:]
+	default:
+		return gvr, "", "", fmt.Errorf("the path '%s' is not served by the cluster data proxy", path)
+	}
+	namespace := ""
+	if len(segments) >= 3 && segments[0] == "namespaces" {
+		namespace, segments = segments[1], segments[2:]
+	}
+	if len(segments) > 2 {
+		return gvr, "", "", fmt.Errorf("the subresource '%s' is not served by the cluster data proxy", strings.Join(segments[2:], "/"))
+	}
+	gvr.Resource = segments[0]
+	if len(segments) == 2 {
+		return gvr, namespace, segments[1], nil
+	}
+	return gvr, namespace, "", nil
+}
+
+// errorResponse returns the response of the error, with the status of the Kubernetes API errors so the clients return the same errors
+func errorResponse(request *http.Request, err error) *http.Response {
+	var apiStatus apierrors.APIStatus
+	if !errors.As(err, &apiStatus) {
+		apiStatus = apierrors.NewInternalError(err)
+	}
+	status := apiStatus.Status()
+	status.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Status"}
+	data, _ := json.Marshal(status)
+	code := int(status.Code)
+	if code == 0 {
+		code = http.StatusInternalServerError
+	}
+	return newResponse(request, code, data)
+}
+
+func newResponse(request *http.Request, code int, data []byte) *http.Response {
+	return &http.Response{
+		StatusCode:    code,
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       request,
+	}
+}
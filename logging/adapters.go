@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"github.com/go-logr/logr"
+	"go.uber.org/zap"
+)
+
+// zapLogger adapts a zap logger, the verbosity is the zap logger level
+type zapLogger struct {
+	logger *zap.SugaredLogger
+}
+
+// NewZapLogger returns a Logger writing to the zap logger
+func NewZapLogger(logger *zap.Logger) Logger {
+	return &zapLogger{logger: logger.WithOptions(zap.AddCallerSkip(1)).Sugar()}
+}
+
+func (l *zapLogger) Error(msg string, err error, keysAndValues ...interface{}) {
+	l.logger.Errorw(msg, append(keysAndValues, "error", err)...)
+}
+
+func (l *zapLogger) Warning(msg string, keysAndValues ...interface{}) {
+	l.logger.Warnw(msg, keysAndValues...)
+}
+
+func (l *zapLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.logger.Infow(msg, keysAndValues...)
+}
+
+func (l *zapLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.logger.Debugw(msg, keysAndValues...)
+}
+
+// logrLogger adapts a logr logger. Warnings are logged at V(0) with a "level" key, debug messages at V(1)
+type logrLogger struct {
+	logger logr.Logger
+}
+
+// NewLogrLogger returns a Logger writing to the logr logger
+func NewLogrLogger(logger logr.Logger) Logger {
+	return &logrLogger{logger: logger.WithCallDepth(1)}
+}
+
+func (l *logrLogger) Error(msg string, err error, keysAndValues ...interface{}) {
+	l.logger.Error(err, msg, keysAndValues...)
+}
+
+func (l *logrLogger) Warning(msg string, keysAndValues ...interface{}) {
+	l.logger.Info(msg, append(keysAndValues, "level", "warning")...)
+}
+
+func (l *logrLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.logger.Info(msg, keysAndValues...)
+}
+
+func (l *logrLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.logger.V(1).Info(msg, keysAndValues...)
+}
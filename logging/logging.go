@@ -0,0 +1,146 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the verbosity of a log message
+type Level int
+
+const (
+	LevelError Level = iota
+	LevelWarning
+	LevelInfo
+	LevelDebug
+)
+
+// Logger is the logger used by the package. Key/value pairs follow the logr convention: "key1", value1, "key2", value2
+type Logger interface {
+	Error(msg string, err error, keysAndValues ...interface{})
+	Warning(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Debug(msg string, keysAndValues ...interface{})
+}
+
+// NoopLogger does not log anything
+type NoopLogger struct {
+}
+
+func (NoopLogger) Error(msg string, err error, keysAndValues ...interface{}) {}
+func (NoopLogger) Warning(msg string, keysAndValues ...interface{})          {}
+func (NoopLogger) Info(msg string, keysAndValues ...interface{})             {}
+func (NoopLogger) Debug(msg string, keysAndValues ...interface{})            {}
+
+var (
+	// defaultLogger writes the errors and the warnings to stderr
+	defaultLogger Logger = NewLeveledLogger(NewWriterLogger(os.Stderr), LevelWarning)
+	loggerMutex          = sync.RWMutex{}
+)
+
+// SetDefault sets the logger used when no logger was injected. Passing nil disables the logging
+func SetDefault(logger Logger) {
+	loggerMutex.Lock()
+	defer loggerMutex.Unlock()
+	if logger == nil {
+		logger = NoopLogger{}
+	}
+	defaultLogger = logger
+}
+
+// L returns the default logger
+func L() Logger {
+	loggerMutex.RLock()
+	defer loggerMutex.RUnlock()
+	return defaultLogger
+}
+
+// OrDefault returns the logger, or the default logger if nil
+func OrDefault(logger Logger) Logger {
+	if logger == nil {
+		return L()
+	}
+	return logger
+}
+
+// leveledLogger drops the messages above the verbosity level
+type leveledLogger struct {
+	logger Logger
+	level  Level
+}
+
+// NewLeveledLogger returns a logger that only logs messages up to the verbosity level, e.g. LevelInfo drops the debug messages
+func NewLeveledLogger(logger Logger, level Level) Logger {
+	return &leveledLogger{logger: logger, level: level}
+}
+
+func (l *leveledLogger) Error(msg string, err error, keysAndValues ...interface{}) {
+	l.logger.Error(msg, err, keysAndValues...)
+}
+
+func (l *leveledLogger) Warning(msg string, keysAndValues ...interface{}) {
+	if l.level >= LevelWarning {
+		l.logger.Warning(msg, keysAndValues...)
+	}
+}
+
+func (l *leveledLogger) Info(msg string, keysAndValues ...interface{}) {
+	if l.level >= LevelInfo {
+		l.logger.Info(msg, keysAndValues...)
+	}
+}
+
+func (l *leveledLogger) Debug(msg string, keysAndValues ...interface{}) {
+	if l.level >= LevelDebug {
+		l.logger.Debug(msg, keysAndValues...)
+	}
+}
+
+// writerLogger writes the messages as lines of text
+type writerLogger struct {
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+// NewWriterLogger returns a Logger writing the messages and their key/value pairs as lines of text to the writer, e.g. os.Stderr
+func NewWriterLogger(writer io.Writer) Logger {
+	return &writerLogger{writer: writer}
+}
+
+func (l *writerLogger) Error(msg string, err error, keysAndValues ...interface{}) {
+	l.write("error", msg, append(keysAndValues, "error", err))
+}
+
+func (l *writerLogger) Warning(msg string, keysAndValues ...interface{}) {
+	l.write("warning", msg, keysAndValues)
+}
+
+func (l *writerLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.write("info", msg, keysAndValues)
+}
+
+func (l *writerLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.write("debug", msg, keysAndValues)
+}
+
+func (l *writerLogger) write(level, msg string, keysAndValues []interface{}) {
+	var sb strings.Builder
+	sb.WriteString(time.Now().UTC().Format(time.RFC3339))
+	sb.WriteString(" " + level + " " + msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		var value interface{}
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		fmt.Fprintf(&sb, " %v=%v", keysAndValues[i], value)
+	}
+	sb.WriteString("\n")
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	_, _ = io.WriteString(l.writer, sb.String())
+}
@@ -0,0 +1,123 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// recordingLogger records the logged messages prefixed by their level
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Error(msg string, err error, keysAndValues ...interface{}) {
+	l.messages = append(l.messages, "error: "+msg)
+}
+func (l *recordingLogger) Warning(msg string, keysAndValues ...interface{}) {
+	l.messages = append(l.messages, "warning: "+msg)
+}
+func (l *recordingLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.messages = append(l.messages, "info: "+msg)
+}
+func (l *recordingLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf("debug: %s %v", msg, keysAndValues))
+}
+
+func logAllLevels(logger Logger) {
+	logger.Error("e", fmt.Errorf("failed"))
+	logger.Warning("w")
+	logger.Info("i")
+	logger.Debug("d")
+}
+
+func TestSetDefault(t *testing.T) {
+	defaultLogger := L()
+	defer SetDefault(defaultLogger)
+
+	// the warnings are written to stderr by default
+	assert.Equal(t, NewLeveledLogger(NewWriterLogger(os.Stderr), LevelWarning), L())
+
+	recorder := &recordingLogger{}
+	SetDefault(recorder)
+	assert.Equal(t, recorder, L())
+	assert.Equal(t, recorder, OrDefault(nil))
+
+	other := &recordingLogger{}
+	assert.Equal(t, other, OrDefault(other))
+
+	SetDefault(nil)
+	assert.IsType(t, NoopLogger{}, L())
+}
+
+func TestNewLeveledLogger(t *testing.T) {
+	tests := []struct {
+		level    Level
+		expected []string
+	}{
+		{level: LevelError, expected: []string{"error: e"}},
+		{level: LevelWarning, expected: []string{"error: e", "warning: w"}},
+		{level: LevelInfo, expected: []string{"error: e", "warning: w", "info: i"}},
+		{level: LevelDebug, expected: []string{"error: e", "warning: w", "info: i", "debug: d []"}},
+	}
+	for _, tt := range tests {
+		recorder := &recordingLogger{}
+		logAllLevels(NewLeveledLogger(recorder, tt.level))
+		assert.Equal(t, tt.expected, recorder.messages, "level %d", tt.level)
+	}
+}
+
+func TestNewWriterLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logAllLevels(NewWriterLogger(&buf))
+	NewWriterLogger(&buf).Warning("unable to get imagePullSecret", "secret", "regcred")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, 5)
+	assert.True(t, strings.HasSuffix(lines[0], " error e error=failed"), lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], " warning w"), lines[1])
+	assert.True(t, strings.HasSuffix(lines[3], " debug d"), lines[3])
+	assert.True(t, strings.HasSuffix(lines[4], " warning unable to get imagePullSecret secret=regcred"), lines[4])
+}
+
+func TestNewZapLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logAllLevels(NewZapLogger(zap.New(core)))
+
+	entries := logs.AllUntimed()
+	assert.Len(t, entries, 3)
+	assert.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+	assert.Equal(t, "failed", entries[0].ContextMap()["error"])
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	assert.Equal(t, zapcore.InfoLevel, entries[2].Level)
+
+	core, logs = observer.New(zapcore.DebugLevel)
+	NewZapLogger(zap.New(core)).Debug("request", "method", "GET")
+	entries = logs.AllUntimed()
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "GET", entries[0].ContextMap()["method"])
+}
+
+func TestNewLogrLogger(t *testing.T) {
+	var lines []string
+	newLogger := func(verbosity int) Logger {
+		lines = nil
+		return NewLogrLogger(funcr.New(func(prefix, args string) { lines = append(lines, args) }, funcr.Options{Verbosity: verbosity}))
+	}
+
+	logAllLevels(newLogger(0))
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.Contains(lines[0], `"error"="failed"`), lines[0])
+	assert.True(t, strings.Contains(lines[1], `"level"="warning"`), lines[1])
+
+	logAllLevels(newLogger(1))
+	assert.Len(t, lines, 4)
+}
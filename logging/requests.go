@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	restclient "k8s.io/client-go/rest"
+)
+
+// WrapConfig returns a copy of the config that logs a summary of each request (method, resource, namespace, status and duration) at debug level.
+// The logger is resolved per request
+func WrapConfig(config *restclient.Config, getLogger func() Logger) *restclient.Config {
+	if config == nil {
+		return nil
+	}
+	if getLogger == nil {
+		getLogger = L
+	}
+	wrapped := restclient.CopyConfig(config)
+	wrapped.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &roundTripper{next: rt, getLogger: getLogger}
+	})
+	return wrapped
+}
+
+type roundTripper struct {
+	next      http.RoundTripper
+	getLogger func() Logger
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := rt.next.RoundTrip(req)
+	summary := RequestSummary(req.Method, req.URL.Path)
+	keysAndValues := []interface{}{"method", summary.Method, "resource", summary.Resource, "duration", time.Since(start).String()}
+	if summary.Namespace != "" {
+		keysAndValues = append(keysAndValues, "namespace", summary.Namespace)
+	}
+	if err != nil {
+		rt.getLogger().Debug("kubernetes API request failed", append(keysAndValues, "error", err.Error())...)
+		return resp, err
+	}
+	rt.getLogger().Debug("kubernetes API request", append(keysAndValues, "status", resp.StatusCode)...)
+	return resp, err
+}
+
+// Summary is the summary of an API server request
+type Summary struct {
+	Method    string
+	Resource  string // group/version/resource[/subresource], e.g. apps/v1/deployments, /v1/pods/log
+	Namespace string
+}
+
+// RequestSummary returns the summary of an API server request from its path, e.g. /apis/apps/v1/namespaces/default/deployments/nginx
+func RequestSummary(method, path string) Summary {
+	summary := Summary{Method: method}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	var group, version string
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		version, parts = parts[1], parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		group, version, parts = parts[1], parts[2], parts[3:]
+	default:
+		summary.Resource = path
+		return summary
+	}
+	if len(parts) >= 2 && parts[0] == "namespaces" {
+		summary.Namespace = parts[1]
+		parts = parts[2:]
+		if len(parts) == 0 {
+			// the namespace object itself
+			parts = []string{"namespaces"}
+		}
+	}
+	resource := ""
+	if len(parts) > 0 {
+		resource = parts[0]
+	}
+	// <resource>/<name>/<subresource>
+	if len(parts) > 2 {
+		resource += "/" + parts[2]
+	}
+	summary.Resource = group + "/" + version + "/" + resource
+	return summary
+}
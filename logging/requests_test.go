@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	restclient "k8s.io/client-go/rest"
+)
+
+func TestRequestSummary(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		expected Summary
+	}{
+		{method: "GET", path: "/api/v1/pods", expected: Summary{Method: "GET", Resource: "/v1/pods"}},
+		{method: "GET", path: "/api/v1/namespaces/default/pods/nginx/log", expected: Summary{Method: "GET", Resource: "/v1/pods/log", Namespace: "default"}},
+		{method: "GET", path: "/api/v1/namespaces", expected: Summary{Method: "GET", Resource: "/v1/namespaces"}},
+		{method: "GET", path: "/api/v1/namespaces/kube-system", expected: Summary{Method: "GET", Resource: "/v1/namespaces", Namespace: "kube-system"}},
+		{method: "LIST", path: "/apis/apps/v1/namespaces/default/deployments", expected: Summary{Method: "LIST", Resource: "apps/v1/deployments", Namespace: "default"}},
+		{method: "DELETE", path: "/apis/rbac.authorization.k8s.io/v1/clusterroles/admin", expected: Summary{Method: "DELETE", Resource: "rbac.authorization.k8s.io/v1/clusterroles"}},
+		{method: "GET", path: "/version", expected: Summary{Method: "GET", Resource: "/version"}},
+		{method: "GET", path: "/apis", expected: Summary{Method: "GET", Resource: "/apis"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RequestSummary(tt.method, tt.path))
+		})
+	}
+}
+
+func TestWrapConfig(t *testing.T) {
+	assert.Nil(t, WrapConfig(nil, nil))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	recorder := &recordingLogger{}
+	config := WrapConfig(&restclient.Config{Host: server.URL}, func() Logger { return recorder })
+	transport, err := restclient.TransportFor(config)
+	assert.NoError(t, err)
+
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL + "/apis/apps/v1/namespaces/default/deployments/nginx")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Len(t, recorder.messages, 1)
+	assert.Contains(t, recorder.messages[0], "debug: kubernetes API request [method GET resource apps/v1/deployments duration")
+	assert.Contains(t, recorder.messages[0], "namespace default status 404]")
+}
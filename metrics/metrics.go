@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	restclient "k8s.io/client-go/rest"
+)
+
+// Sources of the recorded requests
+const (
+	SourceKubernetes = "kubernetes"
+	SourceAWS        = "aws"
+	SourceAzure      = "azure"
+	SourceGCP        = "gcp"
+)
+
+// Request results recorded for calls that do not return an HTTP status code
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+// IRecorder records metrics of the API server and cloud provider calls
+type IRecorder interface {
+	// ObserveRequest records a request and its latency. The result is the HTTP status code, or ResultSuccess/ResultError
+	ObserveRequest(source, operation, result string, duration time.Duration)
+	// ObserveThrottle records a throttled request (e.g. HTTP 429 or a cloud provider throttling error)
+	ObserveThrottle(source, operation string)
+	// ObserveCache records a cache lookup
+	ObserveCache(cache string, hit bool)
+}
+
+// NoopRecorder is the default recorder, it does not record anything
+type NoopRecorder struct {
+}
+
+func (NoopRecorder) ObserveRequest(source, operation, result string, duration time.Duration) {}
+func (NoopRecorder) ObserveThrottle(source, operation string)                                {}
+func (NoopRecorder) ObserveCache(cache string, hit bool)                                     {}
+
+var (
+	recorder      IRecorder = NoopRecorder{}
+	recorderMutex           = sync.RWMutex{}
+)
+
+// SetRecorder sets the recorder used by the package instrumentation. Passing nil disables the instrumentation
+func SetRecorder(r IRecorder) {
+	recorderMutex.Lock()
+	defer recorderMutex.Unlock()
+	if r == nil {
+		r = NoopRecorder{}
+	}
+	recorder = r
+}
+
+// GetRecorder returns the recorder used by the package instrumentation
+func GetRecorder() IRecorder {
+	recorderMutex.RLock()
+	defer recorderMutex.RUnlock()
+	return recorder
+}
+
+// ObserveCall records the latency and result of a cloud provider call, and returns the error of the call.
+// isThrottled classifies the error as a throttling error, it may be nil
+func ObserveCall(source, operation string, isThrottled func(error) bool, call func() error) error {
+	start := time.Now()
+	err := call()
+	r := GetRecorder()
+	result := ResultSuccess
+	if err != nil {
+		result = ResultError
+		if isThrottled != nil && isThrottled(err) {
+			r.ObserveThrottle(source, operation)
+		}
+	}
+	r.ObserveRequest(source, operation, result, time.Since(start))
+	return err
+}
+
+// WrapConfig returns a copy of the config whose requests are recorded by the package recorder.
+// The recorder is resolved per request, so SetRecorder may be called after the clients were created
+func WrapConfig(config *restclient.Config) *restclient.Config {
+	if config == nil {
+		return nil
+	}
+	wrapped := restclient.CopyConfig(config)
+	wrapped.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &roundTripper{next: rt}
+	})
+	return wrapped
+}
+
+type roundTripper struct {
+	next http.RoundTripper
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := rt.next.RoundTrip(req)
+	r := GetRecorder()
+	operation := req.Method
+	result := ResultError
+	if err == nil {
+		result = strconv.Itoa(resp.StatusCode)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			r.ObserveThrottle(SourceKubernetes, operation)
+		}
+	}
+	r.ObserveRequest(SourceKubernetes, operation, result, time.Since(start))
+	return resp, err
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	restclient "k8s.io/client-go/rest"
+)
+
+type recordedRequest struct {
+	source, operation, result string
+}
+
+type testRecorder struct {
+	mutex     sync.Mutex
+	requests  []recordedRequest
+	throttled int
+	hits      int
+	misses    int
+}
+
+func (r *testRecorder) ObserveRequest(source, operation, result string, duration time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.requests = append(r.requests, recordedRequest{source: source, operation: operation, result: result})
+}
+
+func (r *testRecorder) ObserveThrottle(source, operation string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.throttled++
+}
+
+func (r *testRecorder) ObserveCache(cache string, hit bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if hit {
+		r.hits++
+	} else {
+		r.misses++
+	}
+}
+
+func TestWrapConfig(t *testing.T) {
+	recorder := &testRecorder{}
+	SetRecorder(recorder)
+	defer SetRecorder(nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/throttled" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := WrapConfig(&restclient.Config{Host: server.URL})
+	transport, err := restclient.TransportFor(config)
+	assert.NoError(t, err)
+	client := &http.Client{Transport: transport}
+
+	for _, path := range []string{"/ok", "/throttled"} {
+		resp, err := client.Get(server.URL + path)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, []recordedRequest{
+		{source: SourceKubernetes, operation: http.MethodGet, result: "200"},
+		{source: SourceKubernetes, operation: http.MethodGet, result: "429"},
+	}, recorder.requests)
+	assert.Equal(t, 1, recorder.throttled)
+	assert.Nil(t, WrapConfig(nil))
+}
+
+func TestObserveCall(t *testing.T) {
+	recorder := &testRecorder{}
+	SetRecorder(recorder)
+	defer SetRecorder(nil)
+
+	isThrottled := func(err error) bool { return err.Error() == "throttled" }
+	assert.NoError(t, ObserveCall(SourceAWS, "eks.DescribeCluster", isThrottled, func() error { return nil }))
+	assert.Error(t, ObserveCall(SourceAWS, "eks.DescribeCluster", isThrottled, func() error { return errors.New("throttled") }))
+	assert.Error(t, ObserveCall(SourceGCP, "container.GetCluster", nil, func() error { return errors.New("not found") }))
+
+	assert.Equal(t, []recordedRequest{
+		{source: SourceAWS, operation: "eks.DescribeCluster", result: ResultSuccess},
+		{source: SourceAWS, operation: "eks.DescribeCluster", result: ResultError},
+		{source: SourceGCP, operation: "container.GetCluster", result: ResultError},
+	}, recorder.requests)
+	assert.Equal(t, 1, recorder.throttled)
+}
+
+func TestSetRecorderNil(t *testing.T) {
+	SetRecorder(nil)
+	assert.Equal(t, NoopRecorder{}, GetRecorder())
+}
@@ -0,0 +1,73 @@
+package prometheus
+
+import (
+	"time"
+
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Recorder records the package metrics as Prometheus metrics:
+//
+//	<namespace>_requests_total{source,operation,result}
+//	<namespace>_request_duration_seconds{source,operation}
+//	<namespace>_throttled_requests_total{source,operation}
+//	<namespace>_cache_requests_total{cache,result}
+type Recorder struct {
+	requests         *prometheus.CounterVec
+	requestDurations *prometheus.HistogramVec
+	throttled        *prometheus.CounterVec
+	cacheRequests    *prometheus.CounterVec
+}
+
+var _ metrics.IRecorder = &Recorder{}
+
+// NewRecorder creates the metrics and registers them in the registerer
+func NewRecorder(registerer prometheus.Registerer, namespace string) (*Recorder, error) {
+	r := &Recorder{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Number of requests to the API server and cloud providers",
+		}, []string{"source", "operation", "result"}),
+		requestDurations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests to the API server and cloud providers",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"source", "operation"}),
+		throttled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "throttled_requests_total",
+			Help:      "Number of requests throttled by the API server or cloud providers",
+		}, []string{"source", "operation"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_requests_total",
+			Help:      "Number of cache lookups by result (hit/miss)",
+		}, []string{"cache", "result"}),
+	}
+	for _, collector := range []prometheus.Collector{r.requests, r.requestDurations, r.throttled, r.cacheRequests} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Recorder) ObserveRequest(source, operation, result string, duration time.Duration) {
+	r.requests.WithLabelValues(source, operation, result).Inc()
+	r.requestDurations.WithLabelValues(source, operation).Observe(duration.Seconds())
+}
+
+func (r *Recorder) ObserveThrottle(source, operation string) {
+	r.throttled.WithLabelValues(source, operation).Inc()
+}
+
+func (r *Recorder) ObserveCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	r.cacheRequests.WithLabelValues(cache, result).Inc()
+}
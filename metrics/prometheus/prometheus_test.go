@@ -0,0 +1,35 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubescape/k8s-interface/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	recorder, err := NewRecorder(registry, "kubescape")
+	assert.NoError(t, err)
+
+	recorder.ObserveRequest(metrics.SourceKubernetes, "GET", "200", 10*time.Millisecond)
+	recorder.ObserveRequest(metrics.SourceKubernetes, "GET", "200", 20*time.Millisecond)
+	recorder.ObserveRequest(metrics.SourceKubernetes, "GET", "429", 5*time.Millisecond)
+	recorder.ObserveThrottle(metrics.SourceKubernetes, "GET")
+	recorder.ObserveCache("registryToken", true)
+	recorder.ObserveCache("registryToken", false)
+	recorder.ObserveCache("registryToken", true)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(recorder.requests.WithLabelValues(metrics.SourceKubernetes, "GET", "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(recorder.throttled.WithLabelValues(metrics.SourceKubernetes, "GET")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(recorder.cacheRequests.WithLabelValues("registryToken", "hit")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(recorder.cacheRequests.WithLabelValues("registryToken", "miss")))
+	assert.Equal(t, 1, testutil.CollectAndCount(recorder.requestDurations))
+
+	// registering twice fails
+	_, err = NewRecorder(registry, "kubescape")
+	assert.Error(t, err)
+}
@@ -0,0 +1,179 @@
+package names
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	DNS1123LabelMaxLength     = validation.DNS1123LabelMaxLength
+	DNS1123SubdomainMaxLength = validation.DNS1123SubdomainMaxLength
+	RFC1035LabelMaxLength     = validation.DNS1035LabelMaxLength
+	LabelValueMaxLength       = validation.LabelValueMaxLength
+	annotationNameMaxLength   = 63
+	hashSuffixLength          = 8
+)
+
+// ValidateDNS1123Label returns an error if the string is not a valid DNS-1123 label (e.g. namespace or container name)
+func ValidateDNS1123Label(s string) error {
+	return toError("DNS-1123 label", s, validation.IsDNS1123Label(s))
+}
+
+// ValidateDNS1123Subdomain returns an error if the string is not a valid DNS-1123 subdomain (the name of most Kubernetes objects)
+func ValidateDNS1123Subdomain(s string) error {
+	return toError("DNS-1123 subdomain", s, validation.IsDNS1123Subdomain(s))
+}
+
+// ValidateRFC1035Label returns an error if the string is not a valid RFC-1035 label (e.g. service name)
+func ValidateRFC1035Label(s string) error {
+	return toError("RFC-1035 label", s, validation.IsDNS1035Label(s))
+}
+
+// ValidateLabelValue returns an error if the string is not a valid label value
+func ValidateLabelValue(s string) error {
+	return toError("label value", s, validation.IsValidLabelValue(s))
+}
+
+// ValidateAnnotationKey returns an error if the string is not a valid annotation (or label) key
+func ValidateAnnotationKey(s string) error {
+	return toError("annotation key", s, validation.IsQualifiedName(s))
+}
+
+// SanitizeDNS1123Label converts the string to a valid DNS-1123 label
+func SanitizeDNS1123Label(s string) string {
+	return SanitizeToValidName(s, DNS1123LabelMaxLength, true)
+}
+
+// SanitizeDNS1123Subdomain converts the string to a valid DNS-1123 subdomain. Every dot separated part is sanitized separately and empty parts are dropped
+func SanitizeDNS1123Subdomain(s string) string {
+	parts := []string{}
+	for _, part := range strings.Split(strings.ToLower(s), ".") {
+		if part = sanitize(part, DNS1123SubdomainMaxLength, false, isDNS1123LabelChar); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	name := strings.Join(parts, ".")
+	if name == "" && s != "" {
+		return hashOf(s, hashSuffixLength)
+	}
+	if len(name) <= DNS1123SubdomainMaxLength {
+		return name
+	}
+	prefix := trimNonAlphanumeric(name[:DNS1123SubdomainMaxLength-hashSuffixLength-1])
+	return prefix + "-" + hashOf(s, hashSuffixLength)
+}
+
+// SanitizeRFC1035Label converts the string to a valid RFC-1035 label. Since an RFC-1035 label must start with a letter, names starting with a digit are prefixed with "n"
+func SanitizeRFC1035Label(s string) string {
+	name := SanitizeToValidName(s, RFC1035LabelMaxLength, true)
+	if name != "" && !isLetter(rune(name[0])) {
+		name = SanitizeToValidName("n"+name, RFC1035LabelMaxLength, true)
+	}
+	return name
+}
+
+// SanitizeLabelValue converts the string to a valid label value. Unlike names, label values may contain upper case characters, '_' and '.'
+func SanitizeLabelValue(s string) string {
+	return sanitize(s, LabelValueMaxLength, true, isLabelValueChar)
+}
+
+// SanitizeAnnotationKey converts the string to a valid annotation key, in the format of [prefix/]name
+func SanitizeAnnotationKey(s string) string {
+	prefix, name := "", s
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		prefix, name = SanitizeDNS1123Subdomain(s[:i]), s[i+1:]
+	}
+	name = sanitize(name, annotationNameMaxLength, true, isLabelValueChar)
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
+
+// SanitizeToValidName converts any string to a valid DNS-1123 label-like name (lower case alphanumeric characters and '-') no longer than maxLen.
+// Invalid characters are replaced by '-'. If the name is longer than maxLen and hashSuffix is set, it is truncated and suffixed with a hash of the original string,
+// so different long inputs result in different names. The result is deterministic
+func SanitizeToValidName(s string, maxLen int, hashSuffix bool) string {
+	return sanitize(strings.ToLower(s), maxLen, hashSuffix, isDNS1123LabelChar)
+}
+
+func sanitize(s string, maxLen int, hashSuffix bool, isValidChar func(rune) bool) string {
+	if s == "" || maxLen <= 0 {
+		return ""
+	}
+	var sb strings.Builder
+	var last rune
+	for _, r := range s {
+		if !isValidChar(r) {
+			r = '-'
+		}
+		// avoid repeating separators
+		if r == '-' && last == '-' {
+			continue
+		}
+		sb.WriteRune(r)
+		last = r
+	}
+	name := trimNonAlphanumeric(sb.String())
+
+	if len(name) <= maxLen {
+		if name == "" && hashSuffix {
+			if maxLen < hashSuffixLength {
+				return hashOf(s, maxLen)
+			}
+			return hashOf(s, hashSuffixLength)
+		}
+		return name
+	}
+	if !hashSuffix {
+		return trimNonAlphanumeric(name[:maxLen])
+	}
+	if maxLen <= hashSuffixLength+1 {
+		return hashOf(s, maxLen)
+	}
+	prefix := trimNonAlphanumeric(name[:maxLen-hashSuffixLength-1])
+	if prefix == "" {
+		return hashOf(s, hashSuffixLength)
+	}
+	return prefix + "-" + hashOf(s, hashSuffixLength)
+}
+
+func hashOf(s string, length int) string {
+	hash := sha256.Sum256([]byte(s))
+	h := hex.EncodeToString(hash[:])
+	if length < len(h) {
+		return h[:length]
+	}
+	return h
+}
+
+func trimNonAlphanumeric(s string) string {
+	return strings.TrimFunc(s, func(r rune) bool { return !isAlphanumeric(r) })
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isAlphanumeric(r rune) bool {
+	return isLetter(r) || (r >= '0' && r <= '9')
+}
+
+func isDNS1123LabelChar(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-'
+}
+
+func isLabelValueChar(r rune) bool {
+	return isAlphanumeric(r) || r == '-' || r == '_' || r == '.'
+}
+
+func toError(kind, s string, errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid %s '%s': %s", kind, s, strings.Join(errs, "; "))
+}
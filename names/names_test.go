@@ -0,0 +1,110 @@
+package names
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeToValidName(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		maxLen     int
+		hashSuffix bool
+		want       string
+	}{
+		{
+			name:   "valid name",
+			input:  "nginx-deployment",
+			maxLen: 63,
+			want:   "nginx-deployment",
+		},
+		{
+			name:   "upper case and invalid characters",
+			input:  "Nginx_Deployment.v1",
+			maxLen: 63,
+			want:   "nginx-deployment-v1",
+		},
+		{
+			name:   "repeating and trailing separators",
+			input:  "--quay.io//kubescape:latest--",
+			maxLen: 63,
+			want:   "quay-io-kubescape-latest",
+		},
+		{
+			name:   "truncated without hash",
+			input:  strings.Repeat("a", 70),
+			maxLen: 63,
+			want:   strings.Repeat("a", 63),
+		},
+		{
+			name:       "truncated with hash",
+			input:      strings.Repeat("a", 70),
+			maxLen:     20,
+			hashSuffix: true,
+			want:       "aaaaaaaaaaa-" + hashOf(strings.Repeat("a", 70), hashSuffixLength),
+		},
+		{
+			name:       "only invalid characters",
+			input:      "___",
+			maxLen:     10,
+			hashSuffix: true,
+			want:       hashOf("___", hashSuffixLength),
+		},
+		{
+			name:   "empty",
+			input:  "",
+			maxLen: 63,
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SanitizeToValidName(tt.input, tt.maxLen, tt.hashSuffix)
+			assert.Equal(t, tt.want, got)
+			assert.LessOrEqual(t, len(got), tt.maxLen)
+		})
+	}
+}
+
+func TestSanitizeValid(t *testing.T) {
+	inputs := []string{
+		"nginx",
+		"Nginx_Deployment.v1",
+		"1-starts-with-digit",
+		"quay.io/kubescape/kubevuln@sha256:" + strings.Repeat("f", 64),
+		strings.Repeat("very-long-name.", 30),
+		"ñöñ-äscii",
+		"_.-",
+	}
+	for _, input := range inputs {
+		assert.NoError(t, ValidateDNS1123Label(SanitizeDNS1123Label(input)), input)
+		assert.NoError(t, ValidateDNS1123Subdomain(SanitizeDNS1123Subdomain(input)), input)
+		assert.NoError(t, ValidateRFC1035Label(SanitizeRFC1035Label(input)), input)
+		assert.NoError(t, ValidateLabelValue(SanitizeLabelValue(input)), input)
+		assert.NoError(t, ValidateAnnotationKey(SanitizeAnnotationKey("kubescape.io/"+input)), input)
+	}
+}
+
+func TestSanitizeDeterministic(t *testing.T) {
+	a := strings.Repeat("a", 100)
+	assert.Equal(t, SanitizeDNS1123Label(a), SanitizeDNS1123Label(a))
+	assert.NotEqual(t, SanitizeDNS1123Label(a), SanitizeDNS1123Label(a+"b"))
+	assert.Equal(t, "Nginx_Deployment.v1", SanitizeLabelValue("Nginx_Deployment.v1"))
+	assert.Equal(t, "kubescape.io/instance-id", SanitizeAnnotationKey("kubescape.io/instance-id"))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, ValidateDNS1123Label("nginx"))
+	assert.Error(t, ValidateDNS1123Label("Nginx"))
+	assert.Error(t, ValidateDNS1123Label("nginx.io"))
+	assert.NoError(t, ValidateDNS1123Subdomain("nginx.io"))
+	assert.Error(t, ValidateRFC1035Label("1nginx"))
+	assert.NoError(t, ValidateLabelValue(""))
+	assert.NoError(t, ValidateLabelValue("Nginx_1.0"))
+	assert.Error(t, ValidateLabelValue(strings.Repeat("a", 64)))
+	assert.NoError(t, ValidateAnnotationKey("kubescape.io/wlid"))
+	assert.Error(t, ValidateAnnotationKey("kubescape.io/"))
+}
@@ -0,0 +1,233 @@
+package networkpolicy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	CiliumNetworkPolicyKind = "CiliumNetworkPolicy"
+
+	ciliumNamespaceLabel       = "io.kubernetes.pod.namespace"
+	ciliumNamespaceLabelPrefix = "io.cilium.k8s.namespace.labels."
+)
+
+var (
+	// ciliumLabelSources are the source prefixes of the Cilium selector keys, e.g. k8s:app
+	ciliumLabelSources = []string{"k8s:", "any:"}
+	allIPv4            = &peer{ipBlock: toIPBlock("0.0.0.0/0", nil)}
+	allIPv6            = &peer{ipBlock: toIPBlock("::/0", nil)}
+)
+
+// AddCiliumNetworkPolicy adds an unstructured CiliumNetworkPolicy (cilium.io/v2) to the evaluator.
+// Endpoint, CIDR and the world/cluster/all entity rules are evaluated, deny rules and L7 rules are not
+func (evaluator *Evaluator) AddCiliumNetworkPolicy(object map[string]interface{}) error {
+	if kind, _, _ := unstructured.NestedString(object, "kind"); kind != CiliumNetworkPolicyKind {
+		return fmt.Errorf("kind '%s' is not a %s", kind, CiliumNetworkPolicyKind)
+	}
+	name, _, _ := unstructured.NestedString(object, "metadata", "name")
+	namespace, _, _ := unstructured.NestedString(object, "metadata", "namespace")
+
+	var specs []interface{}
+	if spec, ok, _ := unstructured.NestedMap(object, "spec"); ok {
+		specs = append(specs, spec)
+	}
+	if moreSpecs, ok, _ := unstructured.NestedSlice(object, "specs"); ok {
+		specs = append(specs, moreSpecs...)
+	}
+	for i := range specs {
+		spec, ok := specs[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		evaluator.policies = append(evaluator.policies, ciliumPolicy(name, namespace, spec))
+	}
+	return nil
+}
+
+func ciliumPolicy(name, namespace string, spec map[string]interface{}) policy {
+	p := policy{name: name, namespace: namespace, podSelector: labels.Nothing()}
+	if endpointSelector, ok, _ := unstructured.NestedMap(spec, "endpointSelector"); ok {
+		p.podSelector, _ = ciliumSelector(endpointSelector)
+	}
+	ingress, _, _ := unstructured.NestedSlice(spec, "ingress")
+	ingressDeny, _, _ := unstructured.NestedSlice(spec, "ingressDeny")
+	p.isolatesIngress = len(ingress) > 0 || len(ingressDeny) > 0
+	p.ingress = ciliumRules(ingress, namespace, "from")
+
+	egress, _, _ := unstructured.NestedSlice(spec, "egress")
+	egressDeny, _, _ := unstructured.NestedSlice(spec, "egressDeny")
+	p.isolatesEgress = len(egress) > 0 || len(egressDeny) > 0
+	p.egress = ciliumRules(egress, namespace, "to")
+	return p
+}
+
+// ciliumRules returns the rules of the ingress (direction "from") or egress (direction "to") section
+func ciliumRules(ciliumRules []interface{}, namespace, direction string) []rule {
+	var rules []rule
+	for i := range ciliumRules {
+		ciliumRule, ok := ciliumRules[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		r := rule{ports: ciliumPorts(ciliumRule)}
+
+		hasPeers := false
+		endpoints, _, _ := unstructured.NestedSlice(ciliumRule, direction+"Endpoints")
+		for j := range endpoints {
+			hasPeers = true
+			endpoint, ok := endpoints[j].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			podSelector, namespaceSelector := ciliumSelector(endpoint)
+			pr := peer{namespace: namespace, podSelector: podSelector, namespaceSelector: namespaceSelector}
+			r.peers = append(r.peers, pr)
+		}
+		cidrs, _, _ := unstructured.NestedStringSlice(ciliumRule, direction+"CIDR")
+		for j := range cidrs {
+			hasPeers = true
+			if block := toIPBlock(cidrs[j], nil); block != nil {
+				r.peers = append(r.peers, peer{ipBlock: block})
+			}
+		}
+		cidrSets, _, _ := unstructured.NestedSlice(ciliumRule, direction+"CIDRSet")
+		for j := range cidrSets {
+			hasPeers = true
+			cidrSet, ok := cidrSets[j].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			cidr, _, _ := unstructured.NestedString(cidrSet, "cidr")
+			except, _, _ := unstructured.NestedStringSlice(cidrSet, "except")
+			if block := toIPBlock(cidr, except); block != nil {
+				r.peers = append(r.peers, peer{ipBlock: block})
+			}
+		}
+		entities, _, _ := unstructured.NestedStringSlice(ciliumRule, direction+"Entities")
+		for _, entity := range entities {
+			hasPeers = true
+			r.peers = append(r.peers, ciliumEntityPeers(entity)...)
+		}
+
+		switch {
+		case hasPeers && len(r.peers) == 0:
+			// the rule has peers, but none this package can evaluate
+			continue
+		case !hasPeers && len(r.ports) == 0:
+			// an empty rule isolates the endpoints without allowing anything
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+func ciliumEntityPeers(entity string) []peer {
+	allPods := peer{podSelector: labels.Everything(), namespaceSelector: labels.Everything()}
+	switch entity {
+	case "all":
+		return []peer{allPods, *allIPv4, *allIPv6}
+	case "cluster":
+		return []peer{allPods}
+	case "world":
+		return []peer{*allIPv4, *allIPv6}
+	}
+	return nil
+}
+
+func ciliumPorts(ciliumRule map[string]interface{}) []port {
+	var ports []port
+	toPorts, _, _ := unstructured.NestedSlice(ciliumRule, "toPorts")
+	for i := range toPorts {
+		portRule, ok := toPorts[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		portProtocols, _, _ := unstructured.NestedSlice(portRule, "ports")
+		for j := range portProtocols {
+			portProtocol, ok := portProtocols[j].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			p := port{}
+			if protocol, _, _ := unstructured.NestedString(portProtocol, "protocol"); protocol != "" && protocol != "ANY" {
+				p.protocol = corev1.Protocol(protocol)
+			}
+			if portValue, _, _ := unstructured.NestedString(portProtocol, "port"); portValue != "" && portValue != "0" {
+				if portNumber, err := strconv.Atoi(portValue); err == nil {
+					portIntOrString := intstr.FromInt(portNumber)
+					p.port = &portIntOrString
+				} else {
+					portIntOrString := intstr.FromString(portValue)
+					p.port = &portIntOrString
+				}
+			}
+			if endPort, ok, _ := unstructured.NestedInt64(portProtocol, "endPort"); ok {
+				p.endPort = int32(endPort)
+			}
+			ports = append(ports, p)
+		}
+	}
+	return ports
+}
+
+// ciliumSelector returns the pod selector and the namespace selector of a Cilium endpoint selector.
+// The namespace selector is nil if the endpoint selector does not select namespaces, i.e. it selects the namespace of the policy
+func ciliumSelector(endpointSelector map[string]interface{}) (labels.Selector, labels.Selector) {
+	labelSelector := &metav1.LabelSelector{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(endpointSelector, labelSelector); err != nil {
+		return labels.Nothing(), nil
+	}
+
+	podLabelSelector := &metav1.LabelSelector{MatchLabels: map[string]string{}}
+	namespaceLabelSelector := &metav1.LabelSelector{MatchLabels: map[string]string{}}
+	selectsNamespaces := false
+	for key, value := range labelSelector.MatchLabels {
+		key = trimCiliumLabelSource(key)
+		switch {
+		case key == ciliumNamespaceLabel:
+			namespaceLabelSelector.MatchLabels[namespaceNameLabel] = value
+			selectsNamespaces = true
+		case strings.HasPrefix(key, ciliumNamespaceLabelPrefix):
+			namespaceLabelSelector.MatchLabels[strings.TrimPrefix(key, ciliumNamespaceLabelPrefix)] = value
+			selectsNamespaces = true
+		default:
+			podLabelSelector.MatchLabels[key] = value
+		}
+	}
+	for _, requirement := range labelSelector.MatchExpressions {
+		requirement.Key = trimCiliumLabelSource(requirement.Key)
+		switch {
+		case requirement.Key == ciliumNamespaceLabel:
+			requirement.Key = namespaceNameLabel
+			namespaceLabelSelector.MatchExpressions = append(namespaceLabelSelector.MatchExpressions, requirement)
+			selectsNamespaces = true
+		case strings.HasPrefix(requirement.Key, ciliumNamespaceLabelPrefix):
+			requirement.Key = strings.TrimPrefix(requirement.Key, ciliumNamespaceLabelPrefix)
+			namespaceLabelSelector.MatchExpressions = append(namespaceLabelSelector.MatchExpressions, requirement)
+			selectsNamespaces = true
+		default:
+			podLabelSelector.MatchExpressions = append(podLabelSelector.MatchExpressions, requirement)
+		}
+	}
+	if !selectsNamespaces {
+		return toSelector(podLabelSelector), nil
+	}
+	return toSelector(podLabelSelector), toSelector(namespaceLabelSelector)
+}
+
+func trimCiliumLabelSource(key string) string {
+	for _, source := range ciliumLabelSources {
+		key = strings.TrimPrefix(key, source)
+	}
+	return key
+}
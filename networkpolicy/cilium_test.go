@@ -0,0 +1,69 @@
+package networkpolicy
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestAddCiliumNetworkPolicy(t *testing.T) {
+	frontend := newPod("shop", "frontend", "10.0.0.1", map[string]string{"app": "frontend"})
+	backend := newPod("shop", "backend", "10.0.0.2", map[string]string{"app": "backend"})
+	monitoring := newPod("monitoring", "prometheus", "10.0.1.1", map[string]string{"app": "prometheus"})
+	other := newPod("other", "client", "10.0.2.1", map[string]string{"app": "frontend"})
+
+	evaluator := NewEvaluator(nil, nil)
+	require.NoError(t, evaluator.AddCiliumNetworkPolicy(map[string]interface{}{
+		"apiVersion": "cilium.io/v2",
+		"kind":       "CiliumNetworkPolicy",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "backend"},
+		"spec": map[string]interface{}{
+			"endpointSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "backend"}},
+			"ingress": []interface{}{
+				map[string]interface{}{
+					"fromEndpoints": []interface{}{map[string]interface{}{"matchLabels": map[string]interface{}{"k8s:app": "frontend"}}},
+					"toPorts":       []interface{}{map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": "8080", "protocol": "TCP"}}}},
+				},
+				map[string]interface{}{
+					"fromEndpoints": []interface{}{map[string]interface{}{"matchLabels": map[string]interface{}{"k8s:io.kubernetes.pod.namespace": "monitoring"}}},
+				},
+				map[string]interface{}{
+					"fromCIDRSet": []interface{}{map[string]interface{}{"cidr": "192.168.0.0/16", "except": []interface{}{"192.168.1.0/24"}}},
+				},
+			},
+		},
+		"specs": []interface{}{
+			map[string]interface{}{
+				"endpointSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "frontend"}},
+				"ingress": []interface{}{
+					map[string]interface{}{"fromEntities": []interface{}{"world"}, "toPorts": []interface{}{map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": "443"}}}}},
+				},
+				"egress": []interface{}{map[string]interface{}{}},
+			},
+		},
+	}))
+
+	assert.True(t, evaluator.IsIngressIsolated(backend))
+	assert.False(t, evaluator.IsEgressIsolated(backend))
+	assert.True(t, evaluator.IsEgressIsolated(frontend))
+
+	assert.False(t, evaluator.CanReach(frontend, backend, 8080, corev1.ProtocolTCP), "the empty egress rule of the frontend denies all egress")
+	assert.True(t, evaluator.CanReach(monitoring, backend, 9090, corev1.ProtocolTCP))
+	assert.False(t, evaluator.CanReach(other, backend, 8080, corev1.ProtocolTCP))
+	assert.True(t, evaluator.CanReachFromIP(net.ParseIP("192.168.2.1"), backend, 80, corev1.ProtocolTCP))
+	assert.False(t, evaluator.CanReachFromIP(net.ParseIP("192.168.1.1"), backend, 80, corev1.ProtocolTCP))
+
+	assert.True(t, evaluator.CanReachFromIP(net.ParseIP("8.8.8.8"), frontend, 443, corev1.ProtocolTCP))
+	assert.True(t, evaluator.CanReachFromIP(net.ParseIP("8.8.8.8"), frontend, 443, corev1.ProtocolUDP), "a port without protocol is any protocol")
+	assert.False(t, evaluator.CanReachFromIP(net.ParseIP("8.8.8.8"), frontend, 80, corev1.ProtocolTCP))
+
+	// pods no policy selects are not isolated
+	unselected := newPod("shop", "worker", "10.0.0.3", map[string]string{"app": "worker"})
+	assert.False(t, evaluator.CanReach(unselected, backend, 9999, corev1.ProtocolTCP))
+	assert.True(t, evaluator.CanReach(backend, unselected, 9999, corev1.ProtocolTCP))
+
+	assert.Error(t, evaluator.AddCiliumNetworkPolicy(map[string]interface{}{"kind": "NetworkPolicy"}))
+}
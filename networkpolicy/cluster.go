@@ -0,0 +1,36 @@
+package networkpolicy
+
+import (
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewEvaluatorFromCluster returns an evaluator of the NetworkPolicies of the cluster, and of the CiliumNetworkPolicies if the cluster serves them
+func NewEvaluatorFromCluster(k8sAPI *k8sinterface.KubernetesApi) (*Evaluator, error) {
+	namespaces, err := k8sAPI.KubernetesClient.CoreV1().Namespaces().List(k8sAPI.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST namespaces, reason: %s", err.Error())
+	}
+	networkPolicies, err := k8sAPI.KubernetesClient.NetworkingV1().NetworkPolicies("").List(k8sAPI.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST networkpolicies, reason: %s", err.Error())
+	}
+	evaluator := NewEvaluator(namespaces.Items, networkPolicies.Items)
+
+	info, ok := k8sinterface.GetKindInfo(CiliumNetworkPolicyKind)
+	if !ok {
+		return evaluator, nil
+	}
+	ciliumNetworkPolicies, err := k8sAPI.DynamicClient.Resource(info.GroupVersionResource).Namespace("").List(k8sAPI.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST %s, reason: %s", info.GroupVersionResource.Resource, err.Error())
+	}
+	for i := range ciliumNetworkPolicies.Items {
+		if err := evaluator.AddCiliumNetworkPolicy(ciliumNetworkPolicies.Items[i].Object); err != nil {
+			return nil, err
+		}
+	}
+	return evaluator, nil
+}
@@ -0,0 +1,390 @@
+package networkpolicy
+
+import (
+	"net"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// namespaceNameLabel is set by the API server on every namespace (1.21+)
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// Evaluator answers connectivity queries between pods from the network policies of the cluster.
+// Kubernetes NetworkPolicies and CiliumNetworkPolicies are evaluated, other policy engines (e.g. Calico) are not
+type Evaluator struct {
+	policies        []policy
+	namespaceLabels map[string]labels.Set
+}
+
+// policy is a network policy of any engine, reduced to the pods it selects and the traffic it allows
+type policy struct {
+	name        string
+	namespace   string
+	podSelector labels.Selector
+	// ingress/egress are the rules of the direction, nil if the policy does not isolate the direction
+	ingress []rule
+	egress  []rule
+	// isolatesIngress/isolatesEgress are set even if the policy has no rules, e.g. a default deny policy
+	isolatesIngress bool
+	isolatesEgress  bool
+}
+
+// rule allows the traffic of any of its peers on any of its ports. No peers allows all peers, no ports allows all ports
+type rule struct {
+	peers []peer
+	ports []port
+}
+
+// peer is the pods and/or the IP block of a rule
+type peer struct {
+	// namespaceSelector selects the namespaces of the pods, nil to select only namespace
+	namespaceSelector labels.Selector
+	namespace         string
+	// podSelector selects the pods, nil if the peer is an IP block
+	podSelector labels.Selector
+	ipBlock     *ipBlock
+}
+
+type ipBlock struct {
+	cidr   *net.IPNet
+	except []*net.IPNet
+}
+
+type port struct {
+	// protocol is empty for any protocol
+	protocol corev1.Protocol
+	// port is nil for all the ports of the protocol
+	port    *intstr.IntOrString
+	endPort int32
+}
+
+// NewEvaluator returns an evaluator of the Kubernetes NetworkPolicies. The labels of the namespaces are used to evaluate namespace selectors,
+// namespaces that are not listed only have the kubernetes.io/metadata.name label
+func NewEvaluator(namespaces []corev1.Namespace, networkPolicies []networkingv1.NetworkPolicy) *Evaluator {
+	evaluator := &Evaluator{namespaceLabels: map[string]labels.Set{}}
+	for i := range namespaces {
+		evaluator.namespaceLabels[namespaces[i].GetName()] = labels.Set(namespaces[i].GetLabels())
+	}
+	for i := range networkPolicies {
+		evaluator.AddNetworkPolicy(&networkPolicies[i])
+	}
+	return evaluator
+}
+
+// AddNetworkPolicy adds a Kubernetes NetworkPolicy to the evaluator. Policies with invalid selectors select no pods, the IP blocks with an invalid
+// CIDR match no IP: a rule whose peers are all invalid allows nothing
+func (evaluator *Evaluator) AddNetworkPolicy(networkPolicy *networkingv1.NetworkPolicy) {
+	p := policy{
+		name:        networkPolicy.GetName(),
+		namespace:   networkPolicy.GetNamespace(),
+		podSelector: toSelector(&networkPolicy.Spec.PodSelector),
+	}
+	policyTypes := networkPolicy.Spec.PolicyTypes
+	if len(policyTypes) == 0 {
+		// the API server defaults the policy types to Ingress, plus Egress if the policy has egress rules
+		policyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+		if len(networkPolicy.Spec.Egress) > 0 {
+			policyTypes = append(policyTypes, networkingv1.PolicyTypeEgress)
+		}
+	}
+	for _, policyType := range policyTypes {
+		switch policyType {
+		case networkingv1.PolicyTypeIngress:
+			p.isolatesIngress = true
+			for i := range networkPolicy.Spec.Ingress {
+				if r, ok := networkPolicyRule(networkPolicy.Spec.Ingress[i].From, networkPolicy.Spec.Ingress[i].Ports, p.namespace); ok {
+					p.ingress = append(p.ingress, r)
+				}
+			}
+		case networkingv1.PolicyTypeEgress:
+			p.isolatesEgress = true
+			for i := range networkPolicy.Spec.Egress {
+				if r, ok := networkPolicyRule(networkPolicy.Spec.Egress[i].To, networkPolicy.Spec.Egress[i].Ports, p.namespace); ok {
+					p.egress = append(p.egress, r)
+				}
+			}
+		}
+	}
+	evaluator.policies = append(evaluator.policies, p)
+}
+
+// IsIngressIsolated returns true if a policy selects the pod for ingress, so only the traffic the policies allow can reach it
+func (evaluator *Evaluator) IsIngressIsolated(pod *corev1.Pod) bool {
+	for i := range evaluator.policies {
+		if evaluator.policies[i].isolatesIngress && evaluator.policies[i].selects(pod) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEgressIsolated returns true if a policy selects the pod for egress, so the pod can only send the traffic the policies allow
+func (evaluator *Evaluator) IsEgressIsolated(pod *corev1.Pod) bool {
+	for i := range evaluator.policies {
+		if evaluator.policies[i].isolatesEgress && evaluator.policies[i].selects(pod) {
+			return true
+		}
+	}
+	return false
+}
+
+// PoliciesSelecting returns the <namespace>/<name> of the policies selecting the pod, sorted
+func (evaluator *Evaluator) PoliciesSelecting(pod *corev1.Pod) []string {
+	names := []string{}
+	for i := range evaluator.policies {
+		if evaluator.policies[i].selects(pod) {
+			names = append(names, evaluator.policies[i].namespace+"/"+evaluator.policies[i].name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PodsWithoutIngressPolicy returns the pods no policy isolates for ingress, i.e. pods that accept traffic from everywhere
+func (evaluator *Evaluator) PodsWithoutIngressPolicy(pods []corev1.Pod) []corev1.Pod {
+	result := []corev1.Pod{}
+	for i := range pods {
+		if !evaluator.IsIngressIsolated(&pods[i]) {
+			result = append(result, pods[i])
+		}
+	}
+	return result
+}
+
+// PodsWithoutEgressPolicy returns the pods no policy isolates for egress, i.e. pods that can send traffic everywhere
+func (evaluator *Evaluator) PodsWithoutEgressPolicy(pods []corev1.Pod) []corev1.Pod {
+	result := []corev1.Pod{}
+	for i := range pods {
+		if !evaluator.IsEgressIsolated(&pods[i]) {
+			result = append(result, pods[i])
+		}
+	}
+	return result
+}
+
+// CanReach returns true if the policies allow the from pod to connect to the to pod on the port. An empty protocol is TCP
+func (evaluator *Evaluator) CanReach(from, to *corev1.Pod, portNumber int32, protocol corev1.Protocol) bool {
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+	return evaluator.egressAllowed(from, to, portNumber, protocol) && evaluator.ingressAllowed(to, from, nil, portNumber, protocol)
+}
+
+// CanReachFromIP returns true if the policies allow connections from the IP (e.g. outside the cluster) to the pod on the port. An empty protocol is TCP
+func (evaluator *Evaluator) CanReachFromIP(ip net.IP, to *corev1.Pod, portNumber int32, protocol corev1.Protocol) bool {
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+	return evaluator.ingressAllowed(to, nil, ip, portNumber, protocol)
+}
+
+// ingressAllowed returns true if the policies of the pod allow the traffic from the source pod, or from the source IP if the source pod is nil
+func (evaluator *Evaluator) ingressAllowed(pod, sourcePod *corev1.Pod, sourceIP net.IP, portNumber int32, protocol corev1.Protocol) bool {
+	isolated := false
+	for i := range evaluator.policies {
+		p := &evaluator.policies[i]
+		if !p.isolatesIngress || !p.selects(pod) {
+			continue
+		}
+		isolated = true
+		if evaluator.rulesAllow(p.ingress, sourcePod, sourceIP, pod, portNumber, protocol) {
+			return true
+		}
+	}
+	return !isolated
+}
+
+// egressAllowed returns true if the policies of the pod allow the traffic to the destination pod
+func (evaluator *Evaluator) egressAllowed(pod, destination *corev1.Pod, portNumber int32, protocol corev1.Protocol) bool {
+	isolated := false
+	for i := range evaluator.policies {
+		p := &evaluator.policies[i]
+		if !p.isolatesEgress || !p.selects(pod) {
+			continue
+		}
+		isolated = true
+		if evaluator.rulesAllow(p.egress, destination, nil, destination, portNumber, protocol) {
+			return true
+		}
+	}
+	return !isolated
+}
+
+// rulesAllow returns true if a rule allows the peer pod (or the peer IP if the peer pod is nil) on the port of the destination pod
+func (evaluator *Evaluator) rulesAllow(rules []rule, peerPod *corev1.Pod, peerIP net.IP, destination *corev1.Pod, portNumber int32, protocol corev1.Protocol) bool {
+	for i := range rules {
+		if !portsMatch(rules[i].ports, destination, portNumber, protocol) {
+			continue
+		}
+		if len(rules[i].peers) == 0 {
+			return true
+		}
+		for j := range rules[i].peers {
+			if evaluator.peerMatches(&rules[i].peers[j], peerPod, peerIP) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (evaluator *Evaluator) peerMatches(pr *peer, peerPod *corev1.Pod, peerIP net.IP) bool {
+	if peerPod != nil && pr.podSelector != nil {
+		if !pr.podSelector.Matches(labels.Set(peerPod.GetLabels())) {
+			return false
+		}
+		if pr.namespaceSelector == nil {
+			return peerPod.GetNamespace() == pr.namespace
+		}
+		return pr.namespaceSelector.Matches(evaluator.getNamespaceLabels(peerPod.GetNamespace()))
+	}
+	if pr.ipBlock == nil {
+		return false
+	}
+	if peerPod != nil {
+		// pods match IP blocks by their IP, see https://kubernetes.io/docs/concepts/services-networking/network-policies/#behavior-of-to-and-from-selectors
+		peerIP = net.ParseIP(peerPod.Status.PodIP)
+	}
+	return pr.ipBlock.contains(peerIP)
+}
+
+func (evaluator *Evaluator) getNamespaceLabels(namespace string) labels.Set {
+	if namespaceLabels, ok := evaluator.namespaceLabels[namespace]; ok {
+		return namespaceLabels
+	}
+	return labels.Set{namespaceNameLabel: namespace}
+}
+
+func (p *policy) selects(pod *corev1.Pod) bool {
+	return pod.GetNamespace() == p.namespace && p.podSelector.Matches(labels.Set(pod.GetLabels()))
+}
+
+func (block *ipBlock) contains(ip net.IP) bool {
+	if ip == nil || !block.cidr.Contains(ip) {
+		return false
+	}
+	for i := range block.except {
+		if block.except[i].Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// portsMatch returns true if the ports include the port of the destination pod. Named ports are resolved from the containers of the destination pod
+func portsMatch(ports []port, destination *corev1.Pod, portNumber int32, protocol corev1.Protocol) bool {
+	if len(ports) == 0 {
+		return true
+	}
+	for i := range ports {
+		if ports[i].protocol != "" && ports[i].protocol != protocol {
+			continue
+		}
+		if ports[i].port == nil {
+			return true
+		}
+		if ports[i].port.Type == intstr.String {
+			if namedPortNumber, ok := resolveNamedPort(destination, ports[i].port.StrVal, protocol); ok && namedPortNumber == portNumber {
+				return true
+			}
+			continue
+		}
+		if ports[i].port.IntVal == portNumber || (ports[i].endPort >= ports[i].port.IntVal && portNumber >= ports[i].port.IntVal && portNumber <= ports[i].endPort) {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveNamedPort(pod *corev1.Pod, name string, protocol corev1.Protocol) (int32, bool) {
+	if pod == nil {
+		return 0, false
+	}
+	for i := range pod.Spec.Containers {
+		for _, containerPort := range pod.Spec.Containers[i].Ports {
+			containerProtocol := containerPort.Protocol
+			if containerProtocol == "" {
+				containerProtocol = corev1.ProtocolTCP
+			}
+			if containerPort.Name == name && containerProtocol == protocol {
+				return containerPort.ContainerPort, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// networkPolicyRule returns the rule of the peers and the ports, false if the rule has peers but none is valid: the rule then allows nothing,
+// unlike a rule without peers which allows all the peers
+func networkPolicyRule(peers []networkingv1.NetworkPolicyPeer, ports []networkingv1.NetworkPolicyPort, namespace string) (rule, bool) {
+	r := rule{peers: networkPolicyPeers(peers, namespace), ports: networkPolicyPorts(ports)}
+	return r, len(peers) == 0 || len(r.peers) > 0
+}
+
+func networkPolicyPeers(networkPolicyPeers []networkingv1.NetworkPolicyPeer, namespace string) []peer {
+	peers := make([]peer, 0, len(networkPolicyPeers))
+	for i := range networkPolicyPeers {
+		networkPolicyPeer := &networkPolicyPeers[i]
+		if networkPolicyPeer.IPBlock != nil {
+			if block := toIPBlock(networkPolicyPeer.IPBlock.CIDR, networkPolicyPeer.IPBlock.Except); block != nil {
+				peers = append(peers, peer{ipBlock: block})
+			}
+			continue
+		}
+		pr := peer{namespace: namespace, podSelector: labels.Everything()}
+		if networkPolicyPeer.PodSelector != nil {
+			pr.podSelector = toSelector(networkPolicyPeer.PodSelector)
+		}
+		if networkPolicyPeer.NamespaceSelector != nil {
+			pr.namespaceSelector = toSelector(networkPolicyPeer.NamespaceSelector)
+		}
+		peers = append(peers, pr)
+	}
+	return peers
+}
+
+func networkPolicyPorts(networkPolicyPorts []networkingv1.NetworkPolicyPort) []port {
+	ports := make([]port, 0, len(networkPolicyPorts))
+	for i := range networkPolicyPorts {
+		p := port{protocol: corev1.ProtocolTCP, port: networkPolicyPorts[i].Port}
+		if networkPolicyPorts[i].Protocol != nil {
+			p.protocol = *networkPolicyPorts[i].Protocol
+		}
+		if networkPolicyPorts[i].EndPort != nil {
+			p.endPort = *networkPolicyPorts[i].EndPort
+		}
+		ports = append(ports, p)
+	}
+	return ports
+}
+
+// toIPBlock returns the IP block, nil if the CIDR or one of the exceptions is invalid: ignoring an exception would allow the IPs it excludes
+func toIPBlock(cidr string, except []string) *ipBlock {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil
+	}
+	block := &ipBlock{cidr: ipNet}
+	for i := range except {
+		_, exceptNet, err := net.ParseCIDR(except[i])
+		if err != nil {
+			return nil
+		}
+		block.except = append(block.except, exceptNet)
+	}
+	return block
+}
+
+// toSelector returns the selector of the label selector, or a selector of nothing if the label selector is invalid
+func toSelector(labelSelector *metav1.LabelSelector) labels.Selector {
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return labels.Nothing()
+	}
+	return selector
+}
@@ -0,0 +1,160 @@
+package networkpolicy
+
+import (
+	"net"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/k8sinterface/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func newPod(namespace, name, ip string, podLabels map[string]string, ports ...corev1.ContainerPort) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: podLabels},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: name, Ports: ports}}},
+		Status:     corev1.PodStatus{PodIP: ip},
+	}
+}
+
+func newNetworkPolicy(namespace, name string, podSelector map[string]string, spec networkingv1.NetworkPolicySpec) networkingv1.NetworkPolicy {
+	spec.PodSelector = metav1.LabelSelector{MatchLabels: podSelector}
+	return networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}, Spec: spec}
+}
+
+func portOf(p intstr.IntOrString) *intstr.IntOrString {
+	return &p
+}
+
+func TestEvaluatorIngress(t *testing.T) {
+	frontend := newPod("shop", "frontend", "10.0.0.1", map[string]string{"app": "frontend"})
+	backend := newPod("shop", "backend", "10.0.0.2", map[string]string{"app": "backend"}, corev1.ContainerPort{Name: "http", ContainerPort: 8080})
+	monitoring := newPod("monitoring", "prometheus", "10.0.1.1", map[string]string{"app": "prometheus"})
+	other := newPod("other", "client", "10.0.2.1", map[string]string{"app": "frontend"})
+
+	udp := corev1.ProtocolUDP
+	endPort := int32(9100)
+	evaluator := NewEvaluator(
+		[]corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "monitoring", Labels: map[string]string{"team": "sre"}}}},
+		[]networkingv1.NetworkPolicy{
+			newNetworkPolicy("shop", "backend-ingress", map[string]string{"app": "backend"}, networkingv1.NetworkPolicySpec{
+				Ingress: []networkingv1.NetworkPolicyIngressRule{
+					{
+						From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}}}},
+						Ports: []networkingv1.NetworkPolicyPort{{Port: portOf(intstr.FromString("http"))}},
+					},
+					{
+						From:  []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "sre"}}}},
+						Ports: []networkingv1.NetworkPolicyPort{{Port: portOf(intstr.FromInt(9000)), EndPort: &endPort}, {Protocol: &udp}},
+					},
+					{
+						From: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "192.168.0.0/16", Except: []string{"192.168.1.0/24"}}}},
+					},
+				},
+			}),
+		},
+	)
+
+	assert.True(t, evaluator.IsIngressIsolated(backend))
+	assert.False(t, evaluator.IsIngressIsolated(frontend))
+	assert.False(t, evaluator.IsEgressIsolated(backend))
+	assert.Equal(t, []string{"shop/backend-ingress"}, evaluator.PoliciesSelecting(backend))
+
+	// pod selector peers select pods of the policy namespace, named ports are resolved from the destination pod
+	assert.True(t, evaluator.CanReach(frontend, backend, 8080, ""))
+	assert.False(t, evaluator.CanReach(frontend, backend, 80, corev1.ProtocolTCP))
+	assert.False(t, evaluator.CanReach(other, backend, 8080, corev1.ProtocolTCP))
+
+	// namespace selector peers, port ranges and protocol-only ports
+	assert.True(t, evaluator.CanReach(monitoring, backend, 9050, corev1.ProtocolTCP))
+	assert.False(t, evaluator.CanReach(monitoring, backend, 9200, corev1.ProtocolTCP))
+	assert.True(t, evaluator.CanReach(monitoring, backend, 53, corev1.ProtocolUDP))
+
+	// IP blocks
+	assert.True(t, evaluator.CanReachFromIP(net.ParseIP("192.168.2.3"), backend, 443, corev1.ProtocolTCP))
+	assert.False(t, evaluator.CanReachFromIP(net.ParseIP("192.168.1.3"), backend, 443, corev1.ProtocolTCP))
+	assert.False(t, evaluator.CanReachFromIP(net.ParseIP("8.8.8.8"), backend, 443, corev1.ProtocolTCP))
+
+	// pods without policies accept everything
+	assert.True(t, evaluator.CanReach(backend, frontend, 1234, corev1.ProtocolTCP))
+	assert.True(t, evaluator.CanReachFromIP(net.ParseIP("8.8.8.8"), frontend, 443, corev1.ProtocolTCP))
+
+	withoutIngress := evaluator.PodsWithoutIngressPolicy([]corev1.Pod{*frontend, *backend, *monitoring})
+	require.Len(t, withoutIngress, 2)
+	assert.Equal(t, "frontend", withoutIngress[0].GetName())
+	assert.Equal(t, "prometheus", withoutIngress[1].GetName())
+}
+
+func TestEvaluatorInvalidIPBlock(t *testing.T) {
+	backend := newPod("shop", "backend", "10.0.0.2", map[string]string{"app": "backend"})
+	frontend := newPod("shop", "frontend", "10.0.0.1", map[string]string{"app": "frontend"})
+
+	evaluator := NewEvaluator(nil, []networkingv1.NetworkPolicy{
+		newNetworkPolicy("shop", "backend-ingress", map[string]string{"app": "backend"}, networkingv1.NetworkPolicySpec{
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				// the only peer is invalid, the rule allows nothing
+				{From: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/33"}}}},
+				// an invalid exception invalidates the IP block
+				{From: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "192.168.0.0/16", Except: []string{"192.168.1.0"}}}}},
+			},
+		}),
+	})
+	assert.True(t, evaluator.IsIngressIsolated(backend))
+	assert.False(t, evaluator.CanReach(frontend, backend, 8080, corev1.ProtocolTCP))
+	assert.False(t, evaluator.CanReachFromIP(net.ParseIP("8.8.8.8"), backend, 443, corev1.ProtocolTCP))
+	assert.False(t, evaluator.CanReachFromIP(net.ParseIP("192.168.1.3"), backend, 443, corev1.ProtocolTCP))
+}
+
+func TestEvaluatorEgress(t *testing.T) {
+	frontend := newPod("shop", "frontend", "10.0.0.1", map[string]string{"app": "frontend"})
+	backend := newPod("shop", "backend", "10.0.0.2", map[string]string{"app": "backend"})
+	dns := newPod("kube-system", "coredns", "10.0.3.1", map[string]string{"k8s-app": "kube-dns"})
+
+	evaluator := NewEvaluator(nil, []networkingv1.NetworkPolicy{
+		newNetworkPolicy("shop", "default-deny", nil, networkingv1.NetworkPolicySpec{
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		}),
+		newNetworkPolicy("shop", "frontend-egress", map[string]string{"app": "frontend"}, networkingv1.NetworkPolicySpec{
+			// the policy types default to Ingress and Egress since the policy has egress rules
+			Egress: []networkingv1.NetworkPolicyEgressRule{
+				{To: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "backend"}}}}},
+				{To: []networkingv1.NetworkPolicyPeer{{
+					// namespaces without labels only have the kubernetes.io/metadata.name label
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "kube-system"}},
+					PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"k8s-app": "kube-dns"}},
+				}}},
+			},
+		}),
+	})
+
+	assert.True(t, evaluator.IsEgressIsolated(frontend))
+	assert.True(t, evaluator.IsEgressIsolated(backend))
+	assert.Equal(t, []string{"shop/default-deny", "shop/frontend-egress"}, evaluator.PoliciesSelecting(frontend))
+
+	// the egress of the frontend is allowed, but the default deny denies the ingress of the backend
+	assert.False(t, evaluator.CanReach(frontend, backend, 8080, corev1.ProtocolTCP))
+	assert.True(t, evaluator.CanReach(frontend, dns, 53, corev1.ProtocolUDP))
+	assert.False(t, evaluator.CanReach(backend, dns, 53, corev1.ProtocolUDP))
+
+	assert.Empty(t, evaluator.PodsWithoutEgressPolicy([]corev1.Pod{*frontend, *backend}))
+	assert.Len(t, evaluator.PodsWithoutEgressPolicy([]corev1.Pod{*dns}), 1)
+}
+
+func TestNewEvaluatorFromCluster(t *testing.T) {
+	policy := newNetworkPolicy("default", "deny-all", nil, networkingv1.NetworkPolicySpec{})
+	k8sAPI, err := fake.NewKubernetesApi(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&policy,
+	)
+	require.NoError(t, err)
+
+	evaluator, err := NewEvaluatorFromCluster(k8sAPI)
+	require.NoError(t, err)
+	pod := newPod("default", "nginx", "10.0.0.1", map[string]string{"app": "nginx"})
+	assert.True(t, evaluator.IsIngressIsolated(pod))
+	assert.False(t, evaluator.CanReachFromIP(net.ParseIP("10.0.0.2"), pod, 80, corev1.ProtocolTCP))
+}
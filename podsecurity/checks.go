@@ -0,0 +1,518 @@
+package podsecurity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const appArmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+// check is a check of the Pod Security Standards. evaluate returns false with the reason and detail of the violation if the pod fails the check
+type check struct {
+	id    string
+	level Level
+	// supersededByRestricted is set on the baseline checks a restricted check replaces at the restricted level
+	supersededByRestricted bool
+	// exemptOnWindows is set on the restricted checks that do not apply to the pods with spec.os.name=windows
+	exemptOnWindows bool
+	evaluate        func(podAnnotations map[string]string, podSpec *corev1.PodSpec) (string, string, bool)
+}
+
+var checks = []check{
+	{id: "hostNamespaces", level: LevelBaseline, evaluate: checkHostNamespaces},
+	{id: "privileged", level: LevelBaseline, evaluate: checkPrivileged},
+	{id: "capabilities_baseline", level: LevelBaseline, supersededByRestricted: true, evaluate: checkCapabilitiesBaseline},
+	{id: "hostPathVolumes", level: LevelBaseline, evaluate: checkHostPathVolumes},
+	{id: "hostPorts", level: LevelBaseline, evaluate: checkHostPorts},
+	{id: "appArmorProfile", level: LevelBaseline, evaluate: checkAppArmorProfile},
+	{id: "seLinuxOptions", level: LevelBaseline, evaluate: checkSELinuxOptions},
+	{id: "procMount", level: LevelBaseline, evaluate: checkProcMount},
+	{id: "seccompProfile_baseline", level: LevelBaseline, supersededByRestricted: true, evaluate: checkSeccompProfileBaseline},
+	{id: "sysctls", level: LevelBaseline, evaluate: checkSysctls},
+	{id: "windowsHostProcess", level: LevelBaseline, evaluate: checkWindowsHostProcess},
+	{id: "restrictedVolumes", level: LevelRestricted, evaluate: checkRestrictedVolumes},
+	{id: "allowPrivilegeEscalation", level: LevelRestricted, exemptOnWindows: true, evaluate: checkAllowPrivilegeEscalation},
+	{id: "runAsNonRoot", level: LevelRestricted, evaluate: checkRunAsNonRoot},
+	{id: "runAsUser", level: LevelRestricted, evaluate: checkRunAsUser},
+	{id: "seccompProfile_restricted", level: LevelRestricted, exemptOnWindows: true, evaluate: checkSeccompProfileRestricted},
+	{id: "capabilities_restricted", level: LevelRestricted, exemptOnWindows: true, evaluate: checkCapabilitiesRestricted},
+}
+
+// baselineCapabilities are the capabilities the baseline level allows to add
+var baselineCapabilities = map[corev1.Capability]bool{
+	"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true, "FSETID": true, "KILL": true, "MKNOD": true,
+	"NET_BIND_SERVICE": true, "SETFCAP": true, "SETGID": true, "SETPCAP": true, "SETUID": true, "SYS_CHROOT": true,
+}
+
+// safeSysctls are the sysctls the baseline level allows
+var safeSysctls = map[string]bool{
+	"kernel.shm_rmid_forced": true, "net.ipv4.ip_local_port_range": true, "net.ipv4.ip_unprivileged_port_start": true,
+	"net.ipv4.tcp_syncookies": true, "net.ipv4.ping_group_range": true,
+}
+
+// seLinuxTypes are the SELinux types the baseline level allows
+var seLinuxTypes = map[string]bool{"": true, "container_t": true, "container_init_t": true, "container_kvm_t": true}
+
+// restrictedVolumeTypes are the volume types the restricted level allows
+var restrictedVolumeTypes = []string{"configMap", "csi", "downwardAPI", "emptyDir", "ephemeral", "persistentVolumeClaim", "projected", "secret"}
+
+type container struct {
+	name            string
+	securityContext *corev1.SecurityContext
+	ports           []corev1.ContainerPort
+}
+
+// podContainers returns the init, regular and ephemeral containers of the pod spec
+func podContainers(podSpec *corev1.PodSpec) []container {
+	var containers []container
+	for _, c := range append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...) {
+		containers = append(containers, container{name: c.Name, securityContext: c.SecurityContext, ports: c.Ports})
+	}
+	for _, c := range podSpec.EphemeralContainers {
+		containers = append(containers, container{name: c.Name, securityContext: c.SecurityContext, ports: c.Ports})
+	}
+	return containers
+}
+
+// containersDetail returns `container "a" <suffix>` or `containers "a", "b" <suffix>`
+func containersDetail(names []string, suffix string) string {
+	quoted := make([]string, len(names))
+	for i := range names {
+		quoted[i] = fmt.Sprintf("%q", names[i])
+	}
+	if len(names) == 1 {
+		return fmt.Sprintf("container %s %s", quoted[0], suffix)
+	}
+	return fmt.Sprintf("containers %s %s", strings.Join(quoted, ", "), suffix)
+}
+
+func checkHostNamespaces(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	var forbidden []string
+	if podSpec.HostNetwork {
+		forbidden = append(forbidden, "hostNetwork=true")
+	}
+	if podSpec.HostPID {
+		forbidden = append(forbidden, "hostPID=true")
+	}
+	if podSpec.HostIPC {
+		forbidden = append(forbidden, "hostIPC=true")
+	}
+	if len(forbidden) > 0 {
+		return "host namespaces", strings.Join(forbidden, ", "), false
+	}
+	return "", "", true
+}
+
+func checkPrivileged(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	var names []string
+	for _, c := range podContainers(podSpec) {
+		if c.securityContext != nil && c.securityContext.Privileged != nil && *c.securityContext.Privileged {
+			names = append(names, c.name)
+		}
+	}
+	if len(names) > 0 {
+		return "privileged", containersDetail(names, "must not set securityContext.privileged=true"), false
+	}
+	return "", "", true
+}
+
+func checkCapabilitiesBaseline(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	var names []string
+	forbidden := map[string]bool{}
+	for _, c := range podContainers(podSpec) {
+		if c.securityContext == nil || c.securityContext.Capabilities == nil {
+			continue
+		}
+		found := false
+		for _, capability := range c.securityContext.Capabilities.Add {
+			if !baselineCapabilities[capability] {
+				forbidden[string(capability)] = true
+				found = true
+			}
+		}
+		if found {
+			names = append(names, c.name)
+		}
+	}
+	if len(names) > 0 {
+		return "non-default capabilities", containersDetail(names, fmt.Sprintf("must not include %s in securityContext.capabilities.add", quotedKeys(forbidden))), false
+	}
+	return "", "", true
+}
+
+func checkHostPathVolumes(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	var names []string
+	for i := range podSpec.Volumes {
+		if podSpec.Volumes[i].HostPath != nil {
+			names = append(names, fmt.Sprintf("%q", podSpec.Volumes[i].Name))
+		}
+	}
+	if len(names) > 0 {
+		return "hostPath volumes", "volumes " + strings.Join(names, ", "), false
+	}
+	return "", "", true
+}
+
+func checkHostPorts(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	var names []string
+	var ports []string
+	for _, c := range podContainers(podSpec) {
+		found := false
+		for _, port := range c.ports {
+			if port.HostPort != 0 {
+				ports = append(ports, fmt.Sprintf("%d", port.HostPort))
+				found = true
+			}
+		}
+		if found {
+			names = append(names, c.name)
+		}
+	}
+	if len(names) > 0 {
+		return "hostPort", containersDetail(names, "uses hostPorts "+strings.Join(ports, ", ")), false
+	}
+	return "", "", true
+}
+
+func checkAppArmorProfile(podAnnotations map[string]string, _ *corev1.PodSpec) (string, string, bool) {
+	var forbidden []string
+	for key, value := range podAnnotations {
+		if !strings.HasPrefix(key, appArmorAnnotationPrefix) {
+			continue
+		}
+		if value != "runtime/default" && !strings.HasPrefix(value, "localhost/") {
+			forbidden = append(forbidden, fmt.Sprintf("%s=%q", key, value))
+		}
+	}
+	if len(forbidden) > 0 {
+		sort.Strings(forbidden)
+		return "forbidden AppArmor profiles", strings.Join(forbidden, ", "), false
+	}
+	return "", "", true
+}
+
+func checkSELinuxOptions(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	allowed := func(options *corev1.SELinuxOptions) bool {
+		return options == nil || (seLinuxTypes[options.Type] && options.User == "" && options.Role == "")
+	}
+	var forbidden []string
+	if podSpec.SecurityContext != nil && !allowed(podSpec.SecurityContext.SELinuxOptions) {
+		forbidden = append(forbidden, "pod")
+	}
+	var names []string
+	for _, c := range podContainers(podSpec) {
+		if c.securityContext != nil && !allowed(c.securityContext.SELinuxOptions) {
+			names = append(names, c.name)
+		}
+	}
+	if len(names) > 0 {
+		forbidden = append(forbidden, containersDetail(names, ""))
+	}
+	if len(forbidden) > 0 {
+		return "seLinuxOptions", strings.TrimSpace(strings.Join(forbidden, " and ")) + " set forbidden securityContext.seLinuxOptions", false
+	}
+	return "", "", true
+}
+
+func checkProcMount(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	var names []string
+	for _, c := range podContainers(podSpec) {
+		if c.securityContext != nil && c.securityContext.ProcMount != nil && *c.securityContext.ProcMount != corev1.DefaultProcMount {
+			names = append(names, c.name)
+		}
+	}
+	if len(names) > 0 {
+		return "procMount", containersDetail(names, "must not set securityContext.procMount to a value other than Default"), false
+	}
+	return "", "", true
+}
+
+func checkSeccompProfileBaseline(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	unconfined := func(profile *corev1.SeccompProfile) bool {
+		return profile != nil && profile.Type == corev1.SeccompProfileTypeUnconfined
+	}
+	var forbidden []string
+	if podSpec.SecurityContext != nil && unconfined(podSpec.SecurityContext.SeccompProfile) {
+		forbidden = append(forbidden, "pod")
+	}
+	var names []string
+	for _, c := range podContainers(podSpec) {
+		if c.securityContext != nil && unconfined(c.securityContext.SeccompProfile) {
+			names = append(names, c.name)
+		}
+	}
+	if len(names) > 0 {
+		forbidden = append(forbidden, strings.TrimSpace(containersDetail(names, "")))
+	}
+	if len(forbidden) > 0 {
+		return "seccompProfile", strings.Join(forbidden, " and ") + " must not set securityContext.seccompProfile.type to \"Unconfined\"", false
+	}
+	return "", "", true
+}
+
+func checkSysctls(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	if podSpec.SecurityContext == nil {
+		return "", "", true
+	}
+	var forbidden []string
+	for _, sysctl := range podSpec.SecurityContext.Sysctls {
+		if !safeSysctls[sysctl.Name] {
+			forbidden = append(forbidden, sysctl.Name)
+		}
+	}
+	if len(forbidden) > 0 {
+		return "forbidden sysctls", strings.Join(forbidden, ", "), false
+	}
+	return "", "", true
+}
+
+func checkWindowsHostProcess(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	hostProcess := func(options *corev1.WindowsSecurityContextOptions) bool {
+		return options != nil && options.HostProcess != nil && *options.HostProcess
+	}
+	forbidden := podSpec.SecurityContext != nil && hostProcess(podSpec.SecurityContext.WindowsOptions)
+	for _, c := range podContainers(podSpec) {
+		if c.securityContext != nil && hostProcess(c.securityContext.WindowsOptions) {
+			forbidden = true
+		}
+	}
+	if forbidden {
+		return "hostProcess", "pod or containers must not set securityContext.windowsOptions.hostProcess=true", false
+	}
+	return "", "", true
+}
+
+func checkRestrictedVolumes(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	var forbidden []string
+	for i := range podSpec.Volumes {
+		if volumeType := volumeSourceType(&podSpec.Volumes[i].VolumeSource); !contains(restrictedVolumeTypes, volumeType) {
+			forbidden = append(forbidden, fmt.Sprintf("%q uses %s", podSpec.Volumes[i].Name, volumeType))
+		}
+	}
+	if len(forbidden) > 0 {
+		return "restricted volume types", "volumes " + strings.Join(forbidden, ", "), false
+	}
+	return "", "", true
+}
+
+func checkAllowPrivilegeEscalation(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	var names []string
+	for _, c := range podContainers(podSpec) {
+		if c.securityContext == nil || c.securityContext.AllowPrivilegeEscalation == nil || *c.securityContext.AllowPrivilegeEscalation {
+			names = append(names, c.name)
+		}
+	}
+	if len(names) > 0 {
+		return "allowPrivilegeEscalation != false", containersDetail(names, "must set securityContext.allowPrivilegeEscalation=false"), false
+	}
+	return "", "", true
+}
+
+func checkRunAsNonRoot(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	podRunAsNonRoot := podSpec.SecurityContext != nil && podSpec.SecurityContext.RunAsNonRoot != nil && *podSpec.SecurityContext.RunAsNonRoot
+	podSetsFalse := podSpec.SecurityContext != nil && podSpec.SecurityContext.RunAsNonRoot != nil && !*podSpec.SecurityContext.RunAsNonRoot
+
+	var setFalse, unset []string
+	for _, c := range podContainers(podSpec) {
+		if c.securityContext == nil || c.securityContext.RunAsNonRoot == nil {
+			unset = append(unset, c.name)
+		} else if !*c.securityContext.RunAsNonRoot {
+			setFalse = append(setFalse, c.name)
+		}
+	}
+
+	var forbidden []string
+	if podSetsFalse {
+		forbidden = append(forbidden, "pod must not set securityContext.runAsNonRoot=false")
+	}
+	if len(setFalse) > 0 {
+		forbidden = append(forbidden, containersDetail(setFalse, "must not set securityContext.runAsNonRoot=false"))
+	}
+	if !podRunAsNonRoot && len(unset) > 0 {
+		forbidden = append(forbidden, "pod or "+containersDetail(unset, "must set securityContext.runAsNonRoot=true"))
+	}
+	if len(forbidden) > 0 {
+		return "runAsNonRoot != true", strings.Join(forbidden, "; "), false
+	}
+	return "", "", true
+}
+
+func checkRunAsUser(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	var forbidden []string
+	if podSpec.SecurityContext != nil && podSpec.SecurityContext.RunAsUser != nil && *podSpec.SecurityContext.RunAsUser == 0 {
+		forbidden = append(forbidden, "pod")
+	}
+	var names []string
+	for _, c := range podContainers(podSpec) {
+		if c.securityContext != nil && c.securityContext.RunAsUser != nil && *c.securityContext.RunAsUser == 0 {
+			names = append(names, c.name)
+		}
+	}
+	if len(names) > 0 {
+		forbidden = append(forbidden, strings.TrimSpace(containersDetail(names, "")))
+	}
+	if len(forbidden) > 0 {
+		return "runAsUser=0", strings.Join(forbidden, " and ") + " must not set runAsUser=0", false
+	}
+	return "", "", true
+}
+
+func checkSeccompProfileRestricted(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	valid := func(profile *corev1.SeccompProfile) bool {
+		return profile.Type == corev1.SeccompProfileTypeRuntimeDefault || profile.Type == corev1.SeccompProfileTypeLocalhost
+	}
+	podValid := false
+	var forbidden []string
+	if podSpec.SecurityContext != nil && podSpec.SecurityContext.SeccompProfile != nil {
+		if podValid = valid(podSpec.SecurityContext.SeccompProfile); !podValid {
+			forbidden = append(forbidden, fmt.Sprintf("pod must not set securityContext.seccompProfile.type to %q", podSpec.SecurityContext.SeccompProfile.Type))
+		}
+	}
+
+	var invalid, unset []string
+	for _, c := range podContainers(podSpec) {
+		if c.securityContext == nil || c.securityContext.SeccompProfile == nil {
+			unset = append(unset, c.name)
+		} else if !valid(c.securityContext.SeccompProfile) {
+			invalid = append(invalid, c.name)
+		}
+	}
+	if len(invalid) > 0 {
+		forbidden = append(forbidden, containersDetail(invalid, "must not set securityContext.seccompProfile.type to a value other than \"RuntimeDefault\" or \"Localhost\""))
+	}
+	if !podValid && len(unset) > 0 {
+		forbidden = append(forbidden, "pod or "+containersDetail(unset, "must set securityContext.seccompProfile.type to \"RuntimeDefault\" or \"Localhost\""))
+	}
+	if len(forbidden) > 0 {
+		return "seccompProfile", strings.Join(forbidden, "; "), false
+	}
+	return "", "", true
+}
+
+func checkCapabilitiesRestricted(_ map[string]string, podSpec *corev1.PodSpec) (string, string, bool) {
+	var notDropped, added []string
+	forbidden := map[string]bool{}
+	for _, c := range podContainers(podSpec) {
+		if c.securityContext == nil || c.securityContext.Capabilities == nil {
+			notDropped = append(notDropped, c.name)
+			continue
+		}
+		dropsAll := false
+		for _, capability := range c.securityContext.Capabilities.Drop {
+			if capability == "ALL" {
+				dropsAll = true
+			}
+		}
+		if !dropsAll {
+			notDropped = append(notDropped, c.name)
+		}
+		found := false
+		for _, capability := range c.securityContext.Capabilities.Add {
+			if capability != "NET_BIND_SERVICE" {
+				forbidden[string(capability)] = true
+				found = true
+			}
+		}
+		if found {
+			added = append(added, c.name)
+		}
+	}
+
+	var details []string
+	if len(notDropped) > 0 {
+		details = append(details, containersDetail(notDropped, "must set securityContext.capabilities.drop=[\"ALL\"]"))
+	}
+	if len(added) > 0 {
+		details = append(details, containersDetail(added, fmt.Sprintf("must not include %s in securityContext.capabilities.add", quotedKeys(forbidden))))
+	}
+	if len(details) > 0 {
+		return "unrestricted capabilities", strings.Join(details, "; "), false
+	}
+	return "", "", true
+}
+
+// volumeSourceType returns the name of the field of the volume source, e.g. hostPath
+func volumeSourceType(source *corev1.VolumeSource) string {
+	switch {
+	case source.ConfigMap != nil:
+		return "configMap"
+	case source.CSI != nil:
+		return "csi"
+	case source.DownwardAPI != nil:
+		return "downwardAPI"
+	case source.EmptyDir != nil:
+		return "emptyDir"
+	case source.Ephemeral != nil:
+		return "ephemeral"
+	case source.PersistentVolumeClaim != nil:
+		return "persistentVolumeClaim"
+	case source.Projected != nil:
+		return "projected"
+	case source.Secret != nil:
+		return "secret"
+	case source.HostPath != nil:
+		return "hostPath"
+	case source.GCEPersistentDisk != nil:
+		return "gcePersistentDisk"
+	case source.AWSElasticBlockStore != nil:
+		return "awsElasticBlockStore"
+	case source.GitRepo != nil:
+		return "gitRepo"
+	case source.NFS != nil:
+		return "nfs"
+	case source.ISCSI != nil:
+		return "iscsi"
+	case source.Glusterfs != nil:
+		return "glusterfs"
+	case source.RBD != nil:
+		return "rbd"
+	case source.FlexVolume != nil:
+		return "flexVolume"
+	case source.Cinder != nil:
+		return "cinder"
+	case source.CephFS != nil:
+		return "cephfs"
+	case source.Flocker != nil:
+		return "flocker"
+	case source.FC != nil:
+		return "fc"
+	case source.AzureFile != nil:
+		return "azureFile"
+	case source.VsphereVolume != nil:
+		return "vsphereVolume"
+	case source.Quobyte != nil:
+		return "quobyte"
+	case source.AzureDisk != nil:
+		return "azureDisk"
+	case source.PhotonPersistentDisk != nil:
+		return "photonPersistentDisk"
+	case source.PortworxVolume != nil:
+		return "portworxVolume"
+	case source.ScaleIO != nil:
+		return "scaleIO"
+	case source.StorageOS != nil:
+		return "storageos"
+	}
+	return "unknown"
+}
+
+func quotedKeys(set map[string]bool) string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, fmt.Sprintf("%q", key))
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+func contains(list []string, s string) bool {
+	for i := range list {
+		if list[i] == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package podsecurity
+
+import (
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetClusterNamespacePolicies returns the Pod Security admission configuration of the namespaces of the cluster
+func GetClusterNamespacePolicies(k8sAPI *k8sinterface.KubernetesApi) ([]NamespacePolicy, error) {
+	namespaces, err := k8sAPI.KubernetesClient.CoreV1().Namespaces().List(k8sAPI.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST namespaces, reason: %s", err.Error())
+	}
+	policies := make([]NamespacePolicy, len(namespaces.Items))
+	for i := range namespaces.Items {
+		policies[i] = GetNamespacePolicy(namespaces.Items[i].GetName(), namespaces.Items[i].GetLabels())
+	}
+	return policies, nil
+}
+
+// GetClusterEnforcementGaps returns the workloads of the cluster violating the level in namespaces not enforcing it, see GetEnforcementGaps.
+// Workloads owned by another workload are skipped, the gap is reported on the owner
+func GetClusterEnforcementGaps(k8sAPI *k8sinterface.KubernetesApi, level Level) ([]EnforcementGap, error) {
+	policies, err := GetClusterNamespacePolicies(k8sAPI)
+	if err != nil {
+		return nil, err
+	}
+	workloadsByNamespace := map[string][]workloadinterface.IWorkload{}
+	for _, kind := range k8sinterface.WorkloadKinds {
+		workloads, err := k8sAPI.ListWorkloads2("", kind)
+		if err != nil {
+			return nil, err
+		}
+		for i := range workloads {
+			if k8sinterface.IsOwnedByWorkload(workloads[i]) {
+				continue
+			}
+			workloadsByNamespace[workloads[i].GetNamespace()] = append(workloadsByNamespace[workloads[i].GetNamespace()], workloads[i])
+		}
+	}
+
+	gaps := []EnforcementGap{}
+	for i := range policies {
+		namespaceGaps, err := GetEnforcementGaps(policies[i], workloadsByNamespace[policies[i].Namespace], level)
+		if err != nil {
+			return nil, err
+		}
+		gaps = append(gaps, namespaceGaps...)
+	}
+	return gaps, nil
+}
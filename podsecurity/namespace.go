@@ -0,0 +1,113 @@
+package podsecurity
+
+import (
+	"github.com/kubescape/k8s-interface/workloadinterface"
+)
+
+const (
+	LabelEnforce        = "pod-security.kubernetes.io/enforce"
+	LabelEnforceVersion = "pod-security.kubernetes.io/enforce-version"
+	LabelAudit          = "pod-security.kubernetes.io/audit"
+	LabelAuditVersion   = "pod-security.kubernetes.io/audit-version"
+	LabelWarn           = "pod-security.kubernetes.io/warn"
+	LabelWarnVersion    = "pod-security.kubernetes.io/warn-version"
+)
+
+// NamespacePolicy is the Pod Security admission configuration of a namespace, from its labels
+type NamespacePolicy struct {
+	Namespace string `json:"namespace"`
+	// Enforce/Audit/Warn are the levels of the modes. An unset mode is privileged, an invalid level is restricted as pod-security-admission does
+	Enforce Level `json:"enforce"`
+	Audit   Level `json:"audit"`
+	Warn    Level `json:"warn"`
+	// EnforceVersion/AuditVersion/WarnVersion are the versions of the standards of the modes, empty for latest
+	EnforceVersion string `json:"enforceVersion,omitempty"`
+	AuditVersion   string `json:"auditVersion,omitempty"`
+	WarnVersion    string `json:"warnVersion,omitempty"`
+	// EnforceLabelSet is false if the namespace has no enforce label
+	EnforceLabelSet bool `json:"enforceLabelSet"`
+}
+
+// EnforcementGap is a workload violating a level its namespace does not enforce. Such workloads are only reported by the audit/warn modes, if at all
+type EnforcementGap struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	// Enforce is the level the namespace enforces
+	Enforce Level `json:"enforce"`
+	// Level is the level the workload was evaluated against
+	Level      Level       `json:"level"`
+	Violations []Violation `json:"violations"`
+}
+
+// GetNamespacePolicy returns the Pod Security admission configuration of the namespace labels
+func GetNamespacePolicy(namespace string, labels map[string]string) NamespacePolicy {
+	_, enforceLabelSet := labels[LabelEnforce]
+	return NamespacePolicy{
+		Namespace:       namespace,
+		Enforce:         labelLevel(labels, LabelEnforce),
+		Audit:           labelLevel(labels, LabelAudit),
+		Warn:            labelLevel(labels, LabelWarn),
+		EnforceVersion:  labels[LabelEnforceVersion],
+		AuditVersion:    labels[LabelAuditVersion],
+		WarnVersion:     labels[LabelWarnVersion],
+		EnforceLabelSet: enforceLabelSet,
+	}
+}
+
+// GetNamespaceObjectPolicy returns the Pod Security admission configuration of a Namespace object, e.g. of KubernetesApi.GetNamespace
+func GetNamespaceObjectPolicy(namespace workloadinterface.IWorkload) NamespacePolicy {
+	return GetNamespacePolicy(namespace.GetName(), namespace.GetLabels())
+}
+
+// IsEnforced returns true if the namespace enforces the level, or a more restrictive one
+func (policy *NamespacePolicy) IsEnforced(level Level) bool {
+	return policy.Enforce.AtLeast(level)
+}
+
+// GetEnforcementGaps returns the workloads of the namespace violating the level if the namespace does not enforce it.
+// If the level is empty, the most restrictive of the audit/warn levels is used, i.e. the workloads the namespace reports but does not block
+func GetEnforcementGaps(policy NamespacePolicy, workloads []workloadinterface.IWorkload, level Level) ([]EnforcementGap, error) {
+	if level == "" {
+		level = policy.Audit
+		if policy.Warn.AtLeast(level) {
+			level = policy.Warn
+		}
+	}
+	gaps := []EnforcementGap{}
+	if policy.IsEnforced(level) {
+		return gaps, nil
+	}
+	for i := range workloads {
+		if workloads[i].GetNamespace() != policy.Namespace {
+			continue
+		}
+		violations, err := EvaluateWorkload(workloads[i], level)
+		if err != nil {
+			return nil, err
+		}
+		if len(violations) == 0 {
+			continue
+		}
+		gaps = append(gaps, EnforcementGap{
+			Namespace:  policy.Namespace,
+			Kind:       workloads[i].GetKind(),
+			Name:       workloads[i].GetName(),
+			Enforce:    policy.Enforce,
+			Level:      level,
+			Violations: violations,
+		})
+	}
+	return gaps, nil
+}
+
+func labelLevel(labels map[string]string, label string) Level {
+	value, ok := labels[label]
+	if !ok {
+		return LevelPrivileged
+	}
+	if level := Level(value); level.IsValid() {
+		return level
+	}
+	return LevelRestricted
+}
@@ -0,0 +1,60 @@
+package podsecurity
+
+import (
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetNamespacePolicy(t *testing.T) {
+	policy := GetNamespacePolicy("shop", map[string]string{LabelEnforce: "baseline", LabelEnforceVersion: "v1.25", LabelWarn: "restricted", LabelAudit: "strict"})
+	assert.Equal(t, NamespacePolicy{
+		Namespace:       "shop",
+		Enforce:         LevelBaseline,
+		Audit:           LevelRestricted,
+		Warn:            LevelRestricted,
+		EnforceVersion:  "v1.25",
+		EnforceLabelSet: true,
+	}, policy)
+	assert.True(t, policy.IsEnforced(LevelBaseline))
+	assert.False(t, policy.IsEnforced(LevelRestricted))
+
+	policy = GetNamespacePolicy("default", nil)
+	assert.Equal(t, LevelPrivileged, policy.Enforce)
+	assert.False(t, policy.EnforceLabelSet)
+}
+
+func TestGetEnforcementGaps(t *testing.T) {
+	newPod := func(namespace, name string, spec map[string]interface{}) workloadinterface.IWorkload {
+		return workloadinterface.NewWorkloadObj(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata":   map[string]interface{}{"namespace": namespace, "name": name},
+			"spec":       spec,
+		})
+	}
+	workloads := []workloadinterface.IWorkload{
+		newPod("shop", "debug", map[string]interface{}{"hostPID": true, "containers": []interface{}{map[string]interface{}{"name": "debug"}}}),
+		newPod("shop", "nginx", map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "nginx"}}}),
+		newPod("other", "debug", map[string]interface{}{"hostPID": true, "containers": []interface{}{map[string]interface{}{"name": "debug"}}}),
+	}
+
+	// warn=baseline without enforcement reports the pods the namespace warns about but admits
+	gaps, err := GetEnforcementGaps(GetNamespacePolicy("shop", map[string]string{LabelWarn: "baseline"}), workloads, "")
+	require.NoError(t, err)
+	require.Len(t, gaps, 1)
+	assert.Equal(t, "debug", gaps[0].Name)
+	assert.Equal(t, LevelPrivileged, gaps[0].Enforce)
+	assert.Equal(t, LevelBaseline, gaps[0].Level)
+	assert.Equal(t, "hostNamespaces", gaps[0].Violations[0].Check)
+
+	gaps, err = GetEnforcementGaps(GetNamespacePolicy("shop", map[string]string{LabelEnforce: "baseline"}), workloads, LevelBaseline)
+	require.NoError(t, err)
+	assert.Empty(t, gaps)
+
+	gaps, err = GetEnforcementGaps(GetNamespacePolicy("shop", map[string]string{LabelEnforce: "baseline"}), workloads, LevelRestricted)
+	require.NoError(t, err)
+	assert.Len(t, gaps, 2)
+}
@@ -0,0 +1,102 @@
+package podsecurity
+
+import (
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Level is a level of the Pod Security Standards
+type Level string
+
+const (
+	LevelPrivileged Level = "privileged"
+	LevelBaseline   Level = "baseline"
+	LevelRestricted Level = "restricted"
+)
+
+// Levels are the levels of the Pod Security Standards, from the least to the most restrictive
+var Levels = []Level{LevelPrivileged, LevelBaseline, LevelRestricted}
+
+// Violation is a failed check of the Pod Security Standards. The check IDs, reasons and details follow the ones of pod-security-admission
+type Violation struct {
+	// Check is the ID of the check, e.g. hostNamespaces, capabilities_restricted
+	Check string `json:"check"`
+	// Level is the level the check belongs to
+	Level  Level  `json:"level"`
+	Reason string `json:"reason"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (violation *Violation) String() string {
+	if violation.Detail == "" {
+		return violation.Reason
+	}
+	return fmt.Sprintf("%s (%s)", violation.Reason, violation.Detail)
+}
+
+// IsValid returns true if the level is one of privileged/baseline/restricted
+func (level Level) IsValid() bool {
+	return level == LevelPrivileged || level == LevelBaseline || level == LevelRestricted
+}
+
+// AtLeast returns true if the level is as restrictive as the other level
+func (level Level) AtLeast(other Level) bool {
+	return levelIndex(level) >= levelIndex(other)
+}
+
+func levelIndex(level Level) int {
+	for i := range Levels {
+		if Levels[i] == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// Evaluate returns the violations of the pod spec of the checks of the level. The restricted level includes the baseline checks,
+// except the ones a restricted check supersedes (seccompProfile_baseline, capabilities_baseline). The privileged level has no checks
+func Evaluate(podAnnotations map[string]string, podSpec *corev1.PodSpec, level Level) []Violation {
+	violations := []Violation{}
+	for i := range checks {
+		if !level.AtLeast(checks[i].level) || (level == LevelRestricted && checks[i].supersededByRestricted) {
+			continue
+		}
+		if checks[i].level == LevelRestricted && checks[i].exemptOnWindows && isWindowsPod(podSpec) {
+			continue
+		}
+		if reason, detail, ok := checks[i].evaluate(podAnnotations, podSpec); !ok {
+			violations = append(violations, Violation{Check: checks[i].id, Level: checks[i].level, Reason: reason, Detail: detail})
+		}
+	}
+	return violations
+}
+
+// EvaluateWorkload returns the violations of the pod template of the workload (the pod spec for pods) of the checks of the level
+func EvaluateWorkload(workload workloadinterface.IWorkload, level Level) ([]Violation, error) {
+	podSpec, err := workload.GetPodSpec()
+	if err != nil {
+		return nil, err
+	}
+	return Evaluate(workload.GetPodAnnotations(), podSpec, level), nil
+}
+
+// GetWorkloadLevel returns the most restrictive level the pod template of the workload satisfies
+func GetWorkloadLevel(workload workloadinterface.IWorkload) (Level, error) {
+	podSpec, err := workload.GetPodSpec()
+	if err != nil {
+		return "", err
+	}
+	podAnnotations := workload.GetPodAnnotations()
+	for i := len(Levels) - 1; i > 0; i-- {
+		if len(Evaluate(podAnnotations, podSpec, Levels[i])) == 0 {
+			return Levels[i], nil
+		}
+	}
+	return LevelPrivileged, nil
+}
+
+func isWindowsPod(podSpec *corev1.PodSpec) bool {
+	return podSpec.OS != nil && podSpec.OS.Name == corev1.Windows
+}
@@ -0,0 +1,126 @@
+package podsecurity
+
+import (
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func restrictedPodSpec() *corev1.PodSpec {
+	runAsNonRoot, allowPrivilegeEscalation := true, false
+	return &corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{
+			RunAsNonRoot:   &runAsNonRoot,
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		},
+		Containers: []corev1.Container{{
+			Name: "nginx",
+			SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}, Add: []corev1.Capability{"NET_BIND_SERVICE"}},
+			},
+		}},
+		Volumes: []corev1.Volume{{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+	}
+}
+
+func violatedChecks(violations []Violation) []string {
+	checks := []string{}
+	for i := range violations {
+		checks = append(checks, violations[i].Check)
+	}
+	return checks
+}
+
+func TestEvaluateRestricted(t *testing.T) {
+	assert.Empty(t, Evaluate(nil, restrictedPodSpec(), LevelRestricted))
+
+	podSpec := restrictedPodSpec()
+	podSpec.SecurityContext = nil
+	podSpec.Containers[0].SecurityContext.Capabilities.Add = []corev1.Capability{"SYS_ADMIN"}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{Name: "host", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run"}}})
+	violations := Evaluate(nil, podSpec, LevelRestricted)
+	assert.Equal(t, []string{"hostPathVolumes", "restrictedVolumes", "runAsNonRoot", "seccompProfile_restricted", "capabilities_restricted"}, violatedChecks(violations),
+		"capabilities_baseline is superseded by capabilities_restricted")
+	assert.Equal(t, Violation{
+		Check:  "capabilities_restricted",
+		Level:  LevelRestricted,
+		Reason: "unrestricted capabilities",
+		Detail: `container "nginx" must not include "SYS_ADMIN" in securityContext.capabilities.add`,
+	}, violations[len(violations)-1])
+	assert.Equal(t, `volumes "host" uses hostPath`, violations[1].Detail)
+
+	assert.Equal(t, []string{"capabilities_baseline", "hostPathVolumes"}, violatedChecks(Evaluate(nil, podSpec, LevelBaseline)))
+	assert.Empty(t, Evaluate(nil, podSpec, LevelPrivileged))
+}
+
+func TestEvaluateBaseline(t *testing.T) {
+	privileged := true
+	podSpec := &corev1.PodSpec{
+		HostNetwork: true,
+		HostPID:     true,
+		SecurityContext: &corev1.PodSecurityContext{
+			Sysctls:        []corev1.Sysctl{{Name: "net.ipv4.tcp_syncookies", Value: "1"}, {Name: "kernel.msgmax", Value: "65536"}},
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined},
+		},
+		Containers: []corev1.Container{
+			{Name: "agent", SecurityContext: &corev1.SecurityContext{Privileged: &privileged}, Ports: []corev1.ContainerPort{{ContainerPort: 9090, HostPort: 9090}}},
+			{Name: "sidecar", SecurityContext: &corev1.SecurityContext{SELinuxOptions: &corev1.SELinuxOptions{Type: "spc_t"}}},
+		},
+	}
+	annotations := map[string]string{appArmorAnnotationPrefix + "agent": "unconfined", appArmorAnnotationPrefix + "sidecar": "runtime/default"}
+
+	violations := Evaluate(annotations, podSpec, LevelBaseline)
+	assert.Equal(t, []string{"hostNamespaces", "privileged", "hostPorts", "appArmorProfile", "seLinuxOptions", "seccompProfile_baseline", "sysctls"}, violatedChecks(violations))
+	assert.Equal(t, "hostNetwork=true, hostPID=true", violations[0].Detail)
+	assert.Equal(t, `container "agent" must not set securityContext.privileged=true`, violations[1].Detail)
+	assert.Equal(t, "kernel.msgmax", violations[6].Detail)
+}
+
+func TestEvaluateWindows(t *testing.T) {
+	podSpec := restrictedPodSpec()
+	podSpec.SecurityContext.SeccompProfile = nil
+	podSpec.Containers[0].SecurityContext = nil
+	assert.Equal(t, []string{"allowPrivilegeEscalation", "seccompProfile_restricted", "capabilities_restricted"}, violatedChecks(Evaluate(nil, podSpec, LevelRestricted)))
+
+	podSpec.OS = &corev1.PodOS{Name: corev1.Windows}
+	assert.Empty(t, Evaluate(nil, podSpec, LevelRestricted))
+}
+
+func TestGetWorkloadLevel(t *testing.T) {
+	newDeployment := func(podSpec map[string]interface{}) workloadinterface.IWorkload {
+		return workloadinterface.NewWorkloadObj(map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"namespace": "default", "name": "nginx"},
+			"spec":       map[string]interface{}{"template": map[string]interface{}{"spec": podSpec}},
+		})
+	}
+	level, err := GetWorkloadLevel(newDeployment(map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "nginx"}}}))
+	require.NoError(t, err)
+	assert.Equal(t, LevelBaseline, level)
+
+	level, err = GetWorkloadLevel(newDeployment(map[string]interface{}{"hostIPC": true, "containers": []interface{}{map[string]interface{}{"name": "nginx"}}}))
+	require.NoError(t, err)
+	assert.Equal(t, LevelPrivileged, level)
+
+	level, err = GetWorkloadLevel(newDeployment(map[string]interface{}{
+		"securityContext": map[string]interface{}{"runAsNonRoot": true, "seccompProfile": map[string]interface{}{"type": "RuntimeDefault"}},
+		"containers": []interface{}{map[string]interface{}{
+			"name":            "nginx",
+			"securityContext": map[string]interface{}{"allowPrivilegeEscalation": false, "capabilities": map[string]interface{}{"drop": []interface{}{"ALL"}}},
+		}},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, LevelRestricted, level)
+}
+
+func TestLevelAtLeast(t *testing.T) {
+	assert.True(t, LevelRestricted.AtLeast(LevelBaseline))
+	assert.True(t, LevelBaseline.AtLeast(LevelBaseline))
+	assert.False(t, LevelPrivileged.AtLeast(LevelBaseline))
+	assert.False(t, Level("strict").IsValid())
+}
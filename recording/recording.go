@@ -0,0 +1,233 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+
+	restclient "k8s.io/client-go/rest"
+)
+
+// Environment variables enabling the record/replay mode of NewKubernetesApi
+const (
+	// RecordDirEnvVar is the directory the API server interactions are recorded to
+	RecordDirEnvVar = "KS_RECORD_DIR"
+	// ReplayDirEnvVar is the directory the API server interactions are replayed from, no cluster is contacted
+	ReplayDirEnvVar = "KS_REPLAY_DIR"
+)
+
+// Mode is the mode of the recording transport
+type Mode string
+
+const (
+	ModeRecord Mode = "record"
+	ModeReplay Mode = "replay"
+)
+
+// Interaction is a recorded request and its response. Each interaction is stored as a JSON file named after its sequence number
+type Interaction struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"` // path and query of the request
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	// BodyBase64 is set instead of Body when the body is not valid UTF-8 (e.g. protobuf)
+	BodyBase64 []byte `json:"bodyBase64,omitempty"`
+}
+
+func (interaction *Interaction) key() string {
+	return interaction.Method + " " + interaction.URL
+}
+
+func (interaction *Interaction) body() []byte {
+	if interaction.BodyBase64 != nil {
+		return interaction.BodyBase64
+	}
+	return []byte(interaction.Body)
+}
+
+func (interaction *Interaction) setBody(body []byte) {
+	if utf8.Valid(body) {
+		interaction.Body = string(body)
+	} else {
+		interaction.BodyBase64 = body
+	}
+}
+
+// requestURL returns the path and the sorted query of the request, so requests with the same parameters match regardless of their order
+func requestURL(req *http.Request) string {
+	if query := req.URL.Query().Encode(); query != "" {
+		return req.URL.Path + "?" + query
+	}
+	return req.URL.Path
+}
+
+func isWatch(req *http.Request) bool {
+	return req.URL.Query().Get("watch") == "true" || req.URL.Query().Get("watch") == "1"
+}
+
+// Recorder is a transport that records the requests and the responses of the next transport to a directory.
+// Watch requests are not recorded. The values of Secrets are redacted from the recorded responses
+type Recorder struct {
+	next     http.RoundTripper
+	cassette *cassette
+}
+
+// cassette is the directory the interactions are recorded to, shared by the transports of all the clients of a config
+type cassette struct {
+	dir   string
+	seq   int
+	mutex sync.Mutex
+}
+
+// newCassette returns the cassette of the directory. Interactions already in the directory are kept, the new ones are recorded after them
+func newCassette(dir string) (*cassette, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create the recording directory: %w", err)
+	}
+	recorded, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	return &cassette{dir: dir, seq: len(recorded)}, nil
+}
+
+// NewRecorder returns a transport recording to the directory, the directory is created if needed
+func NewRecorder(dir string, next http.RoundTripper) (*Recorder, error) {
+	c, err := newCassette(dir)
+	if err != nil {
+		return nil, err
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{next: next, cassette: c}, nil
+}
+
+func (recorder *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := recorder.next.RoundTrip(req)
+	if err != nil || isWatch(req) {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction := &Interaction{
+		Method:     req.Method,
+		URL:        requestURL(req),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+	}
+	interaction.setBody(redactSecrets(body))
+	if err := recorder.cassette.save(interaction); err != nil {
+		return nil, fmt.Errorf("failed to record %s: %w", interaction.key(), err)
+	}
+	return resp, nil
+}
+
+func (c *cassette) save(interaction *Interaction) error {
+	content, err := json.MarshalIndent(interaction, "", "  ")
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.seq++
+	return os.WriteFile(filepath.Join(c.dir, fmt.Sprintf("%06d.json", c.seq)), content, 0644)
+}
+
+// redactSecrets replaces the values of the Secret and SecretList responses, other responses are returned as is
+func redactSecrets(body []byte) []byte {
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return body
+	}
+	switch obj["kind"] {
+	case "Secret":
+		redactSecret(obj)
+	case "SecretList":
+		items, _ := obj["items"].([]interface{})
+		for i := range items {
+			if item, ok := items[i].(map[string]interface{}); ok {
+				redactSecret(item)
+			}
+		}
+	default:
+		return body
+	}
+	redacted, err := json.Marshal(obj)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+func redactSecret(secret map[string]interface{}) {
+	for _, field := range []string{"data", "stringData"} {
+		values, _ := secret[field].(map[string]interface{})
+		for key := range values {
+			values[key] = ""
+		}
+	}
+	if metadata, ok := secret["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+		}
+	}
+}
+
+// WrapConfig returns a copy of the config recording to or replaying from the directory
+func WrapConfig(config *restclient.Config, mode Mode, dir string) (*restclient.Config, error) {
+	if config == nil {
+		return nil, nil
+	}
+	wrapped := restclient.CopyConfig(config)
+	switch mode {
+	case ModeRecord:
+		c, err := newCassette(dir)
+		if err != nil {
+			return nil, err
+		}
+		wrapped.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &Recorder{next: rt, cassette: c}
+		})
+	case ModeReplay:
+		replayer, err := NewReplayer(dir)
+		if err != nil {
+			return nil, err
+		}
+		wrapped.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return replayer
+		})
+	default:
+		return nil, fmt.Errorf("unknown recording mode '%s'", mode)
+	}
+	return wrapped, nil
+}
+
+// ReplayConfig returns a config serving the interactions of the directory, for replaying without a kubeconfig
+func ReplayConfig(dir string) (*restclient.Config, error) {
+	return WrapConfig(&restclient.Config{Host: "http://replay.invalid"}, ModeReplay, dir)
+}
+
+// ModeFromEnv returns the mode and the directory set by the KS_RECORD_DIR or KS_REPLAY_DIR environment variables, replay taking precedence.
+// The mode is empty if none is set
+func ModeFromEnv() (Mode, string) {
+	if dir := os.Getenv(ReplayDirEnvVar); dir != "" {
+		return ModeReplay, dir
+	}
+	if dir := os.Getenv(RecordDirEnvVar); dir != "" {
+		return ModeRecord, dir
+	}
+	return "", ""
+}
@@ -0,0 +1,148 @@
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+func newAPIServer(t *testing.T) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		var obj interface{}
+		switch r.URL.Path {
+		case "/api/v1/namespaces/default/pods":
+			obj = &corev1.PodList{
+				TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PodList"},
+				Items:    []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default", ResourceVersion: r.URL.Query().Get("labelSelector")}}},
+			}
+		case "/api/v1/namespaces/default/secrets/token":
+			obj = &corev1.Secret{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+				ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "default"},
+				Data:       map[string][]byte{"token": []byte("s3cr3t")},
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			obj = &metav1.Status{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"}, Status: metav1.StatusFailure, Reason: metav1.StatusReasonNotFound, Code: http.StatusNotFound}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(obj))
+	}))
+	return server, &requests
+}
+
+func TestRecordReplay(t *testing.T) {
+	server, requests := newAPIServer(t)
+	defer server.Close()
+	dir := t.TempDir()
+
+	// record
+	config, err := WrapConfig(&restclient.Config{Host: server.URL}, ModeRecord, dir)
+	assert.NoError(t, err)
+	client, err := kubernetes.NewForConfig(config)
+	assert.NoError(t, err)
+
+	pods, err := client.CoreV1().Pods("default").List(context.Background(), metav1.ListOptions{LabelSelector: "app=nginx"})
+	assert.NoError(t, err)
+	assert.Equal(t, "nginx", pods.Items[0].Name)
+	secret, err := client.CoreV1().Secrets("default").Get(context.Background(), "token", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(secret.Data["token"]), "the caller receives the actual response")
+	_, err = client.CoreV1().ConfigMaps("default").Get(context.Background(), "missing", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.Equal(t, 3, *requests)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.Len(t, files, 3)
+	content, err := os.ReadFile(filepath.Join(dir, "000002.json"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "czNjcjN0", "secret values are redacted")
+
+	// replay, without the API server
+	server.Close()
+	config, err = ReplayConfig(dir)
+	assert.NoError(t, err)
+	client, err = kubernetes.NewForConfig(config)
+	assert.NoError(t, err)
+
+	pods, err = client.CoreV1().Pods("default").List(context.Background(), metav1.ListOptions{LabelSelector: "app=nginx"})
+	assert.NoError(t, err)
+	assert.Equal(t, "nginx", pods.Items[0].Name)
+	assert.Equal(t, "app=nginx", pods.Items[0].ResourceVersion)
+	secret, err = client.CoreV1().Secrets("default").Get(context.Background(), "token", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, secret.Data["token"])
+	_, err = client.CoreV1().ConfigMaps("default").Get(context.Background(), "missing", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// not recorded
+	_, err = client.CoreV1().Pods("default").List(context.Background(), metav1.ListOptions{LabelSelector: "app=other"})
+	assert.True(t, apierrors.IsNotFound(err))
+	_, err = client.CoreV1().Pods("default").Watch(context.Background(), metav1.ListOptions{})
+	assert.Error(t, err)
+}
+
+func TestReplayOrder(t *testing.T) {
+	dir := t.TempDir()
+	for i, body := range []string{"first", "second"} {
+		content, _ := json.Marshal(&Interaction{Method: http.MethodGet, URL: "/version", StatusCode: http.StatusOK, Body: body})
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("%06d.json", i+1)), content, 0644))
+	}
+	replayer, err := NewReplayer(dir)
+	assert.NoError(t, err)
+
+	var bodies []string
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "http://replay.invalid/version", nil)
+		resp, err := replayer.RoundTrip(req)
+		assert.NoError(t, err)
+		buf := make([]byte, 16)
+		n, _ := resp.Body.Read(buf)
+		bodies = append(bodies, string(buf[:n]))
+	}
+	assert.Equal(t, []string{"first", "second", "second"}, bodies)
+
+	_, err = NewReplayer(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestRecorderAppends(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "000001.json"), []byte("{}"), 0644))
+	recorder, err := NewRecorder(dir, http.DefaultTransport)
+	assert.NoError(t, err)
+	assert.NoError(t, recorder.cassette.save(&Interaction{Method: http.MethodGet, URL: "/version"}))
+	_, err = os.Stat(filepath.Join(dir, "000002.json"))
+	assert.NoError(t, err)
+}
+
+func TestModeFromEnv(t *testing.T) {
+	t.Setenv(RecordDirEnvVar, "")
+	t.Setenv(ReplayDirEnvVar, "")
+	mode, _ := ModeFromEnv()
+	assert.Equal(t, Mode(""), mode)
+
+	t.Setenv(RecordDirEnvVar, "/tmp/record")
+	mode, dir := ModeFromEnv()
+	assert.Equal(t, ModeRecord, mode)
+	assert.Equal(t, "/tmp/record", dir)
+
+	t.Setenv(ReplayDirEnvVar, "/tmp/replay")
+	mode, dir = ModeFromEnv()
+	assert.Equal(t, ModeReplay, mode)
+	assert.Equal(t, "/tmp/replay", dir)
+}
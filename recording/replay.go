@@ -0,0 +1,105 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Replayer is a transport serving recorded interactions, it never contacts the API server.
+// A request is matched to the interactions with the same method, path and query, which are served in their recorded order.
+// Once all the matching interactions were served, the last one is served again
+type Replayer struct {
+	interactions map[string][]*Interaction
+	served       map[string]int
+	mutex        sync.Mutex
+}
+
+// NewReplayer loads the interactions recorded to the directory
+func NewReplayer(dir string) (*Replayer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recorded interactions found in '%s'", dir)
+	}
+	sort.Strings(files)
+
+	replayer := &Replayer{interactions: map[string][]*Interaction{}, served: map[string]int{}}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		interaction := &Interaction{}
+		if err := json.Unmarshal(content, interaction); err != nil {
+			return nil, fmt.Errorf("failed to load interaction '%s': %w", file, err)
+		}
+		replayer.interactions[interaction.key()] = append(replayer.interactions[interaction.key()], interaction)
+	}
+	return replayer, nil
+}
+
+func (replayer *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	if isWatch(req) {
+		return nil, fmt.Errorf("watch is not supported in replay mode: %s %s", req.Method, req.URL.Path)
+	}
+	key := req.Method + " " + requestURL(req)
+
+	replayer.mutex.Lock()
+	interactions := replayer.interactions[key]
+	i := replayer.served[key]
+	if i < len(interactions)-1 {
+		replayer.served[key]++
+	}
+	replayer.mutex.Unlock()
+
+	if len(interactions) == 0 {
+		return notRecordedResponse(req, key), nil
+	}
+	interaction := interactions[i]
+	header := interaction.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	body := interaction.body()
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+		StatusCode:    interaction.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// notRecordedResponse returns a NotFound Status, so clients fail the same way as for a missing object
+func notRecordedResponse(req *http.Request, key string) *http.Response {
+	body := fmt.Sprintf(`{"kind":"Status","apiVersion":"v1","metadata":{},"status":"Failure","message":%q,"reason":"NotFound","code":404}`,
+		"interaction not recorded: "+key)
+	return &http.Response{
+		Status:        "404 Not Found",
+		StatusCode:    http.StatusNotFound,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,48 @@
+package resources
+
+import (
+	"fmt"
+
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NamespaceComparison is the comparison of the resources of a workload with the ResourceQuotas and LimitRanges of its namespace
+type NamespaceComparison struct {
+	// Resources are the resources of the workload, with the defaults of the LimitRanges applied
+	Resources            *WorkloadResources    `json:"resources"`
+	QuotaComparisons     []QuotaComparison     `json:"quotaComparisons"`
+	LimitRangeViolations []LimitRangeViolation `json:"limitRangeViolations"`
+}
+
+// CompareWithNamespace returns the comparison of the resources of the workload with the ResourceQuotas and LimitRanges of its namespace
+func CompareWithNamespace(k8sAPI *k8sinterface.KubernetesApi, workload workloadinterface.IWorkload) (*NamespaceComparison, error) {
+	workloadResources, err := GetWorkloadResources(workload)
+	if err != nil {
+		return nil, err
+	}
+	namespace := workload.GetNamespace()
+	limitRanges, err := k8sAPI.KubernetesClient.CoreV1().LimitRanges(namespace).List(k8sAPI.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST limitranges, reason: %s", err.Error())
+	}
+	quotas, err := k8sAPI.KubernetesClient.CoreV1().ResourceQuotas(namespace).List(k8sAPI.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to LIST resourcequotas, reason: %s", err.Error())
+	}
+
+	ApplyLimitRangeDefaults(workloadResources, limitRanges.Items)
+	comparison := &NamespaceComparison{
+		Resources:            workloadResources,
+		QuotaComparisons:     []QuotaComparison{},
+		LimitRangeViolations: []LimitRangeViolation{},
+	}
+	for i := range limitRanges.Items {
+		comparison.LimitRangeViolations = append(comparison.LimitRangeViolations, CheckLimitRange(workloadResources, &limitRanges.Items[i])...)
+	}
+	for i := range quotas.Items {
+		comparison.QuotaComparisons = append(comparison.QuotaComparisons, CompareWithResourceQuota(workloadResources, &quotas.Items[i])...)
+	}
+	return comparison, nil
+}
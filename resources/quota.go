@@ -0,0 +1,214 @@
+package resources
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	requestsPrefix = "requests."
+	limitsPrefix   = "limits."
+
+	LimitRangeConstraintMin                  = "min"
+	LimitRangeConstraintMax                  = "max"
+	LimitRangeConstraintMaxLimitRequestRatio = "maxLimitRequestRatio"
+)
+
+// QuotaComparison is the comparison of the resources of a workload with a hard limit of a ResourceQuota
+type QuotaComparison struct {
+	Quota string `json:"quota"`
+	// Resource is the name of the hard limit, e.g. requests.cpu, limits.memory, pods
+	Resource  corev1.ResourceName `json:"resource"`
+	Hard      resource.Quantity   `json:"hard"`
+	Used      resource.Quantity   `json:"used"`
+	Requested resource.Quantity   `json:"requested"`
+	// ExceedsHard is true if the workload alone requests more than the hard limit
+	ExceedsHard bool `json:"exceedsHard"`
+	// ExceedsAvailable is true if the workload requests more than the hard limit minus the used resources.
+	// The used resources include the ones of the workload if it already runs
+	ExceedsAvailable bool `json:"exceedsAvailable"`
+	// MissingContainers are the containers not setting the resource, the quota rejects their pods
+	MissingContainers []string `json:"missingContainers,omitempty"`
+}
+
+// LimitRangeViolation is a container or pod of a workload violating a constraint of a LimitRange
+type LimitRangeViolation struct {
+	LimitRange string              `json:"limitRange"`
+	Type       corev1.LimitType    `json:"type"`
+	Container  string              `json:"container,omitempty"`
+	Resource   corev1.ResourceName `json:"resource"`
+	// Constraint is one of min/max/maxLimitRequestRatio
+	Constraint string `json:"constraint"`
+	// Value is the request, limit or ratio of the container/pod, empty if the container does not set it
+	Value string `json:"value"`
+	Bound string `json:"bound"`
+}
+
+// CompareWithResourceQuota returns the comparison of the total resources of the workload with the compute hard limits of the quota.
+// Quotas with the BestEffort/NotBestEffort scope not matching the workload are skipped, the other scopes are not evaluated
+func CompareWithResourceQuota(workloadResources *WorkloadResources, quota *corev1.ResourceQuota) []QuotaComparison {
+	comparisons := []QuotaComparison{}
+	if !matchesQuotaScopes(workloadResources, quota.Spec.Scopes) {
+		return comparisons
+	}
+	for name, hard := range quota.Spec.Hard {
+		requested, resourceName, containerResources, ok := quotaRequested(workloadResources, name)
+		if !ok {
+			continue
+		}
+		comparison := QuotaComparison{
+			Quota:       quota.GetName(),
+			Resource:    name,
+			Hard:        hard,
+			Used:        quota.Status.Used[name],
+			Requested:   requested,
+			ExceedsHard: requested.Cmp(hard) > 0,
+		}
+		total := comparison.Used.DeepCopy()
+		total.Add(requested)
+		comparison.ExceedsAvailable = total.Cmp(hard) > 0
+		if containerResources != nil {
+			for i := range workloadResources.Containers {
+				if _, ok := containerResources(&workloadResources.Containers[i])[resourceName]; !ok {
+					comparison.MissingContainers = append(comparison.MissingContainers, workloadResources.Containers[i].Name)
+				}
+			}
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	sort.Slice(comparisons, func(i, j int) bool { return comparisons[i].Resource < comparisons[j].Resource })
+	return comparisons
+}
+
+// CheckLimitRange returns the violations of the Container and Pod constraints of the LimitRange. Apply the defaults first, see ApplyLimitRangeDefaults
+func CheckLimitRange(workloadResources *WorkloadResources, limitRange *corev1.LimitRange) []LimitRangeViolation {
+	violations := []LimitRangeViolation{}
+	for _, item := range limitRange.Spec.Limits {
+		switch item.Type {
+		case corev1.LimitTypeContainer:
+			for i := range workloadResources.Containers {
+				container := &workloadResources.Containers[i]
+				violations = append(violations, checkLimitRangeItem(limitRange.GetName(), &item, container.Name, container.Requests, container.Limits)...)
+			}
+		case corev1.LimitTypePod:
+			requests := PodResources(workloadResources.Containers, nil, func(c *ContainerResources) corev1.ResourceList { return c.Requests })
+			limits := PodResources(workloadResources.Containers, nil, func(c *ContainerResources) corev1.ResourceList { return c.Limits })
+			violations = append(violations, checkLimitRangeItem(limitRange.GetName(), &item, "", requests, limits)...)
+		}
+	}
+	return violations
+}
+
+// ApplyLimitRangeDefaults sets the default limits and requests of the Container items of the LimitRanges on the containers not setting them,
+// as the LimitRanger admission plugin does, and aggregates the resources again. A request defaults to the limit if there is no default request
+func ApplyLimitRangeDefaults(workloadResources *WorkloadResources, limitRanges []corev1.LimitRange) {
+	for i := range limitRanges {
+		for _, item := range limitRanges[i].Spec.Limits {
+			if item.Type != corev1.LimitTypeContainer {
+				continue
+			}
+			for j := range workloadResources.Containers {
+				container := &workloadResources.Containers[j]
+				if container.Limits == nil {
+					container.Limits = corev1.ResourceList{}
+				}
+				if container.Requests == nil {
+					container.Requests = corev1.ResourceList{}
+				}
+				for name, quantity := range item.Default {
+					if _, ok := container.Limits[name]; !ok {
+						container.Limits[name] = quantity.DeepCopy()
+					}
+				}
+				for name, quantity := range item.DefaultRequest {
+					if _, ok := container.Requests[name]; !ok {
+						container.Requests[name] = quantity.DeepCopy()
+					}
+				}
+			}
+		}
+	}
+	for j := range workloadResources.Containers {
+		container := &workloadResources.Containers[j]
+		for name, quantity := range container.Limits {
+			if _, ok := container.Requests[name]; !ok {
+				container.Requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	workloadResources.Aggregate()
+}
+
+func checkLimitRangeItem(limitRange string, item *corev1.LimitRangeItem, container string, requests, limits corev1.ResourceList) []LimitRangeViolation {
+	var violations []LimitRangeViolation
+	newViolation := func(name corev1.ResourceName, constraint, value string, bound resource.Quantity) LimitRangeViolation {
+		return LimitRangeViolation{LimitRange: limitRange, Type: item.Type, Container: container, Resource: name, Constraint: constraint, Value: value, Bound: bound.String()}
+	}
+	for name, min := range item.Min {
+		request, ok := requests[name]
+		if !ok {
+			violations = append(violations, newViolation(name, LimitRangeConstraintMin, "", min))
+		} else if request.Cmp(min) < 0 {
+			violations = append(violations, newViolation(name, LimitRangeConstraintMin, request.String(), min))
+		}
+	}
+	for name, max := range item.Max {
+		limit, ok := limits[name]
+		if !ok {
+			violations = append(violations, newViolation(name, LimitRangeConstraintMax, "", max))
+		} else if limit.Cmp(max) > 0 {
+			violations = append(violations, newViolation(name, LimitRangeConstraintMax, limit.String(), max))
+		}
+	}
+	for name, ratio := range item.MaxLimitRequestRatio {
+		limit, hasLimit := limits[name]
+		request, hasRequest := requests[name]
+		if !hasLimit || !hasRequest || request.IsZero() {
+			continue
+		}
+		actual := resource.NewMilliQuantity(limit.MilliValue()*1000/request.MilliValue(), resource.DecimalSI)
+		if actual.Cmp(ratio) > 0 {
+			violations = append(violations, newViolation(name, LimitRangeConstraintMaxLimitRequestRatio, actual.String(), ratio))
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Resource != violations[j].Resource {
+			return violations[i].Resource < violations[j].Resource
+		}
+		return violations[i].Constraint < violations[j].Constraint
+	})
+	return violations
+}
+
+// quotaRequested returns the total resources of the workload the hard limit accounts, the name of the resource,
+// and the container resources the quota requires to be set. ok is false for the hard limits that do not account compute resources, e.g. count/deployments.apps
+func quotaRequested(workloadResources *WorkloadResources, name corev1.ResourceName) (resource.Quantity, corev1.ResourceName, func(*ContainerResources) corev1.ResourceList, bool) {
+	requests := func(c *ContainerResources) corev1.ResourceList { return c.Requests }
+	limits := func(c *ContainerResources) corev1.ResourceList { return c.Limits }
+	switch {
+	case name == corev1.ResourcePods:
+		return *resource.NewQuantity(int64(workloadResources.Replicas), resource.DecimalSI), name, nil, true
+	case strings.HasPrefix(string(name), requestsPrefix):
+		resourceName := corev1.ResourceName(strings.TrimPrefix(string(name), requestsPrefix))
+		return workloadResources.TotalRequests[resourceName], resourceName, requests, true
+	case strings.HasPrefix(string(name), limitsPrefix):
+		resourceName := corev1.ResourceName(strings.TrimPrefix(string(name), limitsPrefix))
+		return workloadResources.TotalLimits[resourceName], resourceName, limits, true
+	case name == corev1.ResourceCPU || name == corev1.ResourceMemory || name == corev1.ResourceEphemeralStorage:
+		return workloadResources.TotalRequests[name], name, requests, true
+	}
+	return resource.Quantity{}, "", nil, false
+}
+
+func matchesQuotaScopes(workloadResources *WorkloadResources, scopes []corev1.ResourceQuotaScope) bool {
+	bestEffort := len(workloadResources.PodRequests) == 0 && len(workloadResources.PodLimits) == 0
+	for _, scope := range scopes {
+		if (scope == corev1.ResourceQuotaScopeBestEffort && !bestEffort) || (scope == corev1.ResourceQuotaScopeNotBestEffort && bestEffort) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package resources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetesfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCompareWithResourceQuota(t *testing.T) {
+	workloadResources, err := GetWorkloadResources(newResourcesTestDeployment(3))
+	require.NoError(t, err)
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute"},
+		Spec: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+			"requests.cpu":            resource.MustParse("8"),
+			"limits.cpu":              resource.MustParse("4"),
+			"requests.nvidia.com/gpu": resource.MustParse("1"),
+			corev1.ResourceMemory:     resource.MustParse("4Gi"),
+			corev1.ResourcePods:       resource.MustParse("10"),
+			"count/deployments.apps":  resource.MustParse("5"),
+			corev1.ResourceSecrets:    resource.MustParse("10"),
+		}},
+		Status: corev1.ResourceQuotaStatus{Used: corev1.ResourceList{"requests.cpu": resource.MustParse("3")}},
+	}
+
+	comparisons := CompareWithResourceQuota(workloadResources, quota)
+	byResource := map[corev1.ResourceName]QuotaComparison{}
+	for _, comparison := range comparisons {
+		byResource[comparison.Resource] = comparison
+	}
+	assert.Len(t, comparisons, 5, "only the compute resources and pods are compared")
+
+	assert.False(t, byResource["requests.cpu"].ExceedsHard)
+	assert.True(t, byResource["requests.cpu"].ExceedsAvailable, "3 used + 6 requested > 8")
+	requested := byResource["requests.cpu"].Requested
+	assert.Equal(t, "6", requested.String())
+
+	assert.False(t, byResource["limits.cpu"].ExceedsHard)
+	assert.Equal(t, []string{"migrate", "proxy", "warmup"}, byResource["limits.cpu"].MissingContainers)
+
+	requested = byResource["pods"].Requested
+	assert.Equal(t, int64(3), requested.Value())
+	assert.Nil(t, byResource["pods"].MissingContainers)
+
+	assert.Equal(t, []string{"migrate", "proxy", "warmup", "frontend"}, byResource["requests.nvidia.com/gpu"].MissingContainers)
+
+	quota.Spec.Scopes = []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}
+	assert.Empty(t, CompareWithResourceQuota(workloadResources, quota))
+}
+
+func TestLimitRange(t *testing.T) {
+	workloadResources, err := GetWorkloadResources(newResourcesTestDeployment(1))
+	require.NoError(t, err)
+	limitRange := corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "defaults"},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{
+			{
+				Type:                 corev1.LimitTypeContainer,
+				Default:              corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				Max:                  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				MaxLimitRequestRatio: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+			},
+			{
+				Type: corev1.LimitTypePod,
+				Max:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+			},
+		}},
+	}
+
+	ApplyLimitRangeDefaults(workloadResources, []corev1.LimitRange{limitRange})
+	assert.Equal(t, "2", workloadResources.Containers[0].Limits.Cpu().String())
+	assert.Equal(t, "1", workloadResources.Containers[3].Limits.Cpu().String(), "the limit of the container is kept")
+
+	violations := CheckLimitRange(workloadResources, &limitRange)
+	// the limit of the sidecar is the default limit, 20 times its request. The pod limit is the frontend and the sidecar limits
+	assert.Equal(t, []LimitRangeViolation{
+		{LimitRange: "defaults", Type: corev1.LimitTypeContainer, Container: "proxy", Resource: corev1.ResourceCPU, Constraint: LimitRangeConstraintMaxLimitRequestRatio, Value: "20", Bound: "4"},
+		{LimitRange: "defaults", Type: corev1.LimitTypePod, Resource: corev1.ResourceMemory, Constraint: LimitRangeConstraintMax, Value: "640Mi", Bound: "512Mi"},
+	}, violations)
+}
+
+func TestCompareWithNamespace(t *testing.T) {
+	k8sAPI := &k8sinterface.KubernetesApi{
+		KubernetesClient: kubernetesfake.NewSimpleClientset(
+			&corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "compute"},
+				Spec:       corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{"requests.cpu": resource.MustParse("4")}},
+			},
+			&corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "compute"},
+				Spec:       corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{"requests.cpu": resource.MustParse("1")}},
+			},
+		),
+		Context: context.Background(),
+	}
+	comparison, err := CompareWithNamespace(k8sAPI, newResourcesTestDeployment(3))
+	require.NoError(t, err)
+	require.Len(t, comparison.QuotaComparisons, 1)
+	assert.True(t, comparison.QuotaComparisons[0].ExceedsHard)
+	assert.Empty(t, comparison.LimitRangeViolations)
+}
@@ -0,0 +1,163 @@
+package resources
+
+import (
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ContainerResources are the requests and limits of a container
+type ContainerResources struct {
+	Name string `json:"name"`
+	// Init is true for the init containers, including the sidecars
+	Init bool `json:"init,omitempty"`
+	// Sidecar is true for the init containers with restartPolicy=Always, running along the regular containers
+	Sidecar  bool                `json:"sidecar,omitempty"`
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+	Limits   corev1.ResourceList `json:"limits,omitempty"`
+}
+
+// WorkloadResources are the requests and limits of the pods of a workload
+type WorkloadResources struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	// Replicas is spec.replicas, 1 for the kinds without replicas (e.g. DaemonSets, whose pod count depends on the nodes)
+	Replicas   int                  `json:"replicas"`
+	Containers []ContainerResources `json:"containers"`
+	// Overhead is the pod overhead of the runtime class, added to the pod requests and limits
+	Overhead corev1.ResourceList `json:"overhead,omitempty"`
+	// PodRequests/PodLimits are the effective requests/limits of a pod, the ones the scheduler and the quota account
+	PodRequests corev1.ResourceList `json:"podRequests"`
+	PodLimits   corev1.ResourceList `json:"podLimits"`
+	// TotalRequests/TotalLimits are the pod requests/limits times the replicas
+	TotalRequests corev1.ResourceList `json:"totalRequests"`
+	TotalLimits   corev1.ResourceList `json:"totalLimits"`
+}
+
+// GetWorkloadResources returns the requests and limits of the pod template of the workload (the pod spec for pods)
+func GetWorkloadResources(workload workloadinterface.IWorkload) (*WorkloadResources, error) {
+	podSpec, err := workload.GetPodSpec()
+	if err != nil {
+		return nil, err
+	}
+	workloadResources := &WorkloadResources{
+		Namespace:  workload.GetNamespace(),
+		Kind:       workload.GetKind(),
+		Name:       workload.GetName(),
+		Replicas:   workload.GetReplicas(),
+		Containers: GetContainersResources(podSpec, getSidecars(workload)),
+		Overhead:   podSpec.Overhead,
+	}
+	workloadResources.Aggregate()
+	return workloadResources, nil
+}
+
+// GetContainersResources returns the requests and limits of the init and regular containers of the pod spec.
+// sidecars are the names of the init containers with restartPolicy=Always, the field is not part of the pod spec of this client version
+func GetContainersResources(podSpec *corev1.PodSpec, sidecars map[string]bool) []ContainerResources {
+	containers := make([]ContainerResources, 0, len(podSpec.InitContainers)+len(podSpec.Containers))
+	for i := range podSpec.InitContainers {
+		containers = append(containers, ContainerResources{
+			Name:     podSpec.InitContainers[i].Name,
+			Init:     true,
+			Sidecar:  sidecars[podSpec.InitContainers[i].Name],
+			Requests: podSpec.InitContainers[i].Resources.Requests.DeepCopy(),
+			Limits:   podSpec.InitContainers[i].Resources.Limits.DeepCopy(),
+		})
+	}
+	for i := range podSpec.Containers {
+		containers = append(containers, ContainerResources{
+			Name:     podSpec.Containers[i].Name,
+			Requests: podSpec.Containers[i].Resources.Requests.DeepCopy(),
+			Limits:   podSpec.Containers[i].Resources.Limits.DeepCopy(),
+		})
+	}
+	return containers
+}
+
+// Aggregate computes the pod and total requests/limits from the containers, e.g. after applying the LimitRange defaults
+func (workloadResources *WorkloadResources) Aggregate() {
+	workloadResources.PodRequests = PodResources(workloadResources.Containers, workloadResources.Overhead, func(c *ContainerResources) corev1.ResourceList { return c.Requests })
+	workloadResources.PodLimits = PodResources(workloadResources.Containers, workloadResources.Overhead, func(c *ContainerResources) corev1.ResourceList { return c.Limits })
+	workloadResources.TotalRequests = multiply(workloadResources.PodRequests, workloadResources.Replicas)
+	workloadResources.TotalLimits = multiply(workloadResources.PodLimits, workloadResources.Replicas)
+}
+
+// PodResources returns the effective resources of a pod, following the rules of the scheduler:
+// the regular containers and the sidecars run together and are summed, each init container runs alone along the sidecars started before it,
+// and the pod resources are the max of both, plus the overhead
+func PodResources(containers []ContainerResources, overhead corev1.ResourceList, resourcesOf func(*ContainerResources) corev1.ResourceList) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for i := range containers {
+		if !containers[i].Init {
+			addResources(result, resourcesOf(&containers[i]))
+		}
+	}
+
+	initResources := corev1.ResourceList{}
+	sidecarResources := corev1.ResourceList{}
+	for i := range containers {
+		if !containers[i].Init {
+			continue
+		}
+		containerResources := corev1.ResourceList{}
+		if containers[i].Sidecar {
+			addResources(result, resourcesOf(&containers[i]))
+			addResources(sidecarResources, resourcesOf(&containers[i]))
+			addResources(containerResources, sidecarResources)
+		} else {
+			addResources(containerResources, resourcesOf(&containers[i]))
+			addResources(containerResources, sidecarResources)
+		}
+		maxResources(initResources, containerResources)
+	}
+	maxResources(result, initResources)
+	addResources(result, overhead)
+	return result
+}
+
+// getSidecars returns the names of the init containers with restartPolicy=Always
+func getSidecars(workload workloadinterface.IWorkload) map[string]bool {
+	sidecars := map[string]bool{}
+	initContainers, _ := workloadinterface.InspectWorkload(workload.GetObject(), append(workloadinterface.PodSpec(workload.GetKind()), "initContainers")...)
+	list, _ := initContainers.([]interface{})
+	for i := range list {
+		container, ok := list[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if restartPolicy, _ := container["restartPolicy"].(string); restartPolicy == string(corev1.RestartPolicyAlways) {
+			name, _ := container["name"].(string)
+			sidecars[name] = true
+		}
+	}
+	return sidecars
+}
+
+func addResources(result, resources corev1.ResourceList) {
+	for name, quantity := range resources {
+		if value, ok := result[name]; ok {
+			value.Add(quantity)
+			result[name] = value
+		} else {
+			result[name] = quantity.DeepCopy()
+		}
+	}
+}
+
+func maxResources(result, resources corev1.ResourceList) {
+	for name, quantity := range resources {
+		if value, ok := result[name]; !ok || quantity.Cmp(value) > 0 {
+			result[name] = quantity.DeepCopy()
+		}
+	}
+}
+
+func multiply(resources corev1.ResourceList, factor int) corev1.ResourceList {
+	result := corev1.ResourceList{}
+	for name, quantity := range resources {
+		result[name] = *resource.NewMilliQuantity(quantity.MilliValue()*int64(factor), quantity.Format)
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func newResourcesTestDeployment(replicas int) workloadinterface.IWorkload {
+	return workloadinterface.NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "frontend"},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{"spec": map[string]interface{}{
+				"initContainers": []interface{}{
+					map[string]interface{}{"name": "migrate", "resources": map[string]interface{}{
+						"requests": map[string]interface{}{"cpu": "2", "memory": "256Mi"},
+					}},
+					map[string]interface{}{"name": "proxy", "restartPolicy": "Always", "resources": map[string]interface{}{
+						"requests": map[string]interface{}{"cpu": "100m", "memory": "64Mi"},
+						"limits":   map[string]interface{}{"memory": "128Mi"},
+					}},
+					map[string]interface{}{"name": "warmup", "resources": map[string]interface{}{
+						"requests": map[string]interface{}{"cpu": "500m", "memory": "1Gi"},
+					}},
+				},
+				"containers": []interface{}{
+					map[string]interface{}{"name": "frontend", "resources": map[string]interface{}{
+						"requests": map[string]interface{}{"cpu": "250m", "memory": "512Mi"},
+						"limits":   map[string]interface{}{"cpu": "1", "memory": "512Mi"},
+					}},
+				},
+			}},
+		},
+	})
+}
+
+func assertQuantities(t *testing.T, expected map[corev1.ResourceName]string, actual corev1.ResourceList) {
+	assert.Len(t, actual, len(expected))
+	for name, value := range expected {
+		quantity, ok := actual[name]
+		if assert.True(t, ok, name) {
+			expectedQuantity := resource.MustParse(value)
+			assert.Equal(t, 0, expectedQuantity.Cmp(quantity), "%s: expected %s, got %s", name, value, quantity.String())
+		}
+	}
+}
+
+func TestGetWorkloadResources(t *testing.T) {
+	workloadResources, err := GetWorkloadResources(newResourcesTestDeployment(3))
+	require.NoError(t, err)
+	assert.Equal(t, 3, workloadResources.Replicas)
+	require.Len(t, workloadResources.Containers, 4)
+	assert.True(t, workloadResources.Containers[1].Sidecar)
+	assert.False(t, workloadResources.Containers[0].Sidecar)
+
+	// cpu: the migrate init container (2) exceeds the containers and the sidecar (250m+100m)
+	// memory: the warmup init container with the sidecar started before it (1Gi+64Mi) exceeds the containers and the sidecar (512Mi+64Mi)
+	assertQuantities(t, map[corev1.ResourceName]string{"cpu": "2", "memory": "1088Mi"}, workloadResources.PodRequests)
+	assertQuantities(t, map[corev1.ResourceName]string{"cpu": "1", "memory": "640Mi"}, workloadResources.PodLimits)
+	assertQuantities(t, map[corev1.ResourceName]string{"cpu": "6", "memory": "3264Mi"}, workloadResources.TotalRequests)
+}
+
+func TestPodResourcesOverhead(t *testing.T) {
+	containers := []ContainerResources{
+		{Name: "a", Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}},
+		{Name: "b", Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")}},
+	}
+	overhead := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")}
+	requests := PodResources(containers, overhead, func(c *ContainerResources) corev1.ResourceList { return c.Requests })
+	assertQuantities(t, map[corev1.ResourceName]string{"cpu": "550m"}, requests)
+}
@@ -0,0 +1,267 @@
+// Package serialization stores workloads, metadata objects and instance IDs with the version of their schema, so the objects stored by older
+// consumers can still be loaded after their representation changes: the stored objects are migrated to the current schema version when loaded
+package serialization
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/kubescape/k8s-interface/instanceidhandler"
+	instanceidhandlerv1 "github.com/kubescape/k8s-interface/instanceidhandler/v1"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+)
+
+// CurrentSchemaVersion is the schema version of the objects stored by MarshalVersioned.
+// The version 0 is the unversioned objects: the workloads stored as JSON and the instance IDs stored in their string format
+const CurrentSchemaVersion = 1
+
+// TypeInstanceID is the type of the stored instance IDs
+const TypeInstanceID workloadinterface.ObjectType = "instanceID"
+
+// Versioned is the stored object with its type and schema version
+type Versioned struct {
+	SchemaVersion int                          `json:"schemaVersion"`
+	Type          workloadinterface.ObjectType `json:"type"`
+	Object        json.RawMessage              `json:"object"`
+}
+
+// Migration migrates an object, as decoded from JSON, from a schema version to the next one
+type Migration func(object interface{}) (interface{}, error)
+
+// Decoder returns the object of the type from the object decoded from JSON, migrated to the current schema version
+type Decoder func(object interface{}) (interface{}, error)
+
+var (
+	registryLock sync.RWMutex
+	// migrations of each type by the version they migrate from
+	migrations = map[workloadinterface.ObjectType]map[int]Migration{
+		TypeInstanceID: {0: migrateInstanceIDFromString},
+	}
+	decoders = map[workloadinterface.ObjectType]Decoder{
+		workloadinterface.TypeWorkloadObject: func(object interface{}) (interface{}, error) {
+			m, err := toMap(object)
+			return workloadinterface.NewWorkloadObj(m), err
+		},
+		workloadinterface.TypeBaseObject: func(object interface{}) (interface{}, error) {
+			m, err := toMap(object)
+			return workloadinterface.NewBaseObject(m), err
+		},
+		workloadinterface.TypeListWorkloads: func(object interface{}) (interface{}, error) {
+			m, err := toMap(object)
+			return workloadinterface.NewListWorkloadsObj(m), err
+		},
+		TypeInstanceID: decodeInstanceID,
+	}
+)
+
+// RegisterMigration registers the migration of the objects of the type from the schema version to the next one.
+// The types without migration from a version are not changed by the version
+func RegisterMigration(objectType workloadinterface.ObjectType, fromVersion int, migration Migration) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if migrations[objectType] == nil {
+		migrations[objectType] = map[int]Migration{}
+	}
+	migrations[objectType][fromVersion] = migration
+}
+
+// RegisterDecoder registers the decoder of the objects of the type, e.g. the types of the objects implementing IMetadata outside of workloadinterface
+func RegisterDecoder(objectType workloadinterface.ObjectType, decoder Decoder) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	decoders[objectType] = decoder
+}
+
+// MarshalVersioned encodes the object with its type and the current schema version.
+// The supported objects are the IMetadata objects (workloads, base objects...), the IListWorkloads and the instance IDs
+func MarshalVersioned(obj interface{}) ([]byte, error) {
+	versioned := &Versioned{SchemaVersion: CurrentSchemaVersion}
+	var object interface{}
+	switch o := obj.(type) {
+	case instanceidhandler.IInstanceID:
+		versioned.Type = TypeInstanceID
+		object = instanceIDObject{APIVersion: o.GetAPIVersion(), Namespace: o.GetNamespace(), Kind: o.GetKind(), Name: o.GetName(), ContainerName: o.GetContainerName()}
+	case workloadinterface.IMetadata:
+		versioned.Type = o.GetObjectType()
+		object = o.GetObject()
+	case workloadinterface.IListWorkloads:
+		versioned.Type = o.GetObjectType()
+		object = o.GetObject()
+	default:
+		return nil, fmt.Errorf("failed to marshal the object of the type %T: not supported", obj)
+	}
+	b, err := json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the object of the type '%s': %w", versioned.Type, err)
+	}
+	versioned.Object = b
+	return json.Marshal(versioned)
+}
+
+// UnmarshalVersioned decodes the object stored by MarshalVersioned, or by the older consumers, and migrates it to the current schema version.
+// The unversioned objects are decoded as workloads, lists of workloads (the objects with items) or instance IDs (the strings)
+func UnmarshalVersioned(data []byte) (interface{}, error) {
+	versioned, err := decodeVersioned(data)
+	if err != nil {
+		return nil, err
+	}
+	if versioned.SchemaVersion > CurrentSchemaVersion {
+		return nil, fmt.Errorf("failed to unmarshal the object of the type '%s': the schema version %d is newer than the supported version %d", versioned.Type, versioned.SchemaVersion, CurrentSchemaVersion)
+	}
+	var object interface{}
+	if err := json.Unmarshal(versioned.Object, &object); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the object of the type '%s': %w", versioned.Type, err)
+	}
+
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	for version := versioned.SchemaVersion; version < CurrentSchemaVersion; version++ {
+		migration, ok := migrations[versioned.Type][version]
+		if !ok {
+			continue
+		}
+		if object, err = migration(object); err != nil {
+			return nil, fmt.Errorf("failed to migrate the object of the type '%s' from the schema version %d: %w", versioned.Type, version, err)
+		}
+	}
+	decoder, ok := decoders[versioned.Type]
+	if !ok {
+		return nil, fmt.Errorf("failed to unmarshal the object of the type '%s': no decoder is registered", versioned.Type)
+	}
+	decoded, err := decoder(object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the object of the type '%s': %w", versioned.Type, err)
+	}
+	return decoded, nil
+}
+
+// UnmarshalWorkload decodes the workload stored by MarshalVersioned, see UnmarshalVersioned
+func UnmarshalWorkload(data []byte) (workloadinterface.IWorkload, error) {
+	obj, err := UnmarshalVersioned(data)
+	if err != nil {
+		return nil, err
+	}
+	workload, ok := obj.(workloadinterface.IWorkload)
+	if !ok {
+		return nil, fmt.Errorf("the object of the type %T is not a workload", obj)
+	}
+	return workload, nil
+}
+
+// UnmarshalMetadata decodes the IMetadata object stored by MarshalVersioned, see UnmarshalVersioned
+func UnmarshalMetadata(data []byte) (workloadinterface.IMetadata, error) {
+	obj, err := UnmarshalVersioned(data)
+	if err != nil {
+		return nil, err
+	}
+	metadata, ok := obj.(workloadinterface.IMetadata)
+	if !ok {
+		return nil, fmt.Errorf("the object of the type %T is not a metadata object", obj)
+	}
+	return metadata, nil
+}
+
+// UnmarshalInstanceID decodes the instance ID stored by MarshalVersioned, see UnmarshalVersioned
+func UnmarshalInstanceID(data []byte) (instanceidhandler.IInstanceID, error) {
+	obj, err := UnmarshalVersioned(data)
+	if err != nil {
+		return nil, err
+	}
+	instanceID, ok := obj.(instanceidhandler.IInstanceID)
+	if !ok {
+		return nil, fmt.Errorf("the object of the type %T is not an instance ID", obj)
+	}
+	return instanceID, nil
+}
+
+// decodeVersioned decodes the versioned object, the unversioned objects are returned with the schema version 0. An object is versioned if it has
+// the schemaVersion, type and object fields, the stored objects may have a schemaVersion field of their own (e.g. custom resources)
+func decodeVersioned(data []byte) (*Versioned, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		return &Versioned{Type: TypeInstanceID, Object: data}, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the versioned object: %w", err)
+	}
+	if isVersioned(fields) {
+		versioned := &Versioned{}
+		if err := json.Unmarshal(data, versioned); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal the versioned object: %w", err)
+		}
+		if versioned.Type == "" || len(versioned.Object) == 0 {
+			return nil, fmt.Errorf("failed to unmarshal the versioned object: missing type or object")
+		}
+		return versioned, nil
+	}
+	if _, ok := fields["items"]; ok {
+		return &Versioned{Type: workloadinterface.TypeListWorkloads, Object: data}, nil
+	}
+	return &Versioned{Type: workloadinterface.TypeWorkloadObject, Object: data}, nil
+}
+
+func isVersioned(fields map[string]json.RawMessage) bool {
+	for _, field := range []string{"schemaVersion", "type", "object"} {
+		if _, ok := fields[field]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// instanceIDObject is the schema of the stored instance IDs
+type instanceIDObject struct {
+	APIVersion    string `json:"apiVersion"`
+	Namespace     string `json:"namespace"`
+	Kind          string `json:"kind"`
+	Name          string `json:"name"`
+	ContainerName string `json:"containerName"`
+}
+
+// migrateInstanceIDFromString migrates the instance IDs stored in their string format
+func migrateInstanceIDFromString(object interface{}) (interface{}, error) {
+	s, ok := object.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected the string format of the instance ID, found %T", object)
+	}
+	instanceID, err := instanceidhandlerv1.GenerateInstanceIDFromString(s)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"apiVersion":    instanceID.GetAPIVersion(),
+		"namespace":     instanceID.GetNamespace(),
+		"kind":          instanceID.GetKind(),
+		"name":          instanceID.GetName(),
+		"containerName": instanceID.GetContainerName(),
+	}, nil
+}
+
+func decodeInstanceID(object interface{}) (interface{}, error) {
+	m, err := toMap(object)
+	if err != nil {
+		return nil, err
+	}
+	field := func(key string) string {
+		s, _ := m[key].(string)
+		return s
+	}
+	instanceID := &instanceidhandlerv1.InstanceID{}
+	instanceID.SetAPIVersion(field("apiVersion"))
+	instanceID.SetNamespace(field("namespace"))
+	instanceID.SetKind(field("kind"))
+	instanceID.SetName(field("name"))
+	instanceID.SetContainerName(field("containerName"))
+	return instanceID, nil
+}
+
+func toMap(object interface{}) (map[string]interface{}, error) {
+	m, ok := object.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object, found %T", object)
+	}
+	return m, nil
+}
@@ -0,0 +1,125 @@
+package serialization
+
+import (
+	"encoding/json"
+	"testing"
+
+	instanceidhandlerv1 "github.com/kubescape/k8s-interface/instanceidhandler/v1"
+	"github.com/kubescape/k8s-interface/workloadinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const deployment = `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "nginx", "namespace": "default"}, "spec": {"replicas": 1}}`
+
+func TestMarshalVersioned(t *testing.T) {
+	workload, err := workloadinterface.NewWorkload([]byte(deployment))
+	require.NoError(t, err)
+	data, err := MarshalVersioned(workload)
+	require.NoError(t, err)
+	versioned := &Versioned{}
+	require.NoError(t, json.Unmarshal(data, versioned))
+	assert.Equal(t, CurrentSchemaVersion, versioned.SchemaVersion)
+	assert.Equal(t, workloadinterface.TypeWorkloadObject, versioned.Type)
+
+	decoded, err := UnmarshalWorkload(data)
+	require.NoError(t, err)
+	assert.Equal(t, workload.GetID(), decoded.GetID())
+	assert.Equal(t, workload.GetObject(), decoded.GetObject())
+
+	// other metadata objects
+	data, err = MarshalVersioned(workloadinterface.NewBaseObject(map[string]interface{}{"kind": "Cluster", "metadata": map[string]interface{}{"name": "minikube"}}))
+	require.NoError(t, err)
+	metadata, err := UnmarshalMetadata(data)
+	require.NoError(t, err)
+	assert.Equal(t, workloadinterface.TypeBaseObject, metadata.GetObjectType())
+	assert.Equal(t, "minikube", metadata.GetName())
+	_, err = UnmarshalWorkload(data)
+	assert.Error(t, err)
+
+	list := workloadinterface.NewListWorkloadsObj(map[string]interface{}{"apiVersion": "v1", "kind": "List", "items": []interface{}{workload.GetObject()}})
+	data, err = MarshalVersioned(list)
+	require.NoError(t, err)
+	decodedList, err := UnmarshalVersioned(data)
+	require.NoError(t, err)
+	require.IsType(t, &workloadinterface.ListWorkloads{}, decodedList)
+	assert.Len(t, decodedList.(*workloadinterface.ListWorkloads).GetItems(), 1)
+
+	_, err = MarshalVersioned("nginx")
+	assert.Error(t, err)
+}
+
+func TestMarshalVersionedInstanceID(t *testing.T) {
+	instanceID, err := instanceidhandlerv1.GenerateInstanceIDFromString("apiVersion-apps/v1/namespace-default/kind-Deployment/name-nginx/containerName-nginx")
+	require.NoError(t, err)
+	data, err := MarshalVersioned(instanceID)
+	require.NoError(t, err)
+	decoded, err := UnmarshalInstanceID(data)
+	require.NoError(t, err)
+	assert.Equal(t, instanceID.GetStringFormatted(), decoded.GetStringFormatted())
+
+	_, err = UnmarshalMetadata(data)
+	assert.Error(t, err)
+}
+
+func TestUnmarshalVersionedUnversioned(t *testing.T) {
+	// the workloads stored as JSON
+	workload, err := UnmarshalWorkload([]byte(deployment))
+	require.NoError(t, err)
+	assert.Equal(t, "apps/v1/default/Deployment/nginx", workload.GetID())
+
+	// the instance IDs stored in their string format
+	stringFormat := "apiVersion-v1/namespace-default/kind-Pod/name-nginx/containerName-nginx"
+	data, err := json.Marshal(stringFormat)
+	require.NoError(t, err)
+	instanceID, err := UnmarshalInstanceID(data)
+	require.NoError(t, err)
+	assert.Equal(t, stringFormat, instanceID.GetStringFormatted())
+
+	_, err = UnmarshalInstanceID([]byte(`"invalid"`))
+	assert.Error(t, err)
+	_, err = UnmarshalVersioned([]byte(`[]`))
+	assert.Error(t, err)
+}
+
+func TestUnmarshalVersionedMigrations(t *testing.T) {
+	const typeRenamed workloadinterface.ObjectType = "renamed"
+	// the schema version 0 stored the name as "title"
+	RegisterMigration(typeRenamed, 0, func(object interface{}) (interface{}, error) {
+		m := object.(map[string]interface{})
+		m["metadata"] = map[string]interface{}{"name": m["title"]}
+		delete(m, "title")
+		return m, nil
+	})
+	RegisterDecoder(typeRenamed, func(object interface{}) (interface{}, error) {
+		return workloadinterface.NewBaseObject(object.(map[string]interface{})), nil
+	})
+
+	metadata, err := UnmarshalMetadata([]byte(`{"schemaVersion": 0, "type": "renamed", "object": {"kind": "Renamed", "title": "nginx"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", metadata.GetName())
+
+	// the migration does not run on the current schema version
+	metadata, err = UnmarshalMetadata([]byte(`{"schemaVersion": 1, "type": "renamed", "object": {"kind": "Renamed", "metadata": {"name": "nginx"}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", metadata.GetName())
+
+	_, err = UnmarshalVersioned([]byte(`{"schemaVersion": 2, "type": "workload", "object": {}}`))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "newer")
+	_, err = UnmarshalVersioned([]byte(`{"schemaVersion": 1, "type": "unknown", "object": {}}`))
+	assert.Error(t, err)
+	_, err = UnmarshalVersioned([]byte(`{"schemaVersion": 1, "type": "", "object": {}}`))
+	assert.Error(t, err)
+}
+
+func TestUnmarshalVersionedSchemaVersionField(t *testing.T) {
+	// an unversioned custom resource with a schemaVersion field of its own
+	workload, err := UnmarshalWorkload([]byte(`{"apiVersion": "example.com/v1", "kind": "Config", "metadata": {"namespace": "default", "name": "app"}, "schemaVersion": 2}`))
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/v1/default/Config/app", workload.GetID())
+
+	workload, err = UnmarshalWorkload([]byte(`{"schemaVersion": 1, "object": {}}`))
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), workload.GetObject()["schemaVersion"])
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name of the spans created by the package
+const TracerName = "github.com/kubescape/k8s-interface"
+
+// attribute keys, the kubernetes and cloud keys follow the OpenTelemetry semantic conventions
+const (
+	AttributeNamespace     = attribute.Key("k8s.namespace.name")
+	AttributeResource      = attribute.Key("k8s.resource")
+	AttributeName          = attribute.Key("k8s.object.name")
+	AttributeCount         = attribute.Key("k8s.object.count")
+	AttributeCloudProvider = attribute.Key("cloud.provider")
+	AttributeCloudRegion   = attribute.Key("cloud.region")
+	AttributeCloudCluster  = attribute.Key("cloud.cluster")
+	AttributeRegistry      = attribute.Key("registry")
+)
+
+// cloud providers, as in the cloud.provider semantic convention
+const (
+	CloudProviderAWS   = "aws"
+	CloudProviderAzure = "azure"
+	CloudProviderGCP   = "gcp"
+)
+
+// Tracer returns the tracer of the package from the global tracer provider.
+// Tracing is a no-op unless the application registered a tracer provider with otel.SetTracerProvider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// StartSpan starts a span, child of the span of the context if any. A nil context is replaced by context.Background()
+func StartSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return Tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// EndSpan ends the span, recording the error and setting the span status to error if err is not nil
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,149 @@
+// Package wlid generates, parses and validates workload IDs (WLIDs): wlid://cluster-<cluster>/namespace-<namespace>/<kind>-<name>, where the kind is
+// lower case. Unlike github.com/armosec/utils-k8s-go/wlid, the functions of this package return an error for malformed WLIDs instead of partial values
+package wlid
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	wlidpkg "github.com/armosec/utils-k8s-go/wlid"
+	"github.com/kubescape/k8s-interface/instanceidhandler"
+	instanceidhandlerv1 "github.com/kubescape/k8s-interface/instanceidhandler/v1"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/kubescape/k8s-interface/names"
+)
+
+const (
+	Prefix          = "wlid://"
+	clusterPrefix   = "cluster-"
+	namespacePrefix = "namespace-"
+	separator       = "/"
+)
+
+// ErrInvalidWlid is the error of the malformed WLIDs, use errors.Is
+var ErrInvalidWlid = errors.New("invalid wlid")
+
+// WLID is a parsed workload ID
+type WLID struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	// Kind is the kind of the workload, e.g. Deployment. Parse restores the case of the built-in kinds, the kinds of custom resources are lower case
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// Generate returns the WLID of the workload, an error if one of the parts is not valid
+func Generate(cluster, namespace, kind, name string) (string, error) {
+	wlid := &WLID{Cluster: cluster, Namespace: namespace, Kind: kind, Name: name}
+	if err := wlid.Validate(); err != nil {
+		return "", err
+	}
+	return wlid.String(), nil
+}
+
+// Parse parses the WLID. The WLID must be canonical: the prefix and the three parts, a lower case kind, valid namespace and name.
+// The cluster may contain separators, e.g. the ARN of an EKS cluster: the namespace and the kind-name are the last two parts
+func Parse(wlid string) (*WLID, error) {
+	if !strings.HasPrefix(wlid, Prefix) {
+		return nil, newError(wlid, "missing '%s' prefix", Prefix)
+	}
+	parts := strings.Split(strings.TrimPrefix(wlid, Prefix), separator)
+	if len(parts) < 3 {
+		return nil, newError(wlid, "expected %d parts separated by '%s', found %d", 3, separator, len(parts))
+	}
+	parts = []string{strings.Join(parts[:len(parts)-2], separator), parts[len(parts)-2], parts[len(parts)-1]}
+	if !strings.HasPrefix(parts[0], clusterPrefix) {
+		return nil, newError(wlid, "the first part must start with '%s'", clusterPrefix)
+	}
+	if !strings.HasPrefix(parts[1], namespacePrefix) {
+		return nil, newError(wlid, "the second part must start with '%s'", namespacePrefix)
+	}
+	kind, name, found := strings.Cut(parts[2], "-")
+	if !found {
+		return nil, newError(wlid, "the third part must be <kind>-<name>")
+	}
+	parsed := &WLID{
+		Cluster:   strings.TrimPrefix(parts[0], clusterPrefix),
+		Namespace: strings.TrimPrefix(parts[1], namespacePrefix),
+		Kind:      wlidpkg.GetK8SKindFronList(kind),
+		Name:      name,
+	}
+	if err := parsed.Validate(); err != nil {
+		return nil, err
+	}
+	if parsed.String() != wlid {
+		return nil, newError(wlid, "not canonical, expected '%s'", parsed.String())
+	}
+	return parsed, nil
+}
+
+// Validate returns an error if the WLID is malformed
+func Validate(wlid string) error {
+	_, err := Parse(wlid)
+	return err
+}
+
+// Validate returns an error if one of the parts of the WLID is not valid. The namespace is empty for the cluster-scoped kinds, e.g. Node,
+// according to the k8sinterface kind mapping
+func (wlid *WLID) Validate() error {
+	switch {
+	case wlid.Cluster == "":
+		return newError(wlid.String(), "empty cluster")
+	case strings.IndexFunc(wlid.Cluster, unicode.IsSpace) != -1:
+		return newError(wlid.String(), "the cluster '%s' contains whitespaces", wlid.Cluster)
+	case wlid.Kind == "":
+		return newError(wlid.String(), "empty kind")
+	case strings.IndexFunc(wlid.Kind, func(r rune) bool { return !(r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))) }) != -1:
+		return newError(wlid.String(), "the kind '%s' must be alphanumeric", wlid.Kind)
+	}
+	if wlid.Namespace == "" {
+		if info, ok := k8sinterface.GetKindInfo(wlid.Kind); !ok || info.Namespaced {
+			return newError(wlid.String(), "empty namespace, the kind '%s' is not cluster-scoped", wlid.Kind)
+		}
+	} else if err := names.ValidateDNS1123Label(wlid.Namespace); err != nil {
+		return newError(wlid.String(), "%s", err.Error())
+	}
+	if err := names.ValidateDNS1123Subdomain(wlid.Name); err != nil {
+		return newError(wlid.String(), "%s", err.Error())
+	}
+	return nil
+}
+
+// String returns the WLID
+func (wlid *WLID) String() string {
+	return Prefix + clusterPrefix + wlid.Cluster + separator + namespacePrefix + wlid.Namespace + separator + strings.ToLower(wlid.Kind) + "-" + wlid.Name
+}
+
+// FromInstanceID returns the WLID of the workload of the instance ID
+func FromInstanceID(cluster string, instanceID instanceidhandler.IInstanceID) (*WLID, error) {
+	wlid := &WLID{Cluster: cluster, Namespace: instanceID.GetNamespace(), Kind: instanceID.GetKind(), Name: instanceID.GetName()}
+	if err := wlid.Validate(); err != nil {
+		return nil, err
+	}
+	return wlid, nil
+}
+
+// ToInstanceID returns the instance ID of the container of the workload. The apiVersion of the kind is resolved with the k8sinterface kind mapping,
+// an error is returned for kinds that are not part of it
+func (wlid *WLID) ToInstanceID(containerName string) (instanceidhandler.IInstanceID, error) {
+	if containerName == "" {
+		return nil, fmt.Errorf("failed to convert '%s' to instance ID: empty container name", wlid.String())
+	}
+	gvk, err := k8sinterface.GVKForKind(wlid.Kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert '%s' to instance ID: %w", wlid.String(), err)
+	}
+	instanceID := &instanceidhandlerv1.InstanceID{}
+	instanceID.SetAPIVersion(gvk.GroupVersion().String())
+	instanceID.SetNamespace(wlid.Namespace)
+	instanceID.SetKind(gvk.Kind)
+	instanceID.SetName(wlid.Name)
+	instanceID.SetContainerName(containerName)
+	return instanceID, nil
+}
+
+func newError(wlid, format string, a ...interface{}) error {
+	return fmt.Errorf("%w '%s': %s", ErrInvalidWlid, wlid, fmt.Sprintf(format, a...))
+}
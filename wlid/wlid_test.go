@@ -0,0 +1,119 @@
+package wlid
+
+import (
+	"errors"
+	"testing"
+
+	instanceidhandlerv1 "github.com/kubescape/k8s-interface/instanceidhandler/v1"
+	"github.com/kubescape/k8s-interface/k8sinterface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	k8sinterface.InitializeMapResourcesMock()
+
+	wlid, err := Generate("minikube", "default", "Deployment", "nginx")
+	require.NoError(t, err)
+	assert.Equal(t, "wlid://cluster-minikube/namespace-default/deployment-nginx", wlid)
+
+	// cluster-scoped kind
+	wlid, err = Generate("minikube", "", "Node", "worker-1")
+	require.NoError(t, err)
+	assert.Equal(t, "wlid://cluster-minikube/namespace-/node-worker-1", wlid)
+
+	// the ARN of an EKS cluster
+	wlid, err = Generate("arn:aws:eks:eu-north-1:123456789012:cluster/prod", "default", "Deployment", "nginx")
+	require.NoError(t, err)
+	assert.Equal(t, "wlid://cluster-arn:aws:eks:eu-north-1:123456789012:cluster/prod/namespace-default/deployment-nginx", wlid)
+
+	tests := []struct {
+		testName                       string
+		cluster, namespace, kind, name string
+	}{
+		{testName: "empty cluster", namespace: "default", kind: "Deployment", name: "nginx"},
+		{testName: "cluster with whitespace", cluster: "a b", namespace: "default", kind: "Deployment", name: "nginx"},
+		{testName: "empty kind", cluster: "minikube", namespace: "default", name: "nginx"},
+		{testName: "kind with dash", cluster: "minikube", namespace: "default", kind: "Deploy-ment", name: "nginx"},
+		{testName: "invalid namespace", cluster: "minikube", namespace: "Default", kind: "Deployment", name: "nginx"},
+		{testName: "empty namespace of a namespaced kind", cluster: "minikube", kind: "Deployment", name: "nginx"},
+		{testName: "empty namespace of an unknown kind", cluster: "minikube", kind: "Unknown", name: "nginx"},
+		{testName: "empty name", cluster: "minikube", namespace: "default", kind: "Deployment"},
+		{testName: "invalid name", cluster: "minikube", namespace: "default", kind: "Deployment", name: "nginx_1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			_, err := Generate(tt.cluster, tt.namespace, tt.kind, tt.name)
+			assert.True(t, errors.Is(err, ErrInvalidWlid), err)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	k8sinterface.InitializeMapResourcesMock()
+
+	wlid, err := Parse("wlid://cluster-minikube/namespace-default/replicaset-nginx-84f5585d68")
+	require.NoError(t, err)
+	assert.Equal(t, &WLID{Cluster: "minikube", Namespace: "default", Kind: "ReplicaSet", Name: "nginx-84f5585d68"}, wlid)
+	assert.Equal(t, "wlid://cluster-minikube/namespace-default/replicaset-nginx-84f5585d68", wlid.String())
+
+	// the cluster may contain dashes
+	wlid, err = Parse("wlid://cluster-my-cluster/namespace-default/pod-nginx")
+	require.NoError(t, err)
+	assert.Equal(t, "my-cluster", wlid.Cluster)
+	assert.Equal(t, "Pod", wlid.Kind)
+
+	// and separators
+	wlid, err = Parse("wlid://cluster-arn:aws:eks:eu-north-1:123456789012:cluster/prod/namespace-default/pod-nginx")
+	require.NoError(t, err)
+	assert.Equal(t, &WLID{Cluster: "arn:aws:eks:eu-north-1:123456789012:cluster/prod", Namespace: "default", Kind: "Pod", Name: "nginx"}, wlid)
+
+	// cluster-scoped kind
+	wlid, err = Parse("wlid://cluster-minikube/namespace-/node-worker-1")
+	require.NoError(t, err)
+	assert.Equal(t, &WLID{Cluster: "minikube", Kind: "Node", Name: "worker-1"}, wlid)
+
+	for _, invalid := range []string{
+		"",
+		"cluster-minikube/namespace-default/pod-nginx",
+		"wlid://cluster-minikube/namespace-default",
+		"wlid://cluster-minikube/namespace-default/pod-nginx/nginx",
+		"wlid://minikube/namespace-default/pod-nginx",
+		"wlid://cluster-minikube/default/pod-nginx",
+		"wlid://cluster-minikube/namespace-default/nginx",
+		"wlid://cluster-minikube/namespace-default/Pod-nginx",
+		"wlid://cluster-minikube/namespace-default/pod-",
+		"wlid://cluster-/namespace-default/pod-nginx",
+		"wlid://cluster-minikube/namespace-/pod-nginx",
+		"wlid://cluster-minikube/namespace-default/pod-nginx ",
+	} {
+		_, err := Parse(invalid)
+		assert.True(t, errors.Is(err, ErrInvalidWlid), "%q: %v", invalid, err)
+		assert.Error(t, Validate(invalid), invalid)
+	}
+	assert.NoError(t, Validate("wlid://cluster-minikube/namespace-default/deployment-nginx"))
+}
+
+func TestInstanceIDConversion(t *testing.T) {
+	k8sinterface.InitializeMapResourcesMock()
+
+	wlid, err := Parse("wlid://cluster-minikube/namespace-default/deployment-nginx")
+	require.NoError(t, err)
+	instanceID, err := wlid.ToInstanceID("nginx")
+	require.NoError(t, err)
+	assert.Equal(t, "apiVersion-apps/v1/namespace-default/kind-Deployment/name-nginx/containerName-nginx", instanceID.GetStringFormatted())
+
+	converted, err := FromInstanceID("minikube", instanceID)
+	require.NoError(t, err)
+	assert.Equal(t, wlid, converted)
+
+	_, err = wlid.ToInstanceID("")
+	assert.Error(t, err)
+	_, err = (&WLID{Cluster: "minikube", Namespace: "default", Kind: "unknownkind", Name: "nginx"}).ToInstanceID("nginx")
+	assert.Error(t, err)
+
+	instanceID, err = instanceidhandlerv1.GenerateInstanceIDFromString("apiVersion-v1/namespace-default/kind-Pod/name-Nginx/containerName-nginx")
+	require.NoError(t, err)
+	_, err = FromInstanceID("minikube", instanceID)
+	assert.True(t, errors.Is(err, ErrInvalidWlid))
+}
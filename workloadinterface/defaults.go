@@ -0,0 +1,395 @@
+package workloadinterface
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	defaultTerminationMessagePath        = "/dev/termination-log"
+	defaultSchedulerName                 = "default-scheduler"
+	defaultServiceAccountName            = "default"
+	defaultTerminationGracePeriodSeconds = int64(30)
+	defaultVolumeMode                    = int32(0644)
+)
+
+// defaultsScheme is the scheme of the built-in types ApplyDefaults defaults, with the defaulting functions of the API server
+var defaultsScheme = newDefaultsScheme()
+
+func newDefaultsScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{corev1.AddToScheme, appsv1.AddToScheme, batchv1.AddToScheme} {
+		if err := addToScheme(scheme); err != nil {
+			panic(err)
+		}
+	}
+	scheme.AddTypeDefaultingFunc(&corev1.Pod{}, func(obj interface{}) { defaultPod(obj.(*corev1.Pod)) })
+	scheme.AddTypeDefaultingFunc(&corev1.PodTemplate{}, func(obj interface{}) { defaultPodSpec(&obj.(*corev1.PodTemplate).Template.Spec) })
+	scheme.AddTypeDefaultingFunc(&corev1.ReplicationController{}, func(obj interface{}) { defaultReplicationController(obj.(*corev1.ReplicationController)) })
+	scheme.AddTypeDefaultingFunc(&corev1.Service{}, func(obj interface{}) { defaultServiceSpec(&obj.(*corev1.Service).Spec) })
+	scheme.AddTypeDefaultingFunc(&appsv1.Deployment{}, func(obj interface{}) { defaultDeployment(obj.(*appsv1.Deployment)) })
+	scheme.AddTypeDefaultingFunc(&appsv1.ReplicaSet{}, func(obj interface{}) { defaultReplicaSet(obj.(*appsv1.ReplicaSet)) })
+	scheme.AddTypeDefaultingFunc(&appsv1.StatefulSet{}, func(obj interface{}) { defaultStatefulSet(obj.(*appsv1.StatefulSet)) })
+	scheme.AddTypeDefaultingFunc(&appsv1.DaemonSet{}, func(obj interface{}) { defaultDaemonSet(obj.(*appsv1.DaemonSet)) })
+	scheme.AddTypeDefaultingFunc(&batchv1.Job{}, func(obj interface{}) { defaultJob(obj.(*batchv1.Job)) })
+	scheme.AddTypeDefaultingFunc(&batchv1.CronJob{}, func(obj interface{}) { defaultCronJob(obj.(*batchv1.CronJob)) })
+	return scheme
+}
+
+// ApplyDefaults applies the defaults the API server sets on admission to the workload, so scanning a manifest (e.g. of a file or a Helm chart) gives the same
+// results as scanning the object in the cluster: replicas, update strategies, imagePullPolicy, the protocol of ports, serviceAccountName, resource requests
+// of pods... The defaults are applied to Pods, PodTemplates, ReplicationControllers, Services and the apps/v1 and batch/v1 workloads, other objects are not modified.
+// The fields of the workload that are not part of the Kubernetes type are removed
+func ApplyDefaults(workload IMetadata) error {
+	gvk := schema.FromAPIVersionAndKind(workload.GetApiVersion(), workload.GetKind())
+	if !defaultsScheme.Recognizes(gvk) {
+		return nil
+	}
+	typed, err := defaultsScheme.New(gvk)
+	if err != nil {
+		return err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(workload.GetObject(), typed); err != nil {
+		return fmt.Errorf("failed to convert %s '%s' to '%s', reason: %s", workload.GetKind(), workload.GetName(), gvk.String(), err.Error())
+	}
+	defaultsScheme.Default(typed)
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typed)
+	if err != nil {
+		return fmt.Errorf("failed to convert %s '%s', reason: %s", workload.GetKind(), workload.GetName(), err.Error())
+	}
+	// the typed objects have a null creationTimestamp and an empty status, the objects of the files do not
+	removeNullCreationTimestamps(obj)
+	if _, ok := workload.GetObject()["status"]; !ok {
+		delete(obj, "status")
+	}
+	workload.SetObject(obj)
+	return nil
+}
+
+// removeNullCreationTimestamps removes the null creationTimestamp of the metadata of the object and of its templates
+func removeNullCreationTimestamps(obj map[string]interface{}) {
+	for key, value := range obj {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			removeNullCreationTimestamps(v)
+		case nil:
+			if key == "creationTimestamp" {
+				delete(obj, key)
+			}
+		}
+	}
+}
+
+func defaultPod(pod *corev1.Pod) {
+	// the requests of the containers are the limits if not set
+	for _, containers := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for i := range containers {
+			for name, limit := range containers[i].Resources.Limits {
+				if containers[i].Resources.Requests == nil {
+					containers[i].Resources.Requests = corev1.ResourceList{}
+				}
+				if _, ok := containers[i].Resources.Requests[name]; !ok {
+					containers[i].Resources.Requests[name] = limit.DeepCopy()
+				}
+			}
+		}
+	}
+	defaultPodSpec(&pod.Spec)
+}
+
+func defaultReplicationController(rc *corev1.ReplicationController) {
+	if rc.Spec.Replicas == nil {
+		rc.Spec.Replicas = int32Ptr(1)
+	}
+	if rc.Spec.Template == nil {
+		return
+	}
+	if len(rc.Spec.Selector) == 0 {
+		rc.Spec.Selector = rc.Spec.Template.Labels
+	}
+	if len(rc.Labels) == 0 {
+		rc.Labels = rc.Spec.Template.Labels
+	}
+	defaultPodSpec(&rc.Spec.Template.Spec)
+}
+
+func defaultDeployment(deployment *appsv1.Deployment) {
+	if deployment.Spec.Replicas == nil {
+		deployment.Spec.Replicas = int32Ptr(1)
+	}
+	if deployment.Spec.Strategy.Type == "" {
+		deployment.Spec.Strategy.Type = appsv1.RollingUpdateDeploymentStrategyType
+	}
+	if deployment.Spec.Strategy.Type == appsv1.RollingUpdateDeploymentStrategyType {
+		if deployment.Spec.Strategy.RollingUpdate == nil {
+			deployment.Spec.Strategy.RollingUpdate = &appsv1.RollingUpdateDeployment{}
+		}
+		if deployment.Spec.Strategy.RollingUpdate.MaxUnavailable == nil {
+			maxUnavailable := intstr.FromString("25%")
+			deployment.Spec.Strategy.RollingUpdate.MaxUnavailable = &maxUnavailable
+		}
+		if deployment.Spec.Strategy.RollingUpdate.MaxSurge == nil {
+			maxSurge := intstr.FromString("25%")
+			deployment.Spec.Strategy.RollingUpdate.MaxSurge = &maxSurge
+		}
+	}
+	if deployment.Spec.RevisionHistoryLimit == nil {
+		deployment.Spec.RevisionHistoryLimit = int32Ptr(10)
+	}
+	if deployment.Spec.ProgressDeadlineSeconds == nil {
+		deployment.Spec.ProgressDeadlineSeconds = int32Ptr(600)
+	}
+	defaultPodSpec(&deployment.Spec.Template.Spec)
+}
+
+func defaultReplicaSet(replicaSet *appsv1.ReplicaSet) {
+	if replicaSet.Spec.Replicas == nil {
+		replicaSet.Spec.Replicas = int32Ptr(1)
+	}
+	defaultPodSpec(&replicaSet.Spec.Template.Spec)
+}
+
+func defaultStatefulSet(statefulSet *appsv1.StatefulSet) {
+	if statefulSet.Spec.Replicas == nil {
+		statefulSet.Spec.Replicas = int32Ptr(1)
+	}
+	if statefulSet.Spec.PodManagementPolicy == "" {
+		statefulSet.Spec.PodManagementPolicy = appsv1.OrderedReadyPodManagement
+	}
+	if statefulSet.Spec.UpdateStrategy.Type == "" {
+		statefulSet.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+	}
+	if statefulSet.Spec.UpdateStrategy.Type == appsv1.RollingUpdateStatefulSetStrategyType {
+		if statefulSet.Spec.UpdateStrategy.RollingUpdate == nil {
+			statefulSet.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{}
+		}
+		if statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition == nil {
+			statefulSet.Spec.UpdateStrategy.RollingUpdate.Partition = int32Ptr(0)
+		}
+	}
+	if statefulSet.Spec.RevisionHistoryLimit == nil {
+		statefulSet.Spec.RevisionHistoryLimit = int32Ptr(10)
+	}
+	for i := range statefulSet.Spec.VolumeClaimTemplates {
+		if statefulSet.Spec.VolumeClaimTemplates[i].Spec.VolumeMode == nil {
+			volumeMode := corev1.PersistentVolumeFilesystem
+			statefulSet.Spec.VolumeClaimTemplates[i].Spec.VolumeMode = &volumeMode
+		}
+	}
+	defaultPodSpec(&statefulSet.Spec.Template.Spec)
+}
+
+func defaultDaemonSet(daemonSet *appsv1.DaemonSet) {
+	if daemonSet.Spec.UpdateStrategy.Type == "" {
+		daemonSet.Spec.UpdateStrategy.Type = appsv1.RollingUpdateDaemonSetStrategyType
+	}
+	if daemonSet.Spec.UpdateStrategy.Type == appsv1.RollingUpdateDaemonSetStrategyType {
+		if daemonSet.Spec.UpdateStrategy.RollingUpdate == nil {
+			daemonSet.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateDaemonSet{}
+		}
+		if daemonSet.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable == nil {
+			maxUnavailable := intstr.FromInt(1)
+			daemonSet.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable = &maxUnavailable
+		}
+		if daemonSet.Spec.UpdateStrategy.RollingUpdate.MaxSurge == nil {
+			maxSurge := intstr.FromInt(0)
+			daemonSet.Spec.UpdateStrategy.RollingUpdate.MaxSurge = &maxSurge
+		}
+	}
+	if daemonSet.Spec.RevisionHistoryLimit == nil {
+		daemonSet.Spec.RevisionHistoryLimit = int32Ptr(10)
+	}
+	defaultPodSpec(&daemonSet.Spec.Template.Spec)
+}
+
+func defaultJob(job *batchv1.Job) {
+	if job.Spec.Completions == nil && job.Spec.Parallelism == nil {
+		job.Spec.Completions = int32Ptr(1)
+	}
+	if job.Spec.Parallelism == nil {
+		job.Spec.Parallelism = int32Ptr(1)
+	}
+	if job.Spec.BackoffLimit == nil {
+		job.Spec.BackoffLimit = int32Ptr(6)
+	}
+	if job.Spec.CompletionMode == nil {
+		completionMode := batchv1.NonIndexedCompletion
+		job.Spec.CompletionMode = &completionMode
+	}
+	if job.Spec.Suspend == nil {
+		suspend := false
+		job.Spec.Suspend = &suspend
+	}
+	defaultPodSpec(&job.Spec.Template.Spec)
+}
+
+func defaultCronJob(cronJob *batchv1.CronJob) {
+	if cronJob.Spec.ConcurrencyPolicy == "" {
+		cronJob.Spec.ConcurrencyPolicy = batchv1.AllowConcurrent
+	}
+	if cronJob.Spec.Suspend == nil {
+		suspend := false
+		cronJob.Spec.Suspend = &suspend
+	}
+	if cronJob.Spec.SuccessfulJobsHistoryLimit == nil {
+		cronJob.Spec.SuccessfulJobsHistoryLimit = int32Ptr(3)
+	}
+	if cronJob.Spec.FailedJobsHistoryLimit == nil {
+		cronJob.Spec.FailedJobsHistoryLimit = int32Ptr(1)
+	}
+	defaultPodSpec(&cronJob.Spec.JobTemplate.Spec.Template.Spec)
+}
+
+func defaultPodSpec(podSpec *corev1.PodSpec) {
+	if podSpec.RestartPolicy == "" {
+		podSpec.RestartPolicy = corev1.RestartPolicyAlways
+	}
+	if podSpec.DNSPolicy == "" {
+		podSpec.DNSPolicy = corev1.DNSClusterFirst
+	}
+	if podSpec.SchedulerName == "" {
+		podSpec.SchedulerName = defaultSchedulerName
+	}
+	if podSpec.ServiceAccountName == "" {
+		podSpec.ServiceAccountName = podSpec.DeprecatedServiceAccount
+	}
+	if podSpec.ServiceAccountName == "" {
+		podSpec.ServiceAccountName = defaultServiceAccountName
+	}
+	if podSpec.TerminationGracePeriodSeconds == nil {
+		gracePeriod := defaultTerminationGracePeriodSeconds
+		podSpec.TerminationGracePeriodSeconds = &gracePeriod
+	}
+	if podSpec.EnableServiceLinks == nil {
+		enableServiceLinks := corev1.DefaultEnableServiceLinks
+		podSpec.EnableServiceLinks = &enableServiceLinks
+	}
+	if podSpec.SecurityContext == nil {
+		podSpec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	for i := range podSpec.InitContainers {
+		defaultContainer(&podSpec.InitContainers[i], podSpec.HostNetwork)
+	}
+	for i := range podSpec.Containers {
+		defaultContainer(&podSpec.Containers[i], podSpec.HostNetwork)
+	}
+	for i := range podSpec.EphemeralContainers {
+		defaultContainer((*corev1.Container)(&podSpec.EphemeralContainers[i].EphemeralContainerCommon), podSpec.HostNetwork)
+	}
+	for i := range podSpec.Volumes {
+		defaultVolume(&podSpec.Volumes[i])
+	}
+}
+
+func defaultContainer(container *corev1.Container, hostNetwork bool) {
+	if container.ImagePullPolicy == "" {
+		container.ImagePullPolicy = defaultImagePullPolicy(container.Image)
+	}
+	if container.TerminationMessagePath == "" {
+		container.TerminationMessagePath = defaultTerminationMessagePath
+	}
+	if container.TerminationMessagePolicy == "" {
+		container.TerminationMessagePolicy = corev1.TerminationMessageReadFile
+	}
+	for i := range container.Ports {
+		if container.Ports[i].Protocol == "" {
+			container.Ports[i].Protocol = corev1.ProtocolTCP
+		}
+		if hostNetwork && container.Ports[i].HostPort == 0 {
+			container.Ports[i].HostPort = container.Ports[i].ContainerPort
+		}
+	}
+	for i := range container.Env {
+		if container.Env[i].ValueFrom != nil && container.Env[i].ValueFrom.FieldRef != nil && container.Env[i].ValueFrom.FieldRef.APIVersion == "" {
+			container.Env[i].ValueFrom.FieldRef.APIVersion = "v1"
+		}
+	}
+	defaultProbe(container.LivenessProbe)
+	defaultProbe(container.ReadinessProbe)
+	defaultProbe(container.StartupProbe)
+}
+
+// defaultImagePullPolicy is Always for the latest tag and for images without a tag or a digest, IfNotPresent otherwise
+func defaultImagePullPolicy(image string) corev1.PullPolicy {
+	if strings.Contains(image, "@") {
+		image, _, _ = strings.Cut(image, "@")
+		if !strings.Contains(image[strings.LastIndex(image, "/")+1:], ":") {
+			return corev1.PullIfNotPresent
+		}
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if _, tag, found := strings.Cut(name, ":"); found && tag != "latest" {
+		return corev1.PullIfNotPresent
+	}
+	return corev1.PullAlways
+}
+
+func defaultProbe(probe *corev1.Probe) {
+	if probe == nil {
+		return
+	}
+	if probe.TimeoutSeconds == 0 {
+		probe.TimeoutSeconds = 1
+	}
+	if probe.PeriodSeconds == 0 {
+		probe.PeriodSeconds = 10
+	}
+	if probe.SuccessThreshold == 0 {
+		probe.SuccessThreshold = 1
+	}
+	if probe.FailureThreshold == 0 {
+		probe.FailureThreshold = 3
+	}
+	if probe.HTTPGet != nil && probe.HTTPGet.Scheme == "" {
+		probe.HTTPGet.Scheme = corev1.URISchemeHTTP
+	}
+}
+
+func defaultVolume(volume *corev1.Volume) {
+	mode := defaultVolumeMode
+	switch {
+	case volume.Secret != nil && volume.Secret.DefaultMode == nil:
+		volume.Secret.DefaultMode = &mode
+	case volume.ConfigMap != nil && volume.ConfigMap.DefaultMode == nil:
+		volume.ConfigMap.DefaultMode = &mode
+	case volume.DownwardAPI != nil && volume.DownwardAPI.DefaultMode == nil:
+		volume.DownwardAPI.DefaultMode = &mode
+	case volume.Projected != nil && volume.Projected.DefaultMode == nil:
+		volume.Projected.DefaultMode = &mode
+	}
+}
+
+func defaultServiceSpec(spec *corev1.ServiceSpec) {
+	if spec.Type == "" {
+		spec.Type = corev1.ServiceTypeClusterIP
+	}
+	if spec.SessionAffinity == "" {
+		spec.SessionAffinity = corev1.ServiceAffinityNone
+	}
+	if spec.Type != corev1.ServiceTypeExternalName && spec.InternalTrafficPolicy == nil {
+		internalTrafficPolicy := corev1.ServiceInternalTrafficPolicyCluster
+		spec.InternalTrafficPolicy = &internalTrafficPolicy
+	}
+	if (spec.Type == corev1.ServiceTypeNodePort || spec.Type == corev1.ServiceTypeLoadBalancer) && spec.ExternalTrafficPolicy == "" {
+		spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeCluster
+	}
+	for i := range spec.Ports {
+		if spec.Ports[i].Protocol == "" {
+			spec.Ports[i].Protocol = corev1.ProtocolTCP
+		}
+		if spec.Ports[i].TargetPort.Type == intstr.Int && spec.Ports[i].TargetPort.IntVal == 0 {
+			spec.Ports[i].TargetPort = intstr.FromInt(int(spec.Ports[i].Port))
+		}
+	}
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
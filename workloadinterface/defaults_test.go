@@ -0,0 +1,138 @@
+package workloadinterface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyDefaultsDeployment(t *testing.T) {
+	deployment := NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "frontend"},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "frontend"}},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "frontend"}},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{
+						"name":  "frontend",
+						"image": "shop/frontend:1.0",
+						"ports": []interface{}{map[string]interface{}{"containerPort": 8080}},
+					}},
+				},
+			},
+		},
+	})
+	require.NoError(t, ApplyDefaults(deployment))
+
+	assert.Equal(t, 1, deployment.GetReplicas())
+	strategy, _ := InspectMap(deployment.GetObject(), "spec", "strategy")
+	assert.Equal(t, map[string]interface{}{"type": "RollingUpdate", "rollingUpdate": map[string]interface{}{"maxUnavailable": "25%", "maxSurge": "25%"}}, strategy)
+	revisionHistoryLimit, _ := InspectMap(deployment.GetObject(), "spec", "revisionHistoryLimit")
+	assert.Equal(t, int64(10), revisionHistoryLimit)
+
+	assert.Equal(t, "default", deployment.GetServiceAccountName())
+	containers, err := deployment.GetContainers()
+	require.NoError(t, err)
+	assert.Equal(t, "IfNotPresent", string(containers[0].ImagePullPolicy))
+	assert.Equal(t, "TCP", string(containers[0].Ports[0].Protocol))
+
+	// no null creationTimestamp or empty status added
+	_, ok := InspectMap(deployment.GetObject(), "metadata", "creationTimestamp")
+	assert.False(t, ok)
+	_, ok = InspectMap(deployment.GetObject(), "spec", "template", "metadata", "creationTimestamp")
+	assert.False(t, ok)
+	_, ok = deployment.GetObject()["status"]
+	assert.False(t, ok)
+}
+
+func TestApplyDefaultsPod(t *testing.T) {
+	pod := NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "frontend"},
+		"spec": map[string]interface{}{
+			"serviceAccountName": "frontend",
+			"containers": []interface{}{map[string]interface{}{
+				"name":      "frontend",
+				"image":     "shop/frontend",
+				"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "500m", "memory": "128Mi"}, "requests": map[string]interface{}{"cpu": "100m"}},
+			}},
+		},
+	})
+	require.NoError(t, ApplyDefaults(pod))
+
+	assert.Equal(t, "frontend", pod.GetServiceAccountName())
+	containers, err := pod.GetContainers()
+	require.NoError(t, err)
+	assert.Equal(t, "Always", string(containers[0].ImagePullPolicy))
+	assert.Equal(t, "100m", containers[0].Resources.Requests.Cpu().String())
+	assert.Equal(t, "128Mi", containers[0].Resources.Requests.Memory().String())
+	restartPolicy, _ := InspectMap(pod.GetObject(), "spec", "restartPolicy")
+	assert.Equal(t, "Always", restartPolicy)
+}
+
+func TestApplyDefaultsCronJob(t *testing.T) {
+	cronJob := NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "CronJob",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "report"},
+		"spec": map[string]interface{}{
+			"schedule": "0 * * * *",
+			"jobTemplate": map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"restartPolicy": "OnFailure",
+				"containers":    []interface{}{map[string]interface{}{"name": "report", "image": "shop/report:2"}},
+			}}}},
+		},
+	})
+	require.NoError(t, ApplyDefaults(cronJob))
+
+	concurrencyPolicy, _ := InspectMap(cronJob.GetObject(), "spec", "concurrencyPolicy")
+	assert.Equal(t, "Allow", concurrencyPolicy)
+	successfulJobsHistoryLimit, _ := InspectMap(cronJob.GetObject(), "spec", "successfulJobsHistoryLimit")
+	assert.Equal(t, int64(3), successfulJobsHistoryLimit)
+	restartPolicy, _ := InspectMap(cronJob.GetObject(), "spec", "jobTemplate", "spec", "template", "spec", "restartPolicy")
+	assert.Equal(t, "OnFailure", restartPolicy)
+	containers, err := cronJob.GetContainers()
+	require.NoError(t, err)
+	assert.Equal(t, "IfNotPresent", string(containers[0].ImagePullPolicy))
+}
+
+func TestApplyDefaultsService(t *testing.T) {
+	service := NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "frontend"},
+		"spec": map[string]interface{}{
+			"type":  "LoadBalancer",
+			"ports": []interface{}{map[string]interface{}{"port": 443, "targetPort": "https"}},
+		},
+	})
+	require.NoError(t, ApplyDefaults(service))
+
+	externalTrafficPolicy, _ := InspectMap(service.GetObject(), "spec", "externalTrafficPolicy")
+	assert.Equal(t, "Cluster", externalTrafficPolicy)
+	ports, _ := InspectMap(service.GetObject(), "spec", "ports")
+	assert.Equal(t, []interface{}{map[string]interface{}{"port": int64(443), "protocol": "TCP", "targetPort": "https"}}, ports)
+}
+
+func TestApplyDefaultsUnknownKind(t *testing.T) {
+	object := map[string]interface{}{
+		"apiVersion": "kubescape.io/v1",
+		"kind":       "RuntimeRuleBinding",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "rules"},
+		"spec":       map[string]interface{}{"rules": []interface{}{"R0001"}},
+	}
+	workload := NewWorkloadObj(object)
+	require.NoError(t, ApplyDefaults(workload))
+	assert.Equal(t, object, workload.GetObject())
+
+	// extensions/v1beta1 is not served anymore, there are no defaults
+	legacy := NewWorkloadObj(map[string]interface{}{"apiVersion": "extensions/v1beta1", "kind": "Deployment", "metadata": map[string]interface{}{"name": "legacy"}})
+	require.NoError(t, ApplyDefaults(legacy))
+	_, ok := InspectMap(legacy.GetObject(), "spec", "replicas")
+	assert.False(t, ok)
+}
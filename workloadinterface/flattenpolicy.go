@@ -0,0 +1,157 @@
+package workloadinterface
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// FlattenForPolicy returns a copy of the object normalized for policy engines (Rego, CEL), so policies do not have to re-derive the Kubernetes defaulting.
+// The numbers are int64/float64 and the managedFields are removed. The defaults the API server sets on the pod spec of workloads (imagePullPolicy, the protocol
+// of container ports, probe thresholds, serviceAccountName...) and on Services are resolved.
+// The envFrom of the containers is expanded to env vars referencing the keys of envSources, the ConfigMaps/Secrets of the namespace (values are never copied).
+// Env vars are sorted by name, explicit env vars take precedence over envFrom as in Kubernetes. envFrom of sources missing from envSources is kept as is
+func FlattenForPolicy(obj IMetadata, envSources ...IMetadata) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj.GetObject())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal '%s', reason: %s", obj.GetName(), err.Error())
+	}
+	flat := map[string]interface{}{}
+	if err := utiljson.Unmarshal(data, &flat); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal '%s', reason: %s", obj.GetName(), err.Error())
+	}
+	RemoveFromMap(flat, "metadata", "managedFields")
+
+	kind := obj.GetKind()
+	if kind == "Service" {
+		err = flattenMapField(flat, []string{"spec"}, &corev1.ServiceSpec{}, func(spec interface{}) {
+			defaultServiceSpec(spec.(*corev1.ServiceSpec))
+		})
+	} else if _, ok := InspectMap(flat, append(PodSpec(kind), "containers")...); ok {
+		sources := newEnvFromSources(obj.GetNamespace(), envSources)
+		err = flattenMapField(flat, PodSpec(kind), &corev1.PodSpec{}, func(spec interface{}) {
+			podSpec := spec.(*corev1.PodSpec)
+			defaultPodSpec(podSpec)
+			expandPodEnvFrom(podSpec, sources)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return flat, nil
+}
+
+// flattenMapField decodes the field of the object to the typed value, applies the defaults and sets the field back
+func flattenMapField(obj map[string]interface{}, path []string, typed interface{}, setDefaults func(interface{})) error {
+	field, _ := InspectMap(obj, path...)
+	data, err := json.Marshal(field)
+	if err != nil {
+		return fmt.Errorf("failed to marshal '%s', reason: %s", strings.Join(path, "."), err.Error())
+	}
+	if err := json.Unmarshal(data, typed); err != nil {
+		return fmt.Errorf("failed to unmarshal '%s', reason: %s", strings.Join(path, "."), err.Error())
+	}
+	setDefaults(typed)
+	defaulted, err := runtime.DefaultUnstructuredConverter.ToUnstructured(typed)
+	if err != nil {
+		return fmt.Errorf("failed to convert '%s', reason: %s", strings.Join(path, "."), err.Error())
+	}
+	SetInMap(obj, path[:len(path)-1], path[len(path)-1], defaulted)
+	return nil
+}
+
+// envFromSources are the keys of the ConfigMaps and Secrets, by kind and name
+type envFromSources map[string][]string
+
+func newEnvFromSources(namespace string, objects []IMetadata) envFromSources {
+	sources := envFromSources{}
+	for _, obj := range objects {
+		if obj.GetNamespace() != namespace {
+			continue
+		}
+		var fields []string
+		switch obj.GetKind() {
+		case ConfigMapKind:
+			fields = []string{"data", "binaryData"}
+		case SecretKind:
+			fields = []string{"data", "stringData"}
+		default:
+			continue
+		}
+		var keys []string
+		for _, field := range fields {
+			if data, ok := InspectMap(obj.GetObject(), field); ok {
+				if m, ok := data.(map[string]interface{}); ok {
+					for key := range m {
+						keys = append(keys, key)
+					}
+				}
+			}
+		}
+		sort.Strings(keys)
+		sources[obj.GetKind()+"/"+obj.GetName()] = keys
+	}
+	return sources
+}
+
+func expandPodEnvFrom(podSpec *corev1.PodSpec, sources envFromSources) {
+	for i := range podSpec.InitContainers {
+		expandEnvFrom(&podSpec.InitContainers[i], sources)
+	}
+	for i := range podSpec.Containers {
+		expandEnvFrom(&podSpec.Containers[i], sources)
+	}
+	for i := range podSpec.EphemeralContainers {
+		expandEnvFrom((*corev1.Container)(&podSpec.EphemeralContainers[i].EphemeralContainerCommon), sources)
+	}
+}
+
+// expandEnvFrom adds the env vars of the envFrom sources that are known and sorts the env vars by name. The envFrom of unknown sources is kept
+func expandEnvFrom(container *corev1.Container, sources envFromSources) {
+	env := map[string]corev1.EnvVar{}
+	var unresolved []corev1.EnvFromSource
+	for _, envFrom := range container.EnvFrom {
+		kind, name, optional := ConfigMapKind, "", (*bool)(nil)
+		switch {
+		case envFrom.ConfigMapRef != nil:
+			name, optional = envFrom.ConfigMapRef.Name, envFrom.ConfigMapRef.Optional
+		case envFrom.SecretRef != nil:
+			kind, name, optional = SecretKind, envFrom.SecretRef.Name, envFrom.SecretRef.Optional
+		}
+		keys, ok := sources[kind+"/"+name]
+		if !ok {
+			unresolved = append(unresolved, envFrom)
+			continue
+		}
+		for _, key := range keys {
+			// keys that are not valid env var names are skipped by the kubelet
+			if strings.Contains(key, "=") {
+				continue
+			}
+			envVar := corev1.EnvVar{Name: envFrom.Prefix + key, ValueFrom: &corev1.EnvVarSource{}}
+			if kind == ConfigMapKind {
+				envVar.ValueFrom.ConfigMapKeyRef = &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key, Optional: optional}
+			} else {
+				envVar.ValueFrom.SecretKeyRef = &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key, Optional: optional}
+			}
+			env[envVar.Name] = envVar
+		}
+	}
+	for _, envVar := range container.Env {
+		env[envVar.Name] = envVar
+	}
+	container.EnvFrom = unresolved
+	container.Env = make([]corev1.EnvVar, 0, len(env))
+	for _, envVar := range env {
+		container.Env = append(container.Env, envVar)
+	}
+	sort.Slice(container.Env, func(i, j int) bool { return container.Env[i].Name < container.Env[j].Name })
+	if len(container.Env) == 0 {
+		container.Env = nil
+	}
+}
@@ -0,0 +1,155 @@
+package workloadinterface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlattenForPolicy(t *testing.T) {
+	deployment := NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"namespace":     "shop",
+			"name":          "frontend",
+			"managedFields": []interface{}{map[string]interface{}{"manager": "kubectl"}},
+		},
+		"spec": map[string]interface{}{
+			"replicas": 2,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"hostNetwork": true,
+					"containers": []interface{}{map[string]interface{}{
+						"name":  "frontend",
+						"image": "registry.io:5000/shop/frontend",
+						"ports": []interface{}{map[string]interface{}{"containerPort": 8080}},
+						"envFrom": []interface{}{
+							map[string]interface{}{"configMapRef": map[string]interface{}{"name": "settings"}},
+							map[string]interface{}{"secretRef": map[string]interface{}{"name": "db"}, "prefix": "DB_"},
+							map[string]interface{}{"configMapRef": map[string]interface{}{"name": "unknown"}},
+						},
+						"env": []interface{}{
+							map[string]interface{}{"name": "MODE", "value": "production"},
+							map[string]interface{}{"name": "POD", "valueFrom": map[string]interface{}{"fieldRef": map[string]interface{}{"fieldPath": "metadata.name"}}},
+						},
+						"livenessProbe": map[string]interface{}{"httpGet": map[string]interface{}{"path": "/healthz", "port": 8080}},
+					}},
+					"initContainers": []interface{}{map[string]interface{}{
+						"name":  "migrate",
+						"image": "shop/migrate:1.2",
+					}},
+					"volumes": []interface{}{map[string]interface{}{"name": "tls", "secret": map[string]interface{}{"secretName": "tls"}}},
+				},
+			},
+		},
+	})
+	settings := NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "settings"},
+		"data":       map[string]interface{}{"MODE": "debug", "REGION": "eu", "in=valid": "x"},
+	})
+	db := NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "db"},
+		"data":       map[string]interface{}{"PASSWORD": "c2VjcmV0"},
+	})
+	otherNamespace := NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"namespace": "other", "name": "unknown"},
+		"data":       map[string]interface{}{"KEY": "value"},
+	})
+
+	flat, err := FlattenForPolicy(deployment, settings, db, otherNamespace)
+	require.NoError(t, err)
+
+	_, ok := InspectMap(flat, "metadata", "managedFields")
+	assert.False(t, ok)
+	replicas, _ := InspectMap(flat, "spec", "replicas")
+	assert.Equal(t, int64(2), replicas)
+
+	podSpec, _ := InspectMap(flat, "spec", "template", "spec")
+	assert.Equal(t, "default", podSpec.(map[string]interface{})["serviceAccountName"])
+	assert.Equal(t, "Always", podSpec.(map[string]interface{})["restartPolicy"])
+	assert.Equal(t, "ClusterFirst", podSpec.(map[string]interface{})["dnsPolicy"])
+	assert.Equal(t, int64(30), podSpec.(map[string]interface{})["terminationGracePeriodSeconds"])
+
+	containers, _ := InspectMap(podSpec, "containers")
+	container := containers.([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "Always", container["imagePullPolicy"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"containerPort": int64(8080), "hostPort": int64(8080), "protocol": "TCP"}}, container["ports"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "DB_PASSWORD", "valueFrom": map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": "db", "key": "PASSWORD"}}},
+		map[string]interface{}{"name": "MODE", "value": "production"},
+		map[string]interface{}{"name": "POD", "valueFrom": map[string]interface{}{"fieldRef": map[string]interface{}{"apiVersion": "v1", "fieldPath": "metadata.name"}}},
+		map[string]interface{}{"name": "REGION", "valueFrom": map[string]interface{}{"configMapKeyRef": map[string]interface{}{"name": "settings", "key": "REGION"}}},
+	}, container["env"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"configMapRef": map[string]interface{}{"name": "unknown"}}}, container["envFrom"])
+	probe := container["livenessProbe"].(map[string]interface{})
+	assert.Equal(t, int64(1), probe["timeoutSeconds"])
+	assert.Equal(t, int64(3), probe["failureThreshold"])
+	scheme, _ := InspectMap(probe, "httpGet", "scheme")
+	assert.Equal(t, "HTTP", scheme)
+
+	initContainers, _ := InspectMap(podSpec, "initContainers")
+	assert.Equal(t, "IfNotPresent", initContainers.([]interface{})[0].(map[string]interface{})["imagePullPolicy"])
+
+	mode, _ := InspectMap(podSpec.(map[string]interface{})["volumes"].([]interface{})[0], "secret", "defaultMode")
+	assert.Equal(t, int64(420), mode)
+
+	// the object is not modified
+	_, ok = InspectMap(deployment.GetObject(), "metadata", "managedFields")
+	assert.True(t, ok)
+	_, ok = InspectMap(deployment.GetObject(), "spec", "template", "spec", "serviceAccountName")
+	assert.False(t, ok)
+}
+
+func TestFlattenForPolicyService(t *testing.T) {
+	service := NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "frontend"},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"app": "frontend"},
+			"ports":    []interface{}{map[string]interface{}{"port": 80}},
+		},
+	})
+	flat, err := FlattenForPolicy(service)
+	require.NoError(t, err)
+
+	serviceType, _ := InspectMap(flat, "spec", "type")
+	assert.Equal(t, "ClusterIP", serviceType)
+	ports, _ := InspectMap(flat, "spec", "ports")
+	assert.Equal(t, []interface{}{map[string]interface{}{"port": int64(80), "protocol": "TCP", "targetPort": int64(80)}}, ports)
+}
+
+func TestFlattenForPolicyNoPodSpec(t *testing.T) {
+	namespace := NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata":   map[string]interface{}{"name": "shop"},
+		"spec":       map[string]interface{}{"finalizers": []interface{}{"kubernetes"}},
+	})
+	flat, err := FlattenForPolicy(namespace)
+	require.NoError(t, err)
+	assert.Equal(t, namespace.GetObject(), flat)
+}
+
+func TestDefaultImagePullPolicy(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                          "Always",
+		"nginx:latest":                   "Always",
+		"nginx:1.25":                     "IfNotPresent",
+		"registry.io:5000/nginx":         "Always",
+		"registry.io:5000/nginx:1.25":    "IfNotPresent",
+		"nginx@sha256:0123456789abcdef":  "IfNotPresent",
+		"nginx:latest@sha256:0123456789": "Always",
+	}
+	for image, expected := range tests {
+		assert.Equal(t, expected, string(defaultImagePullPolicy(image)), image)
+	}
+}
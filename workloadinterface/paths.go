@@ -0,0 +1,258 @@
+package workloadinterface
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PathPodSpec is replaced by the path of the pod spec of the kind of the object, e.g. spec.jobTemplate.spec.template.spec for a CronJob. See PodSpec
+	PathPodSpec = "$podSpec"
+	// PathPodMetadata is replaced by the path of the pod metadata of the kind of the object. See PodMetadata
+	PathPodMetadata = "$podMetadata"
+)
+
+// Frequently used paths, the pod paths are resolved according to the kind of the object
+const (
+	PathName                         = "metadata.name"
+	PathNamespace                    = "metadata.namespace"
+	PathLabels                       = "metadata.labels"
+	PathAnnotations                  = "metadata.annotations"
+	PathOwnerReferences              = "metadata.ownerReferences"
+	PathReplicas                     = "spec.replicas"
+	PathPodLabels                    = PathPodMetadata + ".labels"
+	PathPodAnnotations               = PathPodMetadata + ".annotations"
+	PathNodeName                     = PathPodSpec + ".nodeName"
+	PathNodeSelector                 = PathPodSpec + ".nodeSelector"
+	PathServiceAccountName           = PathPodSpec + ".serviceAccountName"
+	PathAutomountServiceAccountToken = PathPodSpec + ".automountServiceAccountToken"
+	PathHostNetwork                  = PathPodSpec + ".hostNetwork"
+	PathHostPID                      = PathPodSpec + ".hostPID"
+	PathHostIPC                      = PathPodSpec + ".hostIPC"
+	PathPodSecurityContext           = PathPodSpec + ".securityContext"
+	PathContainers                   = PathPodSpec + ".containers"
+	PathInitContainers               = PathPodSpec + ".initContainers"
+	PathEphemeralContainers          = PathPodSpec + ".ephemeralContainers"
+	PathVolumes                      = PathPodSpec + ".volumes"
+	PathImagePullSecrets             = PathPodSpec + ".imagePullSecrets"
+)
+
+// SplitPath splits the path into its fields. The fields are separated by dots, a dot of a field is escaped with a backslash,
+// e.g. metadata.labels.app\.kubernetes\.io/name. The indexes of the list items are numeric fields, e.g. spec.containers.0.image
+func SplitPath(path string) []string {
+	if path == "" {
+		return []string{}
+	}
+	fields := []string{}
+	var field strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			field.WriteByte('.')
+			i++
+		case path[i] == '.':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(path[i])
+		}
+	}
+	return append(fields, field.String())
+}
+
+// ResolvePath splits the path and replaces its PathPodSpec or PathPodMetadata prefix by the pod path of the kind of the object
+func ResolvePath(obj map[string]interface{}, path string) []string {
+	fields := SplitPath(path)
+	if len(fields) == 0 {
+		return fields
+	}
+	kind, _ := obj["kind"].(string)
+	switch fields[0] {
+	case PathPodSpec:
+		return append(PodSpec(kind), fields[1:]...)
+	case PathPodMetadata:
+		return append(PodMetadata(kind), fields[1:]...)
+	}
+	return fields
+}
+
+// GetNested returns the value of the path in the object (see ResolvePath) and whether it was found, a null value is not found.
+// An error is returned if a parent of the value is neither a map nor a list, e.g. spec.replicas.value
+func GetNested(obj map[string]interface{}, path string) (interface{}, bool, error) {
+	var value interface{} = obj
+	fields := ResolvePath(obj, path)
+	for i, field := range fields {
+		switch parent := value.(type) {
+		case map[string]interface{}:
+			child, ok := parent[field]
+			if !ok {
+				return nil, false, nil
+			}
+			value = child
+		case []interface{}:
+			index, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, false, fmt.Errorf("%s: '%s' is not the index of a list item", path, field)
+			}
+			if index < 0 || index >= len(parent) {
+				return nil, false, nil
+			}
+			value = parent[index]
+		case nil:
+			return nil, false, nil
+		default:
+			return nil, false, fmt.Errorf("%s: the value of '%s' is of the type %T, expected a map or a list", path, strings.Join(fields[:i], "."), value)
+		}
+	}
+	return value, value != nil, nil
+}
+
+// GetNestedString returns the string of the path in the object, see GetNested
+func GetNestedString(obj map[string]interface{}, path string) (string, bool, error) {
+	value, found, err := GetNested(obj, path)
+	if !found || err != nil {
+		return "", found, err
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", true, typeError(path, value, "string")
+	}
+	return s, true, nil
+}
+
+// GetNestedBool returns the bool of the path in the object, see GetNested
+func GetNestedBool(obj map[string]interface{}, path string) (bool, bool, error) {
+	value, found, err := GetNested(obj, path)
+	if !found || err != nil {
+		return false, found, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, true, typeError(path, value, "bool")
+	}
+	return b, true, nil
+}
+
+// GetNestedInt64 returns the integer of the path in the object, see GetNested. The JSON numbers decoded as float64 must be integers
+func GetNestedInt64(obj map[string]interface{}, path string) (int64, bool, error) {
+	value, found, err := GetNested(obj, path)
+	if !found || err != nil {
+		return 0, found, err
+	}
+	switch n := value.(type) {
+	case int64:
+		return n, true, nil
+	case int:
+		return int64(n), true, nil
+	case int32:
+		return int64(n), true, nil
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, true, fmt.Errorf("%s: %v is not an integer", path, n)
+		}
+		return int64(n), true, nil
+	case json.Number:
+		i, err := n.Int64()
+		if err != nil {
+			return 0, true, fmt.Errorf("%s: %w", path, err)
+		}
+		return i, true, nil
+	}
+	return 0, true, typeError(path, value, "integer")
+}
+
+// GetNestedFloat64 returns the number of the path in the object, see GetNested
+func GetNestedFloat64(obj map[string]interface{}, path string) (float64, bool, error) {
+	value, found, err := GetNested(obj, path)
+	if !found || err != nil {
+		return 0, found, err
+	}
+	switch n := value.(type) {
+	case float64:
+		return n, true, nil
+	case int64:
+		return float64(n), true, nil
+	case int:
+		return float64(n), true, nil
+	case json.Number:
+		f, err := n.Float64()
+		if err != nil {
+			return 0, true, fmt.Errorf("%s: %w", path, err)
+		}
+		return f, true, nil
+	}
+	return 0, true, typeError(path, value, "number")
+}
+
+// GetNestedMap returns the map of the path in the object, not copied. See GetNested
+func GetNestedMap(obj map[string]interface{}, path string) (map[string]interface{}, bool, error) {
+	value, found, err := GetNested(obj, path)
+	if !found || err != nil {
+		return nil, found, err
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, true, typeError(path, value, "map")
+	}
+	return m, true, nil
+}
+
+// GetNestedStringMap returns the map of strings of the path in the object, e.g. PathLabels. See GetNested
+func GetNestedStringMap(obj map[string]interface{}, path string) (map[string]string, bool, error) {
+	m, found, err := GetNestedMap(obj, path)
+	if !found || err != nil {
+		return nil, found, err
+	}
+	stringMap := make(map[string]string, len(m))
+	for key, value := range m {
+		s, ok := value.(string)
+		if !ok {
+			return nil, true, typeError(path+"."+key, value, "string")
+		}
+		stringMap[key] = s
+	}
+	return stringMap, true, nil
+}
+
+// GetNestedSlice returns the list of the path in the object, not copied. See GetNested
+func GetNestedSlice(obj map[string]interface{}, path string) ([]interface{}, bool, error) {
+	value, found, err := GetNested(obj, path)
+	if !found || err != nil {
+		return nil, found, err
+	}
+	s, ok := value.([]interface{})
+	if !ok {
+		return nil, true, typeError(path, value, "list")
+	}
+	return s, true, nil
+}
+
+// GetNestedStringSlice returns the list of strings of the path in the object, see GetNested
+func GetNestedStringSlice(obj map[string]interface{}, path string) ([]string, bool, error) {
+	value, found, err := GetNested(obj, path)
+	if !found || err != nil {
+		return nil, found, err
+	}
+	switch s := value.(type) {
+	case []string:
+		return s, true, nil
+	case []interface{}:
+		strs := make([]string, 0, len(s))
+		for i := range s {
+			str, ok := s[i].(string)
+			if !ok {
+				return nil, true, typeError(path+"."+strconv.Itoa(i), s[i], "string")
+			}
+			strs = append(strs, str)
+		}
+		return strs, true, nil
+	}
+	return nil, true, typeError(path, value, "list")
+}
+
+func typeError(path string, value interface{}, expected string) error {
+	return fmt.Errorf("%s: the value is of the type %T, expected a %s", path, value, expected)
+}
@@ -0,0 +1,109 @@
+package workloadinterface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitPath(t *testing.T) {
+	assert.Equal(t, []string{}, SplitPath(""))
+	assert.Equal(t, []string{"spec", "containers", "0", "image"}, SplitPath("spec.containers.0.image"))
+	assert.Equal(t, []string{"metadata", "labels", "app.kubernetes.io/name"}, SplitPath(`metadata.labels.app\.kubernetes\.io/name`))
+}
+
+func TestResolvePath(t *testing.T) {
+	assert.Equal(t, []string{"spec", "nodeName"}, ResolvePath(map[string]interface{}{"kind": "Pod"}, PathNodeName))
+	assert.Equal(t, []string{"spec", "template", "spec", "nodeName"}, ResolvePath(map[string]interface{}{"kind": "Deployment"}, PathNodeName))
+	assert.Equal(t, []string{"spec", "jobTemplate", "spec", "template", "metadata", "labels"}, ResolvePath(map[string]interface{}{"kind": "CronJob"}, PathPodLabels))
+	assert.Equal(t, []string{"spec", "replicas"}, ResolvePath(map[string]interface{}{"kind": "CronJob"}, PathReplicas))
+}
+
+func TestGetNested(t *testing.T) {
+	cronJob, err := NewWorkload([]byte(`{"apiVersion": "batch/v1", "kind": "CronJob", "metadata": {"name": "backup", "labels": {"app.kubernetes.io/name": "backup"}},
+		"spec": {"schedule": "0 * * * *", "jobTemplate": {"spec": {"backoffLimit": 3, "template": {"metadata": {"labels": {"app": "backup"}},
+		"spec": {"nodeName": "node-1", "hostNetwork": true, "nodeSelector": null, "containers": [{"name": "backup", "image": "backup:1", "args": ["--all"]}]}}}}}}`))
+	require.NoError(t, err)
+	obj := cronJob.GetObject()
+
+	nodeName, found, err := GetNestedString(obj, PathNodeName)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "node-1", nodeName)
+
+	hostNetwork, found, err := GetNestedBool(obj, PathHostNetwork)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.True(t, hostNetwork)
+
+	backoffLimit, found, err := GetNestedInt64(obj, "spec.jobTemplate.spec.backoffLimit")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(3), backoffLimit)
+
+	backoffFloat, _, err := GetNestedFloat64(obj, "spec.jobTemplate.spec.backoffLimit")
+	require.NoError(t, err)
+	assert.Equal(t, float64(3), backoffFloat)
+
+	image, found, err := GetNestedString(obj, PathContainers+".0.image")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "backup:1", image)
+
+	args, found, err := GetNestedStringSlice(obj, PathContainers+".0.args")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []string{"--all"}, args)
+
+	containers, _, err := GetNestedSlice(obj, PathContainers)
+	require.NoError(t, err)
+	assert.Len(t, containers, 1)
+
+	podLabels, found, err := GetNestedStringMap(obj, PathPodLabels)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, map[string]string{"app": "backup"}, podLabels)
+
+	name, _, err := GetNestedString(obj, `metadata.labels.app\.kubernetes\.io/name`)
+	require.NoError(t, err)
+	assert.Equal(t, "backup", name)
+
+	template, found, err := GetNestedMap(obj, PathPodMetadata)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Contains(t, template, "labels")
+
+	// missing, null and out of range values are not found
+	for _, path := range []string{PathServiceAccountName, PathNodeSelector, PathContainers + ".1.image", PathInitContainers + ".0.image", PathAnnotations + ".key"} {
+		_, found, err := GetNested(obj, path)
+		assert.NoError(t, err, path)
+		assert.False(t, found, path)
+	}
+
+	// unexpected types are errors
+	_, found, err = GetNestedString(obj, PathHostNetwork)
+	assert.Error(t, err)
+	assert.True(t, found)
+	_, _, err = GetNestedBool(obj, PathNodeName)
+	assert.Error(t, err)
+	_, _, err = GetNestedInt64(obj, PathNodeName)
+	assert.Error(t, err)
+	_, _, err = GetNested(obj, PathNodeName+".value")
+	assert.Error(t, err)
+	_, _, err = GetNested(obj, PathContainers+".first")
+	assert.Error(t, err)
+	_, _, err = GetNestedStringMap(obj, PathPodMetadata)
+	assert.Error(t, err)
+	_, _, err = GetNestedStringSlice(obj, PathContainers)
+	assert.Error(t, err)
+
+	// the values set with the typed setters
+	deployment := NewWorkloadObj(map[string]interface{}{"kind": "Deployment", "spec": map[string]interface{}{"replicas": 2}})
+	replicas, found, err := GetNestedInt64(deployment.GetObject(), PathReplicas)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(2), replicas)
+	_, _, err = GetNestedInt64(map[string]interface{}{"spec": map[string]interface{}{"replicas": 1.5}}, PathReplicas)
+	assert.Error(t, err)
+}
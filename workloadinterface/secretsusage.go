@@ -0,0 +1,199 @@
+package workloadinterface
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	SecretKind              = "Secret"
+	ConfigMapKind           = "ConfigMap"
+	SecretProviderClassKind = "SecretProviderClass"
+
+	// SecretsStoreCSIDriver is the driver of the Secrets Store CSI volumes, the volume attributes reference a SecretProviderClass
+	SecretsStoreCSIDriver = "secrets-store.csi.k8s.io"
+)
+
+const (
+	UsageSourceEnv             = "env"
+	UsageSourceEnvFrom         = "envFrom"
+	UsageSourceVolume          = "volume"
+	UsageSourceProjectedVolume = "projectedVolume"
+	UsageSourceImagePullSecret = "imagePullSecret"
+	UsageSourceCSI             = "csi"
+)
+
+// ConfigurationReference is a reference of a workload to a Secret, ConfigMap or SecretProviderClass of its namespace
+type ConfigurationReference struct {
+	// Kind is one of Secret/ConfigMap/SecretProviderClass
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Source is how the workload consumes the resource, one of env/envFrom/volume/projectedVolume/imagePullSecret/csi
+	Source string `json:"source"`
+	// Container is the container consuming the resource, empty for imagePullSecrets and volumes no container mounts
+	Container string `json:"container,omitempty"`
+	// Key is the key of the env var reference
+	Key      string `json:"key,omitempty"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// ConfigurationUsage is a reference of a workload to a Secret/ConfigMap/SecretProviderClass
+type ConfigurationUsage struct {
+	WorkloadNamespace string `json:"workloadNamespace"`
+	WorkloadKind      string `json:"workloadKind"`
+	WorkloadName      string `json:"workloadName"`
+	ConfigurationReference
+}
+
+// SecretsUsageGraph maps the Secrets/ConfigMaps/SecretProviderClasses to the workloads consuming them, and the workloads to the resources they consume
+type SecretsUsageGraph struct {
+	consumers  map[string][]ConfigurationUsage
+	references map[string][]ConfigurationReference
+}
+
+// NewSecretsUsageGraph returns the usage graph of the workloads. Objects without a pod spec are skipped
+func NewSecretsUsageGraph(workloads []IWorkload) (*SecretsUsageGraph, error) {
+	graph := &SecretsUsageGraph{
+		consumers:  map[string][]ConfigurationUsage{},
+		references: map[string][]ConfigurationReference{},
+	}
+	for i := range workloads {
+		if err := graph.AddWorkload(workloads[i]); err != nil {
+			return nil, err
+		}
+	}
+	return graph, nil
+}
+
+// AddWorkload adds the references of the workload to the graph
+func (graph *SecretsUsageGraph) AddWorkload(workload IWorkload) error {
+	if _, ok := InspectWorkload(workload.GetObject(), PodSpec(workload.GetKind())...); !ok {
+		return nil
+	}
+	podSpec, err := workload.GetPodSpec()
+	if err != nil {
+		return err
+	}
+	references := GetConfigurationReferences(podSpec)
+	graph.references[usageGraphKey(workload.GetKind(), workload.GetNamespace(), workload.GetName())] = references
+	for _, reference := range references {
+		key := usageGraphKey(reference.Kind, workload.GetNamespace(), reference.Name)
+		graph.consumers[key] = append(graph.consumers[key], ConfigurationUsage{
+			WorkloadNamespace:      workload.GetNamespace(),
+			WorkloadKind:           workload.GetKind(),
+			WorkloadName:           workload.GetName(),
+			ConfigurationReference: reference,
+		})
+	}
+	return nil
+}
+
+// GetConsumers returns the references of the workloads to the Secret/ConfigMap/SecretProviderClass
+func (graph *SecretsUsageGraph) GetConsumers(kind, namespace, name string) []ConfigurationUsage {
+	return graph.consumers[usageGraphKey(kind, namespace, name)]
+}
+
+// GetReferences returns the Secrets/ConfigMaps/SecretProviderClasses the workload consumes
+func (graph *SecretsUsageGraph) GetReferences(kind, namespace, name string) []ConfigurationReference {
+	return graph.references[usageGraphKey(kind, namespace, name)]
+}
+
+// IsConsumed returns true if a workload consumes the Secret/ConfigMap/SecretProviderClass
+func (graph *SecretsUsageGraph) IsConsumed(kind, namespace, name string) bool {
+	return len(graph.consumers[usageGraphKey(kind, namespace, name)]) > 0
+}
+
+func usageGraphKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// GetConfigurationReferences returns the references of the pod spec to Secrets, ConfigMaps and SecretProviderClasses:
+// env and envFrom of the containers and init containers, secret/configMap/projected/CSI volumes and imagePullSecrets
+func GetConfigurationReferences(podSpec *corev1.PodSpec) []ConfigurationReference {
+	references := []ConfigurationReference{}
+
+	containers := append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...)
+	mountedBy := map[string][]string{}
+	for i := range containers {
+		for _, volumeMount := range containers[i].VolumeMounts {
+			mountedBy[volumeMount.Name] = append(mountedBy[volumeMount.Name], containers[i].Name)
+		}
+	}
+
+	for i := range containers {
+		container := &containers[i]
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil {
+				references = append(references, ConfigurationReference{Kind: SecretKind, Name: envFrom.SecretRef.Name, Source: UsageSourceEnvFrom, Container: container.Name, Optional: isOptional(envFrom.SecretRef.Optional)})
+			}
+			if envFrom.ConfigMapRef != nil {
+				references = append(references, ConfigurationReference{Kind: ConfigMapKind, Name: envFrom.ConfigMapRef.Name, Source: UsageSourceEnvFrom, Container: container.Name, Optional: isOptional(envFrom.ConfigMapRef.Optional)})
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				references = append(references, ConfigurationReference{Kind: SecretKind, Name: ref.Name, Source: UsageSourceEnv, Container: container.Name, Key: ref.Key, Optional: isOptional(ref.Optional)})
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				references = append(references, ConfigurationReference{Kind: ConfigMapKind, Name: ref.Name, Source: UsageSourceEnv, Container: container.Name, Key: ref.Key, Optional: isOptional(ref.Optional)})
+			}
+		}
+	}
+
+	for i := range podSpec.Volumes {
+		for _, reference := range volumeConfigurationReferences(&podSpec.Volumes[i]) {
+			containerNames := mountedBy[podSpec.Volumes[i].Name]
+			if len(containerNames) == 0 {
+				references = append(references, reference)
+				continue
+			}
+			for _, containerName := range containerNames {
+				reference.Container = containerName
+				references = append(references, reference)
+			}
+		}
+	}
+
+	for _, imagePullSecret := range podSpec.ImagePullSecrets {
+		references = append(references, ConfigurationReference{Kind: SecretKind, Name: imagePullSecret.Name, Source: UsageSourceImagePullSecret})
+	}
+	return references
+}
+
+func volumeConfigurationReferences(volume *corev1.Volume) []ConfigurationReference {
+	var references []ConfigurationReference
+	if volume.Secret != nil {
+		references = append(references, ConfigurationReference{Kind: SecretKind, Name: volume.Secret.SecretName, Source: UsageSourceVolume, Optional: isOptional(volume.Secret.Optional)})
+	}
+	if volume.ConfigMap != nil {
+		references = append(references, ConfigurationReference{Kind: ConfigMapKind, Name: volume.ConfigMap.Name, Source: UsageSourceVolume, Optional: isOptional(volume.ConfigMap.Optional)})
+	}
+	if volume.Projected != nil {
+		for _, source := range volume.Projected.Sources {
+			if source.Secret != nil {
+				references = append(references, ConfigurationReference{Kind: SecretKind, Name: source.Secret.Name, Source: UsageSourceProjectedVolume, Optional: isOptional(source.Secret.Optional)})
+			}
+			if source.ConfigMap != nil {
+				references = append(references, ConfigurationReference{Kind: ConfigMapKind, Name: source.ConfigMap.Name, Source: UsageSourceProjectedVolume, Optional: isOptional(source.ConfigMap.Optional)})
+			}
+		}
+	}
+	if volume.CSI != nil {
+		if volume.CSI.Driver == SecretsStoreCSIDriver {
+			if secretProviderClass := volume.CSI.VolumeAttributes["secretProviderClass"]; secretProviderClass != "" {
+				references = append(references, ConfigurationReference{Kind: SecretProviderClassKind, Name: secretProviderClass, Source: UsageSourceCSI})
+			}
+		}
+		// the credentials of the CSI driver, e.g. the service principal of the Azure Key Vault provider
+		if volume.CSI.NodePublishSecretRef != nil {
+			references = append(references, ConfigurationReference{Kind: SecretKind, Name: volume.CSI.NodePublishSecretRef.Name, Source: UsageSourceCSI})
+		}
+	}
+	return references
+}
+
+func isOptional(optional *bool) bool {
+	return optional != nil && *optional
+}
@@ -0,0 +1,89 @@
+package workloadinterface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSecretsUsageTestDeployment() *Workload {
+	return NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "frontend"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"imagePullSecrets": []interface{}{map[string]interface{}{"name": "registry"}},
+					"initContainers": []interface{}{map[string]interface{}{
+						"name":    "migrate",
+						"envFrom": []interface{}{map[string]interface{}{"secretRef": map[string]interface{}{"name": "db"}}},
+					}},
+					"containers": []interface{}{map[string]interface{}{
+						"name": "frontend",
+						"env": []interface{}{
+							map[string]interface{}{"name": "API_KEY", "valueFrom": map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": "api", "key": "key", "optional": true}}},
+							map[string]interface{}{"name": "MODE", "valueFrom": map[string]interface{}{"configMapKeyRef": map[string]interface{}{"name": "settings", "key": "mode"}}},
+						},
+						"volumeMounts": []interface{}{
+							map[string]interface{}{"name": "tls", "mountPath": "/tls"},
+							map[string]interface{}{"name": "vault", "mountPath": "/vault"},
+						},
+					}},
+					"volumes": []interface{}{
+						map[string]interface{}{"name": "tls", "secret": map[string]interface{}{"secretName": "frontend-tls"}},
+						map[string]interface{}{"name": "bundle", "projected": map[string]interface{}{"sources": []interface{}{
+							map[string]interface{}{"configMap": map[string]interface{}{"name": "ca-bundle"}},
+							map[string]interface{}{"serviceAccountToken": map[string]interface{}{"path": "token"}},
+						}}},
+						map[string]interface{}{"name": "vault", "csi": map[string]interface{}{
+							"driver":               SecretsStoreCSIDriver,
+							"volumeAttributes":     map[string]interface{}{"secretProviderClass": "azure-kv"},
+							"nodePublishSecretRef": map[string]interface{}{"name": "kv-credentials"},
+						}},
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestGetConfigurationReferences(t *testing.T) {
+	podSpec, err := newSecretsUsageTestDeployment().GetPodSpec()
+	require.NoError(t, err)
+
+	assert.Equal(t, []ConfigurationReference{
+		{Kind: SecretKind, Name: "db", Source: UsageSourceEnvFrom, Container: "migrate"},
+		{Kind: SecretKind, Name: "api", Source: UsageSourceEnv, Container: "frontend", Key: "key", Optional: true},
+		{Kind: ConfigMapKind, Name: "settings", Source: UsageSourceEnv, Container: "frontend", Key: "mode"},
+		{Kind: SecretKind, Name: "frontend-tls", Source: UsageSourceVolume, Container: "frontend"},
+		{Kind: ConfigMapKind, Name: "ca-bundle", Source: UsageSourceProjectedVolume},
+		{Kind: SecretProviderClassKind, Name: "azure-kv", Source: UsageSourceCSI, Container: "frontend"},
+		{Kind: SecretKind, Name: "kv-credentials", Source: UsageSourceCSI, Container: "frontend"},
+		{Kind: SecretKind, Name: "registry", Source: UsageSourceImagePullSecret},
+	}, GetConfigurationReferences(podSpec))
+}
+
+func TestSecretsUsageGraph(t *testing.T) {
+	secret := NewWorkloadObj(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"namespace": "shop", "name": "db"},
+	})
+	graph, err := NewSecretsUsageGraph([]IWorkload{newSecretsUsageTestDeployment(), secret})
+	require.NoError(t, err)
+
+	assert.Equal(t, []ConfigurationUsage{{
+		WorkloadNamespace:      "shop",
+		WorkloadKind:           "Deployment",
+		WorkloadName:           "frontend",
+		ConfigurationReference: ConfigurationReference{Kind: SecretKind, Name: "db", Source: UsageSourceEnvFrom, Container: "migrate"},
+	}}, graph.GetConsumers(SecretKind, "shop", "db"))
+	assert.True(t, graph.IsConsumed(SecretKind, "shop", "registry"))
+	assert.False(t, graph.IsConsumed(SecretKind, "other", "db"))
+	assert.False(t, graph.IsConsumed(ConfigMapKind, "shop", "db"))
+
+	assert.Len(t, graph.GetReferences("Deployment", "shop", "frontend"), 8)
+	assert.Empty(t, graph.GetReferences("Secret", "shop", "db"))
+}
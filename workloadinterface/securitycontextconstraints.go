@@ -0,0 +1,88 @@
+package workloadinterface
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// SecurityContextConstraintsKind is the kind of the OpenShift SecurityContextConstraints (security.openshift.io/v1), the OpenShift admission policy of pods
+	SecurityContextConstraintsKind = "SecurityContextConstraints"
+
+	SecurityContextConstraintsStrategyRunAsAny         = "RunAsAny"
+	SecurityContextConstraintsStrategyMustRunAs        = "MustRunAs"
+	SecurityContextConstraintsStrategyMustRunAsRange   = "MustRunAsRange"
+	SecurityContextConstraintsStrategyMustRunAsNonRoot = "MustRunAsNonRoot"
+)
+
+// SecurityContextConstraints is the typed view of an OpenShift SecurityContextConstraints object. Unlike most objects the fields are not under spec
+type SecurityContextConstraints struct {
+	Name                     string   `json:"-"`
+	Priority                 *int32   `json:"priority,omitempty"`
+	AllowPrivilegedContainer bool     `json:"allowPrivilegedContainer"`
+	AllowPrivilegeEscalation *bool    `json:"allowPrivilegeEscalation,omitempty"`
+	DefaultAddCapabilities   []string `json:"defaultAddCapabilities,omitempty"`
+	RequiredDropCapabilities []string `json:"requiredDropCapabilities,omitempty"`
+	AllowedCapabilities      []string `json:"allowedCapabilities,omitempty"`
+	AllowHostDirVolumePlugin bool     `json:"allowHostDirVolumePlugin"`
+	Volumes                  []string `json:"volumes,omitempty"`
+	AllowHostNetwork         bool     `json:"allowHostNetwork"`
+	AllowHostPorts           bool     `json:"allowHostPorts"`
+	AllowHostPID             bool     `json:"allowHostPID"`
+	AllowHostIPC             bool     `json:"allowHostIPC"`
+	ReadOnlyRootFilesystem   bool     `json:"readOnlyRootFilesystem"`
+	SELinuxContext           struct {
+		Type string `json:"type,omitempty"`
+	} `json:"seLinuxContext"`
+	RunAsUser struct {
+		Type        string `json:"type,omitempty"`
+		UID         *int64 `json:"uid,omitempty"`
+		UIDRangeMin *int64 `json:"uidRangeMin,omitempty"`
+		UIDRangeMax *int64 `json:"uidRangeMax,omitempty"`
+	} `json:"runAsUser"`
+	SupplementalGroups struct {
+		Type string `json:"type,omitempty"`
+	} `json:"supplementalGroups"`
+	FSGroup struct {
+		Type string `json:"type,omitempty"`
+	} `json:"fsGroup"`
+	SeccompProfiles []string `json:"seccompProfiles,omitempty"`
+	// Users and Groups are granted the SCC directly, service accounts are users of the form system:serviceaccount:<namespace>:<name>
+	Users  []string `json:"users,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// NewSecurityContextConstraints returns the typed view of a SecurityContextConstraints object
+func NewSecurityContextConstraints(workload IMetadata) (*SecurityContextConstraints, error) {
+	if workload.GetKind() != SecurityContextConstraintsKind {
+		return nil, fmt.Errorf("kind '%s' is not %s", workload.GetKind(), SecurityContextConstraintsKind)
+	}
+	data, err := json.Marshal(workload.GetObject())
+	if err != nil {
+		return nil, err
+	}
+	scc := &SecurityContextConstraints{}
+	if err := json.Unmarshal(data, scc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s '%s', reason: %s", SecurityContextConstraintsKind, workload.GetName(), err.Error())
+	}
+	scc.Name = workload.GetName()
+	return scc, nil
+}
+
+// AllowsRunAsRoot returns true if the SCC admits containers running as UID 0
+func (scc *SecurityContextConstraints) AllowsRunAsRoot() bool {
+	switch scc.RunAsUser.Type {
+	case SecurityContextConstraintsStrategyRunAsAny:
+		return true
+	case SecurityContextConstraintsStrategyMustRunAs:
+		return scc.RunAsUser.UID != nil && *scc.RunAsUser.UID == 0
+	case SecurityContextConstraintsStrategyMustRunAsRange:
+		return scc.RunAsUser.UIDRangeMin != nil && *scc.RunAsUser.UIDRangeMin == 0
+	}
+	return false
+}
+
+// AllowsHostAccess returns true if the SCC admits pods sharing the host namespaces or mounting host paths
+func (scc *SecurityContextConstraints) AllowsHostAccess() bool {
+	return scc.AllowHostNetwork || scc.AllowHostPID || scc.AllowHostIPC || scc.AllowHostPorts || scc.AllowHostDirVolumePlugin
+}
@@ -0,0 +1,57 @@
+package workloadinterface
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSecurityContextConstraints(t *testing.T) {
+	restricted := NewWorkloadObj(map[string]interface{}{
+		"apiVersion":               "security.openshift.io/v1",
+		"kind":                     "SecurityContextConstraints",
+		"metadata":                 map[string]interface{}{"name": "restricted-v2"},
+		"priority":                 nil,
+		"allowPrivilegedContainer": false,
+		"allowPrivilegeEscalation": false,
+		"requiredDropCapabilities": []interface{}{"ALL"},
+		"allowedCapabilities":      []interface{}{"NET_BIND_SERVICE"},
+		"volumes":                  []interface{}{"configMap", "downwardAPI", "emptyDir", "persistentVolumeClaim", "projected", "secret"},
+		"runAsUser":                map[string]interface{}{"type": "MustRunAsRange"},
+		"seLinuxContext":           map[string]interface{}{"type": "MustRunAs"},
+		"fsGroup":                  map[string]interface{}{"type": "MustRunAs"},
+		"seccompProfiles":          []interface{}{"runtime/default"},
+	})
+	scc, err := NewSecurityContextConstraints(restricted)
+	require.NoError(t, err)
+	assert.Equal(t, "restricted-v2", scc.Name)
+	assert.Nil(t, scc.Priority)
+	require.NotNil(t, scc.AllowPrivilegeEscalation)
+	assert.False(t, *scc.AllowPrivilegeEscalation)
+	assert.Equal(t, []string{"ALL"}, scc.RequiredDropCapabilities)
+	assert.Equal(t, SecurityContextConstraintsStrategyMustRunAsRange, scc.RunAsUser.Type)
+	assert.Equal(t, SecurityContextConstraintsStrategyMustRunAs, scc.SELinuxContext.Type)
+	assert.False(t, scc.AllowsRunAsRoot(), "the range is allocated from the namespace")
+	assert.False(t, scc.AllowsHostAccess())
+
+	privileged := NewWorkloadObj(map[string]interface{}{
+		"apiVersion":               "security.openshift.io/v1",
+		"kind":                     "SecurityContextConstraints",
+		"metadata":                 map[string]interface{}{"name": "privileged"},
+		"allowPrivilegedContainer": true,
+		"allowHostNetwork":         true,
+		"allowHostDirVolumePlugin": true,
+		"runAsUser":                map[string]interface{}{"type": "MustRunAs", "uid": 0},
+		"users":                    []interface{}{"system:serviceaccount:openshift-infra:build-controller"},
+	})
+	scc, err = NewSecurityContextConstraints(privileged)
+	require.NoError(t, err)
+	assert.True(t, scc.AllowPrivilegedContainer)
+	assert.True(t, scc.AllowsRunAsRoot())
+	assert.True(t, scc.AllowsHostAccess())
+	assert.Equal(t, []string{"system:serviceaccount:openshift-infra:build-controller"}, scc.Users)
+
+	_, err = NewSecurityContextConstraints(NewWorkloadObj(map[string]interface{}{"kind": "Pod", "metadata": map[string]interface{}{"name": "nginx"}}))
+	assert.Error(t, err)
+}
@@ -1,10 +1,12 @@
 package workloadinterface
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/armosec/armoapi-go/apis"
 	"github.com/armosec/utils-k8s-go/armometadata"
@@ -17,9 +19,28 @@ import (
 
 const TypeWorkloadObject ObjectType = "workload"
 
+// Workload is a Kubernetes object. Its object is accessed through object() and mutated through mutable(), which parse the lazy workloads
+// and copy the shared workloads. Both are safe for concurrent use, the mutations of the object are not
 type Workload struct {
 	workload map[string]interface{}
-}
+	// lazy is true for the workloads of NewWorkloadLazy, their JSON (raw) is decoded once on their first access
+	lazy      bool
+	raw       []byte
+	parseOnce sync.Once
+	// shared is true for the workloads of NewWorkloadShared, their object is copied (copied) on the first mutation, guarded by copyMutex
+	shared    bool
+	copied    bool
+	copyMutex sync.RWMutex
+}
+
+// Memory characteristics of the constructors:
+//   - NewWorkload decodes the JSON into a new map immediately, the JSON is not retained
+//   - NewWorkloadLazy validates the JSON, retains a copy of it and decodes it on the first access to the workload: the workloads
+//     that are dropped without being accessed are never decoded. The copy is released once decoded
+//   - NewWorkloadObj wraps the map without copying it: the mutations of the workload modify the map
+//   - NewWorkloadShared wraps the map without copying it until the first mutation, which deep copies the maps and lists of the object
+//     (copy-on-write). The workloads of the objects of a shared cache, e.g. a list or an informer store, only copy the objects they mutate.
+//     GetObject and GetWorkload return the map to the caller, who may mutate it: they copy the object as a mutation does
 
 func NewWorkload(bWorkload []byte) (*Workload, error) {
 	workload := make(map[string]interface{})
@@ -42,6 +63,83 @@ func NewWorkloadObj(workload map[string]interface{}) *Workload {
 	}
 }
 
+// NewWorkloadLazy returns the workload of the JSON object, decoded on its first access. The JSON is copied, the caller may reuse it
+func NewWorkloadLazy(bWorkload []byte) (*Workload, error) {
+	trimmed := bytes.TrimSpace(bWorkload)
+	if len(trimmed) == 0 || trimmed[0] != '{' || !json.Valid(trimmed) {
+		return nil, fmt.Errorf("invalid workload - expected a JSON object")
+	}
+	return &Workload{
+		lazy: true,
+		raw:  append([]byte(nil), trimmed...),
+	}, nil
+}
+
+// NewWorkloadShared returns the workload of the map, which is not modified: the workload copies it on its first mutation
+func NewWorkloadShared(workload map[string]interface{}) *Workload {
+	return &Workload{
+		workload: workload,
+		shared:   true,
+	}
+}
+
+// object returns the object of the workload, decoding the JSON of a lazy workload
+func (w *Workload) object() map[string]interface{} {
+	if w.lazy {
+		w.parseOnce.Do(func() {
+			workload := make(map[string]interface{})
+			// the JSON was validated by NewWorkloadLazy
+			_ = json.Unmarshal(w.raw, &workload)
+			w.workload = workload
+			w.raw = nil
+		})
+	}
+	if w.shared {
+		w.copyMutex.RLock()
+		defer w.copyMutex.RUnlock()
+	}
+	return w.workload
+}
+
+// mutable returns the object of the workload to mutate, copying the object of a shared workload once
+func (w *Workload) mutable() map[string]interface{} {
+	if !w.shared {
+		return w.object()
+	}
+	w.copyMutex.Lock()
+	defer w.copyMutex.Unlock()
+	if !w.copied {
+		w.workload, _ = copyJSONValue(w.workload).(map[string]interface{})
+		w.copied = true
+	}
+	return w.workload
+}
+
+// copyJSONValue deep copies the maps and lists of the value, the other values are not copied
+func copyJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = copyJSONValue(item)
+		}
+		return copied
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		copied := make([]interface{}, len(v))
+		for i := range v {
+			copied[i] = copyJSONValue(v[i])
+		}
+		return copied
+	}
+	return value
+}
+
 func (w *Workload) GetObjectType() ObjectType {
 	return TypeWorkloadObject
 }
@@ -50,10 +148,10 @@ func (w *Workload) Json() string {
 	return w.ToString()
 }
 func (w *Workload) ToString() string {
-	if w.GetWorkload() == nil {
+	if w.object() == nil {
 		return ""
 	}
-	bWorkload, err := json.Marshal(w.GetWorkload())
+	bWorkload, err := json.Marshal(w.object())
 	if err != nil {
 		return err.Error()
 	}
@@ -61,6 +159,10 @@ func (w *Workload) ToString() string {
 }
 
 func (workload *Workload) DeepCopy(w map[string]interface{}) {
+	workload.lazy = false
+	workload.raw = nil
+	workload.shared = false
+	workload.copied = false
 	workload.workload = make(map[string]interface{})
 	byt, _ := json.Marshal(w)
 	json.Unmarshal(byt, &workload.workload)
@@ -68,10 +170,10 @@ func (workload *Workload) DeepCopy(w map[string]interface{}) {
 
 func (w *Workload) ToUnstructured() (*unstructured.Unstructured, error) {
 	obj := &unstructured.Unstructured{}
-	if w.workload == nil {
+	if w.object() == nil {
 		return obj, nil
 	}
-	bWorkload, err := json.Marshal(w.workload)
+	bWorkload, err := json.Marshal(w.object())
 	if err != nil {
 		return obj, err
 	}
@@ -97,18 +199,18 @@ func (w *Workload) RemoveJobID() {
 
 func (w *Workload) RemoveSecretData() {
 	w.RemoveAnnotation("kubectl.kubernetes.io/last-applied-configuration")
-	delete(w.workload, "data")
+	delete(w.mutable(), "data")
 }
 
 func (w *Workload) RemovePodStatus() {
-	delete(w.workload, "status")
+	delete(w.mutable(), "status")
 }
 
 func (w *Workload) RemoveResourceVersion() {
-	if _, ok := w.workload["metadata"]; !ok {
+	if _, ok := w.object()["metadata"]; !ok {
 		return
 	}
-	meta, _ := w.workload["metadata"].(map[string]interface{})
+	meta, _ := w.mutable()["metadata"].(map[string]interface{})
 	delete(meta, "resourceVersion")
 }
 
@@ -130,7 +232,7 @@ func (w *Workload) RemovePodLabel(key string) {
 
 func (w *Workload) RemoveMetadata(scope []string, metadata, key string) {
 
-	workload := w.workload
+	workload := w.mutable()
 	for i := range scope {
 		if _, ok := workload[scope[i]]; !ok {
 			return
@@ -154,15 +256,19 @@ func (w *Workload) SetWorkload(workload map[string]interface{}) {
 }
 
 func (w *Workload) SetObject(workload map[string]interface{}) {
+	w.lazy = false
+	w.raw = nil
+	w.shared = false
+	w.copied = false
 	w.workload = workload
 }
 
 func (w *Workload) SetApiVersion(apiVersion string) {
-	w.workload["apiVersion"] = apiVersion
+	w.mutable()["apiVersion"] = apiVersion
 }
 
 func (w *Workload) SetKind(kind string) {
-	w.workload["kind"] = kind
+	w.mutable()["kind"] = kind
 }
 
 func (w *Workload) SetJobID(jobTracking apis.JobTracking) {
@@ -172,25 +278,25 @@ func (w *Workload) SetJobID(jobTracking apis.JobTracking) {
 }
 
 func (w *Workload) SetNamespace(namespace string) {
-	SetInMap(w.workload, []string{"metadata"}, "namespace", namespace)
+	SetInMap(w.mutable(), []string{"metadata"}, "namespace", namespace)
 }
 
 func (w *Workload) SetName(name string) {
-	SetInMap(w.workload, []string{"metadata"}, "name", name)
+	SetInMap(w.mutable(), []string{"metadata"}, "name", name)
 }
 
 func (w *Workload) SetLabel(key, value string) {
-	SetInMap(w.workload, []string{"metadata", "labels"}, key, value)
+	SetInMap(w.mutable(), []string{"metadata", "labels"}, key, value)
 }
 
 func (w *Workload) SetPodLabel(key, value string) {
-	SetInMap(w.workload, append(PodMetadata(w.GetKind()), "labels"), key, value)
+	SetInMap(w.mutable(), append(PodMetadata(w.GetKind()), "labels"), key, value)
 }
 func (w *Workload) SetAnnotation(key, value string) {
-	SetInMap(w.workload, []string{"metadata", "annotations"}, key, value)
+	SetInMap(w.mutable(), []string{"metadata", "annotations"}, key, value)
 }
 func (w *Workload) SetPodAnnotation(key, value string) {
-	SetInMap(w.workload, append(PodMetadata(w.GetKind()), "annotations"), key, value)
+	SetInMap(w.mutable(), append(PodMetadata(w.GetKind()), "annotations"), key, value)
 }
 
 // ========================================= GET =========================================
@@ -198,10 +304,11 @@ func (w *Workload) GetWorkload() map[string]interface{} {
 	return w.GetObject()
 }
 func (w *Workload) GetObject() map[string]interface{} {
-	return w.workload
+	// the caller may mutate the map
+	return w.mutable()
 }
 func (w *Workload) GetNamespace() string {
-	if v, ok := InspectWorkload(w.workload, "metadata", "namespace"); ok {
+	if v, ok := InspectWorkload(w.object(), "metadata", "namespace"); ok {
 		return v.(string)
 	}
 	return ""
@@ -213,21 +320,21 @@ func (w *Workload) GetID() string {
 	// return fmt.Sprintf("apps/%s/%s/%s/%s", w.GetApiVersion(), w.GetNamespace(), w.GetKind(), w.GetName())
 }
 func (w *Workload) GetName() string {
-	if v, ok := InspectWorkload(w.workload, "metadata", "name"); ok {
+	if v, ok := InspectWorkload(w.object(), "metadata", "name"); ok {
 		return v.(string)
 	}
 	return ""
 }
 
 func (w *Workload) GetData() map[string]interface{} {
-	if v, ok := InspectWorkload(w.workload, "data"); ok {
+	if v, ok := InspectWorkload(w.object(), "data"); ok {
 		return v.(map[string]interface{})
 	}
 	return nil
 }
 
 func (w *Workload) GetApiVersion() string {
-	if v, ok := InspectWorkload(w.workload, "apiVersion"); ok {
+	if v, ok := InspectWorkload(w.object(), "apiVersion"); ok {
 		return v.(string)
 	}
 	return ""
@@ -254,14 +361,14 @@ func (w *Workload) GetGroup() string {
 }
 
 func (w *Workload) GetGenerateName() string {
-	if v, ok := InspectWorkload(w.workload, "metadata", "generateName"); ok {
+	if v, ok := InspectWorkload(w.object(), "metadata", "generateName"); ok {
 		return v.(string)
 	}
 	return ""
 }
 
 func (w *Workload) GetReplicas() int {
-	if v, ok := InspectWorkload(w.workload, "spec", "replicas"); ok {
+	if v, ok := InspectWorkload(w.object(), "spec", "replicas"); ok {
 		switch n := v.(type) {
 		case float64:
 			return int(n)
@@ -281,14 +388,14 @@ func (w *Workload) GetReplicas() int {
 }
 
 func (w *Workload) GetKind() string {
-	if v, ok := InspectWorkload(w.workload, "kind"); ok {
+	if v, ok := InspectWorkload(w.object(), "kind"); ok {
 		return v.(string)
 	}
 	return ""
 }
 func (w *Workload) GetSelector() (*metav1.LabelSelector, error) {
 	selector := &metav1.LabelSelector{}
-	if matchLabels, ok := InspectWorkload(w.workload, "spec", "selector", "matchLabels"); ok && matchLabels != nil {
+	if matchLabels, ok := InspectWorkload(w.object(), "spec", "selector", "matchLabels"); ok && matchLabels != nil {
 		if m, ok := matchLabels.(map[string]interface{}); ok {
 			selector.MatchLabels = make(map[string]string, len(m))
 			for k, v := range m {
@@ -296,7 +403,7 @@ func (w *Workload) GetSelector() (*metav1.LabelSelector, error) {
 			}
 		}
 	}
-	if matchExpressions, ok := InspectWorkload(w.workload, "spec", "selector", "matchExpressions"); ok && matchExpressions != nil {
+	if matchExpressions, ok := InspectWorkload(w.object(), "spec", "selector", "matchExpressions"); ok && matchExpressions != nil {
 		b, err := json.Marshal(matchExpressions)
 		if err != nil {
 			return selector, err
@@ -309,27 +416,27 @@ func (w *Workload) GetSelector() (*metav1.LabelSelector, error) {
 }
 
 func (w *Workload) GetAnnotation(annotation string) (string, bool) {
-	if v, ok := InspectWorkload(w.workload, "metadata", "annotations", annotation); ok {
+	if v, ok := InspectWorkload(w.object(), "metadata", "annotations", annotation); ok {
 		return v.(string), ok
 	}
 	return "", false
 }
 func (w *Workload) GetLabel(label string) (string, bool) {
-	if v, ok := InspectWorkload(w.workload, "metadata", "labels", label); ok {
+	if v, ok := InspectWorkload(w.object(), "metadata", "labels", label); ok {
 		return v.(string), ok
 	}
 	return "", false
 }
 
 func (w *Workload) GetPodLabel(label string) (string, bool) {
-	if v, ok := InspectWorkload(w.workload, append(PodMetadata(w.GetKind()), "labels", label)...); ok && v != nil {
+	if v, ok := InspectWorkload(w.object(), append(PodMetadata(w.GetKind()), "labels", label)...); ok && v != nil {
 		return v.(string), ok
 	}
 	return "", false
 }
 
 func (w *Workload) GetLabels() map[string]string {
-	if v, ok := InspectWorkload(w.workload, "metadata", "labels"); ok && v != nil {
+	if v, ok := InspectWorkload(w.object(), "metadata", "labels"); ok && v != nil {
 		labels := make(map[string]string)
 		for k, i := range v.(map[string]interface{}) {
 			labels[k] = i.(string)
@@ -345,7 +452,7 @@ func (w *Workload) GetInnerLabels() map[string]string {
 }
 
 func (w *Workload) GetPodLabels() map[string]string {
-	if v, ok := InspectWorkload(w.workload, append(PodMetadata(w.GetKind()), "labels")...); ok && v != nil {
+	if v, ok := InspectWorkload(w.object(), append(PodMetadata(w.GetKind()), "labels")...); ok && v != nil {
 		labels := make(map[string]string)
 		for k, i := range v.(map[string]interface{}) {
 			labels[k] = i.(string)
@@ -362,7 +469,7 @@ func (w *Workload) GetInnerAnnotations() map[string]string {
 
 // GetPodAnnotations
 func (w *Workload) GetPodAnnotations() map[string]string {
-	if v, ok := InspectWorkload(w.workload, append(PodMetadata(w.GetKind()), "annotations")...); ok && v != nil {
+	if v, ok := InspectWorkload(w.object(), append(PodMetadata(w.GetKind()), "annotations")...); ok && v != nil {
 		annotations := make(map[string]string)
 		for k, i := range v.(map[string]interface{}) {
 			annotations[k] = fmt.Sprintf("%v", i)
@@ -378,14 +485,14 @@ func (w *Workload) GetInnerAnnotation(annotation string) (string, bool) {
 }
 
 func (w *Workload) GetPodAnnotation(annotation string) (string, bool) {
-	if v, ok := InspectWorkload(w.workload, append(PodMetadata(w.GetKind()), "annotations", annotation)...); ok && v != nil {
+	if v, ok := InspectWorkload(w.object(), append(PodMetadata(w.GetKind()), "annotations", annotation)...); ok && v != nil {
 		return v.(string), ok
 	}
 	return "", false
 }
 
 func (w *Workload) GetAnnotations() map[string]string {
-	if v, ok := InspectWorkload(w.workload, "metadata", "annotations"); ok && v != nil {
+	if v, ok := InspectWorkload(w.object(), "metadata", "annotations"); ok && v != nil {
 		annotations := make(map[string]string)
 		for k, i := range v.(map[string]interface{}) {
 			annotations[k] = fmt.Sprintf("%v", i)
@@ -399,7 +506,7 @@ func (w *Workload) GetAnnotations() map[string]string {
 func (w *Workload) GetVolumes() ([]corev1.Volume, error) {
 	volumes := []corev1.Volume{}
 
-	interVolumes, _ := InspectWorkload(w.workload, append(PodSpec(w.GetKind()), "volumes")...)
+	interVolumes, _ := InspectWorkload(w.object(), append(PodSpec(w.GetKind()), "volumes")...)
 	if interVolumes == nil {
 		return volumes, nil
 	}
@@ -414,7 +521,7 @@ func (w *Workload) GetVolumes() ([]corev1.Volume, error) {
 
 func (w *Workload) GetServiceAccountName() string {
 
-	if v, ok := InspectWorkload(w.workload, append(PodSpec(w.GetKind()), "serviceAccountName")...); ok && v != nil {
+	if v, ok := InspectWorkload(w.object(), append(PodSpec(w.GetKind()), "serviceAccountName")...); ok && v != nil {
 		return v.(string)
 	}
 	return ""
@@ -422,7 +529,7 @@ func (w *Workload) GetServiceAccountName() string {
 
 func (w *Workload) GetPodSpec() (*corev1.PodSpec, error) {
 	podSpec := &corev1.PodSpec{}
-	podSepcRaw, _ := InspectWorkload(w.workload, PodSpec(w.GetKind())...)
+	podSepcRaw, _ := InspectWorkload(w.object(), PodSpec(w.GetKind())...)
 	if podSepcRaw == nil {
 		return podSpec, fmt.Errorf("no PodSpec for workload: %v", w)
 	}
@@ -438,7 +545,7 @@ func (w *Workload) GetPodSpec() (*corev1.PodSpec, error) {
 func (w *Workload) GetImagePullSecret() ([]corev1.LocalObjectReference, error) {
 	imgPullSecrets := []corev1.LocalObjectReference{}
 
-	iImgPullSecrets, _ := InspectWorkload(w.workload, append(PodSpec(w.GetKind()), "imagePullSecrets")...)
+	iImgPullSecrets, _ := InspectWorkload(w.object(), append(PodSpec(w.GetKind()), "imagePullSecrets")...)
 	b, err := json.Marshal(iImgPullSecrets)
 	if err != nil {
 		return imgPullSecrets, err
@@ -452,7 +559,7 @@ func (w *Workload) GetImagePullSecret() ([]corev1.LocalObjectReference, error) {
 func (w *Workload) GetContainers() ([]corev1.Container, error) {
 	containers := []corev1.Container{}
 
-	interContainers, _ := InspectWorkload(w.workload, append(PodSpec(w.GetKind()), "containers")...)
+	interContainers, _ := InspectWorkload(w.object(), append(PodSpec(w.GetKind()), "containers")...)
 	if interContainers == nil {
 		return containers, nil
 	}
@@ -469,7 +576,7 @@ func (w *Workload) GetContainers() ([]corev1.Container, error) {
 func (w *Workload) GetInitContainers() ([]corev1.Container, error) {
 	containers := []corev1.Container{}
 
-	interContainers, _ := InspectWorkload(w.workload, append(PodSpec(w.GetKind()), "initContainers")...)
+	interContainers, _ := InspectWorkload(w.object(), append(PodSpec(w.GetKind()), "initContainers")...)
 	if interContainers == nil {
 		return containers, nil
 	}
@@ -485,7 +592,7 @@ func (w *Workload) GetInitContainers() ([]corev1.Container, error) {
 // GetOwnerReferences -
 func (w *Workload) GetOwnerReferences() ([]metav1.OwnerReference, error) {
 	ownerReferences := []metav1.OwnerReference{}
-	interOwnerReferences, ok := InspectWorkload(w.workload, "metadata", "ownerReferences")
+	interOwnerReferences, ok := InspectWorkload(w.object(), "metadata", "ownerReferences")
 	if !ok {
 		return ownerReferences, nil
 	}
@@ -502,13 +609,13 @@ func (w *Workload) GetOwnerReferences() ([]metav1.OwnerReference, error) {
 	return ownerReferences, nil
 }
 func (w *Workload) GetResourceVersion() string {
-	if v, ok := InspectWorkload(w.workload, "metadata", "resourceVersion"); ok {
+	if v, ok := InspectWorkload(w.object(), "metadata", "resourceVersion"); ok {
 		return v.(string)
 	}
 	return ""
 }
 func (w *Workload) GetUID() string {
-	if v, ok := InspectWorkload(w.workload, "metadata", "uid"); ok {
+	if v, ok := InspectWorkload(w.object(), "metadata", "uid"); ok {
 		return v.(string)
 	}
 	return ""
@@ -674,7 +781,7 @@ func (w *Workload) GetConfigMaps() ([]string, error) {
 
 func (w *Workload) GetPodStatus() (*corev1.PodStatus, error) {
 	status := corev1.PodStatus{}
-	if v, ok := InspectWorkload(w.workload, "status"); ok && v != nil {
+	if v, ok := InspectWorkload(w.object(), "status"); ok && v != nil {
 		vBytes, err := json.Marshal(v)
 		if err != nil {
 			return nil, err
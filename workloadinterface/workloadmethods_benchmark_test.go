@@ -0,0 +1,111 @@
+package workloadinterface
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// benchmarkObjects returns the JSON of the deployments of a list
+func benchmarkObjects(b *testing.B, count int) [][]byte {
+	objects := make([][]byte, count)
+	for i := range objects {
+		workload, err := NewWorkload([]byte(mockDeployment))
+		if err != nil {
+			b.Fatal(err)
+		}
+		workload.SetName(fmt.Sprintf("deployment-%d", i))
+		objects[i] = []byte(workload.ToString())
+	}
+	return objects
+}
+
+func benchmarkMaps(b *testing.B, count int) []map[string]interface{} {
+	objects := benchmarkObjects(b, count)
+	maps := make([]map[string]interface{}, count)
+	for i := range objects {
+		if err := json.Unmarshal(objects[i], &maps[i]); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return maps
+}
+
+func BenchmarkNewWorkload(b *testing.B) {
+	objects := benchmarkObjects(b, 1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range objects {
+			workload, _ := NewWorkload(objects[j])
+			_ = workload.GetKind()
+		}
+	}
+}
+
+// the workloads are filtered by their kind, the decoding cost is the same as NewWorkload
+func BenchmarkNewWorkloadLazy(b *testing.B) {
+	objects := benchmarkObjects(b, 1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range objects {
+			workload, _ := NewWorkloadLazy(objects[j])
+			_ = workload.GetKind()
+		}
+	}
+}
+
+// the workloads are dropped without being accessed, e.g. when the objects are counted
+func BenchmarkNewWorkloadLazyNotAccessed(b *testing.B) {
+	objects := benchmarkObjects(b, 1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range objects {
+			_, _ = NewWorkloadLazy(objects[j])
+		}
+	}
+}
+
+// the deep copy of the consumers that must not mutate a shared list
+func BenchmarkDeepCopyMutated(b *testing.B) {
+	maps := benchmarkMaps(b, 1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range maps {
+			workload := &Workload{}
+			workload.DeepCopy(maps[j])
+			if j%10 == 0 {
+				workload.SetLabel("scanned", "true")
+			}
+		}
+	}
+}
+
+// the copy-on-write of the same consumers, only the mutated workloads (one out of ten) are copied
+func BenchmarkNewWorkloadSharedMutated(b *testing.B) {
+	maps := benchmarkMaps(b, 1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range maps {
+			workload := NewWorkloadShared(maps[j])
+			if j%10 == 0 {
+				workload.SetLabel("scanned", "true")
+			}
+		}
+	}
+}
+
+func BenchmarkToUnstructured(b *testing.B) {
+	maps := benchmarkMaps(b, 1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range maps {
+			_, _ = NewWorkloadObj(maps[j]).ToUnstructured()
+		}
+	}
+}
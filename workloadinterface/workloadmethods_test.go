@@ -1,7 +1,9 @@
 package workloadinterface
 
 import (
+	"reflect"
 	"sort"
+	"sync"
 	"testing"
 
 	_ "embed"
@@ -500,3 +502,90 @@ func TestGetPodStatus(t *testing.T) {
 	}
 
 }
+
+func TestNewWorkloadLazy(t *testing.T) {
+	workload, err := NewWorkloadLazy([]byte(mockDeployment))
+	assert.NoError(t, err)
+	assert.True(t, workload.lazy)
+	assert.Nil(t, workload.workload)
+
+	eager, err := NewWorkload([]byte(mockDeployment))
+	assert.NoError(t, err)
+	assert.Equal(t, eager.GetID(), workload.GetID())
+	assert.Equal(t, eager.GetObject(), workload.GetObject())
+	// the JSON is released once decoded
+	assert.Nil(t, workload.raw)
+
+	workload.SetLabel("scanned", "true")
+	label, _ := workload.GetLabel("scanned")
+	assert.Equal(t, "true", label)
+
+	for _, invalid := range []string{"", "null", "[]", `{"kind":`} {
+		_, err := NewWorkloadLazy([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+
+	// the JSON is copied, the caller may reuse it before the first access
+	bWorkload := []byte(`{"kind":"Pod","metadata":{"name":"nginx"}}`)
+	workload, err = NewWorkloadLazy(bWorkload)
+	assert.NoError(t, err)
+	copy(bWorkload, `{"kind":"Job"`)
+	assert.Equal(t, "Pod", workload.GetKind())
+}
+
+func TestNewWorkloadShared(t *testing.T) {
+	object := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]interface{}{"name": "nginx", "namespace": "default", "labels": map[string]interface{}{"app": "nginx"}},
+		"spec":       map[string]interface{}{"containers": []interface{}{map[string]interface{}{"name": "nginx", "image": "nginx"}}},
+	}
+	workload := NewWorkloadShared(object)
+	assert.Equal(t, "/v1/default/Pod/nginx", workload.GetID())
+	// the getters read the shared map, GetObject returns a copy the caller may mutate
+	obj := workload.GetObject()
+	assert.Equal(t, object, obj)
+	obj["metadata"].(map[string]interface{})["name"] = "redis"
+	assert.Equal(t, "nginx", object["metadata"].(map[string]interface{})["name"])
+	assert.Equal(t, "redis", workload.GetName())
+
+	workload.SetLabel("scanned", "true")
+	workload.RemoveLabel("app")
+	workload.SetNamespace("kube-system")
+	// the shared map is not mutated
+	assert.Equal(t, map[string]interface{}{"app": "nginx"}, object["metadata"].(map[string]interface{})["labels"])
+	assert.Equal(t, "default", object["metadata"].(map[string]interface{})["namespace"])
+	assert.Equal(t, map[string]string{"scanned": "true"}, workload.GetLabels())
+	assert.Equal(t, "kube-system", workload.GetNamespace())
+	containers, err := workload.GetContainers()
+	assert.NoError(t, err)
+	assert.Len(t, containers, 1)
+
+	// the workloads of NewWorkloadObj mutate the map
+	NewWorkloadObj(object).SetLabel("scanned", "true")
+	assert.Equal(t, "true", object["metadata"].(map[string]interface{})["labels"].(map[string]interface{})["scanned"])
+}
+
+func TestNewWorkloadSharedConcurrent(t *testing.T) {
+	object := map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": "nginx"}}
+	workload := NewWorkloadShared(object)
+
+	// the object is copied once by the concurrent calls
+	objects := make(chan map[string]interface{}, 10)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "nginx", workload.GetName())
+			objects <- workload.GetObject()
+		}()
+	}
+	wg.Wait()
+	close(objects)
+	first := <-objects
+	for obj := range objects {
+		assert.True(t, reflect.ValueOf(first).Pointer() == reflect.ValueOf(obj).Pointer())
+	}
+	assert.False(t, reflect.ValueOf(object).Pointer() == reflect.ValueOf(first).Pointer())
+}